@@ -0,0 +1,99 @@
+// Command anonymize copies a Splitwiser database with every name, email
+// address, title and note replaced by a pseudonym, keeping amounts, shares
+// and the links between bills, groups and people, so it can be attached to a
+// bug report:
+//
+//	go run ./cmd/anonymize -in data/bills.db -out bug-report.db
+//
+// The source is copied with SQLite's online backup, so the server may keep
+// running. Pseudonyms are keyed by -key, random unless given; pass the same
+// key to get the same pseudonyms from a later copy.
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+
+	"github.com/mmynk/splitwiser/internal/storage/sqlite"
+	"github.com/mmynk/splitwiser/pkg/logging"
+)
+
+func main() {
+	logging.Setup()
+
+	in := flag.String("in", "./data/bills.db", "database to copy")
+	out := flag.String("out", "", "path of the anonymized copy; must not exist")
+	keyHex := flag.String("key", "", "hex key for the pseudonyms (default: random)")
+	flag.Parse()
+
+	if *out == "" {
+		fmt.Fprintln(os.Stderr, "anonymize: -out is required")
+		os.Exit(2)
+	}
+	key, err := hex.DecodeString(*keyHex)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "anonymize: -key must be hex")
+		os.Exit(2)
+	}
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		rand.Read(key)
+	}
+
+	if err := anonymize(context.Background(), *in, *out, key); err != nil {
+		slog.Error("Anonymize failed", "error", err)
+		os.Exit(1)
+	}
+	slog.Info("Anonymized copy written", "path", *out)
+}
+
+// anonymize copies the database at in to out and anonymizes the copy,
+// removing it if anything fails.
+func anonymize(ctx context.Context, in, out string, key []byte) (err error) {
+	if _, err := os.Stat(in); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(out, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if errors.Is(err, fs.ErrExist) {
+		return fmt.Errorf("%s already exists", out)
+	}
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(out)
+		}
+	}()
+
+	src, err := sqlite.New(in)
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to open %s: %w", in, err)
+	}
+	err = src.Backup(ctx, f)
+	src.Close()
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to copy %s: %w", in, err)
+	}
+
+	dst, err := sqlite.New(out)
+	if err != nil {
+		return fmt.Errorf("failed to open copy: %w", err)
+	}
+	if err := dst.Anonymize(ctx, key); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
@@ -0,0 +1,121 @@
+// Command dbjson exports a Splitwiser database as a self-contained JSON
+// document, or imports one into an empty database, for moving between
+// deployments:
+//
+//	go run ./cmd/dbjson export -db data/bills.db -out splitwiser.json
+//	go run ./cmd/dbjson import -db new/bills.db -in splitwiser.json -dry-run
+//
+// Exports are read in one transaction, so the server may keep running. An
+// import keeps every ID and is all or nothing; -dry-run checks it, foreign
+// keys included, without keeping anything.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/mmynk/splitwiser/internal/storage/sqlite"
+	"github.com/mmynk/splitwiser/pkg/logging"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: dbjson export [-db path] [-out file]")
+	fmt.Fprintln(os.Stderr, "       dbjson import [-db path] -in file [-dry-run]")
+	os.Exit(2)
+}
+
+func main() {
+	logging.Setup()
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	flags := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+	db := flags.String("db", "./data/bills.db", "database to export from or import into")
+	var err error
+	switch os.Args[1] {
+	case "export":
+		out := flags.String("out", "", "file to write (default: stdout)")
+		flags.Parse(os.Args[2:])
+		err = export(context.Background(), *db, *out)
+	case "import":
+		in := flags.String("in", "", "export to import")
+		dryRun := flags.Bool("dry-run", false, "check the export without importing it")
+		flags.Parse(os.Args[2:])
+		if *in == "" {
+			fmt.Fprintln(os.Stderr, "dbjson: -in is required")
+			os.Exit(2)
+		}
+		err = importFile(context.Background(), *db, *in, *dryRun)
+	default:
+		usage()
+	}
+	if err != nil {
+		slog.Error(os.Args[1]+" failed", "error", err)
+		os.Exit(1)
+	}
+}
+
+// export writes the database at path to out, or stdout if out is empty.
+func export(ctx context.Context, path, out string) (err error) {
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+	store, err := sqlite.New(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer store.Close()
+
+	var w io.Writer = os.Stdout
+	if out != "" {
+		f, err := os.Create(out)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+		}()
+		w = f
+	}
+	if err := store.ExportAll(ctx, w); err != nil {
+		return err
+	}
+	if out != "" {
+		slog.Info("Database exported", "path", out)
+	}
+	return nil
+}
+
+// importFile imports the export at in into the database at path, creating
+// it if need be.
+func importFile(ctx context.Context, path, in string, dryRun bool) error {
+	f, err := os.Open(in)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	store, err := sqlite.New(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer store.Close()
+
+	result, err := store.ImportAll(ctx, f, dryRun)
+	if err != nil {
+		return err
+	}
+	if dryRun {
+		slog.Info("Export checked, nothing imported", "rows", result.Rows)
+	} else {
+		slog.Info("Database imported", "path", path, "rows", result.Rows)
+	}
+	return nil
+}
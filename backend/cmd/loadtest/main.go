@@ -0,0 +1,66 @@
+// Command loadtest drives realistic traffic against a running Splitwiser server
+// and reports per-RPC latency percentiles.
+//
+// Each simulated trip registers an organizer, creates a group of travellers,
+// adds bills while concurrent readers poll balances, then settles up:
+//
+//	go run ./cmd/loadtest -url http://localhost:8080 -trips 10 -bills 200 -readers 4
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mmynk/splitwiser/pkg/logging"
+)
+
+func main() {
+	logging.Setup()
+
+	var cfg tripConfig
+	baseURL := flag.String("url", "http://localhost:8080", "server base URL")
+	trips := flag.Int("trips", 5, "number of trips (organizers) run concurrently")
+	flag.IntVar(&cfg.members, "members", 6, "travellers per trip, including the organizer")
+	flag.IntVar(&cfg.bills, "bills", 200, "bills created per trip")
+	flag.IntVar(&cfg.readers, "readers", 4, "concurrent balance readers per trip while bills are added")
+	timeout := flag.Duration("timeout", 10*time.Minute, "overall time limit")
+	flag.Parse()
+
+	if *trips < 1 || cfg.members < 2 || cfg.bills < 1 || cfg.readers < 0 {
+		fmt.Fprintln(os.Stderr, "loadtest: -trips and -bills must be at least 1, -members at least 2")
+		os.Exit(2)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	rec := newRecorder()
+	httpClient := &http.Client{Timeout: 30 * time.Second}
+	runID := time.Now().UnixNano()
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := range *trips {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			t := newTrip(httpClient, *baseURL, rec, fmt.Sprintf("%d-%d", runID, i), cfg)
+			if err := t.run(ctx); err != nil {
+				slog.Error("Trip failed", "trip", i, "error", err)
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	rec.report(os.Stdout, elapsed)
+	if rec.failed() {
+		os.Exit(1)
+	}
+}
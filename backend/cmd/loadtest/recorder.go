@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// recorder collects per-RPC latencies and error counts across goroutines.
+type recorder struct {
+	mu        sync.Mutex
+	latencies map[string][]time.Duration
+	errors    map[string]int
+}
+
+func newRecorder() *recorder {
+	return &recorder{
+		latencies: make(map[string][]time.Duration),
+		errors:    make(map[string]int),
+	}
+}
+
+// time runs fn, recording its latency under name, and returns its error.
+func (r *recorder) time(name string, fn func() error) error {
+	start := time.Now()
+	err := fn()
+	elapsed := time.Since(start)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.latencies[name] = append(r.latencies[name], elapsed)
+	if err != nil {
+		r.errors[name]++
+	}
+	return err
+}
+
+// failed reports whether any call returned an error.
+func (r *recorder) failed() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.errors) > 0
+}
+
+// report writes a table of call counts, errors and latency percentiles per RPC.
+func (r *recorder) report(w io.Writer, elapsed time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := make([]string, 0, len(r.latencies))
+	total := 0
+	for name, samples := range r.latencies {
+		names = append(names, name)
+		total += len(samples)
+	}
+	slices.Sort(names)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "rpc\tcalls\terrors\tp50\tp90\tp99\tmax\t")
+	for _, name := range names {
+		samples := r.latencies[name]
+		slices.Sort(samples)
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\t%s\t%s\t\n", name, len(samples), r.errors[name],
+			percentile(samples, 50), percentile(samples, 90), percentile(samples, 99), samples[len(samples)-1])
+	}
+	tw.Flush()
+	fmt.Fprintf(w, "\n%d calls in %s (%.1f req/s)\n", total, elapsed.Round(time.Millisecond), float64(total)/elapsed.Seconds())
+}
+
+// percentile returns the p-th percentile of sorted samples (nearest rank).
+func percentile(sorted []time.Duration, p int) time.Duration {
+	idx := (len(sorted)*p + 99) / 100
+	return sorted[max(idx-1, 0)].Round(time.Microsecond)
+}
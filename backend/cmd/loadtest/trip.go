@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+
+	"connectrpc.com/connect"
+
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+	"github.com/mmynk/splitwiser/pkg/proto/protoconnect"
+)
+
+// tripConfig sizes each simulated trip.
+type tripConfig struct {
+	members int // travellers, including the organizer
+	bills   int // bills created over the trip
+	readers int // concurrent balance readers while bills are added
+}
+
+// trip is one organizer's run: a group of travellers sharing bills and settling up.
+type trip struct {
+	id     string
+	cfg    tripConfig
+	rec    *recorder
+	auth   protoconnect.AuthServiceClient
+	splits protoconnect.SplitServiceClient
+	groups protoconnect.GroupServiceClient
+	token  string
+	rng    *rand.Rand
+}
+
+func newTrip(httpClient *http.Client, baseURL string, rec *recorder, id string, cfg tripConfig) *trip {
+	t := &trip{id: id, cfg: cfg, rec: rec, rng: rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))}
+	opts := connect.WithInterceptors(connect.UnaryInterceptorFunc(func(next connect.UnaryFunc) connect.UnaryFunc {
+		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+			if t.token != "" {
+				req.Header().Set("Authorization", "Bearer "+t.token)
+			}
+			return next(ctx, req)
+		}
+	}))
+	t.auth = protoconnect.NewAuthServiceClient(httpClient, baseURL, opts)
+	t.splits = protoconnect.NewSplitServiceClient(httpClient, baseURL, opts)
+	t.groups = protoconnect.NewGroupServiceClient(httpClient, baseURL, opts)
+	return t
+}
+
+// run plays the whole trip: register, create the group, add bills under
+// concurrent balance reads, then record the settlements that square everyone up.
+func (t *trip) run(ctx context.Context) error {
+	organizer := "Organizer " + t.id
+	var regResp *connect.Response[pb.RegisterResponse]
+	err := t.rec.time("Register", func() (err error) {
+		regResp, err = t.auth.Register(ctx, connect.NewRequest(&pb.RegisterRequest{
+			Email:       fmt.Sprintf("loadtest-%s@example.com", t.id),
+			Password:    "loadtest-password-123",
+			DisplayName: organizer,
+		}))
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("register: %w", err)
+	}
+	t.token = regResp.Msg.Token
+
+	names := []string{organizer}
+	members := make([]*pb.GroupMember, 0, t.cfg.members-1)
+	for i := 1; i < t.cfg.members; i++ {
+		name := fmt.Sprintf("Traveller %d", i)
+		names = append(names, name)
+		members = append(members, &pb.GroupMember{DisplayName: name})
+	}
+
+	var groupResp *connect.Response[pb.CreateGroupResponse]
+	err = t.rec.time("CreateGroup", func() (err error) {
+		groupResp, err = t.groups.CreateGroup(ctx, connect.NewRequest(&pb.CreateGroupRequest{
+			Name:    "Trip " + t.id,
+			Members: members,
+		}))
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("create group: %w", err)
+	}
+	groupID := groupResp.Msg.Group.Id
+
+	readCtx, stopReaders := context.WithCancel(ctx)
+	var readers sync.WaitGroup
+	for range t.cfg.readers {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			t.readBalances(readCtx, groupID)
+		}()
+	}
+
+	for range t.cfg.bills {
+		if ctx.Err() != nil {
+			break
+		}
+		req := t.randomBill(groupID, names)
+		t.rec.time("CreateBill", func() error {
+			_, err := t.splits.CreateBill(ctx, connect.NewRequest(req))
+			return err
+		})
+	}
+	stopReaders()
+	readers.Wait()
+
+	t.rec.time("ListBillsByGroup", func() error {
+		_, err := t.splits.ListBillsByGroup(ctx, connect.NewRequest(&pb.ListBillsByGroupRequest{GroupId: groupID}))
+		return err
+	})
+	return t.settleUp(ctx, groupID)
+}
+
+// readBalances polls group and personal balances until ctx is cancelled.
+func (t *trip) readBalances(ctx context.Context, groupID string) {
+	for ctx.Err() == nil {
+		t.rec.time("GetGroupBalances", func() error {
+			_, err := t.groups.GetGroupBalances(ctx, connect.NewRequest(&pb.GetGroupBalancesRequest{GroupId: groupID}))
+			return ignoreCanceled(ctx, err)
+		})
+		t.rec.time("GetMyBalances", func() error {
+			_, err := t.groups.GetMyBalances(ctx, connect.NewRequest(&pb.GetMyBalancesRequest{}))
+			return ignoreCanceled(ctx, err)
+		})
+	}
+}
+
+// settleUp records a settlement for every debt edge in the final balances.
+func (t *trip) settleUp(ctx context.Context, groupID string) error {
+	var balances *connect.Response[pb.GetGroupBalancesResponse]
+	err := t.rec.time("GetGroupBalances", func() (err error) {
+		balances, err = t.groups.GetGroupBalances(ctx, connect.NewRequest(&pb.GetGroupBalancesRequest{GroupId: groupID}))
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("final balances: %w", err)
+	}
+	for _, debt := range balances.Msg.DebtMatrix {
+		if debt.Amount < 0.01 {
+			continue
+		}
+		t.rec.time("RecordSettlement", func() error {
+			_, err := t.groups.RecordSettlement(ctx, connect.NewRequest(&pb.RecordSettlementRequest{
+				GroupId:    groupID,
+				FromUserId: debt.FromUserId,
+				ToUserId:   debt.ToUserId,
+				Amount:     debt.Amount,
+				Note:       "loadtest settle up",
+			}))
+			return err
+		})
+	}
+	return nil
+}
+
+// randomBill builds a restaurant-style bill with a few items shared by random
+// subsets of the travellers, paid by one of them.
+func (t *trip) randomBill(groupID string, names []string) *pb.CreateBillRequest {
+	participants := make([]*pb.BillParticipant, len(names))
+	for i, name := range names {
+		participants[i] = &pb.BillParticipant{DisplayName: name}
+	}
+
+	items := make([]*pb.Item, 1+t.rng.IntN(5))
+	subtotal := 0.0
+	for i := range items {
+		amount := math.Round((5+t.rng.Float64()*45)*100) / 100
+		subtotal += amount
+		items[i] = &pb.Item{
+			Description:    fmt.Sprintf("Item %d", i+1),
+			Amount:         amount,
+			ParticipantIds: t.pick(names),
+		}
+	}
+	tax := math.Round(subtotal*0.08*100) / 100
+	tip := math.Round(subtotal*0.15*100) / 100
+	payer := names[t.rng.IntN(len(names))]
+
+	return &pb.CreateBillRequest{
+		Title:        "Trip expense",
+		Items:        items,
+		Subtotal:     subtotal,
+		Tip:          tip,
+		Total:        subtotal + tax + tip,
+		Participants: participants,
+		PayerId:      &payer,
+		GroupId:      &groupID,
+	}
+}
+
+// pick returns a random non-empty subset of names.
+func (t *trip) pick(names []string) []string {
+	var picked []string
+	for _, name := range names {
+		if t.rng.IntN(2) == 0 {
+			picked = append(picked, name)
+		}
+	}
+	if len(picked) == 0 {
+		picked = append(picked, names[t.rng.IntN(len(names))])
+	}
+	return picked
+}
+
+// ignoreCanceled drops errors caused by the readers being stopped.
+func ignoreCanceled(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,68 @@
+// Command rotatekey re-encrypts a Splitwiser database's encrypted columns
+// (user emails, settlement notes, bill comments and audit log snapshots)
+// under a new key.
+// It reads the keys from the same variables as the server:
+//
+//	ENCRYPTION_KEY=<new key> ENCRYPTION_PREVIOUS_KEYS=<old key> \
+//		go run ./cmd/rotatekey -db data/bills.db
+//
+// Values under any previous key, and values stored in plain text before
+// encryption was turned on, end up under ENCRYPTION_KEY, in one transaction.
+// Afterwards the server no longer needs ENCRYPTION_PREVIOUS_KEYS. Run it
+// with only ENCRYPTION_KEY set to encrypt an existing database for the first
+// time. Generate a key with `openssl rand -base64 32`.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+
+	"github.com/mmynk/splitwiser/internal/storage/sqlite"
+	"github.com/mmynk/splitwiser/pkg/logging"
+)
+
+func main() {
+	logging.Setup()
+
+	db := flag.String("db", "./data/bills.db", "database to re-encrypt")
+	flag.Parse()
+
+	keys, err := sqlite.ParseEncryptionKeys(os.Getenv("ENCRYPTION_KEY"))
+	if err != nil || len(keys) != 1 {
+		fmt.Fprintln(os.Stderr, "rotatekey: ENCRYPTION_KEY must hold one base64 key of 32 bytes")
+		os.Exit(2)
+	}
+	previous, err := sqlite.ParseEncryptionKeys(os.Getenv("ENCRYPTION_PREVIOUS_KEYS"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "rotatekey: ENCRYPTION_PREVIOUS_KEYS: %v\n", err)
+		os.Exit(2)
+	}
+	if _, err := os.Stat(*db); err != nil {
+		fmt.Fprintf(os.Stderr, "rotatekey: %v\n", err)
+		os.Exit(1)
+	}
+
+	store, err := sqlite.New(*db, sqlite.WithEncryption(keys[0], previous...))
+	if err != nil {
+		slog.Error("Failed to open database", "path", *db, "error", err)
+		os.Exit(1)
+	}
+	defer store.Close()
+
+	result, err := store.RotateEncryptionKey(context.Background())
+	if err != nil {
+		slog.Error("Key rotation failed; nothing was changed", "error", err)
+		store.Close()
+		os.Exit(1)
+	}
+	args := []any{"path", *db}
+	for _, column := range slices.Sorted(maps.Keys(result.Rows)) {
+		args = append(args, column, result.Rows[column])
+	}
+	slog.Info("Encrypted columns rotated to the current key", args...)
+}
@@ -0,0 +1,52 @@
+// Command seed fills a Splitwiser database with demo data for frontend
+// development and demos: four users who are friends, a trip and a shared
+// flat with bills spread over the last few weeks, a bill between two of them
+// outside any group, and a couple of settlements:
+//
+//	go run ./cmd/seed -db data/bills.db
+//
+// Every user signs in with their email (alice@example.com, bob@example.com,
+// carol@example.com, dave@example.com) and -password. Seeding a database
+// that already has the demo users does nothing, so it is safe to re-run.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/mmynk/splitwiser/internal/storage/sqlite"
+	"github.com/mmynk/splitwiser/pkg/logging"
+)
+
+func main() {
+	logging.Setup()
+
+	db := flag.String("db", "./data/bills.db", "database to seed; created if missing")
+	password := flag.String("password", "password123", "password of every demo user")
+	flag.Parse()
+
+	store, err := sqlite.New(*db)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "seed: failed to open %s: %v\n", *db, err)
+		os.Exit(1)
+	}
+	defer store.Close()
+
+	result, err := seed(context.Background(), store, *password, time.Now())
+	if errors.Is(err, errSeeded) {
+		slog.Info("Database already has the demo data", "path", *db)
+		return
+	}
+	if err != nil {
+		slog.Error("Seed failed", "error", err)
+		store.Close()
+		os.Exit(1)
+	}
+	slog.Info("Demo data seeded", "path", *db,
+		"users", result.users, "groups", result.groups, "bills", result.bills, "settlements", result.settlements)
+}
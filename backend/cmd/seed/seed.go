@@ -0,0 +1,320 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mmynk/splitwiser/internal/auth"
+	"github.com/mmynk/splitwiser/internal/models"
+	"github.com/mmynk/splitwiser/internal/storage/sqlite"
+)
+
+// errSeeded is returned by seed when the demo users already exist.
+var errSeeded = errors.New("database already seeded")
+
+// seedResult counts what seed created.
+type seedResult struct {
+	users, groups, bills, settlements int
+}
+
+// demoUsers are the accounts seed creates, by display name.
+var demoUsers = []struct{ name, email string }{
+	{"Alice", "alice@example.com"},
+	{"Bob", "bob@example.com"},
+	{"Carol", "carol@example.com"},
+	{"Dave", "dave@example.com"},
+}
+
+// seeder creates the demo data, dating it relative to now.
+type seeder struct {
+	store  *sqlite.SQLiteStore
+	now    time.Time
+	users  map[string]*models.User // by display name
+	result seedResult
+}
+
+// seed creates the demo users, friendships, groups, bills and settlements
+// in store, each signing in with password. It returns errSeeded, having
+// changed nothing, if the first demo user already exists.
+func seed(ctx context.Context, store *sqlite.SQLiteStore, password string, now time.Time) (*seedResult, error) {
+	existing, err := store.GetUserByEmail(ctx, demoUsers[0].email)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, errSeeded
+	}
+
+	s := &seeder{store: store, now: now, users: make(map[string]*models.User)}
+	authenticator := auth.NewPasswordAuthenticator(store)
+	for _, u := range demoUsers {
+		user, err := authenticator.Register(ctx, u.email, u.name, password)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s: %w", u.email, err)
+		}
+		s.users[u.name] = user
+		s.result.users++
+	}
+
+	steps := []func(context.Context) error{s.friendships, s.trip, s.flat, s.direct}
+	for _, step := range steps {
+		if err := step(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return &s.result, nil
+}
+
+// daysAgo returns the Unix time days before now.
+func (s *seeder) daysAgo(days int) int64 {
+	return s.now.AddDate(0, 0, -days).Unix()
+}
+
+// member returns a group member for a demo user, or a guest for any other name.
+func (s *seeder) member(name string) models.GroupMember {
+	m := models.GroupMember{DisplayName: name}
+	if user, ok := s.users[name]; ok {
+		m.UserID = user.ID
+	}
+	return m
+}
+
+// participants returns bill participants for names, linked like member.
+func (s *seeder) participants(names ...string) []models.BillParticipant {
+	out := make([]models.BillParticipant, len(names))
+	for i, name := range names {
+		m := s.member(name)
+		out[i] = models.BillParticipant{DisplayName: m.DisplayName, UserID: m.UserID}
+	}
+	return out
+}
+
+// friendships makes Alice friends with everyone and Bob with Dave, and
+// leaves a request from Carol to Dave pending.
+func (s *seeder) friendships(ctx context.Context) error {
+	pairs := []struct {
+		from, to string
+		status   models.FriendshipStatus
+	}{
+		{"Alice", "Bob", models.FriendshipAccepted},
+		{"Alice", "Carol", models.FriendshipAccepted},
+		{"Alice", "Dave", models.FriendshipAccepted},
+		{"Bob", "Dave", models.FriendshipAccepted},
+		{"Carol", "Dave", models.FriendshipPending},
+	}
+	for _, p := range pairs {
+		err := s.store.SendFriendRequest(ctx, &models.Friendship{
+			RequesterID: s.users[p.from].ID,
+			AddresseeID: s.users[p.to].ID,
+			Status:      p.status,
+			CreatedAt:   s.daysAgo(60),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to befriend %s and %s: %w", p.from, p.to, err)
+		}
+	}
+	return nil
+}
+
+// group creates a group of members, created by the first, days ago, whose
+// bills round like the seeded ones.
+func (s *seeder) group(ctx context.Context, name string, days int, members ...string) (*models.Group, error) {
+	group := &models.Group{
+		Name:      name,
+		CreatorID: s.users[members[0]].ID,
+		CreatedAt: s.daysAgo(days),
+		Settings:  models.GroupSettings{RoundingMode: models.RoundHalfUp},
+	}
+	for _, m := range members {
+		group.Members = append(group.Members, s.member(m))
+	}
+	if err := s.store.CreateGroup(ctx, group); err != nil {
+		return nil, fmt.Errorf("failed to create group %q: %w", name, err)
+	}
+	s.result.groups++
+	return group, nil
+}
+
+// bill creates bill, defaulting it to an equal split, rounded so shares add
+// up to the total, whose creator is its payer.
+func (s *seeder) bill(ctx context.Context, bill *models.Bill) error {
+	if bill.SplitType == "" {
+		bill.SplitType = models.SplitEqual
+	}
+	if bill.RoundingMode == "" {
+		bill.RoundingMode = models.RoundHalfUp
+	}
+	if bill.TipSplitMode == "" {
+		bill.TipSplitMode = models.TipSplitProportional
+	}
+	if bill.CreatorID == "" {
+		bill.CreatorID = s.users[bill.PayerID].ID
+	}
+	if err := s.store.CreateBill(ctx, bill); err != nil {
+		return fmt.Errorf("failed to create bill %q: %w", bill.Title, err)
+	}
+	s.result.bills++
+	return nil
+}
+
+// settle records a payment from one demo user to another.
+func (s *seeder) settle(ctx context.Context, group *models.Group, from, to string, amount float64, days int, note string) error {
+	settlement := &models.Settlement{
+		FromUserID: s.users[from].ID,
+		ToUserID:   s.users[to].ID,
+		Amount:     amount,
+		CreatedAt:  s.daysAgo(days),
+		CreatedBy:  s.users[from].ID,
+		Note:       note,
+	}
+	if group != nil {
+		settlement.GroupID = &group.ID
+	}
+	if err := s.store.CreateSettlement(ctx, settlement); err != nil {
+		return fmt.Errorf("failed to record settlement %q: %w", note, err)
+	}
+	s.result.settlements++
+	return nil
+}
+
+// trip seeds a week away in euros: itemized dinners with tax and tip, a
+// shared apartment, weighted shares and a guest who isn't signed up.
+func (s *seeder) trip(ctx context.Context) error {
+	group, err := s.group(ctx, "Lisbon trip", 21, "Alice", "Bob", "Carol", "Eve")
+	if err != nil {
+		return err
+	}
+	everyone := []string{"Alice", "Bob", "Carol", "Eve"}
+	bills := []*models.Bill{
+		{
+			Title:      "Apartment",
+			Total:      480,
+			Subtotal:   480,
+			Currency:   "EUR",
+			PayerID:    "Bob",
+			CategoryID: "travel",
+			CreatedAt:  s.daysAgo(14),
+		},
+		{
+			Title: "Seafood dinner",
+			Items: []models.Item{
+				{Description: "Cataplana", Amount: 48, Participants: everyone},
+				{Description: "Grilled octopus", Amount: 22, Participants: []string{"Bob", "Carol"}},
+				{Description: "Vinho verde", Amount: 30, Quantity: 2, UnitPrice: 15, Participants: []string{"Alice", "Bob", "Carol"},
+					Units: map[string]float64{"Alice": 0.5, "Bob": 1, "Carol": 0.5}},
+			},
+			Total:      123,
+			Subtotal:   100,
+			Tip:        15,
+			Currency:   "EUR",
+			PayerID:    "Alice",
+			CategoryID: "food",
+			CreatedAt:  s.daysAgo(12),
+		},
+		{
+			Title:      "Tuk-tuk tour",
+			Total:      90,
+			Subtotal:   90,
+			SplitType:  models.SplitShares,
+			Currency:   "EUR",
+			PayerID:    "Carol",
+			CategoryID: "entertainment",
+			CreatedAt:  s.daysAgo(11),
+		},
+		{
+			Title:      "Airport taxi",
+			Total:      38,
+			Subtotal:   38,
+			Currency:   "EUR",
+			PayerID:    "Alice",
+			CategoryID: "transport",
+			CreatedAt:  s.daysAgo(8),
+		},
+	}
+	for _, bill := range bills {
+		bill.GroupID = group.ID
+		bill.Participants = s.participants(everyone...)
+		if bill.SplitType == models.SplitShares {
+			// Eve brought a friend along.
+			bill.Participants[3].Shares = 2
+		}
+		if err := s.bill(ctx, bill); err != nil {
+			return err
+		}
+	}
+	return s.settle(ctx, group, "Bob", "Alice", 40, 6, "Dinner")
+}
+
+// flat seeds a shared flat in dollars: rent, utilities and a grocery run
+// with household and personal items, most of it recent.
+func (s *seeder) flat(ctx context.Context) error {
+	group, err := s.group(ctx, "Flat 4B", 45, "Dave", "Alice", "Bob")
+	if err != nil {
+		return err
+	}
+	bills := []*models.Bill{
+		{
+			Title:      "Rent",
+			Total:      2400,
+			Subtotal:   2400,
+			PayerID:    "Dave",
+			CategoryID: "rent",
+			CreatedAt:  s.daysAgo(30),
+		},
+		{
+			Title:      "Electricity",
+			Total:      96.3,
+			Subtotal:   96.3,
+			PayerID:    "Bob",
+			CategoryID: "utilities",
+			CreatedAt:  s.daysAgo(20),
+		},
+		{
+			Title:      "Internet",
+			Total:      60,
+			Subtotal:   60,
+			PayerID:    "Alice",
+			CategoryID: "utilities",
+			CreatedAt:  s.daysAgo(18),
+		},
+		{
+			Title: "Groceries",
+			Items: []models.Item{
+				{Description: "Milk & eggs", Amount: 12.5, Category: models.ItemHousehold},
+				{Description: "Dish soap", Amount: 4.5, Category: models.ItemHousehold},
+				{Description: "Coffee beans", Amount: 18, Participants: []string{"Alice", "Dave"}},
+				{Description: "Shampoo", Amount: 7.5, Category: models.ItemPersonal, Owner: "Bob"},
+			},
+			Total:      45.32,
+			Subtotal:   42.5,
+			PayerID:    "Alice",
+			CategoryID: "groceries",
+			CreatedAt:  s.daysAgo(3),
+		},
+	}
+	for _, bill := range bills {
+		bill.GroupID = group.ID
+		bill.Currency = "USD"
+		bill.Participants = s.participants("Dave", "Alice", "Bob")
+		if err := s.bill(ctx, bill); err != nil {
+			return err
+		}
+	}
+	return s.settle(ctx, group, "Alice", "Dave", 800, 25, "Rent")
+}
+
+// direct seeds a bill between Alice and Dave outside any group.
+func (s *seeder) direct(ctx context.Context) error {
+	return s.bill(ctx, &models.Bill{
+		Title:        "Concert tickets",
+		Total:        150,
+		Subtotal:     150,
+		Currency:     "USD",
+		Participants: s.participants("Alice", "Dave"),
+		PayerID:      "Dave",
+		CategoryID:   "entertainment",
+		CreatedAt:    s.daysAgo(5),
+	})
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+
+	"github.com/mmynk/splitwiser/internal/authctx"
+	"github.com/mmynk/splitwiser/internal/service"
+	"github.com/mmynk/splitwiser/internal/storage/sqlite"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+)
+
+// TestSeed seeds a fresh database and checks every bill splits and every
+// group balances, as the frontend would load them, and that seeding again
+// changes nothing.
+func TestSeed(t *testing.T) {
+	store, err := sqlite.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+
+	result, err := seed(ctx, store, "password123", time.Now())
+	if err != nil {
+		t.Fatalf("seed failed: %v", err)
+	}
+	if _, err := seed(ctx, store, "password123", time.Now()); !errors.Is(err, errSeeded) {
+		t.Fatalf("seeding again: expected errSeeded, got %v", err)
+	}
+
+	alice, err := store.GetUserByEmail(ctx, "alice@example.com")
+	if err != nil || alice == nil {
+		t.Fatalf("GetUserByEmail failed: %v", err)
+	}
+	ctx = authctx.WithUser(ctx, authctx.Principal{ID: alice.ID})
+	splits := service.NewSplitService(store)
+	groups := service.NewGroupService(store)
+
+	bills, err := splits.ListBills(ctx, connect.NewRequest(&pb.ListBillsRequest{}))
+	if err != nil {
+		t.Fatalf("ListBills failed: %v", err)
+	}
+	if len(bills.Msg.Bills) != result.bills {
+		t.Errorf("expected Alice to see all %d bills, got %d", result.bills, len(bills.Msg.Bills))
+	}
+	for _, summary := range bills.Msg.Bills {
+		bill, err := splits.GetBill(ctx, connect.NewRequest(&pb.GetBillRequest{BillId: summary.BillId}))
+		if err != nil {
+			t.Errorf("GetBill %q failed: %v", summary.Title, err)
+			continue
+		}
+		sum := 0.0
+		for _, split := range bill.Msg.Split.GetSplits() {
+			sum += split.Total
+		}
+		if diff := sum - bill.Msg.Total; diff > 0.01 || diff < -0.01 {
+			t.Errorf("%q: splits add up to %.2f, want %.2f", summary.Title, sum, bill.Msg.Total)
+		}
+	}
+
+	list, err := groups.ListGroups(ctx, connect.NewRequest(&pb.ListGroupsRequest{}))
+	if err != nil {
+		t.Fatalf("ListGroups failed: %v", err)
+	}
+	if len(list.Msg.Groups) != result.groups {
+		t.Errorf("expected %d groups, got %d", result.groups, len(list.Msg.Groups))
+	}
+	for _, group := range list.Msg.Groups {
+		balances, err := groups.GetGroupBalances(ctx, connect.NewRequest(&pb.GetGroupBalancesRequest{GroupId: group.Id}))
+		if err != nil {
+			t.Fatalf("GetGroupBalances %q failed: %v", group.Name, err)
+		}
+		if len(balances.Msg.SkippedBills) != 0 {
+			t.Errorf("%q: bills left out of balances: %v", group.Name, balances.Msg.SkippedBills)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/mmynk/splitwiser/internal/storage/sqlite"
+)
+
+var billsArchivedTotal = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "splitwiser_bills_archived_total",
+	Help: "Bills archived by the retention job for their age.",
+})
+
+// archivalConfig controls the background job archiving old bills.
+type archivalConfig struct {
+	interval time.Duration // time between runs; zero disables the job
+	after    time.Duration // bills older than this are archived; zero disables the job
+}
+
+// runBillArchival archives bills older than cfg.after every cfg.interval
+// until ctx is done, starting with a run right away.
+func runBillArchival(ctx context.Context, store *sqlite.SQLiteStore, cfg archivalConfig) {
+	if cfg.interval <= 0 || cfg.after <= 0 {
+		return
+	}
+	archiveBillsOnce(ctx, store, cfg)
+	ticker := time.NewTicker(cfg.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			archiveBillsOnce(ctx, store, cfg)
+		}
+	}
+}
+
+// archiveBillsOnce archives the bills older than cfg.after and records how
+// many it archived.
+func archiveBillsOnce(ctx context.Context, store *sqlite.SQLiteStore, cfg archivalConfig) {
+	archived, err := store.ArchiveBills(ctx, time.Now().Add(-cfg.after).Unix())
+	if err != nil {
+		slog.Error("Bill archival failed", "error", err)
+		return
+	}
+	billsArchivedTotal.Add(float64(archived))
+	slog.Info("Bill archival complete", "archived", archived)
+}
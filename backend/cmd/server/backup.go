@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/mmynk/splitwiser/internal/storage/sqlite"
+)
+
+// backupPrefix and backupTimeLayout name scheduled backup files, which sort
+// oldest first by name.
+const (
+	backupPrefix     = "splitwiser-"
+	backupTimeLayout = "20060102T150405Z"
+)
+
+var backupRunsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "splitwiser_db_backup_runs_total",
+	Help: "Scheduled database backups by result (ok or error).",
+}, []string{"result"})
+
+// backupConfig controls the scheduled backup job.
+type backupConfig struct {
+	dir      string        // where backups are written; empty disables the job
+	interval time.Duration // time between backups
+	keep     int           // newest backups to keep; 0 keeps all
+}
+
+// runBackups writes a backup to cfg.dir every cfg.interval until ctx is done.
+func runBackups(ctx context.Context, store *sqlite.SQLiteStore, cfg backupConfig) {
+	if cfg.dir == "" || cfg.interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(cfg.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := backupOnce(ctx, store, cfg, time.Now()); err != nil {
+				backupRunsTotal.WithLabelValues("error").Inc()
+				slog.Error("Scheduled backup failed", "dir", cfg.dir, "error", err)
+				continue
+			}
+			backupRunsTotal.WithLabelValues("ok").Inc()
+		}
+	}
+}
+
+// backupOnce writes one backup named for now to cfg.dir, then removes the
+// oldest backups beyond cfg.keep. The file only appears once it's complete.
+func backupOnce(ctx context.Context, store *sqlite.SQLiteStore, cfg backupConfig, now time.Time) error {
+	if err := os.MkdirAll(cfg.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	name := filepath.Join(cfg.dir, backupPrefix+now.UTC().Format(backupTimeLayout)+".db")
+	f, err := os.Create(name + ".tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create backup file: %w", err)
+	}
+	err = store.Backup(ctx, f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), name)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	slog.Info("Database backed up", "file", name)
+
+	if cfg.keep <= 0 {
+		return nil
+	}
+	entries, err := os.ReadDir(cfg.dir)
+	if err != nil {
+		return fmt.Errorf("failed to list backups: %w", err)
+	}
+	var backups []string
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), backupPrefix) && strings.HasSuffix(e.Name(), ".db") {
+			backups = append(backups, e.Name())
+		}
+	}
+	slices.Sort(backups)
+	for _, old := range backups[:max(len(backups)-cfg.keep, 0)] {
+		if err := os.Remove(filepath.Join(cfg.dir, old)); err != nil {
+			return fmt.Errorf("failed to remove old backup: %w", err)
+		}
+	}
+	return nil
+}
+
+// backupHandler serves an online backup of the database on GET and restores
+// one from the request body on POST, for callers with the admin token.
+func backupHandler(store *sqlite.SQLiteStore, adminToken string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if adminToken == "" || subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+adminToken)) != 1 {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			// Backup stages the snapshot before writing, so failures come before the body starts.
+			name := backupPrefix + time.Now().UTC().Format(backupTimeLayout) + ".db"
+			w.Header().Set("Content-Type", "application/vnd.sqlite3")
+			w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+			if err := store.Backup(r.Context(), w); err != nil {
+				slog.Error("Backup failed", "error", err)
+				http.Error(w, "backup failed", http.StatusInternalServerError)
+				return
+			}
+			slog.Info("Database backup downloaded", "file", name)
+		case http.MethodPost:
+			if err := store.Restore(r.Context(), r.Body); err != nil {
+				slog.Error("Restore failed", "error", err)
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			slog.Warn("Database restored from backup")
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/mmynk/splitwiser/internal/blob"
+	"github.com/mmynk/splitwiser/internal/storage"
+)
+
+// defaultMaxAttachmentBytes is the largest attachment accepted unless
+// ATTACHMENT_MAX_BYTES says otherwise: enough for a full-resolution phone photo.
+const defaultMaxAttachmentBytes = 10 << 20
+
+// blobURLPath is where the disk driver serves its signed URLs.
+const blobURLPath = "/blobs/"
+
+// attachmentBlobPrefix starts the keys of attachment blobs.
+const attachmentBlobPrefix = "attachments/"
+
+var blobOrphansDeletedTotal = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "splitwiser_blob_orphans_deleted_total",
+	Help: "Blobs deleted by cleanup because nothing referenced them.",
+})
+
+// openBlobStore returns the blob store BLOB_DRIVER selects: files under
+// BLOB_DIR, an S3-compatible bucket or a Google Cloud Storage bucket. The
+// disk driver's signed URLs are keyed from jwtSecret.
+func openBlobStore(jwtSecret string) (blob.Store, error) {
+	urlKey := hmac.New(sha256.New, []byte(jwtSecret))
+	urlKey.Write([]byte("blob urls"))
+
+	cfg := blob.Config{
+		Driver:    getEnv("BLOB_DRIVER", blob.DriverDisk),
+		Dir:       getEnv("BLOB_DIR", "./data/blobs"),
+		URLPrefix: strings.TrimSuffix(getEnv("PUBLIC_URL", ""), "/") + blobURLPath,
+		URLKey:    urlKey.Sum(nil),
+		S3: blob.S3Config{
+			Endpoint:        getEnv("S3_ENDPOINT", ""),
+			Region:          getEnv("S3_REGION", "us-east-1"),
+			Bucket:          getEnv("S3_BUCKET", ""),
+			AccessKeyID:     getEnv("S3_ACCESS_KEY_ID", ""),
+			SecretAccessKey: getEnv("S3_SECRET_ACCESS_KEY", ""),
+			PathStyle:       getEnv("S3_PATH_STYLE", "") == "true",
+		},
+		GCS: blob.GCSConfig{
+			Bucket:   getEnv("GCS_BUCKET", ""),
+			AccessID: getEnv("GCS_HMAC_ACCESS_ID", ""),
+			Secret:   getEnv("GCS_HMAC_SECRET", ""),
+		},
+	}
+	store, err := blob.Open(cfg)
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("Blob storage initialized", "driver", cfg.Driver)
+	return store, nil
+}
+
+// blobCleanupConfig controls the background job deleting orphaned blobs.
+type blobCleanupConfig struct {
+	interval time.Duration // time between runs; zero disables the job
+	grace    time.Duration // blobs younger than this are kept, as their uploads may still be recording them
+}
+
+// runBlobCleanup deletes orphaned attachment blobs every cfg.interval until
+// ctx is done.
+func runBlobCleanup(ctx context.Context, blobs blob.Store, store storage.Store, cfg blobCleanupConfig) {
+	if cfg.interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(cfg.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			cleanupBlobsOnce(ctx, blobs, store, cfg)
+		}
+	}
+}
+
+// cleanupBlobsOnce deletes the attachment blobs no attachment refers to,
+// such as those of purged bills, and records how many it deleted.
+func cleanupBlobsOnce(ctx context.Context, blobs blob.Store, store storage.Store, cfg blobCleanupConfig) {
+	inUse := func(ctx context.Context) (map[string]bool, error) {
+		keys, err := store.ListAttachmentBlobKeys(ctx)
+		if err != nil {
+			return nil, err
+		}
+		set := make(map[string]bool, len(keys))
+		for _, key := range keys {
+			set[key] = true
+		}
+		return set, nil
+	}
+	deleted, err := blob.Sweep(ctx, blobs, attachmentBlobPrefix, cfg.grace, inUse)
+	blobOrphansDeletedTotal.Add(float64(deleted))
+	if err != nil {
+		slog.Error("Blob cleanup failed", "deleted", deleted, "error", err)
+		return
+	}
+	slog.Info("Blob cleanup complete", "deleted", deleted)
+}
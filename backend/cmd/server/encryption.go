@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/mmynk/splitwiser/internal/storage/sqlite"
+)
+
+// loadEncryption returns the store option encrypting sensitive columns with
+// ENCRYPTION_KEY, still reading values under ENCRYPTION_PREVIOUS_KEYS. With
+// no key the option leaves them in plain text.
+func loadEncryption() (sqlite.Option, error) {
+	keys, err := sqlite.ParseEncryptionKeys(getEnv("ENCRYPTION_KEY", ""))
+	if err != nil {
+		return nil, fmt.Errorf("ENCRYPTION_KEY: %w", err)
+	}
+	previous, err := sqlite.ParseEncryptionKeys(getEnv("ENCRYPTION_PREVIOUS_KEYS", ""))
+	if err != nil {
+		return nil, fmt.Errorf("ENCRYPTION_PREVIOUS_KEYS: %w", err)
+	}
+	switch {
+	case len(keys) > 1:
+		return nil, fmt.Errorf("ENCRYPTION_KEY holds %d keys; list old ones in ENCRYPTION_PREVIOUS_KEYS", len(keys))
+	case len(keys) == 0 && len(previous) > 0:
+		return nil, fmt.Errorf("ENCRYPTION_PREVIOUS_KEYS is set without ENCRYPTION_KEY")
+	case len(keys) == 0:
+		return sqlite.WithEncryption(nil), nil
+	}
+	slog.Info("Encryption at rest enabled", "previous_keys", len(previous))
+	return sqlite.WithEncryption(keys[0], previous...), nil
+}
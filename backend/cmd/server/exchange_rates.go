@@ -0,0 +1,71 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/mmynk/splitwiser/internal/fxrate"
+	"github.com/mmynk/splitwiser/internal/service"
+	"github.com/mmynk/splitwiser/internal/storage"
+	"github.com/mmynk/splitwiser/internal/storage/sqlite"
+)
+
+// loadExchangeRates returns the service option looking up the rates bills in
+// a foreign currency are locked at from EXCHANGE_RATES. Unset, bills in
+// another currency than their group's must come with a rate.
+func loadExchangeRates() (service.Option, error) {
+	spec := getEnv("EXCHANGE_RATES", "")
+	if spec == "" {
+		return service.WithExchangeRates(nil), nil
+	}
+	table, err := fxrate.ParseTable(spec)
+	if err != nil {
+		return nil, fmt.Errorf("EXCHANGE_RATES: %w", err)
+	}
+	slog.Info("Exchange rates loaded", "currencies", len(table))
+	return service.WithExchangeRates(table), nil
+}
+
+// rerateHandler replaces the exchange rate locked with a bill on POST
+// ?bill_id=<id>&rate=<rate>, for callers with the admin token, when the rate
+// the bill was created at was wrong.
+func rerateHandler(store *sqlite.SQLiteStore, adminToken string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if adminToken == "" || subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+adminToken)) != 1 {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		billID := r.URL.Query().Get("bill_id")
+		rate, err := strconv.ParseFloat(r.URL.Query().Get("rate"), 64)
+		if billID == "" || err != nil || rate <= 0 {
+			http.Error(w, "bill_id and a positive rate are required", http.StatusBadRequest)
+			return
+		}
+		bill, err := store.GetBill(r.Context(), billID)
+		if err == nil {
+			err = store.SetBillExchangeRate(r.Context(), billID, rate)
+		}
+		if err != nil {
+			slog.Error("Re-rate failed", "bill_id", billID, "error", err)
+			status := http.StatusInternalServerError
+			if errors.Is(err, storage.ErrNotFound) {
+				status = http.StatusNotFound
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+		slog.Warn("Bill exchange rate replaced", "bill_id", billID, "currency", bill.Currency, "old_rate", bill.ExchangeRate, "new_rate", rate)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"bill_id": billID, "old_rate": bill.ExchangeRate, "exchange_rate": rate})
+	})
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mmynk/splitwiser/internal/storage"
+	"github.com/mmynk/splitwiser/internal/storage/sqlite"
+)
+
+// exportHandler serves the whole database as JSON on GET and imports such an
+// export into an empty database on POST, for callers with the admin token.
+// POST with ?dry_run=true checks the export without keeping anything.
+func exportHandler(store *sqlite.SQLiteStore, adminToken string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if adminToken == "" || subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+adminToken)) != 1 {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			name := "splitwiser-" + time.Now().UTC().Format(backupTimeLayout) + ".json"
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+			if err := store.ExportAll(r.Context(), w); err != nil {
+				slog.Error("Export failed", "error", err)
+				http.Error(w, "export failed", http.StatusInternalServerError)
+				return
+			}
+			slog.Info("Database exported", "file", name)
+		case http.MethodPost:
+			dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+			result, err := store.ImportAll(r.Context(), r.Body, dryRun)
+			if err != nil {
+				slog.Error("Import failed", "dry_run", dryRun, "error", err)
+				status := http.StatusBadRequest
+				if errors.Is(err, storage.ErrConflict) {
+					status = http.StatusConflict
+				}
+				http.Error(w, err.Error(), status)
+				return
+			}
+			if !dryRun {
+				slog.Warn("Database imported from export", "rows", result.Rows)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(result)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
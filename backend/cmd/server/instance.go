@@ -0,0 +1,46 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+
+	"github.com/mmynk/splitwiser/internal/service"
+)
+
+// loadInstanceConfig reads the instance's branding, defaults and
+// REGISTRATION_MODE, exiting if any is malformed. Features are left for the
+// caller, which knows what it enabled.
+func loadInstanceConfig() service.InstanceConfig {
+	cfg := service.InstanceConfig{
+		Name:            getEnv("INSTANCE_NAME", "Splitwiser"),
+		LogoURL:         getEnv("INSTANCE_LOGO_URL", ""),
+		DefaultCurrency: getEnv("DEFAULT_CURRENCY", "USD"),
+		DefaultLocale:   getEnv("DEFAULT_LOCALE", "en-US"),
+	}
+	if !isCurrencyCode(cfg.DefaultCurrency) {
+		slog.Error("Invalid DEFAULT_CURRENCY; want a three-letter ISO 4217 code", "value", cfg.DefaultCurrency)
+		os.Exit(1)
+	}
+	switch mode := getEnv("REGISTRATION_MODE", "open"); mode {
+	case "open":
+	case "invite_only":
+		cfg.InviteOnly = true
+	default:
+		slog.Error("Invalid REGISTRATION_MODE; want open or invite_only", "value", mode)
+		os.Exit(1)
+	}
+	return cfg
+}
+
+// isCurrencyCode reports whether s is three upper-case letters.
+func isCurrencyCode(s string) bool {
+	if len(s) != 3 {
+		return false
+	}
+	for _, r := range s {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+)
+
+// listenFDsStart is the first file descriptor passed by systemd socket activation.
+const listenFDsStart = 3
+
+// listenConfig describes where the server accepts connections.
+type listenConfig struct {
+	addr       string      // TCP address; unused when systemd passes sockets
+	unixSocket string      // optional unix socket path, served alongside TCP
+	socketMode fs.FileMode // permissions applied to the unix socket file
+}
+
+// listen opens every configured listener. Sockets passed by systemd (LISTEN_FDS)
+// replace the TCP listener; a unix socket is added when configured.
+func listen(cfg listenConfig) ([]net.Listener, error) {
+	listeners, err := activationListeners()
+	if err != nil {
+		return nil, err
+	}
+	if len(listeners) == 0 {
+		l, err := net.Listen("tcp", cfg.addr)
+		if err != nil {
+			return nil, err
+		}
+		listeners = append(listeners, l)
+	}
+
+	if cfg.unixSocket != "" {
+		l, err := listenUnix(cfg.unixSocket, cfg.socketMode)
+		if err != nil {
+			closeAll(listeners)
+			return nil, err
+		}
+		listeners = append(listeners, l)
+	}
+	return listeners, nil
+}
+
+// activationListeners returns the sockets passed by systemd socket activation,
+// or none if the process wasn't socket-activated.
+func activationListeners() ([]net.Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n <= 0 {
+		return nil, nil
+	}
+	// Don't pass the sockets on to child processes.
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	listeners := make([]net.Listener, 0, n)
+	for fd := listenFDsStart; fd < listenFDsStart+n; fd++ {
+		f := os.NewFile(uintptr(fd), fmt.Sprintf("listen-fd-%d", fd))
+		l, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			closeAll(listeners)
+			return nil, fmt.Errorf("socket activation fd %d: %w", fd, err)
+		}
+		listeners = append(listeners, l)
+	}
+	return listeners, nil
+}
+
+// listenUnix listens on a unix socket at path, replacing a stale socket left by
+// a previous run, and applies mode so a reverse proxy can connect.
+func listenUnix(path string, mode fs.FileMode) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&fs.ModeSocket == 0 {
+			return nil, fmt.Errorf("unix socket path %s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale unix socket: %w", err)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to stat unix socket path: %w", err)
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		l.Close()
+		return nil, fmt.Errorf("failed to set unix socket permissions: %w", err)
+	}
+	slog.Info("Listening on unix socket", "path", path, "mode", mode)
+	return l, nil
+}
+
+// closeAll closes every listener, ignoring errors.
+func closeAll(listeners []net.Listener) {
+	for _, l := range listeners {
+		l.Close()
+	}
+}
+
+// getEnvFileMode reads an octal file mode environment variable (e.g. "0660"), exiting if it's malformed.
+func getEnvFileMode(key string, fallback fs.FileMode) fs.FileMode {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	mode, err := strconv.ParseUint(value, 8, 32)
+	if err != nil || mode > 0o777 {
+		slog.Error("Invalid file mode value", "key", key, "value", value)
+		os.Exit(1)
+	}
+	return fs.FileMode(mode)
+}
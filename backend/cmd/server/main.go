@@ -1,6 +1,9 @@
 package main
 
 import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
 	"crypto/tls"
 	"fmt"
 	"log/slog"
@@ -11,22 +14,27 @@ import (
 	"strings"
 	"time"
 
-	"connectrpc.com/connect"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"golang.org/x/net/http2"
 	"golang.org/x/net/http2/h2c"
 
 	"github.com/mmynk/splitwiser/internal/auth"
+	"github.com/mmynk/splitwiser/internal/bankfeed"
+	"github.com/mmynk/splitwiser/internal/blob"
 	"github.com/mmynk/splitwiser/internal/middleware"
+	"github.com/mmynk/splitwiser/internal/notify"
+	"github.com/mmynk/splitwiser/internal/provision"
+	"github.com/mmynk/splitwiser/internal/quota"
+	"github.com/mmynk/splitwiser/internal/replica"
 	"github.com/mmynk/splitwiser/internal/service"
+	"github.com/mmynk/splitwiser/internal/storage"
+	"github.com/mmynk/splitwiser/internal/storage/audit"
+	"github.com/mmynk/splitwiser/internal/storage/metrics"
+	"github.com/mmynk/splitwiser/internal/storage/readonly"
 	"github.com/mmynk/splitwiser/internal/storage/sqlite"
+	"github.com/mmynk/splitwiser/internal/webhook"
 	"github.com/mmynk/splitwiser/pkg/logging"
-	"github.com/mmynk/splitwiser/pkg/proto/protoconnect"
-)
-
-const (
-	jwtTokenDuration = 24 * time.Hour // Tokens valid for 24 hours
 )
 
 func getEnv(key, fallback string) string {
@@ -36,10 +44,51 @@ func getEnv(key, fallback string) string {
 	return fallback
 }
 
+// getEnvDuration reads a duration environment variable (e.g. "500ms"), exiting if it's malformed.
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		slog.Error("Invalid duration value", "key", key, "value", value)
+		os.Exit(1)
+	}
+	return d
+}
+
+// getEnvInt reads an integer environment variable, exiting if it's malformed.
+func getEnvInt(key string, fallback int64) int64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 0 {
+		slog.Error("Invalid integer value", "key", key, "value", value)
+		os.Exit(1)
+	}
+	return n
+}
+
+// getEnvFloat reads a non-negative number environment variable, exiting if it's malformed.
+func getEnvFloat(key string, fallback float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f < 0 {
+		slog.Error("Invalid number value", "key", key, "value", value)
+		os.Exit(1)
+	}
+	return f
+}
+
 func main() {
 	// Setup colored structured logging (level from LOG_LEVEL env, default INFO)
 	logging.Setup()
-	logger := slog.Default()
 
 	// Read configuration from environment
 	isProd := getEnv("APP_ENV", "development") == "production"
@@ -67,8 +116,58 @@ func main() {
 	dbPath := getEnv("DB_PATH", "./data/bills.db")
 	staticPath := getEnv("STATIC_PATH", "../frontend/static")
 
+	// Branding, defaults and registration mode, served by GetInstanceConfig
+	instance := loadInstanceConfig()
+	instance.Features = []string{service.FeatureAttachments, service.FeatureWebhooks, service.FeaturePublicStats, service.FeatureShareLinks}
+
+	// Maintenance mode: reads keep working while every write is refused with
+	// Unavailable and background jobs that write are paused
+	readOnly := getEnv("READ_ONLY", "") == "true"
+	if readOnly {
+		instance.Features = append(instance.Features, service.FeatureReadOnly)
+		slog.Warn("Server is read-only; writes will be refused")
+	}
+
+	// Dependencies on slow mounts may need a few tries before they're available.
+	retry := startupRetry{
+		attempts: int(getEnvInt("STARTUP_RETRIES", 0)) + 1,
+		backoff:  getEnvDuration("STARTUP_RETRY_BACKOFF", time.Second),
+	}
+
+	// Continuous WAL replication to blob storage (REPLICA_DRIVER unset disables);
+	// a missing database is restored from the replica first
+	replicaCfg := loadReplicaConfig()
+	if replicaCfg.enabled() {
+		replicaCfg.replica.Store, err = openReplicaStore(replicaCfg)
+		if err == nil {
+			err = retry.do("replica restore", func() error {
+				return restoreIfMissing(context.Background(), replicaCfg.replica.Store, replicaCfg, dbPath)
+			})
+		}
+		if err != nil {
+			slog.Error("Failed to restore database from replica", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	// Encryption at rest for emails and notes (ENCRYPTION_KEY unset disables)
+	encryption, err := loadEncryption()
+	if err != nil {
+		slog.Error("Invalid encryption configuration", "error", err)
+		os.Exit(1)
+	}
+
 	// Initialize SQLite storage
-	store, err := sqlite.New(dbPath)
+	store, err := openStore(retry, dbPath,
+		encryption,
+		sqlite.WithJournalMode(getEnv("DB_JOURNAL_MODE", "")),
+		sqlite.WithBusyTimeout(getEnvDuration("DB_BUSY_TIMEOUT", sqlite.DefaultBusyTimeout)),
+		sqlite.WithBusyRetries(int(getEnvInt("DB_BUSY_RETRIES", sqlite.DefaultBusyRetries))),
+		sqlite.WithMaxOpenConns(int(getEnvInt("DB_MAX_OPEN_CONNS", 0))),
+		sqlite.WithMaxIdleConns(int(getEnvInt("DB_MAX_IDLE_CONNS", 0))),
+		sqlite.WithConnMaxLifetime(getEnvDuration("DB_CONN_MAX_LIFETIME", 0)),
+		sqlite.WithPlusTagFolding(getEnv("EMAIL_FOLD_PLUS_TAGS", "") == "true"),
+	)
 	if err != nil {
 		slog.Error("Failed to initialize storage", "error", err)
 		os.Exit(1)
@@ -79,15 +178,137 @@ func main() {
 	// Register custom Prometheus collector for DB-level gauges
 	prometheus.MustRegister(newCollector(store))
 
-	// Initialize authentication components
-	jwtManager := auth.NewJWTManager(jwtSecret, jwtTokenDuration)
-	passwordAuth := auth.NewPasswordAuthenticator(store)
+	var replicator *replica.Replicator
+	if replicaCfg.enabled() {
+		replicator, err = replica.New(dbPath, replicaCfg.replica)
+		if err != nil {
+			slog.Error("Failed to start replication", "error", err)
+			os.Exit(1)
+		}
+		go replicator.Run(context.Background())
+		slog.Info("Replication started", "driver", replicaCfg.blob.Driver, "prefix", replicaCfg.replica.Prefix)
+	}
+
+	// Background ANALYZE and WAL checkpoint, plus VACUUM once enough of the file is free pages (0 interval disables)
+	if !readOnly {
+		go runMaintenance(context.Background(), store, maintenanceConfig{
+			interval:        getEnvDuration("DB_MAINTENANCE_INTERVAL", 6*time.Hour),
+			vacuumFreeRatio: getEnvFloat("DB_VACUUM_FREE_RATIO", 0.25),
+			skipCheckpoint:  replicator != nil,
+		})
+		go runBillArchival(context.Background(), store, archivalConfig{
+			interval: getEnvDuration("BILL_ARCHIVE_INTERVAL", 24*time.Hour),
+			after:    getEnvDuration("BILL_ARCHIVE_AFTER", 0),
+		})
+	}
+
+	// Scheduled online backups to BACKUP_DIR, keeping the newest BACKUP_KEEP (unset dir disables)
+	go runBackups(context.Background(), store, backupConfig{
+		dir:      getEnv("BACKUP_DIR", ""),
+		interval: getEnvDuration("BACKUP_INTERVAL", 24*time.Hour),
+		keep:     int(getEnvInt("BACKUP_KEEP", 7)),
+	})
+
+	// Latency, errors and rows returned of storage calls, by Store method, are exported as metrics
+	instrumented := metrics.New(store)
+
+	// Writes stop here when the server is read-only, before they're counted or audited
+	var writable storage.Store = instrumented
+	var accounts readonly.AccountStore = store
+	if readOnly {
+		writable = readonly.New(instrumented)
+		accounts = readonly.NewAccounts(store)
+	}
+
+	// Every create/update/delete made on behalf of a request or integration is recorded in the audit log
+	audited := audit.New(writable)
+
+	// Per-account quotas for hosted deployments (0 = unlimited)
+	quotas := quota.NewEnforcer(instrumented, quota.Limits{
+		MaxGroups:          int(getEnvInt("QUOTA_MAX_GROUPS", 0)),
+		MaxBillsPerMonth:   int(getEnvInt("QUOTA_MAX_BILLS_PER_MONTH", 0)),
+		MaxAttachmentBytes: getEnvInt("QUOTA_MAX_ATTACHMENT_BYTES", 0),
+	})
+
+	// Group webhooks (e.g. balance threshold crossings) and account webhooks are delivered in the background
+	webhooks := webhook.NewDispatcher(getEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second))
+
+	// Bill attachments (receipt photos and PDFs) live in blob storage outside the database
+	blobs, err := openBlobStore(jwtSecret)
+	if err != nil {
+		slog.Error("Failed to initialize blob storage", "error", err)
+		os.Exit(1)
+	}
+	// Bill share links are signed with a key derived from jwtSecret, like blob URLs
+	shareKey := hmac.New(sha256.New, []byte(jwtSecret))
+	shareKey.Write([]byte("bill share links"))
+	shareLinks := service.WithShareLinks(shareKey.Sum(nil))
 
-	// Create auth middleware
-	authMiddleware := middleware.RequireAuth(jwtManager)
+	attachments := service.WithAttachments(blobs, getEnvInt("ATTACHMENT_MAX_BYTES", defaultMaxAttachmentBytes))
 
-	// Create logging interceptor (runs before auth to capture all errors)
-	loggingInterceptor := middleware.LoggingInterceptor()
+	// Rates bills in another currency than their group's are locked at (EXCHANGE_RATES unset requires one per bill)
+	exchangeRates, err := loadExchangeRates()
+	if err != nil {
+		slog.Error("Invalid exchange rates", "error", err)
+		os.Exit(1)
+	}
+
+	// Receipt photos are read into draft bills by ParseReceipt (OCR_DRIVER unset turns it off)
+	receiptOCR, err := openReceiptOCR()
+	if err != nil {
+		slog.Error("Failed to initialize receipt OCR", "error", err)
+		os.Exit(1)
+	}
+	if receiptOCR != nil {
+		instance.Features = append(instance.Features, service.FeatureReceiptOCR)
+	}
+
+	// Blobs nothing refers to any more, such as receipts of purged bills, are deleted in the background
+	if !readOnly {
+		go runBlobCleanup(context.Background(), blobs, store, blobCleanupConfig{
+			interval: getEnvDuration("BLOB_CLEANUP_INTERVAL", 24*time.Hour),
+			grace:    getEnvDuration("BLOB_ORPHAN_GRACE", time.Hour),
+		})
+	}
+
+	// Notices to users, such as spending cap alerts to a group's creator
+	notifier := notify.LogNotifier{}
+
+	// Deductible bill categories are totaled by tax line in ExportTaxReport (TAX_LINES unset turns it off)
+	taxLines, err := loadTaxLines(context.Background(), store)
+	if err != nil {
+		slog.Error("Invalid tax lines", "error", err)
+		os.Exit(1)
+	}
+	if len(taxLines) > 0 {
+		instance.Features = append(instance.Features, service.FeatureTaxReports)
+	}
+
+	// Initialize authentication components
+	// Sessions last SESSION_IDLE_TIMEOUT without requests; active clients get a
+	// renewed token (X-Session-Token) once theirs is SESSION_RENEW_AFTER old,
+	// until SESSION_MAX_AGE after login (0 for no limit).
+	jwtManager := auth.NewJWTManager(jwtSecret, auth.SessionPolicy{
+		IdleTimeout: getEnvDuration("SESSION_IDLE_TIMEOUT", 24*time.Hour),
+		RenewAfter:  getEnvDuration("SESSION_RENEW_AFTER", time.Hour),
+		MaxAge:      getEnvDuration("SESSION_MAX_AGE", 0),
+	})
+	// Sessions of an account merged into another continue as that account
+	jwtManager.FollowMerges(store)
+	passwordAuth := auth.NewPasswordAuthenticator(accounts)
+	emailManager := auth.NewEmailManager(accounts, auth.LogMailer{})
+	inviter := auth.NewInviter(accounts, auth.LogMailer{})
+
+	// Interceptor chain: recovery → request-id → logging → slow-request → metrics → rate-limit → auth.
+	// Logging runs before auth so rejected requests are still logged.
+	interceptors := middleware.NewChain()
+	if threshold := getEnvDuration("SLOW_REQUEST_THRESHOLD", 0); threshold > 0 {
+		interceptors = interceptors.With(middleware.StageSlowRequest, middleware.SlowRequestInterceptor(threshold))
+	}
+	if rps := getEnvInt("RATE_LIMIT_RPS", 0); rps > 0 {
+		limiter := middleware.NewRateLimiter(float64(rps), int(getEnvInt("RATE_LIMIT_BURST", rps*2)))
+		interceptors = interceptors.With(middleware.StageRateLimit, limiter.Interceptor())
+	}
 
 	mux := http.NewServeMux()
 
@@ -97,38 +318,61 @@ func main() {
 		w.Write([]byte("ok"))
 	})
 
+	// Readiness: the database answers and, with replication on, the replica is current
+	mux.Handle("/readyz", readyHandler(store, replicator, replicaCfg.maxDelay))
+
+	// Signed blob download links, when blobs are kept on local disk
+	if disk, ok := blobs.(*blob.Disk); ok {
+		mux.Handle(blobURLPath, http.StripPrefix(blobURLPath, disk))
+	}
+
 	// Prometheus metrics endpoint — restricted to Fly.io private network in production
 	// Set METRICS_TOKEN secret for admin access via: Authorization: Bearer <token>
 	metricsToken := getEnv("METRICS_TOKEN", "")
 	mux.Handle("/metrics", flyNetworkOnly(metricsToken, promhttp.Handler()))
 
-	// Register AuthService with optional auth so GetCurrentUser can read the JWT,
-	// while Register/Login/Logout remain accessible without a token.
-	optionalAuth := middleware.OptionalAuth(jwtManager)
-	authPath, authHandler := protoconnect.NewAuthServiceHandler(
-		service.NewAuthService(passwordAuth, jwtManager, logger),
-		connect.WithInterceptors(loggingInterceptor, optionalAuth),
-	)
-	mux.Handle(authPath, authHandler)
+	// Admin and integration endpoints write to the database directly, so only reads of them are let through while read-only.
+	handleWrites := mux.Handle
+	if readOnly {
+		handleWrites = func(pattern string, h http.Handler) { mux.Handle(pattern, readOnlyHandler(h)) }
+	}
 
-	// Register protected services with logging + auth middleware
-	splitPath, splitHandler := protoconnect.NewSplitServiceHandler(
-		service.NewSplitService(store),
-		connect.WithInterceptors(loggingInterceptor, authMiddleware),
-	)
-	mux.Handle(splitPath, splitHandler)
+	// Bulk user provisioning (CSV or SCIM-lite JSON) — only enabled when ADMIN_TOKEN is set.
+	// Use: curl -H "Authorization: Bearer $ADMIN_TOKEN" -H "Content-Type: text/csv" --data-binary @users.csv
+	if adminToken := getEnv("ADMIN_TOKEN", ""); adminToken != "" {
+		handleWrites("/admin/provision", provision.Handler(provision.New(audited, inviter), adminToken))
+		// Online backup (GET, responds with an SQLite file) and restore (POST the file) — same token.
+		handleWrites("/admin/backup", backupHandler(store, adminToken))
+		// Whole-database JSON export (GET) and import into an empty database (POST, ?dry_run=true to check only).
+		handleWrites("/admin/export", exportHandler(store, adminToken))
+		// Replace a bill's locked exchange rate (POST ?bill_id=<id>&rate=<rate>) when it was wrong.
+		handleWrites("/admin/rerate", rerateHandler(store, adminToken))
+		// Accounts flagged as duplicates (GET) and merging one into another (POST ?primary=<id>&duplicate=<id>, ?dry_run=true to report only).
+		handleWrites("/admin/merge", mergeHandler(store, adminToken))
+	}
 
-	groupPath, groupHandler := protoconnect.NewGroupServiceHandler(
-		service.NewGroupService(store),
-		connect.WithInterceptors(loggingInterceptor, authMiddleware),
-	)
-	mux.Handle(groupPath, groupHandler)
+	// Bank/card transaction webhooks become draft bills — only enabled when TRANSACTION_FEED_TOKEN is set.
+	// Use: POST /integrations/transactions?group_id=<id> with "Authorization: Bearer $TRANSACTION_FEED_TOKEN"
+	if feedToken := getEnv("TRANSACTION_FEED_TOKEN", ""); feedToken != "" {
+		handleWrites("/integrations/transactions", bankfeed.Handler(bankfeed.New(audited), feedToken))
+		instance.Features = append(instance.Features, service.FeatureTransactionFeed)
+	}
 
-	friendPath, friendHandler := protoconnect.NewFriendServiceHandler(
-		service.NewFriendService(store),
-		connect.WithInterceptors(loggingInterceptor, authMiddleware),
-	)
-	mux.Handle(friendPath, friendHandler)
+	// RPCs; every procedure requires auth unless listed in publicProcedures
+	var authOpts []service.AuthOption
+	if instance.InviteOnly {
+		authOpts = append(authOpts, service.WithInviteOnly())
+	}
+	registerRPCs(mux, interceptors, jwtManager, rpcServices{
+		auth:   service.NewAuthService(passwordAuth, jwtManager, emailManager, inviter, logging.Component("auth"), authOpts...),
+		split:  service.NewSplitService(audited, service.WithQuotas(quotas), service.WithWebhooks(webhooks), service.WithNotifier(notifier), attachments, exchangeRates, service.WithReceiptOCR(receiptOCR), shareLinks, service.WithTaxLines(taxLines)),
+		group:  service.NewGroupService(audited, service.WithQuotas(quotas), service.WithWebhooks(webhooks)),
+		friend: service.NewFriendService(audited),
+		// Opt-in group stats are cached for PUBLIC_STATS_CACHE_TTL
+		publicStats: service.NewPublicStatsService(writable, getEnvDuration("PUBLIC_STATS_CACHE_TTL", 5*time.Minute)),
+		instance:    service.NewInstanceService(instance),
+		sharedBill:  service.NewSharedBillService(writable, shareLinks),
+	})
 
 	// Serve static files from frontend/static
 	staticDir, err := filepath.Abs(staticPath)
@@ -138,6 +382,12 @@ func main() {
 	}
 	slog.Info("Serving static files", "path", staticDir)
 
+	// Verify remaining dependencies before binding the listener
+	if err := checkDependencies(retry, staticDir, tlsCertFile, tlsKeyFile); err != nil {
+		slog.Error("Startup dependency check failed", "error", err)
+		os.Exit(1)
+	}
+
 	// Handle all non-API routes with static file server
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		// Check if this is an API request (Connect RPC)
@@ -170,32 +420,46 @@ func main() {
 
 	addr := fmt.Sprintf(":%d", port)
 
-	// TLS mode: both cert and key must be set (or neither)
-	if tlsCertFile != "" && tlsKeyFile != "" {
+	server := &http.Server{Handler: handler}
+	useTLS := tlsCertFile != "" && tlsKeyFile != ""
+	if useTLS {
 		// TLS negotiates HTTP/2 natively via ALPN — no h2c wrapper needed
-		server := &http.Server{
-			Addr:    addr,
-			Handler: handler,
-			TLSConfig: &tls.Config{
-				MinVersion: tls.VersionTLS12,
-			},
-		}
-		slog.Info("Connect server starting with TLS", "address", addr, "url", fmt.Sprintf("https://localhost%s", addr))
-		if err := server.ListenAndServeTLS(tlsCertFile, tlsKeyFile); err != nil {
-			slog.Error("Server failed", "error", err)
-			os.Exit(1)
+		server.TLSConfig = &tls.Config{
+			MinVersion: tls.VersionTLS12,
 		}
 	} else if tlsCertFile != "" || tlsKeyFile != "" {
 		slog.Error("Both TLS_CERT_FILE and TLS_KEY_FILE must be set (or neither)")
 		os.Exit(1)
 	} else {
 		// No TLS — use h2c for HTTP/2 without TLS (local dev)
-		h2cHandler := h2c.NewHandler(handler, &http2.Server{})
-		slog.Info("Connect server starting", "address", addr, "url", fmt.Sprintf("http://localhost%s", addr))
-		if err := http.ListenAndServe(addr, h2cHandler); err != nil {
-			slog.Error("Server failed", "error", err)
-			os.Exit(1)
-		}
+		server.Handler = h2c.NewHandler(handler, &http2.Server{})
+	}
+
+	// TCP on PORT unless systemd passed sockets (LISTEN_FDS), plus an optional unix socket.
+	listeners, err := listen(listenConfig{
+		addr:       addr,
+		unixSocket: getEnv("UNIX_SOCKET", ""),
+		socketMode: getEnvFileMode("UNIX_SOCKET_MODE", 0o660),
+	})
+	if err != nil {
+		slog.Error("Failed to listen", "error", err)
+		os.Exit(1)
+	}
+
+	errs := make(chan error, len(listeners))
+	for _, l := range listeners {
+		slog.Info("Connect server starting", "address", l.Addr().String(), "network", l.Addr().Network(), "tls", useTLS)
+		go func() {
+			if useTLS {
+				errs <- server.ServeTLS(l, tlsCertFile, tlsKeyFile)
+			} else {
+				errs <- server.Serve(l)
+			}
+		}()
+	}
+	if err := <-errs; err != nil {
+		slog.Error("Server failed", "error", err)
+		os.Exit(1)
 	}
 }
 
@@ -204,8 +468,8 @@ func corsMiddleware(next http.Handler, allowOrigin string) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", allowOrigin)
 		w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Connect-Protocol-Version, Connect-Timeout-Ms, Authorization")
-		w.Header().Set("Access-Control-Expose-Headers", "Connect-Protocol-Version, Connect-Timeout-Ms")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Connect-Protocol-Version, Connect-Timeout-Ms, Authorization, X-Request-Id")
+		w.Header().Set("Access-Control-Expose-Headers", "Connect-Protocol-Version, Connect-Timeout-Ms, X-Request-Id, "+middleware.SessionTokenHeader)
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/mmynk/splitwiser/internal/storage/sqlite"
+)
+
+var (
+	maintenanceRunsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "splitwiser_db_maintenance_runs_total",
+		Help: "Database maintenance runs by result (ok or error).",
+	}, []string{"result"})
+
+	maintenanceDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "splitwiser_db_maintenance_duration_seconds",
+		Help:    "Duration of successful database maintenance runs, by whether they vacuumed.",
+		Buckets: prometheus.ExponentialBuckets(0.01, 4, 8), // 10ms to ~160s
+	}, []string{"vacuumed"})
+
+	maintenanceReclaimedBytes = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "splitwiser_db_maintenance_reclaimed_bytes_total",
+		Help: "Bytes the database file shrank by across maintenance runs.",
+	})
+)
+
+// maintenanceConfig controls the background database maintenance job.
+type maintenanceConfig struct {
+	interval        time.Duration // time between runs; zero disables the job
+	vacuumFreeRatio float64       // vacuum once this fraction of the file is free pages
+	skipCheckpoint  bool          // replication checkpoints the WAL instead
+}
+
+// runMaintenance runs database maintenance every cfg.interval until ctx is done.
+func runMaintenance(ctx context.Context, store *sqlite.SQLiteStore, cfg maintenanceConfig) {
+	if cfg.interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(cfg.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			maintainOnce(ctx, store, cfg)
+		}
+	}
+}
+
+// maintainOnce runs one maintenance pass and records its metrics.
+func maintainOnce(ctx context.Context, store *sqlite.SQLiteStore, cfg maintenanceConfig) {
+	result, err := store.Maintain(ctx, sqlite.MaintenanceOptions{
+		VacuumFreeRatio: cfg.vacuumFreeRatio,
+		SkipCheckpoint:  cfg.skipCheckpoint,
+	})
+	if err != nil {
+		maintenanceRunsTotal.WithLabelValues("error").Inc()
+		slog.Error("Database maintenance failed", "error", err)
+		return
+	}
+	maintenanceRunsTotal.WithLabelValues("ok").Inc()
+	maintenanceDuration.WithLabelValues(strconv.FormatBool(result.Vacuumed)).Observe(result.Duration.Seconds())
+	if reclaimed := result.Before.Bytes - result.After.Bytes; reclaimed > 0 {
+		maintenanceReclaimedBytes.Add(float64(reclaimed))
+	}
+	slog.Info("Database maintenance complete",
+		"vacuumed", result.Vacuumed,
+		"size_before", result.Before.Bytes,
+		"size_after", result.After.Bytes,
+		"free_before", result.Before.FreeBytes,
+		"duration", result.Duration,
+	)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/mmynk/splitwiser/internal/storage"
+	"github.com/mmynk/splitwiser/internal/storage/sqlite"
+)
+
+// mergeHandler lists the accounts flagged as duplicates on GET and merges one
+// account into another on POST ?primary=<id>&duplicate=<id>, for callers
+// with the admin token. POST with ?dry_run=true reports what the merge would
+// move without making it.
+func mergeHandler(store *sqlite.SQLiteStore, adminToken string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if adminToken == "" || subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+adminToken)) != 1 {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			duplicates, err := store.ListDuplicateAccounts(r.Context())
+			if err != nil {
+				slog.Error("Listing duplicate accounts failed", "error", err)
+				http.Error(w, "listing duplicate accounts failed", http.StatusInternalServerError)
+				return
+			}
+			if duplicates == nil {
+				duplicates = []sqlite.DuplicateAccount{}
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]any{"duplicates": duplicates})
+		case http.MethodPost:
+			primary, duplicate := r.URL.Query().Get("primary"), r.URL.Query().Get("duplicate")
+			if primary == "" || duplicate == "" || primary == duplicate {
+				http.Error(w, "primary and a different duplicate are required", http.StatusBadRequest)
+				return
+			}
+			dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+			result, err := store.MergeUsers(r.Context(), primary, duplicate, dryRun)
+			if err != nil {
+				slog.Error("Account merge failed", "primary_id", primary, "duplicate_id", duplicate, "dry_run", dryRun, "error", err)
+				status := http.StatusInternalServerError
+				if errors.Is(err, storage.ErrNotFound) {
+					status = http.StatusNotFound
+				}
+				http.Error(w, err.Error(), status)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(result)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
@@ -40,18 +40,22 @@ func flyNetworkOnly(adminToken string, next http.Handler) http.Handler {
 // splitwiserCollector implements prometheus.Collector to expose DB-level gauges.
 // Queries run on each scrape, so counts are always current without background goroutines.
 type splitwiserCollector struct {
-	store  *sqlite.SQLiteStore
-	users  *prometheus.Desc
-	bills  *prometheus.Desc
-	groups *prometheus.Desc
+	store       *sqlite.SQLiteStore
+	users       *prometheus.Desc
+	bills       *prometheus.Desc
+	groups      *prometheus.Desc
+	dbSize      *prometheus.Desc
+	dbFreeBytes *prometheus.Desc
 }
 
 func newCollector(store *sqlite.SQLiteStore) *splitwiserCollector {
 	return &splitwiserCollector{
-		store:  store,
-		users:  prometheus.NewDesc("splitwiser_users_total", "Total registered users.", nil, nil),
-		bills:  prometheus.NewDesc("splitwiser_bills_total", "Total bills in the database.", nil, nil),
-		groups: prometheus.NewDesc("splitwiser_groups_total", "Total groups in the database.", nil, nil),
+		store:       store,
+		users:       prometheus.NewDesc("splitwiser_users_total", "Total registered users.", nil, nil),
+		bills:       prometheus.NewDesc("splitwiser_bills_total", "Total bills in the database.", nil, nil),
+		groups:      prometheus.NewDesc("splitwiser_groups_total", "Total groups in the database.", nil, nil),
+		dbSize:      prometheus.NewDesc("splitwiser_db_size_bytes", "Size of the database file.", nil, nil),
+		dbFreeBytes: prometheus.NewDesc("splitwiser_db_free_bytes", "Free pages in the database file, reclaimable by VACUUM.", nil, nil),
 	}
 }
 
@@ -59,6 +63,8 @@ func (c *splitwiserCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.users
 	ch <- c.bills
 	ch <- c.groups
+	ch <- c.dbSize
+	ch <- c.dbFreeBytes
 }
 
 func (c *splitwiserCollector) Collect(ch chan<- prometheus.Metric) {
@@ -70,4 +76,12 @@ func (c *splitwiserCollector) Collect(ch chan<- prometheus.Metric) {
 	ch <- prometheus.MustNewConstMetric(c.users, prometheus.GaugeValue, float64(stats.Users))
 	ch <- prometheus.MustNewConstMetric(c.bills, prometheus.GaugeValue, float64(stats.Bills))
 	ch <- prometheus.MustNewConstMetric(c.groups, prometheus.GaugeValue, float64(stats.Groups))
+
+	size, err := c.store.Size(context.Background())
+	if err != nil {
+		slog.Warn("metrics: failed to get database size", "error", err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.dbSize, prometheus.GaugeValue, float64(size.Bytes))
+	ch <- prometheus.MustNewConstMetric(c.dbFreeBytes, prometheus.GaugeValue, float64(size.FreeBytes))
 }
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"log/slog"
+
+	"github.com/mmynk/splitwiser/internal/ocr"
+)
+
+// openReceiptOCR returns the OCR provider OCR_DRIVER selects for reading
+// receipt photos: tesseract on this machine or the Google Cloud Vision API.
+// Unset, receipt scanning is off and it returns nil.
+func openReceiptOCR() (ocr.Provider, error) {
+	cfg := ocr.Config{
+		Driver: getEnv("OCR_DRIVER", ""),
+		Tesseract: ocr.TesseractConfig{
+			Path:      getEnv("TESSERACT_PATH", ""),
+			Languages: getEnv("TESSERACT_LANGUAGES", ""),
+		},
+		Vision: ocr.VisionConfig{
+			APIKey: getEnv("VISION_API_KEY", ""),
+		},
+	}
+	if cfg.Driver == "" {
+		return nil, nil
+	}
+	provider, err := ocr.Open(cfg)
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("Receipt OCR initialized", "driver", cfg.Driver)
+	return provider, nil
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"net/http"
+)
+
+// readOnlyHandler refuses requests that could write, anything but GET and
+// HEAD, with 503 while the server is read-only, and passes the rest to next.
+func readOnlyHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "server is read-only", http.StatusServiceUnavailable)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
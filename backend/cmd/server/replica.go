@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/mmynk/splitwiser/internal/blob"
+	"github.com/mmynk/splitwiser/internal/replica"
+	"github.com/mmynk/splitwiser/internal/storage/sqlite"
+)
+
+// minReplicaLag is the least a replica may fall behind before /readyz
+// reports the server unready, however short REPLICA_INTERVAL is.
+const minReplicaLag = time.Minute
+
+// replicaConfig configures WAL replication of the database.
+type replicaConfig struct {
+	blob     blob.Config // where the replica is kept; an empty Driver disables replication
+	replica  replica.Config
+	maxDelay time.Duration // how stale the last sync may be before /readyz fails
+}
+
+// loadReplicaConfig reads the REPLICA_* environment. The bucket credentials
+// are the blob store's S3_* and GCS_* ones; REPLICA_BUCKET names a separate
+// bucket.
+func loadReplicaConfig() replicaConfig {
+	cfg := replicaConfig{
+		blob: blob.Config{
+			Driver: getEnv("REPLICA_DRIVER", ""),
+			Dir:    getEnv("REPLICA_DIR", "./data/replica"),
+			S3: blob.S3Config{
+				Endpoint:        getEnv("S3_ENDPOINT", ""),
+				Region:          getEnv("S3_REGION", "us-east-1"),
+				Bucket:          getEnv("REPLICA_BUCKET", getEnv("S3_BUCKET", "")),
+				AccessKeyID:     getEnv("S3_ACCESS_KEY_ID", ""),
+				SecretAccessKey: getEnv("S3_SECRET_ACCESS_KEY", ""),
+				PathStyle:       getEnv("S3_PATH_STYLE", "") == "true",
+			},
+			GCS: blob.GCSConfig{
+				Bucket:   getEnv("REPLICA_BUCKET", getEnv("GCS_BUCKET", "")),
+				AccessID: getEnv("GCS_HMAC_ACCESS_ID", ""),
+				Secret:   getEnv("GCS_HMAC_SECRET", ""),
+			},
+		},
+		replica: replica.Config{
+			Prefix:           getEnv("REPLICA_PREFIX", "replica/"),
+			Interval:         getEnvDuration("REPLICA_INTERVAL", replica.DefaultInterval),
+			SnapshotInterval: getEnvDuration("REPLICA_SNAPSHOT_INTERVAL", replica.DefaultSnapshotInterval),
+			CheckpointBytes:  getEnvInt("REPLICA_CHECKPOINT_BYTES", replica.DefaultCheckpointBytes),
+		},
+	}
+	cfg.maxDelay = max(10*cfg.replica.Interval, minReplicaLag)
+	return cfg
+}
+
+// enabled reports whether replication is configured.
+func (c replicaConfig) enabled() bool {
+	return c.blob.Driver != ""
+}
+
+// openReplicaStore opens the blob store the replica is kept in.
+func openReplicaStore(cfg replicaConfig) (blob.Store, error) {
+	store, err := blob.Open(cfg.blob)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open replica store: %w", err)
+	}
+	return store, nil
+}
+
+// restoreIfMissing restores the database at dbPath from the replica when
+// there's no file there, as on a fresh machine or volume. It's a no-op if
+// the replica is empty too.
+func restoreIfMissing(ctx context.Context, store blob.Store, cfg replicaConfig, dbPath string) error {
+	if _, err := os.Stat(dbPath); err == nil {
+		return nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to check database: %w", err)
+	}
+	restored, err := replica.Restore(ctx, store, cfg.replica.Prefix, dbPath)
+	if err != nil {
+		return err
+	}
+	if !restored {
+		slog.Info("No replica to restore, starting with an empty database", "database", dbPath)
+	}
+	return nil
+}
+
+// readyHandler serves /readyz: ready while the database answers and, when
+// replication is on (rep non-nil), the replica has synced within maxDelay.
+// The body reports the replica's position either way.
+func readyHandler(store *sqlite.SQLiteStore, rep *replica.Replicator, maxDelay time.Duration) http.Handler {
+	type replicaStatus struct {
+		Generation   string    `json:"generation"`
+		Index        int       `json:"index"`
+		Offset       int64     `json:"offset"`
+		LastSync     time.Time `json:"last_sync"`
+		LastSnapshot time.Time `json:"last_snapshot"`
+		Error        string    `json:"error,omitempty"`
+	}
+	type readiness struct {
+		Ready    bool           `json:"ready"`
+		Database string         `json:"database"`
+		Replica  *replicaStatus `json:"replica,omitempty"`
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := readiness{Ready: true, Database: "ok"}
+		if err := store.Ping(r.Context()); err != nil {
+			resp.Ready, resp.Database = false, err.Error()
+		}
+		if rep != nil {
+			s := rep.Status()
+			resp.Replica = &replicaStatus{
+				Generation:   s.Generation,
+				Index:        s.Index,
+				Offset:       s.Offset,
+				LastSync:     s.LastSync,
+				LastSnapshot: s.LastSnapshot,
+				Error:        s.LastError,
+			}
+			if s.LastSync.IsZero() || time.Since(s.LastSync) > maxDelay {
+				resp.Ready = false
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if !resp.Ready {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(resp)
+	})
+}
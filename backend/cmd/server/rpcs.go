@@ -0,0 +1,65 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/mmynk/splitwiser/internal/auth"
+	"github.com/mmynk/splitwiser/internal/middleware"
+	"github.com/mmynk/splitwiser/pkg/proto/protoconnect"
+)
+
+// publicProcedures are the RPCs anyone may call without signing in. Every
+// other procedure must reject callers without a valid token, either through
+// RequireAuth or, for AuthService, by checking for a user itself;
+// TestProcedureAuth fails for any that doesn't, so a new RPC that is meant
+// to be public has to be added here.
+var publicProcedures = map[string]bool{
+	protoconnect.AuthServiceRegisterProcedure:                   true,
+	protoconnect.AuthServiceLoginProcedure:                      true,
+	protoconnect.AuthServiceLogoutProcedure:                     true,
+	protoconnect.AuthServiceAcceptInvitationProcedure:           true,
+	protoconnect.PublicStatsServiceGetPublicGroupStatsProcedure: true,
+	protoconnect.InstanceServiceGetInstanceConfigProcedure:      true,
+	protoconnect.SharedBillServiceGetSharedBillProcedure:        true,
+}
+
+// rpcServices are the Connect service implementations the server exposes.
+type rpcServices struct {
+	auth        protoconnect.AuthServiceHandler
+	split       protoconnect.SplitServiceHandler
+	group       protoconnect.GroupServiceHandler
+	friend      protoconnect.FriendServiceHandler
+	publicStats protoconnect.PublicStatsServiceHandler
+	instance    protoconnect.InstanceServiceHandler
+	sharedBill  protoconnect.SharedBillServiceHandler
+}
+
+// registerRPCs mounts the Connect services on mux behind interceptors, with
+// the auth stage each needs.
+func registerRPCs(mux *http.ServeMux, interceptors middleware.Chain, jwtManager *auth.JWTManager, services rpcServices) {
+	protected := interceptors.With(middleware.StageAuth, middleware.RequireAuth(jwtManager))
+
+	// Register AuthService with optional auth so GetCurrentUser can read the JWT,
+	// while Register/Login/Logout remain accessible without a token.
+	mux.Handle(protoconnect.NewAuthServiceHandler(
+		services.auth,
+		interceptors.With(middleware.StageAuth, middleware.OptionalAuth(jwtManager)).HandlerOption(),
+	))
+
+	// Register protected services with the full chain including required auth
+	mux.Handle(protoconnect.NewSplitServiceHandler(services.split, protected.HandlerOption()))
+	mux.Handle(protoconnect.NewGroupServiceHandler(services.group, protected.HandlerOption()))
+	mux.Handle(protoconnect.NewFriendServiceHandler(services.friend, protected.HandlerOption()))
+
+	// Opt-in group stats are public: no auth
+	mux.Handle(protoconnect.NewPublicStatsServiceHandler(services.publicStats, interceptors.HandlerOption()))
+
+	// Shared bills are read by link; signing in only adds the bill's ID for participants
+	mux.Handle(protoconnect.NewSharedBillServiceHandler(
+		services.sharedBill,
+		interceptors.With(middleware.StageAuth, middleware.OptionalAuth(jwtManager)).HandlerOption(),
+	))
+
+	// Instance branding and defaults are read before sign-in: no auth
+	mux.Handle(protoconnect.NewInstanceServiceHandler(services.instance, interceptors.HandlerOption()))
+}
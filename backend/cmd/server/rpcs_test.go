@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	"github.com/mmynk/splitwiser/internal/auth"
+	"github.com/mmynk/splitwiser/internal/middleware"
+	"github.com/mmynk/splitwiser/internal/service"
+	"github.com/mmynk/splitwiser/internal/storage/sqlite"
+	"github.com/mmynk/splitwiser/pkg/logging"
+	_ "github.com/mmynk/splitwiser/pkg/proto"
+)
+
+// protoPackage is the proto package every Splitwiser service is in.
+const protoPackage = "splitwiser.v1"
+
+// allProcedures lists every RPC defined in protoPackage, as Connect
+// procedure paths.
+func allProcedures(t *testing.T) map[string]protoreflect.MethodDescriptor {
+	t.Helper()
+	procedures := make(map[string]protoreflect.MethodDescriptor)
+	protoregistry.GlobalFiles.RangeFilesByPackage(protoPackage, func(file protoreflect.FileDescriptor) bool {
+		for i := 0; i < file.Services().Len(); i++ {
+			svc := file.Services().Get(i)
+			for j := 0; j < svc.Methods().Len(); j++ {
+				m := svc.Methods().Get(j)
+				procedures[fmt.Sprintf("/%s/%s", svc.FullName(), m.Name())] = m
+			}
+		}
+		return true
+	})
+	if len(procedures) == 0 {
+		t.Fatalf("no services registered in %s", protoPackage)
+	}
+	return procedures
+}
+
+// TestProcedureAuth calls every RPC without a token, and with an invalid
+// one, and checks each is rejected as unauthenticated unless it's in
+// publicProcedures. A new RPC fails here until it's wired behind auth or
+// deliberately made public.
+func TestProcedureAuth(t *testing.T) {
+	store, err := sqlite.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	jwtManager := auth.NewJWTManager("test-secret", auth.SessionPolicy{IdleTimeout: time.Hour})
+	mux := http.NewServeMux()
+	registerRPCs(mux, middleware.NewChain(), jwtManager, rpcServices{
+		auth:        service.NewAuthService(auth.NewPasswordAuthenticator(store), jwtManager, auth.NewEmailManager(store, auth.LogMailer{}), auth.NewInviter(store, auth.LogMailer{}), logging.Component("auth")),
+		split:       service.NewSplitService(store),
+		group:       service.NewGroupService(store),
+		friend:      service.NewFriendService(store),
+		publicStats: service.NewPublicStatsService(store, time.Minute),
+		instance:    service.NewInstanceService(service.InstanceConfig{Name: "Splitwiser"}),
+		sharedBill:  service.NewSharedBillService(store),
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	procedures := allProcedures(t)
+	for procedure := range publicProcedures {
+		if procedures[procedure] == nil {
+			t.Errorf("publicProcedures lists %s, which isn't an RPC", procedure)
+		}
+	}
+
+	for procedure, method := range procedures {
+		if publicProcedures[procedure] {
+			continue
+		}
+		t.Run(strings.TrimPrefix(procedure, "/"+protoPackage+"."), func(t *testing.T) {
+			// The auth interceptors only see unary calls.
+			if method.IsStreamingClient() || method.IsStreamingServer() {
+				t.Fatalf("%s streams, which the auth interceptors don't cover", procedure)
+			}
+			for name, authorization := range map[string]string{"no token": "", "invalid token": "Bearer not-a-token"} {
+				req, err := http.NewRequest(http.MethodPost, server.URL+procedure, strings.NewReader("{}"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				req.Header.Set("Content-Type", "application/json")
+				if authorization != "" {
+					req.Header.Set("Authorization", authorization)
+				}
+				resp, err := http.DefaultClient.Do(req)
+				if err != nil {
+					t.Fatalf("%s: %v", name, err)
+				}
+				var body struct {
+					Code    string `json:"code"`
+					Message string `json:"message"`
+				}
+				json.NewDecoder(resp.Body).Decode(&body)
+				resp.Body.Close()
+				if resp.StatusCode == http.StatusNotFound {
+					t.Fatalf("%s isn't registered by registerRPCs", procedure)
+				}
+				if body.Code != "unauthenticated" {
+					t.Errorf("%s: got HTTP %d %q (%s), want unauthenticated; require auth or add it to publicProcedures",
+						name, resp.StatusCode, body.Code, body.Message)
+				}
+			}
+		})
+	}
+}
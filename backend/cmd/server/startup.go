@@ -0,0 +1,103 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/mmynk/splitwiser/internal/middleware"
+	"github.com/mmynk/splitwiser/internal/storage/sqlite"
+)
+
+// maxRetryBackoff caps the delay between startup attempts.
+const maxRetryBackoff = 30 * time.Second
+
+// startupRetry controls how startup dependencies are retried before giving up.
+type startupRetry struct {
+	attempts int           // total attempts, including the first
+	backoff  time.Duration // delay before the second attempt; doubles after each failure
+}
+
+// permanentError marks a startup failure that retrying can't fix.
+type permanentError struct{ error }
+
+func (e permanentError) Unwrap() error { return e.error }
+
+// do runs fn until it succeeds, fails with a permanentError, or attempts run
+// out, logging progress.
+func (r startupRetry) do(name string, fn func() error) error {
+	attempts := max(r.attempts, 1)
+	delay := r.backoff
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = fn(); err == nil {
+			if attempt > 1 {
+				slog.Info("Startup dependency ready", "dependency", name, "attempt", attempt)
+			}
+			return nil
+		}
+		var permanent permanentError
+		if errors.As(err, &permanent) {
+			return fmt.Errorf("%s failed: %w", name, permanent.error)
+		}
+		if attempt == attempts {
+			break
+		}
+		slog.Warn("Startup dependency not ready, retrying",
+			"dependency", name,
+			"attempt", attempt,
+			"max_attempts", attempts,
+			"retry_in", delay,
+			"error", err,
+		)
+		time.Sleep(delay)
+		delay = min(delay*2, maxRetryBackoff)
+	}
+	return fmt.Errorf("%s not ready after %d attempts: %w", name, attempts, err)
+}
+
+// openStore opens the SQLite store, retrying while the database path is unavailable
+// (e.g. a network mount that hasn't come up yet).
+func openStore(r startupRetry, dbPath string, opts ...sqlite.Option) (*sqlite.SQLiteStore, error) {
+	// Queries are only kept for requests under SlowRequestInterceptor.
+	opts = append(opts, sqlite.WithQueryHook(middleware.RecordQuery))
+	var store *sqlite.SQLiteStore
+	err := r.do("storage", func() error {
+		var err error
+		store, err = sqlite.New(dbPath, opts...)
+		// A drifted schema needs an operator, not another attempt.
+		var drift *sqlite.SchemaDriftError
+		if errors.As(err, &drift) {
+			return permanentError{err}
+		}
+		return err
+	})
+	return store, err
+}
+
+// checkDependencies verifies everything the server needs before binding the listener.
+func checkDependencies(r startupRetry, staticDir, tlsCertFile, tlsKeyFile string) error {
+	if err := r.do("static files", func() error {
+		info, err := os.Stat(staticDir)
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("%s is not a directory", staticDir)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if tlsCertFile != "" && tlsKeyFile != "" {
+		return r.do("TLS certificate", func() error {
+			_, err := tls.LoadX509KeyPair(tlsCertFile, tlsKeyFile)
+			return err
+		})
+	}
+	return nil
+}
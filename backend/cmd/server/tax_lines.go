@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/mmynk/splitwiser/internal/storage"
+	"github.com/mmynk/splitwiser/internal/taxreport"
+)
+
+// loadTaxLines reads the tax lines deductible bill categories are totaled
+// under in tax reports from TAX_LINES, checking each category exists. Unset,
+// tax reports are off and it returns nil.
+func loadTaxLines(ctx context.Context, store storage.Store) (taxreport.Lines, error) {
+	spec := getEnv("TAX_LINES", "")
+	if spec == "" {
+		return nil, nil
+	}
+	lines, err := taxreport.ParseLines(spec)
+	if err != nil {
+		return nil, fmt.Errorf("TAX_LINES: %w", err)
+	}
+	categories, err := store.ListCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool, len(categories))
+	for _, c := range categories {
+		known[c.ID] = true
+	}
+	for category := range lines {
+		if !known[category] {
+			return nil, fmt.Errorf("TAX_LINES: no category %q", category)
+		}
+	}
+	slog.Info("Tax lines loaded", "categories", len(lines))
+	return lines, nil
+}
@@ -0,0 +1,207 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mmynk/splitwiser/internal/models"
+	"github.com/mmynk/splitwiser/internal/storage"
+	"github.com/mmynk/splitwiser/pkg/logging"
+)
+
+// logger logs as the auth component; see pkg/logging.
+var logger = logging.Component("auth")
+
+var (
+	ErrInvalidEmail       = errors.New("invalid email address")
+	ErrEmailAlreadyLinked = errors.New("email already linked to this account")
+	ErrEmailNotLinked     = errors.New("email not linked to this account")
+	ErrPrimaryEmail       = errors.New("cannot remove primary email")
+	ErrInvalidVerifyToken = errors.New("invalid or expired verification token")
+)
+
+// verificationTTL is how long an email verification token stays valid.
+const verificationTTL = 24 * time.Hour
+
+// EmailStorage defines the persistence operations needed to link emails to accounts.
+type EmailStorage interface {
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	GetUserByID(ctx context.Context, id string) (*models.User, error)
+	AddUserEmail(ctx context.Context, e *models.UserEmail) error
+	GetUserEmail(ctx context.Context, userID, email string) (*models.UserEmail, error)
+	ListUserEmails(ctx context.Context, userID string) ([]*models.UserEmail, error)
+	MarkUserEmailVerified(ctx context.Context, userID, email string, verifiedAt int64) error
+	DeleteUserEmail(ctx context.Context, userID, email string) error
+}
+
+// Mailer delivers account tokens (email verifications, invitations) to their address.
+type Mailer interface {
+	SendVerification(ctx context.Context, to, token string) error
+	SendInvitation(ctx context.Context, to, token string) error
+}
+
+// LogMailer is a Mailer that writes tokens to the log.
+// Useful for development and self-hosted setups without outbound email.
+type LogMailer struct{}
+
+// SendVerification logs the verification token for the given address.
+func (LogMailer) SendVerification(ctx context.Context, to, token string) error {
+	logger.Info("Email verification requested", "email", to, "token", token)
+	return nil
+}
+
+// SendInvitation logs the invitation token for the given address.
+func (LogMailer) SendInvitation(ctx context.Context, to, token string) error {
+	logger.Info("Account invitation created", "email", to, "token", token)
+	return nil
+}
+
+// EmailManager links additional email addresses to user accounts.
+// Linked addresses must be verified before they can be used to log in.
+type EmailManager struct {
+	storage EmailStorage
+	mailer  Mailer
+	now     func() time.Time
+}
+
+// NewEmailManager creates an EmailManager that sends verification tokens with mailer.
+func NewEmailManager(storage EmailStorage, mailer Mailer) *EmailManager {
+	return &EmailManager{storage: storage, mailer: mailer, now: time.Now}
+}
+
+// Add links a new, unverified address to the user and sends it a verification token.
+// Adding a pending address again issues a fresh token.
+func (m *EmailManager) Add(ctx context.Context, userID, email string) (*models.UserEmail, error) {
+	email = models.NormalizeEmail(email)
+	if email == "" || !strings.Contains(email, "@") {
+		return nil, ErrInvalidEmail
+	}
+
+	owner, err := m.storage.GetUserByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if owner != nil {
+		if owner.ID == userID {
+			return nil, ErrEmailAlreadyLinked
+		}
+		return nil, ErrEmailExists
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	now := m.now()
+	linked := &models.UserEmail{
+		UserID:         userID,
+		Email:          email,
+		TokenHash:      hashToken(token),
+		TokenExpiresAt: now.Add(verificationTTL).Unix(),
+		CreatedAt:      now.Unix(),
+	}
+	if err := m.storage.AddUserEmail(ctx, linked); err != nil {
+		return nil, err
+	}
+
+	if err := m.mailer.SendVerification(ctx, email, token); err != nil {
+		return nil, fmt.Errorf("failed to send verification: %w", err)
+	}
+
+	return m.storage.GetUserEmail(ctx, userID, email)
+}
+
+// Verify confirms a linked address with the token sent to it.
+func (m *EmailManager) Verify(ctx context.Context, userID, email, token string) error {
+	linked, err := m.storage.GetUserEmail(ctx, userID, email)
+	if err != nil {
+		return ErrEmailNotLinked
+	}
+	if linked.Verified {
+		return nil
+	}
+
+	now := m.now()
+	if linked.TokenHash == "" || now.Unix() > linked.TokenExpiresAt ||
+		subtle.ConstantTimeCompare([]byte(linked.TokenHash), []byte(hashToken(token))) != 1 {
+		return ErrInvalidVerifyToken
+	}
+
+	// Another account may have claimed the address since it was added.
+	owner, err := m.storage.GetUserByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+	if owner != nil && owner.ID != userID {
+		return ErrEmailExists
+	}
+
+	return m.storage.MarkUserEmailVerified(ctx, userID, email, now.Unix())
+}
+
+// Remove unlinks an address from the user. The primary address can't be removed.
+func (m *EmailManager) Remove(ctx context.Context, userID, email string) error {
+	user, err := m.storage.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user != nil && user.Email == email {
+		return ErrPrimaryEmail
+	}
+	if err := m.storage.DeleteUserEmail(ctx, userID, email); err != nil {
+		if errors.Is(err, storage.ErrReadOnly) {
+			return err
+		}
+		return ErrEmailNotLinked
+	}
+	return nil
+}
+
+// List returns the user's primary address followed by any linked addresses.
+func (m *EmailManager) List(ctx context.Context, userID string) ([]*models.UserEmail, error) {
+	user, err := m.storage.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, fmt.Errorf("user not found: %s", userID)
+	}
+
+	linked, err := m.storage.ListUserEmails(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	emails := make([]*models.UserEmail, 0, len(linked)+1)
+	emails = append(emails, &models.UserEmail{
+		UserID:     user.ID,
+		Email:      user.Email,
+		Primary:    true,
+		Verified:   true,
+		CreatedAt:  user.CreatedAt,
+		VerifiedAt: user.CreatedAt,
+	})
+	return append(emails, linked...), nil
+}
+
+// newToken returns a random hex-encoded token.
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// hashToken returns the SHA-256 hex digest stored in place of a raw token.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
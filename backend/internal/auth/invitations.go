@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mmynk/splitwiser/internal/models"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var ErrInvalidInvitation = errors.New("invalid or expired invitation")
+
+// invitationTTL is how long an invitation token stays valid.
+const invitationTTL = 7 * 24 * time.Hour
+
+// InvitationStorage defines the persistence operations needed to invite users.
+type InvitationStorage interface {
+	CreateUser(ctx context.Context, user *models.User) error
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	GetUserByID(ctx context.Context, id string) (*models.User, error)
+	CreateInvitation(ctx context.Context, inv *models.Invitation) error
+	GetInvitation(ctx context.Context, tokenHash string) (*models.Invitation, error)
+	DeleteInvitationsByUser(ctx context.Context, userID string) error
+	SetUserPassword(ctx context.Context, userID, passwordHash string) error
+}
+
+// Inviter creates accounts on behalf of users and lets them claim the account
+// by choosing a password with the token from their invitation.
+type Inviter struct {
+	storage InvitationStorage
+	mailer  Mailer
+	now     func() time.Time
+}
+
+// NewInviter creates an Inviter that sends invitation tokens with mailer.
+func NewInviter(storage InvitationStorage, mailer Mailer) *Inviter {
+	return &Inviter{storage: storage, mailer: mailer, now: time.Now}
+}
+
+// Invite creates an account without a password and sends an invitation to it.
+// Returns ErrEmailExists if the address already belongs to an account.
+func (i *Inviter) Invite(ctx context.Context, email, displayName string) (*models.User, error) {
+	email = models.NormalizeEmail(email)
+	if email == "" || !strings.Contains(email, "@") {
+		return nil, ErrInvalidEmail
+	}
+	if displayName == "" {
+		displayName = strings.SplitN(email, "@", 2)[0]
+	}
+
+	existing, err := i.storage.GetUserByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrEmailExists
+	}
+
+	user := models.NewUser(email, displayName, "")
+	if err := i.storage.CreateUser(ctx, user); err != nil {
+		return nil, err
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	now := i.now()
+	if err := i.storage.CreateInvitation(ctx, &models.Invitation{
+		TokenHash: hashToken(token),
+		UserID:    user.ID,
+		ExpiresAt: now.Add(invitationTTL).Unix(),
+		CreatedAt: now.Unix(),
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := i.mailer.SendInvitation(ctx, email, token); err != nil {
+		return nil, fmt.Errorf("failed to send invitation: %w", err)
+	}
+	return user, nil
+}
+
+// Accept sets the password for an invited account and consumes its invitations.
+func (i *Inviter) Accept(ctx context.Context, token, password string) (*models.User, error) {
+	inv, err := i.storage.GetInvitation(ctx, hashToken(token))
+	if err != nil || i.now().Unix() > inv.ExpiresAt {
+		return nil, ErrInvalidInvitation
+	}
+	if err := validatePassword(password); err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+	if err := i.storage.SetUserPassword(ctx, inv.UserID, string(hashedPassword)); err != nil {
+		return nil, err
+	}
+	if err := i.storage.DeleteInvitationsByUser(ctx, inv.UserID); err != nil {
+		return nil, err
+	}
+
+	user, err := i.storage.GetUserByID(ctx, inv.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrInvalidInvitation
+	}
+	return user, nil
+}
@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"time"
@@ -14,39 +15,80 @@ var (
 	ErrMissingToken = errors.New("authorization token required")
 )
 
+// SessionPolicy controls how long a login lasts. Access tokens live for
+// IdleTimeout and are renewed while the user keeps making requests, so a
+// session ends after IdleTimeout without activity, or at MaxAge regardless.
+type SessionPolicy struct {
+	IdleTimeout time.Duration // Token lifetime; how long a session survives without requests
+	RenewAfter  time.Duration // Tokens at least this old are renewed on use; 0 never renews
+	MaxAge      time.Duration // Sessions end this long after login even if active; 0 for no limit
+}
+
 // JWTManager handles JWT token generation and validation.
 type JWTManager struct {
-	secretKey     []byte
-	tokenDuration time.Duration
+	secretKey []byte
+	policy    SessionPolicy
+	merges    AccountMerges
+}
+
+// AccountMerges finds the account a merged account now belongs to.
+type AccountMerges interface {
+	// MergedInto returns the account userID was merged into, or nil if it
+	// wasn't merged.
+	MergedInto(ctx context.Context, userID string) (*models.User, error)
 }
 
 // Claims represents the custom JWT claims for a user session.
 type Claims struct {
-	UserID string `json:"user_id"`
-	Email  string `json:"email"`
+	UserID       string `json:"user_id"`
+	Email        string `json:"email"`
+	SessionStart int64  `json:"session_start,omitempty"` // Unix time of the login renewed tokens descend from
 	jwt.RegisteredClaims
 }
 
-// NewJWTManager creates a new JWT manager with the given secret and token duration.
+// sessionStart returns when the claims' session began. Tokens issued before
+// sessions were tracked start at their issue time.
+func (c *Claims) sessionStart() time.Time {
+	if c.SessionStart == 0 && c.IssuedAt != nil {
+		return c.IssuedAt.Time
+	}
+	return time.Unix(c.SessionStart, 0)
+}
+
+// NewJWTManager creates a new JWT manager with the given secret and session policy.
 // secretKey should be a strong random string (e.g., 32 bytes).
-// tokenDuration is how long tokens remain valid (e.g., 24 hours).
-func NewJWTManager(secretKey string, tokenDuration time.Duration) *JWTManager {
+func NewJWTManager(secretKey string, policy SessionPolicy) *JWTManager {
 	return &JWTManager{
-		secretKey:     []byte(secretKey),
-		tokenDuration: tokenDuration,
+		secretKey: []byte(secretKey),
+		policy:    policy,
 	}
 }
 
-// Generate creates a new JWT token for the given user.
+// Generate creates a new JWT token for the given user, starting a session.
 func (m *JWTManager) Generate(user *models.User) (string, error) {
-	claims := &Claims{
-		UserID: user.ID,
-		Email:  user.Email,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(m.tokenDuration)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-		},
+	now := time.Now()
+	return m.sign(&Claims{UserID: user.ID, Email: user.Email, SessionStart: now.Unix()}, now)
+}
+
+// expiry is when a token issued now for a session that began at start
+// expires: after the idle timeout, but not past the session's maximum age.
+func (m *JWTManager) expiry(start, now time.Time) time.Time {
+	expires := now.Add(m.policy.IdleTimeout)
+	if m.policy.MaxAge > 0 {
+		if end := start.Add(m.policy.MaxAge); end.Before(expires) {
+			return end
+		}
+	}
+	return expires
+}
+
+// sign issues a token for claims' user and session, valid from now.
+func (m *JWTManager) sign(claims *Claims, now time.Time) (string, error) {
+	expires := m.expiry(claims.sessionStart(), now)
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(expires),
+		IssuedAt:  jwt.NewNumericDate(now),
+		NotBefore: jwt.NewNumericDate(now),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -58,6 +100,47 @@ func (m *JWTManager) Generate(user *models.User) (string, error) {
 	return tokenString, nil
 }
 
+// Renew returns a fresh token for the session of validated claims if the
+// policy's RenewAfter has passed since they were issued, or "" if they don't
+// need renewing yet or their session can't be extended any further.
+func (m *JWTManager) Renew(claims *Claims) (string, error) {
+	if m.policy.RenewAfter <= 0 || claims.IssuedAt == nil {
+		return "", nil
+	}
+	now := time.Now()
+	if now.Sub(claims.IssuedAt.Time) < m.policy.RenewAfter {
+		return "", nil
+	}
+	start := claims.sessionStart()
+	if claims.ExpiresAt != nil && !m.expiry(start, now).After(claims.ExpiresAt.Time) {
+		return "", nil // capped by MaxAge: the session ends on schedule
+	}
+	return m.sign(&Claims{UserID: claims.UserID, Email: claims.Email, SessionStart: start.Unix()}, now)
+}
+
+// FollowMerges makes Resolve carry sessions of merged accounts over to the
+// account each was merged into.
+func (m *JWTManager) FollowMerges(merges AccountMerges) {
+	m.merges = merges
+}
+
+// Resolve points validated claims of an account that was merged into
+// another at that account, so the session continues there and is renewed
+// for it. It does nothing without FollowMerges.
+func (m *JWTManager) Resolve(ctx context.Context, claims *Claims) error {
+	if m.merges == nil {
+		return nil
+	}
+	user, err := m.merges.MergedInto(ctx, claims.UserID)
+	if err != nil {
+		return err
+	}
+	if user != nil {
+		claims.UserID, claims.Email = user.ID, user.Email
+	}
+	return nil
+}
+
 // Validate parses and validates a JWT token, returning the claims if valid.
 func (m *JWTManager) Validate(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(
@@ -80,6 +163,10 @@ func (m *JWTManager) Validate(tokenString string) (*Claims, error) {
 	if !ok || !token.Valid {
 		return nil, ErrInvalidToken
 	}
+	// The policy may have shortened MaxAge since the token was issued.
+	if m.policy.MaxAge > 0 && time.Since(claims.sessionStart()) > m.policy.MaxAge {
+		return nil, fmt.Errorf("%w: session exceeded its maximum age", ErrInvalidToken)
+	}
 
 	return claims, nil
 }
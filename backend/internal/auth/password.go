@@ -37,14 +37,23 @@ func NewPasswordAuthenticator(storage UserStorage) *PasswordAuthenticator {
 
 // ValidateCredential checks if the password meets minimum requirements.
 func (a *PasswordAuthenticator) ValidateCredential(credential string) error {
-	if len(credential) < 8 {
+	return validatePassword(credential)
+}
+
+// validatePassword checks a plain password against the minimum requirements.
+func validatePassword(password string) error {
+	if len(password) < 8 {
 		return ErrWeakPassword
 	}
 	return nil
 }
 
-// Register creates a new user account with a hashed password.
+// Register creates a new user account with a hashed password. The email
+// address is stored normalized, and one differing from an existing account's
+// only in case or surrounding space is taken.
 func (a *PasswordAuthenticator) Register(ctx context.Context, email, displayName, credential string) (*models.User, error) {
+	email = models.NormalizeEmail(email)
+
 	// Validate password strength
 	if err := a.ValidateCredential(credential); err != nil {
 		return nil, err
@@ -79,9 +88,10 @@ func (a *PasswordAuthenticator) GetUserByID(ctx context.Context, id string) (*mo
 }
 
 // Authenticate verifies the email and password, returning the user if valid.
+// The address matches its account whatever its case.
 func (a *PasswordAuthenticator) Authenticate(ctx context.Context, email, credential string) (*models.User, error) {
 	// Get user by email
-	user, err := a.storage.GetUserByEmail(ctx, email)
+	user, err := a.storage.GetUserByEmail(ctx, models.NormalizeEmail(email))
 	if err != nil || user == nil {
 		return nil, ErrInvalidCredentials
 	}
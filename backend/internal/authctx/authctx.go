@@ -0,0 +1,46 @@
+// Package authctx carries the authenticated caller through a request context.
+package authctx
+
+import (
+	"context"
+	"slices"
+)
+
+// Principal is the caller a request is made on behalf of.
+type Principal struct {
+	ID     string
+	Email  string
+	Roles  []string
+	Scopes []string
+}
+
+// HasRole reports whether the principal holds role.
+func (p Principal) HasRole(role string) bool {
+	return slices.Contains(p.Roles, role)
+}
+
+// HasScope reports whether the principal was granted scope.
+func (p Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+// principalKey is the context key for the Principal.
+type principalKey struct{}
+
+// WithUser returns a copy of ctx carrying p.
+func WithUser(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the principal carried by ctx, if any.
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// UserID returns the ID of the principal carried by ctx.
+// Returns empty string for unauthenticated requests.
+func UserID(ctx context.Context) string {
+	p, _ := FromContext(ctx)
+	return p.ID
+}
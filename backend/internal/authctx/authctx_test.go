@@ -0,0 +1,31 @@
+package authctx
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWithUser(t *testing.T) {
+	ctx := context.Background()
+	if _, ok := FromContext(ctx); ok {
+		t.Error("expected no principal on a bare context")
+	}
+	if id := UserID(ctx); id != "" {
+		t.Errorf("UserID() = %q, want empty", id)
+	}
+
+	ctx = WithUser(ctx, Principal{ID: "u1", Email: "a@example.com", Roles: []string{"admin"}, Scopes: []string{"bills:read"}})
+	p, ok := FromContext(ctx)
+	if !ok || p.ID != "u1" || p.Email != "a@example.com" {
+		t.Errorf("FromContext() = %+v, %v", p, ok)
+	}
+	if UserID(ctx) != "u1" {
+		t.Errorf("UserID() = %q, want u1", UserID(ctx))
+	}
+	if !p.HasRole("admin") || p.HasRole("owner") {
+		t.Error("HasRole mismatch")
+	}
+	if !p.HasScope("bills:read") || p.HasScope("bills:write") {
+		t.Error("HasScope mismatch")
+	}
+}
@@ -0,0 +1,227 @@
+// Package bankfeed turns bank and card transactions forwarded by an
+// aggregator into draft bills.
+//
+// Each transaction becomes a bill in the chosen group with its amount,
+// merchant and date filled in, parked until someone assigns participants
+// (see UpdateBill). A transaction is imported at most once per group.
+package bankfeed
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/mmynk/splitwiser/internal/models"
+)
+
+// dateLayout is the calendar date format used by Plaid and most bank feeds.
+const dateLayout = "2006-01-02"
+
+// defaultTitle names draft bills for transactions without a merchant.
+const defaultTitle = "Card transaction"
+
+// Transaction is one bank or card transaction. Amount is positive for money
+// spent, as in Plaid; refunds and deposits are negative.
+type Transaction struct {
+	ID       string
+	Amount   float64
+	Currency string // ISO 4217 code; empty if the feed doesn't say
+	Merchant string
+	Date     time.Time // zero if the feed doesn't say
+	Pending  bool
+}
+
+// Status reports what happened to one Transaction.
+type Status string
+
+const (
+	StatusCreated   Status = "created"   // draft bill created
+	StatusDuplicate Status = "duplicate" // imported before; BillID is the earlier bill
+	StatusSkipped   Status = "skipped"   // pending, or not money spent
+	StatusFailed    Status = "failed"
+)
+
+// Result is the outcome of importing one Transaction.
+type Result struct {
+	TransactionID string `json:"transaction_id"`
+	BillID        string `json:"bill_id,omitempty"`
+	Status        Status `json:"status"`
+	Error         string `json:"error,omitempty"`
+}
+
+// Store is the subset of storage needed to create draft bills.
+type Store interface {
+	GetGroup(ctx context.Context, groupID string) (*models.Group, error)
+	CreateTransactionBill(ctx context.Context, bill *models.Bill, transactionID string) (bool, error)
+}
+
+// Importer creates draft bills from transactions.
+type Importer struct {
+	store Store
+}
+
+// New creates an Importer.
+func New(store Store) *Importer {
+	return &Importer{store: store}
+}
+
+// Import creates a draft bill in the group for each transaction, credited to
+// the group's creator. Transactions are independent: a failure is reported
+// in its Result and doesn't stop the rest. It fails only if the group can't
+// be loaded.
+func (im *Importer) Import(ctx context.Context, groupID string, txns []Transaction) ([]Result, error) {
+	group, err := im.store.GetGroup(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]Result, len(txns))
+	for i, t := range txns {
+		results[i] = im.importOne(ctx, group, t)
+	}
+	return results, nil
+}
+
+func (im *Importer) importOne(ctx context.Context, group *models.Group, t Transaction) Result {
+	result := Result{TransactionID: t.ID}
+	if t.ID == "" {
+		return failed(result, fmt.Errorf("transaction ID is required"))
+	}
+	if t.Pending || t.Amount <= 0 {
+		result.Status = StatusSkipped
+		return result
+	}
+	currency := strings.ToUpper(strings.TrimSpace(t.Currency))
+	if currency != "" && !isCurrencyCode(currency) {
+		return failed(result, fmt.Errorf("currency %q must be a three-letter ISO 4217 code", t.Currency))
+	}
+
+	bill := &models.Bill{
+		Title:           strings.TrimSpace(t.Merchant),
+		Total:           t.Amount,
+		Subtotal:        t.Amount,
+		Currency:        currency,
+		GroupID:         group.ID,
+		CreatorID:       group.CreatorID,
+		NeedsAssignment: true,
+	}
+	if bill.Title == "" {
+		bill.Title = defaultTitle
+	}
+	if !t.Date.IsZero() {
+		bill.CreatedAt = t.Date.Unix()
+	}
+
+	created, err := im.store.CreateTransactionBill(ctx, bill, t.ID)
+	if err != nil {
+		return failed(result, err)
+	}
+	result.BillID = bill.ID
+	result.Status = StatusCreated
+	if !created {
+		result.Status = StatusDuplicate
+	}
+	return result
+}
+
+func failed(r Result, err error) Result {
+	r.Status = StatusFailed
+	r.Error = err.Error()
+	return r
+}
+
+// isCurrencyCode reports whether code is three upper-case letters.
+func isCurrencyCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// feedTransaction is a transaction as it arrives, with both Plaid's field
+// names and the generic ones.
+type feedTransaction struct {
+	// Plaid
+	TransactionID          string `json:"transaction_id"`
+	ISOCurrencyCode        string `json:"iso_currency_code"`
+	UnofficialCurrencyCode string `json:"unofficial_currency_code"`
+	MerchantName           string `json:"merchant_name"`
+	Name                   string `json:"name"`
+
+	// Generic
+	ID       string `json:"id"`
+	Currency string `json:"currency"`
+	Merchant string `json:"merchant"`
+
+	Amount  float64 `json:"amount"`
+	Date    string  `json:"date"` // YYYY-MM-DD or RFC 3339
+	Pending bool    `json:"pending"`
+}
+
+// Parse reads transactions from JSON: a single transaction, or a list under
+// "transactions" or, as in Plaid's /transactions/sync, "added". Plaid field
+// names (transaction_id, iso_currency_code, merchant_name, name) and generic
+// ones (id, currency, merchant) are both understood.
+func Parse(r io.Reader) ([]Transaction, error) {
+	var doc struct {
+		feedTransaction
+		Transactions []feedTransaction `json:"transactions"`
+		Added        []feedTransaction `json:"added"`
+	}
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode transactions JSON: %w", err)
+	}
+
+	raw := append(doc.Transactions, doc.Added...)
+	if doc.Transactions == nil && doc.Added == nil {
+		raw = []feedTransaction{doc.feedTransaction}
+	}
+
+	txns := make([]Transaction, len(raw))
+	for i, ft := range raw {
+		t, err := ft.transaction()
+		if err != nil {
+			return nil, err
+		}
+		txns[i] = t
+	}
+	return txns, nil
+}
+
+// transaction converts ft, preferring Plaid's fields where both are set.
+func (ft feedTransaction) transaction() (Transaction, error) {
+	t := Transaction{
+		ID:       firstNonEmpty(ft.TransactionID, ft.ID),
+		Amount:   ft.Amount,
+		Currency: firstNonEmpty(ft.ISOCurrencyCode, ft.UnofficialCurrencyCode, ft.Currency),
+		Merchant: firstNonEmpty(ft.MerchantName, ft.Merchant, ft.Name),
+		Pending:  ft.Pending,
+	}
+	if ft.Date != "" {
+		date, err := time.Parse(dateLayout, ft.Date)
+		if err != nil {
+			date, err = time.Parse(time.RFC3339, ft.Date)
+		}
+		if err != nil {
+			return Transaction{}, fmt.Errorf("transaction %s: date %q must be YYYY-MM-DD or RFC 3339", t.ID, ft.Date)
+		}
+		t.Date = date
+	}
+	return t, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,142 @@
+package bankfeed
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mmynk/splitwiser/internal/models"
+	"github.com/mmynk/splitwiser/internal/storage"
+	"github.com/mmynk/splitwiser/internal/storage/sqlite"
+)
+
+func setupHandler(t *testing.T) (http.Handler, *sqlite.SQLiteStore, *models.Group) {
+	t.Helper()
+	store, err := sqlite.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	group := &models.Group{Name: "Flat", Members: []models.GroupMember{{DisplayName: "Ann"}, {DisplayName: "Ben"}}}
+	if err := store.CreateGroup(context.Background(), group); err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	return Handler(New(store), "feed-secret"), store, group
+}
+
+func post(t *testing.T, h http.Handler, groupID, token, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/integrations/transactions?group_id="+groupID, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func decodeResults(t *testing.T, rec *httptest.ResponseRecorder) []Result {
+	t.Helper()
+	var resp struct {
+		Results []Result `json:"results"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return resp.Results
+}
+
+func TestHandler_RequiresToken(t *testing.T) {
+	h, _, group := setupHandler(t)
+
+	for _, token := range []string{"", "wrong"} {
+		rec := post(t, h, group.ID, token, `{"id": "t1", "amount": 5}`)
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("token %q: expected 403, got %d", token, rec.Code)
+		}
+	}
+}
+
+func TestHandler_UnknownGroup(t *testing.T) {
+	h, _, _ := setupHandler(t)
+
+	rec := post(t, h, "no-such-group", "feed-secret", `{"id": "t1", "amount": 5}`)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rec.Code)
+	}
+}
+
+func TestHandler_PlaidSync(t *testing.T) {
+	h, store, group := setupHandler(t)
+	ctx := context.Background()
+
+	body := `{"added": [
+		{"transaction_id": "tx-1", "amount": 42.5, "iso_currency_code": "usd", "merchant_name": "Corner Market", "name": "CORNER MKT 123", "date": "2026-03-14"},
+		{"transaction_id": "tx-2", "amount": 9.99, "name": "STREAMING CO", "date": "2026-03-15", "pending": true},
+		{"transaction_id": "tx-3", "amount": -20, "name": "REFUND", "date": "2026-03-15"}
+	]}`
+	rec := post(t, h, group.ID, "feed-secret", body)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	results := decodeResults(t, rec)
+	want := []Status{StatusCreated, StatusSkipped, StatusSkipped}
+	if len(results) != len(want) {
+		t.Fatalf("expected %d results, got %+v", len(want), results)
+	}
+	for i, status := range want {
+		if results[i].Status != status {
+			t.Errorf("result %d: status %s, want %s (%+v)", i, results[i].Status, status, results[i])
+		}
+	}
+
+	bill, err := store.GetBill(ctx, results[0].BillID)
+	if err != nil {
+		t.Fatalf("GetBill failed: %v", err)
+	}
+	date := time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC).Unix()
+	if bill.Title != "Corner Market" || bill.Total != 42.5 || bill.Subtotal != 42.5 || bill.Currency != "USD" ||
+		bill.GroupID != group.ID || !bill.NeedsAssignment || bill.CreatedAt != date {
+		t.Errorf("unexpected draft bill: %+v", bill)
+	}
+
+	// Redelivery is deduplicated.
+	rec = post(t, h, group.ID, "feed-secret", body)
+	results2 := decodeResults(t, rec)
+	if results2[0].Status != StatusDuplicate || results2[0].BillID != results[0].BillID {
+		t.Errorf("redelivery: got %+v, want duplicate of %s", results2[0], results[0].BillID)
+	}
+	bills, err := store.ListBillsByGroup(ctx, group.ID, storage.BillFilter{})
+	if err != nil {
+		t.Fatalf("ListBillsByGroup failed: %v", err)
+	}
+	if len(bills) != 1 {
+		t.Errorf("expected 1 bill in group, got %d", len(bills))
+	}
+}
+
+func TestParse_Generic(t *testing.T) {
+	txns, err := Parse(strings.NewReader(`{"id": "g-1", "amount": 12, "currency": "EUR", "merchant": "Bakery", "date": "2026-03-14T08:30:00Z"}`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(txns) != 1 {
+		t.Fatalf("expected 1 transaction, got %d", len(txns))
+	}
+	got := txns[0]
+	if got.ID != "g-1" || got.Amount != 12 || got.Currency != "EUR" || got.Merchant != "Bakery" ||
+		!got.Date.Equal(time.Date(2026, 3, 14, 8, 30, 0, 0, time.UTC)) {
+		t.Errorf("unexpected transaction: %+v", got)
+	}
+
+	if _, err := Parse(strings.NewReader(`{"id": "g-2", "amount": 1, "date": "14/03/2026"}`)); err == nil {
+		t.Error("expected error for malformed date")
+	}
+}
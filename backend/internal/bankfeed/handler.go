@@ -0,0 +1,67 @@
+package bankfeed
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"mime"
+	"net/http"
+
+	"github.com/mmynk/splitwiser/pkg/logging"
+)
+
+// logger logs as the bankfeed component; see pkg/logging.
+var logger = logging.Component("bankfeed")
+
+// maxRequestBytes caps the size of a transaction feed delivery.
+const maxRequestBytes = 5 << 20
+
+// Handler serves transaction webhooks over HTTP. Requests must be POSTs
+// carrying "Authorization: Bearer <token>", the target group in the group_id
+// query parameter and an application/json body (see Parse). The response
+// lists one Result per transaction.
+func Handler(im *Importer, token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if token == "" || subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+token)) != 1 {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		groupID := r.URL.Query().Get("group_id")
+		if groupID == "" {
+			http.Error(w, "group_id is required", http.StatusBadRequest)
+			return
+		}
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+			http.Error(w, "content type must be application/json", http.StatusUnsupportedMediaType)
+			return
+		}
+
+		txns, err := Parse(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		results, err := im.Import(r.Context(), groupID, txns)
+		if err != nil {
+			logger.Error("Transaction import failed", "group_id", groupID, "error", err)
+			http.Error(w, "group not found", http.StatusNotFound)
+			return
+		}
+		created := 0
+		for _, res := range results {
+			if res.Status == StatusCreated {
+				created++
+			}
+		}
+		logger.Info("Imported transactions", "group_id", groupID, "transactions", len(txns), "created", created)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(struct {
+			Results []Result `json:"results"`
+		}{results})
+	})
+}
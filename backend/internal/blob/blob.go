@@ -0,0 +1,73 @@
+// Package blob stores opaque files, such as receipt images attached to
+// bills, outside the database.
+//
+// Blobs are addressed by slash-separated keys like "attachments/<bill>/<id>".
+// Store implementations keep them on local disk (Disk), in an S3-compatible
+// bucket (S3) or in Google Cloud Storage (GCS); Open picks one from a Config.
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned by Get for a key with no blob.
+var ErrNotFound = errors.New("blob not found")
+
+// MaxURLExpiry is the longest a signed URL may stay valid.
+const MaxURLExpiry = 7 * 24 * time.Hour
+
+// Object describes a stored blob.
+type Object struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
+// Store keeps blobs by key.
+type Store interface {
+	// Put stores size bytes read from r under key, replacing any blob there.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+
+	// Get opens the blob stored under key. The caller must close it.
+	// Returns ErrNotFound if there is none.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// Delete removes the blob stored under key. Deleting a missing blob is not an error.
+	Delete(ctx context.Context, key string) error
+
+	// SignedURL returns a URL from which anyone can GET the blob stored under
+	// key until expiry has passed. expiry is at most MaxURLExpiry.
+	SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error)
+
+	// List calls fn for every blob whose key starts with prefix, in no
+	// particular order, stopping at the first error fn returns.
+	List(ctx context.Context, prefix string, fn func(Object) error) error
+}
+
+// validKey reports an error unless key is a relative, slash-separated path
+// without empty, "." or ".." segments, so it can't escape a directory or
+// bucket prefix.
+func validKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("blob key required")
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." || strings.ContainsAny(part, "\\\x00") {
+			return fmt.Errorf("invalid blob key %q", key)
+		}
+	}
+	return nil
+}
+
+// validExpiry reports an error unless expiry is positive and at most MaxURLExpiry.
+func validExpiry(expiry time.Duration) error {
+	if expiry <= 0 || expiry > MaxURLExpiry {
+		return fmt.Errorf("signed URL expiry must be between 1s and %s", MaxURLExpiry)
+	}
+	return nil
+}
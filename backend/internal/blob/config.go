@@ -0,0 +1,41 @@
+package blob
+
+import "fmt"
+
+// Drivers Open accepts.
+const (
+	DriverDisk = "disk"
+	DriverS3   = "s3"
+	DriverGCS  = "gcs"
+)
+
+// Config selects and configures a Store.
+type Config struct {
+	// Driver is DriverDisk, DriverS3 or DriverGCS. Empty means DriverDisk.
+	Driver string
+
+	// Dir, URLPrefix and URLKey configure the disk driver; see NewDisk.
+	Dir       string
+	URLPrefix string
+	URLKey    []byte
+
+	S3  S3Config
+	GCS GCSConfig
+}
+
+// Open returns the Store cfg selects.
+func Open(cfg Config) (Store, error) {
+	switch cfg.Driver {
+	case "", DriverDisk:
+		if cfg.Dir == "" {
+			return nil, fmt.Errorf("blob directory required")
+		}
+		return NewDisk(cfg.Dir, cfg.URLPrefix, cfg.URLKey)
+	case DriverS3:
+		return NewS3(cfg.S3)
+	case DriverGCS:
+		return NewGCS(cfg.GCS)
+	default:
+		return nil, fmt.Errorf("unknown blob driver %q (want %s, %s or %s)", cfg.Driver, DriverDisk, DriverS3, DriverGCS)
+	}
+}
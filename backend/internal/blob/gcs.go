@@ -0,0 +1,45 @@
+package blob
+
+import "fmt"
+
+// GCSConfig describes a Google Cloud Storage bucket, reached through its
+// S3-interoperable XML API with an HMAC key created for a service account.
+type GCSConfig struct {
+	Bucket string
+
+	// AccessID and Secret are the service account's HMAC key.
+	AccessID string
+	Secret   string
+
+	// Endpoint defaults to "https://storage.googleapis.com"; set it to reach
+	// an emulator.
+	Endpoint string
+}
+
+// GCS stores blobs as objects in a Google Cloud Storage bucket. Requests are
+// signed as for S3, which GCS accepts with HMAC keys.
+type GCS struct {
+	*S3
+}
+
+// NewGCS returns a Store keeping blobs in the bucket cfg describes.
+func NewGCS(cfg GCSConfig) (*GCS, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("GCS bucket required")
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://storage.googleapis.com"
+	}
+	s3, err := NewS3(S3Config{
+		Endpoint:        cfg.Endpoint,
+		Region:          "auto",
+		Bucket:          cfg.Bucket,
+		AccessKeyID:     cfg.AccessID,
+		SecretAccessKey: cfg.Secret,
+		PathStyle:       true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &GCS{S3: s3}, nil
+}
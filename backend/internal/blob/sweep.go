@@ -0,0 +1,46 @@
+package blob
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Sweep deletes the blobs under prefix that nothing references any more,
+// such as the receipts of purged bills, and returns how many it deleted.
+//
+// inUse returns the keys still referenced. It is called after listing, so a
+// blob whose reference was recorded meanwhile is kept. Blobs modified within
+// grace are kept too, since a blob is written before its reference is.
+func Sweep(ctx context.Context, store Store, prefix string, grace time.Duration, inUse func(context.Context) (map[string]bool, error)) (int, error) {
+	cutoff := time.Now().Add(-grace)
+	var candidates []string
+	err := store.List(ctx, prefix, func(obj Object) error {
+		if obj.ModTime.Before(cutoff) {
+			candidates = append(candidates, obj.Key)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if len(candidates) == 0 {
+		return 0, nil
+	}
+
+	referenced, err := inUse(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load blob references: %w", err)
+	}
+	deleted := 0
+	for _, key := range candidates {
+		if referenced[key] {
+			continue
+		}
+		if err := store.Delete(ctx, key); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
@@ -0,0 +1,67 @@
+// Package fxrate supplies the exchange rates bills in a foreign currency are
+// converted at when they're created.
+package fxrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrUnknownCurrency is wrapped by the error returned for a currency a
+// Source has no rate for.
+var ErrUnknownCurrency = errors.New("no exchange rate for currency")
+
+// Source looks up current exchange rates.
+type Source interface {
+	// Rate returns what one unit of from is worth in to today.
+	Rate(ctx context.Context, from, to string) (float64, error)
+}
+
+// Table is a Source of fixed rates: the value of one unit of each currency,
+// by ISO 4217 code, in a reference currency common to all of them.
+type Table map[string]float64
+
+// ParseTable parses a comma-separated list of CODE=value pairs, such as
+// "USD=1,EUR=1.08,JPY=0.0067", into a Table.
+func ParseTable(s string) (Table, error) {
+	t := make(Table)
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		code, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return nil, fmt.Errorf("exchange rate %q is not CODE=value", field)
+		}
+		code = strings.ToUpper(strings.TrimSpace(code))
+		if len(code) != 3 {
+			return nil, fmt.Errorf("currency %q must be a three-letter ISO 4217 code", code)
+		}
+		rate, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || rate <= 0 {
+			return nil, fmt.Errorf("exchange rate for %s must be a positive number, got %q", code, value)
+		}
+		t[code] = rate
+	}
+	return t, nil
+}
+
+// Rate divides the value of from by the value of to.
+func (t Table) Rate(ctx context.Context, from, to string) (float64, error) {
+	if from == to {
+		return 1, nil
+	}
+	fromValue, ok := t[from]
+	if !ok {
+		return 0, fmt.Errorf("%w %s", ErrUnknownCurrency, from)
+	}
+	toValue, ok := t[to]
+	if !ok {
+		return 0, fmt.Errorf("%w %s", ErrUnknownCurrency, to)
+	}
+	return fromValue / toValue, nil
+}
@@ -0,0 +1,47 @@
+package fxrate
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestTable(t *testing.T) {
+	table, err := ParseTable(" usd=1, EUR=1.25,JPY=0.0067,")
+	if err != nil {
+		t.Fatalf("ParseTable failed: %v", err)
+	}
+
+	tests := []struct {
+		from, to string
+		want     float64
+	}{
+		{"EUR", "USD", 1.25},
+		{"USD", "EUR", 0.8},
+		{"JPY", "EUR", 0.00536},
+		{"GBP", "GBP", 1},
+	}
+	for _, tt := range tests {
+		got, err := table.Rate(context.Background(), tt.from, tt.to)
+		if err != nil {
+			t.Errorf("Rate(%s, %s) failed: %v", tt.from, tt.to, err)
+			continue
+		}
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Rate(%s, %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+
+	if _, err := table.Rate(context.Background(), "GBP", "USD"); !errors.Is(err, ErrUnknownCurrency) {
+		t.Errorf("expected ErrUnknownCurrency for GBP, got %v", err)
+	}
+}
+
+func TestParseTable_Invalid(t *testing.T) {
+	for _, s := range []string{"USD", "USD=", "USD=-1", "USD=0", "DOLLAR=1", "EUR=abc"} {
+		if _, err := ParseTable(s); err == nil {
+			t.Errorf("ParseTable(%q) succeeded, want an error", s)
+		}
+	}
+}
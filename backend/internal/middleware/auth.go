@@ -2,40 +2,44 @@ package middleware
 
 import (
 	"context"
-	"log/slog"
 	"strings"
 
 	"connectrpc.com/connect"
 	"github.com/mmynk/splitwiser/internal/auth"
+	"github.com/mmynk/splitwiser/internal/authctx"
+	"github.com/mmynk/splitwiser/pkg/logging"
 )
 
-// contextKey is a custom type for context keys to avoid collisions.
-type contextKey string
-
-const (
-	// UserIDKey is the context key for storing the authenticated user ID.
-	UserIDKey contextKey = "user_id"
-	// EmailKey is the context key for storing the authenticated user's email.
-	EmailKey contextKey = "email"
-)
-
-// GetUserID extracts the user ID from the context.
-// Returns empty string if not found.
-func GetUserID(ctx context.Context) string {
-	userID, _ := ctx.Value(UserIDKey).(string)
-	return userID
+// authLogger logs as the auth component, apart from the rest of the HTTP
+// logging, so token problems can be traced without every request.
+var authLogger = logging.Component("auth")
+
+// SessionTokenHeader carries a renewed access token on responses to
+// authenticated requests. Clients should use it in place of the token they
+// sent; see auth.SessionPolicy.
+const SessionTokenHeader = "X-Session-Token"
+
+// renewSession sets SessionTokenHeader on resp if the session policy renews
+// the token claims came from.
+func renewSession(jwtManager *auth.JWTManager, claims *auth.Claims, resp connect.AnyResponse) {
+	token, err := jwtManager.Renew(claims)
+	if err != nil {
+		authLogger.Error("auth: token renewal failed", "user_id", claims.UserID, "error", err)
+		return
+	}
+	if token != "" {
+		resp.Header().Set(SessionTokenHeader, token)
+	}
 }
 
-// GetEmail extracts the user email from the context.
-// Returns empty string if not found.
-func GetEmail(ctx context.Context) string {
-	email, _ := ctx.Value(EmailKey).(string)
-	return email
+// principalFromClaims builds the request principal from validated token claims.
+func principalFromClaims(claims *auth.Claims) authctx.Principal {
+	return authctx.Principal{ID: claims.UserID, Email: claims.Email}
 }
 
 // RequireAuth returns a middleware that validates JWT tokens and requires authentication.
 // It extracts the token from the Authorization header, validates it, and adds
-// the user ID and email to the request context.
+// the caller's principal to the request context (see authctx).
 func RequireAuth(jwtManager *auth.JWTManager) connect.UnaryInterceptorFunc {
 	return func(next connect.UnaryFunc) connect.UnaryFunc {
 		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
@@ -44,14 +48,14 @@ func RequireAuth(jwtManager *auth.JWTManager) connect.UnaryInterceptorFunc {
 			// Extract Authorization header
 			authHeader := req.Header().Get("Authorization")
 			if authHeader == "" {
-				slog.Warn("auth: missing token", "procedure", procedure)
+				authLogger.Warn("auth: missing token", "procedure", procedure)
 				return nil, connect.NewError(connect.CodeUnauthenticated, auth.ErrMissingToken)
 			}
 
 			// Parse Bearer token
 			parts := strings.Split(authHeader, " ")
 			if len(parts) != 2 || parts[0] != "Bearer" {
-				slog.Warn("auth: invalid token format", "procedure", procedure)
+				authLogger.Warn("auth: invalid token format", "procedure", procedure)
 				return nil, connect.NewError(connect.CodeUnauthenticated, auth.ErrInvalidToken)
 			}
 			tokenString := parts[1]
@@ -59,16 +63,23 @@ func RequireAuth(jwtManager *auth.JWTManager) connect.UnaryInterceptorFunc {
 			// Validate token
 			claims, err := jwtManager.Validate(tokenString)
 			if err != nil {
-				slog.Warn("auth: token validation failed", "procedure", procedure, "error", err)
+				authLogger.Warn("auth: token validation failed", "procedure", procedure, "error", err)
 				return nil, connect.NewError(connect.CodeUnauthenticated, err)
 			}
+			if err := jwtManager.Resolve(ctx, claims); err != nil {
+				authLogger.Error("auth: merged account lookup failed", "procedure", procedure, "user_id", claims.UserID, "error", err)
+				return nil, connect.NewError(connect.CodeInternal, err)
+			}
 
 			// Add user info to context
-			ctx = context.WithValue(ctx, UserIDKey, claims.UserID)
-			ctx = context.WithValue(ctx, EmailKey, claims.Email)
+			ctx = authctx.WithUser(ctx, principalFromClaims(claims))
 
-			// Call the next handler with enriched context
-			return next(ctx, req)
+			// Call the next handler with enriched context; activity keeps the session alive
+			resp, err := next(ctx, req)
+			if err == nil {
+				renewSession(jwtManager, claims, resp)
+			}
+			return resp, err
 		}
 	}
 }
@@ -80,6 +91,7 @@ func OptionalAuth(jwtManager *auth.JWTManager) connect.UnaryInterceptorFunc {
 	return func(next connect.UnaryFunc) connect.UnaryFunc {
 		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
 			// Extract Authorization header
+			var claims *auth.Claims
 			authHeader := req.Header().Get("Authorization")
 			if authHeader != "" {
 				// Parse Bearer token
@@ -88,17 +100,20 @@ func OptionalAuth(jwtManager *auth.JWTManager) connect.UnaryInterceptorFunc {
 					tokenString := parts[1]
 
 					// Validate token (ignore errors - optional auth)
-					claims, err := jwtManager.Validate(tokenString)
-					if err == nil {
+					if valid, err := jwtManager.Validate(tokenString); err == nil && jwtManager.Resolve(ctx, valid) == nil {
 						// Add user info to context only if valid
-						ctx = context.WithValue(ctx, UserIDKey, claims.UserID)
-						ctx = context.WithValue(ctx, EmailKey, claims.Email)
+						claims = valid
+						ctx = authctx.WithUser(ctx, principalFromClaims(claims))
 					}
 				}
 			}
 
 			// Call the next handler (with or without user context)
-			return next(ctx, req)
+			resp, err := next(ctx, req)
+			if err == nil && claims != nil {
+				renewSession(jwtManager, claims, resp)
+			}
+			return resp, err
 		}
 	}
 }
@@ -0,0 +1,151 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/mmynk/splitwiser/internal/auth"
+	"github.com/mmynk/splitwiser/internal/authctx"
+	"github.com/mmynk/splitwiser/internal/models"
+)
+
+const testSecret = "test-secret"
+
+// issuedToken signs a token for a session that started sessionAge ago,
+// issued tokenAge ago and expiring at expires.
+func issuedToken(t *testing.T, sessionAge, tokenAge time.Duration, expires time.Time) string {
+	t.Helper()
+	now := time.Now()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &auth.Claims{
+		UserID:       "user-1",
+		Email:        "alice@example.com",
+		SessionStart: now.Add(-sessionAge).Unix(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expires),
+			IssuedAt:  jwt.NewNumericDate(now.Add(-tokenAge)),
+		},
+	}).SignedString([]byte(testSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestRequireAuth_SessionRenewal(t *testing.T) {
+	policy := auth.SessionPolicy{IdleTimeout: time.Hour, RenewAfter: 10 * time.Minute, MaxAge: 8 * time.Hour}
+	jwtManager := auth.NewJWTManager(testSecret, policy)
+	now := time.Now()
+
+	call := func(token string, fail bool) (connect.AnyResponse, error) {
+		handler := RequireAuth(jwtManager)(func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+			if fail {
+				return nil, connect.NewError(connect.CodeInternal, errors.New("boom"))
+			}
+			return connect.NewResponse(&struct{}{}), nil
+		})
+		req := connect.NewRequest(&struct{}{})
+		req.Header().Set("Authorization", "Bearer "+token)
+		return handler(context.Background(), req)
+	}
+
+	tests := []struct {
+		name        string
+		token       string
+		fail        bool
+		wantRenewed bool
+	}{
+		{"fresh token", issuedToken(t, time.Minute, time.Minute, now.Add(59*time.Minute)), false, false},
+		{"active session", issuedToken(t, 2*time.Hour, 20*time.Minute, now.Add(40*time.Minute)), false, true},
+		{"failed request", issuedToken(t, 2*time.Hour, 20*time.Minute, now.Add(40*time.Minute)), true, false},
+		{"at maximum age", issuedToken(t, 7*time.Hour+30*time.Minute, 20*time.Minute, now.Add(30*time.Minute)), false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := call(tt.token, tt.fail)
+			if tt.fail {
+				if err == nil {
+					t.Fatal("expected the handler's error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			renewed := resp.Header().Get(SessionTokenHeader)
+			if (renewed != "") != tt.wantRenewed {
+				t.Fatalf("renewed token = %q, want renewed %v", renewed, tt.wantRenewed)
+			}
+			if renewed == "" {
+				return
+			}
+			old, _ := jwtManager.Validate(tt.token)
+			claims, err := jwtManager.Validate(renewed)
+			if err != nil {
+				t.Fatalf("renewed token invalid: %v", err)
+			}
+			if claims.UserID != "user-1" || claims.SessionStart != old.SessionStart || !claims.ExpiresAt.After(old.ExpiresAt.Time) {
+				t.Errorf("renewed claims = %+v, want the same user and session with a later expiry than %v", claims, old.ExpiresAt)
+			}
+		})
+	}
+
+	// A session past the maximum age is rejected even if its token hasn't expired,
+	// e.g. because the policy was tightened.
+	if _, err := call(issuedToken(t, 9*time.Hour, time.Minute, now.Add(time.Hour)), false); connect.CodeOf(err) != connect.CodeUnauthenticated {
+		t.Errorf("session past maximum age: code = %v, want Unauthenticated", connect.CodeOf(err))
+	}
+}
+
+func TestJWTManager_SessionExpiry(t *testing.T) {
+	jwtManager := auth.NewJWTManager(testSecret, auth.SessionPolicy{IdleTimeout: time.Hour, MaxAge: 30 * time.Minute})
+	token, err := jwtManager.Generate(&models.User{ID: "user-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := jwtManager.Validate(token)
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	// The maximum age caps the first token too.
+	if got := time.Unix(claims.SessionStart, 0).Add(30 * time.Minute); !claims.ExpiresAt.Equal(got) {
+		t.Errorf("expires at %v, want %v", claims.ExpiresAt, got)
+	}
+}
+
+// mergedAccounts maps merged user IDs to the accounts they were merged into.
+type mergedAccounts map[string]*models.User
+
+func (m mergedAccounts) MergedInto(ctx context.Context, userID string) (*models.User, error) {
+	return m[userID], nil
+}
+
+func TestRequireAuth_MergedAccount(t *testing.T) {
+	jwtManager := auth.NewJWTManager(testSecret, auth.SessionPolicy{IdleTimeout: time.Hour, RenewAfter: 10 * time.Minute})
+	jwtManager.FollowMerges(mergedAccounts{"user-1": {ID: "user-2", Email: "alice@work.com"}})
+
+	var principal authctx.Principal
+	handler := RequireAuth(jwtManager)(func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		principal, _ = authctx.FromContext(ctx)
+		return connect.NewResponse(&struct{}{}), nil
+	})
+	req := connect.NewRequest(&struct{}{})
+	req.Header().Set("Authorization", "Bearer "+issuedToken(t, time.Hour, 20*time.Minute, time.Now().Add(40*time.Minute)))
+	resp, err := handler(context.Background(), req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if principal.ID != "user-2" || principal.Email != "alice@work.com" {
+		t.Errorf("principal = %+v, want the account user-1 was merged into", principal)
+	}
+	claims, err := jwtManager.Validate(resp.Header().Get(SessionTokenHeader))
+	if err != nil {
+		t.Fatalf("renewed token invalid: %v", err)
+	}
+	if claims.UserID != "user-2" {
+		t.Errorf("renewed token is for %q, want user-2", claims.UserID)
+	}
+}
@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+)
+
+// recordingInterceptor appends name to *calls when it runs.
+func recordingInterceptor(name string, calls *[]string) connect.UnaryInterceptorFunc {
+	return func(next connect.UnaryFunc) connect.UnaryFunc {
+		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+			*calls = append(*calls, name)
+			return next(ctx, req)
+		}
+	}
+}
+
+func TestChain_Order(t *testing.T) {
+	var calls []string
+	c := Chain{}.
+		With(StageAuth, recordingInterceptor("auth", &calls)).
+		With(StageLogging, recordingInterceptor("logging", &calls)).
+		With(StageRecovery, recordingInterceptor("recovery", &calls)).
+		With(StageRateLimit, recordingInterceptor("rate-limit", &calls))
+
+	var handler connect.UnaryFunc = func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		return nil, nil
+	}
+	interceptors := c.Interceptors()
+	// Connect applies the first interceptor outermost.
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		handler = interceptors[i].WrapUnary(handler)
+	}
+	handler(context.Background(), connect.NewRequest(&struct{}{}))
+
+	want := []string{"recovery", "logging", "rate-limit", "auth"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("call order = %v, want %v", calls, want)
+	}
+}
+
+func TestChain_WithDoesNotModifyBase(t *testing.T) {
+	base := NewChain()
+	withAuth := base.With(StageAuth, recordingInterceptor("auth", new([]string)))
+
+	if got := len(base.Interceptors()); got != 4 {
+		t.Errorf("base chain has %d interceptors, want 4", got)
+	}
+	if got := len(withAuth.Interceptors()); got != 5 {
+		t.Errorf("derived chain has %d interceptors, want 5", got)
+	}
+	if got := len(withAuth.With(StageLogging, nil).Interceptors()); got != 4 {
+		t.Errorf("chain with logging disabled has %d interceptors, want 4", got)
+	}
+}
+
+func TestRateLimiter_Allow(t *testing.T) {
+	now := time.Unix(1000, 0)
+	l := NewRateLimiter(1, 2)
+	l.now = func() time.Time { return now }
+
+	if !l.Allow("a") || !l.Allow("a") {
+		t.Fatal("expected burst of 2 to be allowed")
+	}
+	if l.Allow("a") {
+		t.Error("expected third request to be limited")
+	}
+	if !l.Allow("b") {
+		t.Error("expected a different client to have its own bucket")
+	}
+
+	now = now.Add(time.Second)
+	if !l.Allow("a") {
+		t.Error("expected a token to be refilled after one second")
+	}
+}
+
+func TestSlowRequestInterceptor(t *testing.T) {
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+	defer slog.SetDefault(prev)
+
+	handler := func(delay time.Duration) connect.UnaryFunc {
+		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+			RecordQuery(ctx, "SELECT id\n\tFROM bills", 2*time.Millisecond, nil)
+			RecordQuery(ctx, "UPDATE bills SET title = ?", time.Millisecond, errors.New("busy"))
+			time.Sleep(delay)
+			return nil, nil
+		}
+	}
+	interceptor := SlowRequestInterceptor(50 * time.Millisecond)
+
+	interceptor.WrapUnary(handler(0))(context.Background(), connect.NewRequest(&struct{}{}))
+	if buf.Len() != 0 {
+		t.Errorf("fast request logged:\n%s", buf.String())
+	}
+
+	interceptor.WrapUnary(handler(60*time.Millisecond))(context.Background(), connect.NewRequest(&struct{}{}))
+	out := buf.String()
+	for _, want := range []string{"Slow RPC", "query_count=2", `query="SELECT id FROM bills"`, "error=busy"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("slow request log missing %q:\n%s", want, out)
+		}
+	}
+
+	// Outside the interceptor there's nowhere to record to.
+	RecordQuery(context.Background(), "SELECT 1", time.Second, nil)
+}
@@ -3,64 +3,54 @@ package middleware
 import (
 	"context"
 	"errors"
-	"log/slog"
 	"time"
 
 	"connectrpc.com/connect"
-	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/mmynk/splitwiser/internal/authctx"
+	"github.com/mmynk/splitwiser/pkg/logging"
 )
 
-var (
-	rpcRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
-		Name: "splitwiser_requests_total",
-		Help: "Total number of RPC requests by procedure.",
-	}, []string{"procedure"})
+// logger logs as the http component; see pkg/logging.
+var logger = logging.Component("http")
 
-	rpcErrorsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
-		Name: "splitwiser_errors_total",
-		Help: "Total number of RPC errors by procedure and connect error code.",
-	}, []string{"procedure", "code"})
-)
-
-// LoggingInterceptor returns a Connect interceptor that logs every RPC call
-// and increments Prometheus counters for request and error rates.
+// LoggingInterceptor returns a Connect interceptor that logs every RPC call.
 func LoggingInterceptor() connect.UnaryInterceptorFunc {
 	return func(next connect.UnaryFunc) connect.UnaryFunc {
 		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
 			start := time.Now()
 			procedure := req.Spec().Procedure
-			userID := GetUserID(ctx) // empty if pre-auth
+			userID := authctx.UserID(ctx) // empty if pre-auth
+			requestID := GetRequestID(ctx)
 
 			resp, err := next(ctx, req)
 
 			duration := time.Since(start).Milliseconds()
-			rpcRequestsTotal.WithLabelValues(procedure).Inc()
 
 			if err != nil {
 				var connectErr *connect.Error
 				if errors.As(err, &connectErr) {
-					rpcErrorsTotal.WithLabelValues(procedure, connectErr.Code().String()).Inc()
-					slog.Warn("RPC error",
+					logger.Warn("RPC error",
 						"procedure", procedure,
 						"code", connectErr.Code(),
 						"error", connectErr.Message(),
 						"user_id", userID,
+						"request_id", requestID,
 						"duration_ms", duration,
 					)
 				} else {
-					rpcErrorsTotal.WithLabelValues(procedure, "unknown").Inc()
-					slog.Error("RPC error",
+					logger.Error("RPC error",
 						"procedure", procedure,
 						"error", err,
 						"user_id", userID,
+						"request_id", requestID,
 						"duration_ms", duration,
 					)
 				}
 			} else {
-				slog.Info("RPC ok",
+				logger.Info("RPC ok",
 					"procedure", procedure,
 					"user_id", userID,
+					"request_id", requestID,
 					"duration_ms", duration,
 				)
 			}
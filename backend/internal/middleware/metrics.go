@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+
+	"connectrpc.com/connect"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	rpcRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "splitwiser_requests_total",
+		Help: "Total number of RPC requests by procedure.",
+	}, []string{"procedure"})
+
+	rpcErrorsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "splitwiser_errors_total",
+		Help: "Total number of RPC errors by procedure and connect error code.",
+	}, []string{"procedure", "code"})
+)
+
+// MetricsInterceptor returns a Connect interceptor that increments Prometheus
+// counters for request and error rates.
+func MetricsInterceptor() connect.UnaryInterceptorFunc {
+	return func(next connect.UnaryFunc) connect.UnaryFunc {
+		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+			procedure := req.Spec().Procedure
+
+			resp, err := next(ctx, req)
+
+			rpcRequestsTotal.WithLabelValues(procedure).Inc()
+			if err != nil {
+				code := "unknown"
+				var connectErr *connect.Error
+				if errors.As(err, &connectErr) {
+					code = connectErr.Code().String()
+				}
+				rpcErrorsTotal.WithLabelValues(procedure, code).Inc()
+			}
+
+			return resp, err
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"connectrpc.com/connect"
+)
+
+// ErrRateLimited is returned when a client exceeds its request rate.
+var ErrRateLimited = errors.New("too many requests")
+
+// idleBucketTTL is how long an unused client bucket is kept before it is pruned.
+const idleBucketTTL = 10 * time.Minute
+
+// bucket is a token bucket for one client.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// RateLimiter limits requests per client with a token bucket. Clients are
+// identified by the Fly-Client-IP header when present, else the peer address.
+type RateLimiter struct {
+	rate  float64 // tokens added per second
+	burst float64
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastPrune time.Time
+	now       func() time.Time
+}
+
+// NewRateLimiter allows each client rps requests per second with bursts of up to burst.
+func NewRateLimiter(rps float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:    rps,
+		burst:   float64(burst),
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Allow reports whether the client identified by key may make a request now.
+func (l *RateLimiter) Allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.lastPrune) > idleBucketTTL {
+		for k, b := range l.buckets {
+			if now.Sub(b.last) > idleBucketTTL {
+				delete(l.buckets, k)
+			}
+		}
+		l.lastPrune = now
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens += now.Sub(b.last).Seconds() * l.rate
+	if b.tokens > l.burst {
+		b.tokens = l.burst
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// Interceptor returns a Connect interceptor that rejects requests over the
+// limit with CodeResourceExhausted.
+func (l *RateLimiter) Interceptor() connect.UnaryInterceptorFunc {
+	return func(next connect.UnaryFunc) connect.UnaryFunc {
+		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+			if !l.Allow(clientKey(req)) {
+				return nil, connect.NewError(connect.CodeResourceExhausted, ErrRateLimited)
+			}
+			return next(ctx, req)
+		}
+	}
+}
+
+// clientKey identifies the client making req for rate limiting.
+func clientKey(req connect.AnyRequest) string {
+	if ip := req.Header().Get("Fly-Client-IP"); ip != "" {
+		return ip
+	}
+	addr := req.Peer().Addr
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+
+	"connectrpc.com/connect"
+)
+
+// RecoveryInterceptor returns a Connect interceptor that turns a panicking
+// handler into a CodeInternal error instead of crashing the server.
+func RecoveryInterceptor() connect.UnaryInterceptorFunc {
+	return func(next connect.UnaryFunc) connect.UnaryFunc {
+		return func(ctx context.Context, req connect.AnyRequest) (resp connect.AnyResponse, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					logger.Error("RPC panic",
+						"procedure", req.Spec().Procedure,
+						"request_id", GetRequestID(ctx),
+						"panic", r,
+						"stack", string(debug.Stack()),
+					)
+					resp, err = nil, connect.NewError(connect.CodeInternal, fmt.Errorf("internal error"))
+				}
+			}()
+			return next(ctx, req)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+
+	"connectrpc.com/connect"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the request ID on requests and responses.
+const RequestIDHeader = "X-Request-Id"
+
+// contextKey types the middleware's context keys, RequestIDKey and
+// queryLogKey, so they can't collide with other packages' keys.
+type contextKey string
+
+// RequestIDKey is the context key for storing the request ID.
+const RequestIDKey contextKey = "request_id"
+
+// maxRequestIDLength bounds client-supplied request IDs so they can't bloat logs.
+const maxRequestIDLength = 128
+
+// GetRequestID extracts the request ID from the context.
+// Returns empty string if not found.
+func GetRequestID(ctx context.Context) string {
+	id, _ := ctx.Value(RequestIDKey).(string)
+	return id
+}
+
+// RequestIDInterceptor returns a Connect interceptor that tags each request with
+// an ID, reusing the client's X-Request-Id when present, and echoes it back in
+// the response headers.
+func RequestIDInterceptor() connect.UnaryInterceptorFunc {
+	return func(next connect.UnaryFunc) connect.UnaryFunc {
+		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+			id := req.Header().Get(RequestIDHeader)
+			if id == "" || len(id) > maxRequestIDLength {
+				id = uuid.New().String()
+			}
+			ctx = context.WithValue(ctx, RequestIDKey, id)
+
+			resp, err := next(ctx, req)
+			if resp != nil {
+				resp.Header().Set(RequestIDHeader, id)
+			}
+			var connectErr *connect.Error
+			if errors.As(err, &connectErr) {
+				connectErr.Meta().Set(RequestIDHeader, id)
+			}
+			return resp, err
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"connectrpc.com/connect"
+)
+
+// queryLogKey is the context key for the per-request query log.
+const queryLogKey contextKey = "query_log"
+
+// maxLoggedQueries bounds how many queries a single request keeps for diagnostics.
+const maxLoggedQueries = 100
+
+// maxLoggedQueryLength truncates long statements in slow request logs.
+const maxLoggedQueryLength = 500
+
+type loggedQuery struct {
+	query    string
+	duration time.Duration
+	err      error
+}
+
+// queryLog collects the queries run while serving one request.
+type queryLog struct {
+	mu      sync.Mutex
+	queries []loggedQuery
+	dropped int
+}
+
+// RecordQuery notes a query run under ctx so it can be reported if the request
+// turns out slow. It does nothing outside SlowRequestInterceptor, and its
+// signature matches sqlite.QueryHook.
+func RecordQuery(ctx context.Context, query string, duration time.Duration, err error) {
+	log, _ := ctx.Value(queryLogKey).(*queryLog)
+	if log == nil {
+		return
+	}
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	if len(log.queries) >= maxLoggedQueries {
+		log.dropped++
+		return
+	}
+	log.queries = append(log.queries, loggedQuery{query: query, duration: duration, err: err})
+}
+
+// SlowRequestInterceptor returns a Connect interceptor that, when an RPC takes
+// longer than threshold, logs the queries it ran with their timings.
+func SlowRequestInterceptor(threshold time.Duration) connect.UnaryInterceptorFunc {
+	return func(next connect.UnaryFunc) connect.UnaryFunc {
+		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+			start := time.Now()
+			log := &queryLog{}
+			resp, err := next(context.WithValue(ctx, queryLogKey, log), req)
+
+			duration := time.Since(start)
+			if duration <= threshold {
+				return resp, err
+			}
+
+			log.mu.Lock()
+			defer log.mu.Unlock()
+			procedure := req.Spec().Procedure
+			requestID := GetRequestID(ctx)
+			var queryTime time.Duration
+			for _, q := range log.queries {
+				queryTime += q.duration
+			}
+			logger.Warn("Slow RPC",
+				"procedure", procedure,
+				"request_id", requestID,
+				"duration_ms", duration.Milliseconds(),
+				"query_count", len(log.queries)+log.dropped,
+				"query_ms", queryTime.Milliseconds(),
+			)
+			for i, q := range log.queries {
+				attrs := []any{
+					"procedure", procedure,
+					"request_id", requestID,
+					"index", i,
+					"duration_ms", float64(q.duration.Microseconds()) / 1000,
+					"query", compactQuery(q.query),
+				}
+				if q.err != nil {
+					attrs = append(attrs, "error", q.err)
+				}
+				logger.Warn("Slow RPC query", attrs...)
+			}
+			if log.dropped > 0 {
+				logger.Warn("Slow RPC queries not logged", "procedure", procedure, "request_id", requestID, "count", log.dropped)
+			}
+			return resp, err
+		}
+	}
+}
+
+// compactQuery collapses whitespace in a SQL statement and truncates it for logging.
+func compactQuery(query string) string {
+	query = strings.Join(strings.Fields(query), " ")
+	if len(query) > maxLoggedQueryLength {
+		query = query[:maxLoggedQueryLength] + "..."
+	}
+	return query
+}
@@ -0,0 +1,33 @@
+package models
+
+// Attachment is a file, such as a receipt photo, stored with a bill. Its
+// contents live in a blob store under BlobKey; the database keeps only the
+// metadata.
+type Attachment struct {
+	// ID is the unique identifier for the attachment (UUID format).
+	ID string
+
+	// BillID is the bill the attachment belongs to.
+	BillID string
+
+	// UploaderID is the user who uploaded it; its size counts toward their quota.
+	UploaderID string
+
+	// Name is the file name shown to users.
+	Name string
+
+	// ContentType is the MIME type of the contents.
+	ContentType string
+
+	// Size is the length of the contents in bytes.
+	Size int64
+
+	// SHA256 is the hex-encoded SHA-256 digest of the contents.
+	SHA256 string
+
+	// BlobKey locates the contents in the blob store.
+	BlobKey string
+
+	// CreatedAt is the Unix timestamp when the attachment was uploaded.
+	CreatedAt int64
+}
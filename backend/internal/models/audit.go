@@ -0,0 +1,37 @@
+package models
+
+// AuditAction is the kind of change an audit entry records.
+type AuditAction string
+
+const (
+	AuditCreate AuditAction = "create"
+	AuditUpdate AuditAction = "update"
+	AuditDelete AuditAction = "delete"
+)
+
+// AuditEntry records one change made through the store.
+type AuditEntry struct {
+	// ID is the unique identifier for the entry (UUID format).
+	ID string
+
+	// ActorID is the user who made the change; empty for changes made
+	// without a signed-in user, such as bank feed imports.
+	ActorID string
+
+	Action AuditAction
+
+	// EntityType names what changed, e.g. "bill" or "settlement".
+	EntityType string
+	EntityID   string
+
+	// GroupID is the group the entity belongs to, if any.
+	GroupID string
+
+	// Before and After are the entity as JSON around the change. Before is
+	// empty for creates and After for deletes.
+	Before string
+	After  string
+
+	// CreatedAt is the Unix timestamp when the change was made.
+	CreatedAt int64
+}
@@ -0,0 +1,11 @@
+package models
+
+// Category is an expense category a bill can be filed under, such as food or
+// rent. The standard categories are seeded with the database.
+type Category struct {
+	// ID is a stable slug, e.g. "food".
+	ID string
+
+	// Name is the name shown to users.
+	Name string
+}
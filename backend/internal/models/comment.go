@@ -0,0 +1,20 @@
+package models
+
+// Comment is a message left on a bill by one of the people on it, for
+// discussing the split ("I didn't have the beer").
+type Comment struct {
+	// ID is the unique identifier for the comment (UUID format).
+	ID string
+
+	// BillID is the bill the comment is on.
+	BillID string
+
+	// AuthorID is the user who wrote the comment.
+	AuthorID string
+
+	// Body is the comment's text.
+	Body string
+
+	// CreatedAt is the Unix timestamp when the comment was posted.
+	CreatedAt int64
+}
@@ -6,10 +6,65 @@ type GroupMember struct {
 	UserID      string // empty for guests
 }
 
+// GroupSettings controls how bills in a group are titled and attributed.
+type GroupSettings struct {
+	// DisableAutoTitle requires bills in the group to have an explicit title.
+	DisableAutoTitle bool
+	// TitleTemplate replaces the default auto-title when set. Supported
+	// placeholders are {date}, {payer} and {top_item}.
+	TitleTemplate string
+	// DefaultPayerIsCreator makes the bill's creator its payer when a bill
+	// is created without one.
+	DefaultPayerIsCreator bool
+	// SpendingCaps limits each member's monthly share of the group's bills,
+	// by display name. A bill that pushes a member over raises an alert.
+	SpendingCaps map[string]float64
+	// RoundingMode is used for bills created in the group without one.
+	RoundingMode RoundingMode
+	// PayerRotation has members take turns paying, and warns when a bill is
+	// paid by someone other than whose turn it is.
+	PayerRotation bool
+	// Currency is the ISO 4217 code balances are kept in. Bills in another
+	// currency count at the exchange rate locked when they were created.
+	// Empty counts every bill as it is.
+	Currency string
+}
+
 // Group represents a reusable participant list.
 type Group struct {
 	ID        string
 	Name      string
 	Members   []GroupMember
+	CreatorID string // user who created the group; empty for groups that predate tracking
+	CreatedAt int64
+	Settings  GroupSettings
+}
+
+// GroupArchive is a group with everything recorded in it, for moving the
+// group between instances.
+type GroupArchive struct {
+	Group           *Group
+	Bills           []*Bill
+	Settlements     []*Settlement
+	SettlementPlans []*SettlementPlan
+}
+
+// MonthlySpend is the total of a group's bills in one calendar month (UTC) and currency.
+type MonthlySpend struct {
+	Month     string // YYYY-MM
+	Currency  string // empty for bills created before currencies were tracked
+	BillCount int
+	Total     float64
+}
+
+// SpendingCapAlert records a bill that pushed a group member over their
+// monthly spending cap.
+type SpendingCapAlert struct {
+	BillID    string
+	GroupID   string
+	Member    string // display name
+	Month     string // YYYY-MM (UTC)
+	Spent     float64
+	Cap       float64
 	CreatedAt int64
 }
@@ -0,0 +1,25 @@
+package models
+
+// BillRevision is a version of a bill that an update replaced, kept so the
+// bill's history can be shown and the version restored.
+type BillRevision struct {
+	// ID is the unique identifier for the revision (UUID format).
+	ID string
+
+	// BillID is the bill this is a version of.
+	BillID string
+
+	// Revision numbers the updates of a bill from 1: revision n is the bill
+	// as it was before its nth update.
+	Revision int
+
+	// EditorID is the user whose update replaced this version; empty if the
+	// update wasn't made by a signed-in user.
+	EditorID string
+
+	// EditedAt is the Unix timestamp of that update.
+	EditedAt int64
+
+	// Bill is the version as it was stored, with its items and participants.
+	Bill *Bill
+}
@@ -25,4 +25,58 @@ type Settlement struct {
 
 	// Note is an optional description for the settlement.
 	Note string
+
+	// PlanID links the settlement to the settlement plan it pays toward, if any.
+	PlanID string
+}
+
+// PlanCadence is how often a settlement plan's installments fall due.
+type PlanCadence string
+
+const (
+	PlanCadenceWeekly   PlanCadence = "weekly"
+	PlanCadenceBiweekly PlanCadence = "biweekly"
+	PlanCadenceMonthly  PlanCadence = "monthly"
+)
+
+// SettlementPlan schedules repayment of a debt in installments. Settlements
+// recorded against the plan count toward its progress.
+type SettlementPlan struct {
+	// ID is the unique identifier for the plan (UUID format).
+	ID string
+
+	// GroupID is the group whose debt is being repaid.
+	GroupID string
+
+	// FromUserID is the display name of the person repaying.
+	FromUserID string
+
+	// ToUserID is the display name of the person being repaid.
+	ToUserID string
+
+	// Total is the full amount to be repaid.
+	Total float64
+
+	// InstallmentAmount is the amount due each period; the last installment
+	// takes whatever is left.
+	InstallmentAmount float64
+
+	// Cadence is how often installments fall due.
+	Cadence PlanCadence
+
+	// StartAt is the Unix timestamp when the first installment is due.
+	StartAt int64
+
+	// CreatedAt is the Unix timestamp when the plan was created.
+	CreatedAt int64
+
+	// CreatedBy is the display name of the member who created the plan.
+	CreatedBy string
+
+	// Note is an optional description for the plan.
+	Note string
+
+	// Paid is the sum of settlements recorded against the plan. Computed by
+	// the store; not persisted.
+	Paid float64
 }
@@ -0,0 +1,17 @@
+package models
+
+// SharePayment records that a participant has paid the payer back their
+// share of a bill. A participant without one still owes their share.
+type SharePayment struct {
+	// BillID is the bill the share is of.
+	BillID string
+
+	// Participant is the display name of the participant who paid.
+	Participant string
+
+	// PaidAt is the Unix timestamp when the share was marked as paid.
+	PaidAt int64
+
+	// MarkedBy is the user who marked the share as paid.
+	MarkedBy string
+}
@@ -3,21 +3,156 @@ package models
 // BillParticipant represents a participant on a bill, linking display name to an optional user account.
 type BillParticipant struct {
 	DisplayName string
-	UserID      string // empty for guests
+	UserID      string    // empty for guests
+	Shares      float64   // weight for SplitShares bills; 0 means one share
+	Amount      float64   // exact amount owed for SplitExact bills
+	Covered     CoverMode // share paid by the other participants; empty means none
+	Adjustment  float64   // amount added to (or, if negative, taken off) an even share
+}
+
+// CoverMode controls how much of a participant's share the others pay for.
+type CoverMode string
+
+const (
+	CoverNone      CoverMode = "none"
+	CoverTaxAndTip CoverMode = "tax_tip"
+	CoverAll       CoverMode = "all"
+)
+
+// TipSplitMode controls how a bill's tip is distributed among participants.
+type TipSplitMode string
+
+const (
+	TipSplitProportional TipSplitMode = "proportional"
+	TipSplitEqual        TipSplitMode = "equal"
+)
+
+// SplitType controls how item amounts are divided among their participants.
+type SplitType string
+
+const (
+	SplitEqual  SplitType = "equal"
+	SplitShares SplitType = "shares"
+	SplitExact  SplitType = "exact"
+)
+
+// RemainderMode controls how the part of a bill's subtotal not covered by items is divided.
+type RemainderMode string
+
+const (
+	RemainderEqual        RemainderMode = "equal"
+	RemainderProportional RemainderMode = "proportional"
+	RemainderPayer        RemainderMode = "payer"
+)
+
+// RoundingMode controls how a bill's split is rounded. Empty rounds each
+// amount to the bill's currency, if it has one.
+type RoundingMode string
+
+const (
+	RoundHalfUp  RoundingMode = "half_up"
+	RoundCash    RoundingMode = "cash"
+	RoundBankers RoundingMode = "bankers"
+)
+
+// DiscountType controls how a bill's discount is interpreted.
+type DiscountType string
+
+const (
+	DiscountAmount  DiscountType = "amount"
+	DiscountPercent DiscountType = "percent"
+)
+
+// FeeSplitMode controls how a fee is distributed among participants.
+type FeeSplitMode string
+
+const (
+	FeeSplitProportional FeeSplitMode = "proportional"
+	FeeSplitEqual        FeeSplitMode = "equal"
+)
+
+// Fee is a flat charge on a bill, such as a delivery or service fee.
+// Fees are included in Total but are not taxed.
+type Fee struct {
+	ID          string
+	Description string
+	Amount      float64
+	SplitMode   FeeSplitMode
 }
 
 // Bill represents a bill with items to be split among participants.
+// Total includes tax, tip and fees; Tip and Fees are tracked separately so
+// they can be split differently from tax.
 type Bill struct {
 	ID           string
 	Title        string
 	Items        []Item
 	Total        float64
 	Subtotal     float64
+	Tip          float64
+	TipSplitMode TipSplitMode
+	SplitType    SplitType
+	Discount     float64 // taken off Subtotal before tax; an amount or percent per DiscountType
+	DiscountType DiscountType
+	Fees         []Fee
+	Currency     string // ISO 4217 code; empty for bills created before currencies were tracked
 	Participants []BillParticipant
 	CreatedAt    int64
 	GroupID      string
 	PayerID      string
 	CreatorID    string
+	CategoryID   string // a Category's ID; empty when uncategorized
+
+	// NeedsAssignment marks a bill parked until its participants are known.
+	// Such bills may have no participants and are excluded from balances.
+	NeedsAssignment bool
+
+	// RemainderMode controls how subtotal not covered by items is divided.
+	RemainderMode RemainderMode
+
+	// TaxInclusive means item prices and Subtotal already include tax at
+	// TaxRate percent, as with VAT.
+	TaxInclusive bool
+	TaxRate      float64
+
+	// RoundingMode rounds the split and keeps the rounded shares adding up to
+	// the total.
+	RoundingMode RoundingMode
+
+	// DeletedAt is when the bill was moved to the trash (Unix seconds), or
+	// zero for a live bill. Trashed bills are hidden until restored or purged.
+	DeletedAt int64
+
+	// ArchivedAt is when the bill was archived for its age (Unix seconds), or
+	// zero if it hasn't been. Archived bills are left out of default listings
+	// but still count toward balances.
+	ArchivedAt int64
+
+	// LockedAt is when the bill was locked against edits (Unix seconds), or
+	// zero if it isn't locked. Bills lock once every share is paid, and only
+	// the payer can unlock them.
+	LockedAt int64
+
+	// ExchangeRate converts the bill's amounts into its group's currency, in
+	// group currency per unit of Currency. It's locked when the bill is
+	// created, so balances don't move with the market; zero when the bill
+	// needs no conversion.
+	ExchangeRate float64
+
+	// Note, MerchantName and Location describe the expense; each may be
+	// empty.
+	Note         string
+	MerchantName string
+	Location     string
+
+	// BillDate is when the expense happened (Unix seconds), which may be days
+	// before the bill was entered, or zero if it's CreatedAt.
+	BillDate int64
+
+	// EditorID is the user making an update, credited with the revision it
+	// keeps of the version it replaces; empty for updates no user made. It's
+	// set for each update and isn't stored with the bill.
+	EditorID string `json:"-"`
 }
 
 // Item represents a single line item on a bill.
@@ -27,8 +162,57 @@ type Item struct {
 	Description  string
 	Amount       float64
 	Participants []string // display names
+
+	// Shares overrides a participant's bill-level weight for this item (SplitShares only).
+	Shares map[string]float64
+
+	// Discount is taken off Amount and only reduces this item's participants' shares.
+	Discount float64
+
+	// Quantity and UnitPrice describe multi-unit items ("3x Beer $6"); Units
+	// maps each participant to the number of units they take.
+	Quantity  float64
+	UnitPrice float64
+	Units     map[string]float64
+
+	// Category marks a household item, shared by everyone on the bill, or a
+	// personal item, charged in full to Owner (a display name).
+	Category ItemCategory
+	Owner    string
+
+	// Origin holds what an importer parsed for the item, such as a line read
+	// off a receipt, before anyone edited it; nil for items entered by hand.
+	Origin *ItemOrigin
+}
+
+// ItemOrigin records an item's values as first parsed by an importer, kept
+// through later edits so corrections can be audited.
+type ItemOrigin struct {
+	Source      string // The importer that parsed the item, e.g. "receipt"
+	Description string
+	Amount      float64
+	Quantity    float64
+	UnitPrice   float64
+}
+
+// Edited reports whether the item no longer has the values it was parsed
+// with. Items entered by hand are never edited.
+func (i Item) Edited() bool {
+	if i.Origin == nil {
+		return false
+	}
+	return i.Description != i.Origin.Description || i.Amount != i.Origin.Amount ||
+		i.Quantity != i.Origin.Quantity || i.UnitPrice != i.Origin.UnitPrice
 }
 
+// ItemCategory sorts the items on a mixed receipt.
+type ItemCategory string
+
+const (
+	ItemHousehold ItemCategory = "household"
+	ItemPersonal  ItemCategory = "personal"
+)
+
 // PersonItem represents an item's share for one person.
 type PersonItem struct {
 	Description string
@@ -39,7 +223,10 @@ type PersonItem struct {
 type PersonSplit struct {
 	Participant string
 	Subtotal    float64
+	Discount    float64
 	Tax         float64
+	Tip         float64
+	Fees        float64
 	Total       float64
 	Items       []PersonItem
 }
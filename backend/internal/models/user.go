@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -41,3 +42,73 @@ func NewUser(email, displayName, passwordHash string) *User {
 		UpdatedAt:    now,
 	}
 }
+
+// NormalizeEmail trims an email address and folds it to lower case, so
+// addresses differing only in case or surrounding space are the same.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// CanonicalEmail identifies the account an address belongs to: the address
+// normalized and, with foldPlusTags, without a "+tag" after the local part,
+// so "Ann+Bills@X.com" is "ann@x.com".
+func CanonicalEmail(email string, foldPlusTags bool) string {
+	email = NormalizeEmail(email)
+	if !foldPlusTags {
+		return email
+	}
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok {
+		return email
+	}
+	if base, _, tagged := strings.Cut(local, "+"); tagged && base != "" {
+		return base + "@" + domain
+	}
+	return email
+}
+
+// UserEmail is an email address linked to a user account.
+// The account's primary address lives on User.Email; additional addresses
+// must be verified before they can be used to log in.
+type UserEmail struct {
+	UserID string
+	Email  string
+
+	// Primary is true only for the address stored on the User itself.
+	Primary bool
+
+	// Verified is true once the owner confirmed the address with its token.
+	Verified bool
+
+	// TokenHash is the SHA-256 hex digest of the pending verification token.
+	TokenHash string
+
+	// TokenExpiresAt is the Unix timestamp after which the token is rejected.
+	TokenExpiresAt int64
+
+	CreatedAt  int64
+	VerifiedAt int64
+}
+
+// Invitation lets a provisioned user set their password and activate their account.
+type Invitation struct {
+	// TokenHash is the SHA-256 hex digest of the invitation token.
+	TokenHash string
+
+	UserID    string
+	ExpiresAt int64
+	CreatedAt int64
+}
+
+// Usage summarizes what a user has created, for quota enforcement.
+type Usage struct {
+	// GroupsCreated is the number of existing groups the user created.
+	GroupsCreated int
+
+	// BillsCreated is the number of bills the user created since the
+	// requested cutoff.
+	BillsCreated int
+
+	// AttachmentBytes is the total size of attachments the user uploaded.
+	AttachmentBytes int64
+}
@@ -0,0 +1,37 @@
+package models
+
+// BillView is a named bill filter a user saved, such as "paid by me, last 30
+// days", for listing their bills with. Pinned views are shown as tabs.
+type BillView struct {
+	// ID is the unique identifier for the view (UUID format).
+	ID string
+
+	// UserID is the user who saved the view; only they see it.
+	UserID string
+
+	// Name labels the view's tab.
+	Name string
+
+	// GroupID limits the view to bills in this group; empty for any.
+	GroupID string
+
+	// Ungrouped limits the view to bills without a group.
+	Ungrouped bool
+
+	// LastDays limits the view to bills created in the last this many days,
+	// counted from when it's listed; 0 for any age.
+	LastDays int
+
+	// PaidByMe limits the view to bills UserID paid.
+	PaidByMe bool
+
+	// CategoryID limits the view to bills filed under this category; empty
+	// for any.
+	CategoryID string
+
+	// Pinned views are shown as tabs above the bill list.
+	Pinned bool
+
+	// CreatedAt is the Unix timestamp when the view was saved.
+	CreatedAt int64
+}
@@ -0,0 +1,47 @@
+package models
+
+// GroupWebhook notifies an external URL when a member's debt in a group
+// crosses a threshold.
+type GroupWebhook struct {
+	// ID is the unique identifier for the webhook (UUID format).
+	ID string
+
+	// GroupID is the group whose balances are watched.
+	GroupID string
+
+	// URL receives a JSON POST for every threshold crossing.
+	URL string
+
+	// Threshold is the debt above which a member counts as over the threshold.
+	Threshold float64
+
+	// CreatedAt is the Unix timestamp when the webhook was created.
+	CreatedAt int64
+
+	// CreatedBy is the user ID of whoever registered the webhook.
+	CreatedBy string
+
+	// OverThreshold lists the display names of members whose debt was above
+	// Threshold when balances were last evaluated. Crossings are detected
+	// against it.
+	OverThreshold []string
+}
+
+// AccountWebhook notifies an external URL of bill and settlement changes
+// involving one user, across all of their groups.
+type AccountWebhook struct {
+	// ID is the unique identifier for the webhook (UUID format).
+	ID string
+
+	// UserID is the user whose changes are delivered.
+	UserID string
+
+	// URL receives a signed JSON POST for every change.
+	URL string
+
+	// Secret keys the HMAC signature on each delivery.
+	Secret string
+
+	// CreatedAt is the Unix timestamp when the webhook was created.
+	CreatedAt int64
+}
@@ -0,0 +1,26 @@
+// Package notify delivers notices to users outside the app, such as by email.
+package notify
+
+import (
+	"context"
+
+	"github.com/mmynk/splitwiser/pkg/logging"
+)
+
+// logger logs as the notify component; see pkg/logging.
+var logger = logging.Component("notify")
+
+// Notifier sends a notice to an email address.
+type Notifier interface {
+	Notify(ctx context.Context, to, subject, body string) error
+}
+
+// LogNotifier is a Notifier that writes notices to the log.
+// Useful for development and self-hosted setups without outbound email.
+type LogNotifier struct{}
+
+// Notify logs the notice for the given address.
+func (LogNotifier) Notify(ctx context.Context, to, subject, body string) error {
+	logger.Info("Notification", "email", to, "subject", subject, "body", body)
+	return nil
+}
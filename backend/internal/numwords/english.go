@@ -0,0 +1,69 @@
+package numwords
+
+var englishSmall = [...]string{
+	"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine",
+	"ten", "eleven", "twelve", "thirteen", "fourteen", "fifteen", "sixteen", "seventeen", "eighteen", "nineteen",
+}
+
+var englishTens = [...]string{"", "", "twenty", "thirty", "forty", "fifty", "sixty", "seventy", "eighty", "ninety"}
+
+var englishScales = [...]string{"", "thousand", "million", "billion", "trillion"}
+
+var english = &language{
+	number:   englishNumber,
+	counting: englishNumber,
+	of:       plainOf,
+	plural:   func(n int64) bool { return n != 1 },
+	and:      "and",
+	minus:    "minus",
+	point:    "point",
+	digits:   [10]string(englishSmall[:10]),
+	currencies: map[string]currencyNames{
+		"USD": {unit{"dollar", "dollars"}, unit{"cent", "cents"}},
+		"CAD": {unit{"Canadian dollar", "Canadian dollars"}, unit{"cent", "cents"}},
+		"AUD": {unit{"Australian dollar", "Australian dollars"}, unit{"cent", "cents"}},
+		"NZD": {unit{"New Zealand dollar", "New Zealand dollars"}, unit{"cent", "cents"}},
+		"EUR": {unit{"euro", "euros"}, unit{"cent", "cents"}},
+		"GBP": {unit{"pound", "pounds"}, unit{"penny", "pence"}},
+		"CHF": {unit{"Swiss franc", "Swiss francs"}, unit{"centime", "centimes"}},
+		"JPY": {unit{"yen", "yen"}, unit{}},
+		"KRW": {unit{"won", "won"}, unit{}},
+		"CNY": {unit{"yuan", "yuan"}, unit{"fen", "fen"}},
+		"INR": {unit{"rupee", "rupees"}, unit{"paisa", "paise"}},
+		"MXN": {unit{"peso", "pesos"}, unit{"centavo", "centavos"}},
+		"BRL": {unit{"real", "reais"}, unit{"centavo", "centavos"}},
+		"SEK": {unit{"krona", "kronor"}, unit{"öre", "öre"}},
+		"BHD": {unit{"dinar", "dinars"}, unit{"fils", "fils"}},
+		"KWD": {unit{"dinar", "dinars"}, unit{"fils", "fils"}},
+	},
+	fractions: map[int]unit{
+		2: {"hundredth", "hundredths"},
+		3: {"thousandth", "thousandths"},
+		4: {"ten-thousandth", "ten-thousandths"},
+	},
+}
+
+// englishNumber spells n in short-scale American English, e.g. "one hundred
+// twenty-three thousand four hundred fifty-six".
+func englishNumber(n int64) string {
+	return spellGroups(n, englishBelow1000, func(g int64, power int) string {
+		return englishBelow1000(g) + " " + englishScales[power]
+	})
+}
+
+func englishBelow1000(n int64) string {
+	if n < 20 {
+		return englishSmall[n]
+	}
+	if n < 100 {
+		if n%10 == 0 {
+			return englishTens[n/10]
+		}
+		return englishTens[n/10] + "-" + englishSmall[n%10]
+	}
+	words := englishSmall[n/100] + " hundred"
+	if n%100 != 0 {
+		words += " " + englishBelow1000(n%100)
+	}
+	return words
+}
@@ -0,0 +1,127 @@
+package numwords
+
+import (
+	"math"
+	"strings"
+)
+
+var frenchSmall = [...]string{
+	"zéro", "un", "deux", "trois", "quatre", "cinq", "six", "sept", "huit", "neuf",
+	"dix", "onze", "douze", "treize", "quatorze", "quinze", "seize", "dix-sept", "dix-huit", "dix-neuf",
+}
+
+var frenchTens = [...]string{"", "", "vingt", "trente", "quarante", "cinquante", "soixante"}
+
+// frenchScales names the powers of a thousand from a million up, which are
+// nouns and take a plural.
+var frenchScales = map[int]unit{
+	2: {"million", "millions"},
+	3: {"milliard", "milliards"},
+	4: {"billion", "billions"},
+}
+
+var french = &language{
+	number:   frenchNumber,
+	counting: frenchNumber,
+	// "un million d'euros", "deux millions de dollars"
+	of: func(n int64, words, name string) string {
+		if n >= 1e6 && n%1e6 == 0 {
+			if strings.ContainsRune("aeiouyéèêàâîôû", []rune(strings.ToLower(name))[0]) {
+				return words + " d'" + name
+			}
+			return words + " de " + name
+		}
+		return words + " " + name
+	},
+	plural: func(n int64) bool { return n >= 2 },
+	and:    "et",
+	minus:  "moins",
+	point:  "virgule",
+	digits: [10]string(frenchSmall[:10]),
+	currencies: map[string]currencyNames{
+		"EUR": {unit{"euro", "euros"}, unit{"centime", "centimes"}},
+		"USD": {unit{"dollar", "dollars"}, unit{"cent", "cents"}},
+		"CAD": {unit{"dollar canadien", "dollars canadiens"}, unit{"cent", "cents"}},
+		"CHF": {unit{"franc suisse", "francs suisses"}, unit{"centime", "centimes"}},
+	},
+	fractions: map[int]unit{
+		2: {"centième", "centièmes"},
+		3: {"millième", "millièmes"},
+		4: {"dix-millième", "dix-millièmes"},
+	},
+}
+
+// frenchNumber spells n in French with the traditional spelling, e.g.
+// "quatre-vingt mille deux cent un".
+func frenchNumber(n int64) string {
+	if n == 0 {
+		return frenchSmall[0]
+	}
+	var parts []string
+	for power := 4; power >= 2; power-- {
+		g := n / int64(math.Pow10(3*power)) % 1000
+		switch {
+		case g == 1:
+			parts = append(parts, "un "+frenchScales[power].one)
+		case g > 1:
+			parts = append(parts, frenchBelow1000(g)+" "+frenchScales[power].many)
+		}
+	}
+	switch th := n / 1000 % 1000; {
+	case th == 1:
+		parts = append(parts, "mille")
+	case th > 1:
+		// "Vingt" and "cent" take no plural before "mille".
+		words := frenchBelow1000(th)
+		if strings.HasSuffix(words, "vingts") || strings.HasSuffix(words, "cents") {
+			words = strings.TrimSuffix(words, "s")
+		}
+		parts = append(parts, words+" mille")
+	}
+	if r := n % 1000; r > 0 {
+		parts = append(parts, frenchBelow1000(r))
+	}
+	return strings.Join(parts, " ")
+}
+
+func frenchBelow1000(n int64) string {
+	h, r := n/100, n%100
+	if h == 0 {
+		return frenchBelow100(r)
+	}
+	words := "cent"
+	if h > 1 {
+		words = frenchSmall[h] + " cent"
+		if r == 0 {
+			words += "s"
+		}
+	}
+	if r > 0 {
+		words += " " + frenchBelow100(r)
+	}
+	return words
+}
+
+func frenchBelow100(n int64) string {
+	t, u := n/10, n%10
+	switch {
+	case n < 20:
+		return frenchSmall[n]
+	case t <= 6 && u == 0:
+		return frenchTens[t]
+	case t <= 6 && u == 1:
+		return frenchTens[t] + " et un"
+	case t <= 6:
+		return frenchTens[t] + "-" + frenchSmall[u]
+	case t == 7 && u == 1:
+		return "soixante et onze"
+	case t == 7:
+		return "soixante-" + frenchSmall[10+u]
+	case t == 8 && u == 0:
+		return "quatre-vingts"
+	case t == 8:
+		return "quatre-vingt-" + frenchSmall[u]
+	default:
+		return "quatre-vingt-" + frenchSmall[10+u]
+	}
+}
@@ -0,0 +1,99 @@
+package numwords
+
+import (
+	"math"
+	"strings"
+)
+
+var germanSmall = [...]string{
+	"null", "eins", "zwei", "drei", "vier", "fünf", "sechs", "sieben", "acht", "neun",
+	"zehn", "elf", "zwölf", "dreizehn", "vierzehn", "fünfzehn", "sechzehn", "siebzehn", "achtzehn", "neunzehn",
+}
+
+var germanTens = [...]string{"", "", "zwanzig", "dreißig", "vierzig", "fünfzig", "sechzig", "siebzig", "achtzig", "neunzig"}
+
+// germanScales names the powers of a thousand from a million up, which are
+// nouns and written apart.
+var germanScales = map[int]unit{
+	2: {"Million", "Millionen"},
+	3: {"Milliarde", "Milliarden"},
+	4: {"Billion", "Billionen"},
+}
+
+var german = &language{
+	number:   germanNumber,
+	counting: func(n int64) string { return germanPrefix(germanNumber(n)) },
+	of:       plainOf,
+	plural:   func(n int64) bool { return n != 1 },
+	and:      "und",
+	minus:    "minus",
+	point:    "Komma",
+	digits:   [10]string(germanSmall[:10]),
+	currencies: map[string]currencyNames{
+		"EUR": {unit{"Euro", "Euro"}, unit{"Cent", "Cent"}},
+		"USD": {unit{"Dollar", "Dollar"}, unit{"Cent", "Cent"}},
+		"GBP": {unit{"Pfund", "Pfund"}, unit{"Penny", "Pence"}},
+		"CHF": {unit{"Franken", "Franken"}, unit{"Rappen", "Rappen"}},
+		"JPY": {unit{"Yen", "Yen"}, unit{}},
+	},
+	fractions: map[int]unit{
+		2: {"Hundertstel", "Hundertstel"},
+		3: {"Tausendstel", "Tausendstel"},
+		4: {"Zehntausendstel", "Zehntausendstel"},
+	},
+}
+
+// germanNumber spells n in German: below a million as one word, e.g.
+// "dreiundzwanzigtausendvierhundertfünfzig", with millions and up apart.
+func germanNumber(n int64) string {
+	if n == 0 {
+		return germanSmall[0]
+	}
+	var parts []string
+	for power := 4; power >= 2; power-- {
+		g := n / int64(math.Pow10(3*power)) % 1000
+		switch {
+		case g == 1:
+			parts = append(parts, "eine "+germanScales[power].one)
+		case g > 1:
+			parts = append(parts, germanPrefix(germanBelow1000(g))+" "+germanScales[power].many)
+		}
+	}
+	if rest := n % 1e6; rest != 0 {
+		var words string
+		if th := rest / 1000; th > 0 {
+			words = germanPrefix(germanBelow1000(th)) + "tausend"
+		}
+		if r := rest % 1000; r > 0 {
+			words += germanBelow1000(r)
+		}
+		parts = append(parts, words)
+	}
+	return strings.Join(parts, " ")
+}
+
+// germanPrefix turns a final "eins" into the "ein" used before a noun or
+// "hundert" and "tausend".
+func germanPrefix(words string) string {
+	if s, ok := strings.CutSuffix(words, "eins"); ok {
+		return s + "ein"
+	}
+	return words
+}
+
+func germanBelow1000(n int64) string {
+	var words string
+	if h := n / 100; h > 0 {
+		words = germanPrefix(germanSmall[h]) + "hundert"
+	}
+	switch r := n % 100; {
+	case r == 0:
+	case r < 20:
+		words += germanSmall[r]
+	case r%10 == 0:
+		words += germanTens[r/10]
+	default:
+		words += germanPrefix(germanSmall[r%10]) + "und" + germanTens[r/10]
+	}
+	return words
+}
@@ -0,0 +1,152 @@
+// Package numwords spells out numbers and money amounts in words, such as
+// "twenty-two dollars and fifty cents", for screen readers and chat bots.
+//
+// English, German, Spanish and French are supported; other locales are
+// spelled in English. Currencies without a name in the locale are named by
+// their ISO 4217 code.
+package numwords
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/mmynk/splitwiser/pkg/splitmath"
+)
+
+// maxSpelled bounds the numbers spelled out; larger ones are written in
+// digits.
+const maxSpelled = 1e15
+
+// unit is a currency unit's singular and plural name.
+type unit struct{ one, many string }
+
+// currencyNames names a currency's major and minor units.
+type currencyNames struct{ major, minor unit }
+
+// language spells numbers in one language.
+type language struct {
+	// number spells n on its own, e.g. "one", "eins".
+	number func(n int64) string
+	// counting spells n before a unit's name, e.g. "ein" in "ein Euro".
+	counting func(n int64) string
+	// of joins counted words to a unit's name, e.g. "un million d'euros".
+	of func(n int64, words, name string) string
+	// plural reports whether n of a unit take its plural name.
+	plural func(n int64) bool
+
+	and, minus, point string
+	digits            [10]string
+	currencies        map[string]currencyNames
+	// fractions names minor units by their number of decimal places, for
+	// currencies without names.
+	fractions map[int]unit
+}
+
+var languages = map[string]*language{
+	"en": english,
+	"de": german,
+	"es": spanish,
+	"fr": french,
+}
+
+// lookup returns the language for a BCP 47 locale tag, English by default.
+func lookup(locale string) *language {
+	tag := strings.ToLower(strings.ReplaceAll(locale, "_", "-"))
+	lang, _, _ := strings.Cut(tag, "-")
+	if l, ok := languages[lang]; ok {
+		return l
+	}
+	return english
+}
+
+// Number spells n in the language of locale, e.g. "one hundred twenty-three".
+func Number(n int64, locale string) string {
+	l := lookup(locale)
+	if n < 0 {
+		return l.minus + " " + Number(-n, locale)
+	}
+	if n >= maxSpelled {
+		return strconv.FormatInt(n, 10)
+	}
+	return l.number(n)
+}
+
+// Amount spells amount, rounded to the currency's minor unit, in the
+// language of locale, e.g. "twenty-two dollars and fifty cents" for 22.5 USD
+// in "en". Without a currency the amount is spelled as a decimal number,
+// e.g. "twenty-two point five".
+func Amount(amount float64, currency, locale string) string {
+	l := lookup(locale)
+	digits := splitmath.MinorUnits(currency)
+	scale := math.Pow10(digits)
+	units := math.Round(math.Abs(amount) * scale)
+	if units/scale >= maxSpelled {
+		return splitmath.FormatAmount(amount, currency)
+	}
+	major, minor := int64(units/scale), int64(math.Mod(units, scale))
+
+	var words string
+	if currency == "" {
+		words = l.number(major)
+		if minor != 0 {
+			frac := strings.TrimRight(strconv.FormatInt(minor+int64(scale), 10)[1:], "0")
+			words += " " + l.point
+			for _, d := range frac {
+				words += " " + l.digits[d-'0']
+			}
+		}
+	} else {
+		names, ok := l.currencies[currency]
+		if !ok {
+			names = currencyNames{major: unit{currency, currency}, minor: l.fractions[digits]}
+		}
+		count := func(n int64, u unit) string {
+			name := u.one
+			if l.plural(n) {
+				name = u.many
+			}
+			return l.of(n, l.counting(n), name)
+		}
+		switch {
+		case minor == 0:
+			words = count(major, names.major)
+		case major == 0:
+			words = count(minor, names.minor)
+		default:
+			words = count(major, names.major) + " " + l.and + " " + count(minor, names.minor)
+		}
+	}
+	if amount < 0 && units != 0 {
+		words = l.minus + " " + words
+	}
+	return words
+}
+
+// spellGroups spells n by splitting it into groups of three digits, spelled
+// by group, and naming each group's scale with scale, from the thousands up.
+// Groups of zero are left out, and the rest joined by spaces.
+func spellGroups(n int64, group func(n int64) string, scale func(n int64, power int) string) string {
+	if n == 0 {
+		return group(0)
+	}
+	var parts []string
+	for power := 4; power >= 0; power-- {
+		p := int64(math.Pow10(3 * power))
+		g := n / p % 1000
+		if g == 0 {
+			continue
+		}
+		if power == 0 {
+			parts = append(parts, group(g))
+		} else {
+			parts = append(parts, scale(g, power))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// plainOf joins counted words to a unit name with a space.
+func plainOf(_ int64, words, name string) string {
+	return words + " " + name
+}
@@ -0,0 +1,79 @@
+package numwords
+
+import "testing"
+
+func TestNumber(t *testing.T) {
+	tests := []struct {
+		n      int64
+		locale string
+		want   string
+	}{
+		{0, "en", "zero"},
+		{15, "en", "fifteen"},
+		{42, "en-US", "forty-two"},
+		{100, "en", "one hundred"},
+		{123456, "en", "one hundred twenty-three thousand four hundred fifty-six"},
+		{2000001, "en", "two million one"},
+		{-7, "en", "minus seven"},
+		{1, "de", "eins"},
+		{21, "de", "einundzwanzig"},
+		{101, "de-DE", "einhunderteins"},
+		{123456, "de", "einhundertdreiundzwanzigtausendvierhundertsechsundfünfzig"},
+		{1000000, "de", "eine Million"},
+		{2500000, "de", "zwei Millionen fünfhunderttausend"},
+		{21, "es", "veintiuno"},
+		{100, "es", "cien"},
+		{101, "es", "ciento uno"},
+		{21000, "es", "veintiún mil"},
+		{1200000000, "es-MX", "mil doscientos millones"},
+		{71, "fr", "soixante et onze"},
+		{80, "fr", "quatre-vingts"},
+		{99, "fr", "quatre-vingt-dix-neuf"},
+		{200, "fr-CA", "deux cents"},
+		{80000, "fr", "quatre-vingt mille"},
+		{2000000, "fr", "deux millions"},
+		{42, "ja", "forty-two"},
+		{1e15, "en", "1000000000000000"},
+	}
+	for _, tt := range tests {
+		if got := Number(tt.n, tt.locale); got != tt.want {
+			t.Errorf("Number(%d, %q) = %q, want %q", tt.n, tt.locale, got, tt.want)
+		}
+	}
+}
+
+func TestAmount(t *testing.T) {
+	tests := []struct {
+		amount   float64
+		currency string
+		locale   string
+		want     string
+	}{
+		{22.5, "USD", "en", "twenty-two dollars and fifty cents"},
+		{1.01, "USD", "en", "one dollar and one cent"},
+		{0.5, "GBP", "en", "fifty pence"},
+		{0, "EUR", "en", "zero euros"},
+		{-3, "EUR", "en", "minus three euros"},
+		{-0.001, "EUR", "en", "zero euros"},
+		{1234.567, "JPY", "en", "one thousand two hundred thirty-five yen"},
+		{1.5, "BHD", "en", "one dinar and five hundred fils"},
+		{12.3, "PLN", "en", "twelve PLN and thirty hundredths"},
+		{22.5, "", "en", "twenty-two point five"},
+		{22.05, "", "en", "twenty-two point zero five"},
+		{1, "EUR", "de", "ein Euro"},
+		{101.01, "EUR", "de", "einhundertein Euro und ein Cent"},
+		{22.5, "CHF", "de-CH", "zweiundzwanzig Franken und fünfzig Rappen"},
+		{1, "USD", "es", "un dólar"},
+		{21.21, "EUR", "es", "veintiún euros con veintiún céntimos"},
+		{1000000, "EUR", "es", "un millón de euros"},
+		{1.5, "EUR", "fr", "un euro et cinquante centimes"},
+		{2000000, "EUR", "fr", "deux millions d'euros"},
+		{3000000, "USD", "fr", "trois millions de dollars"},
+		{1e15, "USD", "en", "USD 1000000000000000.00"},
+	}
+	for _, tt := range tests {
+		if got := Amount(tt.amount, tt.currency, tt.locale); got != tt.want {
+			t.Errorf("Amount(%v, %q, %q) = %q, want %q", tt.amount, tt.currency, tt.locale, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package numwords
+
+import "strings"
+
+var spanishSmall = [...]string{
+	"cero", "uno", "dos", "tres", "cuatro", "cinco", "seis", "siete", "ocho", "nueve",
+	"diez", "once", "doce", "trece", "catorce", "quince", "dieciséis", "diecisiete", "dieciocho", "diecinueve",
+	"veinte", "veintiuno", "veintidós", "veintitrés", "veinticuatro", "veinticinco", "veintiséis", "veintisiete", "veintiocho", "veintinueve",
+}
+
+var spanishTens = [...]string{"", "", "", "treinta", "cuarenta", "cincuenta", "sesenta", "setenta", "ochenta", "noventa"}
+
+var spanishHundreds = [...]string{
+	"", "ciento", "doscientos", "trescientos", "cuatrocientos", "quinientos", "seiscientos", "setecientos", "ochocientos", "novecientos",
+}
+
+var spanish = &language{
+	number:   spanishNumber,
+	counting: func(n int64) string { return spanishApocope(spanishNumber(n)) },
+	// "un millón de euros"
+	of: func(n int64, words, name string) string {
+		if n >= 1e6 && n%1e6 == 0 {
+			return words + " de " + name
+		}
+		return words + " " + name
+	},
+	plural: func(n int64) bool { return n != 1 },
+	and:    "con",
+	minus:  "menos",
+	point:  "coma",
+	digits: [10]string(spanishSmall[:10]),
+	currencies: map[string]currencyNames{
+		"EUR": {unit{"euro", "euros"}, unit{"céntimo", "céntimos"}},
+		"USD": {unit{"dólar", "dólares"}, unit{"centavo", "centavos"}},
+		"MXN": {unit{"peso", "pesos"}, unit{"centavo", "centavos"}},
+	},
+	fractions: map[int]unit{
+		2: {"centésimo", "centésimos"},
+		3: {"milésimo", "milésimos"},
+		4: {"diezmilésimo", "diezmilésimos"},
+	},
+}
+
+// spanishNumber spells n in Spanish on the long scale, where a billón is a
+// million millions, e.g. "mil doscientos millones".
+func spanishNumber(n int64) string {
+	if n == 0 {
+		return spanishSmall[0]
+	}
+	count := func(n int64, one, many string) string {
+		if n == 1 {
+			return "un " + one
+		}
+		return spanishApocope(spanishBelowMillion(n)) + " " + many
+	}
+	var parts []string
+	if b := n / 1e12; b > 0 {
+		parts = append(parts, count(b, "billón", "billones"))
+	}
+	if m := n / 1e6 % 1e6; m > 0 {
+		parts = append(parts, count(m, "millón", "millones"))
+	}
+	if r := n % 1e6; r > 0 {
+		parts = append(parts, spanishBelowMillion(r))
+	}
+	return strings.Join(parts, " ")
+}
+
+// spanishApocope shortens a final "uno" to the "un" used before a noun.
+func spanishApocope(words string) string {
+	if s, ok := strings.CutSuffix(words, "veintiuno"); ok {
+		return s + "veintiún"
+	}
+	if s, ok := strings.CutSuffix(words, "uno"); ok {
+		return s + "un"
+	}
+	return words
+}
+
+func spanishBelowMillion(n int64) string {
+	var parts []string
+	switch th := n / 1000; {
+	case th == 1:
+		parts = append(parts, "mil")
+	case th > 1:
+		parts = append(parts, spanishApocope(spanishBelow1000(th))+" mil")
+	}
+	if r := n % 1000; r > 0 {
+		parts = append(parts, spanishBelow1000(r))
+	}
+	return strings.Join(parts, " ")
+}
+
+func spanishBelow1000(n int64) string {
+	if n == 100 {
+		return "cien"
+	}
+	var parts []string
+	if h := n / 100; h > 0 {
+		parts = append(parts, spanishHundreds[h])
+	}
+	switch r := n % 100; {
+	case r == 0:
+	case r < 30:
+		parts = append(parts, spanishSmall[r])
+	case r%10 == 0:
+		parts = append(parts, spanishTens[r/10])
+	default:
+		parts = append(parts, spanishTens[r/10]+" y "+spanishSmall[r%10])
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,47 @@
+// Package ocr reads the text of receipt photos, through Tesseract on the
+// server or the Google Cloud Vision API, and parses it into the items and
+// amounts of a bill.
+//
+//	provider, err := ocr.Open(ocr.Config{Driver: ocr.DriverTesseract})
+//	text, err := provider.Text(ctx, image)
+//	receipt := ocr.ParseReceipt(text)
+package ocr
+
+import (
+	"context"
+	"fmt"
+)
+
+// Drivers Open accepts.
+const (
+	DriverTesseract = "tesseract"
+	DriverVision    = "vision"
+)
+
+// Provider recognizes the text in images.
+type Provider interface {
+	// Text returns the text in image, a JPEG, PNG or WebP photo or scan, line
+	// by line from the top.
+	Text(ctx context.Context, image []byte) (string, error)
+}
+
+// Config selects and configures a Provider.
+type Config struct {
+	// Driver is DriverTesseract or DriverVision.
+	Driver string
+
+	Tesseract TesseractConfig
+	Vision    VisionConfig
+}
+
+// Open returns the Provider cfg selects.
+func Open(cfg Config) (Provider, error) {
+	switch cfg.Driver {
+	case DriverTesseract:
+		return NewTesseract(cfg.Tesseract)
+	case DriverVision:
+		return NewVision(cfg.Vision)
+	default:
+		return nil, fmt.Errorf("unknown OCR driver %q (want %s or %s)", cfg.Driver, DriverTesseract, DriverVision)
+	}
+}
@@ -0,0 +1,153 @@
+package ocr
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseReceipt(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want *Receipt
+	}{
+		{
+			name: "diner",
+			text: `JOE'S DINER
+123 Main St
+Tel 555-0100
+2 x Burger        $21.00
+Fries              4.50 T
+Coke - 2.00
+Coupon            -3.00
+SUBTOTAL          24.50
+Sales Tax 8%       1.96
+TOTAL             26.46
+VISA **** 1234    26.46
+CHANGE             0.00`,
+			want: &Receipt{
+				Merchant: "JOE'S DINER",
+				Items: []Item{
+					{Description: "Burger", Quantity: 2, UnitPrice: 10.5, Amount: 21},
+					{Description: "Fries", Amount: 4.5},
+					{Description: "Coke", Amount: 2},
+				},
+				Subtotal: 24.5,
+				Discount: 3,
+				Tax:      1.96,
+				Total:    26.46,
+			},
+		},
+		{
+			name: "decimal commas and no totals",
+			text: `Bäckerei Schmidt
+Brezel          1,20
+Kaffee          3,50
+Kuchen      1.234,00`,
+			want: &Receipt{
+				Merchant: "Bäckerei Schmidt",
+				Items: []Item{
+					{Description: "Brezel", Amount: 1.2},
+					{Description: "Kaffee", Amount: 3.5},
+					{Description: "Kuchen", Amount: 1234},
+				},
+				Subtotal: 1238.7,
+				Total:    1238.7,
+			},
+		},
+		{
+			name: "tip written in after the total",
+			text: `Tipsy Taco
+Taco Plate   12.00
+Tax           1.00
+Amount Due   13.00
+Tip           2.60`,
+			want: &Receipt{
+				Merchant: "Tipsy Taco",
+				Items:    []Item{{Description: "Taco Plate", Amount: 12}},
+				Subtotal: 12,
+				Tax:      1,
+				Tip:      2.6,
+				Total:    13,
+			},
+		},
+		{
+			name: "nothing readable",
+			text: "\n  \n",
+			want: &Receipt{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseReceipt(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseReceipt =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVision(t *testing.T) {
+	image := []byte("fake image")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/images:annotate" || r.URL.Query().Get("key") != "test-key" {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(map[string]any{"error": map[string]any{"code": 403, "message": "bad key"}})
+			return
+		}
+		var req struct {
+			Requests []struct {
+				Image    struct{ Content string }
+				Features []struct{ Type string }
+			}
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Requests) != 1 {
+			t.Errorf("unexpected request: %v", err)
+		}
+		if got := req.Requests[0].Image.Content; got != base64.StdEncoding.EncodeToString(image) {
+			t.Errorf("image content = %q", got)
+		}
+		if got := req.Requests[0].Features; len(got) != 1 || got[0].Type != "DOCUMENT_TEXT_DETECTION" {
+			t.Errorf("features = %v", got)
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"responses": []any{map[string]any{"fullTextAnnotation": map[string]any{"text": "CAFE\nTOTAL 4.50\n"}}},
+		})
+	}))
+	defer server.Close()
+
+	provider, err := Open(Config{Driver: DriverVision, Vision: VisionConfig{APIKey: "test-key", Endpoint: server.URL}})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	text, err := provider.Text(context.Background(), image)
+	if err != nil {
+		t.Fatalf("Text failed: %v", err)
+	}
+	if text != "CAFE\nTOTAL 4.50\n" {
+		t.Errorf("Text = %q", text)
+	}
+
+	wrongKey, _ := NewVision(VisionConfig{APIKey: "other-key", Endpoint: server.URL})
+	_, err = wrongKey.Text(context.Background(), image)
+	if err == nil || !strings.Contains(err.Error(), "bad key") || strings.Contains(err.Error(), "other-key") {
+		t.Errorf("Text with a wrong key = %v, want the API's message without the key", err)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	if _, err := Open(Config{Driver: "paper"}); err == nil {
+		t.Error("expected an unknown driver to fail")
+	}
+	if _, err := Open(Config{Driver: DriverVision}); err == nil {
+		t.Error("expected Vision without a key to fail")
+	}
+	if _, err := Open(Config{Driver: DriverTesseract, Tesseract: TesseractConfig{Path: "/nonexistent/tesseract"}}); err == nil {
+		t.Error("expected a missing tesseract binary to fail")
+	}
+}
@@ -0,0 +1,190 @@
+package ocr
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Receipt is what ParseReceipt read from a receipt's text. Amounts it
+// couldn't find are 0.
+type Receipt struct {
+	// Merchant is the first line with letters and no amount, usually the
+	// shop's name.
+	Merchant string
+
+	Items []Item
+
+	// Subtotal is the receipt's subtotal line, or failing that the sum of
+	// its items.
+	Subtotal float64
+
+	// Discount adds up the coupons and other reductions listed before the
+	// total, as a positive amount.
+	Discount float64
+
+	Tax float64
+	Tip float64
+
+	// Total is the receipt's total line, or failing that the subtotal less
+	// the discount plus tax and tip.
+	Total float64
+}
+
+// Item is one purchased line of a receipt.
+type Item struct {
+	Description string
+	Quantity    float64 // 0 if the line doesn't say
+	UnitPrice   float64 // Amount / Quantity, if Quantity is set
+	Amount      float64
+}
+
+// amountPattern matches the price at the end of a receipt line: digits with
+// two decimals, a point or comma before them, optionally grouped in
+// thousands. A minus sign may come before or after it, a currency symbol
+// before it, and a tax code such as "T" or a currency code after it.
+var amountPattern = regexp.MustCompile(`(-?)(?:[$€£¥]\s*)?(\d{1,3}(?:[.,]\d{3})*[.,]\d{2}|\d+[.,]\d{2})(-?)\s*[A-Z*]{0,3}$`)
+
+// quantityPattern matches a count before an item's description: "2 x Beer",
+// "2x Beer" or "2 Beer".
+var quantityPattern = regexp.MustCompile(`^(\d{1,3})\s*(?:[xX×]\s*|\s)(\D.*)$`)
+
+// Line kinds ParseReceipt recognizes by their words, checked in this order.
+var (
+	subtotalWords = []string{"subtotal", "sub total", "sub-total", "net total"}
+	totalWords    = []string{"total", "amount due", "balance due", "to pay"}
+	taxWords      = []string{"tax", "vat", "gst", "hst", "pst", "mwst"}
+	tipWords      = []string{"tip", "gratuity"}
+	discountWords = []string{"discount", "coupon", "savings", "promo"}
+	// Payment lines follow the total and say nothing about what was bought.
+	paymentWords = []string{"change", "cash", "visa", "mastercard", "amex", "card", "debit", "credit", "tender", "paid", "payment"}
+)
+
+// ParseReceipt reads the merchant, items and amounts from a receipt's text,
+// as a Provider recognized it. Lines before the subtotal or total are items;
+// the subtotal, tax, tip and total lines are found by their words. It does
+// its best with what it's given: a line it can't make sense of is skipped,
+// so the result is a draft to be checked.
+func ParseReceipt(text string) *Receipt {
+	r := &Receipt{}
+	var itemSum float64
+	totalSeen := false
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		description, amount, ok := splitAmount(line)
+		if !ok {
+			if r.Merchant == "" && strings.IndexFunc(line, isLetter) >= 0 {
+				r.Merchant = line
+			}
+			continue
+		}
+		if totalSeen {
+			// Payment and change lines; a tip written in after the total
+			// still counts.
+			if hasWord(description, tipWords) && r.Tip == 0 {
+				r.Tip = math.Abs(amount)
+			}
+			continue
+		}
+
+		switch {
+		case hasWord(description, subtotalWords):
+			r.Subtotal = amount
+		case hasWord(description, totalWords):
+			r.Total = amount
+			totalSeen = true
+		case hasWord(description, taxWords):
+			r.Tax += amount
+		case hasWord(description, tipWords):
+			r.Tip += amount
+		case hasWord(description, paymentWords):
+		case amount < 0 || hasWord(description, discountWords):
+			r.Discount += math.Abs(amount)
+		case r.Subtotal == 0:
+			item := Item{Description: description, Amount: amount}
+			if m := quantityPattern.FindStringSubmatch(description); m != nil {
+				quantity, _ := strconv.ParseFloat(m[1], 64)
+				if quantity > 0 {
+					item.Description = strings.TrimSpace(m[2])
+					item.Quantity = quantity
+					item.UnitPrice = round2(amount / quantity)
+				}
+			}
+			if item.Description != "" {
+				r.Items = append(r.Items, item)
+				itemSum += amount
+			}
+		}
+	}
+
+	if r.Subtotal == 0 {
+		r.Subtotal = round2(itemSum)
+	}
+	if r.Total == 0 {
+		r.Total = round2(r.Subtotal - r.Discount + r.Tax + r.Tip)
+	}
+	return r
+}
+
+// splitAmount splits the amount off the end of a receipt line, returning the
+// rest of the line as the description.
+func splitAmount(line string) (string, float64, bool) {
+	m := amountPattern.FindStringSubmatchIndex(line)
+	if m == nil {
+		return "", 0, false
+	}
+	amount, ok := parseAmount(line[m[4]:m[5]])
+	if !ok {
+		return "", 0, false
+	}
+	if m[3] > m[2] || m[7] > m[6] {
+		amount = -amount
+	}
+	description := strings.TrimSpace(strings.TrimRight(line[:m[0]], " .:$€£¥-"))
+	return description, amount, true
+}
+
+// parseAmount parses a price whose last point or comma is its decimal
+// separator, ignoring any others as thousands separators.
+func parseAmount(s string) (float64, bool) {
+	i := strings.LastIndexAny(s, ".,")
+	if i < 0 {
+		return 0, false
+	}
+	whole := strings.NewReplacer(".", "", ",", "").Replace(s[:i])
+	v, err := strconv.ParseFloat(whole+"."+s[i+1:], 64)
+	return v, err == nil
+}
+
+// hasWord reports whether description contains any of words, ignoring case.
+// Single words must stand alone, so "Tipsy Burger" isn't a tip.
+func hasWord(description string, words []string) bool {
+	lower := strings.ToLower(description)
+	fields := strings.FieldsFunc(lower, func(r rune) bool { return !isLetter(r) })
+	for _, w := range words {
+		if strings.ContainsAny(w, " -") {
+			if strings.Contains(lower, w) {
+				return true
+			}
+			continue
+		}
+		for _, f := range fields {
+			if f == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isLetter(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r > 0x7f
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,55 @@
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// TesseractConfig describes a local Tesseract installation.
+type TesseractConfig struct {
+	// Path is the tesseract binary. Defaults to "tesseract" on the PATH.
+	Path string
+
+	// Languages are the trained data to read with, joined with "+", such as
+	// "eng+deu". Defaults to "eng".
+	Languages string
+}
+
+// Tesseract recognizes text by running the tesseract command line tool.
+type Tesseract struct {
+	path      string
+	languages string
+}
+
+// NewTesseract returns a Provider running the tesseract binary cfg names,
+// failing if it can't be found.
+func NewTesseract(cfg TesseractConfig) (*Tesseract, error) {
+	if cfg.Path == "" {
+		cfg.Path = "tesseract"
+	}
+	if cfg.Languages == "" {
+		cfg.Languages = "eng"
+	}
+	path, err := exec.LookPath(cfg.Path)
+	if err != nil {
+		return nil, fmt.Errorf("tesseract not found: %w", err)
+	}
+	return &Tesseract{path: path, languages: cfg.Languages}, nil
+}
+
+// Text runs tesseract on image. Page segmentation mode 4 reads a single
+// column of lines of varying size, as receipts are laid out.
+func (t *Tesseract) Text(ctx context.Context, image []byte) (string, error) {
+	cmd := exec.CommandContext(ctx, t.path, "stdin", "stdout", "-l", t.languages, "--psm", "4")
+	cmd.Stdin = bytes.NewReader(image)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("tesseract failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,116 @@
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// VisionConfig describes access to the Google Cloud Vision API.
+type VisionConfig struct {
+	// APIKey is an API key of a project with the Vision API enabled.
+	APIKey string
+
+	// Endpoint defaults to "https://vision.googleapis.com"; set it to reach a
+	// test server.
+	Endpoint string
+}
+
+// Vision recognizes text with the Google Cloud Vision API's document text
+// detection, which keeps the lines of dense text such as receipts together.
+type Vision struct {
+	cfg    VisionConfig
+	client *http.Client
+}
+
+// NewVision returns a Provider calling the Vision API with cfg's key.
+func NewVision(cfg VisionConfig) (*Vision, error) {
+	if cfg.APIKey == "" {
+		return nil, fmt.Errorf("Vision API key required")
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://vision.googleapis.com"
+	}
+	cfg.Endpoint = strings.TrimSuffix(cfg.Endpoint, "/")
+	return &Vision{cfg: cfg, client: &http.Client{Timeout: time.Minute}}, nil
+}
+
+type visionRequest struct {
+	Requests []visionImageRequest `json:"requests"`
+}
+
+type visionImageRequest struct {
+	Image struct {
+		Content []byte `json:"content"` // base64 in JSON
+	} `json:"image"`
+	Features []visionFeature `json:"features"`
+}
+
+type visionFeature struct {
+	Type string `json:"type"`
+}
+
+type visionResponse struct {
+	Responses []struct {
+		FullTextAnnotation struct {
+			Text string `json:"text"`
+		} `json:"fullTextAnnotation"`
+		Error *visionError `json:"error"`
+	} `json:"responses"`
+	Error *visionError `json:"error"`
+}
+
+type visionError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Text sends image to the Vision API's images:annotate method.
+func (v *Vision) Text(ctx context.Context, image []byte) (string, error) {
+	req := visionImageRequest{Features: []visionFeature{{Type: "DOCUMENT_TEXT_DETECTION"}}}
+	req.Image.Content = image
+	body, err := json.Marshal(visionRequest{Requests: []visionImageRequest{req}})
+	if err != nil {
+		return "", err
+	}
+
+	endpoint := v.cfg.Endpoint + "/v1/images:annotate?key=" + url.QueryEscape(v.cfg.APIKey)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	resp, err := v.client.Do(httpReq)
+	if err != nil {
+		// The URL carries the key; keep it out of the error.
+		if urlErr, ok := err.(*url.Error); ok {
+			err = urlErr.Err
+		}
+		return "", fmt.Errorf("Vision API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result visionResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 10<<20)).Decode(&result); err != nil {
+		return "", fmt.Errorf("Vision API returned %s with an unreadable body: %w", resp.Status, err)
+	}
+	if result.Error != nil {
+		return "", fmt.Errorf("Vision API returned %s: %s", resp.Status, result.Error.Message)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Vision API returned %s", resp.Status)
+	}
+	if len(result.Responses) != 1 {
+		return "", fmt.Errorf("Vision API returned %d responses for one image", len(result.Responses))
+	}
+	if e := result.Responses[0].Error; e != nil {
+		return "", fmt.Errorf("Vision API could not read the image: %s", e.Message)
+	}
+	return result.Responses[0].FullTextAnnotation.Text, nil
+}
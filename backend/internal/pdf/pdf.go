@@ -0,0 +1,196 @@
+// Package pdf writes simple text documents, such as bill summaries, as PDF.
+//
+// Documents are A4 pages of left-aligned lines in the standard Helvetica
+// fonts, which every PDF reader has, so nothing is embedded. Text is
+// encoded as Windows-1252; characters outside it print as "?".
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Page geometry, in points.
+const (
+	pageWidth  = 595
+	pageHeight = 842
+	margin     = 56
+)
+
+// style is how a line is set.
+type style struct {
+	font    string // resource name of the font
+	size    float64
+	leading float64 // distance to the next baseline
+	// wrap is how many characters fit on a line, going by Helvetica's
+	// average character width.
+	wrap int
+}
+
+var (
+	titleStyle   = style{font: "F2", size: 18, leading: 26, wrap: 45}
+	headingStyle = style{font: "F2", size: 13, leading: 20, wrap: 62}
+	bodyStyle    = style{font: "F1", size: 10.5, leading: 14, wrap: 82}
+)
+
+// line is one line of text, already wrapped.
+type line struct {
+	text  string
+	style style
+}
+
+// Document is a text document being built up line by line.
+type Document struct {
+	lines []line
+}
+
+// New returns an empty document.
+func New() *Document {
+	return &Document{}
+}
+
+// Title adds a large bold line, wrapped if it's long.
+func (d *Document) Title(text string) { d.add(text, titleStyle) }
+
+// Heading adds a bold line, wrapped if it's long.
+func (d *Document) Heading(text string) { d.add(text, headingStyle) }
+
+// Text adds a line of body text, wrapped if it's long. Leading spaces
+// indent it, wrapped lines included. An empty text adds a blank line.
+func (d *Document) Text(text string) { d.add(text, bodyStyle) }
+
+func (d *Document) add(text string, s style) {
+	trimmed := strings.TrimLeft(text, " ")
+	indent := text[:len(text)-len(trimmed)]
+	for _, l := range wrap(trimmed, max(s.wrap-len(indent), 1)) {
+		if l != "" {
+			l = indent + l
+		}
+		d.lines = append(d.lines, line{text: l, style: s})
+	}
+}
+
+// wrap breaks text at spaces into lines of at most width characters,
+// breaking words only when they don't fit on a line of their own.
+func wrap(text string, width int) []string {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return []string{""}
+	}
+	var lines []string
+	current := ""
+	for _, word := range words {
+		for len([]rune(word)) > width {
+			if current != "" {
+				lines = append(lines, current)
+				current = ""
+			}
+			runes := []rune(word)
+			lines = append(lines, string(runes[:width]))
+			word = string(runes[width:])
+		}
+		switch {
+		case current == "":
+			current = word
+		case len([]rune(current))+1+len([]rune(word)) <= width:
+			current += " " + word
+		default:
+			lines = append(lines, current)
+			current = word
+		}
+	}
+	return append(lines, current)
+}
+
+// paginate splits the lines into pages that fit between the margins.
+func (d *Document) paginate() [][]line {
+	var pages [][]line
+	var page []line
+	used := 0.0
+	for _, l := range d.lines {
+		if used+l.style.leading > pageHeight-2*margin && len(page) > 0 {
+			pages = append(pages, page)
+			page, used = nil, 0
+		}
+		page = append(page, l)
+		used += l.style.leading
+	}
+	if len(page) > 0 || len(pages) == 0 {
+		pages = append(pages, page)
+	}
+	return pages
+}
+
+// Bytes renders the document as a PDF file.
+func (d *Document) Bytes() []byte {
+	pages := d.paginate()
+
+	// Objects 1-4 are the catalog, page tree and fonts; each page then takes
+	// two objects, the page and its content stream.
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"", // page tree, filled in below
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+	}
+	kids := make([]string, len(pages))
+	for i, page := range pages {
+		pageObj := len(objects) + 1
+		kids[i] = fmt.Sprintf("%d 0 R", pageObj)
+		content := pageContent(page)
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+				pageWidth, pageHeight, pageObj+1),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+		)
+	}
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages))
+
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return b.Bytes()
+}
+
+// pageContent writes the content stream that sets a page's lines.
+func pageContent(page []line) string {
+	var b strings.Builder
+	b.WriteString("BT\n")
+	y := float64(pageHeight - margin)
+	for _, l := range page {
+		y -= l.style.leading
+		fmt.Fprintf(&b, "/%s %g Tf 1 0 0 1 %d %g Tm (%s) Tj\n", l.style.font, l.style.size, margin, y, escape(l.text))
+	}
+	b.WriteString("ET")
+	return b.String()
+}
+
+// escape encodes text for the fonts' WinAnsiEncoding, as the body of a PDF
+// literal string.
+func escape(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		c, ok := charmap.Windows1252.EncodeRune(r)
+		if !ok {
+			c = '?'
+		}
+		if c == '(' || c == ')' || c == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,71 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		text  string
+		width int
+		want  []string
+	}{
+		{"", 10, []string{""}},
+		{"short", 10, []string{"short"}},
+		{"the quick brown fox", 10, []string{"the quick", "brown fox"}},
+		{"abcdefghijkl", 5, []string{"abcde", "fghij", "kl"}},
+		{"  extra   spaces ", 20, []string{"extra spaces"}},
+	}
+	for _, tt := range tests {
+		if got := wrap(tt.text, tt.width); !slices.Equal(got, tt.want) {
+			t.Errorf("wrap(%q, %d) = %q, want %q", tt.text, tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestEscape(t *testing.T) {
+	if got, want := escape(`Pizza (large) \ €5`), "Pizza \\(large\\) \\\\ \x805"; got != want {
+		t.Errorf("escape = %q, want %q", got, want)
+	}
+	if got := escape("₹100"); got != "?100" {
+		t.Errorf("escape of a character outside Windows-1252 = %q, want ?100", got)
+	}
+}
+
+func TestDocumentBytes(t *testing.T) {
+	doc := New()
+	doc.Title("Dinner")
+	for i := range 100 {
+		doc.Text(fmt.Sprintf("Line %d", i))
+	}
+	out := doc.Bytes()
+
+	if !bytes.HasPrefix(out, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(out, []byte("%%EOF\n")) {
+		t.Fatalf("output isn't framed as a PDF: %q...", out[:20])
+	}
+	if got := strings.Count(string(out), "/Type /Page "); got != 2 {
+		t.Errorf("100 lines set on %d pages, want 2", got)
+	}
+
+	// Every xref entry must point at its object.
+	start, err := strconv.Atoi(regexp.MustCompile(`startxref\n(\d+)`).FindStringSubmatch(string(out))[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllStringSubmatch(string(out[start:]), -1)
+	if len(entries) == 0 {
+		t.Fatal("xref table has no entries")
+	}
+	for i, e := range entries {
+		offset, _ := strconv.Atoi(e[1])
+		if want := fmt.Sprintf("%d 0 obj", i+1); !bytes.HasPrefix(out[offset:], []byte(want)) {
+			t.Errorf("xref entry %d points at %q, want %q", i+1, out[offset:offset+10], want)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package provision
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"mime"
+	"net/http"
+
+	"github.com/mmynk/splitwiser/pkg/logging"
+)
+
+// logger logs as the provision component; see pkg/logging.
+var logger = logging.Component("provision")
+
+// maxRequestBytes caps the size of a provisioning upload.
+const maxRequestBytes = 5 << 20
+
+// Handler serves bulk provisioning over HTTP. Requests must be POSTs carrying
+// "Authorization: Bearer <adminToken>" and a text/csv or application/json
+// (SCIM-lite) body. The response lists one Result per entry.
+func Handler(p *Provisioner, adminToken string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if adminToken == "" || subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+adminToken)) != 1 {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		body := http.MaxBytesReader(w, r.Body, maxRequestBytes)
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+		var entries []Entry
+		var err error
+		switch mediaType {
+		case "text/csv":
+			entries, err = ParseCSV(body)
+		case "application/json", "application/scim+json":
+			entries, err = ParseSCIM(body)
+		default:
+			http.Error(w, "content type must be text/csv or application/json", http.StatusUnsupportedMediaType)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		results := p.Provision(r.Context(), entries)
+		created := 0
+		for _, res := range results {
+			if res.Status == StatusCreated {
+				created++
+			}
+		}
+		logger.Info("Provisioned users", "entries", len(entries), "created", created)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(struct {
+			Results []Result `json:"results"`
+		}{results})
+	})
+}
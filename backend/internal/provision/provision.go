@@ -0,0 +1,205 @@
+// Package provision bulk-creates user accounts for office deployments.
+//
+// Accounts are created without a password and receive an invitation; the
+// invited user sets their password with AuthService.AcceptInvitation.
+package provision
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mmynk/splitwiser/internal/auth"
+	"github.com/mmynk/splitwiser/internal/models"
+)
+
+// Entry is one user to provision.
+type Entry struct {
+	Email       string
+	DisplayName string
+	GroupIDs    []string // groups to add the user to
+}
+
+// Status reports what happened to one Entry.
+type Status string
+
+const (
+	StatusCreated Status = "created" // account created and invitation sent
+	StatusExists  Status = "exists"  // account already existed; group memberships still applied
+	StatusFailed  Status = "failed"
+)
+
+// Result is the outcome of provisioning one Entry.
+type Result struct {
+	Email  string `json:"email"`
+	UserID string `json:"user_id,omitempty"`
+	Status Status `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Store is the subset of storage needed to provision users into groups.
+type Store interface {
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	GetGroup(ctx context.Context, groupID string) (*models.Group, error)
+	AddGroupMembersWithIDs(ctx context.Context, groupID string, members []models.GroupMember) error
+}
+
+// Provisioner creates invited accounts and their group memberships.
+type Provisioner struct {
+	store   Store
+	inviter *auth.Inviter
+}
+
+// New creates a Provisioner.
+func New(store Store, inviter *auth.Inviter) *Provisioner {
+	return &Provisioner{store: store, inviter: inviter}
+}
+
+// Provision creates each entry's account (if needed) and group memberships.
+// Entries are independent: a failure is reported in its Result and doesn't stop the rest.
+func (p *Provisioner) Provision(ctx context.Context, entries []Entry) []Result {
+	results := make([]Result, len(entries))
+	for i, e := range entries {
+		results[i] = p.provisionOne(ctx, e)
+	}
+	return results
+}
+
+func (p *Provisioner) provisionOne(ctx context.Context, e Entry) Result {
+	result := Result{Email: e.Email}
+
+	user, err := p.inviter.Invite(ctx, e.Email, e.DisplayName)
+	switch {
+	case err == nil:
+		result.Status = StatusCreated
+	case errors.Is(err, auth.ErrEmailExists):
+		user, err = p.store.GetUserByEmail(ctx, e.Email)
+		if err != nil || user == nil {
+			return failed(result, fmt.Errorf("failed to load existing user: %v", err))
+		}
+		result.Status = StatusExists
+	default:
+		return failed(result, err)
+	}
+	result.UserID = user.ID
+
+	for _, groupID := range e.GroupIDs {
+		if _, err := p.store.GetGroup(ctx, groupID); err != nil {
+			return failed(result, err)
+		}
+		member := models.GroupMember{DisplayName: user.DisplayName, UserID: user.ID}
+		if err := p.store.AddGroupMembersWithIDs(ctx, groupID, []models.GroupMember{member}); err != nil {
+			return failed(result, err)
+		}
+	}
+	return result
+}
+
+func failed(r Result, err error) Result {
+	r.Status = StatusFailed
+	r.Error = err.Error()
+	return r
+}
+
+// ParseCSV reads entries from CSV with a header row. Recognized columns are
+// email (required), display_name and groups (group IDs separated by ';').
+func ParseCSV(r io.Reader) ([]Entry, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	cols := make(map[string]int, len(header))
+	for i, name := range header {
+		cols[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := cols["email"]; !ok {
+		return nil, fmt.Errorf("CSV header must include an email column")
+	}
+	field := func(record []string, name string) string {
+		if i, ok := cols[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var entries []Entry
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+		entry := Entry{
+			Email:       field(record, "email"),
+			DisplayName: field(record, "display_name"),
+		}
+		for _, g := range strings.Split(field(record, "groups"), ";") {
+			if g = strings.TrimSpace(g); g != "" {
+				entry.GroupIDs = append(entry.GroupIDs, g)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// scimUser is the subset of the SCIM 2.0 User resource that we understand.
+type scimUser struct {
+	UserName    string `json:"userName"`
+	DisplayName string `json:"displayName"`
+	Emails      []struct {
+		Value   string `json:"value"`
+		Primary bool   `json:"primary"`
+	} `json:"emails"`
+	Groups []struct {
+		Value string `json:"value"`
+	} `json:"groups"`
+}
+
+// ParseSCIM reads entries from SCIM-lite JSON: either a single User resource
+// or a list response with the users under "Resources".
+func ParseSCIM(r io.Reader) ([]Entry, error) {
+	var doc struct {
+		scimUser
+		Resources []scimUser `json:"Resources"`
+	}
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode SCIM JSON: %w", err)
+	}
+
+	users := doc.Resources
+	if users == nil {
+		users = []scimUser{doc.scimUser}
+	}
+
+	entries := make([]Entry, len(users))
+	for i, u := range users {
+		entries[i] = Entry{Email: u.email(), DisplayName: u.DisplayName}
+		for _, g := range u.Groups {
+			entries[i].GroupIDs = append(entries[i].GroupIDs, g.Value)
+		}
+	}
+	return entries, nil
+}
+
+// email returns the primary email, falling back to the first email and then userName.
+func (u scimUser) email() string {
+	for _, e := range u.Emails {
+		if e.Primary {
+			return e.Value
+		}
+	}
+	if len(u.Emails) > 0 {
+		return u.Emails[0].Value
+	}
+	return u.UserName
+}
@@ -0,0 +1,140 @@
+package provision
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mmynk/splitwiser/internal/auth"
+	"github.com/mmynk/splitwiser/internal/models"
+	"github.com/mmynk/splitwiser/internal/storage/sqlite"
+)
+
+type nopMailer struct{}
+
+func (nopMailer) SendVerification(ctx context.Context, to, token string) error { return nil }
+func (nopMailer) SendInvitation(ctx context.Context, to, token string) error   { return nil }
+
+func setupHandler(t *testing.T) (http.Handler, *sqlite.SQLiteStore) {
+	t.Helper()
+	store, err := sqlite.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	p := New(store, auth.NewInviter(store, nopMailer{}))
+	return Handler(p, "admin-secret"), store
+}
+
+func post(t *testing.T, h http.Handler, contentType, token, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/admin/provision", strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func decodeResults(t *testing.T, rec *httptest.ResponseRecorder) []Result {
+	t.Helper()
+	var resp struct {
+		Results []Result `json:"results"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return resp.Results
+}
+
+func TestHandler_RequiresAdminToken(t *testing.T) {
+	h, _ := setupHandler(t)
+
+	for _, token := range []string{"", "wrong"} {
+		rec := post(t, h, "text/csv", token, "email\na@example.com\n")
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("token %q: expected 403, got %d", token, rec.Code)
+		}
+	}
+}
+
+func TestHandler_CSV(t *testing.T) {
+	h, store := setupHandler(t)
+	ctx := context.Background()
+
+	group := &models.Group{Name: "Office", Members: []models.GroupMember{{DisplayName: "Boss"}}}
+	if err := store.CreateGroup(ctx, group); err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+
+	body := "email,display_name,groups\n" +
+		"ann@example.com,Ann," + group.ID + "\n" +
+		"ben@example.com,Ben,\n" +
+		"ann@example.com,Ann,\n" +
+		"not-an-email,Bad,\n"
+	rec := post(t, h, "text/csv", "admin-secret", body)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	results := decodeResults(t, rec)
+	want := []Status{StatusCreated, StatusCreated, StatusExists, StatusFailed}
+	if len(results) != len(want) {
+		t.Fatalf("expected %d results, got %d", len(want), len(results))
+	}
+	for i, status := range want {
+		if results[i].Status != status {
+			t.Errorf("row %d: expected %s, got %s (%s)", i, status, results[i].Status, results[i].Error)
+		}
+	}
+
+	got, err := store.GetGroup(ctx, group.ID)
+	if err != nil {
+		t.Fatalf("GetGroup failed: %v", err)
+	}
+	found := false
+	for _, m := range got.Members {
+		if m.DisplayName == "Ann" && m.UserID == results[0].UserID {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected Ann to be added to the group, got %+v", got.Members)
+	}
+}
+
+func TestHandler_SCIM(t *testing.T) {
+	h, store := setupHandler(t)
+
+	body := `{"Resources": [
+		{"userName": "cat", "displayName": "Cat", "emails": [{"value": "cat@home.com"}, {"value": "cat@example.com", "primary": true}]},
+		{"userName": "dan@example.com", "displayName": "Dan", "groups": [{"value": "missing-group"}]}
+	]}`
+	rec := post(t, h, "application/scim+json", "admin-secret", body)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	results := decodeResults(t, rec)
+	if results[0].Email != "cat@example.com" || results[0].Status != StatusCreated {
+		t.Errorf("unexpected first result: %+v", results[0])
+	}
+	if results[1].Status != StatusFailed {
+		t.Errorf("expected failure for unknown group, got %+v", results[1])
+	}
+
+	user, err := store.GetUserByEmail(context.Background(), "cat@example.com")
+	if err != nil || user == nil {
+		t.Fatalf("expected provisioned user, got %v, %v", user, err)
+	}
+	if user.PasswordHash != "" {
+		t.Error("expected provisioned user to have no password until invitation is accepted")
+	}
+}
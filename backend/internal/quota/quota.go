@@ -0,0 +1,118 @@
+// Package quota enforces soft per-account limits for hosted deployments.
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mmynk/splitwiser/internal/models"
+)
+
+// ErrExceeded is wrapped by every error returned when a limit would be exceeded.
+var ErrExceeded = errors.New("quota exceeded")
+
+// Limits holds the configured per-account quotas. A zero value means unlimited.
+type Limits struct {
+	// MaxGroups is the number of groups a user may have created at once.
+	MaxGroups int
+
+	// MaxBillsPerMonth is the number of bills a user may create per calendar month (UTC).
+	MaxBillsPerMonth int
+
+	// MaxAttachmentBytes is the total attachment storage a user may use.
+	MaxAttachmentBytes int64
+}
+
+// UsageStore is the subset of storage needed to read usage counters.
+type UsageStore interface {
+	GetUsage(ctx context.Context, userID string, since int64) (*models.Usage, error)
+}
+
+// Enforcer checks user actions against configured limits.
+// A nil *Enforcer allows everything, so callers don't need to guard it.
+type Enforcer struct {
+	store  UsageStore
+	limits Limits
+	now    func() time.Time
+}
+
+// NewEnforcer creates an Enforcer that reads usage from store.
+func NewEnforcer(store UsageStore, limits Limits) *Enforcer {
+	return &Enforcer{store: store, limits: limits, now: time.Now}
+}
+
+// Limits returns the configured limits.
+func (e *Enforcer) Limits() Limits {
+	if e == nil {
+		return Limits{}
+	}
+	return e.limits
+}
+
+// CheckCreateGroup returns an ErrExceeded error if the user can't create another group.
+func (e *Enforcer) CheckCreateGroup(ctx context.Context, userID string) error {
+	if e == nil || e.limits.MaxGroups <= 0 {
+		return nil
+	}
+	usage, err := e.usage(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if usage.GroupsCreated >= e.limits.MaxGroups {
+		return fmt.Errorf("%w: limit of %d groups reached", ErrExceeded, e.limits.MaxGroups)
+	}
+	return nil
+}
+
+// CheckCreateBill returns an ErrExceeded error if the user can't create another bill this month.
+func (e *Enforcer) CheckCreateBill(ctx context.Context, userID string) error {
+	return e.CheckCreateBills(ctx, userID, 1)
+}
+
+// CheckCreateBills returns an ErrExceeded error if the user can't create n
+// more bills this month, as when importing them in one batch.
+func (e *Enforcer) CheckCreateBills(ctx context.Context, userID string, n int) error {
+	if e == nil || e.limits.MaxBillsPerMonth <= 0 || n <= 0 {
+		return nil
+	}
+	usage, err := e.usage(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if usage.BillsCreated+n > e.limits.MaxBillsPerMonth {
+		if n == 1 {
+			return fmt.Errorf("%w: limit of %d bills per month reached", ErrExceeded, e.limits.MaxBillsPerMonth)
+		}
+		return fmt.Errorf("%w: %d more bills would pass the limit of %d bills per month (%d used)", ErrExceeded, n, e.limits.MaxBillsPerMonth, usage.BillsCreated)
+	}
+	return nil
+}
+
+// CheckAttachment returns an ErrExceeded error if storing size more bytes would
+// put the user over their attachment storage limit.
+func (e *Enforcer) CheckAttachment(ctx context.Context, userID string, size int64) error {
+	if e == nil || e.limits.MaxAttachmentBytes <= 0 {
+		return nil
+	}
+	usage, err := e.usage(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if usage.AttachmentBytes+size > e.limits.MaxAttachmentBytes {
+		return fmt.Errorf("%w: attachment storage limit of %d bytes reached", ErrExceeded, e.limits.MaxAttachmentBytes)
+	}
+	return nil
+}
+
+// usage loads the user's counters, counting bills from the start of the current month.
+func (e *Enforcer) usage(ctx context.Context, userID string) (*models.Usage, error) {
+	now := e.now().UTC()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	usage, err := e.store.GetUsage(ctx, userID, monthStart.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load usage: %w", err)
+	}
+	return usage, nil
+}
@@ -0,0 +1,477 @@
+// Package replica continuously copies a SQLite database to blob storage by
+// shipping its write-ahead log, and restores it from there.
+//
+// A replica is a series of generations, one per run of the Replicator. A
+// generation holds a snapshot of the database file and the WAL written
+// since, in segments of committed frames. SQLite reuses the WAL file once a
+// checkpoint has copied everything in it to the database; each use is an
+// index of the generation, and a snapshot belongs to the index current when
+// it was taken. Restoring replays every index from the snapshot's onwards.
+//
+// To make sure no frame is reused before it is shipped, the Replicator keeps
+// a read transaction open, which stops other connections from restarting
+// the log, and runs the checkpoints that let it restart itself.
+package replica
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mmynk/splitwiser/internal/blob"
+	"github.com/mmynk/splitwiser/pkg/logging"
+	_ "modernc.org/sqlite"
+)
+
+// logger logs as the storage component; see pkg/logging.
+var logger = logging.Component("storage")
+
+// Defaults for the zero values of Config.
+const (
+	DefaultInterval         = time.Second
+	DefaultSnapshotInterval = 24 * time.Hour
+	DefaultCheckpointBytes  = 4 << 20
+)
+
+// seqTable is the table the Replicator writes to so the log has a frame
+// for its read transaction to hold on to. It isn't part of the app schema.
+const seqTable = "_splitwiser_replica"
+
+// errWALRestarted means the log was restarted by someone else, possibly
+// over frames that were never shipped.
+var errWALRestarted = errors.New("WAL restarted outside the replicator")
+
+// Config configures a Replicator.
+type Config struct {
+	// Store receives the replica, under Prefix.
+	Store  blob.Store
+	Prefix string
+
+	// Interval is how often new WAL frames are shipped.
+	Interval time.Duration
+
+	// SnapshotInterval is how often a new snapshot replaces the WAL shipped
+	// before it.
+	SnapshotInterval time.Duration
+
+	// CheckpointBytes is how much WAL is shipped before the Replicator
+	// checkpoints it so the log can start over.
+	CheckpointBytes int64
+}
+
+// Position is how far a replica has been shipped.
+type Position struct {
+	Generation string
+	Index      int
+	Offset     int64 // bytes of the index's WAL shipped
+}
+
+// Status reports on replication for health checks.
+type Status struct {
+	Position
+	LastSync     time.Time // end of the last sync that succeeded
+	LastSnapshot time.Time
+	LastError    string // from the last sync, if it failed
+}
+
+// Replicator ships a database's WAL to a replica.
+type Replicator struct {
+	path string
+	db   *sql.DB
+	cfg  Config
+
+	// read is the transaction that keeps others from restarting the log.
+	read *sql.Tx
+
+	pos          Position
+	hdr          walHeader // of the index being shipped
+	sum          [2]uint32 // checksum of the WAL up to pos.Offset
+	lastSnapshot time.Time
+
+	mu     sync.Mutex
+	status Status
+}
+
+// New returns a Replicator for the WAL-mode database at path. Its
+// connections are its own; it doesn't start shipping until Run.
+func New(path string, cfg Config) (*Replicator, error) {
+	if cfg.Store == nil {
+		return nil, fmt.Errorf("replica store required")
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultInterval
+	}
+	if cfg.SnapshotInterval <= 0 {
+		cfg.SnapshotInterval = DefaultSnapshotInterval
+	}
+	if cfg.CheckpointBytes <= 0 {
+		cfg.CheckpointBytes = DefaultCheckpointBytes
+	}
+
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	// The read transaction, the write lock taken to checkpoint, and the
+	// checkpoint itself.
+	db.SetMaxOpenConns(3)
+
+	var mode string
+	if err := db.QueryRow("PRAGMA journal_mode").Scan(&mode); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to read journal mode: %w", err)
+	}
+	if !strings.EqualFold(mode, "wal") {
+		db.Close()
+		return nil, fmt.Errorf("replication needs WAL journal mode, database uses %s", mode)
+	}
+	if _, err := db.Exec("CREATE TABLE IF NOT EXISTS " + seqTable + " (id INTEGER PRIMARY KEY, seq INTEGER NOT NULL)"); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create %s: %w", seqTable, err)
+	}
+	if _, err := db.Exec("INSERT OR IGNORE INTO " + seqTable + " (id, seq) VALUES (1, 0)"); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize %s: %w", seqTable, err)
+	}
+	return &Replicator{path: path, db: db, cfg: cfg}, nil
+}
+
+// Status reports how far replication has got.
+func (r *Replicator) Status() Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.status
+}
+
+// Run ships the WAL every Interval until ctx is done, starting a new
+// generation first, then closes the Replicator's connections. Failures are
+// logged and retried; Status reports them.
+func (r *Replicator) Run(ctx context.Context) {
+	defer r.close()
+	ticker := time.NewTicker(r.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		err := r.step(ctx)
+		if errors.Is(err, errWALRestarted) {
+			logger.Warn("WAL restarted before it was shipped, starting a new generation", "generation", r.pos.Generation)
+			r.pos.Generation = ""
+			err = r.step(ctx)
+		}
+		if err != nil && ctx.Err() == nil {
+			logger.Error("Replication failed", "generation", r.pos.Generation, "error", err)
+		}
+		r.setStatus(err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// step does one round of replication: whatever is due of starting a
+// generation, shipping frames, checkpointing and snapshotting.
+func (r *Replicator) step(ctx context.Context) error {
+	if r.pos.Generation == "" {
+		return r.newGeneration(ctx)
+	}
+	if err := r.sync(ctx); err != nil {
+		return err
+	}
+	if r.pos.Offset >= r.cfg.CheckpointBytes {
+		if err := r.checkpoint(ctx); err != nil {
+			return err
+		}
+	}
+	if time.Since(r.lastSnapshot) >= r.cfg.SnapshotInterval {
+		return r.snapshot(ctx)
+	}
+	return nil
+}
+
+func (r *Replicator) setStatus(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status.Position = r.pos
+	r.status.LastSnapshot = r.lastSnapshot
+	if err != nil {
+		r.status.LastError = err.Error()
+		return
+	}
+	r.status.LastSync = time.Now()
+	r.status.LastError = ""
+}
+
+func (r *Replicator) close() {
+	r.releaseRead()
+	r.db.Close()
+}
+
+func (r *Replicator) walPath() string {
+	return r.path + "-wal"
+}
+
+// acquireRead opens the read transaction. Call it after the Replicator's
+// own write so the log has a frame for it to hold.
+func (r *Replicator) acquireRead(ctx context.Context) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin read transaction: %w", err)
+	}
+	var seq int64
+	if err := tx.QueryRowContext(ctx, "SELECT seq FROM "+seqTable+" WHERE id = 1").Scan(&seq); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to begin read transaction: %w", err)
+	}
+	r.read = tx
+	return nil
+}
+
+func (r *Replicator) releaseRead() {
+	if r.read != nil {
+		r.read.Rollback()
+		r.read = nil
+	}
+}
+
+// bumpSeq writes a frame to the log.
+func bumpSeq(ctx context.Context, exec interface {
+	ExecContext(context.Context, string, ...any) (sql.Result, error)
+}) error {
+	if _, err := exec.ExecContext(ctx, "UPDATE "+seqTable+" SET seq = seq + 1 WHERE id = 1"); err != nil {
+		return fmt.Errorf("failed to write %s: %w", seqTable, err)
+	}
+	return nil
+}
+
+// newGeneration starts shipping the current log from its beginning under a
+// new generation, with a snapshot to replay it onto.
+func (r *Replicator) newGeneration(ctx context.Context) error {
+	r.releaseRead()
+	if err := bumpSeq(ctx, r.db); err != nil {
+		return err
+	}
+	if err := r.acquireRead(ctx); err != nil {
+		return err
+	}
+	hdr, err := r.readHeader()
+	if err != nil {
+		return err
+	}
+	generation, err := newGenerationID()
+	if err != nil {
+		return err
+	}
+	r.startIndex(Position{Generation: generation}, hdr)
+	logger.Info("Replication generation started", "generation", generation)
+	return r.snapshot(ctx)
+}
+
+// startIndex makes pos, at the start of the log with header hdr, the next
+// thing to ship.
+func (r *Replicator) startIndex(pos Position, hdr walHeader) {
+	r.pos = pos
+	r.hdr = hdr
+	r.sum = hdr.checksum
+}
+
+func (r *Replicator) readHeader() (walHeader, error) {
+	f, err := os.Open(r.walPath())
+	if err != nil {
+		return walHeader{}, fmt.Errorf("failed to open WAL: %w", err)
+	}
+	defer f.Close()
+	return readWALHeader(f)
+}
+
+// sync ships the committed frames written since the last sync.
+func (r *Replicator) sync(ctx context.Context) error {
+	f, err := os.Open(r.walPath())
+	if err != nil {
+		return fmt.Errorf("failed to open WAL: %w", err)
+	}
+	defer f.Close()
+
+	hdr, err := readWALHeader(f)
+	if err != nil {
+		return err
+	}
+	if hdr != r.hdr {
+		return errWALRestarted
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat WAL: %w", err)
+	}
+	if info.Size() <= r.pos.Offset {
+		return nil
+	}
+
+	buf := make([]byte, info.Size()-r.pos.Offset)
+	if _, err := f.ReadAt(buf, r.pos.Offset); err != nil && err != io.EOF {
+		return fmt.Errorf("failed to read WAL: %w", err)
+	}
+	// The first segment of an index starts with the log's header.
+	skip := 0
+	if r.pos.Offset == 0 {
+		skip = walHeaderSize
+	}
+	n, sum := hdr.committedFrames(buf[skip:], r.sum)
+	if n == 0 {
+		return nil
+	}
+	segment := buf[:skip+n]
+	key := r.cfg.Prefix + walKey(r.pos.Generation, r.pos.Index, r.pos.Offset)
+	if err := r.cfg.Store.Put(ctx, key, bytes.NewReader(segment), int64(len(segment)), "application/octet-stream"); err != nil {
+		return fmt.Errorf("failed to upload WAL segment: %w", err)
+	}
+	r.pos.Offset += int64(len(segment))
+	r.sum = sum
+	return nil
+}
+
+// checkpoint ships the rest of the log and copies it to the database, then
+// writes so the log restarts as the next index.
+//
+// SQLite restarts the log on a write that begins once all of it has been
+// copied, unless a reader still uses it. A writer that slipped in between
+// the checkpoint and the Replicator's own write either restarts the log,
+// which is fine as everything before was shipped, or appends to it, and
+// the read transaction taken right after keeps those frames until they're
+// shipped.
+func (r *Replicator) checkpoint(ctx context.Context) error {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	// Nothing is committed while the log is shipped and checkpointed.
+	if _, err := conn.ExecContext(ctx, "BEGIN IMMEDIATE"); err != nil {
+		return fmt.Errorf("failed to lock database: %w", err)
+	}
+	err = r.sync(ctx)
+	if err == nil {
+		r.releaseRead()
+		if _, err = r.db.ExecContext(ctx, "PRAGMA wal_checkpoint(PASSIVE)"); err != nil {
+			err = fmt.Errorf("failed to checkpoint: %w", err)
+		}
+	}
+	if _, rerr := conn.ExecContext(context.WithoutCancel(ctx), "ROLLBACK"); err == nil && rerr != nil {
+		err = fmt.Errorf("failed to unlock database: %w", rerr)
+	}
+	if err == nil {
+		err = bumpSeq(ctx, conn)
+	}
+	if r.read == nil {
+		if rerr := r.acquireRead(ctx); err == nil {
+			err = rerr
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	hdr, err := r.readHeader()
+	if err != nil {
+		return err
+	}
+	switch {
+	case hdr == r.hdr:
+		// Not restarted, as a reader held the checkpoint back; keep shipping
+		// this index.
+	case hdr.salt1 == r.hdr.salt1+1:
+		r.startIndex(Position{Generation: r.pos.Generation, Index: r.pos.Index + 1}, hdr)
+	default:
+		// Restarted again before the read transaction began.
+		return errWALRestarted
+	}
+	return nil
+}
+
+// snapshot uploads a copy of the database file for the current index and
+// deletes what the replica no longer needs.
+//
+// The copy is taken while the log can't restart, so each page is either as
+// it was when the index began or as some frame of the index left it.
+// Replaying the whole index brings every page up to date.
+func (r *Replicator) snapshot(ctx context.Context) error {
+	tmp, err := os.CreateTemp(filepath.Dir(r.path), ".replica-snapshot-*")
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	src, err := os.Open(r.path)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	size, err := io.Copy(tmp, src)
+	src.Close()
+	if err != nil {
+		return fmt.Errorf("failed to copy database: %w", err)
+	}
+
+	// Everything in the copy must be in the replica before the copy is.
+	if err := r.sync(ctx); err != nil {
+		return err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	key := r.cfg.Prefix + snapshotKey(r.pos.Generation, r.pos.Index)
+	if err := r.cfg.Store.Put(ctx, key, tmp, size, "application/vnd.sqlite3"); err != nil {
+		return fmt.Errorf("failed to upload snapshot: %w", err)
+	}
+	r.lastSnapshot = time.Now()
+	logger.Info("Replica snapshot uploaded", "generation", r.pos.Generation, "index", r.pos.Index, "bytes", size)
+
+	if err := r.prune(ctx); err != nil {
+		logger.Warn("Failed to prune replica", "error", err)
+	}
+	return nil
+}
+
+// prune deletes other generations and the indexes of this one before its
+// latest snapshot.
+func (r *Replicator) prune(ctx context.Context) error {
+	var stale []string
+	err := r.cfg.Store.List(ctx, r.cfg.Prefix+generationsDir, func(obj blob.Object) error {
+		k, ok := parseKey(strings.TrimPrefix(obj.Key, r.cfg.Prefix))
+		if ok && (k.generation != r.pos.Generation || k.index < r.pos.Index) {
+			stale = append(stale, obj.Key)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, key := range stale {
+		if err := r.cfg.Store.Delete(ctx, key); err != nil && !errors.Is(err, blob.ErrNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// newGenerationID returns an ID that sorts after those of earlier
+// generations.
+func newGenerationID() (string, error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate generation ID: %w", err)
+	}
+	return time.Now().UTC().Format("20060102T150405.000000000Z") + "-" + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,191 @@
+package replica
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mmynk/splitwiser/internal/blob"
+)
+
+// openApp opens the database at path the way the app does, in WAL mode.
+func openApp(t *testing.T, path string) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if _, err := db.Exec("CREATE TABLE IF NOT EXISTS notes (id INTEGER PRIMARY KEY, body TEXT NOT NULL)"); err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+	return db
+}
+
+func insertNotes(t *testing.T, db *sql.DB, from, to int) {
+	t.Helper()
+	for i := from; i < to; i++ {
+		if _, err := db.Exec("INSERT INTO notes (id, body) VALUES (?, ?)", i, strings.Repeat("x", 500)); err != nil {
+			t.Fatalf("insert: %v", err)
+		}
+	}
+}
+
+func countNotes(t *testing.T, path string) int {
+	t.Helper()
+	db, err := sql.Open("sqlite", "file:"+path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+	var n int
+	if err := db.QueryRow("SELECT COUNT(*) FROM notes").Scan(&n); err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	return n
+}
+
+func TestCommittedFrames(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db := openApp(t, path)
+	// A reader keeps the log from being checkpointed and restarted.
+	reader, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Rollback()
+	var n int
+	reader.QueryRow("SELECT COUNT(*) FROM notes").Scan(&n)
+	insertNotes(t, db, 0, 3)
+
+	wal, err := os.ReadFile(path + "-wal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hdr, err := parseWALHeader(wal)
+	if err != nil {
+		t.Fatalf("parseWALHeader: %v", err)
+	}
+	frames := wal[walHeaderSize:]
+	got, _ := hdr.committedFrames(frames, hdr.checksum)
+	if got == 0 || got != len(frames) {
+		t.Fatalf("committedFrames = %d of %d bytes", got, len(frames))
+	}
+
+	// A torn last frame and anything after it is left out.
+	torn := append([]byte(nil), frames...)
+	torn[len(torn)-1] ^= 0xff
+	if n, _ := hdr.committedFrames(torn, hdr.checksum); n >= got {
+		t.Errorf("committedFrames with a torn frame = %d, want less than %d", n, got)
+	}
+	// So are frames from an earlier use of the log.
+	stale := append([]byte(nil), frames...)
+	binary.BigEndian.PutUint32(stale[8:], hdr.salt1-1)
+	if n, _ := hdr.committedFrames(stale, hdr.checksum); n != 0 {
+		t.Errorf("committedFrames with stale salts = %d, want 0", n)
+	}
+}
+
+func TestReplicateAndRestore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.db")
+	store, err := blob.NewDisk(filepath.Join(dir, "replica"), "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ok, err := Restore(ctx, store, "db/", filepath.Join(dir, "none.db")); ok || err != nil {
+		t.Fatalf("Restore of an empty replica = %v, %v; want false, nil", ok, err)
+	}
+
+	app := openApp(t, path)
+	insertNotes(t, app, 0, 10)
+
+	r, err := New(path, Config{Store: store, Prefix: "db/", CheckpointBytes: 64 << 10})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer r.close()
+	if err := r.step(ctx); err != nil {
+		t.Fatalf("first step: %v", err)
+	}
+	generation := r.pos.Generation
+	if generation == "" || r.lastSnapshot.IsZero() {
+		t.Fatalf("first step left position %+v without a snapshot", r.pos)
+	}
+
+	// Enough writes to checkpoint and restart the log a few times; others'
+	// checkpoints can't restart it underneath the replicator.
+	for i := 1; i <= 5; i++ {
+		insertNotes(t, app, i*100, i*100+50)
+		if _, err := app.Exec("PRAGMA wal_checkpoint(PASSIVE)"); err != nil {
+			t.Fatalf("app checkpoint: %v", err)
+		}
+		if err := r.step(ctx); err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+	}
+	if r.pos.Generation != generation {
+		t.Fatalf("generation changed from %s to %s", generation, r.pos.Generation)
+	}
+	if r.pos.Index == 0 {
+		t.Fatalf("log never restarted: %+v", r.pos)
+	}
+	insertNotes(t, app, 1000, 1003)
+	if err := r.sync(ctx); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	restored := filepath.Join(dir, "restored.db")
+	if ok, err := Restore(ctx, store, "db/", restored); !ok || err != nil {
+		t.Fatalf("Restore = %v, %v", ok, err)
+	}
+	if got, want := countNotes(t, restored), countNotes(t, path); got != want {
+		t.Errorf("restored %d notes, want %d", got, want)
+	}
+
+	// A new snapshot makes the earlier indexes unnecessary.
+	if err := r.snapshot(ctx); err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	err = store.List(ctx, "db/", func(obj blob.Object) error {
+		if k, ok := parseKey(strings.TrimPrefix(obj.Key, "db/")); !ok || k.index < r.pos.Index {
+			t.Errorf("%s left after snapshot of index %d", obj.Key, r.pos.Index)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	again := filepath.Join(dir, "again.db")
+	if ok, err := Restore(ctx, store, "db/", again); !ok || err != nil {
+		t.Fatalf("Restore after snapshot = %v, %v", ok, err)
+	}
+	if got, want := countNotes(t, again), countNotes(t, path); got != want {
+		t.Errorf("restored %d notes after snapshot, want %d", got, want)
+	}
+}
+
+func TestNewRequiresWAL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=journal_mode(DELETE)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec("CREATE TABLE t (id INTEGER)"); err != nil {
+		t.Fatal(err)
+	}
+	store, err := blob.NewDisk(t.TempDir(), "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(path, Config{Store: store}); err == nil {
+		t.Error("New accepted a database outside WAL mode")
+	}
+}
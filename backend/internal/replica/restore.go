@@ -0,0 +1,217 @@
+package replica
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mmynk/splitwiser/internal/blob"
+)
+
+// generationsDir holds every generation of a replica, under its prefix.
+const generationsDir = "generations/"
+
+// snapshotKey is where the snapshot of a generation's index is kept.
+func snapshotKey(generation string, index int) string {
+	return fmt.Sprintf("%s%s/snapshots/%08x.db", generationsDir, generation, index)
+}
+
+// walKey is where the segment of an index's WAL starting at offset is kept.
+func walKey(generation string, index int, offset int64) string {
+	return fmt.Sprintf("%s%s/wal/%08x/%016x.wal", generationsDir, generation, index, offset)
+}
+
+// replicaKey is a parsed snapshotKey or walKey.
+type replicaKey struct {
+	generation string
+	index      int
+	snapshot   bool
+	offset     int64 // of a WAL segment
+}
+
+// parseKey parses a key, without the replica's prefix, written by
+// snapshotKey or walKey.
+func parseKey(key string) (replicaKey, bool) {
+	parts := strings.Split(strings.TrimPrefix(key, generationsDir), "/")
+	hex := func(s string) (int64, bool) {
+		n, err := strconv.ParseInt(s, 16, 64)
+		return n, err == nil
+	}
+	switch {
+	case len(parts) == 3 && parts[1] == "snapshots" && strings.HasSuffix(parts[2], ".db"):
+		index, ok := hex(strings.TrimSuffix(parts[2], ".db"))
+		return replicaKey{generation: parts[0], index: int(index), snapshot: true}, ok
+	case len(parts) == 4 && parts[1] == "wal" && strings.HasSuffix(parts[3], ".wal"):
+		index, ok := hex(parts[2])
+		offset, ok2 := hex(strings.TrimSuffix(parts[3], ".wal"))
+		return replicaKey{generation: parts[0], index: int(index), offset: offset}, ok && ok2
+	}
+	return replicaKey{}, false
+}
+
+// generation is what a replica holds of one generation.
+type generation struct {
+	snapshots map[int]string           // index → key
+	segments  map[int]map[int64]string // index → offset → key
+}
+
+// Restore rebuilds the database at path, which must not exist, from the
+// newest generation of the replica under prefix: its latest snapshot with
+// the WAL shipped after it replayed. It reports false, leaving path alone,
+// if there is no snapshot to restore.
+func Restore(ctx context.Context, store blob.Store, prefix, path string) (bool, error) {
+	generations := make(map[string]*generation)
+	err := store.List(ctx, prefix+generationsDir, func(obj blob.Object) error {
+		k, ok := parseKey(strings.TrimPrefix(obj.Key, prefix))
+		if !ok {
+			return nil
+		}
+		g := generations[k.generation]
+		if g == nil {
+			g = &generation{snapshots: make(map[int]string), segments: make(map[int]map[int64]string)}
+			generations[k.generation] = g
+		}
+		if k.snapshot {
+			g.snapshots[k.index] = obj.Key
+			return nil
+		}
+		if g.segments[k.index] == nil {
+			g.segments[k.index] = make(map[int64]string)
+		}
+		g.segments[k.index][k.offset] = obj.Key
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to list replica: %w", err)
+	}
+
+	// Generation IDs sort by when they started.
+	var latest string
+	for id, g := range generations {
+		if len(g.snapshots) > 0 && id > latest {
+			latest = id
+		}
+	}
+	if latest == "" {
+		return false, nil
+	}
+	g := generations[latest]
+	start := slices.Max(slices.Collect(maps.Keys(g.snapshots)))
+
+	tmp := path + ".restoring"
+	defer removeDB(tmp)
+	if err := download(ctx, store, g.snapshots[start], tmp); err != nil {
+		return false, err
+	}
+	for index := start; g.segments[index] != nil; index++ {
+		if err := replay(ctx, store, g.segments[index], tmp); err != nil {
+			return false, fmt.Errorf("failed to replay WAL index %d: %w", index, err)
+		}
+	}
+	if err := quickCheck(tmp); err != nil {
+		return false, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return false, fmt.Errorf("failed to move restored database into place: %w", err)
+	}
+	logger.Info("Database restored from replica", "generation", latest, "snapshot_index", start)
+	return true, nil
+}
+
+// replay applies the WAL segments of one index to the database at path by
+// putting them in place as its log and checkpointing. Segments after a gap
+// are left out.
+func replay(ctx context.Context, store blob.Store, segments map[int64]string, path string) error {
+	f, err := os.OpenFile(path+"-wal", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create WAL: %w", err)
+	}
+	var offset int64
+	for {
+		key, ok := segments[offset]
+		if !ok {
+			break
+		}
+		n, err := copyBlob(ctx, store, key, f)
+		if err != nil {
+			f.Close()
+			return err
+		}
+		offset += n
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write WAL: %w", err)
+	}
+
+	db, err := sql.Open("sqlite", "file:"+path)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+	if _, err := db.ExecContext(ctx, "PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
+		return fmt.Errorf("failed to checkpoint: %w", err)
+	}
+	return nil
+}
+
+// download writes the blob at key to a new file at path.
+func download(ctx context.Context, store blob.Store, key, path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create database: %w", err)
+	}
+	if _, err := copyBlob(ctx, store, key, f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write database: %w", err)
+	}
+	return nil
+}
+
+func copyBlob(ctx context.Context, store blob.Store, key string, w io.Writer) (int64, error) {
+	r, err := store.Get(ctx, key)
+	if err != nil {
+		return 0, fmt.Errorf("failed to download %s: %w", key, err)
+	}
+	defer r.Close()
+	n, err := io.Copy(w, r)
+	if err != nil {
+		return n, fmt.Errorf("failed to download %s: %w", key, err)
+	}
+	return n, nil
+}
+
+// quickCheck verifies the database at path isn't corrupt.
+func quickCheck(path string) error {
+	db, err := sql.Open("sqlite", "file:"+path)
+	if err != nil {
+		return fmt.Errorf("failed to open restored database: %w", err)
+	}
+	defer db.Close()
+	var result string
+	if err := db.QueryRow("PRAGMA quick_check(1)").Scan(&result); err != nil {
+		return fmt.Errorf("failed to check restored database: %w", err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("restored database is corrupt: %s", result)
+	}
+	return nil
+}
+
+// removeDB removes a database file and its log, if they're there.
+func removeDB(path string) {
+	for _, p := range []string{path, path + "-wal", path + "-shm"} {
+		if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+			logger.Warn("Failed to remove file", "path", p, "error", err)
+		}
+	}
+}
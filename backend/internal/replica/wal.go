@@ -0,0 +1,98 @@
+package replica
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Sizes in SQLite's write-ahead log format; see https://www.sqlite.org/fileformat.html#wal_file_format.
+const (
+	walHeaderSize      = 32
+	walFrameHeaderSize = 24
+)
+
+// walHeader is the part of a WAL file header that identifies one use of the
+// file: SQLite writes a new one, with new salts, each time it restarts the
+// log from the beginning.
+type walHeader struct {
+	bigEndian bool // checksums read the log as big-endian words
+	pageSize  uint32
+	salt1     uint32
+	salt2     uint32
+	checksum  [2]uint32
+}
+
+// readWALHeader reads the header at the start of a WAL file.
+func readWALHeader(r io.ReaderAt) (walHeader, error) {
+	b := make([]byte, walHeaderSize)
+	if _, err := r.ReadAt(b, 0); err != nil {
+		return walHeader{}, fmt.Errorf("failed to read WAL header: %w", err)
+	}
+	return parseWALHeader(b)
+}
+
+// parseWALHeader checks and decodes a WAL header.
+func parseWALHeader(b []byte) (walHeader, error) {
+	var h walHeader
+	switch magic := binary.BigEndian.Uint32(b[0:]); magic {
+	case 0x377f0682:
+	case 0x377f0683:
+		h.bigEndian = true
+	default:
+		return walHeader{}, fmt.Errorf("not a WAL file (magic %#x)", magic)
+	}
+	h.pageSize = binary.BigEndian.Uint32(b[8:])
+	h.salt1 = binary.BigEndian.Uint32(b[16:])
+	h.salt2 = binary.BigEndian.Uint32(b[20:])
+	h.checksum = [2]uint32{binary.BigEndian.Uint32(b[24:]), binary.BigEndian.Uint32(b[28:])}
+	if h.walChecksum(b[:24], [2]uint32{}) != h.checksum {
+		return walHeader{}, fmt.Errorf("WAL header checksum mismatch")
+	}
+	return h, nil
+}
+
+// frameSize is the size of one frame: a frame header and a page.
+func (h walHeader) frameSize() int {
+	return walFrameHeaderSize + int(h.pageSize)
+}
+
+// walChecksum extends the running checksum sum over b, whose length is a
+// multiple of 8, the way SQLite checksums the log.
+func (h walHeader) walChecksum(b []byte, sum [2]uint32) [2]uint32 {
+	order := binary.ByteOrder(binary.LittleEndian)
+	if h.bigEndian {
+		order = binary.BigEndian
+	}
+	s1, s2 := sum[0], sum[1]
+	for i := 0; i+8 <= len(b); i += 8 {
+		s1 += order.Uint32(b[i:]) + s2
+		s2 += order.Uint32(b[i+4:]) + s1
+	}
+	return [2]uint32{s1, s2}
+}
+
+// committedFrames returns how many bytes of frames, following a running
+// checksum of sum, belong to committed transactions of this use of the log,
+// and the checksum after them. It stops at the first frame left over from
+// an earlier use, torn by a write in progress or past the last commit.
+func (h walHeader) committedFrames(frames []byte, sum [2]uint32) (int, [2]uint32) {
+	size := h.frameSize()
+	committed, committedSum := 0, sum
+	for off := 0; off+size <= len(frames); off += size {
+		frame := frames[off : off+size]
+		if binary.BigEndian.Uint32(frame[8:]) != h.salt1 || binary.BigEndian.Uint32(frame[12:]) != h.salt2 {
+			break
+		}
+		sum = h.walChecksum(frame[:8], sum)
+		sum = h.walChecksum(frame[walFrameHeaderSize:], sum)
+		if sum != [2]uint32{binary.BigEndian.Uint32(frame[16:]), binary.BigEndian.Uint32(frame[20:])} {
+			break
+		}
+		if binary.BigEndian.Uint32(frame[4:]) != 0 {
+			// A nonzero database size marks the last frame of a commit.
+			committed, committedSum = off+size, sum
+		}
+	}
+	return committed, committedSum
+}
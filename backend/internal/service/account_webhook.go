@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/mmynk/splitwiser/internal/authctx"
+	"github.com/mmynk/splitwiser/internal/models"
+	"github.com/mmynk/splitwiser/internal/storage"
+	"github.com/mmynk/splitwiser/internal/webhook"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+)
+
+// accountWebhookToProto converts a model AccountWebhook to a proto
+// AccountWebhook, leaving out the secret.
+func accountWebhookToProto(hook *models.AccountWebhook) *pb.AccountWebhook {
+	return &pb.AccountWebhook{
+		Id:        hook.ID,
+		Url:       hook.URL,
+		CreatedAt: hook.CreatedAt,
+	}
+}
+
+// newWebhookSecret returns a random hex-encoded signing secret.
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate secret: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// notifyAccountWebhooks sends event to every account webhook the given users
+// registered. It runs after the write that caused the event; failures are
+// only logged since the write itself has succeeded.
+func notifyAccountWebhooks(ctx context.Context, store storage.Store, webhooks *webhook.Dispatcher, event webhook.AccountEvent, userIDs []string) {
+	if webhooks == nil {
+		return
+	}
+	slices.Sort(userIDs)
+	for _, userID := range slices.Compact(userIDs) {
+		if userID == "" {
+			continue
+		}
+		hooks, err := store.ListAccountWebhooks(ctx, userID)
+		if err != nil {
+			logger.Error("Account webhook delivery failed - could not list webhooks", "user_id", userID, "error", err)
+			continue
+		}
+		for _, hook := range hooks {
+			event.WebhookID = hook.ID
+			webhooks.SendSigned(hook.URL, hook.Secret, event)
+		}
+	}
+}
+
+// notifyBillChange tells the account webhooks of everyone bills involve, its
+// creator and participants with accounts, that the bill changed. Pass both
+// versions of an updated bill so removed participants hear about it too.
+func notifyBillChange(ctx context.Context, store storage.Store, webhooks *webhook.Dispatcher, eventType string, bills ...*models.Bill) {
+	if len(bills) == 0 {
+		return
+	}
+	var userIDs []string
+	for _, bill := range bills {
+		userIDs = append(userIDs, bill.CreatorID)
+		for _, p := range bill.Participants {
+			userIDs = append(userIDs, p.UserID)
+		}
+	}
+	bill := bills[len(bills)-1]
+	event := webhook.AccountEvent{
+		Type:       eventType,
+		BillID:     bill.ID,
+		GroupID:    bill.GroupID,
+		Title:      bill.Title,
+		Amount:     bill.Total,
+		Currency:   bill.Currency,
+		OccurredAt: time.Now().Unix(),
+	}
+	notifyAccountWebhooks(ctx, store, webhooks, event, userIDs)
+}
+
+// notifySettlementChange tells the account webhooks of the settlement's
+// payer and payee, found by display name among members, and of actorID, who
+// recorded or deleted it, that the settlement changed.
+func notifySettlementChange(ctx context.Context, store storage.Store, webhooks *webhook.Dispatcher, eventType string, settlement *models.Settlement, members []models.GroupMember, actorID string) {
+	userIDs := []string{actorID}
+	for _, m := range members {
+		if m.DisplayName == settlement.FromUserID || m.DisplayName == settlement.ToUserID {
+			userIDs = append(userIDs, m.UserID)
+		}
+	}
+	event := webhook.AccountEvent{
+		Type:         eventType,
+		SettlementID: settlement.ID,
+		Title:        settlement.Note,
+		Amount:       settlement.Amount,
+		OccurredAt:   time.Now().Unix(),
+	}
+	if settlement.GroupID != nil {
+		event.GroupID = *settlement.GroupID
+	}
+	notifyAccountWebhooks(ctx, store, webhooks, event, userIDs)
+}
+
+// CreateAccountWebhook registers a webhook that receives every bill and
+// settlement change involving the caller. The signing secret is returned
+// only here.
+func (s *SplitService) CreateAccountWebhook(ctx context.Context, req *connect.Request[pb.CreateAccountWebhookRequest]) (*connect.Response[pb.CreateAccountWebhookResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+	}
+	if err := validateWebhookURL(req.Msg.Url); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		logger.Error("CreateAccountWebhook failed", "error", err)
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	hook := &models.AccountWebhook{
+		UserID: userID,
+		URL:    req.Msg.Url,
+		Secret: secret,
+	}
+	if err := s.store.CreateAccountWebhook(ctx, hook); err != nil {
+		logger.Error("CreateAccountWebhook failed", "error", err)
+		return nil, storeError(err)
+	}
+
+	return connect.NewResponse(&pb.CreateAccountWebhookResponse{
+		Webhook: accountWebhookToProto(hook),
+		Secret:  secret,
+	}), nil
+}
+
+// ListAccountWebhooks lists the caller's account webhooks.
+func (s *SplitService) ListAccountWebhooks(ctx context.Context, req *connect.Request[pb.ListAccountWebhooksRequest]) (*connect.Response[pb.ListAccountWebhooksResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+	}
+
+	hooks, err := s.store.ListAccountWebhooks(ctx, userID)
+	if err != nil {
+		logger.Error("ListAccountWebhooks failed", "user_id", userID, "error", err)
+		return nil, storeError(err)
+	}
+
+	pbHooks := make([]*pb.AccountWebhook, len(hooks))
+	for i, hook := range hooks {
+		pbHooks[i] = accountWebhookToProto(hook)
+	}
+	return connect.NewResponse(&pb.ListAccountWebhooksResponse{Webhooks: pbHooks}), nil
+}
+
+// DeleteAccountWebhook removes one of the caller's account webhooks.
+func (s *SplitService) DeleteAccountWebhook(ctx context.Context, req *connect.Request[pb.DeleteAccountWebhookRequest]) (*connect.Response[pb.DeleteAccountWebhookResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+	}
+
+	webhookID := req.Msg.GetWebhookId()
+	if webhookID == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("webhook_id required"))
+	}
+	// Someone else's webhook is reported as missing rather than forbidden.
+	hook, err := s.store.GetAccountWebhook(ctx, webhookID)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		logger.Error("DeleteAccountWebhook failed", "error", err)
+		return nil, storeError(err)
+	}
+	if err != nil || hook.UserID != userID {
+		return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("webhook not found"))
+	}
+
+	if err := s.store.DeleteAccountWebhook(ctx, webhookID); err != nil {
+		logger.Error("DeleteAccountWebhook failed", "error", err)
+		return nil, storeError(err)
+	}
+	return connect.NewResponse(&pb.DeleteAccountWebhookResponse{}), nil
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/mmynk/splitwiser/internal/webhook"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+)
+
+// signedDelivery is an account event as received, with its signature header.
+type signedDelivery struct {
+	event     webhook.AccountEvent
+	body      []byte
+	signature string
+}
+
+// accountWebhookReceiver starts a server that records every account event posted to it.
+func accountWebhookReceiver(t *testing.T) (string, <-chan signedDelivery) {
+	t.Helper()
+	deliveries := make(chan signedDelivery, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("failed to read webhook body: %v", err)
+		}
+		d := signedDelivery{body: body, signature: r.Header.Get(webhook.SignatureHeader)}
+		if err := json.Unmarshal(body, &d.event); err != nil {
+			t.Errorf("failed to decode webhook body: %v", err)
+		}
+		deliveries <- d
+	}))
+	t.Cleanup(server.Close)
+	return server.URL, deliveries
+}
+
+func nextDelivery(t *testing.T, deliveries <-chan signedDelivery) signedDelivery {
+	t.Helper()
+	select {
+	case d := <-deliveries:
+		return d
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for account webhook event")
+		return signedDelivery{}
+	}
+}
+
+func TestAccountWebhook(t *testing.T) {
+	groupClient, splitClient, cleanup := setupGroupTestServer(t, WithWebhooks(webhook.NewDispatcher(5*time.Second)))
+	defer cleanup()
+	ctx := context.Background()
+	url, deliveries := accountWebhookReceiver(t)
+
+	hookResp, err := splitClient.CreateAccountWebhook(ctx, connect.NewRequest(&pb.CreateAccountWebhookRequest{Url: url}))
+	if err != nil {
+		t.Fatalf("CreateAccountWebhook failed: %v", err)
+	}
+	hookID, secret := hookResp.Msg.Webhook.Id, hookResp.Msg.Secret
+	if secret == "" {
+		t.Fatal("expected a signing secret on creation")
+	}
+	_, err = splitClient.CreateAccountWebhook(ctx, connect.NewRequest(&pb.CreateAccountWebhookRequest{Url: "ftp://example.com"}))
+	if connect.CodeOf(err) != connect.CodeInvalidArgument {
+		t.Errorf("ftp URL: code = %v, want InvalidArgument", connect.CodeOf(err))
+	}
+
+	groupResp, err := groupClient.CreateGroup(ctx, connect.NewRequest(&pb.CreateGroupRequest{
+		Name:    "Flat",
+		Members: gm("Alice", "Bob"),
+	}))
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	groupID := groupResp.Msg.Group.Id
+
+	billResp, err := splitClient.CreateBill(ctx, connect.NewRequest(&pb.CreateBillRequest{
+		Title:        "Groceries",
+		Total:        40,
+		Subtotal:     40,
+		Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+		GroupId:      &groupID,
+	}))
+	if err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+
+	d := nextDelivery(t, deliveries)
+	if d.signature != webhook.Sign(secret, d.body) {
+		t.Errorf("signature = %q, want the body's HMAC under the webhook secret", d.signature)
+	}
+	want := webhook.AccountEvent{
+		Type: webhook.EventBillCreated, WebhookID: hookID, BillID: billResp.Msg.BillId,
+		GroupID: groupID, Title: "Groceries", Amount: 40, OccurredAt: d.event.OccurredAt,
+	}
+	if d.event != want {
+		t.Errorf("event = %+v, want %+v", d.event, want)
+	}
+
+	if _, err := groupClient.RecordSettlement(ctx, connect.NewRequest(&pb.RecordSettlementRequest{
+		GroupId:    groupID,
+		FromUserId: "Bob",
+		ToUserId:   "Alice",
+		Amount:     20,
+	})); err != nil {
+		t.Fatalf("RecordSettlement failed: %v", err)
+	}
+	if d := nextDelivery(t, deliveries); d.event.Type != webhook.EventSettlementRecorded || d.event.Amount != 20 {
+		t.Errorf("event = %+v, want a 20 settlement", d.event)
+	}
+
+	if _, err := splitClient.DeleteBill(ctx, connect.NewRequest(&pb.DeleteBillRequest{BillId: billResp.Msg.BillId})); err != nil {
+		t.Fatalf("DeleteBill failed: %v", err)
+	}
+	if d := nextDelivery(t, deliveries); d.event.Type != webhook.EventBillDeleted || d.event.BillID != billResp.Msg.BillId {
+		t.Errorf("event = %+v, want the bill's deletion", d.event)
+	}
+
+	listResp, err := splitClient.ListAccountWebhooks(ctx, connect.NewRequest(&pb.ListAccountWebhooksRequest{}))
+	if err != nil {
+		t.Fatalf("ListAccountWebhooks failed: %v", err)
+	}
+	if len(listResp.Msg.Webhooks) != 1 || listResp.Msg.Webhooks[0].Id != hookID {
+		t.Fatalf("webhooks = %v, want the one created", listResp.Msg.Webhooks)
+	}
+	if _, err := splitClient.DeleteAccountWebhook(ctx, connect.NewRequest(&pb.DeleteAccountWebhookRequest{WebhookId: hookID})); err != nil {
+		t.Fatalf("DeleteAccountWebhook failed: %v", err)
+	}
+	_, err = splitClient.DeleteAccountWebhook(ctx, connect.NewRequest(&pb.DeleteAccountWebhookRequest{WebhookId: hookID}))
+	if connect.CodeOf(err) != connect.CodeNotFound {
+		t.Errorf("second delete: code = %v, want NotFound", connect.CodeOf(err))
+	}
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/mmynk/splitwiser/internal/authctx"
+	"github.com/mmynk/splitwiser/internal/models"
+	"github.com/mmynk/splitwiser/internal/storage"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+	"github.com/mmynk/splitwiser/pkg/splitmath"
+)
+
+// activityNouns names each kind of event in a coalesced entry's summary.
+var activityNouns = map[pb.ActivityKind]string{
+	pb.ActivityKind_ACTIVITY_KIND_BILL_ADDED:          "bills added",
+	pb.ActivityKind_ACTIVITY_KIND_SETTLEMENT_RECORDED: "settlements recorded",
+}
+
+// groupActivity sorts events newest first, keeping events of one kind from
+// the same second together, and splits them into days in loc. Within a day,
+// consecutive events of the same kind by the same actor share an entry while
+// each is no more than window after the next older one, so a burst of bills
+// reads as "3 bills added by Alice". A zero window gives every event its own
+// entry.
+func groupActivity(events []*pb.ActivityEvent, window time.Duration, loc *time.Location) []*pb.ActivityDay {
+	events = slices.Clone(events)
+	slices.SortStableFunc(events, func(a, b *pb.ActivityEvent) int {
+		return cmp.Or(cmp.Compare(b.CreatedAt, a.CreatedAt), cmp.Compare(a.Kind, b.Kind), cmp.Compare(b.Id, a.Id))
+	})
+
+	var days []*pb.ActivityDay
+	var entry *pb.ActivityEntry
+	for _, ev := range events {
+		date := time.Unix(ev.CreatedAt, 0).In(loc).Format("2006-01-02")
+		if len(days) == 0 || days[len(days)-1].Date != date {
+			days = append(days, &pb.ActivityDay{Date: date})
+			entry = nil
+		}
+		day := days[len(days)-1]
+
+		if window > 0 && entry != nil && entry.Kind == ev.Kind && entry.Actor == ev.Actor {
+			newer := entry.Events[len(entry.Events)-1]
+			if time.Duration(newer.CreatedAt-ev.CreatedAt)*time.Second <= window {
+				entry.Events = append(entry.Events, ev)
+				continue
+			}
+		}
+		entry = &pb.ActivityEntry{Kind: ev.Kind, Actor: ev.Actor, Events: []*pb.ActivityEvent{ev}}
+		day.Entries = append(day.Entries, entry)
+	}
+
+	for _, day := range days {
+		for _, entry := range day.Entries {
+			if len(entry.Events) == 1 {
+				entry.Summary = entry.Events[0].Summary
+				continue
+			}
+			entry.Summary = fmt.Sprintf("%d %s by %s", len(entry.Events), activityNouns[entry.Kind], entry.Actor)
+		}
+	}
+	return days
+}
+
+// activityEvents turns a group's bills and settlements into feed events,
+// naming each by the member who added it.
+func (s *GroupService) activityEvents(ctx context.Context, group *models.Group, bills []*models.Bill, settlements []*models.Settlement) []*pb.ActivityEvent {
+	names := make(map[string]string, len(group.Members))
+	for _, m := range group.Members {
+		if m.UserID != "" {
+			names[m.UserID] = m.DisplayName
+		}
+	}
+	actor := func(userID, fallback string) string {
+		if userID == "" {
+			return fallback
+		}
+		if name, ok := names[userID]; ok {
+			return name
+		}
+		names[userID] = s.resolveDisplayName(ctx, userID)
+		return names[userID]
+	}
+
+	events := make([]*pb.ActivityEvent, 0, len(bills)+len(settlements))
+	for _, bill := range bills {
+		name := actor(bill.CreatorID, bill.PayerID)
+		events = append(events, &pb.ActivityEvent{
+			Kind:      pb.ActivityKind_ACTIVITY_KIND_BILL_ADDED,
+			Id:        bill.ID,
+			Actor:     name,
+			CreatedAt: bill.CreatedAt,
+			Summary:   fmt.Sprintf("%s added %s (%s)", name, bill.Title, splitmath.FormatAmount(bill.Total, bill.Currency)),
+		})
+	}
+	for _, st := range settlements {
+		events = append(events, &pb.ActivityEvent{
+			Kind:      pb.ActivityKind_ACTIVITY_KIND_SETTLEMENT_RECORDED,
+			Id:        st.ID,
+			Actor:     actor(st.CreatedBy, st.FromUserID),
+			CreatedAt: st.CreatedAt,
+			Summary:   fmt.Sprintf("%s paid %s %s", st.FromUserID, st.ToUserID, splitmath.FormatAmount(st.Amount, "")),
+		})
+	}
+	return events
+}
+
+// GetGroupActivity returns a group's bills and settlements as a feed grouped
+// by day, with bursts of similar events coalesced into one entry.
+func (s *GroupService) GetGroupActivity(ctx context.Context, req *connect.Request[pb.GetGroupActivityRequest]) (*connect.Response[pb.GetGroupActivityResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+	}
+
+	if req.Msg.CoalesceWindowSeconds < 0 {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("coalesce_window_seconds must not be negative"))
+	}
+	loc := time.UTC
+	if req.Msg.TimeZone != "" {
+		var err error
+		if loc, err = time.LoadLocation(req.Msg.TimeZone); err != nil {
+			return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("invalid time zone %q", req.Msg.TimeZone))
+		}
+	}
+
+	groupID := req.Msg.GetGroupId()
+	if err := s.requireMembership(ctx, userID, groupID); err != nil {
+		return nil, err
+	}
+
+	group, err := s.store.GetGroup(ctx, groupID)
+	if err != nil {
+		logger.Error("GetGroupActivity failed to get group", "group_id", groupID, "error", err)
+		return nil, storeError(err)
+	}
+	bills, err := s.store.ListBillsByGroup(ctx, groupID, storage.BillFilter{})
+	if err != nil {
+		logger.Error("GetGroupActivity failed to list bills", "group_id", groupID, "error", err)
+		return nil, storeError(err)
+	}
+	settlements, err := s.store.ListSettlementsByGroup(ctx, groupID, storage.SettlementFilter{})
+	if err != nil {
+		logger.Error("GetGroupActivity failed to list settlements", "group_id", groupID, "error", err)
+		return nil, storeError(err)
+	}
+
+	window := time.Duration(req.Msg.CoalesceWindowSeconds) * time.Second
+	days := groupActivity(s.activityEvents(ctx, group, bills, settlements), window, loc)
+	return connect.NewResponse(&pb.GetGroupActivityResponse{Days: days}), nil
+}
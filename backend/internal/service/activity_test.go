@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+)
+
+func TestGroupActivity(t *testing.T) {
+	bill := pb.ActivityKind_ACTIVITY_KIND_BILL_ADDED
+	settle := pb.ActivityKind_ACTIVITY_KIND_SETTLEMENT_RECORDED
+	at := func(s string) int64 {
+		ts, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ts.Unix()
+	}
+	events := []*pb.ActivityEvent{
+		{Id: "b1", Kind: bill, Actor: "Alice", CreatedAt: at("2026-03-01T22:00:00Z"), Summary: "Alice added Taxi"},
+		{Id: "b2", Kind: bill, Actor: "Alice", CreatedAt: at("2026-03-01T22:10:00Z"), Summary: "Alice added Drinks"},
+		{Id: "b3", Kind: bill, Actor: "Alice", CreatedAt: at("2026-03-01T22:20:00Z"), Summary: "Alice added Snacks"},
+		{Id: "b4", Kind: bill, Actor: "Alice", CreatedAt: at("2026-03-01T23:50:00Z"), Summary: "Alice added Pizza"},
+		{Id: "b5", Kind: bill, Actor: "Alice", CreatedAt: at("2026-03-02T00:05:00Z"), Summary: "Alice added Coffee"},
+		{Id: "s1", Kind: settle, Actor: "Bob", CreatedAt: at("2026-03-02T00:10:00Z"), Summary: "Bob paid Alice 10.00"},
+	}
+
+	render := func(days []*pb.ActivityDay) map[string][]string {
+		got := map[string][]string{}
+		for _, day := range days {
+			for _, entry := range day.Entries {
+				got[day.Date] = append(got[day.Date], entry.Summary)
+			}
+		}
+		return got
+	}
+
+	t.Run("window coalesces bursts within a day", func(t *testing.T) {
+		days := groupActivity(events, 15*time.Minute, time.UTC)
+		if len(days) != 2 || days[0].Date != "2026-03-02" || days[1].Date != "2026-03-01" {
+			t.Fatalf("days = %v, want 2026-03-02 then 2026-03-01", days)
+		}
+		got := render(days)
+		// Coffee is within the window of Pizza but falls on the next day.
+		want := map[string][]string{
+			"2026-03-02": {"Bob paid Alice 10.00", "Alice added Coffee"},
+			"2026-03-01": {"Alice added Pizza", "3 bills added by Alice"},
+		}
+		for date, summaries := range want {
+			if len(got[date]) != len(summaries) {
+				t.Fatalf("%s = %v, want %v", date, got[date], summaries)
+			}
+			for i := range summaries {
+				if got[date][i] != summaries[i] {
+					t.Errorf("%s[%d] = %q, want %q", date, i, got[date][i], summaries[i])
+				}
+			}
+		}
+		if burst := days[1].Entries[1]; burst.Events[0].Id != "b3" || burst.Events[2].Id != "b1" {
+			t.Errorf("burst events not newest first: %v", burst.Events)
+		}
+	})
+
+	t.Run("zero window never coalesces", func(t *testing.T) {
+		days := groupActivity(events, 0, time.UTC)
+		if n := len(days[1].Entries); n != 4 {
+			t.Errorf("2026-03-01 has %d entries, want 4", n)
+		}
+	})
+
+	t.Run("time zone moves day boundaries", func(t *testing.T) {
+		loc := time.FixedZone("UTC+2", 2*60*60)
+		days := groupActivity(events, 2*time.Hour, loc)
+		if len(days) != 1 || days[0].Date != "2026-03-02" {
+			t.Fatalf("days = %v, want everything on 2026-03-02", days)
+		}
+		got := render(days)["2026-03-02"]
+		if len(got) != 2 || got[1] != "5 bills added by Alice" {
+			t.Errorf("entries = %v, want settlement then 5 bills", got)
+		}
+	})
+}
+
+func TestGetGroupActivity(t *testing.T) {
+	groupClient, splitClient, cleanup := setupGroupTestServer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	groupResp, err := groupClient.CreateGroup(ctx, connect.NewRequest(&pb.CreateGroupRequest{
+		Name:    "Trip",
+		Members: gm("Alice", "Bob"),
+	}))
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	groupID := groupResp.Msg.Group.Id
+
+	for _, title := range []string{"Taxi", "Dinner"} {
+		if _, err := splitClient.CreateBill(ctx, connect.NewRequest(&pb.CreateBillRequest{
+			Title:        title,
+			Total:        30,
+			Subtotal:     30,
+			Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+			GroupId:      &groupID,
+		})); err != nil {
+			t.Fatalf("CreateBill failed: %v", err)
+		}
+	}
+	if _, err := groupClient.RecordSettlement(ctx, connect.NewRequest(&pb.RecordSettlementRequest{
+		GroupId:    groupID,
+		FromUserId: "Bob",
+		ToUserId:   "Alice",
+		Amount:     15,
+	})); err != nil {
+		t.Fatalf("RecordSettlement failed: %v", err)
+	}
+
+	resp, err := groupClient.GetGroupActivity(ctx, connect.NewRequest(&pb.GetGroupActivityRequest{
+		GroupId:               groupID,
+		CoalesceWindowSeconds: 3600,
+	}))
+	if err != nil {
+		t.Fatalf("GetGroupActivity failed: %v", err)
+	}
+	var kinds, total int
+	for _, day := range resp.Msg.Days {
+		for _, entry := range day.Entries {
+			kinds++
+			total += len(entry.Events)
+			if entry.Kind == pb.ActivityKind_ACTIVITY_KIND_BILL_ADDED && entry.Summary != "2 bills added by Alice" {
+				t.Errorf("bill entry summary = %q, want 2 bills added by Alice", entry.Summary)
+			}
+		}
+	}
+	if total != 3 {
+		t.Errorf("feed has %d events, want 3", total)
+	}
+	if kinds != 2 {
+		t.Errorf("feed has %d entries, want one for the bills and one for the settlement", kinds)
+	}
+
+	_, err = groupClient.GetGroupActivity(ctx, connect.NewRequest(&pb.GetGroupActivityRequest{GroupId: groupID, TimeZone: "Mars/Olympus"}))
+	if connect.CodeOf(err) != connect.CodeInvalidArgument {
+		t.Errorf("bad time zone: code = %v, want InvalidArgument", connect.CodeOf(err))
+	}
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"connectrpc.com/connect"
+	"github.com/mmynk/splitwiser/internal/authctx"
+	"github.com/mmynk/splitwiser/internal/models"
+	"github.com/mmynk/splitwiser/internal/webhook"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+)
+
+// isUnassigned reports whether no one has been given an item yet, so it
+// only counts toward the bill's remainder. Household and personal items
+// and items split by units are assigned by those instead.
+func isUnassigned(item models.Item) bool {
+	return len(item.Participants) == 0 && len(item.Units) == 0 && item.Category == ""
+}
+
+// AssignRemainingItems splits every unassigned item of a bill equally among
+// the chosen participants, or everyone on the bill if none are chosen, so a
+// long receipt doesn't need each leftover item assigned by hand. The change
+// goes through the same checks as UpdateBill.
+func (s *SplitService) AssignRemainingItems(ctx context.Context, req *connect.Request[pb.AssignRemainingItemsRequest]) (*connect.Response[pb.AssignRemainingItemsResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+	}
+	if req.Msg.BillId == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("bill_id required"))
+	}
+
+	existing, err := s.store.GetBill(ctx, req.Msg.BillId)
+	if err != nil {
+		logger.Error("AssignRemainingItems: failed to get existing bill", "bill_id", req.Msg.BillId, "error", err)
+		return nil, storeError(err)
+	}
+	if !hasAccess(userID, existing) {
+		return nil, connect.NewError(connect.CodePermissionDenied, fmt.Errorf("you must be a participant to update this bill"))
+	}
+	if existing.NeedsAssignment {
+		return nil, connect.NewError(connect.CodeFailedPrecondition, fmt.Errorf("bill is awaiting participant assignment"))
+	}
+
+	assignees := participantDisplayNames(existing.Participants)
+	if len(req.Msg.ParticipantIds) > 0 {
+		assignees = nil
+		for _, name := range req.Msg.ParticipantIds {
+			if !slices.Contains(participantDisplayNames(existing.Participants), name) {
+				return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("%q is not on this bill", name))
+			}
+			if !slices.Contains(assignees, name) {
+				assignees = append(assignees, name)
+			}
+		}
+	}
+
+	msg := billToUpdateRequest(existing)
+	msg.ExchangeRate = existing.ExchangeRate
+	newlyAssigned := make([]bool, len(existing.Items))
+	for i, item := range existing.Items {
+		if isUnassigned(item) {
+			msg.Items[i].ParticipantIds = slices.Clone(assignees)
+			newlyAssigned[i] = true
+		}
+	}
+	if !slices.Contains(newlyAssigned, true) {
+		return nil, connect.NewError(connect.CodeFailedPrecondition, fmt.Errorf("bill has no unassigned items"))
+	}
+
+	existingBill, bill, err := s.billFromUpdate(ctx, "AssignRemainingItems", userID, msg)
+	if err != nil {
+		return nil, err
+	}
+	split, err := billSplit(bill, false)
+	if err != nil {
+		logger.Error("CalculateSplit failed during AssignRemainingItems", "error", err)
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	if err := s.store.UpdateBill(ctx, bill); err != nil {
+		logger.Error("AssignRemainingItems failed", "error", err)
+		return nil, storeError(err)
+	}
+	checkBalanceThresholds(ctx, s.store, s.webhooks, bill.GroupID)
+	notifyBillChange(ctx, s.store, s.webhooks, webhook.EventBillUpdated, existingBill, bill)
+
+	resp := &pb.AssignRemainingItemsResponse{BillId: bill.ID, Split: split}
+	for i, item := range bill.Items {
+		resp.Assignments = append(resp.Assignments, &pb.ItemAssignment{
+			Index:          int32(i),
+			Description:    item.Description,
+			ParticipantIds: item.Participants,
+			NewlyAssigned:  newlyAssigned[i],
+		})
+	}
+	return connect.NewResponse(resp), nil
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"connectrpc.com/connect"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+)
+
+func TestAssignRemainingItems(t *testing.T) {
+	splitClient, cleanup := setupTestServer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	created, err := splitClient.CreateBill(ctx, connect.NewRequest(&pb.CreateBillRequest{
+		Title:    "Market",
+		Total:    60,
+		Subtotal: 60,
+		Items: []*pb.Item{
+			{Description: "Wine", Amount: 30, ParticipantIds: []string{"Alice"}},
+			{Description: "Bread", Amount: 6},
+			{Description: "Olives", Amount: 9},
+			{Description: "Soap", Amount: 15, Category: pb.ItemCategory_ITEM_CATEGORY_HOUSEHOLD},
+		},
+		PayerId:      strPtr("Alice"),
+		Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob"), guestBP("Carol")},
+	}))
+	if err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+	billID := created.Msg.BillId
+
+	resp, err := splitClient.AssignRemainingItems(ctx, connect.NewRequest(&pb.AssignRemainingItemsRequest{
+		BillId:         billID,
+		ParticipantIds: []string{"Bob", "Carol"},
+	}))
+	if err != nil {
+		t.Fatalf("AssignRemainingItems failed: %v", err)
+	}
+	var newly []string
+	for _, a := range resp.Msg.Assignments {
+		if a.NewlyAssigned {
+			newly = append(newly, a.Description)
+			if !slices.Equal(a.ParticipantIds, []string{"Bob", "Carol"}) {
+				t.Errorf("%s assigned to %v, want Bob and Carol", a.Description, a.ParticipantIds)
+			}
+		}
+	}
+	if !slices.Equal(newly, []string{"Bread", "Olives"}) {
+		t.Errorf("newly assigned = %v, want Bread and Olives", newly)
+	}
+	// Bob: half of bread and olives (7.50) plus a third of the soap (5).
+	if got := resp.Msg.Split.Splits["Bob"].GetTotal(); got != 12.5 {
+		t.Errorf("Bob's total = %v, want 12.5", got)
+	}
+
+	bill, err := splitClient.GetBill(ctx, connect.NewRequest(&pb.GetBillRequest{BillId: billID}))
+	if err != nil {
+		t.Fatalf("GetBill failed: %v", err)
+	}
+	if got := bill.Msg.Items[1].ParticipantIds; !slices.Equal(got, []string{"Bob", "Carol"}) {
+		t.Errorf("saved bread participants = %v, want Bob and Carol", got)
+	}
+
+	_, err = splitClient.AssignRemainingItems(ctx, connect.NewRequest(&pb.AssignRemainingItemsRequest{BillId: billID}))
+	if connect.CodeOf(err) != connect.CodeFailedPrecondition {
+		t.Errorf("with nothing left to assign: expected FailedPrecondition, got %v", err)
+	}
+	_, err = splitClient.AssignRemainingItems(ctx, connect.NewRequest(&pb.AssignRemainingItemsRequest{BillId: billID, ParticipantIds: []string{"Dave"}}))
+	if connect.CodeOf(err) != connect.CodeInvalidArgument {
+		t.Errorf("assigning to someone not on the bill: expected InvalidArgument, got %v", err)
+	}
+}
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/google/uuid"
+	"github.com/mmynk/splitwiser/internal/authctx"
+	"github.com/mmynk/splitwiser/internal/blob"
+	"github.com/mmynk/splitwiser/internal/models"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+)
+
+// attachmentTypes are the content types accepted for attachments: receipt
+// photos and scanned or emailed receipts.
+var attachmentTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/webp":      true,
+	"image/heic":      true,
+	"application/pdf": true,
+}
+
+// maxAttachmentName bounds attachment file names, in bytes.
+const maxAttachmentName = 255
+
+// attachmentLinkExpiry is how long GetAttachment's download links stay valid.
+const attachmentLinkExpiry = 15 * time.Minute
+
+// heicBrands are the ISO base media file brands of HEIC/HEIF photos.
+var heicBrands = []string{"heic", "heix", "hevc", "hevx", "mif1", "msf1"}
+
+// sniffContentType reports the content type of data from its leading bytes.
+// It extends http.DetectContentType with HEIC, which iPhones use for photos.
+func sniffContentType(data []byte) string {
+	if len(data) >= 12 && string(data[4:8]) == "ftyp" {
+		for _, brand := range heicBrands {
+			if string(data[8:12]) == brand {
+				return "image/heic"
+			}
+		}
+	}
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(data))
+	return contentType
+}
+
+// attachmentName cleans a client-supplied file name down to its base name.
+func attachmentName(name string) (string, error) {
+	name = strings.TrimSpace(path.Base(strings.ReplaceAll(name, "\\", "/")))
+	if name == "" || name == "." || name == "/" {
+		return "", fmt.Errorf("name required")
+	}
+	if len(name) > maxAttachmentName {
+		return "", fmt.Errorf("name must be at most %d bytes", maxAttachmentName)
+	}
+	return name, nil
+}
+
+func attachmentToProto(a *models.Attachment) *pb.Attachment {
+	return &pb.Attachment{
+		Id:          a.ID,
+		BillId:      a.BillID,
+		Name:        a.Name,
+		ContentType: a.ContentType,
+		Size:        a.Size,
+		Sha256:      a.SHA256,
+		UploaderId:  a.UploaderID,
+		CreatedAt:   a.CreatedAt,
+	}
+}
+
+// attachmentBill loads the bill an attachment belongs to, if userID may see it.
+func (s *SplitService) attachmentBill(ctx context.Context, userID, billID string) (*models.Bill, error) {
+	bill, err := s.store.GetBill(ctx, billID)
+	if err != nil {
+		return nil, storeError(err)
+	}
+	if !hasAccess(userID, bill) {
+		return nil, connect.NewError(connect.CodePermissionDenied, fmt.Errorf("you must be a participant to access this bill's attachments"))
+	}
+	return bill, nil
+}
+
+// UploadAttachment stores a receipt image or PDF with a bill. The content
+// type is detected from the data; a declared type that disagrees with it is
+// rejected.
+func (s *SplitService) UploadAttachment(ctx context.Context, req *connect.Request[pb.UploadAttachmentRequest]) (*connect.Response[pb.UploadAttachmentResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+	}
+	if s.blobs == nil {
+		return nil, connect.NewError(connect.CodeUnimplemented, fmt.Errorf("attachments are not enabled on this server"))
+	}
+
+	if req.Msg.BillId == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("bill_id required"))
+	}
+	name, err := attachmentName(req.Msg.Name)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+	data := req.Msg.Data
+	size := int64(len(data))
+	if size == 0 {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("data required"))
+	}
+	if s.maxAttachmentBytes > 0 && size > s.maxAttachmentBytes {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("attachment is %d bytes; the limit is %d", size, s.maxAttachmentBytes))
+	}
+	contentType := sniffContentType(data)
+	if !attachmentTypes[contentType] {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("unsupported attachment type %s; upload a JPEG, PNG, WebP or HEIC image or a PDF", contentType))
+	}
+	if declared := req.Msg.ContentType; declared != "" {
+		if mediaType, _, err := mime.ParseMediaType(declared); err != nil || mediaType != contentType {
+			return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("content_type %s does not match the data, which is %s", declared, contentType))
+		}
+	}
+
+	bill, err := s.attachmentBill(ctx, userID, req.Msg.BillId)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.quotas.CheckAttachment(ctx, userID, size); err != nil {
+		return nil, quotaError(err)
+	}
+
+	sum := sha256.Sum256(data)
+	attachment := &models.Attachment{
+		ID:          uuid.New().String(),
+		BillID:      bill.ID,
+		UploaderID:  userID,
+		Name:        name,
+		ContentType: contentType,
+		Size:        size,
+		SHA256:      hex.EncodeToString(sum[:]),
+	}
+	attachment.BlobKey = "attachments/" + bill.ID + "/" + attachment.ID
+
+	if err := s.blobs.Put(ctx, attachment.BlobKey, bytes.NewReader(data), size, contentType); err != nil {
+		logger.Error("UploadAttachment failed to store blob", "bill_id", bill.ID, "error", err)
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to store attachment"))
+	}
+	if err := s.store.CreateAttachment(ctx, attachment); err != nil {
+		logger.Error("UploadAttachment failed", "bill_id", bill.ID, "error", err)
+		if err := s.blobs.Delete(context.WithoutCancel(ctx), attachment.BlobKey); err != nil {
+			logger.Error("UploadAttachment failed to remove blob", "key", attachment.BlobKey, "error", err)
+		}
+		return nil, storeError(err)
+	}
+
+	return connect.NewResponse(&pb.UploadAttachmentResponse{Attachment: attachmentToProto(attachment)}), nil
+}
+
+// GetAttachment returns an attachment with its contents, or a short-lived
+// link to them, to anyone who can see its bill.
+func (s *SplitService) GetAttachment(ctx context.Context, req *connect.Request[pb.GetAttachmentRequest]) (*connect.Response[pb.GetAttachmentResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+	}
+	if s.blobs == nil {
+		return nil, connect.NewError(connect.CodeUnimplemented, fmt.Errorf("attachments are not enabled on this server"))
+	}
+
+	attachmentID := req.Msg.AttachmentId
+	if attachmentID == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("attachment_id required"))
+	}
+	attachment, err := s.store.GetAttachment(ctx, attachmentID)
+	if err != nil {
+		return nil, storeError(err)
+	}
+	if _, err := s.attachmentBill(ctx, userID, attachment.BillID); err != nil {
+		return nil, err
+	}
+
+	if req.Msg.Link {
+		url, err := s.blobs.SignedURL(ctx, attachment.BlobKey, attachmentLinkExpiry)
+		if err != nil {
+			logger.Error("GetAttachment failed to sign URL", "attachment_id", attachmentID, "error", err)
+			return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to link attachment"))
+		}
+		return connect.NewResponse(&pb.GetAttachmentResponse{
+			Attachment: attachmentToProto(attachment),
+			Url:        url,
+		}), nil
+	}
+
+	r, err := s.blobs.Get(ctx, attachment.BlobKey)
+	if errors.Is(err, blob.ErrNotFound) {
+		logger.Error("GetAttachment found no blob", "attachment_id", attachmentID, "key", attachment.BlobKey)
+		return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("attachment contents are missing"))
+	}
+	if err != nil {
+		logger.Error("GetAttachment failed to open blob", "attachment_id", attachmentID, "error", err)
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to read attachment"))
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		logger.Error("GetAttachment failed to read blob", "attachment_id", attachmentID, "error", err)
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to read attachment"))
+	}
+
+	return connect.NewResponse(&pb.GetAttachmentResponse{
+		Attachment: attachmentToProto(attachment),
+		Data:       data,
+	}), nil
+}
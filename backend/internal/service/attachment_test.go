@@ -0,0 +1,135 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/mmynk/splitwiser/internal/blob"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+)
+
+// testPNG is the 8-byte PNG signature followed by an IHDR chunk header,
+// enough for content sniffing.
+var testPNG = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01")
+
+func TestSniffContentType(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"png", testPNG, "image/png"},
+		{"jpeg", []byte("\xff\xd8\xff\xe0\x00\x10JFIF\x00"), "image/jpeg"},
+		{"pdf", []byte("%PDF-1.7\n"), "application/pdf"},
+		{"heic", []byte("\x00\x00\x00\x18ftypheic\x00\x00\x00\x00"), "image/heic"},
+		{"text", []byte("just some text"), "text/plain"},
+	}
+	for _, tt := range tests {
+		if got := sniffContentType(tt.data); got != tt.want {
+			t.Errorf("%s: sniffContentType = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAttachments(t *testing.T) {
+	blobs, err := blob.NewDisk(t.TempDir(), "/blobs/", []byte("url-key"))
+	if err != nil {
+		t.Fatalf("NewDisk failed: %v", err)
+	}
+	_, splitClient, cleanup := setupGroupTestServer(t, WithAttachments(blobs, 64))
+	defer cleanup()
+	ctx := context.Background()
+
+	billResp, err := splitClient.CreateBill(ctx, connect.NewRequest(&pb.CreateBillRequest{
+		Title:        "Dinner",
+		Total:        30,
+		Subtotal:     30,
+		Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+	}))
+	if err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+	billID := billResp.Msg.BillId
+
+	upload, err := splitClient.UploadAttachment(ctx, connect.NewRequest(&pb.UploadAttachmentRequest{
+		BillId:      billID,
+		Name:        `C:\Users\alice\receipt.png`,
+		ContentType: "image/png",
+		Data:        testPNG,
+	}))
+	if err != nil {
+		t.Fatalf("UploadAttachment failed: %v", err)
+	}
+	uploaded := upload.Msg.Attachment
+	if uploaded.Name != "receipt.png" || uploaded.ContentType != "image/png" || uploaded.Size != int64(len(testPNG)) {
+		t.Errorf("uploaded attachment = %v, want receipt.png, image/png, %d bytes", uploaded, len(testPNG))
+	}
+	if uploaded.UploaderId != testUserID || len(uploaded.Sha256) != 64 {
+		t.Errorf("uploaded attachment = %v, want uploader and digest set", uploaded)
+	}
+
+	got, err := splitClient.GetAttachment(ctx, connect.NewRequest(&pb.GetAttachmentRequest{AttachmentId: uploaded.Id}))
+	if err != nil {
+		t.Fatalf("GetAttachment failed: %v", err)
+	}
+	if !bytes.Equal(got.Msg.Data, testPNG) {
+		t.Errorf("GetAttachment data = %q, want the uploaded bytes", got.Msg.Data)
+	}
+
+	link, err := splitClient.GetAttachment(ctx, connect.NewRequest(&pb.GetAttachmentRequest{AttachmentId: uploaded.Id, Link: true}))
+	if err != nil {
+		t.Fatalf("GetAttachment with link failed: %v", err)
+	}
+	if !strings.HasPrefix(link.Msg.Url, "/blobs/attachments/"+billID+"/") || len(link.Msg.Data) != 0 {
+		t.Errorf("GetAttachment link = %q with %d bytes, want a signed blob URL and no data", link.Msg.Url, len(link.Msg.Data))
+	}
+
+	bill, err := splitClient.GetBill(ctx, connect.NewRequest(&pb.GetBillRequest{BillId: billID}))
+	if err != nil {
+		t.Fatalf("GetBill failed: %v", err)
+	}
+	if len(bill.Msg.Attachments) != 1 || bill.Msg.Attachments[0].Id != uploaded.Id {
+		t.Errorf("GetBill attachments = %v, want the uploaded one", bill.Msg.Attachments)
+	}
+
+	rejected := []struct {
+		name string
+		req  *pb.UploadAttachmentRequest
+		code connect.Code
+	}{
+		{"unsupported type", &pb.UploadAttachmentRequest{BillId: billID, Name: "notes.txt", Data: []byte("hello")}, connect.CodeInvalidArgument},
+		{"mismatched type", &pb.UploadAttachmentRequest{BillId: billID, Name: "r.jpg", ContentType: "image/jpeg", Data: testPNG}, connect.CodeInvalidArgument},
+		{"too large", &pb.UploadAttachmentRequest{BillId: billID, Name: "big.pdf", Data: append([]byte("%PDF-1.7\n"), make([]byte, 64)...)}, connect.CodeInvalidArgument},
+		{"empty", &pb.UploadAttachmentRequest{BillId: billID, Name: "r.png"}, connect.CodeInvalidArgument},
+		{"no name", &pb.UploadAttachmentRequest{BillId: billID, Data: testPNG}, connect.CodeInvalidArgument},
+		{"missing bill", &pb.UploadAttachmentRequest{BillId: "nope", Name: "r.png", Data: testPNG}, connect.CodeNotFound},
+	}
+	for _, tt := range rejected {
+		_, err := splitClient.UploadAttachment(ctx, connect.NewRequest(tt.req))
+		if connect.CodeOf(err) != tt.code {
+			t.Errorf("%s: code = %v, want %v", tt.name, connect.CodeOf(err), tt.code)
+		}
+	}
+
+	_, err = splitClient.GetAttachment(ctx, connect.NewRequest(&pb.GetAttachmentRequest{AttachmentId: "nope"}))
+	if connect.CodeOf(err) != connect.CodeNotFound {
+		t.Errorf("missing attachment: code = %v, want NotFound", connect.CodeOf(err))
+	}
+}
+
+func TestAttachmentsDisabled(t *testing.T) {
+	splitClient, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	_, err := splitClient.UploadAttachment(context.Background(), connect.NewRequest(&pb.UploadAttachmentRequest{
+		BillId: "any",
+		Name:   "r.png",
+		Data:   testPNG,
+	}))
+	if connect.CodeOf(err) != connect.CodeUnimplemented {
+		t.Errorf("code = %v, want Unimplemented", connect.CodeOf(err))
+	}
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"connectrpc.com/connect"
+	"github.com/mmynk/splitwiser/internal/authctx"
+	"github.com/mmynk/splitwiser/internal/storage"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+)
+
+// GetAuditLog lists the changes made to a group and everything in it, newest
+// first. Only the group's creator may read it; groups created before creators
+// were tracked let any member read it.
+func (s *GroupService) GetAuditLog(ctx context.Context, req *connect.Request[pb.GetAuditLogRequest]) (*connect.Response[pb.GetAuditLogResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+	}
+
+	page, err := pageRequest(req.Msg.PageSize, req.Msg.PageToken)
+	if err != nil {
+		return nil, err
+	}
+
+	groupID := req.Msg.GetGroupId()
+	if err := s.requireMembership(ctx, userID, groupID); err != nil {
+		return nil, err
+	}
+	group, err := s.store.GetGroup(ctx, groupID)
+	if err != nil {
+		logger.Error("GetAuditLog failed to get group", "group_id", groupID, "error", err)
+		return nil, storeError(err)
+	}
+	if group.CreatorID != "" && group.CreatorID != userID {
+		return nil, connect.NewError(connect.CodePermissionDenied, fmt.Errorf("only the group's creator can read its audit log"))
+	}
+
+	entries, err := s.store.ListAuditEntries(ctx, storage.AuditFilter{
+		GroupID:  groupID,
+		EntityID: req.Msg.EntityId,
+		Page:     page,
+	})
+	if err != nil {
+		logger.Error("GetAuditLog failed", "group_id", groupID, "error", err)
+		return nil, storeError(err)
+	}
+	entries, next := pageResults(entries, page)
+
+	names := make(map[string]string)
+	for _, m := range group.Members {
+		if m.UserID != "" {
+			names[m.UserID] = m.DisplayName
+		}
+	}
+	resp := &pb.GetAuditLogResponse{NextPageToken: next}
+	for _, e := range entries {
+		name, ok := names[e.ActorID]
+		if !ok && e.ActorID != "" {
+			name = s.resolveDisplayName(ctx, e.ActorID)
+			names[e.ActorID] = name
+		}
+		resp.Entries = append(resp.Entries, &pb.AuditEntry{
+			Id:         e.ID,
+			ActorId:    e.ActorID,
+			ActorName:  name,
+			Action:     string(e.Action),
+			EntityType: e.EntityType,
+			EntityId:   e.EntityID,
+			BeforeJson: e.Before,
+			AfterJson:  e.After,
+			CreatedAt:  e.CreatedAt,
+		})
+	}
+	return connect.NewResponse(resp), nil
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/mmynk/splitwiser/internal/models"
+	"github.com/mmynk/splitwiser/internal/storage/audit"
+	"github.com/mmynk/splitwiser/internal/storage/sqlite"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+	"github.com/mmynk/splitwiser/pkg/proto/protoconnect"
+)
+
+// setupAuditTestServer creates a test server whose services write through the audit decorator.
+func setupAuditTestServer(t *testing.T) (protoconnect.GroupServiceClient, protoconnect.SplitServiceClient) {
+	t.Helper()
+
+	db, err := sqlite.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.CreateUser(context.Background(), &models.User{
+		ID:           testUserID,
+		Email:        "alice@example.com",
+		DisplayName:  "Alice",
+		PasswordHash: "hash",
+		CreatedAt:    time.Now().Unix(),
+		UpdatedAt:    time.Now().Unix(),
+	}); err != nil {
+		t.Fatalf("failed to create test user: %v", err)
+	}
+	store := audit.New(db)
+
+	authInterceptor := connect.WithInterceptors(testAuthInterceptor())
+	splitPath, splitHandler := protoconnect.NewSplitServiceHandler(NewSplitService(store), authInterceptor)
+	groupPath, groupHandler := protoconnect.NewGroupServiceHandler(NewGroupService(store), authInterceptor)
+	mux := http.NewServeMux()
+	mux.Handle(splitPath, splitHandler)
+	mux.Handle(groupPath, groupHandler)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return protoconnect.NewGroupServiceClient(http.DefaultClient, server.URL),
+		protoconnect.NewSplitServiceClient(http.DefaultClient, server.URL)
+}
+
+func TestGetAuditLog(t *testing.T) {
+	groupClient, splitClient := setupAuditTestServer(t)
+	ctx := context.Background()
+
+	groupResp, err := groupClient.CreateGroup(ctx, connect.NewRequest(&pb.CreateGroupRequest{
+		Name:    "Flat",
+		Members: gm("Alice", "Bob"),
+	}))
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	groupID := groupResp.Msg.Group.Id
+
+	billResp, err := splitClient.CreateBill(ctx, connect.NewRequest(&pb.CreateBillRequest{
+		Title:        "Groceries",
+		Total:        40,
+		Subtotal:     40,
+		Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+		GroupId:      &groupID,
+	}))
+	if err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+	billID := billResp.Msg.BillId
+	if _, err := splitClient.UpdateBill(ctx, connect.NewRequest(&pb.UpdateBillRequest{
+		BillId:       billID,
+		Title:        "Groceries and wine",
+		Total:        55,
+		Subtotal:     55,
+		Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+		GroupId:      &groupID,
+	})); err != nil {
+		t.Fatalf("UpdateBill failed: %v", err)
+	}
+	if _, err := splitClient.DeleteBill(ctx, connect.NewRequest(&pb.DeleteBillRequest{BillId: billID})); err != nil {
+		t.Fatalf("DeleteBill failed: %v", err)
+	}
+
+	resp, err := groupClient.GetAuditLog(ctx, connect.NewRequest(&pb.GetAuditLogRequest{GroupId: groupID, EntityId: billID}))
+	if err != nil {
+		t.Fatalf("GetAuditLog failed: %v", err)
+	}
+	entries := resp.Msg.Entries
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries for the bill, want create, update and delete", len(entries))
+	}
+	for i, action := range []string{"delete", "update", "create"} {
+		e := entries[i]
+		if e.Action != action || e.EntityType != audit.EntityBill || e.ActorId != testUserID || e.ActorName != "Alice" {
+			t.Errorf("entry %d = %s %s by %s (%s), want %s bill by Alice", i, e.Action, e.EntityType, e.ActorName, e.ActorId, action)
+		}
+	}
+
+	title := func(js string) string {
+		t.Helper()
+		var bill models.Bill
+		if err := json.Unmarshal([]byte(js), &bill); err != nil {
+			t.Fatalf("bad snapshot %q: %v", js, err)
+		}
+		return bill.Title
+	}
+	update := entries[1]
+	if before, after := title(update.BeforeJson), title(update.AfterJson); before != "Groceries" || after != "Groceries and wine" {
+		t.Errorf("update went from %q to %q, want Groceries to Groceries and wine", before, after)
+	}
+	if entries[0].AfterJson != "" || entries[2].BeforeJson != "" {
+		t.Error("expected no after state on the delete and no before state on the create")
+	}
+
+	// The whole group's log also has the group's creation, and pages.
+	page, err := groupClient.GetAuditLog(ctx, connect.NewRequest(&pb.GetAuditLogRequest{GroupId: groupID, PageSize: 3}))
+	if err != nil {
+		t.Fatalf("GetAuditLog failed: %v", err)
+	}
+	if len(page.Msg.Entries) != 3 || page.Msg.NextPageToken == "" {
+		t.Fatalf("first page has %d entries and token %q, want 3 and more to come", len(page.Msg.Entries), page.Msg.NextPageToken)
+	}
+	rest, err := groupClient.GetAuditLog(ctx, connect.NewRequest(&pb.GetAuditLogRequest{GroupId: groupID, PageSize: 3, PageToken: page.Msg.NextPageToken}))
+	if err != nil {
+		t.Fatalf("GetAuditLog failed: %v", err)
+	}
+	if n := len(rest.Msg.Entries); n == 0 || rest.Msg.Entries[n-1].EntityType != audit.EntityGroup || rest.Msg.Entries[n-1].Action != "create" {
+		t.Errorf("oldest entry = %v, want the group's creation", rest.Msg.Entries)
+	}
+}
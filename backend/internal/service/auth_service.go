@@ -8,7 +8,8 @@ import (
 
 	"connectrpc.com/connect"
 	"github.com/mmynk/splitwiser/internal/auth"
-	"github.com/mmynk/splitwiser/internal/middleware"
+	"github.com/mmynk/splitwiser/internal/authctx"
+	"github.com/mmynk/splitwiser/internal/models"
 	proto "github.com/mmynk/splitwiser/pkg/proto"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
@@ -17,20 +18,42 @@ import (
 type AuthService struct {
 	authenticator auth.Authenticator
 	jwtManager    *auth.JWTManager
+	emails        *auth.EmailManager
+	inviter       *auth.Inviter
 	logger        *slog.Logger
+	inviteOnly    bool
+}
+
+// AuthOption configures an AuthService.
+type AuthOption func(*AuthService)
+
+// WithInviteOnly refuses Register, so accounts are only created by accepting
+// an invitation.
+func WithInviteOnly() AuthOption {
+	return func(s *AuthService) { s.inviteOnly = true }
 }
 
 // NewAuthService creates a new authentication service.
-func NewAuthService(authenticator auth.Authenticator, jwtManager *auth.JWTManager, logger *slog.Logger) *AuthService {
-	return &AuthService{
+func NewAuthService(authenticator auth.Authenticator, jwtManager *auth.JWTManager, emails *auth.EmailManager, inviter *auth.Inviter, logger *slog.Logger, opts ...AuthOption) *AuthService {
+	s := &AuthService{
 		authenticator: authenticator,
 		jwtManager:    jwtManager,
+		emails:        emails,
+		inviter:       inviter,
 		logger:        logger,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // Register creates a new user account.
 func (s *AuthService) Register(ctx context.Context, req *connect.Request[proto.RegisterRequest]) (*connect.Response[proto.RegisterResponse], error) {
+	if s.inviteOnly {
+		return nil, connect.NewError(connect.CodePermissionDenied, errors.New("registration is by invitation only"))
+	}
+
 	// Validate input
 	if req.Msg.Email == "" {
 		return nil, connect.NewError(connect.CodeInvalidArgument, auth.ErrInvalidCredentials)
@@ -49,7 +72,7 @@ func (s *AuthService) Register(ctx context.Context, req *connect.Request[proto.R
 		if err == auth.ErrWeakPassword {
 			return nil, connect.NewError(connect.CodeInvalidArgument, err)
 		}
-		return nil, connect.NewError(connect.CodeInternal, err)
+		return nil, storeError(err)
 	}
 
 	// Generate JWT token
@@ -118,7 +141,7 @@ func (s *AuthService) Logout(ctx context.Context, req *connect.Request[proto.Log
 // GetCurrentUser returns the currently authenticated user's information.
 func (s *AuthService) GetCurrentUser(ctx context.Context, req *connect.Request[proto.GetCurrentUserRequest]) (*connect.Response[proto.GetCurrentUserResponse], error) {
 	// Get user ID from context (set by auth middleware)
-	userID := middleware.GetUserID(ctx)
+	userID := authctx.UserID(ctx)
 	if userID == "" {
 		return nil, connect.NewError(connect.CodeUnauthenticated, auth.ErrMissingToken)
 	}
@@ -140,3 +163,139 @@ func (s *AuthService) GetCurrentUser(ctx context.Context, req *connect.Request[p
 
 	return connect.NewResponse(response), nil
 }
+
+// AddEmail links an additional email to the current user and sends it a verification token.
+func (s *AuthService) AddEmail(ctx context.Context, req *connect.Request[proto.AddEmailRequest]) (*connect.Response[proto.AddEmailResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, auth.ErrMissingToken)
+	}
+
+	linked, err := s.emails.Add(ctx, userID, req.Msg.Email)
+	if err != nil {
+		s.logger.Error("AddEmail failed", "user_id", userID, "error", err)
+		return nil, emailError(err)
+	}
+
+	return connect.NewResponse(&proto.AddEmailResponse{Email: userEmailToProto(linked)}), nil
+}
+
+// VerifyEmail confirms a linked email with the token that was sent to it.
+func (s *AuthService) VerifyEmail(ctx context.Context, req *connect.Request[proto.VerifyEmailRequest]) (*connect.Response[proto.VerifyEmailResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, auth.ErrMissingToken)
+	}
+
+	if err := s.emails.Verify(ctx, userID, req.Msg.Email, req.Msg.Token); err != nil {
+		s.logger.Warn("VerifyEmail failed", "user_id", userID, "error", err)
+		return nil, emailError(err)
+	}
+
+	emails, err := s.emails.List(ctx, userID)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	for _, e := range emails {
+		if e.Email == req.Msg.Email {
+			return connect.NewResponse(&proto.VerifyEmailResponse{Email: userEmailToProto(e)}), nil
+		}
+	}
+	return nil, connect.NewError(connect.CodeNotFound, auth.ErrEmailNotLinked)
+}
+
+// ListEmails returns the current user's primary and linked emails.
+func (s *AuthService) ListEmails(ctx context.Context, req *connect.Request[proto.ListEmailsRequest]) (*connect.Response[proto.ListEmailsResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, auth.ErrMissingToken)
+	}
+
+	emails, err := s.emails.List(ctx, userID)
+	if err != nil {
+		s.logger.Error("ListEmails failed", "user_id", userID, "error", err)
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	pbEmails := make([]*proto.UserEmail, len(emails))
+	for i, e := range emails {
+		pbEmails[i] = userEmailToProto(e)
+	}
+	return connect.NewResponse(&proto.ListEmailsResponse{Emails: pbEmails}), nil
+}
+
+// RemoveEmail unlinks an email from the current user.
+func (s *AuthService) RemoveEmail(ctx context.Context, req *connect.Request[proto.RemoveEmailRequest]) (*connect.Response[proto.RemoveEmailResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, auth.ErrMissingToken)
+	}
+
+	if err := s.emails.Remove(ctx, userID, req.Msg.Email); err != nil {
+		s.logger.Error("RemoveEmail failed", "user_id", userID, "error", err)
+		return nil, emailError(err)
+	}
+
+	return connect.NewResponse(&proto.RemoveEmailResponse{}), nil
+}
+
+// AcceptInvitation sets the password on a provisioned account and logs the user in.
+func (s *AuthService) AcceptInvitation(ctx context.Context, req *connect.Request[proto.AcceptInvitationRequest]) (*connect.Response[proto.AcceptInvitationResponse], error) {
+	if req.Msg.Token == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, auth.ErrInvalidInvitation)
+	}
+
+	user, err := s.inviter.Accept(ctx, req.Msg.Token, req.Msg.Password)
+	if err != nil {
+		s.logger.Warn("AcceptInvitation failed", "error", err)
+		switch {
+		case errors.Is(err, auth.ErrInvalidInvitation):
+			return nil, connect.NewError(connect.CodeNotFound, err)
+		case errors.Is(err, auth.ErrWeakPassword):
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		default:
+			return nil, storeError(err)
+		}
+	}
+
+	token, err := s.jwtManager.Generate(user)
+	if err != nil {
+		s.logger.Error("Failed to generate token", "user_id", user.ID, "error", err)
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	return connect.NewResponse(&proto.AcceptInvitationResponse{
+		User: &proto.User{
+			Id:          user.ID,
+			Email:       user.Email,
+			DisplayName: user.DisplayName,
+			CreatedAt:   timestamppb.New(time.Unix(user.CreatedAt, 0)),
+		},
+		Token: token,
+	}), nil
+}
+
+// emailError maps EmailManager errors to Connect errors.
+func emailError(err error) error {
+	switch {
+	case errors.Is(err, auth.ErrInvalidEmail), errors.Is(err, auth.ErrInvalidVerifyToken):
+		return connect.NewError(connect.CodeInvalidArgument, err)
+	case errors.Is(err, auth.ErrEmailExists), errors.Is(err, auth.ErrEmailAlreadyLinked):
+		return connect.NewError(connect.CodeAlreadyExists, err)
+	case errors.Is(err, auth.ErrEmailNotLinked):
+		return connect.NewError(connect.CodeNotFound, err)
+	case errors.Is(err, auth.ErrPrimaryEmail):
+		return connect.NewError(connect.CodeFailedPrecondition, err)
+	default:
+		return storeError(err)
+	}
+}
+
+func userEmailToProto(e *models.UserEmail) *proto.UserEmail {
+	return &proto.UserEmail{
+		Email:     e.Email,
+		Primary:   e.Primary,
+		Verified:  e.Verified,
+		CreatedAt: timestamppb.New(time.Unix(e.CreatedAt, 0)),
+	}
+}
@@ -22,6 +22,29 @@ import (
 // a token, while GetCurrentUser works when a valid Bearer token is provided.
 func setupAuthTestServer(t *testing.T) (protoconnect.AuthServiceClient, func()) {
 	t.Helper()
+	client, _, _, cleanup := setupAuthTestServerWithMailer(t)
+	return client, cleanup
+}
+
+// captureMailer records verification and invitation tokens instead of sending them.
+type captureMailer struct {
+	tokens map[string]string
+}
+
+func (m *captureMailer) SendVerification(ctx context.Context, to, token string) error {
+	m.tokens[to] = token
+	return nil
+}
+
+func (m *captureMailer) SendInvitation(ctx context.Context, to, token string) error {
+	m.tokens[to] = token
+	return nil
+}
+
+// setupAuthTestServerWithMailer is setupAuthTestServer that also exposes the
+// tokens sent by AddEmail and the Inviter, so tests can provision accounts.
+func setupAuthTestServerWithMailer(t *testing.T, opts ...AuthOption) (protoconnect.AuthServiceClient, *captureMailer, *auth.Inviter, func()) {
+	t.Helper()
 
 	tmpFile, err := os.CreateTemp("", "test-auth-*.db")
 	if err != nil {
@@ -35,9 +58,12 @@ func setupAuthTestServer(t *testing.T) (protoconnect.AuthServiceClient, func())
 		t.Fatalf("failed to create store: %v", err)
 	}
 
-	jwtManager := auth.NewJWTManager("test-secret-key-for-tests", 24*time.Hour)
+	jwtManager := auth.NewJWTManager("test-secret-key-for-tests", auth.SessionPolicy{IdleTimeout: 24 * time.Hour})
 	passwordAuth := auth.NewPasswordAuthenticator(store)
-	authSvc := NewAuthService(passwordAuth, jwtManager, slog.Default())
+	mailer := &captureMailer{tokens: make(map[string]string)}
+	emailManager := auth.NewEmailManager(store, mailer)
+	inviter := auth.NewInviter(store, mailer)
+	authSvc := NewAuthService(passwordAuth, jwtManager, emailManager, inviter, slog.Default(), opts...)
 
 	authPath, authHandler := protoconnect.NewAuthServiceHandler(
 		authSvc,
@@ -56,7 +82,7 @@ func setupAuthTestServer(t *testing.T) (protoconnect.AuthServiceClient, func())
 		os.Remove(tmpFile.Name())
 	}
 
-	return client, cleanup
+	return client, mailer, inviter, cleanup
 }
 
 func TestGetCurrentUser_ReturnsFullUserDetails(t *testing.T) {
@@ -116,3 +142,237 @@ func TestGetCurrentUser_RequiresAuth(t *testing.T) {
 		t.Errorf("expected CodeUnauthenticated, got %v", connectErr.Code())
 	}
 }
+
+// registerForToken registers a user and returns their JWT.
+func registerForToken(t *testing.T, client protoconnect.AuthServiceClient, email string) string {
+	t.Helper()
+	resp, err := client.Register(context.Background(), connect.NewRequest(&pb.RegisterRequest{
+		Email:       email,
+		DisplayName: "Test User",
+		Password:    "password123",
+	}))
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	return resp.Msg.Token
+}
+
+// withToken wraps msg in a request carrying the Bearer token.
+func withToken[T any](msg *T, token string) *connect.Request[T] {
+	req := connect.NewRequest(msg)
+	req.Header().Set("Authorization", "Bearer "+token)
+	return req
+}
+
+func TestLinkedEmail_LoginAfterVerification(t *testing.T) {
+	client, mailer, _, cleanup := setupAuthTestServerWithMailer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	token := registerForToken(t, client, "main@example.com")
+
+	addResp, err := client.AddEmail(ctx, withToken(&pb.AddEmailRequest{Email: "alt@example.com"}, token))
+	if err != nil {
+		t.Fatalf("AddEmail failed: %v", err)
+	}
+	if addResp.Msg.Email.Verified {
+		t.Error("expected newly added email to be unverified")
+	}
+
+	// Unverified addresses can't be used to log in.
+	_, err = client.Login(ctx, connect.NewRequest(&pb.LoginRequest{Email: "alt@example.com", Password: "password123"}))
+	if connect.CodeOf(err) != connect.CodeUnauthenticated {
+		t.Fatalf("expected Unauthenticated before verification, got %v", err)
+	}
+
+	_, err = client.VerifyEmail(ctx, withToken(&pb.VerifyEmailRequest{Email: "alt@example.com", Token: "wrong"}, token))
+	if connect.CodeOf(err) != connect.CodeInvalidArgument {
+		t.Fatalf("expected InvalidArgument for wrong token, got %v", err)
+	}
+
+	verifyResp, err := client.VerifyEmail(ctx, withToken(&pb.VerifyEmailRequest{
+		Email: "alt@example.com",
+		Token: mailer.tokens["alt@example.com"],
+	}, token))
+	if err != nil {
+		t.Fatalf("VerifyEmail failed: %v", err)
+	}
+	if !verifyResp.Msg.Email.Verified {
+		t.Error("expected email to be verified")
+	}
+
+	loginResp, err := client.Login(ctx, connect.NewRequest(&pb.LoginRequest{Email: "alt@example.com", Password: "password123"}))
+	if err != nil {
+		t.Fatalf("Login with linked email failed: %v", err)
+	}
+	if loginResp.Msg.User.Email != "main@example.com" {
+		t.Errorf("expected primary email on user, got %q", loginResp.Msg.User.Email)
+	}
+
+	// A verified linked address can't be registered as a new account.
+	_, err = client.Register(ctx, connect.NewRequest(&pb.RegisterRequest{
+		Email: "alt@example.com", DisplayName: "Dup", Password: "password123",
+	}))
+	if connect.CodeOf(err) != connect.CodeAlreadyExists {
+		t.Errorf("expected AlreadyExists registering linked email, got %v", err)
+	}
+}
+
+func TestLinkedEmail_ListAndRemove(t *testing.T) {
+	client, _, _, cleanup := setupAuthTestServerWithMailer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	token := registerForToken(t, client, "main@example.com")
+
+	if _, err := client.AddEmail(ctx, withToken(&pb.AddEmailRequest{Email: "alt@example.com"}, token)); err != nil {
+		t.Fatalf("AddEmail failed: %v", err)
+	}
+
+	listResp, err := client.ListEmails(ctx, withToken(&pb.ListEmailsRequest{}, token))
+	if err != nil {
+		t.Fatalf("ListEmails failed: %v", err)
+	}
+	emails := listResp.Msg.Emails
+	if len(emails) != 2 {
+		t.Fatalf("expected 2 emails, got %d", len(emails))
+	}
+	if !emails[0].Primary || emails[0].Email != "main@example.com" {
+		t.Errorf("expected primary email first, got %+v", emails[0])
+	}
+
+	_, err = client.RemoveEmail(ctx, withToken(&pb.RemoveEmailRequest{Email: "main@example.com"}, token))
+	if connect.CodeOf(err) != connect.CodeFailedPrecondition {
+		t.Errorf("expected FailedPrecondition removing primary email, got %v", err)
+	}
+
+	if _, err := client.RemoveEmail(ctx, withToken(&pb.RemoveEmailRequest{Email: "alt@example.com"}, token)); err != nil {
+		t.Fatalf("RemoveEmail failed: %v", err)
+	}
+	_, err = client.RemoveEmail(ctx, withToken(&pb.RemoveEmailRequest{Email: "alt@example.com"}, token))
+	if connect.CodeOf(err) != connect.CodeNotFound {
+		t.Errorf("expected NotFound removing unlinked email, got %v", err)
+	}
+}
+
+func TestLinkedEmail_RejectsOtherAccountsEmail(t *testing.T) {
+	client, _, _, cleanup := setupAuthTestServerWithMailer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	token := registerForToken(t, client, "main@example.com")
+	registerForToken(t, client, "other@example.com")
+
+	_, err := client.AddEmail(ctx, withToken(&pb.AddEmailRequest{Email: "other@example.com"}, token))
+	if connect.CodeOf(err) != connect.CodeAlreadyExists {
+		t.Errorf("expected AlreadyExists, got %v", err)
+	}
+}
+
+func TestAcceptInvitation_ActivatesProvisionedAccount(t *testing.T) {
+	client, mailer, inviter, cleanup := setupAuthTestServerWithMailer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	if _, err := inviter.Invite(ctx, "new@example.com", "Newbie"); err != nil {
+		t.Fatalf("Invite failed: %v", err)
+	}
+
+	// Provisioned accounts have no password until the invitation is accepted.
+	_, err := client.Login(ctx, connect.NewRequest(&pb.LoginRequest{Email: "new@example.com", Password: "password123"}))
+	if connect.CodeOf(err) != connect.CodeUnauthenticated {
+		t.Fatalf("expected Unauthenticated before accepting, got %v", err)
+	}
+
+	_, err = client.AcceptInvitation(ctx, connect.NewRequest(&pb.AcceptInvitationRequest{
+		Token:    mailer.tokens["new@example.com"],
+		Password: "short",
+	}))
+	if connect.CodeOf(err) != connect.CodeInvalidArgument {
+		t.Fatalf("expected InvalidArgument for weak password, got %v", err)
+	}
+
+	resp, err := client.AcceptInvitation(ctx, connect.NewRequest(&pb.AcceptInvitationRequest{
+		Token:    mailer.tokens["new@example.com"],
+		Password: "password123",
+	}))
+	if err != nil {
+		t.Fatalf("AcceptInvitation failed: %v", err)
+	}
+	if resp.Msg.Token == "" || resp.Msg.User.DisplayName != "Newbie" {
+		t.Errorf("unexpected response: %+v", resp.Msg)
+	}
+
+	if _, err := client.Login(ctx, connect.NewRequest(&pb.LoginRequest{Email: "new@example.com", Password: "password123"})); err != nil {
+		t.Fatalf("Login after accepting failed: %v", err)
+	}
+
+	// Invitations are single-use.
+	_, err = client.AcceptInvitation(ctx, connect.NewRequest(&pb.AcceptInvitationRequest{
+		Token:    mailer.tokens["new@example.com"],
+		Password: "another-password",
+	}))
+	if connect.CodeOf(err) != connect.CodeNotFound {
+		t.Errorf("expected NotFound reusing invitation, got %v", err)
+	}
+}
+
+func TestRegister_InviteOnly(t *testing.T) {
+	client, mailer, inviter, cleanup := setupAuthTestServerWithMailer(t, WithInviteOnly())
+	defer cleanup()
+	ctx := context.Background()
+
+	_, err := client.Register(ctx, connect.NewRequest(&pb.RegisterRequest{
+		Email:       "walk-in@example.com",
+		DisplayName: "Walk-in",
+		Password:    "password123",
+	}))
+	if connect.CodeOf(err) != connect.CodePermissionDenied {
+		t.Fatalf("expected PermissionDenied registering on an invite-only instance, got %v", err)
+	}
+
+	if _, err := inviter.Invite(ctx, "new@example.com", "Newbie"); err != nil {
+		t.Fatalf("Invite failed: %v", err)
+	}
+	if _, err := client.AcceptInvitation(ctx, connect.NewRequest(&pb.AcceptInvitationRequest{
+		Token:    mailer.tokens["new@example.com"],
+		Password: "password123",
+	})); err != nil {
+		t.Fatalf("AcceptInvitation failed: %v", err)
+	}
+}
+
+func TestRegister_NormalizesEmail(t *testing.T) {
+	client, cleanup := setupAuthTestServer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	resp, err := client.Register(ctx, connect.NewRequest(&pb.RegisterRequest{
+		Email:       " Ann@Example.com ",
+		DisplayName: "Ann",
+		Password:    "password123",
+	}))
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if got := resp.Msg.User.Email; got != "ann@example.com" {
+		t.Errorf("expected normalized email %q, got %q", "ann@example.com", got)
+	}
+
+	_, err = client.Register(ctx, connect.NewRequest(&pb.RegisterRequest{
+		Email:       "ann@EXAMPLE.com",
+		DisplayName: "Ann again",
+		Password:    "password123",
+	}))
+	if connect.CodeOf(err) != connect.CodeAlreadyExists {
+		t.Errorf("expected AlreadyExists registering the same address in another case, got %v", err)
+	}
+
+	login, err := client.Login(ctx, connect.NewRequest(&pb.LoginRequest{Email: "ANN@example.com  ", Password: "password123"}))
+	if err != nil {
+		t.Fatalf("Login failed: %v", err)
+	}
+	if login.Msg.User.Id != resp.Msg.User.Id {
+		t.Errorf("expected to sign in as %s, got %s", resp.Msg.User.Id, login.Msg.User.Id)
+	}
+}
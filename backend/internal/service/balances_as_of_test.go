@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/mmynk/splitwiser/internal/models"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+)
+
+func TestBalancesAsOf(t *testing.T) {
+	client, store, cleanup := setupSettleUpTest(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	groupResp, err := client.CreateGroup(ctx, connect.NewRequest(&pb.CreateGroupRequest{
+		Name:    "Flat",
+		Members: []*pb.GroupMember{bobMember()},
+	}))
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	groupID := groupResp.Msg.Group.Id
+
+	// Alice pays 100 on day 1000, Bob settles 20 on day 2000 and pays 40 on day 3000.
+	participants := []models.BillParticipant{
+		{DisplayName: "Alice", UserID: testUserID},
+		{DisplayName: "Bob", UserID: testBobID},
+	}
+	for _, bill := range []*models.Bill{
+		{Title: "Rent", Total: 100, Subtotal: 100, GroupID: groupID, PayerID: "Alice", Participants: participants, CreatedAt: 1000},
+		{Title: "Groceries", Total: 40, Subtotal: 40, GroupID: groupID, PayerID: "Bob", Participants: participants, CreatedAt: 3000},
+	} {
+		if err := store.CreateBill(ctx, bill); err != nil {
+			t.Fatalf("CreateBill failed: %v", err)
+		}
+	}
+	if err := store.CreateSettlement(ctx, &models.Settlement{
+		GroupID: &groupID, FromUserID: "Bob", ToUserID: "Alice", Amount: 20, CreatedBy: "Bob", CreatedAt: 2000,
+	}); err != nil {
+		t.Fatalf("CreateSettlement failed: %v", err)
+	}
+
+	tests := []struct {
+		asOf int64
+		want float64 // what Bob owes Alice
+	}{
+		{0, 10},
+		{1000, 0}, // before the rent: created_at must be earlier than as_of
+		{1001, 50},
+		{2001, 30},
+		{3001, 10},
+	}
+	for _, tt := range tests {
+		resp, err := client.GetGroupBalances(ctx, connect.NewRequest(&pb.GetGroupBalancesRequest{GroupId: groupID, AsOf: tt.asOf}))
+		if err != nil {
+			t.Fatalf("GetGroupBalances(as_of=%d) failed: %v", tt.asOf, err)
+		}
+		for _, b := range resp.Msg.MemberBalances {
+			if b.DisplayName == "Alice" && b.NetBalance != tt.want {
+				t.Errorf("as_of=%d: Alice's net balance = %v, want %v", tt.asOf, b.NetBalance, tt.want)
+			}
+		}
+
+		mine, err := client.GetMyBalances(ctx, connect.NewRequest(&pb.GetMyBalancesRequest{AsOf: tt.asOf}))
+		if err != nil {
+			t.Fatalf("GetMyBalances(as_of=%d) failed: %v", tt.asOf, err)
+		}
+		var owed float64
+		for _, p := range mine.Msg.PersonBalances {
+			if p.DisplayName == "Bob" {
+				owed = p.NetAmount
+			}
+		}
+		if owed != tt.want {
+			t.Errorf("as_of=%d: GetMyBalances has Bob owing %v, want %v", tt.asOf, owed, tt.want)
+		}
+	}
+
+	if _, err := client.GetGroupBalances(ctx, connect.NewRequest(&pb.GetGroupBalancesRequest{GroupId: groupID, AsOf: -1})); connect.CodeOf(err) != connect.CodeInvalidArgument {
+		t.Errorf("expected InvalidArgument for a negative as_of, got %v", err)
+	}
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"cmp"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"connectrpc.com/connect"
+	"github.com/mmynk/splitwiser/internal/models"
+)
+
+const (
+	// maxBillNoteLength bounds a bill's note, in characters.
+	maxBillNoteLength = 2000
+	// maxBillDetailLength bounds a bill's merchant name and location, in
+	// characters.
+	maxBillDetailLength = 200
+)
+
+// checkBillDetails bounds a bill's note, merchant name and location, and
+// rejects a bill date before 1970 or more than a day after now, which
+// leaves room for time zones ahead of the server's.
+func checkBillDetails(note, merchantName, location string, billDate int64, now time.Time) error {
+	if utf8.RuneCountInString(note) > maxBillNoteLength {
+		return connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("note must be at most %d characters", maxBillNoteLength))
+	}
+	if utf8.RuneCountInString(merchantName) > maxBillDetailLength {
+		return connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("merchant_name must be at most %d characters", maxBillDetailLength))
+	}
+	if utf8.RuneCountInString(location) > maxBillDetailLength {
+		return connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("location must be at most %d characters", maxBillDetailLength))
+	}
+	if billDate < 0 || billDate > now.Add(24*time.Hour).Unix() {
+		return connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("bill_date must be between 1970 and tomorrow"))
+	}
+	return nil
+}
+
+// expenseDate returns when a bill's expense happened: its bill date if it
+// has one, and otherwise when it was created.
+func expenseDate(bill *models.Bill) time.Time {
+	return time.Unix(cmp.Or(bill.BillDate, bill.CreatedAt), 0).UTC()
+}
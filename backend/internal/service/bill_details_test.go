@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+func TestBillDetails(t *testing.T) {
+	splitClient, cleanup := setupTestServer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	lastWeek := time.Now().AddDate(0, 0, -7).Unix()
+	created, err := splitClient.CreateBill(ctx, connect.NewRequest(&pb.CreateBillRequest{
+		Title:        "Brunch",
+		Total:        40,
+		Subtotal:     40,
+		Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+		PayerId:      strPtr("Alice"),
+		Note:         "Bob's birthday",
+		MerchantName: "Corner Bakery",
+		Location:     "Lisbon",
+		BillDate:     lastWeek,
+	}))
+	if err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+	billID := created.Msg.BillId
+
+	bill, err := splitClient.GetBill(ctx, connect.NewRequest(&pb.GetBillRequest{BillId: billID}))
+	if err != nil {
+		t.Fatalf("GetBill failed: %v", err)
+	}
+	if bill.Msg.Note != "Bob's birthday" || bill.Msg.MerchantName != "Corner Bakery" || bill.Msg.Location != "Lisbon" {
+		t.Errorf("details = %q/%q/%q, want the ones it was created with", bill.Msg.Note, bill.Msg.MerchantName, bill.Msg.Location)
+	}
+	if bill.Msg.BillDate != lastWeek || bill.Msg.CreatedAt == lastWeek {
+		t.Errorf("bill_date = %d and created_at = %d, want bill_date %d apart from created_at", bill.Msg.BillDate, bill.Msg.CreatedAt, lastWeek)
+	}
+
+	// Changing the note alone keeps the rest.
+	_, err = splitClient.UpdateBill(ctx, connect.NewRequest(&pb.UpdateBillRequest{
+		BillId:     billID,
+		Note:       "Bob's 30th",
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"note"}},
+	}))
+	if err != nil {
+		t.Fatalf("UpdateBill failed: %v", err)
+	}
+	listed, err := splitClient.ListMyBills(ctx, connect.NewRequest(&pb.ListMyBillsRequest{}))
+	if err != nil {
+		t.Fatalf("ListMyBills failed: %v", err)
+	}
+	if len(listed.Msg.Bills) != 1 || listed.Msg.Bills[0].MerchantName != "Corner Bakery" || listed.Msg.Bills[0].BillDate != lastWeek {
+		t.Errorf("summaries = %v, want the bill with its merchant and date", listed.Msg.Bills)
+	}
+	bill, err = splitClient.GetBill(ctx, connect.NewRequest(&pb.GetBillRequest{BillId: billID}))
+	if err != nil {
+		t.Fatalf("GetBill failed: %v", err)
+	}
+	if bill.Msg.Note != "Bob's 30th" || bill.Msg.Location != "Lisbon" {
+		t.Errorf("after update: note %q, location %q", bill.Msg.Note, bill.Msg.Location)
+	}
+
+	for name, req := range map[string]*pb.CreateBillRequest{
+		"long note":        {Note: strings.Repeat("x", maxBillNoteLength+1)},
+		"long merchant":    {MerchantName: strings.Repeat("x", maxBillDetailLength+1)},
+		"future bill date": {BillDate: time.Now().AddDate(0, 0, 3).Unix()},
+	} {
+		req.Total, req.Subtotal = 10, 10
+		req.Participants = []*pb.BillParticipant{aliceBP()}
+		_, err := splitClient.CreateBill(ctx, connect.NewRequest(req))
+		if connect.CodeOf(err) != connect.CodeInvalidArgument {
+			t.Errorf("%s: expected InvalidArgument, got %v", name, err)
+		}
+	}
+}
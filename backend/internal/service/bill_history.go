@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"connectrpc.com/connect"
+	"github.com/mmynk/splitwiser/internal/authctx"
+	"github.com/mmynk/splitwiser/internal/models"
+	"github.com/mmynk/splitwiser/internal/webhook"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// billVersions lists every version of a bill, oldest first. Each revision
+// the store kept was replaced by the next one's update, so version 1 is
+// credited to the bill's creator and version n+1 to whoever made revision
+// n's update; the last version is the bill as it is now.
+func (s *SplitService) billVersions(ctx context.Context, bill *models.Bill) ([]*pb.BillVersion, error) {
+	revisions, err := s.store.ListBillRevisions(ctx, bill.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]*pb.BillVersion, 0, len(revisions)+1)
+	editorID, editedAt := bill.CreatorID, bill.CreatedAt
+	if len(revisions) > 0 {
+		editorID, editedAt = revisions[0].Bill.CreatorID, revisions[0].Bill.CreatedAt
+	}
+	for _, r := range revisions {
+		versions = append(versions, &pb.BillVersion{
+			Version:  int32(r.Revision),
+			EditorId: editorID,
+			EditedAt: editedAt,
+			Bill:     billToUpdateRequest(r.Bill),
+		})
+		editorID, editedAt = r.EditorID, r.EditedAt
+	}
+	versions = append(versions, &pb.BillVersion{
+		Version:  int32(len(revisions) + 1),
+		EditorId: editorID,
+		EditedAt: editedAt,
+		Bill:     billToUpdateRequest(bill),
+	})
+
+	ids := make([]string, 0, len(versions))
+	for i, v := range versions {
+		if i > 0 {
+			v.ChangedFields = changedFields(versions[i-1].Bill, v.Bill)
+		}
+		if v.EditorId != "" {
+			ids = append(ids, v.EditorId)
+		}
+	}
+	users, err := s.store.GetUsersByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range versions {
+		if u, ok := users[v.EditorId]; ok {
+			v.EditorName = u.DisplayName
+		}
+	}
+	return versions, nil
+}
+
+// changedFields names the top-level fields of after that differ from before.
+func changedFields(before, after *pb.UpdateBillRequest) []string {
+	var changed []string
+	b, a := before.ProtoReflect(), after.ProtoReflect()
+	fields := a.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if fd.Name() == "bill_id" {
+			continue
+		}
+		if !fieldEqual(fd, b, a) {
+			changed = append(changed, string(fd.Name()))
+		}
+	}
+	return changed
+}
+
+func fieldEqual(fd protoreflect.FieldDescriptor, b, a protoreflect.Message) bool {
+	if b.Has(fd) != a.Has(fd) {
+		return false
+	}
+	return b.Get(fd).Equal(a.Get(fd))
+}
+
+// GetBillHistory lists every version of a bill with who made each change,
+// to anyone who can see the bill.
+func (s *SplitService) GetBillHistory(ctx context.Context, req *connect.Request[pb.GetBillHistoryRequest]) (*connect.Response[pb.GetBillHistoryResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+	}
+	if req.Msg.BillId == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("bill_id required"))
+	}
+
+	bill, err := s.store.GetBill(ctx, req.Msg.BillId)
+	if err != nil {
+		logger.Error("GetBillHistory failed", "bill_id", req.Msg.BillId, "error", err)
+		return nil, storeError(err)
+	}
+	if !hasAccess(userID, bill) {
+		return nil, connect.NewError(connect.CodePermissionDenied, fmt.Errorf("you must be a participant to view this bill"))
+	}
+
+	versions, err := s.billVersions(ctx, bill)
+	if err != nil {
+		logger.Error("GetBillHistory failed", "bill_id", bill.ID, "error", err)
+		return nil, storeError(err)
+	}
+	return connect.NewResponse(&pb.GetBillHistoryResponse{Versions: versions}), nil
+}
+
+// RevertBill restores a bill to an earlier version. The revert goes through
+// the same checks as UpdateBill and is kept as a new version, so it can be
+// undone in turn.
+func (s *SplitService) RevertBill(ctx context.Context, req *connect.Request[pb.RevertBillRequest]) (*connect.Response[pb.RevertBillResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+	}
+	if req.Msg.BillId == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("bill_id required"))
+	}
+	if req.Msg.Version < 1 {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("version must be at least 1"))
+	}
+
+	existing, err := s.store.GetBill(ctx, req.Msg.BillId)
+	if err != nil {
+		logger.Error("RevertBill: failed to get existing bill", "bill_id", req.Msg.BillId, "error", err)
+		return nil, storeError(err)
+	}
+	if !hasAccess(userID, existing) {
+		return nil, connect.NewError(connect.CodePermissionDenied, fmt.Errorf("you must be a participant to update this bill"))
+	}
+
+	revision, err := s.store.GetBillRevision(ctx, existing.ID, int(req.Msg.Version))
+	if err != nil {
+		if s.isCurrentVersion(ctx, existing.ID, req.Msg.Version) {
+			return nil, connect.NewError(connect.CodeFailedPrecondition, fmt.Errorf("version %d is the bill's current version", req.Msg.Version))
+		}
+		return nil, storeError(err)
+	}
+
+	msg := billToUpdateRequest(revision.Bill)
+	msg.BillId = existing.ID
+	msg.ExchangeRate = revision.Bill.ExchangeRate
+	existingBill, bill, err := s.billFromUpdate(ctx, "RevertBill", userID, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	split, err := billSplit(bill, false)
+	if err != nil {
+		logger.Error("CalculateSplit failed during RevertBill", "error", err)
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	if err := s.store.UpdateBill(ctx, bill); err != nil {
+		logger.Error("RevertBill failed", "error", err)
+		return nil, storeError(err)
+	}
+	logger.Info("Reverted bill", "bill_id", bill.ID, "version", req.Msg.Version)
+
+	if !bill.NeedsAssignment {
+		s.autoAddParticipantsToGroup(ctx, bill.GroupID, bill.Participants, bill.PayerID)
+	}
+	checkBalanceThresholds(ctx, s.store, s.webhooks, bill.GroupID)
+	if existingBill.GroupID != bill.GroupID {
+		checkBalanceThresholds(ctx, s.store, s.webhooks, existingBill.GroupID)
+	}
+	notifyBillChange(ctx, s.store, s.webhooks, webhook.EventBillUpdated, existingBill, bill)
+
+	return connect.NewResponse(&pb.RevertBillResponse{
+		BillId: bill.ID,
+		Split:  split,
+	}), nil
+}
+
+// isCurrentVersion reports whether version is the one after the bill's last
+// kept revision, i.e. the bill as it is now.
+func (s *SplitService) isCurrentVersion(ctx context.Context, billID string, version int32) bool {
+	revisions, err := s.store.ListBillRevisions(ctx, billID)
+	return err == nil && int(version) == len(revisions)+1
+}
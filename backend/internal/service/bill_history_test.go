@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/mmynk/splitwiser/internal/models"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+)
+
+func TestBillHistory(t *testing.T) {
+	splitClient, _, _, store, cleanup := setupTestServerWithFriendService(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	created, err := splitClient.CreateBill(ctx, connect.NewRequest(&pb.CreateBillRequest{
+		Title:        "Dinner",
+		Total:        60,
+		Subtotal:     60,
+		PayerId:      strPtr("Alice"),
+		Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+	}))
+	if err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+	billID := created.Msg.BillId
+
+	update := func(title string, total float64) {
+		t.Helper()
+		_, err := splitClient.UpdateBill(ctx, connect.NewRequest(&pb.UpdateBillRequest{
+			BillId:       billID,
+			Title:        title,
+			Total:        total,
+			Subtotal:     total,
+			PayerId:      strPtr("Alice"),
+			Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+		}))
+		if err != nil {
+			t.Fatalf("UpdateBill failed: %v", err)
+		}
+	}
+	update("Dinner at Luigi's", 60)
+	update("Dinner at Luigi's", 90)
+
+	history, err := splitClient.GetBillHistory(ctx, connect.NewRequest(&pb.GetBillHistoryRequest{BillId: billID}))
+	if err != nil {
+		t.Fatalf("GetBillHistory failed: %v", err)
+	}
+	versions := history.Msg.Versions
+	if len(versions) != 3 {
+		t.Fatalf("got %d versions, want 3", len(versions))
+	}
+	for i, v := range versions {
+		if v.Version != int32(i+1) || v.EditorId != testUserID || v.EditorName != "Alice" {
+			t.Errorf("version %d = %d by %s (%s), want %d by Alice", i, v.Version, v.EditorId, v.EditorName, i+1)
+		}
+	}
+	if versions[0].Bill.Title != "Dinner" || versions[2].Bill.Total != 90 {
+		t.Errorf("versions = %v, want the bill as created first and as it is now last", versions)
+	}
+	if len(versions[0].ChangedFields) != 0 {
+		t.Errorf("version 1 changed fields = %v, want none", versions[0].ChangedFields)
+	}
+	if !slices.Equal(versions[1].ChangedFields, []string{"title"}) {
+		t.Errorf("version 2 changed fields = %v, want [title]", versions[1].ChangedFields)
+	}
+	if !slices.Equal(versions[2].ChangedFields, []string{"total", "subtotal"}) {
+		t.Errorf("version 3 changed fields = %v, want [total subtotal]", versions[2].ChangedFields)
+	}
+
+	reverted, err := splitClient.RevertBill(ctx, connect.NewRequest(&pb.RevertBillRequest{BillId: billID, Version: 1}))
+	if err != nil {
+		t.Fatalf("RevertBill failed: %v", err)
+	}
+	if reverted.Msg.Split.Subtotal != 60 {
+		t.Errorf("reverted split subtotal = %v, want 60", reverted.Msg.Split.Subtotal)
+	}
+	bill, err := store.GetBill(ctx, billID)
+	if err != nil {
+		t.Fatalf("GetBill failed: %v", err)
+	}
+	if bill.Title != "Dinner" || bill.Total != 60 || bill.CreatorID != testUserID {
+		t.Errorf("reverted bill = %q %v by %s, want Dinner 60 by Alice", bill.Title, bill.Total, bill.CreatorID)
+	}
+
+	// The revert is a version of its own.
+	history, err = splitClient.GetBillHistory(ctx, connect.NewRequest(&pb.GetBillHistoryRequest{BillId: billID}))
+	if err != nil {
+		t.Fatalf("GetBillHistory failed: %v", err)
+	}
+	if n := len(history.Msg.Versions); n != 4 {
+		t.Fatalf("got %d versions after revert, want 4", n)
+	}
+
+	for _, tc := range []struct {
+		version int32
+		code    connect.Code
+	}{
+		{0, connect.CodeInvalidArgument},
+		{4, connect.CodeFailedPrecondition},
+		{5, connect.CodeNotFound},
+	} {
+		_, err := splitClient.RevertBill(ctx, connect.NewRequest(&pb.RevertBillRequest{BillId: billID, Version: tc.version}))
+		if connect.CodeOf(err) != tc.code {
+			t.Errorf("RevertBill(version %d): expected %v, got %v", tc.version, tc.code, err)
+		}
+	}
+}
+
+func TestBillHistory_NotParticipant(t *testing.T) {
+	splitClient, _, _, store, cleanup := setupTestServerWithFriendService(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	bill := &models.Bill{
+		Title:        "Bob's lunch",
+		Total:        20,
+		Subtotal:     20,
+		CreatorID:    testBobID,
+		PayerID:      "Bob",
+		Participants: []models.BillParticipant{{DisplayName: "Bob", UserID: testBobID}},
+	}
+	if err := store.CreateBill(ctx, bill); err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+	bill.Title = "Bob's long lunch"
+	if err := store.UpdateBill(ctx, bill); err != nil {
+		t.Fatalf("UpdateBill failed: %v", err)
+	}
+
+	_, err := splitClient.GetBillHistory(ctx, connect.NewRequest(&pb.GetBillHistoryRequest{BillId: bill.ID}))
+	if connect.CodeOf(err) != connect.CodePermissionDenied {
+		t.Errorf("GetBillHistory: expected PermissionDenied, got %v", err)
+	}
+	_, err = splitClient.RevertBill(ctx, connect.NewRequest(&pb.RevertBillRequest{BillId: bill.ID, Version: 1}))
+	if connect.CodeOf(err) != connect.CodePermissionDenied {
+		t.Errorf("RevertBill: expected PermissionDenied, got %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/mmynk/splitwiser/internal/authctx"
+	"github.com/mmynk/splitwiser/internal/models"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+)
+
+// checkUnlocked turns away changes to a locked bill.
+func checkUnlocked(bill *models.Bill) error {
+	if bill.LockedAt != 0 {
+		return connect.NewError(connect.CodeFailedPrecondition, fmt.Errorf("bill is locked; its payer must unlock it first"))
+	}
+	return nil
+}
+
+// isPayer reports whether userID paid the bill. When the payer has no
+// account, the bill's creator stands in for them.
+func isPayer(userID string, bill *models.Bill) bool {
+	for _, p := range bill.Participants {
+		if p.DisplayName == bill.PayerID && p.UserID != "" {
+			return p.UserID == userID
+		}
+	}
+	return bill.CreatorID == userID
+}
+
+// LockBill locks a bill so it can't be edited, moved or deleted until its
+// payer unlocks it. Anyone on the bill may lock it; bills also lock on
+// their own once every share is marked as paid.
+func (s *SplitService) LockBill(ctx context.Context, req *connect.Request[pb.LockBillRequest]) (*connect.Response[pb.LockBillResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+	}
+	if req.Msg.BillId == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("bill_id required"))
+	}
+
+	bill, err := s.store.GetBill(ctx, req.Msg.BillId)
+	if err != nil {
+		return nil, storeError(err)
+	}
+	if !hasAccess(userID, bill) {
+		return nil, connect.NewError(connect.CodePermissionDenied, fmt.Errorf("you must be a participant to lock this bill"))
+	}
+	if bill.NeedsAssignment {
+		return nil, connect.NewError(connect.CodeFailedPrecondition, fmt.Errorf("bill is awaiting assignment"))
+	}
+	if bill.LockedAt != 0 {
+		return connect.NewResponse(&pb.LockBillResponse{LockedAt: bill.LockedAt}), nil
+	}
+
+	lockedAt := time.Now().Unix()
+	if err := s.store.SetBillLocked(ctx, bill.ID, lockedAt); err != nil {
+		logger.Error("LockBill failed", "bill_id", bill.ID, "error", err)
+		return nil, storeError(err)
+	}
+	logger.Info("Locked bill", "bill_id", bill.ID)
+	return connect.NewResponse(&pb.LockBillResponse{LockedAt: lockedAt}), nil
+}
+
+// UnlockBill unlocks a bill so it can be changed again. Only the payer may
+// unlock it, since a change can undo what they've been paid back.
+func (s *SplitService) UnlockBill(ctx context.Context, req *connect.Request[pb.UnlockBillRequest]) (*connect.Response[pb.UnlockBillResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+	}
+	if req.Msg.BillId == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("bill_id required"))
+	}
+
+	bill, err := s.store.GetBill(ctx, req.Msg.BillId)
+	if err != nil {
+		return nil, storeError(err)
+	}
+	if !hasAccess(userID, bill) || !isPayer(userID, bill) {
+		return nil, connect.NewError(connect.CodePermissionDenied, fmt.Errorf("only the payer can unlock this bill"))
+	}
+	if bill.LockedAt == 0 {
+		return connect.NewResponse(&pb.UnlockBillResponse{}), nil
+	}
+
+	if err := s.store.SetBillLocked(ctx, bill.ID, 0); err != nil {
+		logger.Error("UnlockBill failed", "bill_id", bill.ID, "error", err)
+		return nil, storeError(err)
+	}
+	logger.Info("Unlocked bill", "bill_id", bill.ID)
+	return connect.NewResponse(&pb.UnlockBillResponse{}), nil
+}
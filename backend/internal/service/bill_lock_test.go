@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/mmynk/splitwiser/internal/models"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+)
+
+func TestLockBill(t *testing.T) {
+	splitClient, _, _, store, cleanup := setupTestServerWithFriendService(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	friendship := &models.Friendship{RequesterID: testBobID, AddresseeID: testUserID, Status: models.FriendshipAccepted}
+	if err := store.SendFriendRequest(ctx, friendship); err != nil {
+		t.Fatalf("SendFriendRequest failed: %v", err)
+	}
+
+	created, err := splitClient.CreateBill(ctx, connect.NewRequest(&pb.CreateBillRequest{
+		Title:        "Rent",
+		Total:        1000,
+		Subtotal:     1000,
+		PayerId:      strPtr("Bob"),
+		Participants: []*pb.BillParticipant{aliceBP(), {DisplayName: "Bob", UserId: strPtr(testBobID)}},
+	}))
+	if err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+	billID := created.Msg.BillId
+
+	locked, err := splitClient.LockBill(ctx, connect.NewRequest(&pb.LockBillRequest{BillId: billID}))
+	if err != nil {
+		t.Fatalf("LockBill failed: %v", err)
+	}
+	if locked.Msg.LockedAt == 0 {
+		t.Error("LockBill returned no lock time")
+	}
+	got, err := splitClient.GetBill(ctx, connect.NewRequest(&pb.GetBillRequest{BillId: billID}))
+	if err != nil {
+		t.Fatalf("GetBill failed: %v", err)
+	}
+	if got.Msg.LockedAt != locked.Msg.LockedAt {
+		t.Errorf("GetBill locked_at = %d, want %d", got.Msg.LockedAt, locked.Msg.LockedAt)
+	}
+
+	_, err = splitClient.UpdateBill(ctx, connect.NewRequest(&pb.UpdateBillRequest{
+		BillId:       billID,
+		Title:        "Rent (edited)",
+		Total:        900,
+		Subtotal:     900,
+		PayerId:      strPtr("Bob"),
+		Participants: got.Msg.Participants,
+	}))
+	if connect.CodeOf(err) != connect.CodeFailedPrecondition {
+		t.Errorf("UpdateBill on a locked bill: expected FailedPrecondition, got %v", err)
+	}
+	_, err = splitClient.DeleteBill(ctx, connect.NewRequest(&pb.DeleteBillRequest{BillId: billID}))
+	if connect.CodeOf(err) != connect.CodeFailedPrecondition {
+		t.Errorf("DeleteBill on a locked bill: expected FailedPrecondition, got %v", err)
+	}
+
+	// Alice didn't pay, so she can't unlock it.
+	_, err = splitClient.UnlockBill(ctx, connect.NewRequest(&pb.UnlockBillRequest{BillId: billID}))
+	if connect.CodeOf(err) != connect.CodePermissionDenied {
+		t.Errorf("UnlockBill by a non-payer: expected PermissionDenied, got %v", err)
+	}
+
+	if err := store.SetBillLocked(ctx, billID, 0); err != nil {
+		t.Fatalf("SetBillLocked failed: %v", err)
+	}
+	if _, err := splitClient.DeleteBill(ctx, connect.NewRequest(&pb.DeleteBillRequest{BillId: billID})); err != nil {
+		t.Errorf("DeleteBill after unlocking failed: %v", err)
+	}
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+
+	"connectrpc.com/connect"
+	"github.com/mmynk/splitwiser/internal/authctx"
+	"github.com/mmynk/splitwiser/internal/models"
+	"github.com/mmynk/splitwiser/internal/pdf"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+)
+
+// billPDFTemplate lays out a bill summary one line per line of output. A
+// line starting with "# " is the title and one starting with "## " a
+// heading; blank lines are kept as spacing. Lines that start with the
+// bill's own text are bulleted, so it's never taken for a heading.
+var billPDFTemplate = template.Must(template.New("bill").Parse(`# {{.Title}}
+{{.Date}}{{with .Group}} - {{.}}{{end}}
+{{- with .Where}}
+At {{.}}
+{{- end}}
+Total {{.Total}}{{with .Payer}}, paid by {{.}}{{end}}
+{{- with .Note}}
+Note: {{.}}
+{{- end}}
+{{- if .Items}}
+
+## Items
+{{- range .Items}}
+- {{.Description}}: {{.Amount}}{{with .Shared}} (shared by {{.}}){{end}}
+{{- end}}
+{{- end}}
+{{- if .Charges}}
+
+## Charges
+{{- range .Charges}}
+- {{.Label}}: {{.Amount}}
+{{- end}}
+{{- end}}
+
+## Per person
+{{- range .People}}
+
+- {{.Name}}: {{.Total}}{{with .Status}} ({{.}}){{end}}
+{{- range .Lines}}
+    - {{.Label}}: {{.Amount}}
+{{- end}}
+{{- end}}
+`))
+
+// billPDFLine is one labelled amount in a bill summary.
+type billPDFLine struct {
+	Label  string
+	Amount string
+}
+
+// billPDFPerson is one person's share in a bill summary.
+type billPDFPerson struct {
+	Name   string
+	Total  string
+	Status string // e.g. "paid", "owes Alice"
+	Lines  []billPDFLine
+}
+
+// billPDFItem is one item of the bill in a bill summary.
+type billPDFItem struct {
+	Description string
+	Amount      string
+	Shared      string // who shares it, e.g. "Alice, Bob"
+}
+
+// billPDFData is what billPDFTemplate renders. Its text is on one line each.
+type billPDFData struct {
+	Title   string
+	Date    string
+	Group   string
+	Where   string // merchant and location, e.g. "Corner Bakery, Lisbon"
+	Total   string
+	Note    string
+	Payer   string
+	Items   []billPDFItem
+	Charges []billPDFLine
+	People  []billPDFPerson
+}
+
+// renderBillPDF renders a bill summary with each person's itemized share as
+// a PDF, with amounts written by money. status gives who has paid their
+// share; it may be nil.
+func renderBillPDF(bill *models.Bill, groupName string, split *pb.CalculateSplitResponse, status *pb.PaymentStatus, money func(float64) string) ([]byte, error) {
+	data := billPDFData{
+		Title: oneLine(bill.Title),
+		Date:  expenseDate(bill).Format("2 January 2006"),
+		Group: oneLine(groupName),
+		Where: oneLine(strings.Join(slices.DeleteFunc([]string{bill.MerchantName, bill.Location}, func(s string) bool { return strings.TrimSpace(s) == "" }), ", ")),
+		Total: money(bill.Total),
+		Payer: oneLine(bill.PayerID),
+		Note:  oneLine(bill.Note),
+	}
+	for _, item := range bill.Items {
+		data.Items = append(data.Items, billPDFItem{
+			Description: oneLine(item.Description),
+			Amount:      money(item.Amount),
+			Shared:      oneLine(strings.Join(item.Participants, ", ")),
+		})
+	}
+	charge := func(label string, amount float64) {
+		if amount != 0 {
+			data.Charges = append(data.Charges, billPDFLine{label, money(amount)})
+		}
+	}
+	charge("Discount", -split.GetDiscountAmount())
+	charge("Tax", split.GetTaxAmount())
+	charge("Tip", split.GetTipAmount())
+	for _, fee := range bill.Fees {
+		charge(oneLine(fee.Description), fee.Amount)
+	}
+
+	paid := make(map[string]bool)
+	for _, name := range status.GetPaid() {
+		paid[name] = true
+	}
+	for _, p := range bill.Participants {
+		ps := split.GetSplits()[p.DisplayName]
+		if ps == nil {
+			continue
+		}
+		person := billPDFPerson{Name: oneLine(p.DisplayName), Total: money(ps.Total)}
+		switch {
+		case p.DisplayName == bill.PayerID:
+			person.Status = "paid the bill"
+		case paid[p.DisplayName]:
+			person.Status = "paid"
+		case status != nil && bill.PayerID != "" && ps.Total != 0:
+			person.Status = "owes " + data.Payer
+		}
+		for _, item := range ps.Items {
+			person.Lines = append(person.Lines, billPDFLine{oneLine(item.Description), money(item.Amount)})
+		}
+		if len(ps.Items) == 0 && ps.Subtotal != 0 {
+			person.Lines = append(person.Lines, billPDFLine{"Share of subtotal", money(ps.Subtotal)})
+		}
+		add := func(label string, amount float64) {
+			if amount != 0 {
+				person.Lines = append(person.Lines, billPDFLine{label, money(amount)})
+			}
+		}
+		add("Discount", -ps.Discount)
+		add("Tax", ps.Tax)
+		add("Tip", ps.Tip)
+		add("Fees", ps.Fees)
+		if ps.Covered > 0 {
+			add("Covering others", ps.Covered)
+		} else {
+			add("Covered by others", ps.Covered)
+		}
+		data.People = append(data.People, person)
+	}
+
+	var out bytes.Buffer
+	if err := billPDFTemplate.Execute(&out, data); err != nil {
+		return nil, err
+	}
+	doc := pdf.New()
+	for _, line := range strings.Split(strings.TrimRight(out.String(), "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "# "):
+			doc.Title(strings.TrimPrefix(line, "# "))
+		case strings.HasPrefix(line, "## "):
+			doc.Heading(strings.TrimPrefix(line, "## "))
+		default:
+			doc.Text(line)
+		}
+	}
+	return doc.Bytes(), nil
+}
+
+// oneLine collapses the whitespace in s, newlines included, to single
+// spaces.
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// unsafeFilenameChars matches runs of characters left out of file names.
+var unsafeFilenameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// safeFilename turns name into a file name stem safe on any system, or
+// returns fallback if nothing of it is left.
+func safeFilename(name, fallback string) string {
+	name = strings.Trim(unsafeFilenameChars.ReplaceAllString(name, "-"), "-.")
+	if name == "" {
+		return fallback
+	}
+	return name
+}
+
+// ExportBillPDF renders a bill as a PDF summary, listing its items and
+// charges and then each person's itemized share and whether they've paid
+// it, for sending to people outside the app such as a landlord.
+func (s *SplitService) ExportBillPDF(ctx context.Context, req *connect.Request[pb.ExportBillPDFRequest]) (*connect.Response[pb.ExportBillPDFResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+	}
+
+	bill, err := s.store.GetBill(ctx, req.Msg.BillId)
+	if err != nil {
+		logger.Error("ExportBillPDF failed", "bill_id", req.Msg.BillId, "error", err)
+		return nil, storeError(err)
+	}
+	if !hasAccess(userID, bill) {
+		return nil, connect.NewError(connect.CodePermissionDenied, fmt.Errorf("you must be a participant to view this bill"))
+	}
+	if bill.NeedsAssignment {
+		return nil, connect.NewError(connect.CodeFailedPrecondition, fmt.Errorf("bill is awaiting participant assignment"))
+	}
+
+	split, err := billSplit(bill, false)
+	if err != nil {
+		logger.Error("CalculateSplit failed during ExportBillPDF", "bill_id", bill.ID, "error", err)
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	var groupName string
+	if bill.GroupID != "" {
+		if group, err := s.store.GetGroup(ctx, bill.GroupID); err == nil {
+			groupName = group.Name
+		}
+	}
+	// A summary without payments is still worth sending.
+	statuses, err := s.paymentStatuses(ctx, []*models.Bill{bill})
+	if err != nil {
+		logger.Error("ExportBillPDF failed to roll up payments", "bill_id", bill.ID, "error", err)
+	}
+
+	money := func(amount float64) string { return localeFormat(req.Msg.Locale).format(amount, bill.Currency) }
+	doc, err := renderBillPDF(bill, groupName, split, statuses[bill.ID], money)
+	if err != nil {
+		logger.Error("ExportBillPDF failed to render", "bill_id", bill.ID, "error", err)
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	return connect.NewResponse(&pb.ExportBillPDFResponse{Pdf: doc, Filename: safeFilename(bill.Title, "bill") + ".pdf"}), nil
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"connectrpc.com/connect"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+)
+
+func TestExportBillPDF(t *testing.T) {
+	splitClient, cleanup := setupTestServer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	created, err := splitClient.CreateBill(ctx, connect.NewRequest(&pb.CreateBillRequest{
+		Title:    "Dinner (Friday)",
+		Total:    66,
+		Subtotal: 60,
+		Tip:      6,
+		Currency: "EUR",
+		Items: []*pb.Item{
+			{Description: "Pasta", Amount: 20, ParticipantIds: []string{"Alice"}},
+			{Description: "Steak", Amount: 40, ParticipantIds: []string{"Bob"}},
+		},
+		PayerId:      strPtr("Alice"),
+		Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+	}))
+	if err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+
+	resp, err := splitClient.ExportBillPDF(ctx, connect.NewRequest(&pb.ExportBillPDFRequest{BillId: created.Msg.BillId, Locale: "de-DE"}))
+	if err != nil {
+		t.Fatalf("ExportBillPDF failed: %v", err)
+	}
+	if resp.Msg.Filename != "Dinner-Friday.pdf" {
+		t.Errorf("filename = %q, want Dinner-Friday.pdf", resp.Msg.Filename)
+	}
+	doc := resp.Msg.Pdf
+	if !bytes.HasPrefix(doc, []byte("%PDF-")) {
+		t.Fatalf("not a PDF: %q", doc[:min(len(doc), 20)])
+	}
+	// Text is set as Windows-1252 literal strings, so € is 0x80.
+	for _, want := range []string{
+		`(Dinner \(Friday\)) Tj`,
+		"(- Steak: 40,00 \x80 \\(shared by Bob\\)) Tj",
+		"(- Bob: 44,00 \x80 \\(owes Alice\\)) Tj",
+		"(    - Tip: 4,00 \x80) Tj",
+	} {
+		if !strings.Contains(string(doc), want) {
+			t.Errorf("PDF is missing %q", want)
+		}
+	}
+
+	_, err = splitClient.ExportBillPDF(ctx, connect.NewRequest(&pb.ExportBillPDFRequest{BillId: "missing"}))
+	if connect.CodeOf(err) != connect.CodeNotFound {
+		t.Errorf("expected NotFound for a missing bill, got %v", err)
+	}
+}
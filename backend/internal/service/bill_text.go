@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"connectrpc.com/connect"
+	"github.com/mmynk/splitwiser/internal/authctx"
+	"github.com/mmynk/splitwiser/internal/models"
+	"github.com/mmynk/splitwiser/internal/numwords"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+	"github.com/mmynk/splitwiser/pkg/splitmath"
+)
+
+// numberFormat is how a locale writes amounts.
+type numberFormat struct {
+	decimal     string
+	group       string
+	symbolAfter bool // "22,00 €" rather than "€22.00"
+}
+
+// localeFormats maps a BCP 47 tag, or just its language, to its number
+// format. Tags not listed use their language's format, then English.
+var localeFormats = map[string]numberFormat{
+	"en":    {decimal: ".", group: ","},
+	"ja":    {decimal: ".", group: ","},
+	"zh":    {decimal: ".", group: ","},
+	"de":    {decimal: ",", group: ".", symbolAfter: true},
+	"de-ch": {decimal: ".", group: "'"},
+	"es":    {decimal: ",", group: ".", symbolAfter: true},
+	"fr":    {decimal: ",", group: "\u202f", symbolAfter: true}, // narrow no-break space
+	"it":    {decimal: ",", group: ".", symbolAfter: true},
+	"nl":    {decimal: ",", group: "."},
+	"pt":    {decimal: ",", group: ".", symbolAfter: true},
+	"sv":    {decimal: ",", group: "\u00a0", symbolAfter: true}, // no-break space
+}
+
+// currencySymbols lists symbols for common currencies; others are written
+// with their ISO code.
+var currencySymbols = map[string]string{
+	"USD": "$", "EUR": "€", "GBP": "£", "JPY": "¥", "CNY": "¥", "INR": "₹",
+	"KRW": "₩", "CAD": "CA$", "AUD": "A$", "NZD": "NZ$", "BRL": "R$", "MXN": "MX$",
+}
+
+// localeFormat returns the number format for a BCP 47 locale tag.
+func localeFormat(locale string) numberFormat {
+	tag := strings.ToLower(strings.ReplaceAll(locale, "_", "-"))
+	if f, ok := localeFormats[tag]; ok {
+		return f
+	}
+	lang, _, _ := strings.Cut(tag, "-")
+	if f, ok := localeFormats[lang]; ok {
+		return f
+	}
+	return localeFormats["en"]
+}
+
+// format writes amount with the currency's minor unit, the locale's
+// separators, and the currency's symbol (or code) where the locale puts it.
+func (f numberFormat) format(amount float64, currency string) string {
+	s := strconv.FormatFloat(math.Abs(amount), 'f', splitmath.MinorUnits(currency), 64)
+	whole, frac, _ := strings.Cut(s, ".")
+
+	var b strings.Builder
+	if amount < 0 && strings.Trim(s, "0.") != "" {
+		b.WriteString("-")
+	}
+	symbol, hasSymbol := currencySymbols[currency]
+	if !hasSymbol {
+		symbol = currency
+	}
+	if currency != "" && !f.symbolAfter {
+		b.WriteString(symbol)
+		if !hasSymbol {
+			b.WriteString(" ")
+		}
+	}
+	for i, r := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteString(f.group)
+		}
+		b.WriteRune(r)
+	}
+	if frac != "" {
+		b.WriteString(f.decimal)
+		b.WriteString(frac)
+	}
+	if currency != "" && f.symbolAfter {
+		b.WriteString(" " + symbol)
+	}
+	return b.String()
+}
+
+// shareBreakdown lists what makes up a person's share, e.g.
+// "Pizza $20.00 + tax $2.00". It's empty when the share is a single amount.
+func shareBreakdown(ps *pb.PersonSplit, money func(float64) string) string {
+	var parts []string
+	for _, item := range ps.Items {
+		parts = append(parts, item.Description+" "+money(item.Amount))
+	}
+	if len(ps.Items) == 0 && ps.Subtotal != 0 {
+		parts = append(parts, "subtotal "+money(ps.Subtotal))
+	}
+	b, terms := strings.Join(parts, " + "), len(parts)
+	add := func(sign, label string, amount float64) {
+		if amount != 0 {
+			b += " " + sign + " " + label + " " + money(amount)
+			terms++
+		}
+	}
+	add("-", "discount", ps.Discount)
+	add("+", "tax", ps.Tax)
+	add("+", "tip", ps.Tip)
+	add("+", "fees", ps.Fees)
+	if ps.Covered > 0 {
+		add("+", "covering others", ps.Covered)
+	} else {
+		add("-", "covered", -ps.Covered)
+	}
+
+	if terms <= 1 {
+		return ""
+	}
+	return b
+}
+
+// renderBillText writes a bill's split as one line per participant under a
+// title line, with amounts written by money. Markdown bolds the title and
+// names.
+func renderBillText(bill *models.Bill, split *pb.CalculateSplitResponse, money func(float64) string, markdown bool) string {
+	name := func(s string) string {
+		if markdown {
+			return "**" + s + "**"
+		}
+		return s
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %s", name(bill.Title), money(bill.Total))
+	if bill.PayerID != "" {
+		fmt.Fprintf(&b, ", paid by %s", bill.PayerID)
+	}
+	b.WriteString("\n")
+
+	for _, p := range bill.Participants {
+		ps := split.Splits[p.DisplayName]
+		if ps == nil {
+			continue
+		}
+		b.WriteString("- " + name(p.DisplayName))
+		switch {
+		case p.DisplayName == bill.PayerID:
+			b.WriteString(" paid, own share " + money(ps.Total))
+		case ps.Total == 0:
+			b.WriteString(" owes nothing\n")
+			continue
+		case bill.PayerID != "":
+			b.WriteString(" owes " + bill.PayerID + " " + money(ps.Total))
+		default:
+			b.WriteString(" owes " + money(ps.Total))
+		}
+		if breakdown := shareBreakdown(ps, money); breakdown != "" {
+			b.WriteString(" (" + breakdown + ")")
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// RenderBillText renders a bill's split as plain text or markdown for pasting
+// into a group chat, with amounts formatted, or spelled out, for the requested
+// locale.
+func (s *SplitService) RenderBillText(ctx context.Context, req *connect.Request[pb.RenderBillTextRequest]) (*connect.Response[pb.RenderBillTextResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+	}
+
+	bill, err := s.store.GetBill(ctx, req.Msg.BillId)
+	if err != nil {
+		logger.Error("RenderBillText failed", "bill_id", req.Msg.BillId, "error", err)
+		return nil, storeError(err)
+	}
+	if !hasAccess(userID, bill) {
+		return nil, connect.NewError(connect.CodePermissionDenied, fmt.Errorf("you must be a participant to view this bill"))
+	}
+	if bill.NeedsAssignment {
+		return nil, connect.NewError(connect.CodeFailedPrecondition, fmt.Errorf("bill is awaiting participant assignment"))
+	}
+
+	split, err := billSplit(bill, false)
+	if err != nil {
+		logger.Error("CalculateSplit failed during RenderBillText", "bill_id", bill.ID, "error", err)
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	money := func(amount float64) string { return localeFormat(req.Msg.Locale).format(amount, bill.Currency) }
+	if req.Msg.SpellOut {
+		money = func(amount float64) string { return numwords.Amount(amount, bill.Currency, req.Msg.Locale) }
+	}
+	text := renderBillText(bill, split, money, req.Msg.Format == pb.TextFormat_TEXT_FORMAT_MARKDOWN)
+	return connect.NewResponse(&pb.RenderBillTextResponse{Text: text}), nil
+}
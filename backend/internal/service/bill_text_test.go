@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"connectrpc.com/connect"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+)
+
+func TestNumberFormat(t *testing.T) {
+	tests := []struct {
+		locale   string
+		amount   float64
+		currency string
+		want     string
+	}{
+		{"en-US", 1234.5, "USD", "$1,234.50"},
+		{"", 22, "", "22.00"},
+		{"de-DE", 1234.5, "EUR", "1.234,50 €"},
+		{"de_CH", 1234.5, "CHF", "CHF 1'234.50"},
+		{"fr", 1234567.891, "EUR", "1\u202f234\u202f567,89 €"},
+		{"ja-JP", 1234, "JPY", "¥1,234"},
+		{"en", 12.3456, "BHD", "BHD 12.346"},
+		{"xx-YY", -5, "GBP", "-£5.00"},
+		{"en", -0.001, "USD", "$0.00"},
+	}
+	for _, tt := range tests {
+		if got := localeFormat(tt.locale).format(tt.amount, tt.currency); got != tt.want {
+			t.Errorf("format(%q, %v, %q) = %q, want %q", tt.locale, tt.amount, tt.currency, got, tt.want)
+		}
+	}
+}
+
+func TestRenderBillText(t *testing.T) {
+	client, cleanup := setupTestServer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	alice := "Alice"
+	createResp, err := client.CreateBill(ctx, connect.NewRequest(&pb.CreateBillRequest{
+		Title: "Dinner",
+		Items: []*pb.Item{
+			{Description: "Pizza", Amount: 20, ParticipantIds: []string{"Bob"}},
+			{Description: "Pasta", Amount: 20, ParticipantIds: []string{"Alice"}},
+		},
+		Total:        44,
+		Subtotal:     40,
+		Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob"), guestBP("Carol")},
+		PayerId:      &alice,
+		Currency:     "USD",
+	}))
+	if err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+	billID := createResp.Msg.BillId
+
+	resp, err := client.RenderBillText(ctx, connect.NewRequest(&pb.RenderBillTextRequest{BillId: billID}))
+	if err != nil {
+		t.Fatalf("RenderBillText failed: %v", err)
+	}
+	want := "Dinner: $44.00, paid by Alice\n" +
+		"- Alice paid, own share $22.00 (Pasta $20.00 + tax $2.00)\n" +
+		"- Bob owes Alice $22.00 (Pizza $20.00 + tax $2.00)\n" +
+		"- Carol owes nothing\n"
+	if resp.Msg.Text != want {
+		t.Errorf("plain text =\n%s\nwant\n%s", resp.Msg.Text, want)
+	}
+
+	resp, err = client.RenderBillText(ctx, connect.NewRequest(&pb.RenderBillTextRequest{
+		BillId: billID,
+		Format: pb.TextFormat_TEXT_FORMAT_MARKDOWN,
+		Locale: "de-DE",
+	}))
+	if err != nil {
+		t.Fatalf("RenderBillText failed: %v", err)
+	}
+	want = "**Dinner**: 44,00 $, paid by Alice\n" +
+		"- **Alice** paid, own share 22,00 $ (Pasta 20,00 $ + tax 2,00 $)\n" +
+		"- **Bob** owes Alice 22,00 $ (Pizza 20,00 $ + tax 2,00 $)\n" +
+		"- **Carol** owes nothing\n"
+	if resp.Msg.Text != want {
+		t.Errorf("markdown text =\n%s\nwant\n%s", resp.Msg.Text, want)
+	}
+
+	resp, err = client.RenderBillText(ctx, connect.NewRequest(&pb.RenderBillTextRequest{BillId: billID, SpellOut: true}))
+	if err != nil {
+		t.Fatalf("RenderBillText failed: %v", err)
+	}
+	if line := "- Bob owes Alice twenty-two dollars (Pizza twenty dollars + tax two dollars)\n"; !strings.Contains(resp.Msg.Text, line) {
+		t.Errorf("spelled-out text =\n%s\nwant a line\n%s", resp.Msg.Text, line)
+	}
+
+	getResp, err := client.GetBill(ctx, connect.NewRequest(&pb.GetBillRequest{BillId: billID, SpellOut: true, Locale: "es"}))
+	if err != nil {
+		t.Fatalf("GetBill failed: %v", err)
+	}
+	if getResp.Msg.TotalWords != "cuarenta y cuatro dólares" || getResp.Msg.Split.Splits["Bob"].TotalWords != "veintidós dólares" {
+		t.Errorf("GetBill words = %q, Bob %q", getResp.Msg.TotalWords, getResp.Msg.Split.Splits["Bob"].TotalWords)
+	}
+
+	_, err = client.RenderBillText(ctx, connect.NewRequest(&pb.RenderBillTextRequest{BillId: "missing"}))
+	if connect.CodeOf(err) != connect.CodeNotFound {
+		t.Errorf("missing bill: got %v, want NotFound", err)
+	}
+}
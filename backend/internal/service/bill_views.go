@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/mmynk/splitwiser/internal/authctx"
+	"github.com/mmynk/splitwiser/internal/models"
+	"github.com/mmynk/splitwiser/internal/storage"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+)
+
+// CreateBillView saves a named bill filter for the caller.
+func (s *SplitService) CreateBillView(ctx context.Context, req *connect.Request[pb.CreateBillViewRequest]) (*connect.Response[pb.CreateBillViewResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+	}
+
+	view, err := s.billView(ctx, req.Msg.Name, req.Msg.Filter, req.Msg.Pinned)
+	if err != nil {
+		return nil, err
+	}
+	view.UserID = userID
+	if err := s.store.CreateBillView(ctx, view); err != nil {
+		logger.Error("CreateBillView failed", "error", err)
+		return nil, storeError(err)
+	}
+	return connect.NewResponse(&pb.CreateBillViewResponse{View: billViewToProto(view)}), nil
+}
+
+// ListBillViews lists the caller's saved bill views, oldest first.
+func (s *SplitService) ListBillViews(ctx context.Context, req *connect.Request[pb.ListBillViewsRequest]) (*connect.Response[pb.ListBillViewsResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+	}
+
+	views, err := s.store.ListBillViews(ctx, userID)
+	if err != nil {
+		logger.Error("ListBillViews failed", "user_id", userID, "error", err)
+		return nil, storeError(err)
+	}
+	pbViews := make([]*pb.BillView, len(views))
+	for i, view := range views {
+		pbViews[i] = billViewToProto(view)
+	}
+	return connect.NewResponse(&pb.ListBillViewsResponse{Views: pbViews}), nil
+}
+
+// UpdateBillView replaces the name, filter and pinning of one of the
+// caller's saved bill views.
+func (s *SplitService) UpdateBillView(ctx context.Context, req *connect.Request[pb.UpdateBillViewRequest]) (*connect.Response[pb.UpdateBillViewResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+	}
+
+	existing, err := s.ownBillView(ctx, userID, req.Msg.ViewId)
+	if err != nil {
+		return nil, err
+	}
+	view, err := s.billView(ctx, req.Msg.Name, req.Msg.Filter, req.Msg.Pinned)
+	if err != nil {
+		return nil, err
+	}
+	view.ID, view.UserID, view.CreatedAt = existing.ID, existing.UserID, existing.CreatedAt
+	if err := s.store.UpdateBillView(ctx, view); err != nil {
+		logger.Error("UpdateBillView failed", "error", err)
+		return nil, storeError(err)
+	}
+	return connect.NewResponse(&pb.UpdateBillViewResponse{View: billViewToProto(view)}), nil
+}
+
+// DeleteBillView removes one of the caller's saved bill views.
+func (s *SplitService) DeleteBillView(ctx context.Context, req *connect.Request[pb.DeleteBillViewRequest]) (*connect.Response[pb.DeleteBillViewResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+	}
+
+	if _, err := s.ownBillView(ctx, userID, req.Msg.ViewId); err != nil {
+		return nil, err
+	}
+	if err := s.store.DeleteBillView(ctx, req.Msg.ViewId); err != nil {
+		logger.Error("DeleteBillView failed", "error", err)
+		return nil, storeError(err)
+	}
+	return connect.NewResponse(&pb.DeleteBillViewResponse{}), nil
+}
+
+// ownBillView loads the view userID saved as viewID. Someone else's view is
+// reported as missing rather than forbidden.
+func (s *SplitService) ownBillView(ctx context.Context, userID, viewID string) (*models.BillView, error) {
+	if viewID == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("view_id required"))
+	}
+	view, err := s.store.GetBillView(ctx, viewID)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		logger.Error("Bill view lookup failed", "view_id", viewID, "error", err)
+		return nil, storeError(err)
+	}
+	if err != nil || view.UserID != userID {
+		return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("bill view not found"))
+	}
+	return view, nil
+}
+
+// billView validates the fields of a view being created or updated.
+func (s *SplitService) billView(ctx context.Context, name string, filter *pb.BillViewFilter, pinned bool) (*models.BillView, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("view name required"))
+	}
+	if filter.GetGroupId() != "" && filter.GetUngrouped() {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("group_id and ungrouped can't be combined"))
+	}
+	if filter.GetLastDays() < 0 {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("last_days can't be negative"))
+	}
+	if err := s.checkCategory(ctx, filter.GetCategoryId()); err != nil {
+		return nil, err
+	}
+	return &models.BillView{
+		Name:       name,
+		GroupID:    filter.GetGroupId(),
+		Ungrouped:  filter.GetUngrouped(),
+		LastDays:   int(filter.GetLastDays()),
+		PaidByMe:   filter.GetPaidByMe(),
+		CategoryID: filter.GetCategoryId(),
+		Pinned:     pinned,
+	}, nil
+}
+
+// billViewFilter is the filter view applies to its owner's bills when listed
+// at now.
+func billViewFilter(view *models.BillView, now time.Time) storage.BillFilter {
+	filter := storage.BillFilter{
+		GroupID:    view.GroupID,
+		Ungrouped:  view.Ungrouped,
+		CategoryID: view.CategoryID,
+	}
+	if view.LastDays > 0 {
+		filter.Since = now.AddDate(0, 0, -view.LastDays).Unix()
+	}
+	if view.PaidByMe {
+		filter.PaidBy = view.UserID
+	}
+	return filter
+}
+
+func billViewToProto(view *models.BillView) *pb.BillView {
+	filter := &pb.BillViewFilter{
+		Ungrouped: view.Ungrouped,
+		LastDays:  int32(view.LastDays),
+		PaidByMe:  view.PaidByMe,
+	}
+	if view.GroupID != "" {
+		filter.GroupId = &view.GroupID
+	}
+	if view.CategoryID != "" {
+		filter.CategoryId = &view.CategoryID
+	}
+	return &pb.BillView{
+		Id:        view.ID,
+		Name:      view.Name,
+		Filter:    filter,
+		Pinned:    view.Pinned,
+		CreatedAt: view.CreatedAt,
+	}
+}
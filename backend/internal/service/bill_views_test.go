@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"connectrpc.com/connect"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+)
+
+func TestBillViews(t *testing.T) {
+	client, cleanup := setupTestServer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	for _, bill := range []struct{ title, payer, category string }{
+		{"Dinner", "Alice", "food"},
+		{"Taxi", "Bob", "transport"},
+	} {
+		if _, err := client.CreateBill(ctx, connect.NewRequest(&pb.CreateBillRequest{
+			Title:        bill.title,
+			Total:        20,
+			Subtotal:     20,
+			Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+			PayerId:      &bill.payer,
+			CategoryId:   bill.category,
+		})); err != nil {
+			t.Fatalf("CreateBill failed: %v", err)
+		}
+	}
+	titles := func(req *pb.ListBillsRequest) ([]string, error) {
+		resp, err := client.ListBills(ctx, connect.NewRequest(req))
+		if err != nil {
+			return nil, err
+		}
+		var out []string
+		for _, b := range resp.Msg.Bills {
+			out = append(out, b.Title)
+		}
+		return out, nil
+	}
+
+	created, err := client.CreateBillView(ctx, connect.NewRequest(&pb.CreateBillViewRequest{
+		Name:   " Paid by me, last 30 days ",
+		Filter: &pb.BillViewFilter{LastDays: 30, PaidByMe: true},
+		Pinned: true,
+	}))
+	if err != nil {
+		t.Fatalf("CreateBillView failed: %v", err)
+	}
+	view := created.Msg.View
+	if view.Name != "Paid by me, last 30 days" || !view.Pinned || view.Filter.LastDays != 30 || !view.Filter.PaidByMe {
+		t.Errorf("created view = %v", view)
+	}
+
+	for name, req := range map[string]*pb.CreateBillViewRequest{
+		"no name":             {Name: " "},
+		"unknown category":    {Name: "Odd", Filter: &pb.BillViewFilter{CategoryId: strPtr("no-such-category")}},
+		"negative days":       {Name: "Odd", Filter: &pb.BillViewFilter{LastDays: -1}},
+		"group and ungrouped": {Name: "Odd", Filter: &pb.BillViewFilter{GroupId: strPtr("g"), Ungrouped: true}},
+	} {
+		if _, err := client.CreateBillView(ctx, connect.NewRequest(req)); connect.CodeOf(err) != connect.CodeInvalidArgument {
+			t.Errorf("%s: code = %v, want InvalidArgument", name, connect.CodeOf(err))
+		}
+	}
+
+	list, err := client.ListBillViews(ctx, connect.NewRequest(&pb.ListBillViewsRequest{}))
+	if err != nil || len(list.Msg.Views) != 1 || list.Msg.Views[0].Id != view.Id {
+		t.Fatalf("ListBillViews = %v (err %v), want the created view", list.Msg.GetViews(), err)
+	}
+
+	got, err := titles(&pb.ListBillsRequest{ViewId: view.Id})
+	if err != nil || len(got) != 1 || got[0] != "Dinner" {
+		t.Errorf("bills in view = %v (err %v), want [Dinner]", got, err)
+	}
+	got, err = titles(&pb.ListBillsRequest{PaidByMe: true})
+	if err != nil || len(got) != 1 || got[0] != "Dinner" {
+		t.Errorf("bills paid by me = %v (err %v), want [Dinner]", got, err)
+	}
+	if _, err := titles(&pb.ListBillsRequest{ViewId: view.Id, CategoryId: strPtr("food")}); connect.CodeOf(err) != connect.CodeInvalidArgument {
+		t.Errorf("view_id with a filter: code = %v, want InvalidArgument", connect.CodeOf(err))
+	}
+
+	updated, err := client.UpdateBillView(ctx, connect.NewRequest(&pb.UpdateBillViewRequest{
+		ViewId: view.Id,
+		Name:   "Transport",
+		Filter: &pb.BillViewFilter{CategoryId: strPtr("transport")},
+	}))
+	if err != nil {
+		t.Fatalf("UpdateBillView failed: %v", err)
+	}
+	if updated.Msg.View.Pinned || updated.Msg.View.CreatedAt != view.CreatedAt {
+		t.Errorf("updated view = %v, want unpinned with the original created_at", updated.Msg.View)
+	}
+	got, err = titles(&pb.ListBillsRequest{ViewId: view.Id})
+	if err != nil || len(got) != 1 || got[0] != "Taxi" {
+		t.Errorf("bills in updated view = %v (err %v), want [Taxi]", got, err)
+	}
+
+	if _, err := client.DeleteBillView(ctx, connect.NewRequest(&pb.DeleteBillViewRequest{ViewId: view.Id})); err != nil {
+		t.Fatalf("DeleteBillView failed: %v", err)
+	}
+	if _, err := titles(&pb.ListBillsRequest{ViewId: view.Id}); connect.CodeOf(err) != connect.CodeNotFound {
+		t.Errorf("deleted view: code = %v, want NotFound", connect.CodeOf(err))
+	}
+	if _, err := client.UpdateBillView(ctx, connect.NewRequest(&pb.UpdateBillViewRequest{ViewId: view.Id, Name: "Gone"})); connect.CodeOf(err) != connect.CodeNotFound {
+		t.Errorf("UpdateBillView of a deleted view: code = %v, want NotFound", connect.CodeOf(err))
+	}
+}
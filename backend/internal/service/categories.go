@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"connectrpc.com/connect"
+	"github.com/mmynk/splitwiser/internal/authctx"
+	"github.com/mmynk/splitwiser/internal/models"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+)
+
+// ListCategories lists the expense categories bills can be filed under.
+func (s *SplitService) ListCategories(ctx context.Context, req *connect.Request[pb.ListCategoriesRequest]) (*connect.Response[pb.ListCategoriesResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+	}
+
+	categories, err := s.store.ListCategories(ctx)
+	if err != nil {
+		logger.Error("ListCategories failed", "error", err)
+		return nil, storeError(err)
+	}
+	resp := &pb.ListCategoriesResponse{Categories: make([]*pb.Category, len(categories))}
+	for i, c := range categories {
+		resp.Categories[i] = &pb.Category{Id: c.ID, Name: c.Name}
+	}
+	return connect.NewResponse(resp), nil
+}
+
+// checkCategory rejects a category ID that isn't one of ListCategories'.
+// Empty, for an uncategorized bill, is fine.
+func (s *SplitService) checkCategory(ctx context.Context, categoryID string) error {
+	if categoryID == "" {
+		return nil
+	}
+	categories, err := s.store.ListCategories(ctx)
+	if err != nil {
+		logger.Error("Category lookup failed", "error", err)
+		return storeError(err)
+	}
+	if !slices.ContainsFunc(categories, func(c models.Category) bool { return c.ID == categoryID }) {
+		return connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("unknown category %q", categoryID))
+	}
+	return nil
+}
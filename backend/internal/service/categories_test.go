@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"connectrpc.com/connect"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+)
+
+func TestBillCategories(t *testing.T) {
+	groupClient, splitClient, cleanup := setupGroupTestServer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	categories, err := splitClient.ListCategories(ctx, connect.NewRequest(&pb.ListCategoriesRequest{}))
+	if err != nil {
+		t.Fatalf("ListCategories failed: %v", err)
+	}
+	ids := make(map[string]bool)
+	for _, c := range categories.Msg.Categories {
+		ids[c.Id] = true
+	}
+	for _, id := range []string{"food", "rent", "travel"} {
+		if !ids[id] {
+			t.Errorf("standard category %q missing from %v", id, categories.Msg.Categories)
+		}
+	}
+
+	group, err := groupClient.CreateGroup(ctx, connect.NewRequest(&pb.CreateGroupRequest{Name: "Flat", Members: gm("Alice", "Bob")}))
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	groupID := group.Msg.Group.Id
+	createBill := func(title, categoryID string) (string, error) {
+		resp, err := splitClient.CreateBill(ctx, connect.NewRequest(&pb.CreateBillRequest{
+			Title:        title,
+			Total:        10,
+			Subtotal:     10,
+			Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+			GroupId:      &groupID,
+			CategoryId:   categoryID,
+		}))
+		if err != nil {
+			return "", err
+		}
+		return resp.Msg.BillId, nil
+	}
+	rentID, err := createBill("March rent", "rent")
+	if err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+	pizzaID, err := createBill("Pizza", "food")
+	if err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+	if _, err := createBill("Misc", ""); err != nil {
+		t.Fatalf("CreateBill without a category failed: %v", err)
+	}
+	if _, err := createBill("Bogus", "no-such-category"); connect.CodeOf(err) != connect.CodeInvalidArgument {
+		t.Errorf("CreateBill with an unknown category: got %v, want InvalidArgument", err)
+	}
+
+	bill, err := splitClient.GetBill(ctx, connect.NewRequest(&pb.GetBillRequest{BillId: rentID}))
+	if err != nil {
+		t.Fatalf("GetBill failed: %v", err)
+	}
+	if bill.Msg.CategoryId != "rent" {
+		t.Errorf("category = %q, want rent", bill.Msg.CategoryId)
+	}
+
+	rent := "rent"
+	byGroup, err := splitClient.ListBillsByGroup(ctx, connect.NewRequest(&pb.ListBillsByGroupRequest{GroupId: groupID, CategoryId: &rent}))
+	if err != nil {
+		t.Fatalf("ListBillsByGroup failed: %v", err)
+	}
+	if len(byGroup.Msg.Bills) != 1 || byGroup.Msg.Bills[0].BillId != rentID || byGroup.Msg.Bills[0].CategoryId != "rent" {
+		t.Errorf("rent bills in group = %v, want only March rent", byGroup.Msg.Bills)
+	}
+
+	// Recategorizing moves the bill between filters.
+	if _, err := splitClient.UpdateBill(ctx, connect.NewRequest(&pb.UpdateBillRequest{
+		BillId:       pizzaID,
+		Title:        "Pizza",
+		Total:        10,
+		Subtotal:     10,
+		Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+		GroupId:      &groupID,
+		CategoryId:   "rent",
+	})); err != nil {
+		t.Fatalf("UpdateBill failed: %v", err)
+	}
+	mine, err := splitClient.ListBills(ctx, connect.NewRequest(&pb.ListBillsRequest{CategoryId: &rent}))
+	if err != nil {
+		t.Fatalf("ListBills failed: %v", err)
+	}
+	if len(mine.Msg.Bills) != 2 {
+		t.Errorf("rent bills = %v, want March rent and Pizza", mine.Msg.Bills)
+	}
+}
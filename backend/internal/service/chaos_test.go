@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/mmynk/splitwiser/internal/models"
+	"github.com/mmynk/splitwiser/internal/storage/chaos"
+	"github.com/mmynk/splitwiser/internal/storage/sqlite"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+	"github.com/mmynk/splitwiser/pkg/proto/protoconnect"
+)
+
+// setupChaosTestServer is setupGroupTestServer over a store that injects
+// faults. No faults are set until the test sets them.
+func setupChaosTestServer(t *testing.T) (protoconnect.GroupServiceClient, protoconnect.SplitServiceClient, *chaos.Store) {
+	t.Helper()
+
+	db, err := sqlite.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	store := chaos.New(db, chaos.Config{Seed: 1})
+	t.Cleanup(func() { store.Close() })
+
+	if err := db.CreateUser(context.Background(), &models.User{
+		ID:           testUserID,
+		Email:        "alice@example.com",
+		DisplayName:  "Alice",
+		PasswordHash: "hash",
+		CreatedAt:    time.Now().Unix(),
+		UpdatedAt:    time.Now().Unix(),
+	}); err != nil {
+		t.Fatalf("failed to create test user: %v", err)
+	}
+
+	authInterceptor := connect.WithInterceptors(testAuthInterceptor())
+	splitPath, splitHandler := protoconnect.NewSplitServiceHandler(NewSplitService(store), authInterceptor)
+	groupPath, groupHandler := protoconnect.NewGroupServiceHandler(NewGroupService(store), authInterceptor)
+	mux := http.NewServeMux()
+	mux.Handle(splitPath, splitHandler)
+	mux.Handle(groupPath, groupHandler)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return protoconnect.NewGroupServiceClient(http.DefaultClient, server.URL),
+		protoconnect.NewSplitServiceClient(http.DefaultClient, server.URL),
+		store
+}
+
+func TestStorageFaults_ErrorCodes(t *testing.T) {
+	groupClient, splitClient, store := setupChaosTestServer(t)
+	ctx := context.Background()
+
+	groupResp, err := groupClient.CreateGroup(ctx, connect.NewRequest(&pb.CreateGroupRequest{
+		Name:    "Trip",
+		Members: gm("Alice", "Bob"),
+	}))
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	groupID := groupResp.Msg.Group.Id
+
+	tests := []struct {
+		name   string
+		method string
+		call   func() error
+		want   connect.Code
+	}{
+		{"CreateBill save fails", "CreateBill", func() error {
+			_, err := splitClient.CreateBill(ctx, connect.NewRequest(&pb.CreateBillRequest{
+				Title: "Dinner", Total: 10, Subtotal: 10,
+				Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+			}))
+			return err
+		}, connect.CodeInternal},
+		{"ListMyBills list fails", "ListBillsByUser", func() error {
+			_, err := splitClient.ListMyBills(ctx, connect.NewRequest(&pb.ListMyBillsRequest{}))
+			return err
+		}, connect.CodeInternal},
+		{"ListGroups list fails", "ListGroupsByUser", func() error {
+			_, err := groupClient.ListGroups(ctx, connect.NewRequest(&pb.ListGroupsRequest{}))
+			return err
+		}, connect.CodeInternal},
+		{"GetGroupBalances bills fail", "ListBillsByGroup", func() error {
+			_, err := groupClient.GetGroupBalances(ctx, connect.NewRequest(&pb.GetGroupBalancesRequest{GroupId: groupID}))
+			return err
+		}, connect.CodeInternal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store.SetFault(tt.method, chaos.Fault{ErrorRate: 1})
+			defer store.SetFault(tt.method, chaos.Fault{})
+
+			if code := connect.CodeOf(tt.call()); code != tt.want {
+				t.Errorf("code = %v, want %v", code, tt.want)
+			}
+			if _, failed := store.Calls(tt.method); failed == 0 {
+				t.Errorf("%s was never failed", tt.method)
+			}
+		})
+	}
+
+	// Nothing was saved by the failed CreateBill.
+	listResp, err := splitClient.ListMyBills(ctx, connect.NewRequest(&pb.ListMyBillsRequest{}))
+	if err != nil {
+		t.Fatalf("ListMyBills failed: %v", err)
+	}
+	if len(listResp.Msg.Bills) != 0 {
+		t.Errorf("expected no bills after failed create, got %d", len(listResp.Msg.Bills))
+	}
+}
+
+func TestStorageFaults_GracefulDegradation(t *testing.T) {
+	groupClient, splitClient, store := setupChaosTestServer(t)
+	ctx := context.Background()
+
+	groupResp, err := groupClient.CreateGroup(ctx, connect.NewRequest(&pb.CreateGroupRequest{
+		Name:    "Trip",
+		Members: gm("Alice"),
+	}))
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	groupID := groupResp.Msg.Group.Id
+
+	// Adding Bob to the group is best effort; the bill is still created.
+	store.SetFault("AddGroupMembersWithIDs", chaos.Fault{ErrorRate: 1})
+	createResp, err := splitClient.CreateBill(ctx, connect.NewRequest(&pb.CreateBillRequest{
+		Title: "Dinner", Total: 30, Subtotal: 30,
+		Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+		GroupId:      &groupID,
+	}))
+	if err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+	store.Reset()
+
+	// Group names are decoration: bills are still returned without them.
+	store.SetFault("GetGroup", chaos.Fault{ErrorRate: 1})
+	getResp, err := splitClient.GetBill(ctx, connect.NewRequest(&pb.GetBillRequest{BillId: createResp.Msg.BillId}))
+	if err != nil {
+		t.Fatalf("GetBill failed: %v", err)
+	}
+	if getResp.Msg.GroupName != nil || getResp.Msg.Split.Splits["Bob"].GetTotal() != 15 {
+		t.Errorf("GetBill = group name %v, Bob %v; want no group name and Bob's 15", getResp.Msg.GroupName, getResp.Msg.Split.Splits["Bob"])
+	}
+	listResp, err := splitClient.ListMyBills(ctx, connect.NewRequest(&pb.ListMyBillsRequest{}))
+	if err != nil {
+		t.Fatalf("ListMyBills failed: %v", err)
+	}
+	if len(listResp.Msg.Bills) != 1 || listResp.Msg.Bills[0].GroupName != nil {
+		t.Errorf("ListMyBills = %v, want one bill without a group name", listResp.Msg.Bills)
+	}
+}
+
+func TestStorageFaults_Latency(t *testing.T) {
+	_, splitClient, store := setupChaosTestServer(t)
+
+	// Slow storage only slows calls down.
+	store.SetDefault(chaos.Fault{Latency: 20 * time.Millisecond})
+	if _, err := splitClient.ListMyBills(context.Background(), connect.NewRequest(&pb.ListMyBillsRequest{})); err != nil {
+		t.Fatalf("ListMyBills failed under latency: %v", err)
+	}
+
+	// A deadline cuts storage waits short. Whether the client sees its own
+	// deadline or the server's error first is a race, so only speed is checked.
+	store.SetDefault(chaos.Fault{Latency: time.Second})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := splitClient.ListMyBills(ctx, connect.NewRequest(&pb.ListMyBillsRequest{})); err == nil {
+		t.Error("expected an error once the deadline passed")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("call took %v; the deadline should cut the injected latency short", elapsed)
+	}
+}
+
+func TestStorageFaults_RandomFailures(t *testing.T) {
+	groupClient, splitClient, store := setupChaosTestServer(t)
+	ctx := context.Background()
+
+	groupResp, err := groupClient.CreateGroup(ctx, connect.NewRequest(&pb.CreateGroupRequest{
+		Name:    "Flat",
+		Members: gm("Alice", "Bob"),
+	}))
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	groupID := groupResp.Msg.Group.Id
+
+	// With every store call failing a third of the time, each RPC either
+	// succeeds or reports a proper error; nothing panics or leaks as Unknown.
+	store.SetDefault(chaos.Fault{ErrorRate: 0.3})
+	allowed := map[connect.Code]bool{connect.CodeInternal: true, connect.CodeNotFound: true}
+	check := func(op string, err error) {
+		t.Helper()
+		if err != nil && !allowed[connect.CodeOf(err)] {
+			t.Errorf("%s: unexpected error %v", op, err)
+		}
+	}
+	var billIDs []string
+	for range 30 {
+		resp, err := splitClient.CreateBill(ctx, connect.NewRequest(&pb.CreateBillRequest{
+			Title: "Groceries", Total: 20, Subtotal: 20,
+			Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+			GroupId:      &groupID,
+		}))
+		check("CreateBill", err)
+		if err == nil {
+			billIDs = append(billIDs, resp.Msg.BillId)
+		}
+		_, err = groupClient.GetGroupBalances(ctx, connect.NewRequest(&pb.GetGroupBalancesRequest{GroupId: groupID}))
+		check("GetGroupBalances", err)
+		_, err = splitClient.ListBillsByGroup(ctx, connect.NewRequest(&pb.ListBillsByGroupRequest{GroupId: groupID}))
+		check("ListBillsByGroup", err)
+	}
+	for _, id := range billIDs {
+		_, err := splitClient.GetBill(ctx, connect.NewRequest(&pb.GetBillRequest{BillId: id}))
+		check("GetBill", err)
+	}
+	if calls, failed := store.Calls("CreateBillInGroup"); failed == 0 || failed == calls {
+		t.Errorf("CreateBillInGroup failed %d of %d calls; want some of each", failed, calls)
+	}
+
+	// Once storage recovers, every bill that was reported created is there.
+	store.Reset()
+	listResp, err := splitClient.ListBillsByGroup(ctx, connect.NewRequest(&pb.ListBillsByGroupRequest{GroupId: groupID}))
+	if err != nil {
+		t.Fatalf("ListBillsByGroup failed: %v", err)
+	}
+	if len(listResp.Msg.Bills) != len(billIDs) {
+		t.Errorf("group has %d bills, want the %d reported created", len(listResp.Msg.Bills), len(billIDs))
+	}
+}
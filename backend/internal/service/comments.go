@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"connectrpc.com/connect"
+	"github.com/mmynk/splitwiser/internal/authctx"
+	"github.com/mmynk/splitwiser/internal/models"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+)
+
+// maxCommentLength bounds a comment's body, in characters.
+const maxCommentLength = 2000
+
+func commentToProto(c *models.Comment) *pb.Comment {
+	return &pb.Comment{
+		Id:        c.ID,
+		BillId:    c.BillID,
+		AuthorId:  c.AuthorID,
+		Body:      c.Body,
+		CreatedAt: c.CreatedAt,
+	}
+}
+
+// commentBill loads the bill a comment is on, if userID is on it.
+func (s *SplitService) commentBill(ctx context.Context, userID, billID string) (*models.Bill, error) {
+	bill, err := s.store.GetBill(ctx, billID)
+	if err != nil {
+		return nil, storeError(err)
+	}
+	if !hasAccess(userID, bill) {
+		return nil, connect.NewError(connect.CodePermissionDenied, fmt.Errorf("you must be a participant to comment on this bill"))
+	}
+	return bill, nil
+}
+
+// AddBillComment posts a comment on a bill from one of its participants.
+func (s *SplitService) AddBillComment(ctx context.Context, req *connect.Request[pb.AddBillCommentRequest]) (*connect.Response[pb.AddBillCommentResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+	}
+	if req.Msg.BillId == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("bill_id required"))
+	}
+	body := strings.TrimSpace(req.Msg.Body)
+	if body == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("body required"))
+	}
+	if utf8.RuneCountInString(body) > maxCommentLength {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("body must be at most %d characters", maxCommentLength))
+	}
+
+	bill, err := s.commentBill(ctx, userID, req.Msg.BillId)
+	if err != nil {
+		return nil, err
+	}
+	comment := &models.Comment{BillID: bill.ID, AuthorID: userID, Body: body}
+	if err := s.store.CreateComment(ctx, comment); err != nil {
+		logger.Error("AddBillComment failed", "bill_id", bill.ID, "error", err)
+		return nil, storeError(err)
+	}
+
+	return connect.NewResponse(&pb.AddBillCommentResponse{Comment: commentToProto(comment)}), nil
+}
+
+// ListBillComments returns a bill's comments, oldest first, to anyone who
+// can see the bill.
+func (s *SplitService) ListBillComments(ctx context.Context, req *connect.Request[pb.ListBillCommentsRequest]) (*connect.Response[pb.ListBillCommentsResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+	}
+	if req.Msg.BillId == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("bill_id required"))
+	}
+
+	bill, err := s.commentBill(ctx, userID, req.Msg.BillId)
+	if err != nil {
+		return nil, err
+	}
+	comments, err := s.store.ListCommentsByBill(ctx, bill.ID)
+	if err != nil {
+		logger.Error("ListBillComments failed", "bill_id", bill.ID, "error", err)
+		return nil, storeError(err)
+	}
+
+	resp := &pb.ListBillCommentsResponse{Comments: make([]*pb.Comment, len(comments))}
+	for i, c := range comments {
+		resp.Comments[i] = commentToProto(c)
+	}
+	return connect.NewResponse(resp), nil
+}
+
+// DeleteBillComment removes a comment. Its author may delete it, and so may
+// the bill's creator, who moderates the bill's discussion.
+func (s *SplitService) DeleteBillComment(ctx context.Context, req *connect.Request[pb.DeleteBillCommentRequest]) (*connect.Response[pb.DeleteBillCommentResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+	}
+	if req.Msg.CommentId == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("comment_id required"))
+	}
+
+	comment, err := s.store.GetComment(ctx, req.Msg.CommentId)
+	if err != nil {
+		return nil, storeError(err)
+	}
+	bill, err := s.commentBill(ctx, userID, comment.BillID)
+	if err != nil {
+		return nil, err
+	}
+	if comment.AuthorID != userID && bill.CreatorID != userID {
+		return nil, connect.NewError(connect.CodePermissionDenied, fmt.Errorf("only the comment's author or the bill's creator can delete it"))
+	}
+	if err := s.store.DeleteComment(ctx, comment.ID); err != nil {
+		logger.Error("DeleteBillComment failed", "comment_id", comment.ID, "error", err)
+		return nil, storeError(err)
+	}
+
+	return connect.NewResponse(&pb.DeleteBillCommentResponse{}), nil
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/mmynk/splitwiser/internal/models"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+)
+
+func TestBillComments(t *testing.T) {
+	splitClient, _, _, store, cleanup := setupTestServerWithFriendService(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	bill := &models.Bill{
+		Title:        "Pub",
+		Total:        30,
+		Subtotal:     30,
+		CreatorID:    testUserID,
+		PayerID:      "Alice",
+		Participants: []models.BillParticipant{{DisplayName: "Alice", UserID: testUserID}, {DisplayName: "Bob", UserID: testBobID}},
+	}
+	if err := store.CreateBill(ctx, bill); err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+	billID := bill.ID
+
+	added, err := splitClient.AddBillComment(ctx, connect.NewRequest(&pb.AddBillCommentRequest{
+		BillId: billID,
+		Body:   "  I didn't have the beer  ",
+	}))
+	if err != nil {
+		t.Fatalf("AddBillComment failed: %v", err)
+	}
+	if c := added.Msg.Comment; c.Body != "I didn't have the beer" || c.AuthorId != testUserID || c.BillId != billID {
+		t.Errorf("added comment = %v, want the trimmed body by Alice on the bill", c)
+	}
+
+	// Bob answers; his comment was written through the store as the test
+	// server always signs in as Alice.
+	reply := &models.Comment{BillID: billID, AuthorID: testBobID, Body: "You had two", CreatedAt: added.Msg.Comment.CreatedAt + 1}
+	if err := store.CreateComment(ctx, reply); err != nil {
+		t.Fatalf("CreateComment failed: %v", err)
+	}
+
+	list, err := splitClient.ListBillComments(ctx, connect.NewRequest(&pb.ListBillCommentsRequest{BillId: billID}))
+	if err != nil {
+		t.Fatalf("ListBillComments failed: %v", err)
+	}
+	if len(list.Msg.Comments) != 2 || list.Msg.Comments[0].Id != added.Msg.Comment.Id || list.Msg.Comments[1].Id != reply.ID {
+		t.Fatalf("ListBillComments = %v, want Alice's comment then Bob's", list.Msg.Comments)
+	}
+
+	for _, body := range []string{"", "   ", strings.Repeat("x", maxCommentLength+1)} {
+		_, err := splitClient.AddBillComment(ctx, connect.NewRequest(&pb.AddBillCommentRequest{BillId: billID, Body: body}))
+		if connect.CodeOf(err) != connect.CodeInvalidArgument {
+			t.Errorf("AddBillComment(%d-byte body): expected InvalidArgument, got %v", len(body), err)
+		}
+	}
+
+	// Alice created the bill, so she may delete Bob's comment as well as her own.
+	for _, id := range []string{reply.ID, added.Msg.Comment.Id} {
+		if _, err := splitClient.DeleteBillComment(ctx, connect.NewRequest(&pb.DeleteBillCommentRequest{CommentId: id})); err != nil {
+			t.Fatalf("DeleteBillComment(%s) failed: %v", id, err)
+		}
+	}
+	_, err = splitClient.DeleteBillComment(ctx, connect.NewRequest(&pb.DeleteBillCommentRequest{CommentId: reply.ID}))
+	if connect.CodeOf(err) != connect.CodeNotFound {
+		t.Errorf("expected NotFound deleting a deleted comment, got %v", err)
+	}
+}
+
+func TestBillComments_NotParticipant(t *testing.T) {
+	splitClient, _, _, store, cleanup := setupTestServerWithFriendService(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	// Bob's bill with Carol, which Alice isn't on.
+	bill := &models.Bill{
+		Title:        "Taxi",
+		Total:        20,
+		Subtotal:     20,
+		CreatorID:    testBobID,
+		PayerID:      "Bob",
+		Participants: []models.BillParticipant{{DisplayName: "Bob", UserID: testBobID}, {DisplayName: "Carol"}},
+	}
+	if err := store.CreateBill(ctx, bill); err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+	comment := &models.Comment{BillID: bill.ID, AuthorID: testBobID, Body: "Carol owes me"}
+	if err := store.CreateComment(ctx, comment); err != nil {
+		t.Fatalf("CreateComment failed: %v", err)
+	}
+
+	if _, err := splitClient.AddBillComment(ctx, connect.NewRequest(&pb.AddBillCommentRequest{BillId: bill.ID, Body: "Hi"})); connect.CodeOf(err) != connect.CodePermissionDenied {
+		t.Errorf("AddBillComment: expected PermissionDenied, got %v", err)
+	}
+	if _, err := splitClient.ListBillComments(ctx, connect.NewRequest(&pb.ListBillCommentsRequest{BillId: bill.ID})); connect.CodeOf(err) != connect.CodePermissionDenied {
+		t.Errorf("ListBillComments: expected PermissionDenied, got %v", err)
+	}
+	if _, err := splitClient.DeleteBillComment(ctx, connect.NewRequest(&pb.DeleteBillCommentRequest{CommentId: comment.ID})); connect.CodeOf(err) != connect.CodePermissionDenied {
+		t.Errorf("DeleteBillComment: expected PermissionDenied, got %v", err)
+	}
+
+	// On the bill but neither the author nor the creator, Alice can read
+	// Bob's comment but not delete it.
+	bill.Participants = append(bill.Participants, models.BillParticipant{DisplayName: "Alice", UserID: testUserID})
+	if err := store.UpdateBill(ctx, bill); err != nil {
+		t.Fatalf("UpdateBill failed: %v", err)
+	}
+	if _, err := splitClient.ListBillComments(ctx, connect.NewRequest(&pb.ListBillCommentsRequest{BillId: bill.ID})); err != nil {
+		t.Errorf("ListBillComments as a participant failed: %v", err)
+	}
+	if _, err := splitClient.DeleteBillComment(ctx, connect.NewRequest(&pb.DeleteBillCommentRequest{CommentId: comment.ID})); connect.CodeOf(err) != connect.CodePermissionDenied {
+		t.Errorf("DeleteBillComment of someone else's comment: expected PermissionDenied, got %v", err)
+	}
+}
@@ -0,0 +1,26 @@
+package service
+
+import (
+	"errors"
+
+	"connectrpc.com/connect"
+	"github.com/mmynk/splitwiser/internal/storage"
+)
+
+// storeError maps an error from the store to the Connect code it amounts to:
+// a missing row is NotFound, a duplicate AlreadyExists, a dangling
+// reference FailedPrecondition and a write to a read-only store Unavailable.
+// Anything else is Internal.
+func storeError(err error) error {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		return connect.NewError(connect.CodeNotFound, err)
+	case errors.Is(err, storage.ErrConflict):
+		return connect.NewError(connect.CodeAlreadyExists, err)
+	case errors.Is(err, storage.ErrForeignKey):
+		return connect.NewError(connect.CodeFailedPrecondition, err)
+	case errors.Is(err, storage.ErrReadOnly):
+		return connect.NewError(connect.CodeUnavailable, err)
+	}
+	return connect.NewError(connect.CodeInternal, err)
+}
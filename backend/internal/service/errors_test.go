@@ -0,0 +1,31 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/mmynk/splitwiser/internal/storage"
+)
+
+func TestStoreError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want connect.Code
+	}{
+		{storage.Errorf(storage.ErrNotFound, "bill not found: %s", "b1"), connect.CodeNotFound},
+		{storage.Errorf(storage.ErrConflict, "friendship already exists"), connect.CodeAlreadyExists},
+		{storage.Errorf(storage.ErrForeignKey, "FOREIGN KEY constraint failed"), connect.CodeFailedPrecondition},
+		{storage.Errorf(storage.ErrReadOnly, "storage is read-only: CreateBill refused"), connect.CodeUnavailable},
+		{errors.New("disk I/O error"), connect.CodeInternal},
+	}
+	for _, tt := range tests {
+		err := storeError(tt.err)
+		if got := connect.CodeOf(err); got != tt.want {
+			t.Errorf("storeError(%q) code = %v, want %v", tt.err, got, tt.want)
+		}
+		if !errors.Is(err, tt.err) {
+			t.Errorf("storeError(%q) does not wrap the store error", tt.err)
+		}
+	}
+}
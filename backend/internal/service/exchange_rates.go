@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"connectrpc.com/connect"
+
+	"github.com/mmynk/splitwiser/internal/fxrate"
+	"github.com/mmynk/splitwiser/internal/models"
+)
+
+// lockExchangeRate sets the rate bill converts into its group's currency at:
+// requested when given, else today's rate from the configured source. Bills
+// outside a group, without a currency or in the group's own need no rate.
+func (s *SplitService) lockExchangeRate(ctx context.Context, bill *models.Bill, requested float64) error {
+	bill.ExchangeRate = 0
+	if requested < 0 {
+		return connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("exchange rate must be positive, got %v", requested))
+	}
+	if bill.GroupID == "" || bill.Currency == "" {
+		return nil
+	}
+	group, err := s.store.GetGroup(ctx, bill.GroupID)
+	if err != nil {
+		return storeError(err)
+	}
+	currency := group.Settings.Currency
+	if currency == "" || currency == bill.Currency {
+		return nil
+	}
+	if requested > 0 {
+		bill.ExchangeRate = requested
+		return nil
+	}
+
+	if s.rates == nil {
+		return connect.NewError(connect.CodeInvalidArgument,
+			fmt.Errorf("the group keeps balances in %s; set exchange_rate to convert %s", currency, bill.Currency))
+	}
+	rate, err := s.rates.Rate(ctx, bill.Currency, currency)
+	if errors.Is(err, fxrate.ErrUnknownCurrency) {
+		return connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("%w; set exchange_rate to convert %s to %s", err, bill.Currency, currency))
+	}
+	if err != nil {
+		return connect.NewError(connect.CodeUnavailable, fmt.Errorf("could not look up the exchange rate: %w", err))
+	}
+	bill.ExchangeRate = rate
+	logger.Info("Locked exchange rate", "group_id", bill.GroupID, "from", bill.Currency, "to", currency, "rate", rate)
+	return nil
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"connectrpc.com/connect"
+
+	"github.com/mmynk/splitwiser/internal/fxrate"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+)
+
+func TestExchangeRateLocking(t *testing.T) {
+	rates := fxrate.Table{"EUR": 1, "USD": 0.5}
+	groupClient, splitClient, cleanup := setupGroupTestServer(t, WithExchangeRates(rates))
+	defer cleanup()
+	ctx := context.Background()
+
+	groupResp, err := groupClient.CreateGroup(ctx, connect.NewRequest(&pb.CreateGroupRequest{
+		Name:    "Trip",
+		Members: gm("Alice", "Bob"),
+	}))
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	groupID := groupResp.Msg.Group.Id
+	createBill := func(title, currency, payer string, total, rate float64) (*connect.Response[pb.CreateBillResponse], error) {
+		return splitClient.CreateBill(ctx, connect.NewRequest(&pb.CreateBillRequest{
+			Title:        title,
+			Total:        total,
+			Subtotal:     total,
+			Currency:     currency,
+			Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+			GroupId:      &groupID,
+			PayerId:      &payer,
+			ExchangeRate: rate,
+		}))
+	}
+
+	// Before the group has a currency, bills aren't converted.
+	taxi, err := createBill("Taxi", "USD", "Alice", 30, 0)
+	if err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+	setCurrency := func(currency string) error {
+		_, err := groupClient.UpdateGroup(ctx, connect.NewRequest(&pb.UpdateGroupRequest{
+			GroupId:  groupID,
+			Name:     "Trip",
+			Members:  gm("Alice", "Bob"),
+			Settings: &pb.GroupSettings{Currency: currency},
+		}))
+		return err
+	}
+	if err := setCurrency("eur"); err != nil {
+		t.Fatalf("UpdateGroup failed: %v", err)
+	}
+
+	// Today's rate is locked with the bill; later rates don't move it.
+	dinner, err := createBill("Dinner", "USD", "Alice", 40, 0)
+	if err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+	rates["USD"] = 2
+	getRate := func(billID string) float64 {
+		t.Helper()
+		resp, err := splitClient.GetBill(ctx, connect.NewRequest(&pb.GetBillRequest{BillId: billID}))
+		if err != nil {
+			t.Fatalf("GetBill failed: %v", err)
+		}
+		return resp.Msg.ExchangeRate
+	}
+	if got := getRate(dinner.Msg.BillId); got != 0.5 {
+		t.Errorf("expected locked rate 0.5, got %v", got)
+	}
+	payer := "Alice"
+	_, err = splitClient.UpdateBill(ctx, connect.NewRequest(&pb.UpdateBillRequest{
+		BillId:       dinner.Msg.BillId,
+		Title:        "Dinner out",
+		Total:        40,
+		Subtotal:     40,
+		Currency:     "USD",
+		Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+		GroupId:      &groupID,
+		PayerId:      &payer,
+	}))
+	if err != nil {
+		t.Fatalf("UpdateBill failed: %v", err)
+	}
+	if got := getRate(dinner.Msg.BillId); got != 0.5 {
+		t.Errorf("expected the update to keep rate 0.5, got %v", got)
+	}
+
+	// A currency without a known rate needs one given.
+	if _, err := createBill("Museum", "GBP", "Bob", 10, 0); connect.CodeOf(err) != connect.CodeInvalidArgument {
+		t.Fatalf("expected InvalidArgument without a GBP rate, got %v", err)
+	}
+	museum, err := createBill("Museum", "GBP", "Bob", 10, 1.2)
+	if err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+	if got := getRate(museum.Msg.BillId); got != 1.2 {
+		t.Errorf("expected rate 1.2, got %v", got)
+	}
+
+	// Dinner is 20 EUR, of which Bob owes 10; the museum 12 EUR, of which
+	// Alice owes 6. The taxi predates the currency and has no rate.
+	balances, err := groupClient.GetGroupBalances(ctx, connect.NewRequest(&pb.GetGroupBalancesRequest{GroupId: groupID}))
+	if err != nil {
+		t.Fatalf("GetGroupBalances failed: %v", err)
+	}
+	for _, b := range balances.Msg.MemberBalances {
+		want := map[string]float64{"Alice": 4, "Bob": -4}[b.DisplayName]
+		if b.NetBalance != want {
+			t.Errorf("%s: expected net balance %v, got %v", b.DisplayName, want, b.NetBalance)
+		}
+	}
+	skipped := balances.Msg.SkippedBills
+	if len(skipped) != 1 || skipped[0].BillId != taxi.Msg.BillId || skipped[0].Reason != pb.SkipReason_SKIP_REASON_NO_EXCHANGE_RATE {
+		t.Errorf("expected the taxi to be skipped for its exchange rate, got %v", skipped)
+	}
+
+	if err := setCurrency("USD"); connect.CodeOf(err) != connect.CodeFailedPrecondition {
+		t.Errorf("expected FailedPrecondition changing the group's currency, got %v", err)
+	}
+}
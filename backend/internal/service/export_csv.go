@@ -0,0 +1,144 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"connectrpc.com/connect"
+	"github.com/mmynk/splitwiser/internal/authctx"
+	"github.com/mmynk/splitwiser/internal/models"
+	"github.com/mmynk/splitwiser/internal/storage"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+	"github.com/mmynk/splitwiser/pkg/splitmath"
+)
+
+// billsCSVHeader names the columns of an ExportBillsCSV file.
+var billsCSVHeader = []string{
+	"bill_id", "bill", "date", "group", "currency", "person", "paid_by",
+	"items", "discount", "tax", "tip", "fees", "covered", "total",
+}
+
+// csvText keeps text a spreadsheet would read as a formula, such as
+// "=SUM(A1)", as text by quoting it with a leading apostrophe.
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// writeBillsCSV writes a header row and then one row per person per bill,
+// in the order of bills. groupNames maps group IDs to names. Amounts are
+// plain decimals in the bill's currency, for spreadsheets to read.
+func writeBillsCSV(bills []*models.Bill, groupNames map[string]string) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(billsCSVHeader); err != nil {
+		return nil, err
+	}
+	for _, bill := range bills {
+		split, err := billSplit(bill, false)
+		if err != nil {
+			logger.Error("CalculateSplit failed during ExportBillsCSV", "bill_id", bill.ID, "error", err)
+			continue
+		}
+		amount := func(v float64) string {
+			return strconv.FormatFloat(v, 'f', splitmath.MinorUnits(bill.Currency), 64)
+		}
+		date := expenseDate(bill).Format(importDateLayout)
+		for _, p := range bill.Participants {
+			ps := split.GetSplits()[p.DisplayName]
+			if ps == nil {
+				continue
+			}
+			if err := w.Write([]string{
+				bill.ID, csvText(bill.Title), date, csvText(groupNames[bill.GroupID]), bill.Currency, csvText(p.DisplayName), csvText(bill.PayerID),
+				amount(ps.Subtotal), amount(ps.Discount), amount(ps.Tax), amount(ps.Tip), amount(ps.Fees), amount(ps.Covered), amount(ps.Total),
+			}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ExportBillsCSV exports bills as a CSV with one row per person per bill,
+// giving each person's share of the items, discount, tax, tip and fees, any
+// covering, and their total, for spreadsheets and expense reports. It exports a group's
+// bills when group_id is set, and otherwise every bill the caller is on.
+// Bills awaiting assignment have no shares yet and are left out.
+func (s *SplitService) ExportBillsCSV(ctx context.Context, req *connect.Request[pb.ExportBillsCSVRequest]) (*connect.Response[pb.ExportBillsCSVResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+	}
+
+	filter := storage.BillFilter{
+		Since:           req.Msg.Since,
+		Until:           req.Msg.Until,
+		ExcludeArchived: !req.Msg.IncludeArchived,
+	}
+	groupNames := make(map[string]string)
+	filename := "bills"
+	var bills []*models.Bill
+	if req.Msg.GroupId != nil {
+		group, err := s.store.GetGroup(ctx, req.Msg.GetGroupId())
+		if err != nil {
+			logger.Error("ExportBillsCSV: failed to get group", "group_id", req.Msg.GetGroupId(), "error", err)
+			return nil, storeError(err)
+		}
+		if !isMember(userID, group.Members) {
+			return nil, connect.NewError(connect.CodePermissionDenied, fmt.Errorf("you must be a member of this group"))
+		}
+		groupNames[group.ID] = group.Name
+		filename = safeFilename(group.Name, "group") + "-bills"
+
+		bills, err = s.store.ListBillsByGroup(ctx, group.ID, filter)
+		if err != nil {
+			logger.Error("ExportBillsCSV failed", "group_id", group.ID, "error", err)
+			return nil, storeError(err)
+		}
+	} else {
+		// The user's listing leaves out items, so the bills are read in full.
+		summaries, err := s.store.ListBillsByUser(ctx, userID, filter)
+		if err != nil {
+			logger.Error("ExportBillsCSV failed", "user_id", userID, "error", err)
+			return nil, storeError(err)
+		}
+		ids := make([]string, len(summaries))
+		for i, bill := range summaries {
+			ids[i] = bill.ID
+		}
+		if bills, err = s.store.GetBillsByIDs(ctx, ids); err != nil {
+			logger.Error("ExportBillsCSV failed to read bills", "user_id", userID, "error", err)
+			return nil, storeError(err)
+		}
+		for _, bill := range bills {
+			if _, ok := groupNames[bill.GroupID]; bill.GroupID != "" && !ok {
+				if group, err := s.store.GetGroup(ctx, bill.GroupID); err == nil {
+					groupNames[bill.GroupID] = group.Name
+				}
+			}
+		}
+	}
+
+	bills = slices.DeleteFunc(bills, func(b *models.Bill) bool { return b.NeedsAssignment })
+	// Listings are newest first; spreadsheets read better oldest first.
+	slices.Reverse(bills)
+
+	data, err := writeBillsCSV(bills, groupNames)
+	if err != nil {
+		logger.Error("ExportBillsCSV failed to write CSV", "error", err)
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	return connect.NewResponse(&pb.ExportBillsCSVResponse{Csv: data, Filename: filename + ".csv"}), nil
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"slices"
+	"testing"
+
+	"connectrpc.com/connect"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+)
+
+func TestExportBillsCSV(t *testing.T) {
+	splitClient, groupClient, _, _, cleanup := setupTestServerWithFriendService(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	group, err := groupClient.CreateGroup(ctx, connect.NewRequest(&pb.CreateGroupRequest{Name: "Flat 2B", Members: gm("Alice", "Bob")}))
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	groupID := group.Msg.Group.Id
+
+	if _, err := splitClient.CreateBill(ctx, connect.NewRequest(&pb.CreateBillRequest{
+		Title:    "=Groceries",
+		Total:    33,
+		Subtotal: 30,
+		Tip:      3,
+		Items: []*pb.Item{
+			{Description: "Bread", Amount: 10, ParticipantIds: []string{"Alice"}},
+			{Description: "Cheese", Amount: 20, ParticipantIds: []string{"Bob"}},
+		},
+		PayerId:      strPtr("Alice"),
+		Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+		GroupId:      &groupID,
+	})); err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+	if _, err := splitClient.CreateBill(ctx, connect.NewRequest(&pb.CreateBillRequest{
+		Title:        "Taxi",
+		Total:        12,
+		Subtotal:     12,
+		PayerId:      strPtr("Alice"),
+		Participants: []*pb.BillParticipant{aliceBP(), guestBP("Carol")},
+	})); err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+
+	exported, err := splitClient.ExportBillsCSV(ctx, connect.NewRequest(&pb.ExportBillsCSVRequest{GroupId: &groupID}))
+	if err != nil {
+		t.Fatalf("ExportBillsCSV failed: %v", err)
+	}
+	if exported.Msg.Filename != "Flat-2B-bills.csv" {
+		t.Errorf("filename = %q, want Flat-2B-bills.csv", exported.Msg.Filename)
+	}
+	rows, err := csv.NewReader(bytes.NewReader(exported.Msg.Csv)).ReadAll()
+	if err != nil {
+		t.Fatalf("export isn't valid CSV: %v", err)
+	}
+	if len(rows) != 3 || !slices.Equal(rows[0], billsCSVHeader) {
+		t.Fatalf("rows = %q, want the header and a row each for Alice and Bob", rows)
+	}
+	bob := rows[2]
+	// bill, group, person, paid_by, items, tip, total
+	got := []string{bob[1], bob[3], bob[5], bob[6], bob[7], bob[10], bob[13]}
+	if want := []string{"'=Groceries", "Flat 2B", "Bob", "Alice", "20.00", "2.00", "22.00"}; !slices.Equal(got, want) {
+		t.Errorf("Bob's row = %q, want %q", got, want)
+	}
+
+	mine, err := splitClient.ExportBillsCSV(ctx, connect.NewRequest(&pb.ExportBillsCSVRequest{}))
+	if err != nil {
+		t.Fatalf("ExportBillsCSV failed: %v", err)
+	}
+	rows, err = csv.NewReader(bytes.NewReader(mine.Msg.Csv)).ReadAll()
+	if err != nil {
+		t.Fatalf("export isn't valid CSV: %v", err)
+	}
+	var titles []string
+	for _, row := range rows[1:] {
+		titles = append(titles, row[1])
+	}
+	slices.Sort(titles)
+	if want := []string{"'=Groceries", "'=Groceries", "Taxi", "Taxi"}; !slices.Equal(titles, want) {
+		t.Errorf("bills of the rows = %q, want %q", titles, want)
+	}
+}
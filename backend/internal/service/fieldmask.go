@@ -0,0 +1,178 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// maskTree is a parsed field mask: the fields to keep at one level of a
+// message, each with the subfields to keep. A field with no subfields is kept
+// whole.
+type maskTree map[string]maskTree
+
+// parseFieldMask checks mask's paths against resp, the (empty) response
+// message it applies to, and returns them as a tree. Paths are dot-separated field names
+// and step into the elements of repeated fields and the values of map fields,
+// so "bills.title" keeps the title of every bill. An empty mask returns nil.
+func parseFieldMask(mask *fieldmaskpb.FieldMask, resp proto.Message) (maskTree, error) {
+	if len(mask.GetPaths()) == 0 {
+		return nil, nil
+	}
+	tree := maskTree{}
+	for _, path := range mask.GetPaths() {
+		names := strings.Split(path, ".")
+		md := resp.ProtoReflect().Descriptor()
+		for _, name := range names {
+			if md == nil {
+				return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("field mask path %q goes past a scalar field", path))
+			}
+			fd := md.Fields().ByName(protoreflect.Name(name))
+			if fd == nil {
+				return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("field mask path %q: unknown field %q", path, name))
+			}
+			md = fd.Message()
+			if fd.IsMap() {
+				md = fd.MapValue().Message()
+			}
+		}
+		tree.add(names)
+	}
+	return tree, nil
+}
+
+// add keeps the field at names. Keeping a field whole wins over keeping some
+// of its subfields, whichever path comes first.
+func (t maskTree) add(names []string) {
+	child, ok := t[names[0]]
+	switch {
+	case ok && len(child) == 0:
+	case len(names) == 1:
+		t[names[0]] = maskTree{}
+	default:
+		if !ok {
+			child = maskTree{}
+			t[names[0]] = child
+		}
+		child.add(names[1:])
+	}
+}
+
+// has reports whether the mask keeps any of the field at path, so callers can
+// skip work for fields that would be cleared. A nil tree keeps everything.
+func (t maskTree) has(path ...string) bool {
+	for _, name := range path {
+		if len(t) == 0 {
+			return true
+		}
+		child, ok := t[name]
+		if !ok {
+			return false
+		}
+		t = child
+	}
+	return true
+}
+
+// apply clears every field of msg the mask doesn't keep. A nil tree keeps everything.
+func (t maskTree) apply(msg proto.Message) {
+	if t == nil {
+		return
+	}
+	t.prune(msg.ProtoReflect())
+}
+
+func (t maskTree) prune(m protoreflect.Message) {
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		child, ok := t[string(fd.Name())]
+		switch {
+		case !ok:
+			m.Clear(fd)
+		case len(child) == 0:
+		case fd.IsList():
+			list := v.List()
+			for i := range list.Len() {
+				child.prune(list.Get(i).Message())
+			}
+		case fd.IsMap():
+			v.Map().Range(func(_ protoreflect.MapKey, mv protoreflect.Value) bool {
+				child.prune(mv.Message())
+				return true
+			})
+		default:
+			child.prune(v.Message())
+		}
+		return true
+	})
+}
+
+// updateOptions are the UpdateBillRequest fields that say how to apply an
+// update rather than what to store, so an update mask doesn't name them.
+var updateOptions = map[string]bool{
+	"bill_id":                  true,
+	"update_mask":              true,
+	"item_validation":          true,
+	"item_tolerance":           true,
+	"removed_participant_mode": true,
+	"exchange_rate":            true,
+}
+
+// applyUpdateMask copies the fields mask names from patch into base, the
+// stored bill as an update request. Paths are dot-separated field names; an
+// index after a repeated field, as in "items.2.amount", names one element,
+// which must exist in both. A field absent from patch is cleared.
+func applyUpdateMask(mask *fieldmaskpb.FieldMask, base, patch proto.Message) error {
+	for _, path := range mask.GetPaths() {
+		names := strings.Split(path, ".")
+		if updateOptions[names[0]] {
+			return connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("update mask path %q: %s is not a bill field", path, names[0]))
+		}
+		if err := copyMasked(base.ProtoReflect(), patch.ProtoReflect(), names, path); err != nil {
+			return connect.NewError(connect.CodeInvalidArgument, err)
+		}
+	}
+	return nil
+}
+
+func copyMasked(dst, src protoreflect.Message, names []string, path string) error {
+	fd := dst.Descriptor().Fields().ByName(protoreflect.Name(names[0]))
+	if fd == nil {
+		return fmt.Errorf("update mask path %q: unknown field %q", path, names[0])
+	}
+	if len(names) == 1 {
+		if src.Has(fd) {
+			dst.Set(fd, src.Get(fd))
+		} else {
+			dst.Clear(fd)
+		}
+		return nil
+	}
+	switch {
+	case fd.IsList() && fd.Message() != nil:
+		i, err := strconv.Atoi(names[1])
+		if err != nil {
+			return fmt.Errorf("update mask path %q: %s needs an index", path, names[0])
+		}
+		to, from := dst.Mutable(fd).List(), src.Get(fd).List()
+		if i < 0 || i >= to.Len() {
+			return fmt.Errorf("update mask path %q: the bill has %d %s", path, to.Len(), names[0])
+		}
+		if i >= from.Len() {
+			return fmt.Errorf("update mask path %q: the request has %d %s", path, from.Len(), names[0])
+		}
+		if len(names) == 2 {
+			to.Set(i, from.Get(i))
+			return nil
+		}
+		return copyMasked(to.Get(i).Message(), from.Get(i).Message(), names[2:], path)
+	case fd.Message() != nil && !fd.IsList() && !fd.IsMap():
+		return copyMasked(dst.Mutable(fd).Message(), src.Get(fd).Message(), names[1:], path)
+	default:
+		return fmt.Errorf("update mask path %q goes past %s", path, names[0])
+	}
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+)
+
+func TestFieldMask(t *testing.T) {
+	client, cleanup := setupTestServer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	createResp, err := client.CreateBill(ctx, connect.NewRequest(&pb.CreateBillRequest{
+		Title: "Lunch",
+		Items: []*pb.Item{
+			{Description: "Soup", Amount: 12, ParticipantIds: []string{"Alice"}},
+			{Description: "Salad", Amount: 8, ParticipantIds: []string{"Bob"}},
+		},
+		Total:        22,
+		Subtotal:     20,
+		Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+	}))
+	if err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+
+	t.Run("GetBill keeps only masked fields", func(t *testing.T) {
+		resp, err := client.GetBill(ctx, connect.NewRequest(&pb.GetBillRequest{
+			BillId:    createResp.Msg.BillId,
+			FieldMask: &fieldmaskpb.FieldMask{Paths: []string{"title", "split.splits.total"}},
+		}))
+		if err != nil {
+			t.Fatalf("GetBill failed: %v", err)
+		}
+		msg := resp.Msg
+		if msg.Title != "Lunch" || msg.BillId != "" || msg.Total != 0 || len(msg.Items) != 0 || len(msg.Participants) != 0 {
+			t.Errorf("unexpected fields outside the mask: %v", msg)
+		}
+		alice := msg.Split.GetSplits()["Alice"]
+		if alice.GetTotal() != 13.2 || alice.GetSubtotal() != 0 || len(alice.GetItems()) != 0 || msg.Split.TaxAmount != 0 {
+			t.Errorf("split = %v, want only per-person totals", msg.Split)
+		}
+	})
+
+	t.Run("GetBill skips the split when it isn't masked", func(t *testing.T) {
+		resp, err := client.GetBill(ctx, connect.NewRequest(&pb.GetBillRequest{
+			BillId:    createResp.Msg.BillId,
+			FieldMask: &fieldmaskpb.FieldMask{Paths: []string{"items.description"}},
+		}))
+		if err != nil {
+			t.Fatalf("GetBill failed: %v", err)
+		}
+		if resp.Msg.Split != nil || len(resp.Msg.Items) != 2 || resp.Msg.Items[0].Description != "Soup" || resp.Msg.Items[0].Amount != 0 {
+			t.Errorf("unexpected response: %v", resp.Msg)
+		}
+	})
+
+	t.Run("list masks apply to every element", func(t *testing.T) {
+		resp, err := client.ListMyBills(ctx, connect.NewRequest(&pb.ListMyBillsRequest{
+			FieldMask: &fieldmaskpb.FieldMask{Paths: []string{"bills.title", "bills"}},
+		}))
+		if err != nil {
+			t.Fatalf("ListMyBills failed: %v", err)
+		}
+		// "bills" keeps the whole summary even though "bills.title" came first.
+		if len(resp.Msg.Bills) != 1 || resp.Msg.Bills[0].Title != "Lunch" || resp.Msg.Bills[0].Total != 22 {
+			t.Errorf("bills = %v, want the whole summary", resp.Msg.Bills)
+		}
+
+		resp, err = client.ListMyBills(ctx, connect.NewRequest(&pb.ListMyBillsRequest{
+			FieldMask: &fieldmaskpb.FieldMask{Paths: []string{"bills.title"}},
+		}))
+		if err != nil {
+			t.Fatalf("ListMyBills failed: %v", err)
+		}
+		if len(resp.Msg.Bills) != 1 || resp.Msg.Bills[0].Title != "Lunch" || resp.Msg.Bills[0].Total != 0 || resp.Msg.Bills[0].BillId != "" {
+			t.Errorf("bills = %v, want titles only", resp.Msg.Bills)
+		}
+	})
+
+	t.Run("invalid paths are rejected", func(t *testing.T) {
+		for _, path := range []string{"nope", "title.length", "split.splits.nope"} {
+			_, err := client.GetBill(ctx, connect.NewRequest(&pb.GetBillRequest{
+				BillId:    createResp.Msg.BillId,
+				FieldMask: &fieldmaskpb.FieldMask{Paths: []string{path}},
+			}))
+			if connect.CodeOf(err) != connect.CodeInvalidArgument {
+				t.Errorf("path %q: got %v, want InvalidArgument", path, err)
+			}
+		}
+	})
+}
+
+func TestUpdateBill_UpdateMask(t *testing.T) {
+	client, cleanup := setupTestServer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	createResp, err := client.CreateBill(ctx, connect.NewRequest(&pb.CreateBillRequest{
+		Title: "Lunch",
+		Items: []*pb.Item{
+			{Description: "Pizza", Amount: 20, ParticipantIds: []string{"Alice", "Bob"}},
+			{Description: "Salad", Amount: 10, ParticipantIds: []string{"Bob"}},
+		},
+		Total:        33,
+		Subtotal:     30,
+		Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+		PayerId:      strPtr("Alice"),
+	}))
+	if err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+	billID := createResp.Msg.BillId
+
+	update := func(msg *pb.UpdateBillRequest, paths ...string) (*pb.UpdateBillResponse, error) {
+		msg.BillId = billID
+		msg.UpdateMask = &fieldmaskpb.FieldMask{Paths: paths}
+		resp, err := client.UpdateBill(ctx, connect.NewRequest(msg))
+		if err != nil {
+			return nil, err
+		}
+		return resp.Msg, nil
+	}
+
+	// Only the title changes; the items, participants and payer stay.
+	if _, err := update(&pb.UpdateBillRequest{Title: "Team lunch"}, "title"); err != nil {
+		t.Fatalf("UpdateBill(title) failed: %v", err)
+	}
+	// The salad was 12, from the second element of the request's items.
+	resp, err := update(&pb.UpdateBillRequest{Items: []*pb.Item{{}, {Amount: 12}}, Subtotal: 32, Total: 35}, "items.1.amount", "subtotal", "total")
+	if err != nil {
+		t.Fatalf("UpdateBill(items.1.amount) failed: %v", err)
+	}
+	if got := resp.Split.Splits["Bob"].Subtotal; got != 22 {
+		t.Errorf("Bob's subtotal = %v, want 22 (half the pizza and the salad)", got)
+	}
+
+	got, err := client.GetBill(ctx, connect.NewRequest(&pb.GetBillRequest{BillId: billID}))
+	if err != nil {
+		t.Fatalf("GetBill failed: %v", err)
+	}
+	bill := got.Msg
+	if bill.Title != "Team lunch" || bill.PayerId != "Alice" || len(bill.Participants) != 2 {
+		t.Errorf("bill = %q paid by %q with %d participants; want Team lunch, Alice, 2", bill.Title, bill.PayerId, len(bill.Participants))
+	}
+	if len(bill.Items) != 2 || bill.Items[0].Amount != 20 || bill.Items[1].Description != "Salad" || bill.Items[1].Amount != 12 {
+		t.Errorf("items = %v, want the pizza unchanged and the salad at 12", bill.Items)
+	}
+
+	for _, paths := range [][]string{
+		{"bill_id"},
+		{"exchange_rate"},
+		{"nonsense"},
+		{"items.5.amount"},
+		{"items.x"},
+		{"title.length"},
+		{"items.1.amount"}, // the request has no second item
+	} {
+		if _, err := update(&pb.UpdateBillRequest{Title: "Dinner"}, paths...); connect.CodeOf(err) != connect.CodeInvalidArgument {
+			t.Errorf("UpdateBill(%v): expected InvalidArgument, got %v", paths, err)
+		}
+	}
+}
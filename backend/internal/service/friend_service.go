@@ -3,10 +3,9 @@ package service
 import (
 	"context"
 	"fmt"
-	"log/slog"
 
 	"connectrpc.com/connect"
-	"github.com/mmynk/splitwiser/internal/middleware"
+	"github.com/mmynk/splitwiser/internal/authctx"
 	"github.com/mmynk/splitwiser/internal/models"
 	"github.com/mmynk/splitwiser/internal/storage"
 	pb "github.com/mmynk/splitwiser/pkg/proto"
@@ -26,7 +25,7 @@ func NewFriendService(store storage.Store) *FriendService {
 
 // SendFriendRequest sends a friend request to another registered user.
 func (s *FriendService) SendFriendRequest(ctx context.Context, req *connect.Request[pb.SendFriendRequestRequest]) (*connect.Response[pb.SendFriendRequestResponse], error) {
-	callerID := middleware.GetUserID(ctx)
+	callerID := authctx.UserID(ctx)
 	if callerID == "" {
 		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
 	}
@@ -42,7 +41,7 @@ func (s *FriendService) SendFriendRequest(ctx context.Context, req *connect.Requ
 	// Verify addressee exists
 	users, err := s.store.GetUsersByIDs(ctx, []string{addresseeID})
 	if err != nil {
-		slog.Error("SendFriendRequest: lookup addressee failed", "error", err, "addressee_id", addresseeID)
+		logger.Error("SendFriendRequest: lookup addressee failed", "error", err, "addressee_id", addresseeID)
 		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to lookup user: %w", err))
 	}
 	addressee, ok := users[addresseeID]
@@ -53,7 +52,7 @@ func (s *FriendService) SendFriendRequest(ctx context.Context, req *connect.Requ
 	// Look up caller's display name
 	callerUsers, err := s.store.GetUsersByIDs(ctx, []string{callerID})
 	if err != nil || callerUsers[callerID] == nil {
-		slog.Error("SendFriendRequest: lookup caller failed", "error", err, "caller_id", callerID)
+		logger.Error("SendFriendRequest: lookup caller failed", "error", err, "caller_id", callerID)
 		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to lookup caller: %w", err))
 	}
 	caller := callerUsers[callerID]
@@ -65,10 +64,10 @@ func (s *FriendService) SendFriendRequest(ctx context.Context, req *connect.Requ
 	}
 
 	if err := s.store.SendFriendRequest(ctx, friendship); err != nil {
-		slog.Error("SendFriendRequest: insert failed", "error", err, "requester_id", callerID, "addressee_id", addresseeID)
+		logger.Error("SendFriendRequest: insert failed", "error", err, "requester_id", callerID, "addressee_id", addresseeID)
 		return nil, connect.NewError(connect.CodeAlreadyExists, err)
 	}
-	slog.Info("Friend request sent", "requester_id", callerID, "addressee_id", addresseeID)
+	logger.Info("Friend request sent", "requester_id", callerID, "addressee_id", addresseeID)
 
 	return connect.NewResponse(&pb.SendFriendRequestResponse{
 		Request: &pb.FriendRequest{
@@ -85,7 +84,7 @@ func (s *FriendService) SendFriendRequest(ctx context.Context, req *connect.Requ
 
 // RespondToFriendRequest accepts or declines a pending friend request.
 func (s *FriendService) RespondToFriendRequest(ctx context.Context, req *connect.Request[pb.RespondToFriendRequestRequest]) (*connect.Response[pb.RespondToFriendRequestResponse], error) {
-	callerID := middleware.GetUserID(ctx)
+	callerID := authctx.UserID(ctx)
 	if callerID == "" {
 		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
 	}
@@ -96,7 +95,7 @@ func (s *FriendService) RespondToFriendRequest(ctx context.Context, req *connect
 
 	friendship, err := s.store.GetFriendship(ctx, req.Msg.RequestId)
 	if err != nil {
-		slog.Error("RespondToFriendRequest: lookup failed", "error", err, "request_id", req.Msg.RequestId)
+		logger.Error("RespondToFriendRequest: lookup failed", "error", err, "request_id", req.Msg.RequestId)
 		return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("friend request not found"))
 	}
 
@@ -114,17 +113,17 @@ func (s *FriendService) RespondToFriendRequest(ctx context.Context, req *connect
 	}
 
 	if err := s.store.UpdateFriendshipStatus(ctx, friendship.ID, newStatus); err != nil {
-		slog.Error("RespondToFriendRequest: update status failed", "error", err, "request_id", friendship.ID)
-		return nil, connect.NewError(connect.CodeInternal, err)
+		logger.Error("RespondToFriendRequest: update status failed", "error", err, "request_id", friendship.ID)
+		return nil, storeError(err)
 	}
 	friendship.Status = newStatus
-	slog.Info("Friend request responded", "request_id", friendship.ID, "accept", req.Msg.Accept, "caller_id", callerID)
+	logger.Info("Friend request responded", "request_id", friendship.ID, "accept", req.Msg.Accept, "caller_id", callerID)
 
 	// Hydrate display names
 	userMap, err := s.store.GetUsersByIDs(ctx, []string{friendship.RequesterID, friendship.AddresseeID})
 	if err != nil {
-		slog.Error("RespondToFriendRequest: hydrate names failed", "error", err, "request_id", friendship.ID)
-		return nil, connect.NewError(connect.CodeInternal, err)
+		logger.Error("RespondToFriendRequest: hydrate names failed", "error", err, "request_id", friendship.ID)
+		return nil, storeError(err)
 	}
 
 	pbReq := friendshipToProto(friendship, userMap)
@@ -133,15 +132,15 @@ func (s *FriendService) RespondToFriendRequest(ctx context.Context, req *connect
 
 // ListFriends returns all accepted friends of the authenticated user.
 func (s *FriendService) ListFriends(ctx context.Context, req *connect.Request[pb.ListFriendsRequest]) (*connect.Response[pb.ListFriendsResponse], error) {
-	callerID := middleware.GetUserID(ctx)
+	callerID := authctx.UserID(ctx)
 	if callerID == "" {
 		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
 	}
 
 	friends, err := s.store.GetFriends(ctx, callerID)
 	if err != nil {
-		slog.Error("ListFriends: storage failed", "error", err, "caller_id", callerID)
-		return nil, connect.NewError(connect.CodeInternal, err)
+		logger.Error("ListFriends: storage failed", "error", err, "caller_id", callerID)
+		return nil, storeError(err)
 	}
 
 	pbFriends := make([]*pb.Friend, len(friends))
@@ -158,15 +157,15 @@ func (s *FriendService) ListFriends(ctx context.Context, req *connect.Request[pb
 
 // ListFriendRequests lists pending incoming or outgoing friend requests.
 func (s *FriendService) ListFriendRequests(ctx context.Context, req *connect.Request[pb.ListFriendRequestsRequest]) (*connect.Response[pb.ListFriendRequestsResponse], error) {
-	callerID := middleware.GetUserID(ctx)
+	callerID := authctx.UserID(ctx)
 	if callerID == "" {
 		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
 	}
 
 	friendships, err := s.store.ListFriendships(ctx, callerID, req.Msg.Incoming, models.FriendshipPending)
 	if err != nil {
-		slog.Error("ListFriendRequests: storage failed", "error", err, "caller_id", callerID)
-		return nil, connect.NewError(connect.CodeInternal, err)
+		logger.Error("ListFriendRequests: storage failed", "error", err, "caller_id", callerID)
+		return nil, storeError(err)
 	}
 
 	// Collect all user IDs to fetch in one query
@@ -182,8 +181,8 @@ func (s *FriendService) ListFriendRequests(ctx context.Context, req *connect.Req
 
 	userMap, err := s.store.GetUsersByIDs(ctx, ids)
 	if err != nil {
-		slog.Error("ListFriendRequests: hydrate names failed", "error", err, "caller_id", callerID)
-		return nil, connect.NewError(connect.CodeInternal, err)
+		logger.Error("ListFriendRequests: hydrate names failed", "error", err, "caller_id", callerID)
+		return nil, storeError(err)
 	}
 
 	pbRequests := make([]*pb.FriendRequest, len(friendships))
@@ -196,7 +195,7 @@ func (s *FriendService) ListFriendRequests(ctx context.Context, req *connect.Req
 
 // RemoveFriend removes an accepted friendship.
 func (s *FriendService) RemoveFriend(ctx context.Context, req *connect.Request[pb.RemoveFriendRequest]) (*connect.Response[pb.RemoveFriendResponse], error) {
-	callerID := middleware.GetUserID(ctx)
+	callerID := authctx.UserID(ctx)
 	if callerID == "" {
 		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
 	}
@@ -213,17 +212,17 @@ func (s *FriendService) RemoveFriend(ctx context.Context, req *connect.Request[p
 		return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("no accepted friendship with that user"))
 	}
 	if err := s.store.DeleteFriendship(ctx, friendship.ID); err != nil {
-		slog.Error("RemoveFriend: delete failed", "error", err, "friendship_id", friendship.ID, "caller_id", callerID)
-		return nil, connect.NewError(connect.CodeInternal, err)
+		logger.Error("RemoveFriend: delete failed", "error", err, "friendship_id", friendship.ID, "caller_id", callerID)
+		return nil, storeError(err)
 	}
-	slog.Info("Friend removed", "caller_id", callerID, "removed_user_id", req.Msg.UserId)
+	logger.Info("Friend removed", "caller_id", callerID, "removed_user_id", req.Msg.UserId)
 
 	return connect.NewResponse(&pb.RemoveFriendResponse{}), nil
 }
 
 // SearchFriends searches accepted friends by partial display name.
 func (s *FriendService) SearchFriends(ctx context.Context, req *connect.Request[pb.SearchFriendsRequest]) (*connect.Response[pb.SearchFriendsResponse], error) {
-	callerID := middleware.GetUserID(ctx)
+	callerID := authctx.UserID(ctx)
 	if callerID == "" {
 		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
 	}
@@ -235,8 +234,8 @@ func (s *FriendService) SearchFriends(ctx context.Context, req *connect.Request[
 
 	users, err := s.store.SearchFriends(ctx, callerID, query)
 	if err != nil {
-		slog.Error("SearchFriends: storage failed", "error", err, "caller_id", callerID)
-		return nil, connect.NewError(connect.CodeInternal, err)
+		logger.Error("SearchFriends: storage failed", "error", err, "caller_id", callerID)
+		return nil, storeError(err)
 	}
 
 	pbUsers := make([]*pb.FriendSearchResult, len(users))
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+	"github.com/mmynk/splitwiser/pkg/proto/protoconnect"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite golden files in testdata/golden")
+
+// volatileFields are generated per run and replaced before comparing with golden files.
+var volatileFields = map[string]bool{
+	"bill_id":    true,
+	"group_id":   true,
+	"created_at": true,
+	"id":         true,
+}
+
+// assertGolden compares msg, rendered as JSON with proto field names and all
+// fields populated, against testdata/golden/<name>.json. Run with -update to
+// rewrite the file after an intentional change.
+func assertGolden(t *testing.T, name string, msg proto.Message) {
+	t.Helper()
+
+	raw, err := protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}.Marshal(msg)
+	if err != nil {
+		t.Fatalf("marshal %s: %v", name, err)
+	}
+	// Round-trip through encoding/json for stable key order and whitespace.
+	var doc any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		t.Fatalf("unmarshal %s: %v", name, err)
+	}
+	scrubVolatile(doc)
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		t.Fatalf("encode %s: %v", name, err)
+	}
+	got := buf.Bytes()
+
+	path := filepath.Join("testdata", "golden", name+".json")
+	if *updateGolden {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("create golden dir: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("write golden file: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read golden file (run with -update to create it): %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s does not match %s (run with -update if the change is intended)\ngot:\n%s\nwant:\n%s", name, path, got, want)
+	}
+}
+
+// scrubVolatile replaces generated IDs and timestamps with a placeholder.
+func scrubVolatile(v any) {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, val := range v {
+			if volatileFields[key] && val != nil && val != "" {
+				v[key] = "<scrubbed>"
+				continue
+			}
+			scrubVolatile(val)
+		}
+	case []any:
+		for _, val := range v {
+			scrubVolatile(val)
+		}
+	}
+}
+
+// createGoldenTrip creates a group with two bills whose amounts are exact in
+// binary, so balances render identically however they're summed.
+func createGoldenTrip(t *testing.T, splitClient protoconnect.SplitServiceClient, groupID string) string {
+	t.Helper()
+	ctx := context.Background()
+	alice, bob := "Alice", "Bob"
+
+	dinner, err := splitClient.CreateBill(ctx, connect.NewRequest(&pb.CreateBillRequest{
+		Title: "Dinner",
+		Items: []*pb.Item{
+			{Description: "Pizza", Amount: 24, ParticipantIds: []string{"Alice", "Bob"}},
+			{Description: "Wine", Amount: 16, ParticipantIds: []string{"Bob", "Carol"}},
+		},
+		Subtotal:     40,
+		Tip:          10,
+		Total:        50,
+		Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob"), guestBP("Carol")},
+		PayerId:      &alice,
+		GroupId:      &groupID,
+	}))
+	if err != nil {
+		t.Fatalf("CreateBill (dinner) failed: %v", err)
+	}
+
+	_, err = splitClient.CreateBill(ctx, connect.NewRequest(&pb.CreateBillRequest{
+		Title:        "Taxi",
+		Subtotal:     30,
+		Total:        30,
+		Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob"), guestBP("Carol")},
+		PayerId:      &bob,
+		GroupId:      &groupID,
+	}))
+	if err != nil {
+		t.Fatalf("CreateBill (taxi) failed: %v", err)
+	}
+	return dinner.Msg.BillId
+}
+
+func TestGolden_GetBillAndGroupBalances(t *testing.T) {
+	splitClient, groupClient, cleanup := setupTestServerWithGroupService(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	groupResp, err := groupClient.CreateGroup(ctx, connect.NewRequest(&pb.CreateGroupRequest{
+		Name:    "Golden Trip",
+		Members: []*pb.GroupMember{{DisplayName: "Bob"}, {DisplayName: "Carol"}},
+	}))
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	billID := createGoldenTrip(t, splitClient, groupResp.Msg.Group.Id)
+
+	t.Run("GetBill", func(t *testing.T) {
+		resp, err := splitClient.GetBill(ctx, connect.NewRequest(&pb.GetBillRequest{BillId: billID}))
+		if err != nil {
+			t.Fatalf("GetBill failed: %v", err)
+		}
+		assertGolden(t, "get_bill", resp.Msg)
+	})
+
+	t.Run("GetGroupBalances", func(t *testing.T) {
+		resp, err := groupClient.GetGroupBalances(ctx, connect.NewRequest(&pb.GetGroupBalancesRequest{
+			GroupId: groupResp.Msg.Group.Id,
+		}))
+		if err != nil {
+			t.Fatalf("GetGroupBalances failed: %v", err)
+		}
+		assertGolden(t, "get_group_balances", resp.Msg)
+	})
+}
@@ -0,0 +1,465 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/mmynk/splitwiser/internal/authctx"
+	"github.com/mmynk/splitwiser/internal/models"
+	"github.com/mmynk/splitwiser/internal/storage"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+)
+
+// groupArchiveVersion is the GroupArchive format written by ExportGroupArchive.
+const groupArchiveVersion = 1
+
+// archivedBillToProto converts a bill to its archived form. Participants keep
+// only their display names, since user IDs don't carry across instances.
+func archivedBillToProto(bill *models.Bill) *pb.ArchivedBill {
+	participants := make([]*pb.ArchivedParticipant, len(bill.Participants))
+	for i, p := range bill.Participants {
+		participants[i] = &pb.ArchivedParticipant{
+			DisplayName: p.DisplayName,
+			Shares:      p.Shares,
+			Amount:      p.Amount,
+			Covered:     coverModeToProto(p.Covered),
+			Adjustment:  p.Adjustment,
+		}
+	}
+	return &pb.ArchivedBill{
+		Id:              bill.ID,
+		Title:           bill.Title,
+		Total:           bill.Total,
+		Subtotal:        bill.Subtotal,
+		Items:           modelToPbItems(bill.Items),
+		Participants:    participants,
+		Payer:           bill.PayerID,
+		Tip:             bill.Tip,
+		TipSplitMode:    tipSplitModeToProto(bill.TipSplitMode),
+		SplitType:       splitTypeToProto(bill.SplitType),
+		Discount:        bill.Discount,
+		DiscountType:    discountTypeToProto(bill.DiscountType),
+		Fees:            modelToPbFees(bill.Fees),
+		Currency:        bill.Currency,
+		CreatedAt:       bill.CreatedAt,
+		NeedsAssignment: bill.NeedsAssignment,
+		RemainderMode:   remainderModeToProto(bill.RemainderMode),
+		TaxInclusive:    bill.TaxInclusive,
+		TaxRate:         bill.TaxRate,
+		RoundingMode:    roundingModeToProto(bill.RoundingMode),
+		CategoryId:      bill.CategoryID,
+		ExchangeRate:    bill.ExchangeRate,
+		Note:            bill.Note,
+		MerchantName:    bill.MerchantName,
+		Location:        bill.Location,
+		BillDate:        bill.BillDate,
+	}
+}
+
+// archivedBillFromProto converts an archived bill back to a model bill,
+// renaming people per names and linking participants to members' accounts.
+// A bill archived without a bill date is dated when it was created.
+func archivedBillFromProto(ab *pb.ArchivedBill, names map[string]string, members []models.GroupMember) *models.Bill {
+	rename := func(name string) string {
+		if renamed, ok := names[name]; ok {
+			return renamed
+		}
+		return name
+	}
+	renameKeys := func(m map[string]float64) map[string]float64 {
+		if m == nil {
+			return nil
+		}
+		out := make(map[string]float64, len(m))
+		for k, v := range m {
+			out[rename(k)] = v
+		}
+		return out
+	}
+
+	items := pbToModelItems(ab.Items)
+	for i := range items {
+		for j, name := range items[i].Participants {
+			items[i].Participants[j] = rename(name)
+		}
+		items[i].Shares = renameKeys(items[i].Shares)
+		items[i].Units = renameKeys(items[i].Units)
+		items[i].Owner = rename(items[i].Owner)
+	}
+
+	userIDs := make(map[string]string, len(members))
+	for _, m := range members {
+		userIDs[m.DisplayName] = m.UserID
+	}
+	participants := make([]models.BillParticipant, len(ab.Participants))
+	for i, p := range ab.Participants {
+		name := rename(p.DisplayName)
+		participants[i] = models.BillParticipant{
+			DisplayName: name,
+			UserID:      userIDs[name],
+			Shares:      p.Shares,
+			Amount:      p.Amount,
+			Covered:     coverModeFromProto(p.Covered),
+			Adjustment:  p.Adjustment,
+		}
+	}
+
+	return &models.Bill{
+		Title:           ab.Title,
+		Items:           items,
+		Total:           ab.Total,
+		Subtotal:        ab.Subtotal,
+		Tip:             ab.Tip,
+		TipSplitMode:    tipSplitModeFromProto(ab.TipSplitMode),
+		SplitType:       splitTypeFromProto(ab.SplitType),
+		Discount:        ab.Discount,
+		DiscountType:    discountTypeFromProto(ab.DiscountType),
+		Fees:            pbToModelFees(ab.Fees),
+		Currency:        ab.Currency,
+		Participants:    participants,
+		PayerID:         rename(ab.Payer),
+		NeedsAssignment: ab.NeedsAssignment,
+		RemainderMode:   remainderModeFromProto(ab.RemainderMode),
+		TaxInclusive:    ab.TaxInclusive,
+		TaxRate:         ab.TaxRate,
+		RoundingMode:    roundingModeFromProto(ab.RoundingMode),
+		CategoryID:      ab.CategoryId,
+		ExchangeRate:    ab.ExchangeRate,
+		Note:            ab.Note,
+		MerchantName:    ab.MerchantName,
+		Location:        ab.Location,
+		BillDate:        cmp.Or(ab.BillDate, ab.CreatedAt),
+	}
+}
+
+// ExportGroupArchive returns a group with its bills, settlements and
+// settlement plans in a form ImportGroupArchive accepts on any instance.
+func (s *GroupService) ExportGroupArchive(ctx context.Context, req *connect.Request[pb.ExportGroupArchiveRequest]) (*connect.Response[pb.ExportGroupArchiveResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+	}
+
+	groupID := req.Msg.GetGroupId()
+	if groupID == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("group_id required"))
+	}
+	group, err := s.store.GetGroup(ctx, groupID)
+	if err != nil {
+		logger.Error("ExportGroupArchive failed - group not found", "group_id", groupID, "error", err)
+		return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("group not found"))
+	}
+	if !isMemberByName(s.resolveDisplayName(ctx, userID), group.Members) {
+		return nil, connect.NewError(connect.CodePermissionDenied, fmt.Errorf("not a member of this group"))
+	}
+
+	var memberIDs []string
+	for _, m := range group.Members {
+		if m.UserID != "" {
+			memberIDs = append(memberIDs, m.UserID)
+		}
+	}
+	users, err := s.store.GetUsersByIDs(ctx, memberIDs)
+	if err != nil {
+		logger.Error("ExportGroupArchive failed to look up members", "group_id", groupID, "error", err)
+		return nil, storeError(err)
+	}
+	bills, err := s.store.ListBillsByGroup(ctx, groupID, storage.BillFilter{})
+	if err != nil {
+		logger.Error("ExportGroupArchive failed to list bills", "group_id", groupID, "error", err)
+		return nil, storeError(err)
+	}
+	settlements, err := s.store.ListSettlementsByGroup(ctx, groupID, storage.SettlementFilter{})
+	if err != nil {
+		logger.Error("ExportGroupArchive failed to list settlements", "group_id", groupID, "error", err)
+		return nil, storeError(err)
+	}
+	plans, err := s.store.ListSettlementPlansByGroup(ctx, groupID)
+	if err != nil {
+		logger.Error("ExportGroupArchive failed to list settlement plans", "group_id", groupID, "error", err)
+		return nil, storeError(err)
+	}
+
+	now := time.Now()
+	archive := &pb.GroupArchive{
+		Version:       groupArchiveVersion,
+		ExportedAt:    now.Unix(),
+		SourceGroupId: group.ID,
+		Name:          group.Name,
+		Settings:      modelToPbGroup(group).Settings,
+		CreatedAt:     group.CreatedAt,
+	}
+	for _, m := range group.Members {
+		am := &pb.ArchivedMember{DisplayName: m.DisplayName}
+		if u := users[m.UserID]; u != nil {
+			am.Email = u.Email
+		}
+		archive.Members = append(archive.Members, am)
+	}
+	for _, bill := range bills {
+		archive.Bills = append(archive.Bills, archivedBillToProto(bill))
+	}
+	for _, settlement := range settlements {
+		archive.Settlements = append(archive.Settlements, settlementToProto(settlement))
+	}
+	for _, plan := range plans {
+		archive.SettlementPlans = append(archive.SettlementPlans, settlementPlanToProto(plan, now))
+	}
+
+	return connect.NewResponse(&pb.ExportGroupArchiveResponse{Archive: archive}), nil
+}
+
+// ImportGroupArchive creates a new group owned by the caller from an archive.
+// Members whose email belongs to the caller or one of the caller's friends are
+// linked to that account and take its display name; everyone else is imported
+// as a guest. All IDs are reassigned, and bills are created now, keeping
+// when they were created elsewhere as their bill date.
+func (s *GroupService) ImportGroupArchive(ctx context.Context, req *connect.Request[pb.ImportGroupArchiveRequest]) (*connect.Response[pb.ImportGroupArchiveResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+	}
+
+	archive := req.Msg.GetArchive()
+	if archive == nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("archive required"))
+	}
+	if archive.Version != groupArchiveVersion {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("unsupported archive version %d", archive.Version))
+	}
+
+	if err := s.quotas.CheckCreateGroup(ctx, userID); err != nil {
+		logger.Error("ImportGroupArchive quota check failed", "user_id", userID, "error", err)
+		return nil, quotaError(err)
+	}
+	if err := s.quotas.CheckCreateBills(ctx, userID, len(archive.Bills)); err != nil {
+		logger.Error("ImportGroupArchive bill quota check failed", "user_id", userID, "bills", len(archive.Bills), "error", err)
+		return nil, quotaError(err)
+	}
+
+	name := strings.TrimSpace(req.Msg.GetName())
+	if name == "" {
+		name = strings.TrimSpace(archive.Name)
+	}
+	if name == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("group name required"))
+	}
+	existing, err := s.store.ListGroupsByUser(ctx, userID, storage.Page{})
+	if err != nil {
+		logger.Error("ImportGroupArchive failed to list groups", "user_id", userID, "error", err)
+		return nil, storeError(err)
+	}
+	taken := make(map[string]bool, len(existing))
+	for _, g := range existing {
+		taken[g.Name] = true
+	}
+	if taken[name] {
+		if req.Msg.OnConflict == pb.ImportConflictPolicy_IMPORT_CONFLICT_POLICY_FAIL {
+			return nil, connect.NewError(connect.CodeAlreadyExists, fmt.Errorf("you already have a group named %q", name))
+		}
+		renamed := name + " (imported)"
+		for n := 2; taken[renamed]; n++ {
+			renamed = fmt.Sprintf("%s (imported %d)", name, n)
+		}
+		name = renamed
+	}
+
+	var warnings []string
+	members, names, err := s.linkArchivedMembers(ctx, userID, archive.Members, &warnings)
+	if err != nil {
+		return nil, err
+	}
+	rename := func(name string) string {
+		if renamed, ok := names[name]; ok {
+			return renamed
+		}
+		return name
+	}
+
+	now := time.Now().Unix()
+	bills := make([]*models.Bill, len(archive.Bills))
+	for i, ab := range archive.Bills {
+		bill := archivedBillFromProto(ab, names, members)
+		bill.CreatorID = userID
+		// Imported bills count toward this month's quota like any others.
+		bill.CreatedAt = now
+		if !bill.NeedsAssignment {
+			if err := validatePayerID(bill.PayerID, bill.Participants); err != nil {
+				return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("bill %q: %w", ab.Title, err))
+			}
+			if _, err := billSplit(bill, false); err != nil {
+				return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("bill %q: %w", ab.Title, err))
+			}
+		}
+		// Bill participants outside the member list join the group as
+		// guests, as they would when the bill was created.
+		members = append(members, findNewParticipants(bill.Participants, members)...)
+		bills[i] = bill
+	}
+
+	plans := make([]*models.SettlementPlan, len(archive.SettlementPlans))
+	for i, ap := range archive.SettlementPlans {
+		plan := &models.SettlementPlan{
+			ID:                ap.Id,
+			FromUserID:        rename(ap.FromUserId),
+			ToUserID:          rename(ap.ToUserId),
+			Total:             ap.Total,
+			InstallmentAmount: ap.InstallmentAmount,
+			Cadence:           planCadenceFromProto(ap.Cadence),
+			StartAt:           ap.StartAt,
+			CreatedAt:         ap.CreatedAt,
+			CreatedBy:         rename(ap.CreatedBy),
+			Note:              ap.Note,
+		}
+		if plan.Total <= 0 || plan.InstallmentAmount <= 0 {
+			return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("settlement plan %q: amounts must be positive", ap.Id))
+		}
+		if !isMemberByName(plan.FromUserID, members) || !isMemberByName(plan.ToUserID, members) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("settlement plan %q: both people must be group members", ap.Id))
+		}
+		plans[i] = plan
+	}
+
+	settlements := make([]*models.Settlement, len(archive.Settlements))
+	for i, as := range archive.Settlements {
+		settlement := &models.Settlement{
+			FromUserID: rename(as.FromUserId),
+			ToUserID:   rename(as.ToUserId),
+			Amount:     as.Amount,
+			CreatedAt:  as.CreatedAt,
+			CreatedBy:  rename(as.CreatedBy),
+			Note:       as.Note,
+			PlanID:     as.GetPlanId(),
+		}
+		if settlement.Amount <= 0 {
+			return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("settlement %q: amount must be positive", as.Id))
+		}
+		if !isMemberByName(settlement.FromUserID, members) || !isMemberByName(settlement.ToUserID, members) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("settlement %q: both people must be group members", as.Id))
+		}
+		settlements[i] = settlement
+	}
+
+	if len(archive.Attachments) > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d attachments were not imported", len(archive.Attachments)))
+	}
+
+	group := &models.Group{
+		Name:      name,
+		Members:   members,
+		CreatorID: userID,
+		CreatedAt: archive.CreatedAt,
+		Settings:  pbToModelGroupSettings(archive.Settings),
+	}
+	if caps := group.Settings.SpendingCaps; len(caps) > 0 {
+		group.Settings.SpendingCaps = make(map[string]float64, len(caps))
+		for member, amount := range caps {
+			if member = rename(member); isMemberByName(member, members) && amount > 0 {
+				group.Settings.SpendingCaps[member] = amount
+			}
+		}
+	}
+	if err := s.store.ImportGroupArchive(ctx, &models.GroupArchive{
+		Group:           group,
+		Bills:           bills,
+		Settlements:     settlements,
+		SettlementPlans: plans,
+	}); err != nil {
+		logger.Error("ImportGroupArchive failed", "source_group_id", archive.SourceGroupId, "error", err)
+		return nil, storeError(err)
+	}
+
+	return connect.NewResponse(&pb.ImportGroupArchiveResponse{
+		Group:                   modelToPbGroup(group),
+		BillsImported:           int32(len(bills)),
+		SettlementsImported:     int32(len(settlements)),
+		SettlementPlansImported: int32(len(plans)),
+		Warnings:                warnings,
+	}), nil
+}
+
+// linkArchivedMembers turns archived members into group members for the
+// importer. It returns the members, a map from archived display names to the
+// names they were imported under, and appends a warning for each member that
+// couldn't be linked. The importer always ends up a linked member.
+func (s *GroupService) linkArchivedMembers(ctx context.Context, userID string, archived []*pb.ArchivedMember, warnings *[]string) ([]models.GroupMember, map[string]string, error) {
+	members := make([]models.GroupMember, 0, len(archived)+1)
+	names := make(map[string]string)
+	linked := make(map[string]bool)
+
+	for _, am := range archived {
+		name := strings.TrimSpace(am.DisplayName)
+		if name == "" {
+			return nil, nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("archived member has no display name"))
+		}
+		if isMemberByName(name, members) {
+			return nil, nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("duplicate member %q", name))
+		}
+		members = append(members, models.GroupMember{DisplayName: name})
+	}
+
+	for i, am := range archived {
+		email := strings.ToLower(strings.TrimSpace(am.Email))
+		if email == "" {
+			continue
+		}
+		user, err := s.store.GetUserByEmail(ctx, email)
+		if err != nil {
+			logger.Error("ImportGroupArchive failed to look up member", "error", err)
+			return nil, nil, storeError(err)
+		}
+		if user == nil {
+			*warnings = append(*warnings, fmt.Sprintf("%s has no account here and was imported as a guest", am.DisplayName))
+			continue
+		}
+		if linked[user.ID] {
+			*warnings = append(*warnings, fmt.Sprintf("%s shares an account with another member and was imported as a guest", am.DisplayName))
+			continue
+		}
+		if user.ID != userID {
+			ok, err := s.store.AreFriends(ctx, userID, user.ID)
+			if err != nil {
+				return nil, nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to verify friendship: %w", err))
+			}
+			if !ok {
+				*warnings = append(*warnings, fmt.Sprintf("%s is not your friend and was imported as a guest", am.DisplayName))
+				continue
+			}
+		}
+		if user.DisplayName != members[i].DisplayName {
+			if isMemberByName(user.DisplayName, members) {
+				*warnings = append(*warnings, fmt.Sprintf("%s's account name %q is taken by another member; imported as a guest", am.DisplayName, user.DisplayName))
+				continue
+			}
+			names[members[i].DisplayName] = user.DisplayName
+			members[i].DisplayName = user.DisplayName
+		}
+		members[i].UserID = user.ID
+		linked[user.ID] = true
+	}
+
+	if !linked[userID] {
+		importerName := s.resolveDisplayName(ctx, userID)
+		matched := false
+		for i := range members {
+			if members[i].DisplayName == importerName && members[i].UserID == "" {
+				members[i].UserID = userID
+				matched = true
+				*warnings = append(*warnings, fmt.Sprintf("linked you to member %s by name", importerName))
+				break
+			}
+		}
+		if !matched {
+			if isMemberByName(importerName, members) {
+				return nil, nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("archive already has a member named %q", importerName))
+			}
+			members = append(members, models.GroupMember{DisplayName: importerName, UserID: userID})
+		}
+	}
+
+	return members, names, nil
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+)
+
+func TestGroupArchive_RoundTrip(t *testing.T) {
+	groupClient, splitClient, cleanup := setupGroupTestServer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	groupResp, err := groupClient.CreateGroup(ctx, connect.NewRequest(&pb.CreateGroupRequest{
+		Name:    "Flat",
+		Members: gm("Alice", "Bob"),
+	}))
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	groupID := groupResp.Msg.Group.Id
+
+	payer := "Alice"
+	billResp, err := splitClient.CreateBill(ctx, connect.NewRequest(&pb.CreateBillRequest{
+		Title:    "Groceries",
+		Total:    110,
+		Subtotal: 100,
+		Items: []*pb.Item{
+			{Description: "Cheese", Amount: 60, ParticipantIds: []string{"Alice", "Bob"}},
+			{Description: "Wine", Amount: 40, ParticipantIds: []string{"Bob"}},
+		},
+		Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+		GroupId:      &groupID,
+		PayerId:      &payer,
+	}))
+	if err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+
+	planResp, err := groupClient.CreateSettlementPlan(ctx, connect.NewRequest(&pb.CreateSettlementPlanRequest{
+		GroupId:           groupID,
+		FromUserId:        "Bob",
+		ToUserId:          "Alice",
+		Total:             60,
+		InstallmentAmount: 20,
+		StartAt:           time.Now().Unix(),
+	}))
+	if err != nil {
+		t.Fatalf("CreateSettlementPlan failed: %v", err)
+	}
+	planID := planResp.Msg.Plan.Id
+	if _, err := groupClient.RecordSettlement(ctx, connect.NewRequest(&pb.RecordSettlementRequest{
+		GroupId:    groupID,
+		FromUserId: "Bob",
+		ToUserId:   "Alice",
+		Amount:     20,
+		PlanId:     &planID,
+	})); err != nil {
+		t.Fatalf("RecordSettlement failed: %v", err)
+	}
+
+	exportResp, err := groupClient.ExportGroupArchive(ctx, connect.NewRequest(&pb.ExportGroupArchiveRequest{GroupId: groupID}))
+	if err != nil {
+		t.Fatalf("ExportGroupArchive failed: %v", err)
+	}
+	archive := exportResp.Msg.Archive
+	if len(archive.Bills) != 1 || len(archive.Settlements) != 1 || len(archive.SettlementPlans) != 1 {
+		t.Fatalf("archive has %d bills, %d settlements, %d plans; want 1 each",
+			len(archive.Bills), len(archive.Settlements), len(archive.SettlementPlans))
+	}
+	if archive.Members[0].Email != "alice@example.com" || archive.Members[1].Email != "" {
+		t.Errorf("member emails = %q, %q", archive.Members[0].Email, archive.Members[1].Email)
+	}
+
+	// Importing next to the original renames the copy by default.
+	importResp, err := groupClient.ImportGroupArchive(ctx, connect.NewRequest(&pb.ImportGroupArchiveRequest{Archive: archive}))
+	if err != nil {
+		t.Fatalf("ImportGroupArchive failed: %v", err)
+	}
+	imported := importResp.Msg
+	if imported.Group.Name != "Flat (imported)" {
+		t.Errorf("imported name = %q, want %q", imported.Group.Name, "Flat (imported)")
+	}
+	if imported.Group.Id == groupID {
+		t.Error("imported group kept the source group ID")
+	}
+	if imported.BillsImported != 1 || imported.SettlementsImported != 1 || imported.SettlementPlansImported != 1 {
+		t.Errorf("imported counts = %d/%d/%d, want 1/1/1",
+			imported.BillsImported, imported.SettlementsImported, imported.SettlementPlansImported)
+	}
+	if len(imported.Warnings) != 0 {
+		t.Errorf("unexpected warnings: %v", imported.Warnings)
+	}
+	newGroupID := imported.Group.Id
+
+	bills, err := splitClient.ListBillsByGroup(ctx, connect.NewRequest(&pb.ListBillsByGroupRequest{GroupId: newGroupID}))
+	if err != nil {
+		t.Fatalf("ListBillsByGroup failed: %v", err)
+	}
+	if len(bills.Msg.Bills) != 1 {
+		t.Fatalf("imported group has %d bills, want 1", len(bills.Msg.Bills))
+	}
+	if bills.Msg.Bills[0].BillId == billResp.Msg.BillId {
+		t.Error("imported bill kept the source bill ID")
+	}
+
+	plans, err := groupClient.ListSettlementPlans(ctx, connect.NewRequest(&pb.ListSettlementPlansRequest{GroupId: newGroupID}))
+	if err != nil {
+		t.Fatalf("ListSettlementPlans failed: %v", err)
+	}
+	if len(plans.Msg.Plans) != 1 || plans.Msg.Plans[0].Id == planID {
+		t.Fatalf("imported plans = %v, want one with a new ID", plans.Msg.Plans)
+	}
+	if plans.Msg.Plans[0].Paid != 20 {
+		t.Errorf("imported plan paid = %v, want 20 (settlement relinked to the new plan)", plans.Msg.Plans[0].Paid)
+	}
+
+	before, err := groupClient.GetGroupBalances(ctx, connect.NewRequest(&pb.GetGroupBalancesRequest{GroupId: groupID}))
+	if err != nil {
+		t.Fatalf("GetGroupBalances failed: %v", err)
+	}
+	after, err := groupClient.GetGroupBalances(ctx, connect.NewRequest(&pb.GetGroupBalancesRequest{GroupId: newGroupID}))
+	if err != nil {
+		t.Fatalf("GetGroupBalances failed: %v", err)
+	}
+	for i, want := range before.Msg.MemberBalances {
+		got := after.Msg.MemberBalances[i]
+		if got.DisplayName != want.DisplayName || got.NetBalance != want.NetBalance {
+			t.Errorf("balance %d = %s %v, want %s %v", i, got.DisplayName, got.NetBalance, want.DisplayName, want.NetBalance)
+		}
+	}
+
+	// A second rename gets a number.
+	again, err := groupClient.ImportGroupArchive(ctx, connect.NewRequest(&pb.ImportGroupArchiveRequest{Archive: archive}))
+	if err != nil {
+		t.Fatalf("second ImportGroupArchive failed: %v", err)
+	}
+	if again.Msg.Group.Name != "Flat (imported 2)" {
+		t.Errorf("second imported name = %q, want %q", again.Msg.Group.Name, "Flat (imported 2)")
+	}
+}
+
+func TestGroupArchive_ImportConflicts(t *testing.T) {
+	groupClient, _, cleanup := setupGroupTestServer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	if _, err := groupClient.CreateGroup(ctx, connect.NewRequest(&pb.CreateGroupRequest{
+		Name:    "Trip",
+		Members: gm("Alice"),
+	})); err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+
+	archive := &pb.GroupArchive{
+		Version: 1,
+		Name:    "Trip",
+		Members: []*pb.ArchivedMember{
+			{DisplayName: "Alice", Email: "alice@example.com"},
+			{DisplayName: "Carol", Email: "carol@example.com"},
+		},
+	}
+
+	t.Run("FailPolicy", func(t *testing.T) {
+		_, err := groupClient.ImportGroupArchive(ctx, connect.NewRequest(&pb.ImportGroupArchiveRequest{
+			Archive:    archive,
+			OnConflict: pb.ImportConflictPolicy_IMPORT_CONFLICT_POLICY_FAIL,
+		}))
+		if connect.CodeOf(err) != connect.CodeAlreadyExists {
+			t.Errorf("code = %v, want AlreadyExists", connect.CodeOf(err))
+		}
+	})
+
+	t.Run("NameOverride", func(t *testing.T) {
+		resp, err := groupClient.ImportGroupArchive(ctx, connect.NewRequest(&pb.ImportGroupArchiveRequest{
+			Archive:    archive,
+			Name:       "Trip 2025",
+			OnConflict: pb.ImportConflictPolicy_IMPORT_CONFLICT_POLICY_FAIL,
+		}))
+		if err != nil {
+			t.Fatalf("ImportGroupArchive failed: %v", err)
+		}
+		if resp.Msg.Group.Name != "Trip 2025" {
+			t.Errorf("name = %q, want %q", resp.Msg.Group.Name, "Trip 2025")
+		}
+		// Carol has no account here: she comes in as a guest with a warning.
+		if len(resp.Msg.Warnings) != 1 {
+			t.Errorf("warnings = %v, want one for Carol", resp.Msg.Warnings)
+		}
+		for _, m := range resp.Msg.Group.Members {
+			if m.DisplayName == "Alice" && m.GetUserId() != testUserID {
+				t.Errorf("Alice linked to %q, want %q", m.GetUserId(), testUserID)
+			}
+			if m.DisplayName == "Carol" && m.UserId != nil {
+				t.Errorf("Carol linked to %q, want guest", m.GetUserId())
+			}
+		}
+	})
+
+	t.Run("UnsupportedVersion", func(t *testing.T) {
+		_, err := groupClient.ImportGroupArchive(ctx, connect.NewRequest(&pb.ImportGroupArchiveRequest{
+			Archive: &pb.GroupArchive{Version: 2, Name: "Later"},
+		}))
+		if connect.CodeOf(err) != connect.CodeInvalidArgument {
+			t.Errorf("code = %v, want InvalidArgument", connect.CodeOf(err))
+		}
+	})
+}
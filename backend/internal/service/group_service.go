@@ -3,26 +3,32 @@ package service
 import (
 	"context"
 	"fmt"
-	"log/slog"
+	"maps"
+	"strings"
 
 	"connectrpc.com/connect"
-	"github.com/mmynk/splitwiser/internal/calculator"
-	"github.com/mmynk/splitwiser/internal/middleware"
+	"github.com/mmynk/splitwiser/internal/authctx"
 	"github.com/mmynk/splitwiser/internal/models"
+	"github.com/mmynk/splitwiser/internal/quota"
 	"github.com/mmynk/splitwiser/internal/storage"
+	"github.com/mmynk/splitwiser/internal/webhook"
 	pb "github.com/mmynk/splitwiser/pkg/proto"
 	"github.com/mmynk/splitwiser/pkg/proto/protoconnect"
+	"github.com/mmynk/splitwiser/pkg/splitmath"
 )
 
 // GroupService implements the Connect GroupService
 type GroupService struct {
 	protoconnect.UnimplementedGroupServiceHandler
-	store storage.Store
+	store    storage.Store
+	quotas   *quota.Enforcer
+	webhooks *webhook.Dispatcher
 }
 
 // NewGroupService creates a new GroupService with the given storage backend.
-func NewGroupService(store storage.Store) *GroupService {
-	return &GroupService{store: store}
+func NewGroupService(store storage.Store, opts ...Option) *GroupService {
+	o := applyOptions(opts)
+	return &GroupService{store: store, quotas: o.quotas, webhooks: o.webhooks}
 }
 
 // isMember checks if the user (by UUID) is in the members list.
@@ -81,13 +87,50 @@ func pbToModelMembers(pbMembers []*pb.GroupMember) []models.GroupMember {
 	return result
 }
 
+// modelToPbGroup converts a model Group to a proto Group.
+func modelToPbGroup(group *models.Group) *pb.Group {
+	return &pb.Group{
+		Id:        group.ID,
+		Name:      group.Name,
+		Members:   modelToPbMembers(group.Members),
+		CreatedAt: group.CreatedAt,
+		Settings: &pb.GroupSettings{
+			DisableAutoTitle:      group.Settings.DisableAutoTitle,
+			TitleTemplate:         group.Settings.TitleTemplate,
+			DefaultPayerIsCreator: group.Settings.DefaultPayerIsCreator,
+			SpendingCaps:          group.Settings.SpendingCaps,
+			RoundingMode:          roundingModeToProto(group.Settings.RoundingMode),
+			PayerRotation:         group.Settings.PayerRotation,
+			Currency:              group.Settings.Currency,
+		},
+	}
+}
+
+// pbToModelGroupSettings converts proto GroupSettings to model GroupSettings.
+func pbToModelGroupSettings(settings *pb.GroupSettings) models.GroupSettings {
+	return models.GroupSettings{
+		DisableAutoTitle:      settings.GetDisableAutoTitle(),
+		TitleTemplate:         strings.TrimSpace(settings.GetTitleTemplate()),
+		DefaultPayerIsCreator: settings.GetDefaultPayerIsCreator(),
+		SpendingCaps:          settings.GetSpendingCaps(),
+		RoundingMode:          roundingModeFromProto(settings.GetRoundingMode()),
+		PayerRotation:         settings.GetPayerRotation(),
+		Currency:              normalizeCurrency(settings.GetCurrency()),
+	}
+}
+
 // CreateGroup creates a new group.
 func (s *GroupService) CreateGroup(ctx context.Context, req *connect.Request[pb.CreateGroupRequest]) (*connect.Response[pb.CreateGroupResponse], error) {
-	userID := middleware.GetUserID(ctx)
+	userID := authctx.UserID(ctx)
 	if userID == "" {
 		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
 	}
 
+	if err := s.quotas.CheckCreateGroup(ctx, userID); err != nil {
+		logger.Error("CreateGroup quota check failed", "user_id", userID, "error", err)
+		return nil, quotaError(err)
+	}
+
 	creatorName := s.resolveDisplayName(ctx, userID)
 
 	members := pbToModelMembers(req.Msg.Members)
@@ -110,79 +153,90 @@ func (s *GroupService) CreateGroup(ctx context.Context, req *connect.Request[pb.
 	}
 
 	group := &models.Group{
-		Name:    req.Msg.Name,
-		Members: members,
+		Name:      req.Msg.Name,
+		Members:   members,
+		CreatorID: userID,
+		Settings:  pbToModelGroupSettings(req.Msg.Settings),
+	}
+	if err := validateSpendingCaps(group.Settings.SpendingCaps, members); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
 	}
 
 	if err := s.store.CreateGroup(ctx, group); err != nil {
-		slog.Error("CreateGroup failed", "error", err)
-		return nil, connect.NewError(connect.CodeInternal, err)
+		logger.Error("CreateGroup failed", "error", err)
+		return nil, storeError(err)
 	}
 
 	return connect.NewResponse(&pb.CreateGroupResponse{
-		Group: &pb.Group{
-			Id:        group.ID,
-			Name:      group.Name,
-			Members:   modelToPbMembers(group.Members),
-			CreatedAt: group.CreatedAt,
-		},
+		Group: modelToPbGroup(group),
 	}), nil
 }
 
 // GetGroup retrieves a group by ID.
 func (s *GroupService) GetGroup(ctx context.Context, req *connect.Request[pb.GetGroupRequest]) (*connect.Response[pb.GetGroupResponse], error) {
-	userID := middleware.GetUserID(ctx)
+	userID := authctx.UserID(ctx)
 	if userID == "" {
 		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
 	}
 
+	mask, err := parseFieldMask(req.Msg.FieldMask, &pb.GetGroupResponse{})
+	if err != nil {
+		return nil, err
+	}
+
 	group, err := s.store.GetGroup(ctx, req.Msg.GroupId)
 	if err != nil {
-		slog.Error("GetGroup failed", "group_id", req.Msg.GroupId, "error", err)
-		return nil, connect.NewError(connect.CodeNotFound, err)
+		logger.Error("GetGroup failed", "group_id", req.Msg.GroupId, "error", err)
+		return nil, storeError(err)
 	}
 
-	return connect.NewResponse(&pb.GetGroupResponse{
-		Group: &pb.Group{
-			Id:        group.ID,
-			Name:      group.Name,
-			Members:   modelToPbMembers(group.Members),
-			CreatedAt: group.CreatedAt,
-		},
-	}), nil
+	resp := &pb.GetGroupResponse{
+		Group: modelToPbGroup(group),
+	}
+	mask.apply(resp)
+	return connect.NewResponse(resp), nil
 }
 
 // ListGroups retrieves all groups the authenticated user belongs to.
 func (s *GroupService) ListGroups(ctx context.Context, req *connect.Request[pb.ListGroupsRequest]) (*connect.Response[pb.ListGroupsResponse], error) {
-	userID := middleware.GetUserID(ctx)
+	userID := authctx.UserID(ctx)
 	if userID == "" {
 		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
 	}
 
-	groups, err := s.store.ListGroupsByUser(ctx, userID)
+	mask, err := parseFieldMask(req.Msg.FieldMask, &pb.ListGroupsResponse{})
 	if err != nil {
-		slog.Error("ListGroups failed", "error", err)
-		return nil, connect.NewError(connect.CodeInternal, err)
+		return nil, err
+	}
+
+	page, err := pageRequest(req.Msg.PageSize, req.Msg.PageToken)
+	if err != nil {
+		return nil, err
 	}
 
+	groups, err := s.store.ListGroupsByUser(ctx, userID, page)
+	if err != nil {
+		logger.Error("ListGroups failed", "error", err)
+		return nil, storeError(err)
+	}
+	groups, nextPageToken := pageResults(groups, page)
+
 	protoGroups := make([]*pb.Group, len(groups))
 	for i, group := range groups {
-		protoGroups[i] = &pb.Group{
-			Id:        group.ID,
-			Name:      group.Name,
-			Members:   modelToPbMembers(group.Members),
-			CreatedAt: group.CreatedAt,
-		}
+		protoGroups[i] = modelToPbGroup(group)
 	}
 
-	return connect.NewResponse(&pb.ListGroupsResponse{
-		Groups: protoGroups,
-	}), nil
+	resp := &pb.ListGroupsResponse{
+		Groups:        protoGroups,
+		NextPageToken: nextPageToken,
+	}
+	mask.apply(resp)
+	return connect.NewResponse(resp), nil
 }
 
 // UpdateGroup updates an existing group.
 func (s *GroupService) UpdateGroup(ctx context.Context, req *connect.Request[pb.UpdateGroupRequest]) (*connect.Response[pb.UpdateGroupResponse], error) {
-	userID := middleware.GetUserID(ctx)
+	userID := authctx.UserID(ctx)
 	if userID == "" {
 		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
 	}
@@ -194,89 +248,169 @@ func (s *GroupService) UpdateGroup(ctx context.Context, req *connect.Request[pb.
 	}
 
 	group := &models.Group{
-		ID:      req.Msg.GroupId,
-		Name:    req.Msg.Name,
-		Members: members,
+		ID:       req.Msg.GroupId,
+		Name:     req.Msg.Name,
+		Members:  members,
+		Settings: pbToModelGroupSettings(req.Msg.Settings),
+	}
+	existing, err := s.store.GetGroup(ctx, group.ID)
+	if err != nil {
+		logger.Error("UpdateGroup: failed to get existing group", "group_id", group.ID, "error", err)
+		return nil, storeError(err)
+	}
+	if req.Msg.Settings == nil {
+		group.Settings = existing.Settings
+		// Caps follow their members out of the group.
+		maps.DeleteFunc(group.Settings.SpendingCaps, func(member string, _ float64) bool {
+			return !isMemberByName(member, members)
+		})
+	}
+	if err := validateSpendingCaps(group.Settings.SpendingCaps, members); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+	// Bills' exchange rates are locked against the group's currency.
+	if existing.Settings.Currency != "" && group.Settings.Currency != existing.Settings.Currency {
+		return nil, connect.NewError(connect.CodeFailedPrecondition,
+			fmt.Errorf("the group's currency is %s and can't change once set", existing.Settings.Currency))
 	}
 
 	if err := s.store.UpdateGroup(ctx, group); err != nil {
-		slog.Error("UpdateGroup failed", "error", err)
-		return nil, connect.NewError(connect.CodeNotFound, err)
+		logger.Error("UpdateGroup failed", "error", err)
+		return nil, storeError(err)
 	}
 
 	updatedGroup, err := s.store.GetGroup(ctx, group.ID)
 	if err != nil {
-		slog.Error("Failed to fetch updated group", "error", err)
-		return nil, connect.NewError(connect.CodeInternal, err)
+		logger.Error("Failed to fetch updated group", "error", err)
+		return nil, storeError(err)
 	}
 
 	return connect.NewResponse(&pb.UpdateGroupResponse{
-		Group: &pb.Group{
-			Id:        updatedGroup.ID,
-			Name:      updatedGroup.Name,
-			Members:   modelToPbMembers(updatedGroup.Members),
-			CreatedAt: updatedGroup.CreatedAt,
-		},
+		Group: modelToPbGroup(updatedGroup),
 	}), nil
 }
 
 // DeleteGroup removes a group by ID.
 func (s *GroupService) DeleteGroup(ctx context.Context, req *connect.Request[pb.DeleteGroupRequest]) (*connect.Response[pb.DeleteGroupResponse], error) {
 	if err := s.store.DeleteGroup(ctx, req.Msg.GroupId); err != nil {
-		slog.Error("DeleteGroup failed", "error", err)
-		return nil, connect.NewError(connect.CodeNotFound, err)
+		logger.Error("DeleteGroup failed", "error", err)
+		return nil, storeError(err)
 	}
 
 	return connect.NewResponse(&pb.DeleteGroupResponse{}), nil
 }
 
-// computeGroupBalances calculates member balances and debt edges for a single group.
-func (s *GroupService) computeGroupBalances(ctx context.Context, groupID string) ([]calculator.MemberBalance, []calculator.DebtEdge, error) {
-	billSummaries, err := s.store.ListBillsByGroup(ctx, groupID)
+// billForBalance converts a stored bill into the calculator's balance input.
+func billForBalance(bill *models.Bill) splitmath.BillForBalance {
+	return splitmath.BillForBalance{
+		Total:        bill.Total,
+		Subtotal:     bill.Subtotal,
+		PayerID:      bill.PayerID,
+		Items:        toCalcItems(bill.Items),
+		Participants: participantDisplayNames(bill.Participants),
+		Options:      calcOptions(bill),
+		Rate:         bill.ExchangeRate,
+	}
+}
+
+// skippedBill describes why a bill can't count toward balances in a group
+// kept in currency, or returns nil if it can.
+func skippedBill(bill *models.Bill, currency string) *pb.SkippedBill {
+	skipped := &pb.SkippedBill{
+		BillId:    bill.ID,
+		Title:     bill.Title,
+		CreatorId: bill.CreatorID,
+	}
+	switch {
+	case bill.NeedsAssignment:
+		skipped.Reason = pb.SkipReason_SKIP_REASON_NEEDS_ASSIGNMENT
+		skipped.Message = "Assign the remaining items so this bill counts toward balances"
+	case bill.PayerID == "":
+		skipped.Reason = pb.SkipReason_SKIP_REASON_NO_PAYER
+		skipped.Message = "Set who paid so this bill counts toward balances"
+	case bill.ExchangeRate == 0 && currency != "" && bill.Currency != "" && bill.Currency != currency:
+		skipped.Reason = pb.SkipReason_SKIP_REASON_NO_EXCHANGE_RATE
+		skipped.Message = fmt.Sprintf("Lock an exchange rate from %s to %s so this bill counts toward balances", bill.Currency, currency)
+	default:
+		return nil
+	}
+	return skipped
+}
+
+// computeGroupBalances calculates member balances and debt edges for a single group,
+// simplifying debts with the given mode. Bills that can't be balanced yet are
+// returned as skipped.
+func computeGroupBalances(ctx context.Context, store storage.Store, groupID string, mode splitmath.SimplifyMode) ([]splitmath.MemberBalance, []splitmath.DebtEdge, []*pb.SkippedBill, error) {
+	return computeGroupBalancesWith(ctx, store, groupID, mode, nil, 0)
+}
+
+// computeGroupBalancesWith is computeGroupBalances as if override had been
+// saved: the stored bill with its ID is ignored, and override is counted if it
+// belongs to the group. A nil override uses the stored bills as they are.
+// With a non-zero asOf, only bills and settlements created before that Unix
+// time count, so balances can be looked up as they stood then.
+func computeGroupBalancesWith(ctx context.Context, store storage.Store, groupID string, mode splitmath.SimplifyMode, override *models.Bill, asOf int64) ([]splitmath.MemberBalance, []splitmath.DebtEdge, []*pb.SkippedBill, error) {
+	// Listed bills come with their items and participants, loaded in a
+	// handful of queries however many bills the group has.
+	listed, err := store.ListBillsByGroup(ctx, groupID, storage.BillFilter{Until: asOf})
 	if err != nil {
-		return nil, nil, fmt.Errorf("could not list bills: %w", err)
+		return nil, nil, nil, fmt.Errorf("could not list bills: %w", err)
 	}
 
-	var bills []calculator.BillForBalance
-	for _, summary := range billSummaries {
-		bill, err := s.store.GetBill(ctx, summary.ID)
-		if err != nil {
-			return nil, nil, fmt.Errorf("could not get bill %s: %w", summary.ID, err)
+	var groupBills []*models.Bill
+	for _, bill := range listed {
+		if override != nil && bill.ID == override.ID {
+			continue
 		}
+		groupBills = append(groupBills, bill)
+	}
+	if override != nil && override.GroupID == groupID {
+		groupBills = append(groupBills, override)
+	}
 
-		calcItems := make([]calculator.Item, len(bill.Items))
-		for i, item := range bill.Items {
-			calcItems[i] = calculator.Item{
-				Description:  item.Description,
-				Amount:       item.Amount,
-				Participants: item.Participants,
-			}
+	group, err := store.GetGroup(ctx, groupID)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("could not get group: %w", err)
+	}
+
+	var bills []splitmath.BillForBalance
+	var skipped []*pb.SkippedBill
+	for _, bill := range groupBills {
+		if skip := skippedBill(bill, group.Settings.Currency); skip != nil {
+			skipped = append(skipped, skip)
+			continue
 		}
 
-		bills = append(bills, calculator.BillForBalance{
-			Total:        bill.Total,
-			Subtotal:     bill.Subtotal,
-			PayerID:      bill.PayerID,
-			Items:        calcItems,
-			Participants: participantDisplayNames(bill.Participants),
-		})
+		bills = append(bills, billForBalance(bill))
 	}
 
-	settlementsList, err := s.store.ListSettlementsByGroup(ctx, groupID)
+	settlementsList, err := store.ListSettlementsByGroup(ctx, groupID, storage.SettlementFilter{Until: asOf})
 	if err != nil {
-		return nil, nil, fmt.Errorf("could not list settlements: %w", err)
+		return nil, nil, nil, fmt.Errorf("could not list settlements: %w", err)
 	}
 
-	calcSettlements := make([]calculator.SettlementForBalance, len(settlementsList))
+	calcSettlements := make([]splitmath.SettlementForBalance, len(settlementsList))
 	for i, settlement := range settlementsList {
-		calcSettlements[i] = calculator.SettlementForBalance{
+		calcSettlements[i] = splitmath.SettlementForBalance{
 			FromUserID: settlement.FromUserID,
 			ToUserID:   settlement.ToUserID,
 			Amount:     settlement.Amount,
 		}
 	}
 
-	return calculator.CalculateGroupBalances(bills, calcSettlements)
+	memberBalances, debtEdges, err := splitmath.CalculateGroupBalancesWithMode(bills, calcSettlements, mode)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return memberBalances, debtEdges, skipped, nil
+}
+
+// simplifyModeFromProto maps the requested debt simplification to the calculator's mode.
+func simplifyModeFromProto(mode pb.SimplifyMode) splitmath.SimplifyMode {
+	if mode == pb.SimplifyMode_SIMPLIFY_MODE_MIN_TRANSFERS {
+		return splitmath.SimplifyMinTransfers
+	}
+	return splitmath.SimplifyGreedy
 }
 
 // GetGroupBalances calculates balances across all bills in a group.
@@ -285,19 +419,38 @@ func (s *GroupService) GetGroupBalances(ctx context.Context, req *connect.Reques
 	if groupID == "" {
 		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("group_id required"))
 	}
+	if req.Msg.GetAsOf() < 0 {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("as_of must not be negative"))
+	}
 
-	_, err := s.store.GetGroup(ctx, groupID)
+	mask, err := parseFieldMask(req.Msg.FieldMask, &pb.GetGroupBalancesResponse{})
 	if err != nil {
-		slog.Error("GetGroupBalances failed - group not found", "group_id", groupID, "error", err)
+		return nil, err
+	}
+
+	_, err = s.store.GetGroup(ctx, groupID)
+	if err != nil {
+		logger.Error("GetGroupBalances failed - group not found", "group_id", groupID, "error", err)
 		return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("group not found"))
 	}
 
-	memberBalances, debtEdges, err := s.computeGroupBalances(ctx, groupID)
+	memberBalances, debtEdges, skipped, err := computeGroupBalancesWith(ctx, s.store, groupID, simplifyModeFromProto(req.Msg.GetSimplifyMode()), nil, req.Msg.GetAsOf())
 	if err != nil {
-		slog.Error("GetGroupBalances failed", "group_id", groupID, "error", err)
+		logger.Error("GetGroupBalances failed", "group_id", groupID, "error", err)
 		return nil, connect.NewError(connect.CodeInternal, err)
 	}
 
+	resp := &pb.GetGroupBalancesResponse{
+		MemberBalances: memberBalancesToProto(memberBalances),
+		DebtMatrix:     debtEdgesToProto(debtEdges),
+		SkippedBills:   skipped,
+	}
+	mask.apply(resp)
+	return connect.NewResponse(resp), nil
+}
+
+// memberBalancesToProto converts calculator member balances to proto MemberBalances.
+func memberBalancesToProto(memberBalances []splitmath.MemberBalance) []*pb.MemberBalance {
 	pbBalances := make([]*pb.MemberBalance, len(memberBalances))
 	for i, bal := range memberBalances {
 		pbBalances[i] = &pb.MemberBalance{
@@ -307,7 +460,11 @@ func (s *GroupService) GetGroupBalances(ctx context.Context, req *connect.Reques
 			TotalOwed:   bal.TotalOwed,
 		}
 	}
+	return pbBalances
+}
 
+// debtEdgesToProto converts calculator debt edges to proto DebtEdges.
+func debtEdgesToProto(debtEdges []splitmath.DebtEdge) []*pb.DebtEdge {
 	pbDebts := make([]*pb.DebtEdge, len(debtEdges))
 	for i, debt := range debtEdges {
 		pbDebts[i] = &pb.DebtEdge{
@@ -316,26 +473,26 @@ func (s *GroupService) GetGroupBalances(ctx context.Context, req *connect.Reques
 			Amount:     debt.Amount,
 		}
 	}
-
-	return connect.NewResponse(&pb.GetGroupBalancesResponse{
-		MemberBalances: pbBalances,
-		DebtMatrix:     pbDebts,
-	}), nil
+	return pbDebts
 }
 
 // GetMyBalances aggregates balances across all groups for the authenticated user.
 func (s *GroupService) GetMyBalances(ctx context.Context, req *connect.Request[pb.GetMyBalancesRequest]) (*connect.Response[pb.GetMyBalancesResponse], error) {
-	userID := middleware.GetUserID(ctx)
+	userID := authctx.UserID(ctx)
 	if userID == "" {
 		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
 	}
+	asOf := req.Msg.GetAsOf()
+	if asOf < 0 {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("as_of must not be negative"))
+	}
 
 	myName := s.resolveDisplayName(ctx, userID)
 
-	groups, err := s.store.ListGroupsByUser(ctx, userID)
+	groups, err := s.store.ListGroupsByUser(ctx, userID, storage.Page{})
 	if err != nil {
-		slog.Error("GetMyBalances failed - could not list groups", "error", err)
-		return nil, connect.NewError(connect.CodeInternal, err)
+		logger.Error("GetMyBalances failed - could not list groups", "error", err)
+		return nil, storeError(err)
 	}
 
 	// Aggregate per-person balances across all groups.
@@ -357,9 +514,9 @@ func (s *GroupService) GetMyBalances(ctx context.Context, req *connect.Request[p
 			}
 		}
 
-		_, debtEdges, err := s.computeGroupBalances(ctx, group.ID)
+		_, debtEdges, _, err := computeGroupBalancesWith(ctx, s.store, group.ID, splitmath.SimplifyGreedy, nil, asOf)
 		if err != nil {
-			slog.Error("GetMyBalances failed - balance calc error", "group_id", group.ID, "error", err)
+			logger.Error("GetMyBalances failed - balance calc error", "group_id", group.ID, "error", err)
 			continue
 		}
 
@@ -397,13 +554,20 @@ func (s *GroupService) GetMyBalances(ctx context.Context, req *connect.Request[p
 	// Fold in direct (no-group) bills — compute debt edges just like group bills.
 	directSummaries, err := s.store.ListDirectBillsByUser(ctx, userID)
 	if err != nil {
-		slog.Error("GetMyBalances failed - could not list direct bills", "error", err)
+		logger.Error("GetMyBalances failed - could not list direct bills", "error", err)
 	} else {
+		ids := make([]string, len(directSummaries))
+		for i, summary := range directSummaries {
+			ids[i] = summary.ID
+		}
+		loaded, err := s.store.GetBillsByIDs(ctx, ids)
+		if err != nil {
+			logger.Error("GetMyBalances failed - could not load direct bills", "error", err)
+		}
 		nameToUserID := make(map[string]string)
-		var directBills []calculator.BillForBalance
-		for _, summary := range directSummaries {
-			bill, err := s.store.GetBill(ctx, summary.ID)
-			if err != nil {
+		var directBills []splitmath.BillForBalance
+		for _, bill := range loaded {
+			if bill.NeedsAssignment || (asOf != 0 && bill.CreatedAt >= asOf) {
 				continue
 			}
 			for _, p := range bill.Participants {
@@ -411,24 +575,10 @@ func (s *GroupService) GetMyBalances(ctx context.Context, req *connect.Request[p
 					nameToUserID[p.DisplayName] = p.UserID
 				}
 			}
-			items := make([]calculator.Item, len(bill.Items))
-			for i, item := range bill.Items {
-				items[i] = calculator.Item{
-					Description:  item.Description,
-					Amount:       item.Amount,
-					Participants: item.Participants,
-				}
-			}
-			directBills = append(directBills, calculator.BillForBalance{
-				Total:        bill.Total,
-				Subtotal:     bill.Subtotal,
-				PayerID:      bill.PayerID,
-				Items:        items,
-				Participants: participantDisplayNames(bill.Participants),
-			})
+			directBills = append(directBills, billForBalance(bill))
 		}
 		if len(directBills) > 0 {
-			_, directEdges, err := calculator.CalculateGroupBalances(directBills, nil)
+			_, directEdges, err := splitmath.CalculateGroupBalances(directBills, nil)
 			if err == nil {
 				for _, edge := range directEdges {
 					var otherName string
@@ -461,9 +611,12 @@ func (s *GroupService) GetMyBalances(ctx context.Context, req *connect.Request[p
 	// without belonging to any specific group balance.
 	directSettlements, err := s.store.ListDirectSettlementsByUser(ctx, myName)
 	if err != nil {
-		slog.Error("GetMyBalances failed - could not list direct settlements", "error", err)
+		logger.Error("GetMyBalances failed - could not list direct settlements", "error", err)
 	}
 	for _, ds := range directSettlements {
+		if asOf != 0 && ds.CreatedAt >= asOf {
+			continue
+		}
 		var otherName string
 		var amount float64
 		if ds.FromUserID == myName {
@@ -518,7 +671,7 @@ func (s *GroupService) GetMyBalances(ctx context.Context, req *connect.Request[p
 
 // RecordSettlement records a payment between group members.
 func (s *GroupService) RecordSettlement(ctx context.Context, req *connect.Request[pb.RecordSettlementRequest]) (*connect.Response[pb.RecordSettlementResponse], error) {
-	userID := middleware.GetUserID(ctx)
+	userID := authctx.UserID(ctx)
 	if userID == "" {
 		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
 	}
@@ -547,7 +700,7 @@ func (s *GroupService) RecordSettlement(ctx context.Context, req *connect.Reques
 
 	group, err := s.store.GetGroup(ctx, groupID)
 	if err != nil {
-		slog.Error("RecordSettlement failed - group not found", "group_id", groupID, "error", err)
+		logger.Error("RecordSettlement failed - group not found", "group_id", groupID, "error", err)
 		return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("group not found"))
 	}
 
@@ -564,6 +717,17 @@ func (s *GroupService) RecordSettlement(ctx context.Context, req *connect.Reques
 		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("to_user is not a member of this group"))
 	}
 
+	// A payment toward a plan must be between the plan's two members, in its direction.
+	if planID := req.Msg.GetPlanId(); planID != "" {
+		plan, err := s.store.GetSettlementPlan(ctx, planID)
+		if err != nil {
+			return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("settlement plan not found"))
+		}
+		if plan.GroupID != groupID || plan.FromUserID != fromUserID || plan.ToUserID != toUserID {
+			return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("settlement does not match plan %s", planID))
+		}
+	}
+
 	settlement := &models.Settlement{
 		GroupID:    &groupID,
 		FromUserID: fromUserID,
@@ -571,32 +735,24 @@ func (s *GroupService) RecordSettlement(ctx context.Context, req *connect.Reques
 		Amount:     amount,
 		CreatedBy:  creatorDisplayName,
 		Note:       note,
+		PlanID:     req.Msg.GetPlanId(),
 	}
 
 	if err := s.store.CreateSettlement(ctx, settlement); err != nil {
-		slog.Error("RecordSettlement failed", "error", err)
-		return nil, connect.NewError(connect.CodeInternal, err)
+		logger.Error("RecordSettlement failed", "error", err)
+		return nil, storeError(err)
 	}
+	checkBalanceThresholds(ctx, s.store, s.webhooks, groupID)
+	notifySettlementChange(ctx, s.store, s.webhooks, webhook.EventSettlementRecorded, settlement, group.Members, userID)
 
 	return connect.NewResponse(&pb.RecordSettlementResponse{
-		Settlement: &pb.Settlement{
-			Id:         settlement.ID,
-			GroupId:    settlement.GroupID,
-			FromUserId: settlement.FromUserID,
-			ToUserId:   settlement.ToUserID,
-			Amount:     settlement.Amount,
-			CreatedAt:  settlement.CreatedAt,
-			CreatedBy:  settlement.CreatedBy,
-			Note:       settlement.Note,
-			FromName:   fromUserID,
-			ToName:     toUserID,
-		},
+		Settlement: settlementToProto(settlement),
 	}), nil
 }
 
 // ListSettlements lists all settlements for a group.
 func (s *GroupService) ListSettlements(ctx context.Context, req *connect.Request[pb.ListSettlementsRequest]) (*connect.Response[pb.ListSettlementsResponse], error) {
-	userID := middleware.GetUserID(ctx)
+	userID := authctx.UserID(ctx)
 	if userID == "" {
 		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
 	}
@@ -608,7 +764,7 @@ func (s *GroupService) ListSettlements(ctx context.Context, req *connect.Request
 
 	group, err := s.store.GetGroup(ctx, groupID)
 	if err != nil {
-		slog.Error("ListSettlements failed - group not found", "group_id", groupID, "error", err)
+		logger.Error("ListSettlements failed - group not found", "group_id", groupID, "error", err)
 		return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("group not found"))
 	}
 
@@ -617,11 +773,17 @@ func (s *GroupService) ListSettlements(ctx context.Context, req *connect.Request
 		return nil, connect.NewError(connect.CodePermissionDenied, fmt.Errorf("not a member of this group"))
 	}
 
-	settlements, err := s.store.ListSettlementsByGroup(ctx, groupID)
+	page, err := pageRequest(req.Msg.PageSize, req.Msg.PageToken)
 	if err != nil {
-		slog.Error("ListSettlements failed", "error", err)
-		return nil, connect.NewError(connect.CodeInternal, err)
+		return nil, err
+	}
+
+	settlements, err := s.store.ListSettlementsByGroup(ctx, groupID, storage.SettlementFilter{Page: page})
+	if err != nil {
+		logger.Error("ListSettlements failed", "error", err)
+		return nil, storeError(err)
 	}
+	settlements, nextPageToken := pageResults(settlements, page)
 
 	pbSettlements := make([]*pb.Settlement, len(settlements))
 	for i, settlement := range settlements {
@@ -629,13 +791,14 @@ func (s *GroupService) ListSettlements(ctx context.Context, req *connect.Request
 	}
 
 	return connect.NewResponse(&pb.ListSettlementsResponse{
-		Settlements: pbSettlements,
+		Settlements:   pbSettlements,
+		NextPageToken: nextPageToken,
 	}), nil
 }
 
 // DeleteSettlement removes a settlement.
 func (s *GroupService) DeleteSettlement(ctx context.Context, req *connect.Request[pb.DeleteSettlementRequest]) (*connect.Response[pb.DeleteSettlementResponse], error) {
-	userID := middleware.GetUserID(ctx)
+	userID := authctx.UserID(ctx)
 	if userID == "" {
 		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
 	}
@@ -647,22 +810,24 @@ func (s *GroupService) DeleteSettlement(ctx context.Context, req *connect.Reques
 
 	settlement, err := s.store.GetSettlement(ctx, settlementID)
 	if err != nil {
-		slog.Error("DeleteSettlement failed - settlement not found", "settlement_id", settlementID, "error", err)
+		logger.Error("DeleteSettlement failed - settlement not found", "settlement_id", settlementID, "error", err)
 		return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("settlement not found"))
 	}
 
 	deletorDisplayName := s.resolveDisplayName(ctx, userID)
 
+	var members []models.GroupMember
 	if settlement.GroupID != nil {
 		// Group settlement: verify caller is a member of that group.
 		group, err := s.store.GetGroup(ctx, *settlement.GroupID)
 		if err != nil {
-			slog.Error("DeleteSettlement failed - group not found", "group_id", *settlement.GroupID, "error", err)
+			logger.Error("DeleteSettlement failed - group not found", "group_id", *settlement.GroupID, "error", err)
 			return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("group not found"))
 		}
 		if !isMemberByName(deletorDisplayName, group.Members) {
 			return nil, connect.NewError(connect.CodePermissionDenied, fmt.Errorf("not a member of this group"))
 		}
+		members = group.Members
 	} else {
 		// Direct settlement: only the creator can delete it.
 		if settlement.CreatedBy != deletorDisplayName {
@@ -671,9 +836,13 @@ func (s *GroupService) DeleteSettlement(ctx context.Context, req *connect.Reques
 	}
 
 	if err := s.store.DeleteSettlement(ctx, settlementID); err != nil {
-		slog.Error("DeleteSettlement failed", "error", err)
-		return nil, connect.NewError(connect.CodeInternal, err)
+		logger.Error("DeleteSettlement failed", "error", err)
+		return nil, storeError(err)
+	}
+	if settlement.GroupID != nil {
+		checkBalanceThresholds(ctx, s.store, s.webhooks, *settlement.GroupID)
 	}
+	notifySettlementChange(ctx, s.store, s.webhooks, webhook.EventSettlementDeleted, settlement, members, userID)
 
 	return connect.NewResponse(&pb.DeleteSettlementResponse{}), nil
 }
@@ -681,7 +850,7 @@ func (s *GroupService) DeleteSettlement(ctx context.Context, req *connect.Reques
 // SettleUpWithPerson creates settlement records across all groups where the auth user
 // and the target user have outstanding debts, zeroing their balance in each group.
 func (s *GroupService) SettleUpWithPerson(ctx context.Context, req *connect.Request[pb.SettleUpWithPersonRequest]) (*connect.Response[pb.SettleUpWithPersonResponse], error) {
-	userID := middleware.GetUserID(ctx)
+	userID := authctx.UserID(ctx)
 	if userID == "" {
 		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
 	}
@@ -699,9 +868,9 @@ func (s *GroupService) SettleUpWithPerson(ctx context.Context, req *connect.Requ
 		return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("user not found"))
 	}
 
-	myGroups, err := s.store.ListGroupsByUser(ctx, userID)
+	myGroups, err := s.store.ListGroupsByUser(ctx, userID, storage.Page{})
 	if err != nil {
-		return nil, connect.NewError(connect.CodeInternal, err)
+		return nil, storeError(err)
 	}
 
 	myName := s.resolveDisplayName(ctx, userID)
@@ -726,9 +895,9 @@ func (s *GroupService) SettleUpWithPerson(ctx context.Context, req *connect.Requ
 			myNameInGroup = myName
 		}
 
-		_, debtEdges, err := s.computeGroupBalances(ctx, group.ID)
+		_, debtEdges, _, err := computeGroupBalances(ctx, s.store, group.ID, splitmath.SimplifyGreedy)
 		if err != nil {
-			slog.Error("SettleUpWithPerson balance calc error", "group_id", group.ID, "error", err)
+			logger.Error("SettleUpWithPerson balance calc error", "group_id", group.ID, "error", err)
 			continue
 		}
 
@@ -753,10 +922,12 @@ func (s *GroupService) SettleUpWithPerson(ctx context.Context, req *connect.Requ
 				CreatedBy:  myName,
 			}
 			if err := s.store.CreateSettlement(ctx, settlement); err != nil {
-				slog.Error("SettleUpWithPerson failed to create settlement", "group_id", group.ID, "error", err)
-				return nil, connect.NewError(connect.CodeInternal, err)
+				logger.Error("SettleUpWithPerson failed to create settlement", "group_id", group.ID, "error", err)
+				return nil, storeError(err)
 			}
 			created = append(created, settlementToProto(settlement))
+			checkBalanceThresholds(ctx, s.store, s.webhooks, group.ID)
+			notifySettlementChange(ctx, s.store, s.webhooks, webhook.EventSettlementRecorded, settlement, group.Members, userID)
 			break
 		}
 	}
@@ -772,7 +943,7 @@ func (s *GroupService) SettleUpWithPerson(ctx context.Context, req *connect.Requ
 
 // settlementToProto converts a models.Settlement to its proto representation.
 func settlementToProto(s *models.Settlement) *pb.Settlement {
-	return &pb.Settlement{
+	pbs := &pb.Settlement{
 		Id:         s.ID,
 		GroupId:    s.GroupID,
 		FromUserId: s.FromUserID,
@@ -784,4 +955,9 @@ func settlementToProto(s *models.Settlement) *pb.Settlement {
 		FromName:   s.FromUserID,
 		ToName:     s.ToUserID,
 	}
+	if s.PlanID != "" {
+		planID := s.PlanID
+		pbs.PlanId = &planID
+	}
+	return pbs
 }
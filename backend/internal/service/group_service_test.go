@@ -17,7 +17,7 @@ import (
 
 // setupGroupTestServer creates a test server with both SplitService and GroupService.
 // It also creates the Alice user in the DB so resolveDisplayName returns "Alice".
-func setupGroupTestServer(t *testing.T) (protoconnect.GroupServiceClient, protoconnect.SplitServiceClient, func()) {
+func setupGroupTestServer(t *testing.T, opts ...Option) (protoconnect.GroupServiceClient, protoconnect.SplitServiceClient, func()) {
 	t.Helper()
 
 	tmpFile, err := os.CreateTemp("", "test-*.db")
@@ -47,8 +47,8 @@ func setupGroupTestServer(t *testing.T) (protoconnect.GroupServiceClient, protoc
 	}
 
 	authInterceptor := connect.WithInterceptors(testAuthInterceptor())
-	splitSvc := NewSplitService(store)
-	groupSvc := NewGroupService(store)
+	splitSvc := NewSplitService(store, opts...)
+	groupSvc := NewGroupService(store, opts...)
 
 	splitPath, splitHandler := protoconnect.NewSplitServiceHandler(splitSvc, authInterceptor)
 	groupPath, groupHandler := protoconnect.NewGroupServiceHandler(groupSvc, authInterceptor)
@@ -278,6 +278,190 @@ func TestUpdateGroup_NotFound(t *testing.T) {
 	}
 }
 
+func TestGroupSettings_DisableAutoTitle(t *testing.T) {
+	client, splitClient, cleanup := setupGroupTestServer(t)
+	defer cleanup()
+
+	createResp, err := client.CreateGroup(context.Background(), connect.NewRequest(&pb.CreateGroupRequest{
+		Name:     "Strict",
+		Members:  gm("Bob"),
+		Settings: &pb.GroupSettings{DisableAutoTitle: true},
+	}))
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	groupID := createResp.Msg.Group.Id
+	if !createResp.Msg.Group.Settings.DisableAutoTitle {
+		t.Error("expected disable_auto_title to be returned")
+	}
+
+	_, err = splitClient.CreateBill(context.Background(), connect.NewRequest(&pb.CreateBillRequest{
+		Total:        10,
+		Subtotal:     10,
+		Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+		GroupId:      &groupID,
+	}))
+	if connect.CodeOf(err) != connect.CodeInvalidArgument {
+		t.Fatalf("expected InvalidArgument for untitled bill, got %v", err)
+	}
+
+	_, err = splitClient.CreateBill(context.Background(), connect.NewRequest(&pb.CreateBillRequest{
+		Title:        "Taxi",
+		Total:        10,
+		Subtotal:     10,
+		Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+		GroupId:      &groupID,
+	}))
+	if err != nil {
+		t.Fatalf("CreateBill with title failed: %v", err)
+	}
+
+	// Updating without settings leaves them unchanged.
+	updateResp, err := client.UpdateGroup(context.Background(), connect.NewRequest(&pb.UpdateGroupRequest{
+		GroupId: groupID,
+		Name:    "Still Strict",
+		Members: createResp.Msg.Group.Members,
+	}))
+	if err != nil {
+		t.Fatalf("UpdateGroup failed: %v", err)
+	}
+	if !updateResp.Msg.Group.Settings.DisableAutoTitle {
+		t.Error("expected disable_auto_title to survive an update without settings")
+	}
+}
+
+func TestGroupSettings_TitleTemplate(t *testing.T) {
+	client, splitClient, cleanup := setupGroupTestServer(t)
+	defer cleanup()
+
+	createResp, err := client.CreateGroup(context.Background(), connect.NewRequest(&pb.CreateGroupRequest{
+		Name:     "Templated",
+		Members:  gm("Bob"),
+		Settings: &pb.GroupSettings{TitleTemplate: "{top_item} paid by {payer}"},
+	}))
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	groupID := createResp.Msg.Group.Id
+
+	billResp, err := splitClient.CreateBill(context.Background(), connect.NewRequest(&pb.CreateBillRequest{
+		Items: []*pb.Item{
+			{Description: "Fries", Amount: 5, ParticipantIds: []string{"Alice"}},
+			{Description: "Steak", Amount: 30, ParticipantIds: []string{"Bob"}},
+		},
+		Total:        35,
+		Subtotal:     35,
+		Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+		PayerId:      strPtr("Alice"),
+		GroupId:      &groupID,
+	}))
+	if err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+
+	getResp, err := splitClient.GetBill(context.Background(), connect.NewRequest(&pb.GetBillRequest{
+		BillId: billResp.Msg.BillId,
+	}))
+	if err != nil {
+		t.Fatalf("GetBill failed: %v", err)
+	}
+	if getResp.Msg.Title != "Steak paid by Alice" {
+		t.Errorf("expected templated title 'Steak paid by Alice', got %q", getResp.Msg.Title)
+	}
+}
+
+func TestGroupSettings_DefaultPayerIsCreator(t *testing.T) {
+	client, splitClient, cleanup := setupGroupTestServer(t)
+	defer cleanup()
+
+	createResp, err := client.CreateGroup(context.Background(), connect.NewRequest(&pb.CreateGroupRequest{
+		Name:     "Creator Pays",
+		Members:  gm("Bob"),
+		Settings: &pb.GroupSettings{DefaultPayerIsCreator: true},
+	}))
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	groupID := createResp.Msg.Group.Id
+	if !createResp.Msg.Group.Settings.DefaultPayerIsCreator {
+		t.Error("expected default_payer_is_creator to be returned")
+	}
+
+	billResp, err := splitClient.CreateBill(context.Background(), connect.NewRequest(&pb.CreateBillRequest{
+		Title:        "Groceries",
+		Total:        20,
+		Subtotal:     20,
+		Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+		GroupId:      &groupID,
+	}))
+	if err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+	if len(billResp.Msg.Warnings) != 0 {
+		t.Errorf("expected no warnings, got %v", billResp.Msg.Warnings)
+	}
+
+	getResp, err := splitClient.GetBill(context.Background(), connect.NewRequest(&pb.GetBillRequest{
+		BillId: billResp.Msg.BillId,
+	}))
+	if err != nil {
+		t.Fatalf("GetBill failed: %v", err)
+	}
+	if getResp.Msg.PayerId != "Alice" {
+		t.Errorf("expected creator Alice as payer, got %q", getResp.Msg.PayerId)
+	}
+
+	// An explicit payer still wins.
+	billResp, err = splitClient.CreateBill(context.Background(), connect.NewRequest(&pb.CreateBillRequest{
+		Title:        "Fuel",
+		Total:        30,
+		Subtotal:     30,
+		Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+		PayerId:      strPtr("Bob"),
+		GroupId:      &groupID,
+	}))
+	if err != nil {
+		t.Fatalf("CreateBill with payer failed: %v", err)
+	}
+	getResp, err = splitClient.GetBill(context.Background(), connect.NewRequest(&pb.GetBillRequest{
+		BillId: billResp.Msg.BillId,
+	}))
+	if err != nil {
+		t.Fatalf("GetBill failed: %v", err)
+	}
+	if getResp.Msg.PayerId != "Bob" {
+		t.Errorf("expected explicit payer Bob, got %q", getResp.Msg.PayerId)
+	}
+}
+
+func TestCreateBill_MissingPayerWarning(t *testing.T) {
+	client, splitClient, cleanup := setupGroupTestServer(t)
+	defer cleanup()
+
+	createResp, err := client.CreateGroup(context.Background(), connect.NewRequest(&pb.CreateGroupRequest{
+		Name:    "No Default Payer",
+		Members: gm("Bob"),
+	}))
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	groupID := createResp.Msg.Group.Id
+
+	billResp, err := splitClient.CreateBill(context.Background(), connect.NewRequest(&pb.CreateBillRequest{
+		Title:        "Groceries",
+		Total:        20,
+		Subtotal:     20,
+		Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+		GroupId:      &groupID,
+	}))
+	if err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+	if len(billResp.Msg.Warnings) != 1 {
+		t.Fatalf("expected 1 warning for a bill without payer, got %v", billResp.Msg.Warnings)
+	}
+}
+
 func TestDeleteGroup(t *testing.T) {
 	client, _, cleanup := setupGroupTestServer(t)
 	defer cleanup()
@@ -573,7 +757,7 @@ func TestGetGroupBalances_BillsWithoutPayer(t *testing.T) {
 	}
 	groupId := groupResp.Msg.Group.Id
 
-	_, err = splitClient.CreateBill(context.Background(), connect.NewRequest(&pb.CreateBillRequest{
+	billResp, err := splitClient.CreateBill(context.Background(), connect.NewRequest(&pb.CreateBillRequest{
 		Title:        "Dinner",
 		Total:        100,
 		Subtotal:     100,
@@ -595,6 +779,24 @@ func TestGetGroupBalances_BillsWithoutPayer(t *testing.T) {
 	if len(balResp.Msg.MemberBalances) != 0 {
 		t.Errorf("expected 0 balances (no payer), got %d", len(balResp.Msg.MemberBalances))
 	}
+
+	// The skipped bill is surfaced so its creator can be asked to set a payer.
+	if len(balResp.Msg.SkippedBills) != 1 {
+		t.Fatalf("expected 1 skipped bill, got %d", len(balResp.Msg.SkippedBills))
+	}
+	skipped := balResp.Msg.SkippedBills[0]
+	if skipped.BillId != billResp.Msg.BillId || skipped.Title != "Dinner" {
+		t.Errorf("skipped bill: expected %s 'Dinner', got %s %q", billResp.Msg.BillId, skipped.BillId, skipped.Title)
+	}
+	if skipped.Reason != pb.SkipReason_SKIP_REASON_NO_PAYER {
+		t.Errorf("skipped reason: expected NO_PAYER, got %v", skipped.Reason)
+	}
+	if skipped.CreatorId != testUserID {
+		t.Errorf("skipped creator: expected %s, got %s", testUserID, skipped.CreatorId)
+	}
+	if skipped.Message == "" {
+		t.Error("expected a nudge message for the skipped bill")
+	}
 }
 
 // Settlement Tests
@@ -869,6 +1071,72 @@ func TestGetGroupBalances_WithSettlements(t *testing.T) {
 	}
 }
 
+func TestGetGroupBalances_MinTransfers(t *testing.T) {
+	groupClient, splitClient, cleanup := setupGroupTestServer(t)
+	defer cleanup()
+
+	groupResp, err := groupClient.CreateGroup(context.Background(), connect.NewRequest(&pb.CreateGroupRequest{
+		Name:    "Min Transfers Group",
+		Members: gm("Alice", "Bob", "Charlie", "Dave"),
+	}))
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	groupId := groupResp.Msg.Group.Id
+
+	// Alice paid $10 split with Charlie, Bob paid $6 split with Dave:
+	// two independent debts, so two transfers are enough.
+	alicePayer, bobPayer := "Alice", "Bob"
+	_, err = splitClient.CreateBill(context.Background(), connect.NewRequest(&pb.CreateBillRequest{
+		Title:        "Lunch",
+		Total:        10,
+		Subtotal:     10,
+		Participants: []*pb.BillParticipant{aliceBP(), guestBP("Charlie")},
+		GroupId:      &groupId,
+		PayerId:      &alicePayer,
+	}))
+	if err != nil {
+		t.Fatalf("CreateBill 1 failed: %v", err)
+	}
+	_, err = splitClient.CreateBill(context.Background(), connect.NewRequest(&pb.CreateBillRequest{
+		Title:        "Coffee",
+		Total:        6,
+		Subtotal:     6,
+		Participants: []*pb.BillParticipant{guestBP("Bob"), guestBP("Dave")},
+		GroupId:      &groupId,
+		PayerId:      &bobPayer,
+	}))
+	if err != nil {
+		t.Fatalf("CreateBill 2 failed: %v", err)
+	}
+
+	balResp, err := groupClient.GetGroupBalances(context.Background(), connect.NewRequest(&pb.GetGroupBalancesRequest{
+		GroupId:      groupId,
+		SimplifyMode: pb.SimplifyMode_SIMPLIFY_MODE_MIN_TRANSFERS,
+	}))
+	if err != nil {
+		t.Fatalf("GetGroupBalances failed: %v", err)
+	}
+
+	if len(balResp.Msg.DebtMatrix) != 2 {
+		t.Fatalf("expected 2 debt edges, got %d", len(balResp.Msg.DebtMatrix))
+	}
+	for _, debt := range balResp.Msg.DebtMatrix {
+		switch debt.FromUserId {
+		case "Charlie":
+			if debt.ToUserId != "Alice" || debt.Amount != 5 {
+				t.Errorf("debt: expected Charlie→Alice $5, got %s→%s $%f", debt.FromUserId, debt.ToUserId, debt.Amount)
+			}
+		case "Dave":
+			if debt.ToUserId != "Bob" || debt.Amount != 3 {
+				t.Errorf("debt: expected Dave→Bob $3, got %s→%s $%f", debt.FromUserId, debt.ToUserId, debt.Amount)
+			}
+		default:
+			t.Errorf("unexpected debtor %s", debt.FromUserId)
+		}
+	}
+}
+
 // GetMyBalances Tests
 
 func TestGetMyBalances_NoGroups(t *testing.T) {
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/mmynk/splitwiser/internal/authctx"
+	"github.com/mmynk/splitwiser/internal/models"
+	"github.com/mmynk/splitwiser/internal/storage"
+	"github.com/mmynk/splitwiser/internal/webhook"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+	"github.com/mmynk/splitwiser/pkg/splitmath"
+)
+
+// groupWebhookToProto converts a model GroupWebhook to a proto GroupWebhook.
+func groupWebhookToProto(hook *models.GroupWebhook) *pb.GroupWebhook {
+	return &pb.GroupWebhook{
+		Id:                   hook.ID,
+		GroupId:              hook.GroupID,
+		Url:                  hook.URL,
+		Threshold:            hook.Threshold,
+		CreatedAt:            hook.CreatedAt,
+		CreatedBy:            hook.CreatedBy,
+		MembersOverThreshold: hook.OverThreshold,
+	}
+}
+
+// validateWebhookURL accepts absolute http and https URLs.
+func validateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url must be an absolute http or https URL")
+	}
+	return nil
+}
+
+// checkBalanceThresholds compares members' debts in a group against the
+// thresholds of its webhooks, firing an event for every member who crossed one
+// since the last check. It runs after each write that can move the group's
+// balances; failures are only logged since the write itself has succeeded.
+func checkBalanceThresholds(ctx context.Context, store storage.Store, webhooks *webhook.Dispatcher, groupID string) {
+	if webhooks == nil || groupID == "" {
+		return
+	}
+	hooks, err := store.ListGroupWebhooks(ctx, groupID)
+	if err != nil {
+		logger.Error("Balance threshold check failed - could not list webhooks", "group_id", groupID, "error", err)
+		return
+	}
+	if len(hooks) == 0 {
+		return
+	}
+
+	group, err := store.GetGroup(ctx, groupID)
+	if err != nil {
+		logger.Error("Balance threshold check failed - group not found", "group_id", groupID, "error", err)
+		return
+	}
+	balances, _, _, err := computeGroupBalances(ctx, store, groupID, splitmath.SimplifyGreedy)
+	if err != nil {
+		logger.Error("Balance threshold check failed - balance calc error", "group_id", groupID, "error", err)
+		return
+	}
+
+	// Debts by member; balances are sorted by name, so over is too.
+	owes := make(map[string]float64)
+	for _, b := range balances {
+		if debt := splitmath.RoundAmount(-b.NetBalance, ""); debt > 0 {
+			owes[b.MemberName] = debt
+		}
+	}
+
+	now := time.Now().Unix()
+	for _, hook := range hooks {
+		var over []string
+		for _, b := range balances {
+			if owes[b.MemberName] > hook.Threshold {
+				over = append(over, b.MemberName)
+			}
+		}
+		if slices.Equal(over, hook.OverThreshold) {
+			continue
+		}
+
+		event := webhook.Event{
+			WebhookID:  hook.ID,
+			GroupID:    group.ID,
+			GroupName:  group.Name,
+			Threshold:  hook.Threshold,
+			OccurredAt: now,
+		}
+		for _, member := range over {
+			if !slices.Contains(hook.OverThreshold, member) {
+				event.Type, event.Member, event.Owes = webhook.EventThresholdExceeded, member, owes[member]
+				webhooks.Send(hook.URL, event)
+			}
+		}
+		for _, member := range hook.OverThreshold {
+			if !slices.Contains(over, member) {
+				event.Type, event.Member, event.Owes = webhook.EventThresholdCleared, member, owes[member]
+				webhooks.Send(hook.URL, event)
+			}
+		}
+
+		if err := store.SetGroupWebhookOverThreshold(ctx, hook.ID, over); err != nil {
+			logger.Error("Balance threshold check failed - could not save state", "webhook_id", hook.ID, "error", err)
+		}
+	}
+}
+
+// CreateGroupWebhook registers a webhook that fires when a member's debt in
+// the group crosses the given threshold.
+func (s *GroupService) CreateGroupWebhook(ctx context.Context, req *connect.Request[pb.CreateGroupWebhookRequest]) (*connect.Response[pb.CreateGroupWebhookResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+	}
+
+	msg := req.Msg
+	if msg.GroupId == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("group_id required"))
+	}
+	if err := validateWebhookURL(msg.Url); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+	if msg.Threshold <= 0 {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("threshold must be positive"))
+	}
+
+	group, err := s.store.GetGroup(ctx, msg.GroupId)
+	if err != nil {
+		logger.Error("CreateGroupWebhook failed - group not found", "group_id", msg.GroupId, "error", err)
+		return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("group not found"))
+	}
+	if !isMemberByName(s.resolveDisplayName(ctx, userID), group.Members) {
+		return nil, connect.NewError(connect.CodePermissionDenied, fmt.Errorf("not a member of this group"))
+	}
+
+	hook := &models.GroupWebhook{
+		GroupID:   msg.GroupId,
+		URL:       msg.Url,
+		Threshold: msg.Threshold,
+		CreatedBy: userID,
+	}
+	if err := s.store.CreateGroupWebhook(ctx, hook); err != nil {
+		logger.Error("CreateGroupWebhook failed", "error", err)
+		return nil, storeError(err)
+	}
+
+	// Members already over the threshold count as crossing it now.
+	checkBalanceThresholds(ctx, s.store, s.webhooks, group.ID)
+
+	return connect.NewResponse(&pb.CreateGroupWebhookResponse{Webhook: groupWebhookToProto(hook)}), nil
+}
+
+// ListGroupWebhooks lists the webhooks registered on a group.
+func (s *GroupService) ListGroupWebhooks(ctx context.Context, req *connect.Request[pb.ListGroupWebhooksRequest]) (*connect.Response[pb.ListGroupWebhooksResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+	}
+
+	groupID := req.Msg.GetGroupId()
+	if groupID == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("group_id required"))
+	}
+	group, err := s.store.GetGroup(ctx, groupID)
+	if err != nil {
+		logger.Error("ListGroupWebhooks failed - group not found", "group_id", groupID, "error", err)
+		return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("group not found"))
+	}
+	if !isMemberByName(s.resolveDisplayName(ctx, userID), group.Members) {
+		return nil, connect.NewError(connect.CodePermissionDenied, fmt.Errorf("not a member of this group"))
+	}
+
+	hooks, err := s.store.ListGroupWebhooks(ctx, groupID)
+	if err != nil {
+		logger.Error("ListGroupWebhooks failed", "group_id", groupID, "error", err)
+		return nil, storeError(err)
+	}
+
+	pbHooks := make([]*pb.GroupWebhook, len(hooks))
+	for i, hook := range hooks {
+		pbHooks[i] = groupWebhookToProto(hook)
+	}
+	return connect.NewResponse(&pb.ListGroupWebhooksResponse{Webhooks: pbHooks}), nil
+}
+
+// DeleteGroupWebhook removes a group webhook.
+func (s *GroupService) DeleteGroupWebhook(ctx context.Context, req *connect.Request[pb.DeleteGroupWebhookRequest]) (*connect.Response[pb.DeleteGroupWebhookResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+	}
+
+	webhookID := req.Msg.GetWebhookId()
+	if webhookID == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("webhook_id required"))
+	}
+	hook, err := s.store.GetGroupWebhook(ctx, webhookID)
+	if err != nil {
+		logger.Error("DeleteGroupWebhook failed - webhook not found", "webhook_id", webhookID, "error", err)
+		return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("webhook not found"))
+	}
+	group, err := s.store.GetGroup(ctx, hook.GroupID)
+	if err != nil {
+		logger.Error("DeleteGroupWebhook failed - group not found", "group_id", hook.GroupID, "error", err)
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("group not found"))
+	}
+	if !isMemberByName(s.resolveDisplayName(ctx, userID), group.Members) {
+		return nil, connect.NewError(connect.CodePermissionDenied, fmt.Errorf("not a member of this group"))
+	}
+
+	if err := s.store.DeleteGroupWebhook(ctx, webhookID); err != nil {
+		logger.Error("DeleteGroupWebhook failed", "error", err)
+		return nil, storeError(err)
+	}
+	return connect.NewResponse(&pb.DeleteGroupWebhookResponse{}), nil
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/mmynk/splitwiser/internal/webhook"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+)
+
+// webhookReceiver starts a server that records every webhook event posted to it.
+func webhookReceiver(t *testing.T) (string, <-chan webhook.Event) {
+	t.Helper()
+	events := make(chan webhook.Event, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event webhook.Event
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Errorf("failed to decode webhook body: %v", err)
+		}
+		events <- event
+	}))
+	t.Cleanup(server.Close)
+	return server.URL, events
+}
+
+func nextEvent(t *testing.T, events <-chan webhook.Event) webhook.Event {
+	t.Helper()
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for webhook event")
+		return webhook.Event{}
+	}
+}
+
+func TestGroupWebhook_ThresholdCrossings(t *testing.T) {
+	groupClient, splitClient, cleanup := setupGroupTestServer(t, WithWebhooks(webhook.NewDispatcher(5*time.Second)))
+	defer cleanup()
+	ctx := context.Background()
+	url, events := webhookReceiver(t)
+
+	groupResp, err := groupClient.CreateGroup(ctx, connect.NewRequest(&pb.CreateGroupRequest{
+		Name:    "Flat",
+		Members: gm("Alice", "Bob"),
+	}))
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	groupID := groupResp.Msg.Group.Id
+
+	hookResp, err := groupClient.CreateGroupWebhook(ctx, connect.NewRequest(&pb.CreateGroupWebhookRequest{
+		GroupId:   groupID,
+		Url:       url,
+		Threshold: 50,
+	}))
+	if err != nil {
+		t.Fatalf("CreateGroupWebhook failed: %v", err)
+	}
+
+	createBill := func(total float64) {
+		t.Helper()
+		payer := "Alice"
+		_, err := splitClient.CreateBill(ctx, connect.NewRequest(&pb.CreateBillRequest{
+			Title:        "Groceries",
+			Total:        total,
+			Subtotal:     total,
+			Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+			GroupId:      &groupID,
+			PayerId:      &payer,
+		}))
+		if err != nil {
+			t.Fatalf("CreateBill failed: %v", err)
+		}
+	}
+
+	// Bob owes 40: under the threshold, nothing fires.
+	createBill(80)
+	// Bob owes 60: crosses it.
+	createBill(40)
+
+	event := nextEvent(t, events)
+	if event.Type != webhook.EventThresholdExceeded || event.Member != "Bob" || event.Owes != 60 {
+		t.Errorf("expected Bob exceeding with 60 owed, got %+v", event)
+	}
+	if event.WebhookID != hookResp.Msg.Webhook.Id || event.GroupID != groupID || event.GroupName != "Flat" || event.Threshold != 50 {
+		t.Errorf("unexpected event metadata: %+v", event)
+	}
+
+	listResp, err := groupClient.ListGroupWebhooks(ctx, connect.NewRequest(&pb.ListGroupWebhooksRequest{GroupId: groupID}))
+	if err != nil {
+		t.Fatalf("ListGroupWebhooks failed: %v", err)
+	}
+	if len(listResp.Msg.Webhooks) != 1 {
+		t.Fatalf("expected 1 webhook, got %d", len(listResp.Msg.Webhooks))
+	}
+	if over := listResp.Msg.Webhooks[0].MembersOverThreshold; len(over) != 1 || over[0] != "Bob" {
+		t.Errorf("members_over_threshold: expected [Bob], got %v", over)
+	}
+
+	// Another bill while Bob is already over doesn't fire again; paying 40 back clears it.
+	createBill(10)
+	_, err = groupClient.RecordSettlement(ctx, connect.NewRequest(&pb.RecordSettlementRequest{
+		GroupId:    groupID,
+		FromUserId: "Bob",
+		ToUserId:   "Alice",
+		Amount:     40,
+	}))
+	if err != nil {
+		t.Fatalf("RecordSettlement failed: %v", err)
+	}
+
+	event = nextEvent(t, events)
+	if event.Type != webhook.EventThresholdCleared || event.Member != "Bob" || event.Owes != 25 {
+		t.Errorf("expected Bob cleared with 25 owed, got %+v", event)
+	}
+
+	_, err = groupClient.DeleteGroupWebhook(ctx, connect.NewRequest(&pb.DeleteGroupWebhookRequest{
+		WebhookId: hookResp.Msg.Webhook.Id,
+	}))
+	if err != nil {
+		t.Fatalf("DeleteGroupWebhook failed: %v", err)
+	}
+	createBill(200)
+	select {
+	case event := <-events:
+		t.Errorf("deleted webhook fired: %+v", event)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestCreateGroupWebhook_Validation(t *testing.T) {
+	groupClient, _, cleanup := setupGroupTestServer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	groupResp, err := groupClient.CreateGroup(ctx, connect.NewRequest(&pb.CreateGroupRequest{
+		Name:    "Flat",
+		Members: gm("Alice", "Bob"),
+	}))
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	groupID := groupResp.Msg.Group.Id
+
+	tests := []struct {
+		name      string
+		url       string
+		threshold float64
+	}{
+		{"non-http scheme", "ftp://example.com/hook", 50},
+		{"relative url", "/hook", 50},
+		{"zero threshold", "https://example.com/hook", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := groupClient.CreateGroupWebhook(ctx, connect.NewRequest(&pb.CreateGroupWebhookRequest{
+				GroupId:   groupID,
+				Url:       tt.url,
+				Threshold: tt.threshold,
+			}))
+			if connect.CodeOf(err) != connect.CodeInvalidArgument {
+				t.Errorf("expected CodeInvalidArgument, got %v", err)
+			}
+		})
+	}
+}
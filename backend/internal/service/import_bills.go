@@ -0,0 +1,201 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/mmynk/splitwiser/internal/authctx"
+	"github.com/mmynk/splitwiser/internal/models"
+	"github.com/mmynk/splitwiser/internal/webhook"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+)
+
+// maxImportRows caps how many bills one ImportBills call may create.
+const maxImportRows = 5000
+
+// importDateLayout is the format of the date column of an imported CSV.
+const importDateLayout = "2006-01-02"
+
+// importColumns are the columns an imported CSV must have.
+var importColumns = []string{"date", "description", "amount", "payer", "participants"}
+
+// ImportBills creates bills in a group from a CSV of expenses. Every row is
+// checked before anything is saved: if any row fails, no bills are created
+// and the response lists each failing row. Participants who aren't members
+// of the group join it as guests, as they would when creating a bill, and
+// every imported bill counts toward the caller's monthly bill quota.
+func (s *SplitService) ImportBills(ctx context.Context, req *connect.Request[pb.ImportBillsRequest]) (*connect.Response[pb.ImportBillsResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+	}
+	if req.Msg.GroupId == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("group_id required"))
+	}
+
+	group, err := s.store.GetGroup(ctx, req.Msg.GroupId)
+	if err != nil {
+		logger.Error("ImportBills failed to get group", "group_id", req.Msg.GroupId, "error", err)
+		return nil, storeError(err)
+	}
+	if !isMember(userID, group.Members) {
+		return nil, connect.NewError(connect.CodePermissionDenied, fmt.Errorf("you must be a member of this group"))
+	}
+
+	reader := csv.NewReader(bytes.NewReader(req.Msg.Csv))
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("failed to read CSV header: %w", err))
+	}
+	cols := make(map[string]int, len(header))
+	for i, name := range header {
+		cols[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range importColumns {
+		if _, ok := cols[name]; !ok {
+			return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("CSV header must include a %s column", name))
+		}
+	}
+	field := func(record []string, name string) string {
+		if i := cols[name]; i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	resp := &pb.ImportBillsResponse{}
+	var bills []*models.Bill
+	var members []models.GroupMember
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			resp.Errors = append(resp.Errors, &pb.ImportRowError{Row: int32(parseErr.StartLine), Message: parseErr.Err.Error()})
+			continue
+		}
+		if err != nil {
+			return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("failed to read CSV: %w", err))
+		}
+		if len(bills)+len(resp.Errors) == maxImportRows {
+			return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("CSV has more than %d rows", maxImportRows))
+		}
+		row, _ := reader.FieldPos(0)
+
+		bill, err := importedBill(field(record, "date"), field(record, "description"), field(record, "amount"),
+			field(record, "payer"), field(record, "participants"), group)
+		if err != nil {
+			resp.Errors = append(resp.Errors, &pb.ImportRowError{Row: int32(row), Message: err.Error()})
+			continue
+		}
+		bill.CreatorID = userID
+		members = append(members, findNewParticipants(bill.Participants, slices.Concat(group.Members, members))...)
+		bills = append(bills, bill)
+	}
+	if len(resp.Errors) > 0 {
+		return connect.NewResponse(resp), nil
+	}
+	if len(bills) == 0 {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("CSV has no rows"))
+	}
+	if err := s.quotas.CheckCreateBills(ctx, userID, len(bills)); err != nil {
+		logger.Error("ImportBills quota check failed", "user_id", userID, "bills", len(bills), "error", err)
+		return nil, quotaError(err)
+	}
+
+	added, err := s.store.CreateBillsInGroup(ctx, group.ID, bills, members)
+	if err != nil {
+		logger.Error("ImportBills failed", "group_id", group.ID, "error", err)
+		return nil, storeError(err)
+	}
+	logger.Info("Imported bills", "group_id", group.ID, "bills", len(bills), "members_added", added)
+	checkBalanceThresholds(ctx, s.store, s.webhooks, group.ID)
+
+	// Each imported bill is announced and checked against spending caps as
+	// if it were created on its own, in the order of the rows.
+	splits := make([]*pb.CalculateSplitResponse, len(bills))
+	for i, bill := range bills {
+		resp.BillIds = append(resp.BillIds, bill.ID)
+		notifyBillChange(ctx, s.store, s.webhooks, webhook.EventBillCreated, bill)
+		if splits[i], err = billSplit(bill, false); err != nil {
+			logger.Error("CalculateSplit failed during ImportBills", "bill_id", bill.ID, "error", err)
+		}
+	}
+	s.checkBatchSpendingCaps(ctx, bills, splits)
+	// Members are only skipped if they joined the group since it was read.
+	if added == len(members) {
+		for _, m := range members {
+			resp.AddedMembers = append(resp.AddedMembers, m.DisplayName)
+		}
+	}
+	return connect.NewResponse(resp), nil
+}
+
+// importedBill builds the bill for one row of an imported CSV: an equal
+// split of amount among participants, in the group's currency. Participants
+// who are group members are linked to their accounts.
+func importedBill(date, description, amount, payer, participants string, group *models.Group) (*models.Bill, error) {
+	total, err := strconv.ParseFloat(amount, 64)
+	if err != nil || total <= 0 {
+		return nil, fmt.Errorf("amount %q must be a positive number", amount)
+	}
+	if description == "" && group.Settings.DisableAutoTitle {
+		return nil, fmt.Errorf("group '%s' requires a description", group.Name)
+	}
+
+	bill := &models.Bill{
+		Title:        description,
+		Total:        total,
+		Subtotal:     total,
+		Currency:     group.Settings.Currency,
+		PayerID:      payer,
+		GroupID:      group.ID,
+		RoundingMode: group.Settings.RoundingMode,
+	}
+	if date != "" {
+		day, err := time.Parse(importDateLayout, date)
+		if err != nil {
+			return nil, fmt.Errorf("date %q must be YYYY-MM-DD", date)
+		}
+		if day.Unix() < 0 || day.After(time.Now().Add(24*time.Hour)) {
+			return nil, fmt.Errorf("date %q must be between 1970 and tomorrow", date)
+		}
+		bill.BillDate = day.Unix()
+	}
+	for _, name := range strings.Split(participants, ";") {
+		name = strings.TrimSpace(name)
+		if name == "" || slices.ContainsFunc(bill.Participants, func(p models.BillParticipant) bool { return p.DisplayName == name }) {
+			continue
+		}
+		p := models.BillParticipant{DisplayName: name}
+		for _, m := range group.Members {
+			if m.DisplayName == name {
+				p.UserID = m.UserID
+			}
+		}
+		bill.Participants = append(bill.Participants, p)
+	}
+	if payer == "" {
+		return nil, fmt.Errorf("payer required")
+	}
+	if !slices.ContainsFunc(bill.Participants, func(p models.BillParticipant) bool { return p.DisplayName == payer }) {
+		return nil, fmt.Errorf("payer %q must be one of the participants", payer)
+	}
+	if _, err := billSplit(bill, false); err != nil {
+		return nil, err
+	}
+	return bill, nil
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/mmynk/splitwiser/internal/quota"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+)
+
+func TestImportBills(t *testing.T) {
+	splitClient, groupClient, _, store, cleanup := setupTestServerWithFriendService(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	created, err := groupClient.CreateGroup(ctx, connect.NewRequest(&pb.CreateGroupRequest{Name: "Flat", Members: gm("Alice", "Bob")}))
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	groupID := created.Msg.Group.Id
+
+	csv := "Date,Description,Amount,Payer,Participants\n" +
+		"2026-01-03,Groceries,30,Alice,Alice;Bob;Carol\n" +
+		"2026-01-10,\"Internet, January\",45.50,Bob,Alice;Bob\n"
+	imported, err := splitClient.ImportBills(ctx, connect.NewRequest(&pb.ImportBillsRequest{GroupId: groupID, Csv: []byte(csv)}))
+	if err != nil {
+		t.Fatalf("ImportBills failed: %v", err)
+	}
+	if len(imported.Msg.Errors) != 0 || len(imported.Msg.BillIds) != 2 {
+		t.Fatalf("ImportBills = %v, want two bills", imported.Msg)
+	}
+	if !slices.Equal(imported.Msg.AddedMembers, []string{"Carol"}) {
+		t.Errorf("added members = %v, want [Carol]", imported.Msg.AddedMembers)
+	}
+
+	bill, err := store.GetBill(ctx, imported.Msg.BillIds[1])
+	if err != nil {
+		t.Fatalf("GetBill failed: %v", err)
+	}
+	if bill.Title != "Internet, January" || bill.Total != 45.5 || bill.PayerID != "Bob" || bill.GroupID != groupID {
+		t.Errorf("imported bill = %+v, want Bob's internet bill in the group", bill)
+	}
+	if want := time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC).Unix(); bill.BillDate != want || bill.CreatedAt == want {
+		t.Errorf("bill_date = %d and created_at = %d, want bill_date %d apart from created_at", bill.BillDate, bill.CreatedAt, want)
+	}
+	if bill.Participants[0].UserID != testUserID {
+		t.Errorf("Alice not linked to her account: %+v", bill.Participants[0])
+	}
+
+	// One bad row keeps every row out.
+	bad := "date,description,amount,payer,participants\n" +
+		"2026-02-01,Rent,1000,Alice,Alice;Bob\n" +
+		"Feb 2,Power,abc,Dave,Alice\n" +
+		",Water,20,Dave,Alice;Bob\n"
+	rejected, err := splitClient.ImportBills(ctx, connect.NewRequest(&pb.ImportBillsRequest{GroupId: groupID, Csv: []byte(bad)}))
+	if err != nil {
+		t.Fatalf("ImportBills failed: %v", err)
+	}
+	if len(rejected.Msg.BillIds) != 0 {
+		t.Errorf("created %d bills despite bad rows", len(rejected.Msg.BillIds))
+	}
+	var rows []int32
+	for _, e := range rejected.Msg.Errors {
+		rows = append(rows, e.Row)
+	}
+	if !slices.Equal(rows, []int32{3, 4}) {
+		t.Errorf("failing rows = %v (%v), want [3 4]", rows, rejected.Msg.Errors)
+	}
+	list, err := splitClient.ListBillsByGroup(ctx, connect.NewRequest(&pb.ListBillsByGroupRequest{GroupId: groupID}))
+	if err != nil {
+		t.Fatalf("ListBillsByGroup failed: %v", err)
+	}
+	if len(list.Msg.Bills) != 2 {
+		t.Errorf("group has %d bills, want the 2 first imported", len(list.Msg.Bills))
+	}
+
+	_, err = splitClient.ImportBills(ctx, connect.NewRequest(&pb.ImportBillsRequest{GroupId: groupID, Csv: []byte("date,amount\n2026-01-01,10\n")}))
+	if connect.CodeOf(err) != connect.CodeInvalidArgument {
+		t.Errorf("CSV without the required columns: expected InvalidArgument, got %v", err)
+	}
+}
+
+func TestImportBills_Quota(t *testing.T) {
+	splitClient, groupClient, cleanup := setupTestServerWithQuotas(t, quota.Limits{MaxBillsPerMonth: 2})
+	defer cleanup()
+	ctx := context.Background()
+
+	created, err := groupClient.CreateGroup(ctx, connect.NewRequest(&pb.CreateGroupRequest{Name: "Flat", Members: gm("Alice", "Bob")}))
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	groupID := created.Msg.Group.Id
+
+	// Back-dated rows still count toward this month's quota.
+	csv := "date,description,amount,payer,participants\n" +
+		"2025-03-01,Rent,1000,Alice,Alice;Bob\n" +
+		"2025-03-02,Power,80,Bob,Alice;Bob\n" +
+		"2025-03-03,Water,20,Bob,Alice;Bob\n"
+	_, err = splitClient.ImportBills(ctx, connect.NewRequest(&pb.ImportBillsRequest{GroupId: groupID, Csv: []byte(csv)}))
+	if connect.CodeOf(err) != connect.CodeResourceExhausted {
+		t.Fatalf("importing 3 bills over a quota of 2: expected ResourceExhausted, got %v", err)
+	}
+
+	two := strings.Join(strings.Split(csv, "\n")[:3], "\n")
+	if _, err := splitClient.ImportBills(ctx, connect.NewRequest(&pb.ImportBillsRequest{GroupId: groupID, Csv: []byte(two)})); err != nil {
+		t.Fatalf("ImportBills within the quota failed: %v", err)
+	}
+	_, err = splitClient.CreateBill(ctx, connect.NewRequest(&pb.CreateBillRequest{
+		Title:        "Lunch",
+		Total:        20,
+		Subtotal:     20,
+		Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+	}))
+	if connect.CodeOf(err) != connect.CodeResourceExhausted {
+		t.Errorf("CreateBill after importing the quota: expected ResourceExhausted, got %v", err)
+	}
+}
+
+func TestImportBills_SpendingCaps(t *testing.T) {
+	groupClient, splitClient, cleanup := setupGroupTestServer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	created, err := groupClient.CreateGroup(ctx, connect.NewRequest(&pb.CreateGroupRequest{
+		Name:     "Kids",
+		Members:  gm("Alice", "Bob"),
+		Settings: &pb.GroupSettings{SpendingCaps: map[string]float64{"Bob": 50}},
+	}))
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	groupID := created.Msg.Group.Id
+
+	// Bob's half of each bill: 30, then 60 over his cap of 50.
+	csv := "date,description,amount,payer,participants\n" +
+		",Shoes,60,Alice,Alice;Bob\n" +
+		",Coat,60,Alice,Alice;Bob\n"
+	imported, err := splitClient.ImportBills(ctx, connect.NewRequest(&pb.ImportBillsRequest{GroupId: groupID, Csv: []byte(csv)}))
+	if err != nil {
+		t.Fatalf("ImportBills failed: %v", err)
+	}
+
+	stats, err := groupClient.GetGroupStats(ctx, connect.NewRequest(&pb.GetGroupStatsRequest{GroupId: groupID}))
+	if err != nil {
+		t.Fatalf("GetGroupStats failed: %v", err)
+	}
+	if alerts := stats.Msg.Alerts; len(alerts) != 1 || alerts[0].BillId != imported.Msg.BillIds[1] {
+		t.Errorf("alerts = %v, want one for the imported coat", alerts)
+	}
+}
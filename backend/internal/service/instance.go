@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+
+	"connectrpc.com/connect"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+	"github.com/mmynk/splitwiser/pkg/proto/protoconnect"
+)
+
+// Optional features an instance reports through GetInstanceConfig.
+const (
+	FeatureAttachments     = "attachments"
+	FeatureWebhooks        = "webhooks"
+	FeaturePublicStats     = "public_stats"
+	FeatureTaxReports      = "tax_reports"
+	FeatureTransactionFeed = "transaction_feed"
+	FeatureReadOnly        = "read_only"
+	FeatureReceiptOCR      = "receipt_ocr"
+	FeatureShareLinks      = "share_links"
+)
+
+// InstanceConfig is how a deployment is branded and set up, as its
+// operator configured it.
+type InstanceConfig struct {
+	Name            string
+	LogoURL         string
+	DefaultCurrency string
+	DefaultLocale   string
+	InviteOnly      bool     // Register is refused; see WithInviteOnly
+	Features        []string // Feature* names that are enabled
+}
+
+// InstanceService implements the Connect InstanceService.
+type InstanceService struct {
+	protoconnect.UnimplementedInstanceServiceHandler
+	config InstanceConfig
+}
+
+// NewInstanceService creates an InstanceService describing config.
+func NewInstanceService(config InstanceConfig) *InstanceService {
+	return &InstanceService{config: config}
+}
+
+// GetInstanceConfig returns the instance's branding, defaults and enabled
+// features. It needs no authentication, so the sign-in page can use it.
+func (s *InstanceService) GetInstanceConfig(ctx context.Context, req *connect.Request[pb.GetInstanceConfigRequest]) (*connect.Response[pb.GetInstanceConfigResponse], error) {
+	mode := pb.RegistrationMode_REGISTRATION_MODE_OPEN
+	if s.config.InviteOnly {
+		mode = pb.RegistrationMode_REGISTRATION_MODE_INVITE_ONLY
+	}
+	return connect.NewResponse(&pb.GetInstanceConfigResponse{
+		Name:             s.config.Name,
+		LogoUrl:          s.config.LogoURL,
+		DefaultCurrency:  s.config.DefaultCurrency,
+		DefaultLocale:    s.config.DefaultLocale,
+		RegistrationMode: mode,
+		Features:         s.config.Features,
+	}), nil
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"connectrpc.com/connect"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+	"github.com/mmynk/splitwiser/pkg/proto/protoconnect"
+)
+
+func TestGetInstanceConfig(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle(protoconnect.NewInstanceServiceHandler(NewInstanceService(InstanceConfig{
+		Name:            "Flat Finances",
+		LogoURL:         "https://example.com/logo.svg",
+		DefaultCurrency: "EUR",
+		DefaultLocale:   "de-DE",
+		InviteOnly:      true,
+		Features:        []string{FeatureAttachments, FeatureTransactionFeed},
+	})))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	client := protoconnect.NewInstanceServiceClient(http.DefaultClient, server.URL)
+
+	resp, err := client.GetInstanceConfig(context.Background(), connect.NewRequest(&pb.GetInstanceConfigRequest{}))
+	if err != nil {
+		t.Fatalf("GetInstanceConfig failed: %v", err)
+	}
+	got := resp.Msg
+	if got.Name != "Flat Finances" || got.LogoUrl != "https://example.com/logo.svg" || got.DefaultCurrency != "EUR" || got.DefaultLocale != "de-DE" {
+		t.Errorf("branding and defaults = %v", got)
+	}
+	if got.RegistrationMode != pb.RegistrationMode_REGISTRATION_MODE_INVITE_ONLY {
+		t.Errorf("registration mode = %v, want invite only", got.RegistrationMode)
+	}
+	if !slices.Equal(got.Features, []string{"attachments", "transaction_feed"}) {
+		t.Errorf("features = %v", got.Features)
+	}
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"connectrpc.com/connect"
+	"github.com/mmynk/splitwiser/internal/authctx"
+	"github.com/mmynk/splitwiser/internal/models"
+	"github.com/mmynk/splitwiser/internal/webhook"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+)
+
+// MoveBillToGroup moves a bill into another group, or into one from no
+// group. The caller must be on the bill and a member of both groups. The
+// move goes through the same checks as UpdateBill, and participants who
+// aren't members of the target group join it in the same transaction.
+func (s *SplitService) MoveBillToGroup(ctx context.Context, req *connect.Request[pb.MoveBillToGroupRequest]) (*connect.Response[pb.MoveBillToGroupResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+	}
+	if req.Msg.BillId == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("bill_id required"))
+	}
+	if req.Msg.TargetGroupId == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("target_group_id required"))
+	}
+
+	existing, err := s.store.GetBill(ctx, req.Msg.BillId)
+	if err != nil {
+		logger.Error("MoveBillToGroup: failed to get existing bill", "bill_id", req.Msg.BillId, "error", err)
+		return nil, storeError(err)
+	}
+	if !hasAccess(userID, existing) {
+		return nil, connect.NewError(connect.CodePermissionDenied, fmt.Errorf("you must be a participant to update this bill"))
+	}
+	if existing.GroupID == req.Msg.TargetGroupId {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("bill is already in this group"))
+	}
+	if existing.GroupID != "" {
+		source, err := s.store.GetGroup(ctx, existing.GroupID)
+		if err != nil {
+			logger.Error("MoveBillToGroup: failed to get source group", "group_id", existing.GroupID, "error", err)
+			return nil, storeError(err)
+		}
+		if !isMember(userID, source.Members) {
+			return nil, connect.NewError(connect.CodePermissionDenied, fmt.Errorf("you must be a member of the bill's group"))
+		}
+	}
+	target, err := s.store.GetGroup(ctx, req.Msg.TargetGroupId)
+	if err != nil {
+		logger.Error("MoveBillToGroup: failed to get target group", "group_id", req.Msg.TargetGroupId, "error", err)
+		return nil, storeError(err)
+	}
+	if !isMember(userID, target.Members) {
+		return nil, connect.NewError(connect.CodePermissionDenied, fmt.Errorf("you must be a member of the target group"))
+	}
+
+	msg := billToUpdateRequest(existing)
+	msg.GroupId = &target.ID
+	msg.ExchangeRate = req.Msg.ExchangeRate
+	existingBill, bill, err := s.billFromUpdate(ctx, "MoveBillToGroup", userID, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	split, err := billSplit(bill, false)
+	if err != nil {
+		logger.Error("CalculateSplit failed during MoveBillToGroup", "error", err)
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	var members []models.GroupMember
+	if !bill.NeedsAssignment {
+		members = findNewParticipants(withPayer(bill.Participants, bill.PayerID), target.Members)
+	}
+	added, err := s.store.UpdateBillInGroup(ctx, bill, members)
+	if err != nil {
+		logger.Error("MoveBillToGroup failed", "bill_id", bill.ID, "error", err)
+		return nil, storeError(err)
+	}
+	logger.Info("Moved bill", "bill_id", bill.ID, "from_group_id", existingBill.GroupID, "to_group_id", bill.GroupID, "members_added", added)
+
+	checkBalanceThresholds(ctx, s.store, s.webhooks, bill.GroupID)
+	checkBalanceThresholds(ctx, s.store, s.webhooks, existingBill.GroupID)
+	notifyBillChange(ctx, s.store, s.webhooks, webhook.EventBillUpdated, existingBill, bill)
+
+	resp := &pb.MoveBillToGroupResponse{BillId: bill.ID, Split: split}
+	// Members are only skipped if they joined the group since it was read.
+	if added == len(members) {
+		for _, m := range members {
+			resp.AddedMembers = append(resp.AddedMembers, m.DisplayName)
+		}
+	}
+	return connect.NewResponse(resp), nil
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/mmynk/splitwiser/internal/models"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+)
+
+func TestMoveBillToGroup(t *testing.T) {
+	splitClient, groupClient, _, store, cleanup := setupTestServerWithFriendService(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	createGroup := func(name string, members ...string) string {
+		t.Helper()
+		resp, err := groupClient.CreateGroup(ctx, connect.NewRequest(&pb.CreateGroupRequest{Name: name, Members: gm(members...)}))
+		if err != nil {
+			t.Fatalf("CreateGroup failed: %v", err)
+		}
+		return resp.Msg.Group.Id
+	}
+	flat := createGroup("Flat", "Alice", "Bob", "Carol")
+	trip := createGroup("Trip", "Alice")
+
+	created, err := splitClient.CreateBill(ctx, connect.NewRequest(&pb.CreateBillRequest{
+		Title:        "Groceries",
+		Total:        30,
+		Subtotal:     30,
+		PayerId:      strPtr("Alice"),
+		Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob"), guestBP("Carol")},
+		GroupId:      &flat,
+	}))
+	if err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+	billID := created.Msg.BillId
+
+	moved, err := splitClient.MoveBillToGroup(ctx, connect.NewRequest(&pb.MoveBillToGroupRequest{BillId: billID, TargetGroupId: trip}))
+	if err != nil {
+		t.Fatalf("MoveBillToGroup failed: %v", err)
+	}
+	if !slices.Equal(moved.Msg.AddedMembers, []string{"Bob", "Carol"}) {
+		t.Errorf("added members = %v, want [Bob Carol]", moved.Msg.AddedMembers)
+	}
+
+	bill, err := store.GetBill(ctx, billID)
+	if err != nil {
+		t.Fatalf("GetBill failed: %v", err)
+	}
+	if bill.GroupID != trip {
+		t.Errorf("bill group = %s, want the trip", bill.GroupID)
+	}
+	group, err := store.GetGroup(ctx, trip)
+	if err != nil {
+		t.Fatalf("GetGroup failed: %v", err)
+	}
+	if len(group.Members) != 3 {
+		t.Errorf("trip members = %v, want Alice, Bob and Carol", group.Members)
+	}
+	list, err := splitClient.ListBillsByGroup(ctx, connect.NewRequest(&pb.ListBillsByGroupRequest{GroupId: flat}))
+	if err != nil {
+		t.Fatalf("ListBillsByGroup failed: %v", err)
+	}
+	if len(list.Msg.Bills) != 0 {
+		t.Errorf("flat still lists %d bills, want none", len(list.Msg.Bills))
+	}
+
+	_, err = splitClient.MoveBillToGroup(ctx, connect.NewRequest(&pb.MoveBillToGroupRequest{BillId: billID, TargetGroupId: trip}))
+	if connect.CodeOf(err) != connect.CodeInvalidArgument {
+		t.Errorf("moving into the bill's own group: expected InvalidArgument, got %v", err)
+	}
+
+	// Alice isn't in Bob's group, so she can't move the bill into it.
+	bobs := &models.Group{Name: "Bob's", Members: []models.GroupMember{{DisplayName: "Bob", UserID: testBobID}}}
+	if err := store.CreateGroup(ctx, bobs); err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	_, err = splitClient.MoveBillToGroup(ctx, connect.NewRequest(&pb.MoveBillToGroupRequest{BillId: billID, TargetGroupId: bobs.ID}))
+	if connect.CodeOf(err) != connect.CodePermissionDenied {
+		t.Errorf("moving into a group the caller isn't in: expected PermissionDenied, got %v", err)
+	}
+}
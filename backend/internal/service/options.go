@@ -0,0 +1,103 @@
+package service
+
+import (
+	"errors"
+
+	"connectrpc.com/connect"
+	"github.com/mmynk/splitwiser/internal/blob"
+	"github.com/mmynk/splitwiser/internal/fxrate"
+	"github.com/mmynk/splitwiser/internal/notify"
+	"github.com/mmynk/splitwiser/internal/ocr"
+	"github.com/mmynk/splitwiser/internal/quota"
+	"github.com/mmynk/splitwiser/internal/taxreport"
+	"github.com/mmynk/splitwiser/internal/webhook"
+	"github.com/mmynk/splitwiser/pkg/logging"
+)
+
+// logger logs as the service component; see pkg/logging.
+var logger = logging.Component("service")
+
+// Option configures optional dependencies shared by the services.
+type Option func(*options)
+
+type options struct {
+	quotas   *quota.Enforcer
+	webhooks *webhook.Dispatcher
+	notifier notify.Notifier
+	rates    fxrate.Source
+	ocr      ocr.Provider
+	shareKey []byte
+
+	blobs              blob.Store
+	maxAttachmentBytes int64
+
+	taxLines taxreport.Lines
+}
+
+// WithQuotas enforces per-account quotas on resource creation.
+func WithQuotas(q *quota.Enforcer) Option {
+	return func(o *options) { o.quotas = q }
+}
+
+// WithWebhooks delivers group webhook events, such as balance threshold
+// crossings, and account webhook events through d. Without it, webhooks can
+// be managed but never fire.
+func WithWebhooks(d *webhook.Dispatcher) Option {
+	return func(o *options) { o.webhooks = d }
+}
+
+// WithNotifier sends notices to users, such as spending cap alerts to a
+// group's creator, through n. Without it, alerts are only recorded.
+func WithNotifier(n notify.Notifier) Option {
+	return func(o *options) { o.notifier = n }
+}
+
+// WithAttachments stores bill attachments in blobs, rejecting files larger
+// than maxBytes. Without it, attachments can't be uploaded.
+func WithAttachments(blobs blob.Store, maxBytes int64) Option {
+	return func(o *options) {
+		o.blobs = blobs
+		o.maxAttachmentBytes = maxBytes
+	}
+}
+
+// WithTaxLines files deductible bills in tax reports under the tax line
+// lines maps their category to. Without it, tax reports can't be exported.
+func WithTaxLines(lines taxreport.Lines) Option {
+	return func(o *options) { o.taxLines = lines }
+}
+
+// WithExchangeRates looks up the rate a bill in another currency than its
+// group's is locked at, when the bill is created without one. Without it,
+// such bills must come with their rate.
+func WithExchangeRates(rates fxrate.Source) Option {
+	return func(o *options) { o.rates = rates }
+}
+
+// WithReceiptOCR reads receipt photos sent to ParseReceipt with p. Without
+// it, receipts can't be scanned.
+func WithReceiptOCR(p ocr.Provider) Option {
+	return func(o *options) { o.ocr = p }
+}
+
+// WithShareLinks signs bill share links with key. Without it, bills can't
+// be shared.
+func WithShareLinks(key []byte) Option {
+	return func(o *options) { o.shareKey = key }
+}
+
+func applyOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// quotaError maps a quota check failure to a Connect error.
+func quotaError(err error) error {
+	if errors.Is(err, quota.ErrExceeded) {
+		return connect.NewError(connect.CodeResourceExhausted, err)
+	}
+	return connect.NewError(connect.CodeInternal, err)
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+
+	"connectrpc.com/connect"
+	"github.com/mmynk/splitwiser/internal/authctx"
+	"github.com/mmynk/splitwiser/internal/models"
+	"github.com/mmynk/splitwiser/internal/storage"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+	"github.com/mmynk/splitwiser/pkg/splitmath"
+)
+
+// balanceContext collects the bills and settlements of one group, or of the
+// user's direct (no-group) dealings when the group ID is empty.
+type balanceContext struct {
+	bills       []splitmath.BillForBalance
+	settlements []splitmath.SettlementForBalance
+}
+
+// GetOverallBalances reports what the authenticated user and each counterparty
+// owe each other across all of the user's groups and direct bills. Unlike
+// GetMyBalances, amounts are pairwise: a debt is only attributed to the person
+// the user actually shared a bill or settlement with.
+func (s *GroupService) GetOverallBalances(ctx context.Context, req *connect.Request[pb.GetOverallBalancesRequest]) (*connect.Response[pb.GetOverallBalancesResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+	}
+
+	myName := s.resolveDisplayName(ctx, userID)
+
+	groups, err := s.store.ListGroupsByUser(ctx, userID, storage.Page{})
+	if err != nil {
+		logger.Error("GetOverallBalances failed - could not list groups", "error", err)
+		return nil, storeError(err)
+	}
+	bills, err := s.store.ListBalanceBillsByUser(ctx, userID)
+	if err != nil {
+		logger.Error("GetOverallBalances failed - could not list bills", "error", err)
+		return nil, storeError(err)
+	}
+	settlements, err := s.store.ListBalanceSettlementsByUser(ctx, userID, myName)
+	if err != nil {
+		logger.Error("GetOverallBalances failed - could not list settlements", "error", err)
+		return nil, storeError(err)
+	}
+
+	// Registered users' IDs by display name, for linking counterparties.
+	nameToUserID := make(map[string]string)
+	for _, group := range groups {
+		for _, m := range group.Members {
+			if m.UserID != "" {
+				nameToUserID[m.DisplayName] = m.UserID
+			}
+		}
+	}
+
+	contexts := make(map[string]*balanceContext)
+	contextFor := func(groupID string) *balanceContext {
+		c, ok := contexts[groupID]
+		if !ok {
+			c = &balanceContext{}
+			contexts[groupID] = c
+		}
+		return c
+	}
+	for _, bill := range bills {
+		for _, p := range bill.Participants {
+			if p.UserID != "" && nameToUserID[p.DisplayName] == "" {
+				nameToUserID[p.DisplayName] = p.UserID
+			}
+		}
+		c := contextFor(bill.GroupID)
+		c.bills = append(c.bills, billForBalance(bill))
+	}
+	for _, settlement := range settlements {
+		c := contextFor(settlementGroupID(settlement))
+		c.settlements = append(c.settlements, splitmath.SettlementForBalance{
+			FromUserID: settlement.FromUserID,
+			ToUserID:   settlement.ToUserID,
+			Amount:     settlement.Amount,
+		})
+	}
+
+	groupNames := make(map[string]string, len(groups))
+	for _, group := range groups {
+		groupNames[group.ID] = group.Name
+	}
+
+	perPerson := make(map[string]*pb.CounterpartyBalance)
+	for groupID, c := range contexts {
+		net, err := splitmath.PairwiseBalances(myName, c.bills, c.settlements)
+		if err != nil {
+			logger.Error("GetOverallBalances failed - balance calc error", "group_id", groupID, "error", err)
+			return nil, connect.NewError(connect.CodeInternal, err)
+		}
+		for name, amount := range net {
+			counterparty, ok := perPerson[name]
+			if !ok {
+				counterparty = &pb.CounterpartyBalance{DisplayName: name}
+				if id := nameToUserID[name]; id != "" {
+					counterparty.UserId = &id
+				}
+				perPerson[name] = counterparty
+			}
+			counterparty.NetAmount += amount
+			if groupID == "" {
+				counterparty.DirectAmount += amount
+				continue
+			}
+			counterparty.GroupBalances = append(counterparty.GroupBalances, &pb.PersonGroupBalance{
+				GroupId:   groupID,
+				GroupName: groupNames[groupID],
+				NetAmount: amount,
+			})
+		}
+	}
+
+	resp := &pb.GetOverallBalancesResponse{}
+	for _, counterparty := range perPerson {
+		slices.SortFunc(counterparty.GroupBalances, func(a, b *pb.PersonGroupBalance) int {
+			return cmp.Or(cmp.Compare(a.GroupName, b.GroupName), cmp.Compare(a.GroupId, b.GroupId))
+		})
+		if counterparty.NetAmount > 0 {
+			resp.TotalOwedToYou += counterparty.NetAmount
+		} else {
+			resp.TotalYouOwe += -counterparty.NetAmount
+		}
+		resp.Counterparties = append(resp.Counterparties, counterparty)
+	}
+	slices.SortFunc(resp.Counterparties, func(a, b *pb.CounterpartyBalance) int {
+		return cmp.Compare(a.DisplayName, b.DisplayName)
+	})
+
+	return connect.NewResponse(resp), nil
+}
+
+// settlementGroupID returns the settlement's group ID, or "" for a direct settlement.
+func settlementGroupID(settlement *models.Settlement) string {
+	if settlement.GroupID == nil {
+		return ""
+	}
+	return *settlement.GroupID
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"connectrpc.com/connect"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+)
+
+func TestGetOverallBalances(t *testing.T) {
+	groupClient, splitClient, cleanup := setupGroupTestServer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	groupResp, err := groupClient.CreateGroup(ctx, connect.NewRequest(&pb.CreateGroupRequest{
+		Name:    "Trip",
+		Members: gm("Alice", "Bob", "Charlie"),
+	}))
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	groupID := groupResp.Msg.Group.Id
+
+	// In the group, Alice paid $90 for all three: Bob and Charlie owe her $30 each.
+	alicePayer := "Alice"
+	_, err = splitClient.CreateBill(ctx, connect.NewRequest(&pb.CreateBillRequest{
+		Title:        "Cabin",
+		Total:        90,
+		Subtotal:     90,
+		Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob"), guestBP("Charlie")},
+		GroupId:      &groupID,
+		PayerId:      &alicePayer,
+	}))
+	if err != nil {
+		t.Fatalf("CreateBill (group) failed: %v", err)
+	}
+
+	// Bob pays $10 of that back.
+	_, err = groupClient.RecordSettlement(ctx, connect.NewRequest(&pb.RecordSettlementRequest{
+		GroupId:    groupID,
+		FromUserId: "Bob",
+		ToUserId:   "Alice",
+		Amount:     10,
+	}))
+	if err != nil {
+		t.Fatalf("RecordSettlement failed: %v", err)
+	}
+
+	// Outside the group, Bob paid $60 for Alice and himself: Alice owes him $30.
+	bobPayer := "Bob"
+	_, err = splitClient.CreateBill(ctx, connect.NewRequest(&pb.CreateBillRequest{
+		Title:        "Concert",
+		Total:        60,
+		Subtotal:     60,
+		Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+		PayerId:      &bobPayer,
+	}))
+	if err != nil {
+		t.Fatalf("CreateBill (direct) failed: %v", err)
+	}
+
+	resp, err := groupClient.GetOverallBalances(ctx, connect.NewRequest(&pb.GetOverallBalancesRequest{}))
+	if err != nil {
+		t.Fatalf("GetOverallBalances failed: %v", err)
+	}
+
+	if resp.Msg.TotalYouOwe != 10 {
+		t.Errorf("total_you_owe: expected 10, got %f", resp.Msg.TotalYouOwe)
+	}
+	if resp.Msg.TotalOwedToYou != 30 {
+		t.Errorf("total_owed_to_you: expected 30, got %f", resp.Msg.TotalOwedToYou)
+	}
+	if len(resp.Msg.Counterparties) != 2 {
+		t.Fatalf("expected 2 counterparties, got %d", len(resp.Msg.Counterparties))
+	}
+
+	bob, charlie := resp.Msg.Counterparties[0], resp.Msg.Counterparties[1]
+	if bob.DisplayName != "Bob" || charlie.DisplayName != "Charlie" {
+		t.Fatalf("expected Bob then Charlie, got %s then %s", bob.DisplayName, charlie.DisplayName)
+	}
+	// Bob: owes $20 in the group, is owed $30 directly.
+	if bob.NetAmount != -10 {
+		t.Errorf("Bob net_amount: expected -10, got %f", bob.NetAmount)
+	}
+	if bob.DirectAmount != -30 {
+		t.Errorf("Bob direct_amount: expected -30, got %f", bob.DirectAmount)
+	}
+	if len(bob.GroupBalances) != 1 || bob.GroupBalances[0].GroupId != groupID || bob.GroupBalances[0].NetAmount != 20 {
+		t.Errorf("Bob group_balances: expected [%s: 20], got %v", groupID, bob.GroupBalances)
+	}
+	if charlie.NetAmount != 30 {
+		t.Errorf("Charlie net_amount: expected 30, got %f", charlie.NetAmount)
+	}
+	if charlie.DirectAmount != 0 {
+		t.Errorf("Charlie direct_amount: expected 0, got %f", charlie.DirectAmount)
+	}
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+
+	"connectrpc.com/connect"
+	"github.com/mmynk/splitwiser/internal/storage"
+)
+
+// maxPageSize caps page_size so one request can't ask for an unbounded page.
+const maxPageSize = 500
+
+// pageRequest turns a request's page_size and page_token into the storage
+// page to fetch. A page size of 0 fetches everything after the token. The
+// page asks for one row more than the page size so pageResults can tell
+// whether another page follows.
+func pageRequest(size int32, token string) (storage.Page, error) {
+	if size < 0 {
+		return storage.Page{}, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("page_size cannot be negative"))
+	}
+	var page storage.Page
+	if token != "" {
+		raw, err := base64.RawURLEncoding.DecodeString(token)
+		if err != nil {
+			return storage.Page{}, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("invalid page_token"))
+		}
+		page.Offset, err = strconv.Atoi(string(raw))
+		if err != nil || page.Offset < 0 {
+			return storage.Page{}, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("invalid page_token"))
+		}
+	}
+	if size > 0 {
+		page.Limit = int(min(size, maxPageSize)) + 1
+	}
+	return page, nil
+}
+
+// pageResults drops the extra row fetched for page and returns the token for
+// the next page, or "" when items is the last page.
+func pageResults[T any](items []T, page storage.Page) ([]T, string) {
+	if page.Limit == 0 || len(items) < page.Limit {
+		return items, ""
+	}
+	size := page.Limit - 1
+	next := strconv.Itoa(page.Offset + size)
+	return items[:size], base64.RawURLEncoding.EncodeToString([]byte(next))
+}
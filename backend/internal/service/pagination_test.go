@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"connectrpc.com/connect"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+)
+
+// collectPages calls list with page_size 2 until it returns no next token,
+// returning every page's length and the IDs seen across pages.
+func collectPages(t *testing.T, list func(token string) ([]string, string, error)) ([]int, map[string]bool) {
+	t.Helper()
+	var sizes []int
+	seen := make(map[string]bool)
+	token := ""
+	for range 10 {
+		ids, next, err := list(token)
+		if err != nil {
+			t.Fatalf("list page failed: %v", err)
+		}
+		sizes = append(sizes, len(ids))
+		for _, id := range ids {
+			if seen[id] {
+				t.Errorf("%s returned on two pages", id)
+			}
+			seen[id] = true
+		}
+		if next == "" {
+			return sizes, seen
+		}
+		token = next
+	}
+	t.Fatal("pagination never ended")
+	return nil, nil
+}
+
+func TestPagination(t *testing.T) {
+	groupClient, splitClient, cleanup := setupGroupTestServer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	var groupID string
+	for i := range 3 {
+		resp, err := groupClient.CreateGroup(ctx, connect.NewRequest(&pb.CreateGroupRequest{
+			Name:    fmt.Sprintf("Group %d", i),
+			Members: gm("Alice", "Bob"),
+		}))
+		if err != nil {
+			t.Fatalf("CreateGroup failed: %v", err)
+		}
+		groupID = resp.Msg.Group.Id
+	}
+	for i := range 5 {
+		_, err := splitClient.CreateBill(ctx, connect.NewRequest(&pb.CreateBillRequest{
+			Title:        fmt.Sprintf("Bill %d", i),
+			Total:        10,
+			Subtotal:     10,
+			Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+			GroupId:      &groupID,
+		}))
+		if err != nil {
+			t.Fatalf("CreateBill failed: %v", err)
+		}
+		_, err = groupClient.RecordSettlement(ctx, connect.NewRequest(&pb.RecordSettlementRequest{
+			GroupId:    groupID,
+			FromUserId: "Bob",
+			ToUserId:   "Alice",
+			Amount:     1,
+		}))
+		if err != nil {
+			t.Fatalf("RecordSettlement failed: %v", err)
+		}
+	}
+
+	t.Run("bills", func(t *testing.T) {
+		sizes, seen := collectPages(t, func(token string) ([]string, string, error) {
+			resp, err := splitClient.ListBillsByGroup(ctx, connect.NewRequest(&pb.ListBillsByGroupRequest{GroupId: groupID, PageSize: 2, PageToken: token}))
+			if err != nil {
+				return nil, "", err
+			}
+			var ids []string
+			for _, b := range resp.Msg.Bills {
+				ids = append(ids, b.BillId)
+			}
+			return ids, resp.Msg.NextPageToken, nil
+		})
+		if fmt.Sprint(sizes) != "[2 2 1]" || len(seen) != 5 {
+			t.Errorf("pages = %v with %d bills, want [2 2 1] covering 5", sizes, len(seen))
+		}
+	})
+
+	t.Run("groups", func(t *testing.T) {
+		sizes, seen := collectPages(t, func(token string) ([]string, string, error) {
+			resp, err := groupClient.ListGroups(ctx, connect.NewRequest(&pb.ListGroupsRequest{PageSize: 2, PageToken: token}))
+			if err != nil {
+				return nil, "", err
+			}
+			var ids []string
+			for _, g := range resp.Msg.Groups {
+				ids = append(ids, g.Id)
+			}
+			return ids, resp.Msg.NextPageToken, nil
+		})
+		if fmt.Sprint(sizes) != "[2 1]" || len(seen) != 3 {
+			t.Errorf("pages = %v with %d groups, want [2 1] covering 3", sizes, len(seen))
+		}
+	})
+
+	t.Run("settlements", func(t *testing.T) {
+		sizes, seen := collectPages(t, func(token string) ([]string, string, error) {
+			resp, err := groupClient.ListSettlements(ctx, connect.NewRequest(&pb.ListSettlementsRequest{GroupId: groupID, PageSize: 2, PageToken: token}))
+			if err != nil {
+				return nil, "", err
+			}
+			var ids []string
+			for _, s := range resp.Msg.Settlements {
+				ids = append(ids, s.Id)
+			}
+			return ids, resp.Msg.NextPageToken, nil
+		})
+		if fmt.Sprint(sizes) != "[2 2 1]" || len(seen) != 5 {
+			t.Errorf("pages = %v with %d settlements, want [2 2 1] covering 5", sizes, len(seen))
+		}
+	})
+
+	t.Run("no page size returns everything", func(t *testing.T) {
+		resp, err := splitClient.ListBillsByGroup(ctx, connect.NewRequest(&pb.ListBillsByGroupRequest{GroupId: groupID}))
+		if err != nil {
+			t.Fatalf("ListBillsByGroup failed: %v", err)
+		}
+		if len(resp.Msg.Bills) != 5 || resp.Msg.NextPageToken != "" {
+			t.Errorf("got %d bills and token %q, want all 5 and no token", len(resp.Msg.Bills), resp.Msg.NextPageToken)
+		}
+	})
+
+	t.Run("bad requests", func(t *testing.T) {
+		_, err := groupClient.ListGroups(ctx, connect.NewRequest(&pb.ListGroupsRequest{PageSize: 2, PageToken: "not a token"}))
+		if connect.CodeOf(err) != connect.CodeInvalidArgument {
+			t.Errorf("bad token: got %v, want InvalidArgument", err)
+		}
+		_, err = groupClient.ListGroups(ctx, connect.NewRequest(&pb.ListGroupsRequest{PageSize: -1}))
+		if connect.CodeOf(err) != connect.CodeInvalidArgument {
+			t.Errorf("negative page size: got %v, want InvalidArgument", err)
+		}
+	})
+}
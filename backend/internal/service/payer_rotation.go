@@ -0,0 +1,107 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+
+	"connectrpc.com/connect"
+	"github.com/mmynk/splitwiser/internal/authctx"
+	"github.com/mmynk/splitwiser/internal/models"
+	"github.com/mmynk/splitwiser/internal/storage"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+	"github.com/mmynk/splitwiser/pkg/splitmath"
+)
+
+// payerRotation orders members by whose turn it is to pay: whoever has paid
+// the least in total goes first, so a big dinner counts for more than a
+// coffee, then whoever paid longest ago (never paying counts as longest),
+// then by name. Parked bills and bills paid by non-members don't count.
+func payerRotation(members []models.GroupMember, bills []*models.Bill) []*pb.PayerTurn {
+	turns := make(map[string]*pb.PayerTurn, len(members))
+	rotation := make([]*pb.PayerTurn, len(members))
+	for i, m := range members {
+		rotation[i] = &pb.PayerTurn{DisplayName: m.DisplayName}
+		turns[m.DisplayName] = rotation[i]
+	}
+	for _, bill := range bills {
+		turn := turns[bill.PayerID]
+		if turn == nil || bill.NeedsAssignment {
+			continue
+		}
+		turn.TotalPaid += bill.Total
+		turn.BillsPaid++
+		turn.LastPaidAt = max(turn.LastPaidAt, bill.CreatedAt)
+	}
+
+	for _, turn := range rotation {
+		turn.TotalPaid = splitmath.RoundAmount(turn.TotalPaid, "")
+	}
+	slices.SortFunc(rotation, func(a, b *pb.PayerTurn) int {
+		return cmp.Or(
+			cmp.Compare(a.TotalPaid, b.TotalPaid),
+			cmp.Compare(a.LastPaidAt, b.LastPaidAt),
+			cmp.Compare(a.DisplayName, b.DisplayName),
+		)
+	})
+	return rotation
+}
+
+// expectedPayer returns whose turn it was to pay when bill is paid by someone
+// else in a group that rotates payers, and "" otherwise. It must run before
+// bill is saved. Lookup failures are only logged; the nudge is advisory.
+func (s *SplitService) expectedPayer(ctx context.Context, bill *models.Bill) string {
+	if bill.GroupID == "" || bill.PayerID == "" || bill.NeedsAssignment {
+		return ""
+	}
+	group, err := s.store.GetGroup(ctx, bill.GroupID)
+	if err != nil {
+		logger.Error("Payer rotation check failed - group not found", "group_id", bill.GroupID, "error", err)
+		return ""
+	}
+	if !group.Settings.PayerRotation {
+		return ""
+	}
+	bills, err := s.store.ListBillsByGroup(ctx, group.ID, storage.BillFilter{})
+	if err != nil {
+		logger.Error("Payer rotation check failed", "group_id", group.ID, "error", err)
+		return ""
+	}
+	rotation := payerRotation(group.Members, bills)
+	if len(rotation) == 0 || rotation[0].DisplayName == bill.PayerID {
+		return ""
+	}
+	return rotation[0].DisplayName
+}
+
+// GetNextPayer reports whose turn it is to pay in a group, with every
+// member's payment history in turn order.
+func (s *GroupService) GetNextPayer(ctx context.Context, req *connect.Request[pb.GetNextPayerRequest]) (*connect.Response[pb.GetNextPayerResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+	}
+
+	groupID := req.Msg.GetGroupId()
+	if err := s.requireMembership(ctx, userID, groupID); err != nil {
+		return nil, err
+	}
+
+	group, err := s.store.GetGroup(ctx, groupID)
+	if err != nil {
+		logger.Error("GetNextPayer failed to get group", "group_id", groupID, "error", err)
+		return nil, storeError(err)
+	}
+	bills, err := s.store.ListBillsByGroup(ctx, groupID, storage.BillFilter{})
+	if err != nil {
+		logger.Error("GetNextPayer failed to list bills", "group_id", groupID, "error", err)
+		return nil, storeError(err)
+	}
+
+	resp := &pb.GetNextPayerResponse{Rotation: payerRotation(group.Members, bills)}
+	if len(resp.Rotation) > 0 {
+		resp.NextPayer = resp.Rotation[0].DisplayName
+	}
+	return connect.NewResponse(resp), nil
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/mmynk/splitwiser/internal/models"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+)
+
+func TestPayerRotation(t *testing.T) {
+	members := []models.GroupMember{{DisplayName: "Cat"}, {DisplayName: "Bob"}, {DisplayName: "Amy"}, {DisplayName: "Dan"}}
+	bills := []*models.Bill{
+		{PayerID: "Amy", Total: 20, CreatedAt: 100},
+		{PayerID: "Bob", Total: 5, CreatedAt: 300},
+		{PayerID: "Bob", Total: 15, CreatedAt: 200},
+		{PayerID: "Cat", Total: 8, CreatedAt: 400},
+		{PayerID: "Dan", Total: 90, NeedsAssignment: true}, // parked: doesn't count
+		{PayerID: "Eve", Total: 1},                         // not a member
+	}
+
+	rotation := payerRotation(members, bills)
+	// Dan never paid; Cat paid least; Amy and Bob tie on 20 and Amy paid longer ago.
+	want := []string{"Dan", "Cat", "Amy", "Bob"}
+	for i, turn := range rotation {
+		if turn.DisplayName != want[i] {
+			t.Fatalf("rotation = %v, want %v", rotation, want)
+		}
+	}
+	if bob := rotation[3]; bob.TotalPaid != 20 || bob.BillsPaid != 2 || bob.LastPaidAt != 300 {
+		t.Errorf("Bob = %+v, want 20 over 2 bills, last at 300", bob)
+	}
+}
+
+func TestGetNextPayer(t *testing.T) {
+	groupClient, splitClient, cleanup := setupGroupTestServer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	groupResp, err := groupClient.CreateGroup(ctx, connect.NewRequest(&pb.CreateGroupRequest{
+		Name:     "Lunch",
+		Members:  gm("Alice", "Bob", "Carol"),
+		Settings: &pb.GroupSettings{PayerRotation: true},
+	}))
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	groupID := groupResp.Msg.Group.Id
+
+	nextPayer := func() string {
+		t.Helper()
+		resp, err := groupClient.GetNextPayer(ctx, connect.NewRequest(&pb.GetNextPayerRequest{GroupId: groupID}))
+		if err != nil {
+			t.Fatalf("GetNextPayer failed: %v", err)
+		}
+		return resp.Msg.NextPayer
+	}
+	pay := func(payer string, total float64) *pb.CreateBillResponse {
+		t.Helper()
+		resp, err := splitClient.CreateBill(ctx, connect.NewRequest(&pb.CreateBillRequest{
+			Title:        "Lunch",
+			Total:        total,
+			Subtotal:     total,
+			Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob"), guestBP("Carol")},
+			GroupId:      &groupID,
+			PayerId:      &payer,
+		}))
+		if err != nil {
+			t.Fatalf("CreateBill failed: %v", err)
+		}
+		return resp.Msg
+	}
+
+	if got := nextPayer(); got != "Alice" {
+		t.Fatalf("first payer = %q, want Alice", got)
+	}
+	if resp := pay("Alice", 30); resp.ExpectedPayer != nil || len(resp.Warnings) != 0 {
+		t.Errorf("in-turn payment nudged: %v", resp.Warnings)
+	}
+
+	// Carol pays when it was Bob's turn.
+	resp := pay("Carol", 12)
+	if resp.GetExpectedPayer() != "Bob" || len(resp.Warnings) != 1 {
+		t.Errorf("out-of-turn payment: expected payer %q, warnings %v; want Bob and one warning", resp.GetExpectedPayer(), resp.Warnings)
+	}
+	if got := nextPayer(); got != "Bob" {
+		t.Errorf("next payer = %q, want Bob, who hasn't paid", got)
+	}
+
+	// Carol paid last, but the least, so she's up after Bob.
+	pay("Bob", 20)
+	if got := nextPayer(); got != "Carol" {
+		t.Errorf("next payer = %q, want Carol", got)
+	}
+
+	// Without rotation there's no nudge.
+	plainResp, err := groupClient.CreateGroup(ctx, connect.NewRequest(&pb.CreateGroupRequest{Name: "Dinner", Members: gm("Alice", "Bob")}))
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	bob := "Bob"
+	billResp, err := splitClient.CreateBill(ctx, connect.NewRequest(&pb.CreateBillRequest{
+		Title:        "Dinner",
+		Total:        10,
+		Subtotal:     10,
+		Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+		GroupId:      &plainResp.Msg.Group.Id,
+		PayerId:      &bob,
+	}))
+	if err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+	if billResp.Msg.ExpectedPayer != nil {
+		t.Errorf("nudged in a group without rotation: %q", billResp.Msg.GetExpectedPayer())
+	}
+}
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/mmynk/splitwiser/internal/authctx"
+	"github.com/mmynk/splitwiser/internal/storage"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+)
+
+// newStatsToken returns a random hex-encoded public stats token.
+func newStatsToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// hashStatsToken returns the SHA-256 hex digest stored in place of a raw stats token.
+func hashStatsToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// EnablePublicStats publishes a group's aggregate stats under a new token.
+// Any member may enable them; calling it again rotates the token.
+func (s *GroupService) EnablePublicStats(ctx context.Context, req *connect.Request[pb.EnablePublicStatsRequest]) (*connect.Response[pb.EnablePublicStatsResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+	}
+
+	groupID := req.Msg.GetGroupId()
+	if err := s.requireMembership(ctx, userID, groupID); err != nil {
+		return nil, err
+	}
+
+	token, err := newStatsToken()
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	if err := s.store.SetGroupStatsToken(ctx, groupID, hashStatsToken(token), userID); err != nil {
+		logger.Error("EnablePublicStats failed", "group_id", groupID, "error", err)
+		return nil, storeError(err)
+	}
+
+	logger.Info("Public stats enabled", "group_id", groupID, "user_id", userID)
+	return connect.NewResponse(&pb.EnablePublicStatsResponse{Token: token}), nil
+}
+
+// DisablePublicStats stops publishing a group's stats. Responses already
+// cached by PublicStatsService may be served until they expire.
+func (s *GroupService) DisablePublicStats(ctx context.Context, req *connect.Request[pb.DisablePublicStatsRequest]) (*connect.Response[pb.DisablePublicStatsResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+	}
+
+	groupID := req.Msg.GetGroupId()
+	if err := s.requireMembership(ctx, userID, groupID); err != nil {
+		return nil, err
+	}
+
+	if err := s.store.DeleteGroupStatsToken(ctx, groupID); err != nil {
+		logger.Error("DisablePublicStats failed", "group_id", groupID, "error", err)
+		return nil, storeError(err)
+	}
+
+	logger.Info("Public stats disabled", "group_id", groupID, "user_id", userID)
+	return connect.NewResponse(&pb.DisablePublicStatsResponse{}), nil
+}
+
+// requireMembership returns a connect error unless groupID names a group the user belongs to.
+func (s *GroupService) requireMembership(ctx context.Context, userID, groupID string) error {
+	if groupID == "" {
+		return connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("group_id required"))
+	}
+	group, err := s.store.GetGroup(ctx, groupID)
+	if err != nil {
+		logger.Error("Group lookup failed", "group_id", groupID, "error", err)
+		return connect.NewError(connect.CodeNotFound, fmt.Errorf("group not found"))
+	}
+	if !isMemberByName(s.resolveDisplayName(ctx, userID), group.Members) {
+		return connect.NewError(connect.CodePermissionDenied, fmt.Errorf("not a member of this group"))
+	}
+	return nil
+}
+
+// PublicStatsService serves opted-in groups' aggregate stats without authentication.
+type PublicStatsService struct {
+	store    storage.Store
+	cacheTTL time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedStats // by token hash
+}
+
+type cachedStats struct {
+	resp    *pb.GetPublicGroupStatsResponse
+	expires time.Time
+}
+
+// NewPublicStatsService creates a PublicStatsService that caches each
+// group's stats for cacheTTL (0 disables caching).
+func NewPublicStatsService(store storage.Store, cacheTTL time.Duration) *PublicStatsService {
+	return &PublicStatsService{
+		store:    store,
+		cacheTTL: cacheTTL,
+		cache:    make(map[string]cachedStats),
+	}
+}
+
+// GetPublicGroupStats returns monthly spend totals for the group published
+// under the request's token. Unknown tokens are NotFound.
+func (s *PublicStatsService) GetPublicGroupStats(ctx context.Context, req *connect.Request[pb.GetPublicGroupStatsRequest]) (*connect.Response[pb.GetPublicGroupStatsResponse], error) {
+	if req.Msg.Token == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("token required"))
+	}
+	tokenHash := hashStatsToken(req.Msg.Token)
+	now := time.Now()
+
+	s.mu.Lock()
+	cached, ok := s.cache[tokenHash]
+	s.mu.Unlock()
+	if !ok || now.After(cached.expires) {
+		stats, err := s.loadStats(ctx, tokenHash, now)
+		if err != nil {
+			return nil, err
+		}
+		cached = cachedStats{resp: stats, expires: now.Add(s.cacheTTL)}
+		if s.cacheTTL > 0 {
+			s.mu.Lock()
+			s.cache[tokenHash] = cached
+			// Drop expired entries so rotated tokens don't accumulate.
+			for hash, entry := range s.cache {
+				if now.After(entry.expires) {
+					delete(s.cache, hash)
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+
+	resp := connect.NewResponse(cached.resp)
+	if s.cacheTTL > 0 {
+		resp.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(cached.expires.Sub(now).Seconds())))
+	}
+	return resp, nil
+}
+
+// loadStats builds the stats response for the group published under tokenHash.
+func (s *PublicStatsService) loadStats(ctx context.Context, tokenHash string, now time.Time) (*pb.GetPublicGroupStatsResponse, error) {
+	groupID, err := s.store.GetGroupIDByStatsToken(ctx, tokenHash)
+	if err != nil {
+		logger.Error("GetPublicGroupStats token lookup failed", "error", err)
+		return nil, storeError(err)
+	}
+	if groupID == "" {
+		return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("no published stats for this token"))
+	}
+
+	group, err := s.store.GetGroup(ctx, groupID)
+	if err != nil {
+		logger.Error("GetPublicGroupStats failed to get group", "group_id", groupID, "error", err)
+		return nil, storeError(err)
+	}
+	months, err := s.store.ListGroupMonthlySpend(ctx, groupID)
+	if err != nil {
+		logger.Error("GetPublicGroupStats failed to list spend", "group_id", groupID, "error", err)
+		return nil, storeError(err)
+	}
+
+	resp := &pb.GetPublicGroupStatsResponse{
+		MemberCount: int32(len(group.Members)),
+		GeneratedAt: now.Unix(),
+	}
+	for _, m := range months {
+		resp.Months = append(resp.Months, &pb.MonthlySpend{
+			Month:     m.Month,
+			Currency:  m.Currency,
+			BillCount: int32(m.BillCount),
+			Total:     m.Total,
+		})
+	}
+	return resp, nil
+}
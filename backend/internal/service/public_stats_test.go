@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/mmynk/splitwiser/internal/models"
+	"github.com/mmynk/splitwiser/internal/storage/sqlite"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+	"github.com/mmynk/splitwiser/pkg/proto/protoconnect"
+)
+
+// setupPublicStatsServer serves GroupService and SplitService as Alice plus an
+// unauthenticated PublicStatsService, all on one store.
+func setupPublicStatsServer(t *testing.T, cacheTTL time.Duration) (protoconnect.GroupServiceClient, protoconnect.SplitServiceClient, protoconnect.PublicStatsServiceClient) {
+	t.Helper()
+
+	dbPath := t.TempDir() + "/test.db"
+	store, err := sqlite.New(dbPath)
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	if err := store.CreateUser(context.Background(), &models.User{
+		ID:           testUserID,
+		Email:        "alice@example.com",
+		DisplayName:  "Alice",
+		PasswordHash: "hash",
+		CreatedAt:    time.Now().Unix(),
+		UpdatedAt:    time.Now().Unix(),
+	}); err != nil {
+		t.Fatalf("failed to create test user: %v", err)
+	}
+
+	authInterceptor := connect.WithInterceptors(testAuthInterceptor())
+	mux := http.NewServeMux()
+	mux.Handle(protoconnect.NewGroupServiceHandler(NewGroupService(store), authInterceptor))
+	mux.Handle(protoconnect.NewSplitServiceHandler(NewSplitService(store), authInterceptor))
+	mux.Handle(protoconnect.NewPublicStatsServiceHandler(NewPublicStatsService(store, cacheTTL)))
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return protoconnect.NewGroupServiceClient(http.DefaultClient, server.URL),
+		protoconnect.NewSplitServiceClient(http.DefaultClient, server.URL),
+		protoconnect.NewPublicStatsServiceClient(http.DefaultClient, server.URL)
+}
+
+func TestPublicGroupStats(t *testing.T) {
+	groupClient, splitClient, statsClient := setupPublicStatsServer(t, time.Minute)
+	ctx := context.Background()
+
+	groupResp, err := groupClient.CreateGroup(ctx, connect.NewRequest(&pb.CreateGroupRequest{
+		Name:    "Chess Club",
+		Members: gm("Alice", "Bob", "Carol"),
+	}))
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	groupID := groupResp.Msg.Group.Id
+
+	for _, total := range []float64{30, 45} {
+		if _, err := splitClient.CreateBill(ctx, connect.NewRequest(&pb.CreateBillRequest{
+			Title:        "Snacks",
+			Total:        total,
+			Subtotal:     total,
+			Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+			GroupId:      &groupID,
+		})); err != nil {
+			t.Fatalf("CreateBill failed: %v", err)
+		}
+	}
+
+	enableResp, err := groupClient.EnablePublicStats(ctx, connect.NewRequest(&pb.EnablePublicStatsRequest{GroupId: groupID}))
+	if err != nil {
+		t.Fatalf("EnablePublicStats failed: %v", err)
+	}
+	token := enableResp.Msg.Token
+
+	statsResp, err := statsClient.GetPublicGroupStats(ctx, connect.NewRequest(&pb.GetPublicGroupStatsRequest{Token: token}))
+	if err != nil {
+		t.Fatalf("GetPublicGroupStats failed: %v", err)
+	}
+	stats := statsResp.Msg
+	if stats.MemberCount != 3 {
+		t.Errorf("member count = %d, want 3", stats.MemberCount)
+	}
+	if len(stats.Months) != 1 {
+		t.Fatalf("got %d months, want 1", len(stats.Months))
+	}
+	if month := stats.Months[0]; month.BillCount != 2 || month.Total != 75 || month.Month != time.Now().UTC().Format("2006-01") {
+		t.Errorf("month = %+v, want this month with 2 bills totalling 75", month)
+	}
+	if cc := statsResp.Header().Get("Cache-Control"); cc == "" {
+		t.Error("expected a Cache-Control header")
+	}
+
+	// Within the cache TTL a new bill doesn't show up yet.
+	if _, err := splitClient.CreateBill(ctx, connect.NewRequest(&pb.CreateBillRequest{
+		Title:        "Board",
+		Total:        25,
+		Subtotal:     25,
+		Participants: []*pb.BillParticipant{aliceBP()},
+		GroupId:      &groupID,
+	})); err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+	cachedResp, err := statsClient.GetPublicGroupStats(ctx, connect.NewRequest(&pb.GetPublicGroupStatsRequest{Token: token}))
+	if err != nil {
+		t.Fatalf("GetPublicGroupStats failed: %v", err)
+	}
+	if cachedResp.Msg.Months[0].Total != 75 {
+		t.Errorf("cached total = %v, want 75", cachedResp.Msg.Months[0].Total)
+	}
+
+	_, err = statsClient.GetPublicGroupStats(ctx, connect.NewRequest(&pb.GetPublicGroupStatsRequest{Token: "not-a-token"}))
+	if connect.CodeOf(err) != connect.CodeNotFound {
+		t.Errorf("unknown token code = %v, want NotFound", connect.CodeOf(err))
+	}
+}
+
+func TestPublicGroupStats_Disabled(t *testing.T) {
+	groupClient, _, statsClient := setupPublicStatsServer(t, 0)
+	ctx := context.Background()
+
+	groupResp, err := groupClient.CreateGroup(ctx, connect.NewRequest(&pb.CreateGroupRequest{Name: "Club", Members: gm("Alice")}))
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	groupID := groupResp.Msg.Group.Id
+
+	enableResp, err := groupClient.EnablePublicStats(ctx, connect.NewRequest(&pb.EnablePublicStatsRequest{GroupId: groupID}))
+	if err != nil {
+		t.Fatalf("EnablePublicStats failed: %v", err)
+	}
+	if _, err := statsClient.GetPublicGroupStats(ctx, connect.NewRequest(&pb.GetPublicGroupStatsRequest{Token: enableResp.Msg.Token})); err != nil {
+		t.Fatalf("GetPublicGroupStats failed: %v", err)
+	}
+
+	if _, err := groupClient.DisablePublicStats(ctx, connect.NewRequest(&pb.DisablePublicStatsRequest{GroupId: groupID})); err != nil {
+		t.Fatalf("DisablePublicStats failed: %v", err)
+	}
+	_, err = statsClient.GetPublicGroupStats(ctx, connect.NewRequest(&pb.GetPublicGroupStatsRequest{Token: enableResp.Msg.Token}))
+	if connect.CodeOf(err) != connect.CodeNotFound {
+		t.Errorf("code after disabling = %v, want NotFound", connect.CodeOf(err))
+	}
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/mmynk/splitwiser/internal/quota"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+)
+
+func TestCreateGroup_QuotaExceeded(t *testing.T) {
+	_, groupClient, cleanup := setupTestServerWithQuotas(t, quota.Limits{MaxGroups: 1})
+	defer cleanup()
+	ctx := context.Background()
+
+	if _, err := groupClient.CreateGroup(ctx, connect.NewRequest(&pb.CreateGroupRequest{
+		Name:    "First",
+		Members: gm("Bob"),
+	})); err != nil {
+		t.Fatalf("first CreateGroup failed: %v", err)
+	}
+
+	_, err := groupClient.CreateGroup(ctx, connect.NewRequest(&pb.CreateGroupRequest{
+		Name:    "Second",
+		Members: gm("Bob"),
+	}))
+	if connect.CodeOf(err) != connect.CodeResourceExhausted {
+		t.Fatalf("expected ResourceExhausted, got %v", err)
+	}
+}
+
+func TestCreateGroup_QuotaFreedByDelete(t *testing.T) {
+	_, groupClient, cleanup := setupTestServerWithQuotas(t, quota.Limits{MaxGroups: 1})
+	defer cleanup()
+	ctx := context.Background()
+
+	resp, err := groupClient.CreateGroup(ctx, connect.NewRequest(&pb.CreateGroupRequest{
+		Name:    "First",
+		Members: gm("Bob"),
+	}))
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	if _, err := groupClient.DeleteGroup(ctx, connect.NewRequest(&pb.DeleteGroupRequest{
+		GroupId: resp.Msg.Group.Id,
+	})); err != nil {
+		t.Fatalf("DeleteGroup failed: %v", err)
+	}
+
+	if _, err := groupClient.CreateGroup(ctx, connect.NewRequest(&pb.CreateGroupRequest{
+		Name:    "Second",
+		Members: gm("Bob"),
+	})); err != nil {
+		t.Fatalf("expected CreateGroup to succeed after delete, got %v", err)
+	}
+}
+
+func TestCreateBill_MonthlyQuotaExceeded(t *testing.T) {
+	splitClient, _, cleanup := setupTestServerWithQuotas(t, quota.Limits{MaxBillsPerMonth: 2})
+	defer cleanup()
+	ctx := context.Background()
+
+	newBill := func() *connect.Request[pb.CreateBillRequest] {
+		return connect.NewRequest(&pb.CreateBillRequest{
+			Title:        "Lunch",
+			Items:        []*pb.Item{{Description: "Food", Amount: 20, ParticipantIds: []string{"Alice", "Bob"}}},
+			Total:        20,
+			Subtotal:     20,
+			Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+		})
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := splitClient.CreateBill(ctx, newBill()); err != nil {
+			t.Fatalf("CreateBill %d failed: %v", i+1, err)
+		}
+	}
+
+	_, err := splitClient.CreateBill(ctx, newBill())
+	if connect.CodeOf(err) != connect.CodeResourceExhausted {
+		t.Fatalf("expected ResourceExhausted, got %v", err)
+	}
+}
+
+func TestImportGroupArchive_BillQuotaExceeded(t *testing.T) {
+	_, groupClient, cleanup := setupTestServerWithQuotas(t, quota.Limits{MaxBillsPerMonth: 2})
+	defer cleanup()
+	ctx := context.Background()
+
+	archive := &pb.GroupArchive{
+		Version: 1,
+		Name:    "Trip",
+		Members: []*pb.ArchivedMember{{DisplayName: "Alice"}, {DisplayName: "Bob"}},
+	}
+	for _, title := range []string{"Taxi", "Dinner", "Museum"} {
+		archive.Bills = append(archive.Bills, &pb.ArchivedBill{
+			Title:        title,
+			Total:        20,
+			Subtotal:     20,
+			Payer:        "Alice",
+			Participants: []*pb.ArchivedParticipant{{DisplayName: "Alice"}, {DisplayName: "Bob"}},
+		})
+	}
+
+	_, err := groupClient.ImportGroupArchive(ctx, connect.NewRequest(&pb.ImportGroupArchiveRequest{Archive: archive}))
+	if connect.CodeOf(err) != connect.CodeResourceExhausted {
+		t.Fatalf("expected ResourceExhausted, got %v", err)
+	}
+
+	// Bills archived long ago still count toward this month once imported.
+	archive.Bills = archive.Bills[:2]
+	for _, bill := range archive.Bills {
+		bill.CreatedAt = time.Now().AddDate(-1, 0, 0).Unix()
+	}
+	if _, err := groupClient.ImportGroupArchive(ctx, connect.NewRequest(&pb.ImportGroupArchiveRequest{Archive: archive})); err != nil {
+		t.Fatalf("ImportGroupArchive within the quota failed: %v", err)
+	}
+	archive.Bills = archive.Bills[:1]
+	_, err = groupClient.ImportGroupArchive(ctx, connect.NewRequest(&pb.ImportGroupArchiveRequest{Archive: archive}))
+	if connect.CodeOf(err) != connect.CodeResourceExhausted {
+		t.Errorf("second import: expected ResourceExhausted, got %v", err)
+	}
+}
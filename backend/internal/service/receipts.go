@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"mime"
+
+	"connectrpc.com/connect"
+	"github.com/mmynk/splitwiser/internal/authctx"
+	"github.com/mmynk/splitwiser/internal/models"
+	"github.com/mmynk/splitwiser/internal/ocr"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+)
+
+// receiptTypes are the image types ParseReceipt accepts; both OCR providers
+// read these.
+var receiptTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/webp": true,
+}
+
+// maxReceiptBytes bounds the images ParseReceipt accepts: a full-resolution
+// phone photo.
+const maxReceiptBytes = 10 << 20
+
+// receiptItemSource names ParseReceipt in the origins of the items it reads.
+const receiptItemSource = "receipt"
+
+// defaultReceiptTitle names drafts of receipts without a legible merchant.
+const defaultReceiptTitle = "Receipt"
+
+// ParseReceipt reads a receipt photo with the OCR provider and returns a
+// draft CreateBillRequest with its items and amounts, paid for and split by
+// the caller alone until they add the others. Nothing is saved; the draft is
+// for the caller to check, complete and send to CreateBill.
+func (s *SplitService) ParseReceipt(ctx context.Context, req *connect.Request[pb.ParseReceiptRequest]) (*connect.Response[pb.ParseReceiptResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+	}
+	if s.ocr == nil {
+		return nil, connect.NewError(connect.CodeUnimplemented, fmt.Errorf("receipt scanning is not enabled on this server"))
+	}
+
+	image := req.Msg.Image
+	if len(image) == 0 {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("image required"))
+	}
+	if len(image) > maxReceiptBytes {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("image is %d bytes; the limit is %d", len(image), maxReceiptBytes))
+	}
+	contentType := sniffContentType(image)
+	if !receiptTypes[contentType] {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("unsupported image type %s; upload a JPEG, PNG or WebP photo", contentType))
+	}
+	if declared := req.Msg.ContentType; declared != "" {
+		if mediaType, _, err := mime.ParseMediaType(declared); err != nil || mediaType != contentType {
+			return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("content_type %s does not match the image, which is %s", declared, contentType))
+		}
+	}
+
+	text, err := s.ocr.Text(ctx, image)
+	if err != nil {
+		logger.Error("ParseReceipt failed to read the image", "error", err)
+		return nil, connect.NewError(connect.CodeUnavailable, fmt.Errorf("failed to read the receipt"))
+	}
+	receipt := ocr.ParseReceipt(text)
+
+	resp := &pb.ParseReceiptResponse{
+		Draft:    s.receiptDraft(ctx, userID, receipt),
+		Tax:      receipt.Tax,
+		Text:     text,
+		Warnings: receiptWarnings(receipt),
+	}
+	return connect.NewResponse(resp), nil
+}
+
+// receiptDraft turns a parsed receipt into a bill paid for by userID, who is
+// its only participant.
+func (s *SplitService) receiptDraft(ctx context.Context, userID string, receipt *ocr.Receipt) *pb.CreateBillRequest {
+	name := userID
+	if users, err := s.store.GetUsersByIDs(ctx, []string{userID}); err == nil && users[userID] != nil {
+		name = users[userID].DisplayName
+	}
+	draft := &pb.CreateBillRequest{
+		Title:        receipt.Merchant,
+		Total:        receipt.Total,
+		Subtotal:     receipt.Subtotal,
+		Tip:          receipt.Tip,
+		Discount:     receipt.Discount,
+		Participants: []*pb.BillParticipant{{DisplayName: name, UserId: &userID}},
+		PayerId:      &name,
+	}
+	if draft.Title == "" {
+		draft.Title = defaultReceiptTitle
+	}
+	for _, item := range receipt.Items {
+		draft.Items = append(draft.Items, &pb.Item{
+			Description: item.Description,
+			Amount:      item.Amount,
+			Quantity:    item.Quantity,
+			UnitPrice:   item.UnitPrice,
+			Origin: itemOriginToProto(&models.ItemOrigin{
+				Source:      receiptItemSource,
+				Description: item.Description,
+				Amount:      item.Amount,
+				Quantity:    item.Quantity,
+				UnitPrice:   item.UnitPrice,
+			}),
+		})
+	}
+	return draft
+}
+
+// receiptWarnings lists what a parsed receipt leaves to be checked.
+func receiptWarnings(receipt *ocr.Receipt) []string {
+	var warnings []string
+	if len(receipt.Items) == 0 {
+		warnings = append(warnings, "no items were found on the receipt")
+	} else {
+		var sum float64
+		for _, item := range receipt.Items {
+			sum += item.Amount
+		}
+		if math.Abs(sum-receipt.Subtotal) >= 0.01 {
+			warnings = append(warnings, fmt.Sprintf("items add up to %.2f but the subtotal is %.2f", sum, receipt.Subtotal))
+		}
+	}
+	if receipt.Total == 0 {
+		warnings = append(warnings, "no total was found on the receipt")
+	} else if parts := receipt.Subtotal - receipt.Discount + receipt.Tax + receipt.Tip; math.Abs(receipt.Total-parts) >= 0.01 {
+		warnings = append(warnings, fmt.Sprintf("the total is %.2f but the subtotal, discount, tax and tip come to %.2f", receipt.Total, parts))
+	}
+	if receipt.Merchant == "" {
+		warnings = append(warnings, "no merchant was found; the bill is titled "+defaultReceiptTitle)
+	}
+	return warnings
+}
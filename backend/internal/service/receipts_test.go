@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"connectrpc.com/connect"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+)
+
+// fakeOCR is an ocr.Provider that reads the same text from every image.
+type fakeOCR struct {
+	text string
+	err  error
+}
+
+func (f fakeOCR) Text(ctx context.Context, image []byte) (string, error) {
+	return f.text, f.err
+}
+
+const testReceiptText = `CORNER CAFE
+2 x Latte     9.00
+Croissant     3.50
+SUBTOTAL     12.50
+TAX           1.00
+TOTAL        13.50
+VISA         13.50`
+
+func TestParseReceipt(t *testing.T) {
+	_, splitClient, cleanup := setupGroupTestServer(t, WithReceiptOCR(fakeOCR{text: testReceiptText}))
+	defer cleanup()
+	ctx := context.Background()
+
+	resp, err := splitClient.ParseReceipt(ctx, connect.NewRequest(&pb.ParseReceiptRequest{Image: testPNG, ContentType: "image/png"}))
+	if err != nil {
+		t.Fatalf("ParseReceipt failed: %v", err)
+	}
+	draft := resp.Msg.Draft
+	if draft.Title != "CORNER CAFE" || draft.Subtotal != 12.5 || draft.Total != 13.5 || resp.Msg.Tax != 1 {
+		t.Errorf("draft = %v, tax %v; want CORNER CAFE, 12.50 + 1.00 tax = 13.50", draft, resp.Msg.Tax)
+	}
+	if len(draft.Items) != 2 || draft.Items[0].Description != "Latte" || draft.Items[0].Quantity != 2 || draft.Items[0].UnitPrice != 4.5 {
+		t.Fatalf("items = %v, want 2 x Latte at 4.50 and a croissant", draft.Items)
+	}
+	if origin := draft.Items[1].Origin; origin == nil || origin.Source != "receipt" || origin.Amount != 3.5 {
+		t.Errorf("croissant origin = %v, want the receipt's 3.50", origin)
+	}
+	if draft.GetPayerId() != "Alice" || len(draft.Participants) != 1 || draft.Participants[0].GetUserId() != testUserID {
+		t.Errorf("draft participants = %v, payer %q; want Alice alone", draft.Participants, draft.GetPayerId())
+	}
+	if len(resp.Msg.Warnings) != 0 {
+		t.Errorf("unexpected warnings %v", resp.Msg.Warnings)
+	}
+	if resp.Msg.Text != testReceiptText {
+		t.Errorf("text = %q, want the recognized text", resp.Msg.Text)
+	}
+
+	// The draft is a bill as it stands.
+	created, err := splitClient.CreateBill(ctx, connect.NewRequest(draft))
+	if err != nil {
+		t.Fatalf("CreateBill with the draft failed: %v", err)
+	}
+	if created.Msg.Split.Splits["Alice"].Total != 13.5 {
+		t.Errorf("Alice's total = %v, want 13.50", created.Msg.Split.Splits["Alice"].Total)
+	}
+}
+
+func TestParseReceipt_Errors(t *testing.T) {
+	_, disabled, cleanup := setupGroupTestServer(t)
+	defer cleanup()
+	ctx := context.Background()
+	if _, err := disabled.ParseReceipt(ctx, connect.NewRequest(&pb.ParseReceiptRequest{Image: testPNG})); connect.CodeOf(err) != connect.CodeUnimplemented {
+		t.Errorf("ParseReceipt without OCR: expected Unimplemented, got %v", err)
+	}
+
+	_, splitClient, cleanup2 := setupGroupTestServer(t, WithReceiptOCR(fakeOCR{text: "smudge"}))
+	defer cleanup2()
+	for name, req := range map[string]*pb.ParseReceiptRequest{
+		"no image":        {},
+		"pdf":             {Image: []byte("%PDF-1.7\n")},
+		"mismatched type": {Image: testPNG, ContentType: "image/jpeg"},
+		"too large":       {Image: append(append([]byte{}, testPNG...), make([]byte, maxReceiptBytes)...)},
+	} {
+		if _, err := splitClient.ParseReceipt(ctx, connect.NewRequest(req)); connect.CodeOf(err) != connect.CodeInvalidArgument {
+			t.Errorf("%s: expected InvalidArgument, got %v", name, err)
+		}
+	}
+
+	// Unreadable receipts come back as an empty draft with warnings.
+	resp, err := splitClient.ParseReceipt(ctx, connect.NewRequest(&pb.ParseReceiptRequest{Image: testPNG}))
+	if err != nil {
+		t.Fatalf("ParseReceipt failed: %v", err)
+	}
+	if resp.Msg.Draft.Title != "smudge" || len(resp.Msg.Warnings) != 2 {
+		t.Errorf("draft = %v, warnings %v; want no items or total", resp.Msg.Draft, resp.Msg.Warnings)
+	}
+
+	_, failing, cleanup3 := setupGroupTestServer(t, WithReceiptOCR(fakeOCR{err: errors.New("provider down")}))
+	defer cleanup3()
+	if _, err := failing.ParseReceipt(ctx, connect.NewRequest(&pb.ParseReceiptRequest{Image: testPNG})); connect.CodeOf(err) != connect.CodeUnavailable {
+		t.Errorf("ParseReceipt with a failing provider: expected Unavailable, got %v", err)
+	}
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"connectrpc.com/connect"
+
+	"github.com/mmynk/splitwiser/internal/models"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+	"github.com/mmynk/splitwiser/pkg/splitmath"
+)
+
+// reassignRemovedItems rewrites the items of bill, an update of existing, so
+// the participants the update leaves off no longer share any: their part goes
+// to the items' other participants or to the payer, as mode says. Items are
+// otherwise saved as sent.
+func reassignRemovedItems(existing, bill *models.Bill, mode pb.RemovedParticipantMode) error {
+	if mode == pb.RemovedParticipantMode_REMOVED_PARTICIPANT_MODE_UNSPECIFIED || bill.SplitType == models.SplitExact {
+		return nil
+	}
+
+	weights := make(map[string]float64)
+	for _, p := range existing.Participants {
+		weights[p.DisplayName] = p.Shares
+	}
+	onBill := make(map[string]bool)
+	for _, p := range bill.Participants {
+		onBill[p.DisplayName] = true
+		weights[p.DisplayName] = p.Shares
+	}
+	var removed []string
+	for _, p := range existing.Participants {
+		if !onBill[p.DisplayName] {
+			removed = append(removed, p.DisplayName)
+		}
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+	if mode == pb.RemovedParticipantMode_REMOVED_PARTICIPANT_MODE_PAYER && !onBill[bill.PayerID] {
+		return connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("removed participants' items can only go to a payer on the bill"))
+	}
+
+	var parts []models.Item
+	for i := range bill.Items {
+		item := &bill.Items[i]
+		item.Shares, item.Units = maps.Clone(item.Shares), maps.Clone(item.Units)
+		for _, person := range removed {
+			switch mode {
+			case pb.RemovedParticipantMode_REMOVED_PARTICIPANT_MODE_REMAINING:
+				if err := giveToRemaining(item, person); err != nil {
+					return connect.NewError(connect.CodeInvalidArgument, err)
+				}
+			case pb.RemovedParticipantMode_REMOVED_PARTICIPANT_MODE_PAYER:
+				if part := giveToPayer(item, person, bill, weights); part != nil {
+					parts = append(parts, *part)
+				}
+			default:
+				return connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("unknown removed participant mode %v", mode))
+			}
+		}
+	}
+	bill.Items = append(bill.Items, parts...)
+	logger.Info("Reassigned removed participants' items", "bill_id", bill.ID, "removed", removed, "mode", mode.String())
+	return nil
+}
+
+// giveToRemaining takes person off item, dividing their units evenly among
+// the item's other participants. Equal and share splits need nothing more.
+// It fails if nobody else shares the item.
+func giveToRemaining(item *models.Item, person string) error {
+	switch item.Category {
+	case models.ItemHousehold:
+		return nil
+	case models.ItemPersonal:
+		if item.Owner == person {
+			return fmt.Errorf("%q is a personal item of %s, who is being removed; reassign it first", item.Description, person)
+		}
+		return nil
+	}
+	if !slices.Contains(item.Participants, person) {
+		return nil
+	}
+	rest := slices.DeleteFunc(slices.Clone(item.Participants), func(p string) bool { return p == person })
+	if len(rest) == 0 {
+		return fmt.Errorf("nobody else shares %q with %s, who is being removed; reassign it first", item.Description, person)
+	}
+	if units := item.Units[person]; units > 0 {
+		each := units / float64(len(rest))
+		for _, p := range rest {
+			item.Units[p] += each
+		}
+	}
+	delete(item.Units, person)
+	delete(item.Shares, person)
+	item.Participants = rest
+	return nil
+}
+
+// giveToPayer moves person's part of item to the bill's payer: the payer takes
+// their place, or, sharing the item already, their units or share weight. An
+// equal split can't weigh the payer more, so there the part becomes an item
+// of its own, which giveToPayer returns for the caller to add.
+func giveToPayer(item *models.Item, person string, bill *models.Bill, weights map[string]float64) *models.Item {
+	payer := bill.PayerID
+	switch item.Category {
+	case models.ItemHousehold:
+		return nil
+	case models.ItemPersonal:
+		if item.Owner == person {
+			item.Owner = payer
+		}
+		return nil
+	}
+	i := slices.Index(item.Participants, person)
+	if i < 0 {
+		return nil
+	}
+
+	if !slices.Contains(item.Participants, payer) {
+		item.Participants = slices.Clone(item.Participants)
+		item.Participants[i] = payer
+		if units, ok := item.Units[person]; ok {
+			item.Units[payer] = units
+			delete(item.Units, person)
+		}
+		if w, ok := item.Shares[person]; ok {
+			item.Shares[payer] = w
+			delete(item.Shares, person)
+		}
+		return nil
+	}
+
+	var part *models.Item
+	switch {
+	case len(item.Units) > 0:
+		item.Units[payer] += item.Units[person]
+	case bill.SplitType == models.SplitShares:
+		weight := func(p string) float64 {
+			if w := item.Shares[p]; w > 0 {
+				return w
+			}
+			if w := weights[p]; w > 0 {
+				return w
+			}
+			return 1
+		}
+		if item.Shares == nil {
+			item.Shares = make(map[string]float64)
+		}
+		item.Shares[payer] = weight(payer) + weight(person)
+	default:
+		n := float64(len(item.Participants))
+		part = &models.Item{
+			Description:  fmt.Sprintf("%s (%s's part)", item.Description, person),
+			Amount:       splitmath.RoundAmount(item.Amount/n, bill.Currency),
+			Discount:     splitmath.RoundAmount(item.Discount/n, bill.Currency),
+			Participants: []string{payer},
+		}
+		item.Amount -= part.Amount
+		item.Discount -= part.Discount
+	}
+	delete(item.Units, person)
+	delete(item.Shares, person)
+	item.Participants = slices.Delete(slices.Clone(item.Participants), i, i+1)
+	return part
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"connectrpc.com/connect"
+
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+)
+
+func TestUpdateBill_RemovedParticipantMode(t *testing.T) {
+	client, cleanup := setupTestServer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	alice := "Alice"
+	items := func() []*pb.Item {
+		return []*pb.Item{
+			{Description: "Pizza", Amount: 30, ParticipantIds: []string{"Alice", "Bob", "Carol"}},
+			{Description: "Wine", Amount: 20, ParticipantIds: []string{"Bob", "Carol"}},
+			{Description: "Beer", Amount: 6, Quantity: 3, UnitPrice: 2, ParticipantIds: []string{"Bob", "Carol"},
+				Units: map[string]float64{"Bob": 1, "Carol": 2}},
+		}
+	}
+	createBill := func(t *testing.T, items []*pb.Item) string {
+		t.Helper()
+		resp, err := client.CreateBill(ctx, connect.NewRequest(&pb.CreateBillRequest{
+			Title:        "Dinner",
+			Items:        items,
+			Total:        56,
+			Subtotal:     56,
+			Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob"), guestBP("Carol")},
+			PayerId:      &alice,
+		}))
+		if err != nil {
+			t.Fatalf("CreateBill failed: %v", err)
+		}
+		return resp.Msg.BillId
+	}
+	// Carol leaves; the client sends the items unchanged.
+	removeCarol := func(billID string, items []*pb.Item, mode pb.RemovedParticipantMode) (*connect.Response[pb.UpdateBillResponse], error) {
+		return client.UpdateBill(ctx, connect.NewRequest(&pb.UpdateBillRequest{
+			BillId:                 billID,
+			Title:                  "Dinner",
+			Items:                  items,
+			Total:                  56,
+			Subtotal:               56,
+			Participants:           []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+			PayerId:                &alice,
+			RemovedParticipantMode: mode,
+		}))
+	}
+
+	tests := []struct {
+		name      string
+		mode      pb.RemovedParticipantMode
+		wantTotal map[string]float64
+		wantItems int
+	}{
+		{
+			name: "remaining",
+			mode: pb.RemovedParticipantMode_REMOVED_PARTICIPANT_MODE_REMAINING,
+			// Pizza 15/15, Wine to Bob, all three beers to Bob.
+			wantTotal: map[string]float64{"Alice": 15, "Bob": 41},
+			wantItems: 3,
+		},
+		{
+			name: "payer",
+			mode: pb.RemovedParticipantMode_REMOVED_PARTICIPANT_MODE_PAYER,
+			// Alice already shares the pizza, so Carol's 10 of it becomes its own
+			// item; Alice takes Carol's place on the wine and her two beers.
+			wantTotal: map[string]float64{"Alice": 34, "Bob": 22},
+			wantItems: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			billID := createBill(t, items())
+			resp, err := removeCarol(billID, items(), tt.mode)
+			if err != nil {
+				t.Fatalf("UpdateBill failed: %v", err)
+			}
+			splits := resp.Msg.Split.Splits
+			if len(splits) != len(tt.wantTotal) {
+				t.Errorf("expected splits for %v, got %v", tt.wantTotal, splits)
+			}
+			for person, want := range tt.wantTotal {
+				if got := splits[person].GetTotal(); got != want {
+					t.Errorf("%s total: expected %v, got %v", person, want, got)
+				}
+			}
+
+			bill, err := client.GetBill(ctx, connect.NewRequest(&pb.GetBillRequest{BillId: billID}))
+			if err != nil {
+				t.Fatalf("GetBill failed: %v", err)
+			}
+			if len(bill.Msg.Items) != tt.wantItems {
+				t.Errorf("expected %d items, got %d", tt.wantItems, len(bill.Msg.Items))
+			}
+			for _, item := range bill.Msg.Items {
+				for _, p := range item.ParticipantIds {
+					if p == "Carol" {
+						t.Errorf("Carol still shares %q", item.Description)
+					}
+				}
+			}
+		})
+	}
+
+	t.Run("nobody left", func(t *testing.T) {
+		only := []*pb.Item{{Description: "Cake", Amount: 56, ParticipantIds: []string{"Carol"}}}
+		billID := createBill(t, only)
+		_, err := removeCarol(billID, only, pb.RemovedParticipantMode_REMOVED_PARTICIPANT_MODE_REMAINING)
+		if connect.CodeOf(err) != connect.CodeInvalidArgument {
+			t.Fatalf("expected InvalidArgument, got %v", err)
+		}
+
+		// Nothing was saved.
+		bill, err := client.GetBill(ctx, connect.NewRequest(&pb.GetBillRequest{BillId: billID}))
+		if err != nil {
+			t.Fatalf("GetBill failed: %v", err)
+		}
+		if len(bill.Msg.Participants) != 3 {
+			t.Errorf("expected the bill to keep 3 participants, got %d", len(bill.Msg.Participants))
+		}
+	})
+}
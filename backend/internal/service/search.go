@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"connectrpc.com/connect"
+	"github.com/mmynk/splitwiser/internal/authctx"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+)
+
+// SearchBills finds the authenticated user's bills whose title, item
+// descriptions or participant names contain every word of the query, best
+// matches first.
+func (s *SplitService) SearchBills(ctx context.Context, req *connect.Request[pb.SearchBillsRequest]) (*connect.Response[pb.SearchBillsResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+	}
+
+	query := strings.TrimSpace(req.Msg.Query)
+	if query == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("query required"))
+	}
+	mask, err := parseFieldMask(req.Msg.FieldMask, &pb.SearchBillsResponse{})
+	if err != nil {
+		return nil, err
+	}
+	filter, err := billFilter(req.Msg.GetGroupId(), req.Msg.Ungrouped, req.Msg.Since, req.Msg.Until, req.Msg.PageSize, req.Msg.PageToken)
+	if err != nil {
+		return nil, err
+	}
+	filter.CategoryID = req.Msg.GetCategoryId()
+	filter.ExcludeArchived = !req.Msg.IncludeArchived
+
+	bills, err := s.store.SearchBills(ctx, userID, query, filter)
+	if err != nil {
+		logger.Error("SearchBills failed", "error", err)
+		return nil, storeError(err)
+	}
+	bills, nextPageToken := pageResults(bills, filter.Page)
+
+	resp := &pb.SearchBillsResponse{
+		Bills:         s.userBillSummaries(ctx, bills, mask),
+		NextPageToken: nextPageToken,
+	}
+	mask.apply(resp)
+	return connect.NewResponse(resp), nil
+}
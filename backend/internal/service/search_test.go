@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"connectrpc.com/connect"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+)
+
+func TestSearchBills(t *testing.T) {
+	client, cleanup := setupTestServer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	for _, title := range []string{"Lunch", "Dinner"} {
+		if _, err := client.CreateBill(ctx, connect.NewRequest(&pb.CreateBillRequest{
+			Title:        title,
+			Total:        20,
+			Subtotal:     20,
+			Items:        []*pb.Item{{Description: title + " tacos", Amount: 20, ParticipantIds: []string{"Alice", "Bob"}}},
+			Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+		})); err != nil {
+			t.Fatalf("CreateBill failed: %v", err)
+		}
+	}
+
+	resp, err := client.SearchBills(ctx, connect.NewRequest(&pb.SearchBillsRequest{Query: "taco", PageSize: 1}))
+	if err != nil {
+		t.Fatalf("SearchBills failed: %v", err)
+	}
+	if len(resp.Msg.Bills) != 1 || resp.Msg.NextPageToken == "" {
+		t.Fatalf("first page = %v with token %q, want one bill and more to come", resp.Msg.Bills, resp.Msg.NextPageToken)
+	}
+	resp, err = client.SearchBills(ctx, connect.NewRequest(&pb.SearchBillsRequest{Query: "dinner tacos"}))
+	if err != nil {
+		t.Fatalf("SearchBills failed: %v", err)
+	}
+	if len(resp.Msg.Bills) != 1 || resp.Msg.Bills[0].Title != "Dinner" || resp.Msg.Bills[0].ParticipantCount != 2 {
+		t.Errorf("dinner tacos = %v, want the Dinner bill with its participants", resp.Msg.Bills)
+	}
+
+	groupID := "g"
+	for name, req := range map[string]*pb.SearchBillsRequest{
+		"empty query":       {Query: "  "},
+		"group + ungrouped": {Query: "tacos", GroupId: &groupID, Ungrouped: true},
+		"since after until": {Query: "tacos", Since: 20, Until: 10},
+	} {
+		if _, err := client.SearchBills(ctx, connect.NewRequest(req)); connect.CodeOf(err) != connect.CodeInvalidArgument {
+			t.Errorf("%s: code = %v, want InvalidArgument", name, connect.CodeOf(err))
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/mmynk/splitwiser/internal/authctx"
+	"github.com/mmynk/splitwiser/internal/models"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+	"github.com/mmynk/splitwiser/pkg/splitmath"
+)
+
+// maxPlanInstallments caps how many installments a settlement plan can have.
+const maxPlanInstallments = 260
+
+// planCadenceFromProto converts a proto PlanCadence to a model PlanCadence.
+func planCadenceFromProto(c pb.PlanCadence) models.PlanCadence {
+	switch c {
+	case pb.PlanCadence_PLAN_CADENCE_WEEKLY:
+		return models.PlanCadenceWeekly
+	case pb.PlanCadence_PLAN_CADENCE_BIWEEKLY:
+		return models.PlanCadenceBiweekly
+	default:
+		return models.PlanCadenceMonthly
+	}
+}
+
+// planCadenceToProto converts a model PlanCadence to a proto PlanCadence.
+func planCadenceToProto(c models.PlanCadence) pb.PlanCadence {
+	switch c {
+	case models.PlanCadenceWeekly:
+		return pb.PlanCadence_PLAN_CADENCE_WEEKLY
+	case models.PlanCadenceBiweekly:
+		return pb.PlanCadence_PLAN_CADENCE_BIWEEKLY
+	default:
+		return pb.PlanCadence_PLAN_CADENCE_MONTHLY
+	}
+}
+
+// installmentStatusToProto converts a calculator InstallmentStatus to its proto enum.
+func installmentStatusToProto(s splitmath.InstallmentStatus) pb.InstallmentStatus {
+	switch s {
+	case splitmath.InstallmentDue:
+		return pb.InstallmentStatus_INSTALLMENT_STATUS_DUE
+	case splitmath.InstallmentOverdue:
+		return pb.InstallmentStatus_INSTALLMENT_STATUS_OVERDUE
+	case splitmath.InstallmentPaid:
+		return pb.InstallmentStatus_INSTALLMENT_STATUS_PAID
+	default:
+		return pb.InstallmentStatus_INSTALLMENT_STATUS_UPCOMING
+	}
+}
+
+// planInstallments schedules a plan's installments as of now.
+func planInstallments(plan *models.SettlementPlan, now time.Time) []splitmath.Installment {
+	return splitmath.Installments(plan.Total, plan.InstallmentAmount, splitmath.Cadence(plan.Cadence),
+		time.Unix(plan.StartAt, 0), plan.Paid, now)
+}
+
+// installmentToProto converts a calculator Installment to a proto Installment.
+func installmentToProto(inst splitmath.Installment) *pb.Installment {
+	return &pb.Installment{
+		Number: int32(inst.Number),
+		DueAt:  inst.DueAt.Unix(),
+		Amount: inst.Amount,
+		Paid:   inst.Paid,
+		Status: installmentStatusToProto(inst.Status),
+	}
+}
+
+// settlementPlanToProto converts a model SettlementPlan to a proto SettlementPlan
+// with its installment schedule as of now.
+func settlementPlanToProto(plan *models.SettlementPlan, now time.Time) *pb.SettlementPlan {
+	installments := planInstallments(plan, now)
+	pbInstallments := make([]*pb.Installment, len(installments))
+	for i, inst := range installments {
+		pbInstallments[i] = installmentToProto(inst)
+	}
+	return &pb.SettlementPlan{
+		Id:                plan.ID,
+		GroupId:           plan.GroupID,
+		FromUserId:        plan.FromUserID,
+		ToUserId:          plan.ToUserID,
+		Total:             plan.Total,
+		InstallmentAmount: plan.InstallmentAmount,
+		Cadence:           planCadenceToProto(plan.Cadence),
+		StartAt:           plan.StartAt,
+		CreatedAt:         plan.CreatedAt,
+		CreatedBy:         plan.CreatedBy,
+		Note:              plan.Note,
+		Paid:              plan.Paid,
+		Remaining:         math.Max(plan.Total-plan.Paid, 0),
+		Installments:      pbInstallments,
+	}
+}
+
+// CreateSettlementPlan schedules repayment of a debt between two group members in installments.
+func (s *GroupService) CreateSettlementPlan(ctx context.Context, req *connect.Request[pb.CreateSettlementPlanRequest]) (*connect.Response[pb.CreateSettlementPlanResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+	}
+
+	msg := req.Msg
+	if msg.GroupId == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("group_id required"))
+	}
+	if msg.FromUserId == "" || msg.ToUserId == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("from_user_id and to_user_id required"))
+	}
+	if msg.FromUserId == msg.ToUserId {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("from_user_id and to_user_id must be different"))
+	}
+	if msg.Total <= 0 || msg.InstallmentAmount <= 0 {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("total and installment_amount must be positive"))
+	}
+	if msg.Total/msg.InstallmentAmount > maxPlanInstallments {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("a plan can have at most %d installments", maxPlanInstallments))
+	}
+
+	group, err := s.store.GetGroup(ctx, msg.GroupId)
+	if err != nil {
+		logger.Error("CreateSettlementPlan failed - group not found", "group_id", msg.GroupId, "error", err)
+		return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("group not found"))
+	}
+	creatorDisplayName := s.resolveDisplayName(ctx, userID)
+	if !isMemberByName(creatorDisplayName, group.Members) {
+		return nil, connect.NewError(connect.CodePermissionDenied, fmt.Errorf("not a member of this group"))
+	}
+	if !isMemberByName(msg.FromUserId, group.Members) || !isMemberByName(msg.ToUserId, group.Members) {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("from_user and to_user must be members of this group"))
+	}
+
+	plan := &models.SettlementPlan{
+		GroupID:           msg.GroupId,
+		FromUserID:        msg.FromUserId,
+		ToUserID:          msg.ToUserId,
+		Total:             msg.Total,
+		InstallmentAmount: msg.InstallmentAmount,
+		Cadence:           planCadenceFromProto(msg.Cadence),
+		StartAt:           msg.StartAt,
+		CreatedBy:         creatorDisplayName,
+		Note:              msg.Note,
+	}
+	now := time.Now()
+	if plan.StartAt == 0 {
+		plan.StartAt = now.Unix()
+	}
+
+	if err := s.store.CreateSettlementPlan(ctx, plan); err != nil {
+		logger.Error("CreateSettlementPlan failed", "error", err)
+		return nil, storeError(err)
+	}
+
+	return connect.NewResponse(&pb.CreateSettlementPlanResponse{Plan: settlementPlanToProto(plan, now)}), nil
+}
+
+// ListSettlementPlans lists a group's settlement plans with their progress.
+func (s *GroupService) ListSettlementPlans(ctx context.Context, req *connect.Request[pb.ListSettlementPlansRequest]) (*connect.Response[pb.ListSettlementPlansResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+	}
+
+	groupID := req.Msg.GetGroupId()
+	if groupID == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("group_id required"))
+	}
+	group, err := s.store.GetGroup(ctx, groupID)
+	if err != nil {
+		logger.Error("ListSettlementPlans failed - group not found", "group_id", groupID, "error", err)
+		return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("group not found"))
+	}
+	if !isMemberByName(s.resolveDisplayName(ctx, userID), group.Members) {
+		return nil, connect.NewError(connect.CodePermissionDenied, fmt.Errorf("not a member of this group"))
+	}
+
+	plans, err := s.store.ListSettlementPlansByGroup(ctx, groupID)
+	if err != nil {
+		logger.Error("ListSettlementPlans failed", "group_id", groupID, "error", err)
+		return nil, storeError(err)
+	}
+
+	now := time.Now()
+	pbPlans := make([]*pb.SettlementPlan, len(plans))
+	for i, plan := range plans {
+		pbPlans[i] = settlementPlanToProto(plan, now)
+	}
+	return connect.NewResponse(&pb.ListSettlementPlansResponse{Plans: pbPlans}), nil
+}
+
+// DeleteSettlementPlan removes a settlement plan. Settlements already recorded
+// against it still count toward balances.
+func (s *GroupService) DeleteSettlementPlan(ctx context.Context, req *connect.Request[pb.DeleteSettlementPlanRequest]) (*connect.Response[pb.DeleteSettlementPlanResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+	}
+
+	planID := req.Msg.GetPlanId()
+	if planID == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("plan_id required"))
+	}
+	plan, err := s.store.GetSettlementPlan(ctx, planID)
+	if err != nil {
+		logger.Error("DeleteSettlementPlan failed - plan not found", "plan_id", planID, "error", err)
+		return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("settlement plan not found"))
+	}
+	group, err := s.store.GetGroup(ctx, plan.GroupID)
+	if err != nil {
+		logger.Error("DeleteSettlementPlan failed - group not found", "group_id", plan.GroupID, "error", err)
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("group not found"))
+	}
+	if !isMemberByName(s.resolveDisplayName(ctx, userID), group.Members) {
+		return nil, connect.NewError(connect.CodePermissionDenied, fmt.Errorf("not a member of this group"))
+	}
+
+	if err := s.store.DeleteSettlementPlan(ctx, planID); err != nil {
+		logger.Error("DeleteSettlementPlan failed", "error", err)
+		return nil, storeError(err)
+	}
+	return connect.NewResponse(&pb.DeleteSettlementPlanResponse{}), nil
+}
+
+// ListInstallmentReminders returns the installments the authenticated user is
+// repaying that are due within the reminder window or overdue, soonest first.
+func (s *GroupService) ListInstallmentReminders(ctx context.Context, req *connect.Request[pb.ListInstallmentRemindersRequest]) (*connect.Response[pb.ListInstallmentRemindersResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+	}
+
+	plans, err := s.store.ListSettlementPlansByPayer(ctx, s.resolveDisplayName(ctx, userID))
+	if err != nil {
+		logger.Error("ListInstallmentReminders failed", "error", err)
+		return nil, storeError(err)
+	}
+
+	now := time.Now()
+	groupNames := make(map[string]string)
+	var reminders []*pb.InstallmentReminder
+	for _, plan := range plans {
+		for _, inst := range planInstallments(plan, now) {
+			if inst.Status != splitmath.InstallmentDue && inst.Status != splitmath.InstallmentOverdue {
+				continue
+			}
+			name, ok := groupNames[plan.GroupID]
+			if !ok {
+				if group, err := s.store.GetGroup(ctx, plan.GroupID); err == nil {
+					name = group.Name
+				}
+				groupNames[plan.GroupID] = name
+			}
+			reminders = append(reminders, &pb.InstallmentReminder{
+				PlanId:      plan.ID,
+				GroupId:     plan.GroupID,
+				GroupName:   name,
+				ToUserId:    plan.ToUserID,
+				Installment: installmentToProto(inst),
+			})
+		}
+	}
+	slices.SortStableFunc(reminders, func(a, b *pb.InstallmentReminder) int {
+		return cmp.Compare(a.Installment.DueAt, b.Installment.DueAt)
+	})
+
+	return connect.NewResponse(&pb.ListInstallmentRemindersResponse{Reminders: reminders}), nil
+}
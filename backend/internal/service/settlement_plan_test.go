@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+)
+
+func TestSettlementPlan_ProgressAndReminders(t *testing.T) {
+	client, _, cleanup := setupGroupTestServer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	groupResp, err := client.CreateGroup(ctx, connect.NewRequest(&pb.CreateGroupRequest{
+		Name:    "Flatmates",
+		Members: gm("Alice", "Bob"),
+	}))
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	groupID := groupResp.Msg.Group.Id
+
+	// Weekly installments of 30 on 100, the first due 15 days ago:
+	// due on days -15, -8 and -1, and the last (10) in 6 days.
+	start := time.Now().AddDate(0, 0, -15).Unix()
+	planResp, err := client.CreateSettlementPlan(ctx, connect.NewRequest(&pb.CreateSettlementPlanRequest{
+		GroupId:           groupID,
+		FromUserId:        "Alice",
+		ToUserId:          "Bob",
+		Total:             100,
+		InstallmentAmount: 30,
+		Cadence:           pb.PlanCadence_PLAN_CADENCE_WEEKLY,
+		StartAt:           start,
+	}))
+	if err != nil {
+		t.Fatalf("CreateSettlementPlan failed: %v", err)
+	}
+	plan := planResp.Msg.Plan
+	if len(plan.Installments) != 4 || plan.Installments[3].Amount != 10 {
+		t.Fatalf("expected 4 installments ending with 10, got %v", plan.Installments)
+	}
+
+	recordResp, err := client.RecordSettlement(ctx, connect.NewRequest(&pb.RecordSettlementRequest{
+		GroupId:    groupID,
+		FromUserId: "Alice",
+		ToUserId:   "Bob",
+		Amount:     45,
+		PlanId:     &plan.Id,
+	}))
+	if err != nil {
+		t.Fatalf("RecordSettlement failed: %v", err)
+	}
+	if recordResp.Msg.Settlement.GetPlanId() != plan.Id {
+		t.Errorf("expected settlement linked to plan %s, got %q", plan.Id, recordResp.Msg.Settlement.GetPlanId())
+	}
+
+	listResp, err := client.ListSettlementPlans(ctx, connect.NewRequest(&pb.ListSettlementPlansRequest{GroupId: groupID}))
+	if err != nil {
+		t.Fatalf("ListSettlementPlans failed: %v", err)
+	}
+	if len(listResp.Msg.Plans) != 1 {
+		t.Fatalf("expected 1 plan, got %d", len(listResp.Msg.Plans))
+	}
+	got := listResp.Msg.Plans[0]
+	if got.Paid != 45 || got.Remaining != 55 {
+		t.Errorf("expected paid 45 and remaining 55, got %f and %f", got.Paid, got.Remaining)
+	}
+	wantStatus := []pb.InstallmentStatus{
+		pb.InstallmentStatus_INSTALLMENT_STATUS_PAID,
+		pb.InstallmentStatus_INSTALLMENT_STATUS_OVERDUE,
+		pb.InstallmentStatus_INSTALLMENT_STATUS_OVERDUE,
+		pb.InstallmentStatus_INSTALLMENT_STATUS_UPCOMING,
+	}
+	for i, want := range wantStatus {
+		if got.Installments[i].Status != want {
+			t.Errorf("installment %d: expected %v, got %v", i+1, want, got.Installments[i].Status)
+		}
+	}
+
+	remindResp, err := client.ListInstallmentReminders(ctx, connect.NewRequest(&pb.ListInstallmentRemindersRequest{}))
+	if err != nil {
+		t.Fatalf("ListInstallmentReminders failed: %v", err)
+	}
+	reminders := remindResp.Msg.Reminders
+	if len(reminders) != 2 {
+		t.Fatalf("expected 2 reminders for overdue installments, got %d", len(reminders))
+	}
+	if reminders[0].Installment.Number != 2 || reminders[0].ToUserId != "Bob" || reminders[0].GroupName != "Flatmates" {
+		t.Errorf("unexpected first reminder: %v", reminders[0])
+	}
+
+	// Deleting the plan keeps its settlement, unlinked.
+	if _, err := client.DeleteSettlementPlan(ctx, connect.NewRequest(&pb.DeleteSettlementPlanRequest{PlanId: plan.Id})); err != nil {
+		t.Fatalf("DeleteSettlementPlan failed: %v", err)
+	}
+	settlementsResp, err := client.ListSettlements(ctx, connect.NewRequest(&pb.ListSettlementsRequest{GroupId: groupID}))
+	if err != nil {
+		t.Fatalf("ListSettlements failed: %v", err)
+	}
+	if len(settlementsResp.Msg.Settlements) != 1 || settlementsResp.Msg.Settlements[0].PlanId != nil {
+		t.Errorf("expected the settlement to survive unlinked, got %v", settlementsResp.Msg.Settlements)
+	}
+}
+
+func TestRecordSettlement_PlanMismatch(t *testing.T) {
+	client, _, cleanup := setupGroupTestServer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	groupResp, err := client.CreateGroup(ctx, connect.NewRequest(&pb.CreateGroupRequest{
+		Name:    "Flatmates",
+		Members: gm("Alice", "Bob"),
+	}))
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	groupID := groupResp.Msg.Group.Id
+
+	planResp, err := client.CreateSettlementPlan(ctx, connect.NewRequest(&pb.CreateSettlementPlanRequest{
+		GroupId:           groupID,
+		FromUserId:        "Alice",
+		ToUserId:          "Bob",
+		Total:             100,
+		InstallmentAmount: 25,
+	}))
+	if err != nil {
+		t.Fatalf("CreateSettlementPlan failed: %v", err)
+	}
+
+	// Bob paying Alice doesn't count toward Alice repaying Bob.
+	_, err = client.RecordSettlement(ctx, connect.NewRequest(&pb.RecordSettlementRequest{
+		GroupId:    groupID,
+		FromUserId: "Bob",
+		ToUserId:   "Alice",
+		Amount:     25,
+		PlanId:     &planResp.Msg.Plan.Id,
+	}))
+	if connect.CodeOf(err) != connect.CodeInvalidArgument {
+		t.Errorf("expected InvalidArgument for a settlement against the wrong plan, got %v", err)
+	}
+
+	_, err = client.CreateSettlementPlan(ctx, connect.NewRequest(&pb.CreateSettlementPlanRequest{
+		GroupId:           groupID,
+		FromUserId:        "Alice",
+		ToUserId:          "Bob",
+		Total:             100,
+		InstallmentAmount: 0.01,
+	}))
+	if connect.CodeOf(err) != connect.CodeInvalidArgument {
+		t.Errorf("expected InvalidArgument for too many installments, got %v", err)
+	}
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"connectrpc.com/connect"
+	"github.com/mmynk/splitwiser/internal/authctx"
+	"github.com/mmynk/splitwiser/internal/storage"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+	"github.com/mmynk/splitwiser/pkg/proto/protoconnect"
+)
+
+// shareSignature signs the random part of a bill share token.
+func shareSignature(key []byte, id string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(id))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// newShareToken returns a bill share token: a random ID and its signature,
+// so forged tokens are turned away before the database is asked.
+func newShareToken(key []byte) (string, error) {
+	id, err := newStatsToken()
+	if err != nil {
+		return "", err
+	}
+	return id + "." + shareSignature(key, id), nil
+}
+
+// validShareToken reports whether token was signed with key.
+func validShareToken(key []byte, token string) bool {
+	id, signature, ok := strings.Cut(token, ".")
+	return ok && len(key) > 0 && hmac.Equal([]byte(signature), []byte(shareSignature(key, id)))
+}
+
+// shareableBill returns the ID of the bill to share, if userID is on it.
+func (s *SplitService) shareableBill(ctx context.Context, userID, billID string) (string, error) {
+	if s.shareKey == nil {
+		return "", connect.NewError(connect.CodeUnimplemented, fmt.Errorf("share links are not enabled on this server"))
+	}
+	if billID == "" {
+		return "", connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("bill_id required"))
+	}
+	bill, err := s.store.GetBill(ctx, billID)
+	if err != nil {
+		return "", storeError(err)
+	}
+	if !hasAccess(userID, bill) {
+		return "", connect.NewError(connect.CodePermissionDenied, fmt.Errorf("you must be a participant to share this bill"))
+	}
+	return bill.ID, nil
+}
+
+// ShareBill issues a read-only link to a bill for participants without
+// accounts. Any participant may share the bill; sharing it again replaces
+// the earlier link.
+func (s *SplitService) ShareBill(ctx context.Context, req *connect.Request[pb.ShareBillRequest]) (*connect.Response[pb.ShareBillResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+	}
+	billID, err := s.shareableBill(ctx, userID, req.Msg.BillId)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := newShareToken(s.shareKey)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	if err := s.store.SetBillShareToken(ctx, billID, hashStatsToken(token), userID); err != nil {
+		logger.Error("ShareBill failed", "bill_id", billID, "error", err)
+		return nil, storeError(err)
+	}
+
+	logger.Info("Bill shared", "bill_id", billID, "user_id", userID)
+	return connect.NewResponse(&pb.ShareBillResponse{Token: token}), nil
+}
+
+// RevokeBillShare stops a bill's share link from working.
+func (s *SplitService) RevokeBillShare(ctx context.Context, req *connect.Request[pb.RevokeBillShareRequest]) (*connect.Response[pb.RevokeBillShareResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+	}
+	billID, err := s.shareableBill(ctx, userID, req.Msg.BillId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.store.DeleteBillShareToken(ctx, billID); err != nil {
+		logger.Error("RevokeBillShare failed", "bill_id", billID, "error", err)
+		return nil, storeError(err)
+	}
+
+	logger.Info("Bill share revoked", "bill_id", billID, "user_id", userID)
+	return connect.NewResponse(&pb.RevokeBillShareResponse{}), nil
+}
+
+// SharedBillService shows shared bills read-only to anyone holding their
+// link, signed in or not.
+type SharedBillService struct {
+	protoconnect.UnimplementedSharedBillServiceHandler
+	store    storage.Store
+	shareKey []byte
+}
+
+// NewSharedBillService creates a SharedBillService checking links against
+// the key given with WithShareLinks.
+func NewSharedBillService(store storage.Store, opts ...Option) *SharedBillService {
+	o := applyOptions(opts)
+	return &SharedBillService{store: store, shareKey: o.shareKey}
+}
+
+// GetSharedBill returns the bill shared under the request's token. Forged,
+// revoked and replaced tokens, and those of deleted bills, are NotFound.
+func (s *SharedBillService) GetSharedBill(ctx context.Context, req *connect.Request[pb.GetSharedBillRequest]) (*connect.Response[pb.GetSharedBillResponse], error) {
+	if req.Msg.Token == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("token required"))
+	}
+	notFound := connect.NewError(connect.CodeNotFound, fmt.Errorf("no bill is shared under this token"))
+	if !validShareToken(s.shareKey, req.Msg.Token) {
+		return nil, notFound
+	}
+
+	billID, err := s.store.GetBillIDByShareToken(ctx, hashStatsToken(req.Msg.Token))
+	if err != nil {
+		logger.Error("GetSharedBill token lookup failed", "error", err)
+		return nil, storeError(err)
+	}
+	if billID == "" {
+		return nil, notFound
+	}
+	bill, err := s.store.GetBill(ctx, billID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, notFound
+	}
+	if err != nil {
+		logger.Error("GetSharedBill failed to get bill", "bill_id", billID, "error", err)
+		return nil, storeError(err)
+	}
+
+	resp := &pb.GetSharedBillResponse{
+		Title:     bill.Title,
+		Currency:  bill.Currency,
+		Total:     bill.Total,
+		Subtotal:  bill.Subtotal,
+		Tip:       bill.Tip,
+		Payer:     bill.PayerID,
+		Items:     modelToPbItems(bill.Items),
+		CreatedAt: bill.CreatedAt,
+	}
+	for _, p := range bill.Participants {
+		resp.Participants = append(resp.Participants, p.DisplayName)
+	}
+	// Importers' raw values stay private to the bill's participants.
+	for _, item := range resp.Items {
+		item.Origin, item.Edited = nil, false
+	}
+	if !bill.NeedsAssignment {
+		resp.Split, err = billSplit(bill, false)
+		if err != nil {
+			logger.Error("CalculateSplit failed during GetSharedBill", "bill_id", bill.ID, "error", err)
+			return nil, connect.NewError(connect.CodeInternal, err)
+		}
+	}
+	if userID := authctx.UserID(ctx); userID != "" && hasAccess(userID, bill) {
+		resp.BillId = &bill.ID
+	}
+	return connect.NewResponse(resp), nil
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/mmynk/splitwiser/internal/authctx"
+	"github.com/mmynk/splitwiser/internal/models"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+	"github.com/mmynk/splitwiser/pkg/splitmath"
+)
+
+// paymentStatus rolls up who has paid their share of a bill, given its
+// split and the participants marked as paid. The payer's own share counts
+// as paid. Bills awaiting assignment have no status.
+func paymentStatus(bill *models.Bill, split *pb.CalculateSplitResponse, paid map[string]bool) *pb.PaymentStatus {
+	if bill.NeedsAssignment {
+		return nil
+	}
+	status := &pb.PaymentStatus{}
+	var outstanding float64
+	for _, p := range bill.Participants {
+		switch {
+		case p.DisplayName == bill.PayerID:
+		case paid[p.DisplayName]:
+			status.Paid = append(status.Paid, p.DisplayName)
+		default:
+			status.Outstanding = append(status.Outstanding, p.DisplayName)
+			outstanding += split.GetSplits()[p.DisplayName].GetTotal()
+		}
+	}
+	status.OutstandingAmount = splitmath.RoundAmount(outstanding, bill.Currency)
+	status.Settled = len(status.Outstanding) == 0
+	return status
+}
+
+// paymentStatuses rolls up the payment status of each of bills, by bill ID,
+// with one lookup of the shares marked as paid. Bills whose split fails are
+// left out.
+func (s *SplitService) paymentStatuses(ctx context.Context, bills []*models.Bill) (map[string]*pb.PaymentStatus, error) {
+	var ids []string
+	for _, bill := range bills {
+		if !bill.NeedsAssignment {
+			ids = append(ids, bill.ID)
+		}
+	}
+	payments, err := s.store.ListSharePayments(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	paid := make(map[string]map[string]bool)
+	for _, p := range payments {
+		if paid[p.BillID] == nil {
+			paid[p.BillID] = make(map[string]bool)
+		}
+		paid[p.BillID][p.Participant] = true
+	}
+
+	statuses := make(map[string]*pb.PaymentStatus, len(ids))
+	for _, bill := range bills {
+		if bill.NeedsAssignment {
+			continue
+		}
+		split, err := billSplit(bill, false)
+		if err != nil {
+			logger.Error("CalculateSplit failed for payment status", "bill_id", bill.ID, "error", err)
+			continue
+		}
+		statuses[bill.ID] = paymentStatus(bill, split, paid[bill.ID])
+	}
+	return statuses, nil
+}
+
+// summaryPaymentStatuses is paymentStatuses for a listing of bills, unless
+// mask leaves bills.payment_status out. A listing is still served if the
+// payments can't be looked up, without statuses.
+func (s *SplitService) summaryPaymentStatuses(ctx context.Context, bills []*models.Bill, mask maskTree) map[string]*pb.PaymentStatus {
+	if !mask.has("bills", "payment_status") {
+		return nil
+	}
+	statuses, err := s.paymentStatuses(ctx, bills)
+	if err != nil {
+		logger.Error("Failed to list share payments for bill summaries", "error", err)
+	}
+	return statuses
+}
+
+// shareToMark checks that userID may mark participant's share of a bill and
+// returns the bill.
+func (s *SplitService) shareToMark(ctx context.Context, userID, billID, participant string) (*models.Bill, error) {
+	if billID == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("bill_id required"))
+	}
+	if participant == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("participant required"))
+	}
+	bill, err := s.store.GetBill(ctx, billID)
+	if err != nil {
+		return nil, storeError(err)
+	}
+	if !hasAccess(userID, bill) {
+		return nil, connect.NewError(connect.CodePermissionDenied, fmt.Errorf("you must be a participant to update this bill"))
+	}
+	if bill.NeedsAssignment {
+		return nil, connect.NewError(connect.CodeFailedPrecondition, fmt.Errorf("bill is awaiting assignment"))
+	}
+	if !slices.ContainsFunc(bill.Participants, func(p models.BillParticipant) bool { return p.DisplayName == participant }) {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("%q is not on this bill", participant))
+	}
+	if participant == bill.PayerID {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("%q paid the bill and owes nothing on it", participant))
+	}
+	return bill, nil
+}
+
+// MarkShareAsPaid records that a participant has paid the payer back their
+// share. Anyone on the bill may mark any share. Marking the last outstanding
+// share locks the bill, so what's been paid can't change under anyone.
+func (s *SplitService) MarkShareAsPaid(ctx context.Context, req *connect.Request[pb.MarkShareAsPaidRequest]) (*connect.Response[pb.MarkShareAsPaidResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+	}
+	bill, err := s.shareToMark(ctx, userID, req.Msg.BillId, req.Msg.Participant)
+	if err != nil {
+		return nil, err
+	}
+
+	payment := &models.SharePayment{BillID: bill.ID, Participant: req.Msg.Participant, MarkedBy: userID}
+	if err := s.store.MarkShareAsPaid(ctx, payment); err != nil {
+		logger.Error("MarkShareAsPaid failed", "bill_id", bill.ID, "error", err)
+		return nil, storeError(err)
+	}
+
+	statuses, err := s.paymentStatuses(ctx, []*models.Bill{bill})
+	if err != nil {
+		logger.Error("MarkShareAsPaid failed to roll up payments", "bill_id", bill.ID, "error", err)
+		return nil, storeError(err)
+	}
+	resp := &pb.MarkShareAsPaidResponse{PaymentStatus: statuses[bill.ID]}
+	if resp.PaymentStatus.GetSettled() && bill.LockedAt == 0 {
+		// The payment is recorded either way; the lock can be applied by hand.
+		if err := s.store.SetBillLocked(ctx, bill.ID, time.Now().Unix()); err != nil {
+			logger.Error("MarkShareAsPaid failed to lock settled bill", "bill_id", bill.ID, "error", err)
+		} else {
+			resp.Locked = true
+		}
+	}
+	return connect.NewResponse(resp), nil
+}
+
+// UnmarkShareAsPaid records that a participant's share is outstanding again,
+// e.g. after marking the wrong person. A locked bill must be unlocked first.
+func (s *SplitService) UnmarkShareAsPaid(ctx context.Context, req *connect.Request[pb.UnmarkShareAsPaidRequest]) (*connect.Response[pb.UnmarkShareAsPaidResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+	}
+	bill, err := s.shareToMark(ctx, userID, req.Msg.BillId, req.Msg.Participant)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkUnlocked(bill); err != nil {
+		return nil, err
+	}
+
+	if err := s.store.UnmarkShareAsPaid(ctx, bill.ID, req.Msg.Participant); err != nil {
+		logger.Error("UnmarkShareAsPaid failed", "bill_id", bill.ID, "error", err)
+		return nil, storeError(err)
+	}
+
+	statuses, err := s.paymentStatuses(ctx, []*models.Bill{bill})
+	if err != nil {
+		logger.Error("UnmarkShareAsPaid failed to roll up payments", "bill_id", bill.ID, "error", err)
+		return nil, storeError(err)
+	}
+	return connect.NewResponse(&pb.UnmarkShareAsPaidResponse{PaymentStatus: statuses[bill.ID]}), nil
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"connectrpc.com/connect"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+)
+
+func TestMarkShareAsPaid(t *testing.T) {
+	splitClient, cleanup := setupTestServer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	created, err := splitClient.CreateBill(ctx, connect.NewRequest(&pb.CreateBillRequest{
+		Title:        "Cabin",
+		Total:        90,
+		Subtotal:     90,
+		PayerId:      strPtr("Alice"),
+		Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob"), guestBP("Carol")},
+	}))
+	if err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+	billID := created.Msg.BillId
+
+	marked, err := splitClient.MarkShareAsPaid(ctx, connect.NewRequest(&pb.MarkShareAsPaidRequest{BillId: billID, Participant: "Bob"}))
+	if err != nil {
+		t.Fatalf("MarkShareAsPaid failed: %v", err)
+	}
+	status := marked.Msg.PaymentStatus
+	if !slices.Equal(status.Paid, []string{"Bob"}) || !slices.Equal(status.Outstanding, []string{"Carol"}) || status.OutstandingAmount != 30 || status.Settled {
+		t.Errorf("status after Bob paid = %v, want Carol owing 30", status)
+	}
+
+	settled, err := splitClient.MarkShareAsPaid(ctx, connect.NewRequest(&pb.MarkShareAsPaidRequest{BillId: billID, Participant: "Carol"}))
+	if err != nil {
+		t.Fatalf("MarkShareAsPaid failed: %v", err)
+	}
+	if !settled.Msg.Locked {
+		t.Error("paying the last share should lock the bill")
+	}
+	got, err := splitClient.GetBill(ctx, connect.NewRequest(&pb.GetBillRequest{BillId: billID}))
+	if err != nil {
+		t.Fatalf("GetBill failed: %v", err)
+	}
+	if status := got.Msg.PaymentStatus; !status.Settled || status.OutstandingAmount != 0 || len(status.Paid) != 2 {
+		t.Errorf("GetBill status = %v, want settled", status)
+	}
+
+	list, err := splitClient.ListMyBills(ctx, connect.NewRequest(&pb.ListMyBillsRequest{}))
+	if err != nil {
+		t.Fatalf("ListMyBills failed: %v", err)
+	}
+	if len(list.Msg.Bills) != 1 || !list.Msg.Bills[0].PaymentStatus.GetSettled() {
+		t.Errorf("ListMyBills = %v, want the bill as settled", list.Msg.Bills)
+	}
+
+	// The settled bill is locked until Alice, its payer, unlocks it.
+	_, err = splitClient.UnmarkShareAsPaid(ctx, connect.NewRequest(&pb.UnmarkShareAsPaidRequest{BillId: billID, Participant: "Carol"}))
+	if connect.CodeOf(err) != connect.CodeFailedPrecondition {
+		t.Errorf("unmarking a share of a locked bill: expected FailedPrecondition, got %v", err)
+	}
+	if _, err := splitClient.UnlockBill(ctx, connect.NewRequest(&pb.UnlockBillRequest{BillId: billID})); err != nil {
+		t.Fatalf("UnlockBill failed: %v", err)
+	}
+	unmarked, err := splitClient.UnmarkShareAsPaid(ctx, connect.NewRequest(&pb.UnmarkShareAsPaidRequest{BillId: billID, Participant: "Carol"}))
+	if err != nil {
+		t.Fatalf("UnmarkShareAsPaid failed: %v", err)
+	}
+	if status := unmarked.Msg.PaymentStatus; status.Settled || !slices.Equal(status.Outstanding, []string{"Carol"}) {
+		t.Errorf("status after unmarking Carol = %v, want Carol outstanding", status)
+	}
+
+	for _, participant := range []string{"", "Dave", "Alice"} {
+		_, err := splitClient.MarkShareAsPaid(ctx, connect.NewRequest(&pb.MarkShareAsPaidRequest{BillId: billID, Participant: participant}))
+		if connect.CodeOf(err) != connect.CodeInvalidArgument {
+			t.Errorf("MarkShareAsPaid(%q): expected InvalidArgument, got %v", participant, err)
+		}
+	}
+}
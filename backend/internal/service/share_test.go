@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/mmynk/splitwiser/internal/models"
+	"github.com/mmynk/splitwiser/internal/storage/sqlite"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+	"github.com/mmynk/splitwiser/pkg/proto/protoconnect"
+)
+
+// setupShareServer serves SplitService as Alice with share links enabled,
+// and SharedBillService both to anonymous callers and to Alice, all on one
+// store.
+func setupShareServer(t *testing.T) (protoconnect.SplitServiceClient, protoconnect.SharedBillServiceClient, protoconnect.SharedBillServiceClient) {
+	t.Helper()
+
+	store, err := sqlite.New(t.TempDir() + "/test.db")
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	if err := store.CreateUser(context.Background(), &models.User{
+		ID:           testUserID,
+		Email:        "alice@example.com",
+		DisplayName:  "Alice",
+		PasswordHash: "hash",
+		CreatedAt:    time.Now().Unix(),
+		UpdatedAt:    time.Now().Unix(),
+	}); err != nil {
+		t.Fatalf("failed to create test user: %v", err)
+	}
+
+	shareLinks := WithShareLinks([]byte("test share key"))
+	authInterceptor := connect.WithInterceptors(testAuthInterceptor())
+
+	mux := http.NewServeMux()
+	mux.Handle(protoconnect.NewSplitServiceHandler(NewSplitService(store, shareLinks), authInterceptor))
+	mux.Handle(protoconnect.NewSharedBillServiceHandler(NewSharedBillService(store, shareLinks), authInterceptor))
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	anonymous := http.NewServeMux()
+	anonymous.Handle(protoconnect.NewSharedBillServiceHandler(NewSharedBillService(store, shareLinks)))
+	anonymousServer := httptest.NewServer(anonymous)
+	t.Cleanup(anonymousServer.Close)
+
+	return protoconnect.NewSplitServiceClient(http.DefaultClient, server.URL),
+		protoconnect.NewSharedBillServiceClient(http.DefaultClient, anonymousServer.URL),
+		protoconnect.NewSharedBillServiceClient(http.DefaultClient, server.URL)
+}
+
+func TestShareBill(t *testing.T) {
+	splitClient, sharedClient, aliceSharedClient := setupShareServer(t)
+	ctx := context.Background()
+
+	created, err := splitClient.CreateBill(ctx, connect.NewRequest(&pb.CreateBillRequest{
+		Title:    "Dinner",
+		Total:    60,
+		Subtotal: 60,
+		Items: []*pb.Item{
+			{Description: "Pasta", Amount: 20, ParticipantIds: []string{"Alice"}},
+			{Description: "Steak", Amount: 40, ParticipantIds: []string{"Bob"}},
+		},
+		PayerId:      strPtr("Alice"),
+		Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+	}))
+	if err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+	billID := created.Msg.BillId
+
+	shared, err := splitClient.ShareBill(ctx, connect.NewRequest(&pb.ShareBillRequest{BillId: billID}))
+	if err != nil {
+		t.Fatalf("ShareBill failed: %v", err)
+	}
+	token := shared.Msg.Token
+
+	got, err := sharedClient.GetSharedBill(ctx, connect.NewRequest(&pb.GetSharedBillRequest{Token: token}))
+	if err != nil {
+		t.Fatalf("GetSharedBill failed: %v", err)
+	}
+	if got.Msg.Title != "Dinner" || got.Msg.Payer != "Alice" || len(got.Msg.Participants) != 2 || len(got.Msg.Items) != 2 {
+		t.Errorf("GetSharedBill = %v, want the dinner paid by Alice", got.Msg)
+	}
+	if bob := got.Msg.Split.GetSplits()["Bob"]; bob.GetTotal() != 40 {
+		t.Errorf("Bob's share = %v, want 40", bob.GetTotal())
+	}
+	if got.Msg.BillId != nil {
+		t.Errorf("anonymous caller got bill ID %q, want none", got.Msg.GetBillId())
+	}
+
+	signedIn, err := aliceSharedClient.GetSharedBill(ctx, connect.NewRequest(&pb.GetSharedBillRequest{Token: token}))
+	if err != nil {
+		t.Fatalf("GetSharedBill as Alice failed: %v", err)
+	}
+	if signedIn.Msg.GetBillId() != billID {
+		t.Errorf("signed-in participant got bill ID %q, want %q", signedIn.Msg.GetBillId(), billID)
+	}
+
+	// A forged signature is turned away like an unknown token.
+	id, _, _ := strings.Cut(token, ".")
+	for _, bad := range []string{id, id + ".00", "not-a-token"} {
+		_, err := sharedClient.GetSharedBill(ctx, connect.NewRequest(&pb.GetSharedBillRequest{Token: bad}))
+		if connect.CodeOf(err) != connect.CodeNotFound {
+			t.Errorf("GetSharedBill(%q): expected NotFound, got %v", bad, err)
+		}
+	}
+
+	// Sharing again replaces the link.
+	reshared, err := splitClient.ShareBill(ctx, connect.NewRequest(&pb.ShareBillRequest{BillId: billID}))
+	if err != nil {
+		t.Fatalf("ShareBill failed: %v", err)
+	}
+	_, err = sharedClient.GetSharedBill(ctx, connect.NewRequest(&pb.GetSharedBillRequest{Token: token}))
+	if connect.CodeOf(err) != connect.CodeNotFound {
+		t.Errorf("expected NotFound for a replaced link, got %v", err)
+	}
+
+	if _, err := splitClient.RevokeBillShare(ctx, connect.NewRequest(&pb.RevokeBillShareRequest{BillId: billID})); err != nil {
+		t.Fatalf("RevokeBillShare failed: %v", err)
+	}
+	_, err = sharedClient.GetSharedBill(ctx, connect.NewRequest(&pb.GetSharedBillRequest{Token: reshared.Msg.Token}))
+	if connect.CodeOf(err) != connect.CodeNotFound {
+		t.Errorf("expected NotFound for a revoked link, got %v", err)
+	}
+}
+
+func TestShareBill_NotEnabled(t *testing.T) {
+	splitClient, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	_, err := splitClient.ShareBill(context.Background(), connect.NewRequest(&pb.ShareBillRequest{BillId: "any"}))
+	if connect.CodeOf(err) != connect.CodeUnimplemented {
+		t.Errorf("expected Unimplemented without a share key, got %v", err)
+	}
+}
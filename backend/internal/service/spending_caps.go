@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/mmynk/splitwiser/internal/authctx"
+	"github.com/mmynk/splitwiser/internal/models"
+	"github.com/mmynk/splitwiser/internal/storage"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+	"github.com/mmynk/splitwiser/pkg/splitmath"
+)
+
+// monthLayout formats calendar months as used by spending caps and stats.
+const monthLayout = "2006-01"
+
+// billMonth returns the calendar month (UTC) a bill created at createdAt falls in.
+func billMonth(createdAt int64) string {
+	return time.Unix(createdAt, 0).UTC().Format(monthLayout)
+}
+
+// validateSpendingCaps rejects negative caps and caps for people who aren't members.
+func validateSpendingCaps(caps map[string]float64, members []models.GroupMember) error {
+	for member, amount := range caps {
+		if amount < 0 {
+			return fmt.Errorf("spending cap for %s cannot be negative", member)
+		}
+		if !isMemberByName(member, members) {
+			return fmt.Errorf("spending cap for %s, who is not a group member", member)
+		}
+	}
+	return nil
+}
+
+// groupMonthSpend sums each person's share of the group's bills created in
+// month (YYYY-MM, UTC). Bills awaiting assignment are left out.
+func groupMonthSpend(ctx context.Context, store storage.Store, groupID, month string) (map[string]float64, error) {
+	bills, err := store.ListBillsByGroup(ctx, groupID, storage.BillFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("could not list bills: %w", err)
+	}
+
+	spend := make(map[string]float64)
+	for _, bill := range bills {
+		if bill.NeedsAssignment || billMonth(bill.CreatedAt) != month {
+			continue
+		}
+		split, err := billSplit(bill, false)
+		if err != nil {
+			logger.Warn("Skipping bill in monthly spend", "bill_id", bill.ID, "error", err)
+			continue
+		}
+		for person, ps := range split.Splits {
+			spend[person] += ps.Total
+		}
+	}
+	for person, amount := range spend {
+		spend[person] = splitmath.RoundAmount(amount, "")
+	}
+	return spend, nil
+}
+
+// checkSpendingCaps records an alert for every capped member a newly created
+// bill pushed over their monthly cap, notifies the group's creator, and
+// returns those members. Failures are only logged since the bill is saved.
+func (s *SplitService) checkSpendingCaps(ctx context.Context, bill *models.Bill, split *pb.CalculateSplitResponse) []string {
+	return s.checkBatchSpendingCaps(ctx, []*models.Bill{bill}, []*pb.CalculateSplitResponse{split})[bill.ID]
+}
+
+// checkBatchSpendingCaps is checkSpendingCaps for bills of one group saved
+// together, such as an import, with splits[i] the split of bills[i]. Each
+// bill is checked as if the ones before it were saved first, so only the
+// bill that crosses a cap raises an alert. It returns the members each bill
+// pushed over their caps, by bill ID.
+func (s *SplitService) checkBatchSpendingCaps(ctx context.Context, bills []*models.Bill, splits []*pb.CalculateSplitResponse) map[string][]string {
+	if len(bills) == 0 || bills[0].GroupID == "" {
+		return nil
+	}
+	group, err := s.store.GetGroup(ctx, bills[0].GroupID)
+	if err != nil {
+		logger.Error("Spending cap check failed - group not found", "group_id", bills[0].GroupID, "error", err)
+		return nil
+	}
+	if len(group.Settings.SpendingCaps) == 0 {
+		return nil
+	}
+
+	month := billMonth(bills[0].CreatedAt)
+	spend, err := groupMonthSpend(ctx, s.store, group.ID, month)
+	if err != nil {
+		logger.Error("Spending cap check failed", "group_id", group.ID, "error", err)
+		return nil
+	}
+	// The month's spend already counts the whole batch; it's taken back out
+	// and added again one bill at a time.
+	counted := func(i int) bool { return splits[i] != nil && billMonth(bills[i].CreatedAt) == month }
+	for i := range bills {
+		if counted(i) {
+			for person, ps := range splits[i].Splits {
+				spend[person] -= ps.Total
+			}
+		}
+	}
+
+	overCap := make(map[string][]string)
+	for i, bill := range bills {
+		if !counted(i) {
+			continue
+		}
+		split := splits[i]
+		for person, ps := range split.Splits {
+			spend[person] += ps.Total
+		}
+
+		var alerts []models.SpendingCapAlert
+		for _, member := range slices.Sorted(maps.Keys(group.Settings.SpendingCaps)) {
+			limit := group.Settings.SpendingCaps[member]
+			share := split.Splits[member].GetTotal()
+			if limit <= 0 || share <= 0 {
+				continue
+			}
+			// Only the bill that crosses the cap raises an alert, not every bill after it.
+			if after := splitmath.RoundAmount(spend[member], ""); after > limit && after-share <= limit {
+				alerts = append(alerts, models.SpendingCapAlert{
+					BillID:  bill.ID,
+					GroupID: group.ID,
+					Member:  member,
+					Month:   month,
+					Spent:   after,
+					Cap:     limit,
+				})
+			}
+		}
+		if len(alerts) == 0 {
+			continue
+		}
+		if err := s.store.CreateSpendingCapAlerts(ctx, alerts); err != nil {
+			logger.Error("Spending cap check failed - could not save alerts", "bill_id", bill.ID, "error", err)
+		}
+		s.notifySpendingCapAlerts(ctx, group, bill, alerts)
+
+		for _, alert := range alerts {
+			overCap[bill.ID] = append(overCap[bill.ID], alert.Member)
+		}
+	}
+	return overCap
+}
+
+// notifySpendingCapAlerts tells the group's creator which members bill pushed over their caps.
+func (s *SplitService) notifySpendingCapAlerts(ctx context.Context, group *models.Group, bill *models.Bill, alerts []models.SpendingCapAlert) {
+	if s.notifier == nil || group.CreatorID == "" {
+		return
+	}
+	users, err := s.store.GetUsersByIDs(ctx, []string{group.CreatorID})
+	if err != nil || users[group.CreatorID] == nil {
+		logger.Error("Spending cap notification failed - group creator not found", "group_id", group.ID, "error", err)
+		return
+	}
+
+	var body strings.Builder
+	for _, alert := range alerts {
+		fmt.Fprintf(&body, "%s has spent %s of their %s monthly cap in %s after %q.\n",
+			alert.Member, splitmath.FormatAmount(alert.Spent, bill.Currency), splitmath.FormatAmount(alert.Cap, bill.Currency), alert.Month, bill.Title)
+	}
+	subject := fmt.Sprintf("Spending cap exceeded in %s", group.Name)
+	if err := s.notifier.Notify(ctx, users[group.CreatorID].Email, subject, body.String()); err != nil {
+		logger.Error("Spending cap notification failed", "group_id", group.ID, "bill_id", bill.ID, "error", err)
+	}
+}
+
+// spendingCapAlertsToProto converts model cap alerts to proto.
+func spendingCapAlertsToProto(alerts []models.SpendingCapAlert) []*pb.SpendingCapAlert {
+	out := make([]*pb.SpendingCapAlert, len(alerts))
+	for i, alert := range alerts {
+		out[i] = &pb.SpendingCapAlert{
+			BillId:    alert.BillID,
+			Member:    alert.Member,
+			Spent:     alert.Spent,
+			Cap:       alert.Cap,
+			CreatedAt: alert.CreatedAt,
+		}
+	}
+	return out
+}
+
+// GetGroupStats reports each member's share of the group's bills in a month
+// against their spending cap, with the cap alerts raised that month.
+func (s *GroupService) GetGroupStats(ctx context.Context, req *connect.Request[pb.GetGroupStatsRequest]) (*connect.Response[pb.GetGroupStatsResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+	}
+
+	groupID := req.Msg.GetGroupId()
+	if err := s.requireMembership(ctx, userID, groupID); err != nil {
+		return nil, err
+	}
+	month := req.Msg.Month
+	if month == "" {
+		month = time.Now().UTC().Format(monthLayout)
+	} else if _, err := time.Parse(monthLayout, month); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("month must be YYYY-MM"))
+	}
+
+	group, err := s.store.GetGroup(ctx, groupID)
+	if err != nil {
+		logger.Error("GetGroupStats failed to get group", "group_id", groupID, "error", err)
+		return nil, storeError(err)
+	}
+	spend, err := groupMonthSpend(ctx, s.store, groupID, month)
+	if err != nil {
+		logger.Error("GetGroupStats failed to compute spend", "group_id", groupID, "error", err)
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	alerts, err := s.store.ListSpendingCapAlerts(ctx, groupID, month)
+	if err != nil {
+		logger.Error("GetGroupStats failed to list alerts", "group_id", groupID, "error", err)
+		return nil, storeError(err)
+	}
+
+	resp := &pb.GetGroupStatsResponse{
+		Month:  month,
+		Alerts: spendingCapAlertsToProto(alerts),
+	}
+	for _, m := range group.Members {
+		limit := group.Settings.SpendingCaps[m.DisplayName]
+		resp.Members = append(resp.Members, &pb.MemberSpend{
+			DisplayName: m.DisplayName,
+			Spent:       spend[m.DisplayName],
+			Cap:         limit,
+			OverCap:     limit > 0 && spend[m.DisplayName] > limit,
+		})
+	}
+	slices.SortFunc(resp.Members, func(a, b *pb.MemberSpend) int {
+		return strings.Compare(a.DisplayName, b.DisplayName)
+	})
+	return connect.NewResponse(resp), nil
+}
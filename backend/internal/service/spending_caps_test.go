@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+)
+
+// recordingNotifier keeps every notice it's asked to send.
+type recordingNotifier struct {
+	mu      sync.Mutex
+	notices []string // "to: subject: body"
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, to, subject, body string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.notices = append(n.notices, to+": "+subject+": "+body)
+	return nil
+}
+
+func TestSpendingCaps(t *testing.T) {
+	notifier := &recordingNotifier{}
+	groupClient, splitClient, cleanup := setupGroupTestServer(t, WithNotifier(notifier))
+	defer cleanup()
+	ctx := context.Background()
+
+	groupResp, err := groupClient.CreateGroup(ctx, connect.NewRequest(&pb.CreateGroupRequest{
+		Name:     "Kids",
+		Members:  gm("Alice", "Bob"),
+		Settings: &pb.GroupSettings{SpendingCaps: map[string]float64{"Bob": 50}},
+	}))
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	groupID := groupResp.Msg.Group.Id
+
+	// Bob's half of each bill: 30, then 50 (at the cap), then 55, then 60.
+	payer := "Alice"
+	var overBillID string
+	for i, total := range []float64{60, 40, 10, 10} {
+		resp, err := splitClient.CreateBill(ctx, connect.NewRequest(&pb.CreateBillRequest{
+			Title:        "Shoes",
+			Total:        total,
+			Subtotal:     total,
+			Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+			GroupId:      &groupID,
+			PayerId:      &payer,
+		}))
+		if err != nil {
+			t.Fatalf("CreateBill %d failed: %v", i, err)
+		}
+		want := []string(nil)
+		if i == 2 {
+			want = []string{"Bob"}
+			overBillID = resp.Msg.BillId
+		}
+		if !reflect.DeepEqual(resp.Msg.OverCapMembers, want) {
+			t.Errorf("bill %d over cap = %v, want %v", i, resp.Msg.OverCapMembers, want)
+		}
+	}
+
+	if len(notifier.notices) != 1 || !strings.HasPrefix(notifier.notices[0], "alice@example.com: ") {
+		t.Errorf("notices = %q, want one to the group creator", notifier.notices)
+	}
+
+	billResp, err := splitClient.GetBill(ctx, connect.NewRequest(&pb.GetBillRequest{BillId: overBillID}))
+	if err != nil {
+		t.Fatalf("GetBill failed: %v", err)
+	}
+	if !reflect.DeepEqual(billResp.Msg.OverCapMembers, []string{"Bob"}) {
+		t.Errorf("GetBill over cap = %v, want [Bob]", billResp.Msg.OverCapMembers)
+	}
+
+	statsResp, err := groupClient.GetGroupStats(ctx, connect.NewRequest(&pb.GetGroupStatsRequest{GroupId: groupID}))
+	if err != nil {
+		t.Fatalf("GetGroupStats failed: %v", err)
+	}
+	stats := statsResp.Msg
+	if stats.Month != time.Now().UTC().Format("2006-01") {
+		t.Errorf("month = %q, want the current month", stats.Month)
+	}
+	if len(stats.Members) != 2 {
+		t.Fatalf("got %d members, want 2", len(stats.Members))
+	}
+	if bob := stats.Members[1]; bob.DisplayName != "Bob" || bob.Spent != 60 || bob.Cap != 50 || !bob.OverCap {
+		t.Errorf("Bob = %+v, want 60 spent over a 50 cap", bob)
+	}
+	if alice := stats.Members[0]; alice.Cap != 0 || alice.OverCap {
+		t.Errorf("Alice = %+v, want no cap", alice)
+	}
+	if len(stats.Alerts) != 1 || stats.Alerts[0].BillId != overBillID || stats.Alerts[0].Spent != 55 {
+		t.Errorf("alerts = %v, want one for the bill that crossed the cap", stats.Alerts)
+	}
+
+	lastMonth, err := groupClient.GetGroupStats(ctx, connect.NewRequest(&pb.GetGroupStatsRequest{GroupId: groupID, Month: "2001-01"}))
+	if err != nil {
+		t.Fatalf("GetGroupStats failed: %v", err)
+	}
+	if lastMonth.Msg.Members[1].Spent != 0 || len(lastMonth.Msg.Alerts) != 0 {
+		t.Errorf("stats for another month = %v, want nothing spent", lastMonth.Msg)
+	}
+}
+
+func TestSpendingCaps_Validation(t *testing.T) {
+	groupClient, _, cleanup := setupGroupTestServer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	_, err := groupClient.CreateGroup(ctx, connect.NewRequest(&pb.CreateGroupRequest{
+		Name:     "Kids",
+		Members:  gm("Alice"),
+		Settings: &pb.GroupSettings{SpendingCaps: map[string]float64{"Zed": 50}},
+	}))
+	if connect.CodeOf(err) != connect.CodeInvalidArgument {
+		t.Errorf("cap for a non-member: code = %v, want InvalidArgument", connect.CodeOf(err))
+	}
+
+	groupResp, err := groupClient.CreateGroup(ctx, connect.NewRequest(&pb.CreateGroupRequest{Name: "Kids", Members: gm("Alice")}))
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	_, err = groupClient.GetGroupStats(ctx, connect.NewRequest(&pb.GetGroupStatsRequest{GroupId: groupResp.Msg.Group.Id, Month: "March"}))
+	if connect.CodeOf(err) != connect.CodeInvalidArgument {
+		t.Errorf("bad month: code = %v, want InvalidArgument", connect.CodeOf(err))
+	}
+}
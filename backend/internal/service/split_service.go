@@ -3,26 +3,59 @@ package service
 import (
 	"context"
 	"fmt"
-	"log/slog"
+	"slices"
+	"strings"
+	"time"
 
 	"connectrpc.com/connect"
-	"github.com/mmynk/splitwiser/internal/calculator"
-	"github.com/mmynk/splitwiser/internal/middleware"
+	"github.com/mmynk/splitwiser/internal/authctx"
+	"github.com/mmynk/splitwiser/internal/blob"
+	"github.com/mmynk/splitwiser/internal/fxrate"
 	"github.com/mmynk/splitwiser/internal/models"
+	"github.com/mmynk/splitwiser/internal/notify"
+	"github.com/mmynk/splitwiser/internal/numwords"
+	"github.com/mmynk/splitwiser/internal/ocr"
+	"github.com/mmynk/splitwiser/internal/quota"
 	"github.com/mmynk/splitwiser/internal/storage"
+	"github.com/mmynk/splitwiser/internal/taxreport"
+	"github.com/mmynk/splitwiser/internal/webhook"
 	pb "github.com/mmynk/splitwiser/pkg/proto"
 	"github.com/mmynk/splitwiser/pkg/proto/protoconnect"
+	"github.com/mmynk/splitwiser/pkg/splitmath"
 )
 
 // SplitService implements the Connect SplitService
 type SplitService struct {
 	protoconnect.UnimplementedSplitServiceHandler
-	store storage.Store
+	store    storage.Store
+	quotas   *quota.Enforcer
+	webhooks *webhook.Dispatcher
+	notifier notify.Notifier
+	rates    fxrate.Source
+	ocr      ocr.Provider
+	shareKey []byte
+
+	blobs              blob.Store
+	maxAttachmentBytes int64
+
+	taxLines taxreport.Lines
 }
 
 // NewSplitService creates a new SplitService with the given storage backend.
-func NewSplitService(store storage.Store) *SplitService {
-	return &SplitService{store: store}
+func NewSplitService(store storage.Store, opts ...Option) *SplitService {
+	o := applyOptions(opts)
+	return &SplitService{
+		store:              store,
+		quotas:             o.quotas,
+		webhooks:           o.webhooks,
+		notifier:           o.notifier,
+		rates:              o.rates,
+		ocr:                o.ocr,
+		shareKey:           o.shareKey,
+		blobs:              o.blobs,
+		maxAttachmentBytes: o.maxAttachmentBytes,
+		taxLines:           o.taxLines,
+	}
 }
 
 // validatePayerID checks if the payer is one of the participant display names.
@@ -69,6 +102,10 @@ func pbToModelParticipants(pbParticipants []*pb.BillParticipant) []models.BillPa
 		result[i] = models.BillParticipant{
 			DisplayName: p.DisplayName,
 			UserID:      p.GetUserId(),
+			Shares:      p.Shares,
+			Amount:      p.Amount,
+			Covered:     coverModeFromProto(p.Covered),
+			Adjustment:  p.Adjustment,
 		}
 	}
 	return result
@@ -78,7 +115,7 @@ func pbToModelParticipants(pbParticipants []*pb.BillParticipant) []models.BillPa
 func modelToPbParticipants(participants []models.BillParticipant) []*pb.BillParticipant {
 	result := make([]*pb.BillParticipant, len(participants))
 	for i, p := range participants {
-		pbp := &pb.BillParticipant{DisplayName: p.DisplayName}
+		pbp := &pb.BillParticipant{DisplayName: p.DisplayName, Shares: p.Shares, Amount: p.Amount, Covered: coverModeToProto(p.Covered), Adjustment: p.Adjustment}
 		if p.UserID != "" {
 			uid := p.UserID
 			pbp.UserId = &uid
@@ -106,6 +143,14 @@ func findNewParticipants(participants []models.BillParticipant, existingMembers
 	return newOnes
 }
 
+// withPayer returns participants with the payer added if not already listed.
+func withPayer(participants []models.BillParticipant, payerID string) []models.BillParticipant {
+	if payerID == "" || slices.ContainsFunc(participants, func(p models.BillParticipant) bool { return p.DisplayName == payerID }) {
+		return participants
+	}
+	return append(slices.Clip(participants), models.BillParticipant{DisplayName: payerID})
+}
+
 // autoAddParticipantsToGroup adds any bill participants (and payer) not already in the group.
 func (s *SplitService) autoAddParticipantsToGroup(ctx context.Context, groupID string, participants []models.BillParticipant, payerID string) {
 	if groupID == "" {
@@ -113,59 +158,572 @@ func (s *SplitService) autoAddParticipantsToGroup(ctx context.Context, groupID s
 	}
 	group, err := s.store.GetGroup(ctx, groupID)
 	if err != nil {
-		slog.Warn("autoAddParticipantsToGroup: failed to get group", "group_id", groupID, "error", err)
+		logger.Warn("autoAddParticipantsToGroup: failed to get group", "group_id", groupID, "error", err)
+		return
+	}
+
+	newMembers := findNewParticipants(withPayer(participants, payerID), group.Members)
+	if len(newMembers) == 0 {
 		return
 	}
 
-	// Include payer as a participant if not already listed
-	allParticipants := participants
-	payerIsParticipant := false
+	if err := s.store.AddGroupMembersWithIDs(ctx, groupID, newMembers); err != nil {
+		logger.Error("autoAddParticipantsToGroup: failed to add members", "group_id", groupID, "error", err)
+		return
+	}
+	logger.Info("Auto-added participants to group", "group_id", groupID, "count", len(newMembers))
+}
+
+// checkTitleRequired rejects an empty title when the bill's group has auto-titles disabled.
+func (s *SplitService) checkTitleRequired(ctx context.Context, groupID, title string) error {
+	if groupID == "" || strings.TrimSpace(title) != "" {
+		return nil
+	}
+	group, err := s.store.GetGroup(ctx, groupID)
+	if err != nil {
+		return storeError(err)
+	}
+	if group.Settings.DisableAutoTitle {
+		return connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("group '%s' requires an explicit bill title", group.Name))
+	}
+	return nil
+}
+
+// defaultPayer returns the creator's display name when the bill's group makes
+// the creator the default payer and the creator is one of the participants.
+func (s *SplitService) defaultPayer(ctx context.Context, groupID, userID string, participants []models.BillParticipant) (string, error) {
+	if groupID == "" {
+		return "", nil
+	}
+	group, err := s.store.GetGroup(ctx, groupID)
+	if err != nil {
+		return "", storeError(err)
+	}
+	if !group.Settings.DefaultPayerIsCreator {
+		return "", nil
+	}
 	for _, p := range participants {
-		if p.DisplayName == payerID {
-			payerIsParticipant = true
-			break
+		if p.UserID == userID {
+			return p.DisplayName, nil
 		}
 	}
-	if payerID != "" && !payerIsParticipant {
-		allParticipants = append(allParticipants, models.BillParticipant{DisplayName: payerID})
+	return "", nil
+}
+
+// groupRoundingMode returns the rounding mode the bill's group sets for new bills.
+func (s *SplitService) groupRoundingMode(ctx context.Context, groupID string) (models.RoundingMode, error) {
+	if groupID == "" {
+		return "", nil
+	}
+	group, err := s.store.GetGroup(ctx, groupID)
+	if err != nil {
+		return "", storeError(err)
 	}
+	return group.Settings.RoundingMode, nil
+}
 
-	newMembers := findNewParticipants(allParticipants, group.Members)
-	if len(newMembers) == 0 {
-		return
+// pbToModelItems converts proto Items to model Items. An unset amount is
+// derived from quantity and unit price.
+func pbToModelItems(pbItems []*pb.Item) []models.Item {
+	items := make([]models.Item, len(pbItems))
+	for i, item := range pbItems {
+		amount := item.Amount
+		if amount == 0 && item.Quantity > 0 {
+			amount = item.Quantity * item.UnitPrice
+		}
+		items[i] = models.Item{
+			Description:  item.Description,
+			Amount:       amount,
+			Participants: item.ParticipantIds,
+			Shares:       item.Shares,
+			Discount:     item.Discount,
+			Quantity:     item.Quantity,
+			UnitPrice:    item.UnitPrice,
+			Units:        item.Units,
+			Category:     itemCategoryFromProto(item.Category),
+			Owner:        item.Owner,
+			Origin:       itemOriginFromProto(item.Origin),
+		}
 	}
+	return items
+}
 
-	if err := s.store.AddGroupMembersWithIDs(ctx, groupID, newMembers); err != nil {
-		slog.Error("autoAddParticipantsToGroup: failed to add members", "group_id", groupID, "error", err)
-		return
+// modelToPbItems converts model Items to proto Items.
+func modelToPbItems(items []models.Item) []*pb.Item {
+	result := make([]*pb.Item, len(items))
+	for i, item := range items {
+		result[i] = &pb.Item{
+			Description:    item.Description,
+			Amount:         item.Amount,
+			ParticipantIds: item.Participants,
+			Shares:         item.Shares,
+			Discount:       item.Discount,
+			Quantity:       item.Quantity,
+			UnitPrice:      item.UnitPrice,
+			Units:          item.Units,
+			Category:       itemCategoryToProto(item.Category),
+			Owner:          item.Owner,
+			Origin:         itemOriginToProto(item.Origin),
+			Edited:         item.Edited(),
+		}
 	}
-	slog.Info("Auto-added participants to group", "group_id", groupID, "count", len(newMembers))
+	return result
 }
 
-// CalculateSplit handles bill split calculation
-func (s *SplitService) CalculateSplit(ctx context.Context, req *connect.Request[pb.CalculateSplitRequest]) (*connect.Response[pb.CalculateSplitResponse], error) {
-	items := make([]calculator.Item, len(req.Msg.Items))
-	for i, item := range req.Msg.Items {
-		slog.Debug("Processing item",
-			"index", i+1,
-			"description", item.Description,
-			"amount", item.Amount,
-			"participants", item.ParticipantIds,
-		)
-		items[i] = calculator.Item{
+// itemOriginFromProto converts a proto ItemOrigin to the model value.
+func itemOriginFromProto(origin *pb.ItemOrigin) *models.ItemOrigin {
+	if origin == nil {
+		return nil
+	}
+	return &models.ItemOrigin{
+		Source:      origin.Source,
+		Description: origin.Description,
+		Amount:      origin.Amount,
+		Quantity:    origin.Quantity,
+		UnitPrice:   origin.UnitPrice,
+	}
+}
+
+// itemOriginToProto converts a model ItemOrigin to the proto message.
+func itemOriginToProto(origin *models.ItemOrigin) *pb.ItemOrigin {
+	if origin == nil {
+		return nil
+	}
+	return &pb.ItemOrigin{
+		Source:      origin.Source,
+		Description: origin.Description,
+		Amount:      origin.Amount,
+		Quantity:    origin.Quantity,
+		UnitPrice:   origin.UnitPrice,
+	}
+}
+
+// validateItemOrigins checks that items only carry origins they may: on a new
+// bill (existing nil) any origin naming its source, on an update only the
+// existing bill's item origins, each kept by at most one item. An origin
+// records what was parsed, so edits can't rewrite it.
+func validateItemOrigins(items []models.Item, existing *models.Bill) error {
+	var unused []*models.ItemOrigin
+	if existing != nil {
+		for _, item := range existing.Items {
+			if item.Origin != nil {
+				unused = append(unused, item.Origin)
+			}
+		}
+	}
+	for _, item := range items {
+		if item.Origin == nil {
+			continue
+		}
+		if item.Origin.Source == "" {
+			return fmt.Errorf("item %q: origin source required", item.Description)
+		}
+		if existing == nil {
+			continue
+		}
+		i := slices.IndexFunc(unused, func(o *models.ItemOrigin) bool { return *o == *item.Origin })
+		if i < 0 {
+			return fmt.Errorf("item %q: origin can't be changed", item.Description)
+		}
+		unused = slices.Delete(unused, i, i+1)
+	}
+	return nil
+}
+
+// itemCategoryFromProto converts the proto item category to the model value.
+func itemCategoryFromProto(category pb.ItemCategory) models.ItemCategory {
+	switch category {
+	case pb.ItemCategory_ITEM_CATEGORY_HOUSEHOLD:
+		return models.ItemHousehold
+	case pb.ItemCategory_ITEM_CATEGORY_PERSONAL:
+		return models.ItemPersonal
+	default:
+		return ""
+	}
+}
+
+// itemCategoryToProto converts the model item category to the proto value.
+func itemCategoryToProto(category models.ItemCategory) pb.ItemCategory {
+	switch category {
+	case models.ItemHousehold:
+		return pb.ItemCategory_ITEM_CATEGORY_HOUSEHOLD
+	case models.ItemPersonal:
+		return pb.ItemCategory_ITEM_CATEGORY_PERSONAL
+	default:
+		return pb.ItemCategory_ITEM_CATEGORY_UNSPECIFIED
+	}
+}
+
+// toCalcItems converts model Items to calculator Items.
+func toCalcItems(items []models.Item) []splitmath.Item {
+	calcItems := make([]splitmath.Item, len(items))
+	for i, item := range items {
+		calcItems[i] = splitmath.Item{
 			Description:  item.Description,
 			Amount:       item.Amount,
-			Participants: item.ParticipantIds,
+			Participants: item.Participants,
+			Shares:       item.Shares,
+			Discount:     item.Discount,
+			Quantity:     item.Quantity,
+			UnitPrice:    item.UnitPrice,
+			Units:        item.Units,
+			Category:     splitmath.ItemCategory(item.Category),
+			Owner:        item.Owner,
+		}
+	}
+	return calcItems
+}
+
+// pbToModelFees converts proto Fees to model Fees.
+func pbToModelFees(pbFees []*pb.Fee) []models.Fee {
+	fees := make([]models.Fee, len(pbFees))
+	for i, fee := range pbFees {
+		fees[i] = models.Fee{
+			Description: fee.Description,
+			Amount:      fee.Amount,
+			SplitMode:   feeSplitModeFromProto(fee.SplitMode),
+		}
+	}
+	return fees
+}
+
+// modelToPbFees converts model Fees to proto Fees.
+func modelToPbFees(fees []models.Fee) []*pb.Fee {
+	result := make([]*pb.Fee, len(fees))
+	for i, fee := range fees {
+		result[i] = &pb.Fee{
+			Description: fee.Description,
+			Amount:      fee.Amount,
+			SplitMode:   feeSplitModeToProto(fee.SplitMode),
+		}
+	}
+	return result
+}
+
+// toCalcFees converts model Fees to calculator Fees.
+func toCalcFees(fees []models.Fee) []splitmath.Fee {
+	calcFees := make([]splitmath.Fee, len(fees))
+	for i, fee := range fees {
+		calcFees[i] = splitmath.Fee{
+			Description: fee.Description,
+			Amount:      fee.Amount,
+			SplitMode:   splitmath.FeeSplitMode(fee.SplitMode),
+		}
+	}
+	return calcFees
+}
+
+// feeSplitModeFromProto converts the proto fee split mode to the model value.
+func feeSplitModeFromProto(mode pb.FeeSplitMode) models.FeeSplitMode {
+	if mode == pb.FeeSplitMode_FEE_SPLIT_MODE_EQUAL {
+		return models.FeeSplitEqual
+	}
+	return models.FeeSplitProportional
+}
+
+// feeSplitModeToProto converts the model fee split mode to the proto value.
+func feeSplitModeToProto(mode models.FeeSplitMode) pb.FeeSplitMode {
+	if mode == models.FeeSplitEqual {
+		return pb.FeeSplitMode_FEE_SPLIT_MODE_EQUAL
+	}
+	return pb.FeeSplitMode_FEE_SPLIT_MODE_PROPORTIONAL
+}
+
+// coverModeFromProto converts the proto cover mode to the model value.
+func coverModeFromProto(mode pb.CoverMode) models.CoverMode {
+	switch mode {
+	case pb.CoverMode_COVER_MODE_TAX_AND_TIP:
+		return models.CoverTaxAndTip
+	case pb.CoverMode_COVER_MODE_ALL:
+		return models.CoverAll
+	default:
+		return models.CoverNone
+	}
+}
+
+// coverModeToProto converts the model cover mode to the proto value.
+func coverModeToProto(mode models.CoverMode) pb.CoverMode {
+	switch mode {
+	case models.CoverTaxAndTip:
+		return pb.CoverMode_COVER_MODE_TAX_AND_TIP
+	case models.CoverAll:
+		return pb.CoverMode_COVER_MODE_ALL
+	default:
+		return pb.CoverMode_COVER_MODE_NONE
+	}
+}
+
+// coverageFromProto converts a CalculateSplit covered map to calculator cover modes.
+func coverageFromProto(covered map[string]pb.CoverMode) map[string]splitmath.CoverMode {
+	result := make(map[string]splitmath.CoverMode, len(covered))
+	for person, mode := range covered {
+		result[person] = splitmath.CoverMode(coverModeFromProto(mode))
+	}
+	return result
+}
+
+// tipSplitModeFromProto converts the proto tip split mode to the model value.
+func tipSplitModeFromProto(mode pb.TipSplitMode) models.TipSplitMode {
+	if mode == pb.TipSplitMode_TIP_SPLIT_MODE_EQUAL {
+		return models.TipSplitEqual
+	}
+	return models.TipSplitProportional
+}
+
+// tipSplitModeToProto converts the model tip split mode to the proto value.
+func tipSplitModeToProto(mode models.TipSplitMode) pb.TipSplitMode {
+	if mode == models.TipSplitEqual {
+		return pb.TipSplitMode_TIP_SPLIT_MODE_EQUAL
+	}
+	return pb.TipSplitMode_TIP_SPLIT_MODE_PROPORTIONAL
+}
+
+// splitTypeFromProto converts the proto split type to the model value.
+func splitTypeFromProto(t pb.SplitType) models.SplitType {
+	switch t {
+	case pb.SplitType_SPLIT_TYPE_SHARES:
+		return models.SplitShares
+	case pb.SplitType_SPLIT_TYPE_EXACT:
+		return models.SplitExact
+	default:
+		return models.SplitEqual
+	}
+}
+
+// splitTypeToProto converts the model split type to the proto value.
+func splitTypeToProto(t models.SplitType) pb.SplitType {
+	switch t {
+	case models.SplitShares:
+		return pb.SplitType_SPLIT_TYPE_SHARES
+	case models.SplitExact:
+		return pb.SplitType_SPLIT_TYPE_EXACT
+	default:
+		return pb.SplitType_SPLIT_TYPE_EQUAL
+	}
+}
+
+// remainderModeFromProto converts the proto remainder mode to the model value.
+func remainderModeFromProto(mode pb.RemainderMode) models.RemainderMode {
+	switch mode {
+	case pb.RemainderMode_REMAINDER_MODE_PROPORTIONAL:
+		return models.RemainderProportional
+	case pb.RemainderMode_REMAINDER_MODE_PAYER:
+		return models.RemainderPayer
+	default:
+		return models.RemainderEqual
+	}
+}
+
+// remainderModeToProto converts the model remainder mode to the proto value.
+func remainderModeToProto(mode models.RemainderMode) pb.RemainderMode {
+	switch mode {
+	case models.RemainderProportional:
+		return pb.RemainderMode_REMAINDER_MODE_PROPORTIONAL
+	case models.RemainderPayer:
+		return pb.RemainderMode_REMAINDER_MODE_PAYER
+	default:
+		return pb.RemainderMode_REMAINDER_MODE_EQUAL
+	}
+}
+
+// roundingModeFromProto converts the proto rounding mode to the model value.
+func roundingModeFromProto(mode pb.RoundingMode) models.RoundingMode {
+	switch mode {
+	case pb.RoundingMode_ROUNDING_MODE_HALF_UP:
+		return models.RoundHalfUp
+	case pb.RoundingMode_ROUNDING_MODE_CASH:
+		return models.RoundCash
+	case pb.RoundingMode_ROUNDING_MODE_BANKERS:
+		return models.RoundBankers
+	default:
+		return ""
+	}
+}
+
+// roundingModeToProto converts the model rounding mode to the proto value.
+func roundingModeToProto(mode models.RoundingMode) pb.RoundingMode {
+	switch mode {
+	case models.RoundHalfUp:
+		return pb.RoundingMode_ROUNDING_MODE_HALF_UP
+	case models.RoundCash:
+		return pb.RoundingMode_ROUNDING_MODE_CASH
+	case models.RoundBankers:
+		return pb.RoundingMode_ROUNDING_MODE_BANKERS
+	default:
+		return pb.RoundingMode_ROUNDING_MODE_UNSPECIFIED
+	}
+}
+
+// discountTypeFromProto converts the proto discount type to the model value.
+func discountTypeFromProto(t pb.DiscountType) models.DiscountType {
+	if t == pb.DiscountType_DISCOUNT_TYPE_PERCENT {
+		return models.DiscountPercent
+	}
+	return models.DiscountAmount
+}
+
+// discountTypeToProto converts the model discount type to the proto value.
+func discountTypeToProto(t models.DiscountType) pb.DiscountType {
+	if t == models.DiscountPercent {
+		return pb.DiscountType_DISCOUNT_TYPE_PERCENT
+	}
+	return pb.DiscountType_DISCOUNT_TYPE_AMOUNT
+}
+
+// participantShares maps display names to their bill-level shares, skipping unset weights.
+func participantShares(participants []models.BillParticipant) map[string]float64 {
+	shares := make(map[string]float64)
+	for _, p := range participants {
+		if p.Shares != 0 {
+			shares[p.DisplayName] = p.Shares
 		}
 	}
+	return shares
+}
 
-	splits, err := calculator.CalculateSplit(items, req.Msg.Total, req.Msg.Subtotal, req.Msg.ParticipantIds)
+// participantAmounts maps display names to their exact amounts owed.
+func participantAmounts(participants []models.BillParticipant) map[string]float64 {
+	amounts := make(map[string]float64, len(participants))
+	for _, p := range participants {
+		amounts[p.DisplayName] = p.Amount
+	}
+	return amounts
+}
+
+// participantCoverage maps display names to their cover modes, skipping uncovered participants.
+func participantCoverage(participants []models.BillParticipant) map[string]splitmath.CoverMode {
+	covered := make(map[string]splitmath.CoverMode)
+	for _, p := range participants {
+		if p.Covered != "" && p.Covered != models.CoverNone {
+			covered[p.DisplayName] = splitmath.CoverMode(p.Covered)
+		}
+	}
+	return covered
+}
+
+// participantAdjustments maps display names to their adjustments, skipping zeros.
+func participantAdjustments(participants []models.BillParticipant) map[string]float64 {
+	adjustments := make(map[string]float64)
+	for _, p := range participants {
+		if p.Adjustment != 0 {
+			adjustments[p.DisplayName] = p.Adjustment
+		}
+	}
+	return adjustments
+}
+
+// normalizeCurrency upper-cases a requested ISO 4217 code; the calculator
+// rejects anything that isn't three letters.
+func normalizeCurrency(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// calcOptions extracts the bill-level calculator options stored on a bill.
+func calcOptions(bill *models.Bill) splitmath.Options {
+	return splitmath.Options{
+		Tip:           bill.Tip,
+		TipSplitMode:  splitmath.TipSplitMode(bill.TipSplitMode),
+		SplitType:     splitmath.SplitType(bill.SplitType),
+		Shares:        participantShares(bill.Participants),
+		Amounts:       participantAmounts(bill.Participants),
+		Discount:      bill.Discount,
+		DiscountType:  splitmath.DiscountType(bill.DiscountType),
+		Fees:          toCalcFees(bill.Fees),
+		Covered:       participantCoverage(bill.Participants),
+		Adjustments:   participantAdjustments(bill.Participants),
+		Currency:      bill.Currency,
+		RemainderMode: splitmath.RemainderMode(bill.RemainderMode),
+		Payer:         bill.PayerID,
+		TaxInclusive:  bill.TaxInclusive,
+		TaxRate:       bill.TaxRate,
+		Rounding:      splitmath.RoundingMode(bill.RoundingMode),
+	}
+}
+
+// defaultItemTolerance is how far items may be from the subtotal under strict
+// item validation when the request doesn't say, allowing for receipt rounding.
+const defaultItemTolerance = 0.01
+
+// validateItemsAgainstSubtotal checks a bill's items against its subtotal
+// under the requested validation mode. Bills without items always pass.
+func validateItemsAgainstSubtotal(bill *models.Bill, mode pb.ItemValidation, tolerance float64) error {
+	if mode == pb.ItemValidation_ITEM_VALIDATION_LENIENT || len(bill.Items) == 0 {
+		return nil
+	}
+	if tolerance < 0 {
+		return connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("item_tolerance cannot be negative"))
+	}
+	if tolerance == 0 {
+		tolerance = defaultItemTolerance
+	}
+
+	itemsTotal := splitmath.ItemsTotal(toCalcItems(bill.Items))
+	diff := itemsTotal - bill.Subtotal
+	format := func(amount float64) string { return splitmath.FormatAmount(amount, bill.Currency) }
+	if diff > tolerance {
+		return connect.NewError(connect.CodeInvalidArgument, fmt.Errorf(
+			"items total %s exceeds subtotal %s by %s", format(itemsTotal), format(bill.Subtotal), format(diff)))
+	}
+	if mode == pb.ItemValidation_ITEM_VALIDATION_EXACT && -diff > tolerance {
+		return connect.NewError(connect.CodeInvalidArgument, fmt.Errorf(
+			"items total %s is %s short of subtotal %s", format(itemsTotal), format(-diff), format(bill.Subtotal)))
+	}
+	return nil
+}
+
+// billSplit calculates a bill's split response, with a computation trace when
+// debug is set. Bills awaiting participant assignment have no split yet and return nil.
+func billSplit(bill *models.Bill, debug bool) (*pb.CalculateSplitResponse, error) {
+	if bill.NeedsAssignment {
+		return nil, nil
+	}
+	splits, opts, err := calculateBill(bill, debug)
 	if err != nil {
-		slog.Error("CalculateSplit failed", "error", err)
-		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		return nil, err
+	}
+	return splitResponse(splits, bill.Total, bill.Subtotal, opts), nil
+}
+
+// calculateBill runs the calculator over a bill, recording a trace in the
+// returned options when debug is set.
+func calculateBill(bill *models.Bill, debug bool) (map[string]*splitmath.PersonSplit, splitmath.Options, error) {
+	opts := calcOptions(bill)
+	if debug {
+		opts.Trace = &splitmath.Trace{}
+	}
+	splits, err := splitmath.CalculateSplitWithOptions(
+		toCalcItems(bill.Items), bill.Total, bill.Subtotal,
+		participantDisplayNames(bill.Participants), opts,
+	)
+	return splits, opts, err
+}
+
+// itemMatrix lays out calculated splits as who had what: one row per item
+// of items, with each person's share of it.
+func itemMatrix(items []models.Item, splits map[string]*splitmath.PersonSplit) []*pb.ItemShares {
+	rows := make([]*pb.ItemShares, len(items))
+	for i, item := range items {
+		rows[i] = &pb.ItemShares{Index: int32(i), Description: item.Description, Amount: item.Amount}
+	}
+	for person, split := range splits {
+		for _, share := range split.Items {
+			if share.Item < 0 || share.Item >= len(rows) {
+				continue
+			}
+			row := rows[share.Item]
+			if row.Shares == nil {
+				row.Shares = make(map[string]float64)
+			}
+			row.Shares[person] += share.Amount
+		}
 	}
+	return rows
+}
 
-	protoSplits := make(map[string]*pb.PersonSplit)
+// splitResponse converts calculator output into a CalculateSplitResponse,
+// including opts.Trace when one was recorded.
+func splitResponse(splits map[string]*splitmath.PersonSplit, total, subtotal float64, opts splitmath.Options) *pb.CalculateSplitResponse {
+	protoSplits := make(map[string]*pb.PersonSplit, len(splits))
 	for person, split := range splits {
 		protoItems := make([]*pb.PersonItem, len(split.Items))
 		for i, item := range split.Items {
@@ -176,301 +734,633 @@ func (s *SplitService) CalculateSplit(ctx context.Context, req *connect.Request[
 		}
 		protoSplits[person] = &pb.PersonSplit{
 			Subtotal: split.Subtotal,
+			Discount: split.Discount,
 			Tax:      split.Tax,
+			Tip:      split.Tip,
+			Fees:     split.Fees,
+			Covered:  split.Covered,
 			Total:    split.Total,
 			Items:    protoItems,
 		}
 	}
+	return &pb.CalculateSplitResponse{
+		Splits:         protoSplits,
+		TaxAmount:      opts.TaxAmount(total, subtotal),
+		Subtotal:       opts.ExcludingTax(subtotal),
+		TipAmount:      opts.Tip,
+		DiscountAmount: opts.ExcludingTax(opts.DiscountAmount(subtotal)),
+		FeeAmount:      opts.FeesTotal(),
+		Trace:          traceToProto(opts.Trace),
+		Currency:       opts.Currency,
+	}
+}
 
-	return connect.NewResponse(&pb.CalculateSplitResponse{
-		Splits:    protoSplits,
-		TaxAmount: req.Msg.Total - req.Msg.Subtotal,
-		Subtotal:  req.Msg.Subtotal,
-	}), nil
+// traceToProto converts a calculator trace to proto trace steps; nil stays nil.
+func traceToProto(trace *splitmath.Trace) []*pb.TraceStep {
+	if trace == nil {
+		return nil
+	}
+	steps := make([]*pb.TraceStep, len(trace.Steps))
+	for i, step := range trace.Steps {
+		steps[i] = &pb.TraceStep{
+			Step:        step.Step,
+			Participant: step.Participant,
+			Detail:      step.Detail,
+			Amount:      step.Amount,
+			Ratio:       step.Ratio,
+		}
+	}
+	return steps
+}
+
+// CalculateSplit handles bill split calculation
+func (s *SplitService) CalculateSplit(ctx context.Context, req *connect.Request[pb.CalculateSplitRequest]) (*connect.Response[pb.CalculateSplitResponse], error) {
+	for i, item := range req.Msg.Items {
+		logger.Debug("Processing item",
+			"index", i+1,
+			"description", item.Description,
+			"amount", item.Amount,
+			"participants", item.ParticipantIds,
+		)
+	}
+
+	opts := splitmath.Options{
+		Tip:           req.Msg.Tip,
+		TipSplitMode:  splitmath.TipSplitMode(tipSplitModeFromProto(req.Msg.TipSplitMode)),
+		SplitType:     splitmath.SplitType(splitTypeFromProto(req.Msg.SplitType)),
+		Shares:        req.Msg.Shares,
+		Amounts:       req.Msg.Amounts,
+		Discount:      req.Msg.Discount,
+		DiscountType:  splitmath.DiscountType(discountTypeFromProto(req.Msg.DiscountType)),
+		Fees:          toCalcFees(pbToModelFees(req.Msg.Fees)),
+		Covered:       coverageFromProto(req.Msg.Covered),
+		Adjustments:   req.Msg.Adjustments,
+		Currency:      normalizeCurrency(req.Msg.Currency),
+		RemainderMode: splitmath.RemainderMode(remainderModeFromProto(req.Msg.RemainderMode)),
+		Payer:         req.Msg.Payer,
+		TaxInclusive:  req.Msg.TaxInclusive,
+		TaxRate:       req.Msg.TaxRate,
+		Rounding:      splitmath.RoundingMode(roundingModeFromProto(req.Msg.RoundingMode)),
+	}
+	if req.Msg.Debug {
+		opts.Trace = &splitmath.Trace{}
+	}
+	if req.Msg.EvenSplitTolerance < 0 || req.Msg.EvenSplitTolerance >= 1 {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("even_split_tolerance must be at least 0 and below 1"))
+	}
+	splits, err := splitmath.CalculateSplitWithOptions(toCalcItems(pbToModelItems(req.Msg.Items)), req.Msg.Total, req.Msg.Subtotal, req.Msg.ParticipantIds, opts)
+	if err != nil {
+		logger.Error("CalculateSplit failed", "error", err)
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	resp := splitResponse(splits, req.Msg.Total, req.Msg.Subtotal, opts)
+	if len(req.Msg.Items) > 0 && opts.SplitType != splitmath.SplitExact {
+		tolerance := req.Msg.EvenSplitTolerance
+		if tolerance == 0 {
+			tolerance = splitmath.DefaultEvenTolerance
+		}
+		if even := splitmath.NearEven(splits, tolerance, opts.Currency); even != nil {
+			resp.EvenSplitHint = &pb.EvenSplitHint{Share: even.Share, Difference: even.Difference}
+		}
+	}
+	return connect.NewResponse(resp), nil
 }
 
 // CreateBill creates a new bill and persists it to storage.
 func (s *SplitService) CreateBill(ctx context.Context, req *connect.Request[pb.CreateBillRequest]) (*connect.Response[pb.CreateBillResponse], error) {
-	userID := middleware.GetUserID(ctx)
+	userID := authctx.UserID(ctx)
 	if userID == "" {
 		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
 	}
 
+	if err := s.quotas.CheckCreateBill(ctx, userID); err != nil {
+		logger.Error("CreateBill quota check failed", "user_id", userID, "error", err)
+		return nil, quotaError(err)
+	}
+
+	if err := s.checkTitleRequired(ctx, req.Msg.GetGroupId(), req.Msg.Title); err != nil {
+		logger.Error("CreateBill title check failed", "error", err)
+		return nil, err
+	}
+	if err := s.checkCategory(ctx, req.Msg.CategoryId); err != nil {
+		return nil, err
+	}
+	if err := checkBillDetails(req.Msg.Note, req.Msg.MerchantName, req.Msg.Location, req.Msg.BillDate, time.Now()); err != nil {
+		return nil, err
+	}
+
 	participants := pbToModelParticipants(req.Msg.Participants)
 
 	if err := validateRegisteredParticipants(ctx, s.store, userID, participants); err != nil {
 		return nil, err
 	}
 
-	// Convert proto items to models
-	items := make([]models.Item, len(req.Msg.Items))
-	for i, item := range req.Msg.Items {
-		items[i] = models.Item{
-			Description:  item.Description,
-			Amount:       item.Amount,
-			Participants: item.ParticipantIds,
+	payerID := req.Msg.GetPayerId()
+	if payerID == "" {
+		var err error
+		payerID, err = s.defaultPayer(ctx, req.Msg.GetGroupId(), userID, participants)
+		if err != nil {
+			logger.Error("CreateBill default payer lookup failed", "error", err)
+			return nil, err
 		}
 	}
 
-	if err := validatePayerID(req.Msg.GetPayerId(), participants); err != nil {
-		slog.Error("CreateBill payer validation failed", "error", err)
-		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	// Parked bills may not list the payer among participants yet; it's checked on finalization.
+	if !req.Msg.NeedsAssignment {
+		if err := validatePayerID(payerID, participants); err != nil {
+			logger.Error("CreateBill payer validation failed", "error", err)
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		}
 	}
 
 	bill := &models.Bill{
-		Title:        req.Msg.Title,
-		Items:        items,
-		Total:        req.Msg.Total,
-		Subtotal:     req.Msg.Subtotal,
-		Participants: participants,
-		CreatorID:    userID,
+		Title:           req.Msg.Title,
+		Items:           pbToModelItems(req.Msg.Items),
+		Total:           req.Msg.Total,
+		Subtotal:        req.Msg.Subtotal,
+		Tip:             req.Msg.Tip,
+		TipSplitMode:    tipSplitModeFromProto(req.Msg.TipSplitMode),
+		SplitType:       splitTypeFromProto(req.Msg.SplitType),
+		Discount:        req.Msg.Discount,
+		DiscountType:    discountTypeFromProto(req.Msg.DiscountType),
+		Fees:            pbToModelFees(req.Msg.Fees),
+		Currency:        normalizeCurrency(req.Msg.Currency),
+		Participants:    participants,
+		CreatorID:       userID,
+		NeedsAssignment: req.Msg.NeedsAssignment,
+		RemainderMode:   remainderModeFromProto(req.Msg.RemainderMode),
+		TaxInclusive:    req.Msg.TaxInclusive,
+		TaxRate:         req.Msg.TaxRate,
+		RoundingMode:    roundingModeFromProto(req.Msg.RoundingMode),
+		CategoryID:      req.Msg.CategoryId,
+		Note:            req.Msg.Note,
+		MerchantName:    req.Msg.MerchantName,
+		Location:        req.Msg.Location,
+		BillDate:        req.Msg.BillDate,
 	}
 	if req.Msg.GetGroupId() != "" {
 		bill.GroupID = req.Msg.GetGroupId()
 	}
-	if req.Msg.GetPayerId() != "" {
-		bill.PayerID = req.Msg.GetPayerId()
+	if payerID != "" {
+		bill.PayerID = payerID
 	}
-
-	if err := s.store.CreateBill(ctx, bill); err != nil {
-		slog.Error("CreateBill failed", "error", err)
-		return nil, connect.NewError(connect.CodeInternal, err)
+	if bill.RoundingMode == "" {
+		var err error
+		bill.RoundingMode, err = s.groupRoundingMode(ctx, bill.GroupID)
+		if err != nil {
+			logger.Error("CreateBill group rounding mode lookup failed", "error", err)
+			return nil, err
+		}
+	}
+	if err := s.lockExchangeRate(ctx, bill, req.Msg.ExchangeRate); err != nil {
+		logger.Error("CreateBill exchange rate lookup failed", "error", err)
+		return nil, err
 	}
 
-	s.autoAddParticipantsToGroup(ctx, bill.GroupID, bill.Participants, bill.PayerID)
-
-	displayNames := participantDisplayNames(participants)
-	calcItems := make([]calculator.Item, len(req.Msg.Items))
-	for i, item := range req.Msg.Items {
-		calcItems[i] = calculator.Item{
-			Description:  item.Description,
-			Amount:       item.Amount,
-			Participants: item.ParticipantIds,
-		}
+	if err := validateItemsAgainstSubtotal(bill, req.Msg.ItemValidation, req.Msg.ItemTolerance); err != nil {
+		logger.Error("CreateBill item validation failed", "error", err)
+		return nil, err
+	}
+	if err := validateItemOrigins(bill.Items, nil); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
 	}
 
-	splits, err := calculator.CalculateSplit(calcItems, req.Msg.Total, req.Msg.Subtotal, displayNames)
+	// Calculate before persisting so an invalid bill is never stored.
+	split, err := billSplit(bill, false)
 	if err != nil {
-		slog.Error("CalculateSplit failed during CreateBill", "error", err)
+		logger.Error("CalculateSplit failed during CreateBill", "error", err)
 		return nil, connect.NewError(connect.CodeInvalidArgument, err)
 	}
 
-	protoSplits := make(map[string]*pb.PersonSplit)
-	for person, split := range splits {
-		protoItems := make([]*pb.PersonItem, len(split.Items))
-		for i, item := range split.Items {
-			protoItems[i] = &pb.PersonItem{
-				Description: item.Description,
-				Amount:      item.Amount,
-			}
-		}
-		protoSplits[person] = &pb.PersonSplit{
-			Subtotal: split.Subtotal,
-			Tax:      split.Tax,
-			Total:    split.Total,
-			Items:    protoItems,
+	// Rotation is checked before saving so the new bill doesn't count itself.
+	expectedPayer := s.expectedPayer(ctx, bill)
+
+	// Participants join the bill's group in the same transaction, so the bill
+	// is never saved without them.
+	var added int
+	if bill.GroupID == "" {
+		err = s.store.CreateBill(ctx, bill)
+	} else {
+		var members []models.GroupMember
+		if !bill.NeedsAssignment {
+			members = findNewParticipants(withPayer(bill.Participants, bill.PayerID), nil)
 		}
+		added, err = s.store.CreateBillInGroup(ctx, bill, members)
 	}
+	if err != nil {
+		logger.Error("CreateBill failed", "error", err)
+		return nil, storeError(err)
+	}
+	if added > 0 {
+		logger.Info("Auto-added participants to group", "group_id", bill.GroupID, "count", added)
+	}
+	checkBalanceThresholds(ctx, s.store, s.webhooks, bill.GroupID)
+	notifyBillChange(ctx, s.store, s.webhooks, webhook.EventBillCreated, bill)
+	overCap := s.checkSpendingCaps(ctx, bill, split)
 
-	return connect.NewResponse(&pb.CreateBillResponse{
-		BillId: bill.ID,
-		Split: &pb.CalculateSplitResponse{
-			Splits:    protoSplits,
-			TaxAmount: req.Msg.Total - req.Msg.Subtotal,
-			Subtotal:  req.Msg.Subtotal,
-		},
-	}), nil
+	var warnings []string
+	if bill.PayerID == "" {
+		warnings = append(warnings, "bill has no payer and is left out of balances until one is set")
+	}
+	if len(overCap) > 0 {
+		warnings = append(warnings, fmt.Sprintf("bill puts %s over their monthly spending cap", strings.Join(overCap, ", ")))
+	}
+	resp := &pb.CreateBillResponse{
+		BillId:         bill.ID,
+		Split:          split,
+		Warnings:       warnings,
+		OverCapMembers: overCap,
+	}
+	if expectedPayer != "" {
+		resp.Warnings = append(resp.Warnings, fmt.Sprintf("it was %s's turn to pay", expectedPayer))
+		resp.ExpectedPayer = &expectedPayer
+	}
+
+	return connect.NewResponse(resp), nil
 }
 
 // GetBill retrieves a bill by ID from storage.
 func (s *SplitService) GetBill(ctx context.Context, req *connect.Request[pb.GetBillRequest]) (*connect.Response[pb.GetBillResponse], error) {
-	userID := middleware.GetUserID(ctx)
+	userID := authctx.UserID(ctx)
 	if userID == "" {
 		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
 	}
 
 	bill, err := s.store.GetBill(ctx, req.Msg.BillId)
 	if err != nil {
-		slog.Error("GetBill failed", "bill_id", req.Msg.BillId, "error", err)
-		return nil, connect.NewError(connect.CodeNotFound, err)
+		logger.Error("GetBill failed", "bill_id", req.Msg.BillId, "error", err)
+		return nil, storeError(err)
 	}
 
 	if !hasAccess(userID, bill) {
 		return nil, connect.NewError(connect.CodePermissionDenied, fmt.Errorf("you must be a participant to view this bill"))
 	}
 
-	protoItems := make([]*pb.Item, len(bill.Items))
-	for i, item := range bill.Items {
-		protoItems[i] = &pb.Item{
-			Description:    item.Description,
-			Amount:         item.Amount,
-			ParticipantIds: item.Participants,
-		}
+	mask, err := parseFieldMask(req.Msg.FieldMask, &pb.GetBillResponse{})
+	if err != nil {
+		return nil, err
 	}
 
-	displayNames := participantDisplayNames(bill.Participants)
-	calcItems := make([]calculator.Item, len(bill.Items))
-	for i, item := range bill.Items {
-		calcItems[i] = calculator.Item{
-			Description:  item.Description,
-			Amount:       item.Amount,
-			Participants: item.Participants,
+	var split *pb.CalculateSplitResponse
+	var matrix []*pb.ItemShares
+	if !bill.NeedsAssignment && (mask.has("split") || mask.has("item_matrix")) {
+		splits, opts, err := calculateBill(bill, req.Msg.Debug)
+		if err != nil {
+			logger.Error("CalculateSplit failed during GetBill", "error", err)
+			return nil, connect.NewError(connect.CodeInternal, err)
 		}
+		split = splitResponse(splits, bill.Total, bill.Subtotal, opts)
+		matrix = itemMatrix(bill.Items, splits)
 	}
 
-	splits, err := calculator.CalculateSplit(calcItems, bill.Total, bill.Subtotal, displayNames)
-	if err != nil {
-		slog.Error("CalculateSplit failed during GetBill", "error", err)
-		return nil, connect.NewError(connect.CodeInternal, err)
+	resp := &pb.GetBillResponse{
+		BillId:          bill.ID,
+		Title:           bill.Title,
+		Items:           modelToPbItems(bill.Items),
+		Total:           bill.Total,
+		Subtotal:        bill.Subtotal,
+		Tip:             bill.Tip,
+		TipSplitMode:    tipSplitModeToProto(bill.TipSplitMode),
+		SplitType:       splitTypeToProto(bill.SplitType),
+		Discount:        bill.Discount,
+		DiscountType:    discountTypeToProto(bill.DiscountType),
+		Fees:            modelToPbFees(bill.Fees),
+		Currency:        bill.Currency,
+		Participants:    modelToPbParticipants(bill.Participants),
+		PayerId:         bill.PayerID,
+		Split:           split,
+		CreatedAt:       bill.CreatedAt,
+		NeedsAssignment: bill.NeedsAssignment,
+		RemainderMode:   remainderModeToProto(bill.RemainderMode),
+		TaxInclusive:    bill.TaxInclusive,
+		TaxRate:         bill.TaxRate,
+		RoundingMode:    roundingModeToProto(bill.RoundingMode),
+		CategoryId:      bill.CategoryID,
+		ArchivedAt:      bill.ArchivedAt,
+		ExchangeRate:    bill.ExchangeRate,
+		LockedAt:        bill.LockedAt,
+		ItemMatrix:      matrix,
+		Note:            bill.Note,
+		MerchantName:    bill.MerchantName,
+		Location:        bill.Location,
+		BillDate:        bill.BillDate,
+	}
+	if req.Msg.SpellOut {
+		resp.TotalWords = numwords.Amount(bill.Total, bill.Currency, req.Msg.Locale)
+		for _, ps := range split.GetSplits() {
+			ps.TotalWords = numwords.Amount(ps.Total, bill.Currency, req.Msg.Locale)
+		}
 	}
-
-	protoSplits := make(map[string]*pb.PersonSplit)
-	for person, split := range splits {
-		personItems := make([]*pb.PersonItem, len(split.Items))
-		for i, item := range split.Items {
-			personItems[i] = &pb.PersonItem{
-				Description: item.Description,
-				Amount:      item.Amount,
+	if bill.GroupID != "" {
+		resp.GroupId = &bill.GroupID
+		if mask.has("group_name") {
+			group, err := s.store.GetGroup(ctx, bill.GroupID)
+			if err == nil && group != nil {
+				resp.GroupName = &group.Name
 			}
 		}
-		protoSplits[person] = &pb.PersonSplit{
-			Subtotal: split.Subtotal,
-			Tax:      split.Tax,
-			Total:    split.Total,
-			Items:    personItems,
+		if mask.has("over_cap_members") {
+			alerts, err := s.store.ListBillSpendingCapAlerts(ctx, bill.ID)
+			if err != nil {
+				logger.Error("GetBill failed to list spending cap alerts", "bill_id", bill.ID, "error", err)
+				return nil, storeError(err)
+			}
+			for _, alert := range alerts {
+				resp.OverCapMembers = append(resp.OverCapMembers, alert.Member)
+			}
 		}
 	}
-
-	resp := &pb.GetBillResponse{
-		BillId:       bill.ID,
-		Title:        bill.Title,
-		Items:        protoItems,
-		Total:        bill.Total,
-		Subtotal:     bill.Subtotal,
-		Participants: modelToPbParticipants(bill.Participants),
-		PayerId:      bill.PayerID,
-		Split: &pb.CalculateSplitResponse{
-			Splits:    protoSplits,
-			TaxAmount: bill.Total - bill.Subtotal,
-			Subtotal:  bill.Subtotal,
-		},
-		CreatedAt: bill.CreatedAt,
+	if mask.has("payment_status") {
+		statuses, err := s.paymentStatuses(ctx, []*models.Bill{bill})
+		if err != nil {
+			logger.Error("GetBill failed to list share payments", "bill_id", bill.ID, "error", err)
+			return nil, storeError(err)
+		}
+		resp.PaymentStatus = statuses[bill.ID]
 	}
-	if bill.GroupID != "" {
-		resp.GroupId = &bill.GroupID
-		group, err := s.store.GetGroup(ctx, bill.GroupID)
-		if err == nil && group != nil {
-			resp.GroupName = &group.Name
+	if mask.has("attachments") {
+		attachments, err := s.store.ListAttachmentsByBill(ctx, bill.ID)
+		if err != nil {
+			logger.Error("GetBill failed to list attachments", "bill_id", bill.ID, "error", err)
+			return nil, storeError(err)
+		}
+		for _, a := range attachments {
+			resp.Attachments = append(resp.Attachments, attachmentToProto(a))
 		}
 	}
+	mask.apply(resp)
 	return connect.NewResponse(resp), nil
 }
 
-// UpdateBill updates an existing bill.
-func (s *SplitService) UpdateBill(ctx context.Context, req *connect.Request[pb.UpdateBillRequest]) (*connect.Response[pb.UpdateBillResponse], error) {
-	userID := middleware.GetUserID(ctx)
-	if userID == "" {
-		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+// billToUpdateRequest returns the update request that would store bill as it
+// is, for an update mask to patch.
+func billToUpdateRequest(bill *models.Bill) *pb.UpdateBillRequest {
+	msg := &pb.UpdateBillRequest{
+		BillId:          bill.ID,
+		Title:           bill.Title,
+		Total:           bill.Total,
+		Subtotal:        bill.Subtotal,
+		Items:           modelToPbItems(bill.Items),
+		Participants:    modelToPbParticipants(bill.Participants),
+		Tip:             bill.Tip,
+		TipSplitMode:    tipSplitModeToProto(bill.TipSplitMode),
+		SplitType:       splitTypeToProto(bill.SplitType),
+		NeedsAssignment: bill.NeedsAssignment,
+		Discount:        bill.Discount,
+		DiscountType:    discountTypeToProto(bill.DiscountType),
+		Fees:            modelToPbFees(bill.Fees),
+		Currency:        bill.Currency,
+		RemainderMode:   remainderModeToProto(bill.RemainderMode),
+		TaxInclusive:    bill.TaxInclusive,
+		TaxRate:         bill.TaxRate,
+		RoundingMode:    roundingModeToProto(bill.RoundingMode),
+		CategoryId:      bill.CategoryID,
+		Note:            bill.Note,
+		MerchantName:    bill.MerchantName,
+		Location:        bill.Location,
+		BillDate:        bill.BillDate,
+	}
+	if bill.PayerID != "" {
+		msg.PayerId = &bill.PayerID
+	}
+	if bill.GroupID != "" {
+		msg.GroupId = &bill.GroupID
 	}
+	return msg
+}
 
-	existingBill, err := s.store.GetBill(ctx, req.Msg.BillId)
+// billFromUpdate checks an update request against the stored bill and builds
+// the updated bill from it without persisting anything. With an update mask,
+// only the fields it names are taken from the request. op names the calling
+// RPC in logs.
+func (s *SplitService) billFromUpdate(ctx context.Context, op, userID string, msg *pb.UpdateBillRequest) (existing, bill *models.Bill, err error) {
+	existing, err = s.store.GetBill(ctx, msg.BillId)
 	if err != nil {
-		slog.Error("UpdateBill: failed to get existing bill", "bill_id", req.Msg.BillId, "error", err)
-		return nil, connect.NewError(connect.CodeNotFound, err)
+		logger.Error(op+": failed to get existing bill", "bill_id", msg.BillId, "error", err)
+		return nil, nil, storeError(err)
 	}
 
-	if !hasAccess(userID, existingBill) {
-		return nil, connect.NewError(connect.CodePermissionDenied, fmt.Errorf("you must be a participant to update this bill"))
+	if !hasAccess(userID, existing) {
+		return nil, nil, connect.NewError(connect.CodePermissionDenied, fmt.Errorf("you must be a participant to update this bill"))
+	}
+	if err := checkUnlocked(existing); err != nil {
+		return nil, nil, err
 	}
 
-	participants := pbToModelParticipants(req.Msg.Participants)
+	if len(msg.UpdateMask.GetPaths()) > 0 {
+		patched := billToUpdateRequest(existing)
+		if err := applyUpdateMask(msg.UpdateMask, patched, msg); err != nil {
+			return nil, nil, err
+		}
+		patched.BillId = msg.BillId
+		patched.ItemValidation = msg.ItemValidation
+		patched.ItemTolerance = msg.ItemTolerance
+		patched.RemovedParticipantMode = msg.RemovedParticipantMode
+		patched.ExchangeRate = msg.ExchangeRate
+		msg = patched
+	}
+
+	if err := s.checkTitleRequired(ctx, msg.GetGroupId(), msg.Title); err != nil {
+		logger.Error(op+" title check failed", "error", err)
+		return nil, nil, err
+	}
+	if err := s.checkCategory(ctx, msg.CategoryId); err != nil {
+		return nil, nil, err
+	}
+	if err := checkBillDetails(msg.Note, msg.MerchantName, msg.Location, msg.BillDate, time.Now()); err != nil {
+		return nil, nil, err
+	}
+
+	participants := pbToModelParticipants(msg.Participants)
 
 	if err := validateRegisteredParticipants(ctx, s.store, userID, participants); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	items := make([]models.Item, len(req.Msg.Items))
-	for i, item := range req.Msg.Items {
-		items[i] = models.Item{
-			Description:  item.Description,
-			Amount:       item.Amount,
-			Participants: item.ParticipantIds,
+	if !msg.NeedsAssignment {
+		if err := validatePayerID(msg.GetPayerId(), participants); err != nil {
+			logger.Error(op+" payer validation failed", "error", err)
+			return nil, nil, connect.NewError(connect.CodeInvalidArgument, err)
 		}
 	}
 
-	if err := validatePayerID(req.Msg.GetPayerId(), participants); err != nil {
-		slog.Error("UpdateBill payer validation failed", "error", err)
-		return nil, connect.NewError(connect.CodeInvalidArgument, err)
-	}
+	bill = &models.Bill{
+		ID:              msg.BillId,
+		Title:           msg.Title,
+		Items:           pbToModelItems(msg.Items),
+		Total:           msg.Total,
+		Subtotal:        msg.Subtotal,
+		Tip:             msg.Tip,
+		TipSplitMode:    tipSplitModeFromProto(msg.TipSplitMode),
+		SplitType:       splitTypeFromProto(msg.SplitType),
+		Discount:        msg.Discount,
+		DiscountType:    discountTypeFromProto(msg.DiscountType),
+		Fees:            pbToModelFees(msg.Fees),
+		Currency:        normalizeCurrency(msg.Currency),
+		Participants:    participants,
+		NeedsAssignment: msg.NeedsAssignment,
+		RemainderMode:   remainderModeFromProto(msg.RemainderMode),
+		TaxInclusive:    msg.TaxInclusive,
+		TaxRate:         msg.TaxRate,
+		RoundingMode:    roundingModeFromProto(msg.RoundingMode),
+		CategoryID:      msg.CategoryId,
+		Note:            msg.Note,
+		MerchantName:    msg.MerchantName,
+		Location:        msg.Location,
+		BillDate:        msg.BillDate,
+		EditorID:        userID,
+	}
+	if msg.GetGroupId() != "" {
+		bill.GroupID = msg.GetGroupId()
+	}
+	if msg.GetPayerId() != "" {
+		bill.PayerID = msg.GetPayerId()
+	}
+
+	// The rate stays as locked unless the bill moves to another currency or group.
+	if bill.Currency == existing.Currency && bill.GroupID == existing.GroupID {
+		bill.ExchangeRate = existing.ExchangeRate
+	} else if err := s.lockExchangeRate(ctx, bill, msg.ExchangeRate); err != nil {
+		logger.Error(op+" exchange rate lookup failed", "error", err)
+		return nil, nil, err
+	}
+
+	if err := reassignRemovedItems(existing, bill, msg.RemovedParticipantMode); err != nil {
+		return nil, nil, err
+	}
+
+	if err := validateItemsAgainstSubtotal(bill, msg.ItemValidation, msg.ItemTolerance); err != nil {
+		logger.Error(op+" item validation failed", "error", err)
+		return nil, nil, err
+	}
+	if err := validateItemOrigins(bill.Items, existing); err != nil {
+		return nil, nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+	return existing, bill, nil
+}
 
-	bill := &models.Bill{
-		ID:           req.Msg.BillId,
-		Title:        req.Msg.Title,
-		Items:        items,
-		Total:        req.Msg.Total,
-		Subtotal:     req.Msg.Subtotal,
-		Participants: participants,
+// UpdateBill updates an existing bill.
+func (s *SplitService) UpdateBill(ctx context.Context, req *connect.Request[pb.UpdateBillRequest]) (*connect.Response[pb.UpdateBillResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
 	}
-	if req.Msg.GetGroupId() != "" {
-		bill.GroupID = req.Msg.GetGroupId()
+
+	existingBill, bill, err := s.billFromUpdate(ctx, "UpdateBill", userID, req.Msg)
+	if err != nil {
+		return nil, err
 	}
-	if req.Msg.GetPayerId() != "" {
-		bill.PayerID = req.Msg.GetPayerId()
+
+	split, err := billSplit(bill, false)
+	if err != nil {
+		logger.Error("CalculateSplit failed during UpdateBill", "error", err)
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
 	}
 
 	if err := s.store.UpdateBill(ctx, bill); err != nil {
-		slog.Error("UpdateBill failed", "error", err)
-		return nil, connect.NewError(connect.CodeNotFound, err)
+		logger.Error("UpdateBill failed", "error", err)
+		return nil, storeError(err)
 	}
 
-	s.autoAddParticipantsToGroup(ctx, bill.GroupID, bill.Participants, bill.PayerID)
+	if existingBill.NeedsAssignment && !bill.NeedsAssignment {
+		logger.Info("Finalized unassigned bill", "bill_id", bill.ID, "participants", len(bill.Participants))
+	}
+	if !bill.NeedsAssignment {
+		s.autoAddParticipantsToGroup(ctx, bill.GroupID, bill.Participants, bill.PayerID)
+	}
+	checkBalanceThresholds(ctx, s.store, s.webhooks, bill.GroupID)
+	if existingBill.GroupID != bill.GroupID {
+		checkBalanceThresholds(ctx, s.store, s.webhooks, existingBill.GroupID)
+	}
+	notifyBillChange(ctx, s.store, s.webhooks, webhook.EventBillUpdated, existingBill, bill)
 
-	displayNames := participantDisplayNames(participants)
-	calcItems := make([]calculator.Item, len(req.Msg.Items))
-	for i, item := range req.Msg.Items {
-		calcItems[i] = calculator.Item{
-			Description:  item.Description,
-			Amount:       item.Amount,
-			Participants: item.ParticipantIds,
-		}
+	return connect.NewResponse(&pb.UpdateBillResponse{
+		BillId: bill.ID,
+		Split:  split,
+	}), nil
+}
+
+// PreviewBillUpdate shows how an update would change each participant's share
+// and the group's balances, without persisting anything.
+func (s *SplitService) PreviewBillUpdate(ctx context.Context, req *connect.Request[pb.PreviewBillUpdateRequest]) (*connect.Response[pb.PreviewBillUpdateResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+	}
+	if req.Msg.Update == nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("update required"))
+	}
+
+	existingBill, bill, err := s.billFromUpdate(ctx, "PreviewBillUpdate", userID, req.Msg.Update)
+	if err != nil {
+		return nil, err
 	}
 
-	splits, err := calculator.CalculateSplit(calcItems, req.Msg.Total, req.Msg.Subtotal, displayNames)
+	before, err := billSplit(existingBill, false)
+	if err != nil {
+		logger.Error("PreviewBillUpdate failed to split existing bill", "bill_id", existingBill.ID, "error", err)
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	after, err := billSplit(bill, false)
 	if err != nil {
-		slog.Error("CalculateSplit failed during UpdateBill", "error", err)
 		return nil, connect.NewError(connect.CodeInvalidArgument, err)
 	}
 
-	protoSplits := make(map[string]*pb.PersonSplit)
-	for person, split := range splits {
-		protoItems := make([]*pb.PersonItem, len(split.Items))
-		for i, item := range split.Items {
-			protoItems[i] = &pb.PersonItem{
-				Description: item.Description,
-				Amount:      item.Amount,
-			}
-		}
-		protoSplits[person] = &pb.PersonSplit{
-			Subtotal: split.Subtotal,
-			Tax:      split.Tax,
-			Total:    split.Total,
-			Items:    protoItems,
+	resp := &pb.PreviewBillUpdateResponse{
+		Split:       after,
+		ShareDeltas: shareDeltas(before, after, bill.Currency),
+	}
+
+	groupID := bill.GroupID
+	if groupID == "" {
+		groupID = existingBill.GroupID
+	}
+	if groupID != "" {
+		memberBalances, debtEdges, _, err := computeGroupBalancesWith(ctx, s.store, groupID, splitmath.SimplifyGreedy, bill, 0)
+		if err != nil {
+			logger.Error("PreviewBillUpdate balance calc error", "group_id", groupID, "error", err)
+			return nil, connect.NewError(connect.CodeInternal, err)
 		}
+		resp.GroupId = &groupID
+		resp.MemberBalances = memberBalancesToProto(memberBalances)
+		resp.DebtMatrix = debtEdgesToProto(debtEdges)
 	}
 
-	return connect.NewResponse(&pb.UpdateBillResponse{
-		BillId: bill.ID,
-		Split: &pb.CalculateSplitResponse{
-			Splits:    protoSplits,
-			TaxAmount: req.Msg.Total - req.Msg.Subtotal,
-			Subtotal:  req.Msg.Subtotal,
-		},
-	}), nil
+	return connect.NewResponse(resp), nil
 }
 
-// DeleteBill deletes a bill.
+// shareDeltas compares each participant's total between two splits of a bill,
+// either of which may be nil for a parked bill.
+func shareDeltas(before, after *pb.CalculateSplitResponse, currency string) []*pb.ShareDelta {
+	var people []string
+	for person := range before.GetSplits() {
+		people = append(people, person)
+	}
+	for person := range after.GetSplits() {
+		if _, ok := before.GetSplits()[person]; !ok {
+			people = append(people, person)
+		}
+	}
+	slices.Sort(people)
+
+	deltas := make([]*pb.ShareDelta, len(people))
+	for i, person := range people {
+		was := before.GetSplits()[person].GetTotal()
+		now := after.GetSplits()[person].GetTotal()
+		deltas[i] = &pb.ShareDelta{
+			Participant: person,
+			Before:      was,
+			After:       now,
+			Delta:       splitmath.RoundAmount(now-was, currency),
+		}
+	}
+	return deltas
+}
+
+// DeleteBill moves a bill to the trash, from which RestoreBill can bring it back.
 func (s *SplitService) DeleteBill(ctx context.Context, req *connect.Request[pb.DeleteBillRequest]) (*connect.Response[pb.DeleteBillResponse], error) {
-	userID := middleware.GetUserID(ctx)
+	userID := authctx.UserID(ctx)
 	if userID == "" {
 		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
 	}
@@ -481,39 +1371,147 @@ func (s *SplitService) DeleteBill(ctx context.Context, req *connect.Request[pb.D
 
 	existingBill, err := s.store.GetBill(ctx, req.Msg.BillId)
 	if err != nil {
-		slog.Error("DeleteBill: failed to get existing bill", "bill_id", req.Msg.BillId, "error", err)
-		return nil, connect.NewError(connect.CodeNotFound, err)
+		logger.Error("DeleteBill: failed to get existing bill", "bill_id", req.Msg.BillId, "error", err)
+		return nil, storeError(err)
 	}
 
 	if !hasAccess(userID, existingBill) {
 		return nil, connect.NewError(connect.CodePermissionDenied, fmt.Errorf("you must be a participant to delete this bill"))
 	}
+	if err := checkUnlocked(existingBill); err != nil {
+		return nil, err
+	}
 
 	if err := s.store.DeleteBill(ctx, req.Msg.BillId); err != nil {
-		slog.Error("DeleteBill failed", "error", err)
-		return nil, connect.NewError(connect.CodeNotFound, err)
+		logger.Error("DeleteBill failed", "error", err)
+		return nil, storeError(err)
 	}
+	checkBalanceThresholds(ctx, s.store, s.webhooks, existingBill.GroupID)
+	notifyBillChange(ctx, s.store, s.webhooks, webhook.EventBillDeleted, existingBill)
 
 	return connect.NewResponse(&pb.DeleteBillResponse{}), nil
 }
 
 // ListMyBills retrieves all bills where the authenticated user is a participant.
 func (s *SplitService) ListMyBills(ctx context.Context, req *connect.Request[pb.ListMyBillsRequest]) (*connect.Response[pb.ListMyBillsResponse], error) {
-	userID := middleware.GetUserID(ctx)
+	userID := authctx.UserID(ctx)
 	if userID == "" {
 		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
 	}
 
-	bills, err := s.store.ListBillsByUser(ctx, userID)
+	mask, err := parseFieldMask(req.Msg.FieldMask, &pb.ListMyBillsResponse{})
 	if err != nil {
-		slog.Error("ListMyBills failed", "error", err)
-		return nil, connect.NewError(connect.CodeInternal, err)
+		return nil, err
+	}
+
+	bills, err := s.store.ListBillsByUser(ctx, userID, storage.BillFilter{ExcludeArchived: !req.Msg.IncludeArchived})
+	if err != nil {
+		logger.Error("ListMyBills failed", "error", err)
+		return nil, storeError(err)
+	}
+	summaries := s.userBillSummaries(ctx, bills, mask)
+
+	resp := &pb.ListMyBillsResponse{Bills: summaries}
+	mask.apply(resp)
+	return connect.NewResponse(resp), nil
+}
+
+// ListBills retrieves a page of the bills the authenticated user created or
+// participates in, grouped and ungrouped, newest first.
+func (s *SplitService) ListBills(ctx context.Context, req *connect.Request[pb.ListBillsRequest]) (*connect.Response[pb.ListBillsResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
 	}
 
+	mask, err := parseFieldMask(req.Msg.FieldMask, &pb.ListBillsResponse{})
+	if err != nil {
+		return nil, err
+	}
+	filter, err := s.listBillsFilter(ctx, userID, req.Msg)
+	if err != nil {
+		return nil, err
+	}
+	page := filter.Page
+
+	bills, err := s.store.ListBillsByUser(ctx, userID, filter)
+	if err != nil {
+		logger.Error("ListBills failed", "error", err)
+		return nil, storeError(err)
+	}
+	bills, nextPageToken := pageResults(bills, page)
+
+	resp := &pb.ListBillsResponse{
+		Bills:         s.userBillSummaries(ctx, bills, mask),
+		NextPageToken: nextPageToken,
+	}
+	mask.apply(resp)
+	return connect.NewResponse(resp), nil
+}
+
+// listBillsFilter validates the filter and page fields of a ListBills
+// request. A view_id stands for the filter the caller saved in that view.
+// Archived bills are left out unless the request includes them.
+func (s *SplitService) listBillsFilter(ctx context.Context, userID string, msg *pb.ListBillsRequest) (storage.BillFilter, error) {
+	if msg.ViewId == "" {
+		filter, err := billFilter(msg.GetGroupId(), msg.Ungrouped, msg.Since, msg.Until, msg.PageSize, msg.PageToken)
+		if err != nil {
+			return storage.BillFilter{}, err
+		}
+		filter.CategoryID = msg.GetCategoryId()
+		if msg.PaidByMe {
+			filter.PaidBy = userID
+		}
+		filter.ExcludeArchived = !msg.IncludeArchived
+		return filter, nil
+	}
+
+	if msg.GroupId != nil || msg.Ungrouped || msg.Since != 0 || msg.Until != 0 || msg.CategoryId != nil || msg.PaidByMe {
+		return storage.BillFilter{}, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("view_id can't be combined with other filters"))
+	}
+	view, err := s.ownBillView(ctx, userID, msg.ViewId)
+	if err != nil {
+		return storage.BillFilter{}, err
+	}
+	page, err := pageRequest(msg.PageSize, msg.PageToken)
+	if err != nil {
+		return storage.BillFilter{}, err
+	}
+	filter := billViewFilter(view, time.Now())
+	filter.ExcludeArchived = !msg.IncludeArchived
+	filter.Page = page
+	return filter, nil
+}
+
+// billFilter validates the filter and page fields of a bill listing request.
+func billFilter(groupID string, ungrouped bool, since, until int64, pageSize int32, pageToken string) (storage.BillFilter, error) {
+	if groupID != "" && ungrouped {
+		return storage.BillFilter{}, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("group_id and ungrouped can't be combined"))
+	}
+	if since != 0 && until != 0 && since >= until {
+		return storage.BillFilter{}, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("since must be before until"))
+	}
+	page, err := pageRequest(pageSize, pageToken)
+	if err != nil {
+		return storage.BillFilter{}, err
+	}
+	return storage.BillFilter{
+		GroupID:   groupID,
+		Ungrouped: ungrouped,
+		Since:     since,
+		Until:     until,
+		Page:      page,
+	}, nil
+}
+
+// userBillSummaries converts bills listed for a user to summaries, with the
+// names of their groups and their payment statuses unless mask leaves them
+// out.
+func (s *SplitService) userBillSummaries(ctx context.Context, bills []*models.Bill, mask maskTree) []*pb.BillSummary {
 	// Collect unique group IDs to fetch names
 	groupIDs := make(map[string]struct{})
 	for _, bill := range bills {
-		if bill.GroupID != "" {
+		if bill.GroupID != "" && mask.has("bills", "group_name") {
 			groupIDs[bill.GroupID] = struct{}{}
 		}
 	}
@@ -524,6 +1522,8 @@ func (s *SplitService) ListMyBills(ctx context.Context, req *connect.Request[pb.
 		}
 	}
 
+	statuses := s.summaryPaymentStatuses(ctx, bills, mask)
+
 	summaries := make([]*pb.BillSummary, len(bills))
 	for i, bill := range bills {
 		s := &pb.BillSummary{
@@ -533,6 +1533,15 @@ func (s *SplitService) ListMyBills(ctx context.Context, req *connect.Request[pb.
 			PayerId:          bill.PayerID,
 			CreatedAt:        bill.CreatedAt,
 			ParticipantCount: int32(len(bill.Participants)),
+			NeedsAssignment:  bill.NeedsAssignment,
+			Currency:         bill.Currency,
+			DeletedAt:        bill.DeletedAt,
+			CategoryId:       bill.CategoryID,
+			ArchivedAt:       bill.ArchivedAt,
+			PaymentStatus:    statuses[bill.ID],
+			LockedAt:         bill.LockedAt,
+			MerchantName:     bill.MerchantName,
+			BillDate:         bill.BillDate,
 		}
 		if bill.GroupID != "" {
 			gid := bill.GroupID
@@ -543,33 +1552,48 @@ func (s *SplitService) ListMyBills(ctx context.Context, req *connect.Request[pb.
 		}
 		summaries[i] = s
 	}
-
-	return connect.NewResponse(&pb.ListMyBillsResponse{Bills: summaries}), nil
+	return summaries
 }
 
 // ListBillsByGroup retrieves all bills associated with a group.
 func (s *SplitService) ListBillsByGroup(ctx context.Context, req *connect.Request[pb.ListBillsByGroupRequest]) (*connect.Response[pb.ListBillsByGroupResponse], error) {
-	userID := middleware.GetUserID(ctx)
+	userID := authctx.UserID(ctx)
 	if userID == "" {
 		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
 	}
 
 	group, err := s.store.GetGroup(ctx, req.Msg.GroupId)
 	if err != nil {
-		slog.Error("ListBillsByGroup: failed to get group", "group_id", req.Msg.GroupId, "error", err)
-		return nil, connect.NewError(connect.CodeNotFound, err)
+		logger.Error("ListBillsByGroup: failed to get group", "group_id", req.Msg.GroupId, "error", err)
+		return nil, storeError(err)
 	}
 
 	if !isMember(userID, group.Members) {
 		return nil, connect.NewError(connect.CodePermissionDenied, fmt.Errorf("you must be a member of this group"))
 	}
 
-	bills, err := s.store.ListBillsByGroup(ctx, req.Msg.GroupId)
+	mask, err := parseFieldMask(req.Msg.FieldMask, &pb.ListBillsByGroupResponse{})
 	if err != nil {
-		slog.Error("ListBillsByGroup failed", "group_id", req.Msg.GroupId, "error", err)
-		return nil, connect.NewError(connect.CodeInternal, err)
+		return nil, err
+	}
+
+	page, err := pageRequest(req.Msg.PageSize, req.Msg.PageToken)
+	if err != nil {
+		return nil, err
 	}
 
+	bills, err := s.store.ListBillsByGroup(ctx, req.Msg.GroupId, storage.BillFilter{
+		CategoryID:      req.Msg.GetCategoryId(),
+		ExcludeArchived: !req.Msg.IncludeArchived,
+		Page:            page,
+	})
+	if err != nil {
+		logger.Error("ListBillsByGroup failed", "group_id", req.Msg.GroupId, "error", err)
+		return nil, storeError(err)
+	}
+	bills, nextPageToken := pageResults(bills, page)
+	statuses := s.summaryPaymentStatuses(ctx, bills, mask)
+
 	summaries := make([]*pb.BillSummary, len(bills))
 	for i, bill := range bills {
 		summaries[i] = &pb.BillSummary{
@@ -579,17 +1603,72 @@ func (s *SplitService) ListBillsByGroup(ctx context.Context, req *connect.Reques
 			PayerId:          bill.PayerID,
 			CreatedAt:        bill.CreatedAt,
 			ParticipantCount: int32(len(bill.Participants)),
+			NeedsAssignment:  bill.NeedsAssignment,
+			Currency:         bill.Currency,
+			CategoryId:       bill.CategoryID,
+			ArchivedAt:       bill.ArchivedAt,
+			PaymentStatus:    statuses[bill.ID],
+			LockedAt:         bill.LockedAt,
+			MerchantName:     bill.MerchantName,
+			BillDate:         bill.BillDate,
 		}
 	}
 
-	return connect.NewResponse(&pb.ListBillsByGroupResponse{
-		Bills: summaries,
-	}), nil
+	resp := &pb.ListBillsByGroupResponse{
+		Bills:         summaries,
+		NextPageToken: nextPageToken,
+	}
+	mask.apply(resp)
+	return connect.NewResponse(resp), nil
+}
+
+// ListUnassignedBills retrieves bills awaiting participant assignment that the
+// authenticated user created or that belong to one of the user's groups.
+func (s *SplitService) ListUnassignedBills(ctx context.Context, req *connect.Request[pb.ListUnassignedBillsRequest]) (*connect.Response[pb.ListUnassignedBillsResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+	}
+
+	mask, err := parseFieldMask(req.Msg.FieldMask, &pb.ListUnassignedBillsResponse{})
+	if err != nil {
+		return nil, err
+	}
+
+	bills, err := s.store.ListUnassignedBillsByUser(ctx, userID)
+	if err != nil {
+		logger.Error("ListUnassignedBills failed", "error", err)
+		return nil, storeError(err)
+	}
+
+	summaries := make([]*pb.BillSummary, len(bills))
+	for i, bill := range bills {
+		summaries[i] = &pb.BillSummary{
+			BillId:          bill.ID,
+			Title:           bill.Title,
+			Total:           bill.Total,
+			PayerId:         bill.PayerID,
+			CreatedAt:       bill.CreatedAt,
+			NeedsAssignment: true,
+			Currency:        bill.Currency,
+			CategoryId:      bill.CategoryID,
+			MerchantName:    bill.MerchantName,
+			BillDate:        bill.BillDate,
+		}
+		if bill.GroupID != "" {
+			gid := bill.GroupID
+			summaries[i].GroupId = &gid
+		}
+	}
+
+	resp := &pb.ListUnassignedBillsResponse{Bills: summaries}
+	mask.apply(resp)
+	return connect.NewResponse(resp), nil
 }
 
 // SearchUsers finds a registered user by exact email address (excluding the caller).
 func (s *SplitService) SearchUsers(ctx context.Context, req *connect.Request[pb.SearchUsersRequest]) (*connect.Response[pb.SearchUsersResponse], error) {
-	userID := middleware.GetUserID(ctx)
+	userID := authctx.UserID(ctx)
 	if userID == "" {
 		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
 	}
@@ -601,8 +1680,8 @@ func (s *SplitService) SearchUsers(ctx context.Context, req *connect.Request[pb.
 
 	user, err := s.store.SearchUsers(ctx, email, userID)
 	if err != nil {
-		slog.Error("SearchUsers failed", "error", err)
-		return nil, connect.NewError(connect.CodeInternal, err)
+		logger.Error("SearchUsers failed", "error", err)
+		return nil, storeError(err)
 	}
 
 	if user == nil {
@@ -2,17 +2,24 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"math"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"connectrpc.com/connect"
-	"github.com/mmynk/splitwiser/internal/middleware"
+	"github.com/mmynk/splitwiser/internal/authctx"
 	"github.com/mmynk/splitwiser/internal/models"
+	"github.com/mmynk/splitwiser/internal/quota"
 	"github.com/mmynk/splitwiser/internal/storage/sqlite"
 	pb "github.com/mmynk/splitwiser/pkg/proto"
 	"github.com/mmynk/splitwiser/pkg/proto/protoconnect"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
 )
 
 const testUserID = "test-user-uuid-alice"
@@ -21,7 +28,7 @@ const testUserID = "test-user-uuid-alice"
 func testAuthInterceptor() connect.UnaryInterceptorFunc {
 	return func(next connect.UnaryFunc) connect.UnaryFunc {
 		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
-			ctx = context.WithValue(ctx, middleware.UserIDKey, testUserID)
+			ctx = authctx.WithUser(ctx, authctx.Principal{ID: testUserID})
 			return next(ctx, req)
 		}
 	}
@@ -42,6 +49,12 @@ func guestBP(name string) *pb.BillParticipant {
 // It also creates the test user (Alice) in the DB so resolveDisplayName works.
 func setupTestServerWithGroupService(t *testing.T) (protoconnect.SplitServiceClient, protoconnect.GroupServiceClient, func()) {
 	t.Helper()
+	return setupTestServerWithQuotas(t, quota.Limits{})
+}
+
+// setupTestServerWithQuotas is setupTestServerWithGroupService with the given quota limits enforced.
+func setupTestServerWithQuotas(t *testing.T, limits quota.Limits) (protoconnect.SplitServiceClient, protoconnect.GroupServiceClient, func()) {
+	t.Helper()
 
 	tmpFile, err := os.CreateTemp("", "test-*.db")
 	if err != nil {
@@ -70,10 +83,11 @@ func setupTestServerWithGroupService(t *testing.T) (protoconnect.SplitServiceCli
 	}
 
 	authInterceptor := connect.WithInterceptors(testAuthInterceptor())
-	splitSvc := NewSplitService(store)
+	quotas := WithQuotas(quota.NewEnforcer(store, limits))
+	splitSvc := NewSplitService(store, quotas)
 	splitPath, splitHandler := protoconnect.NewSplitServiceHandler(splitSvc, authInterceptor)
 
-	groupSvc := NewGroupService(store)
+	groupSvc := NewGroupService(store, quotas)
 	groupPath, groupHandler := protoconnect.NewGroupServiceHandler(groupSvc, authInterceptor)
 
 	mux := http.NewServeMux()
@@ -777,6 +791,133 @@ func TestDeleteBill_NotFound(t *testing.T) {
 	}
 }
 
+func TestItemOrigin(t *testing.T) {
+	client, cleanup := setupTestServer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	parsed := &pb.ItemOrigin{Source: "receipt", Description: "PIZA MARG", Amount: 12, Quantity: 1, UnitPrice: 12}
+	createResp, err := client.CreateBill(ctx, connect.NewRequest(&pb.CreateBillRequest{
+		Title:    "Dinner",
+		Total:    20,
+		Subtotal: 20,
+		Items: []*pb.Item{
+			{Description: "PIZA MARG", Amount: 12, Quantity: 1, UnitPrice: 12, ParticipantIds: []string{"Alice", "Bob"}, Origin: parsed},
+			{Description: "Wine", Amount: 8, ParticipantIds: []string{"Alice", "Bob"}},
+		},
+		Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+	}))
+	if err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+	billID := createResp.Msg.BillId
+
+	getItems := func() []*pb.Item {
+		t.Helper()
+		resp, err := client.GetBill(ctx, connect.NewRequest(&pb.GetBillRequest{BillId: billID}))
+		if err != nil {
+			t.Fatalf("GetBill failed: %v", err)
+		}
+		return resp.Msg.Items
+	}
+	items := getItems()
+	if !proto.Equal(items[0].Origin, parsed) || items[0].Edited || items[1].Origin != nil {
+		t.Fatalf("items = %v, want the parsed item unedited with its origin and the other without", items)
+	}
+
+	// Correcting the parsed item keeps its origin and marks it edited.
+	update := func(origin *pb.ItemOrigin) error {
+		_, err := client.UpdateBill(ctx, connect.NewRequest(&pb.UpdateBillRequest{
+			BillId:   billID,
+			Title:    "Dinner",
+			Total:    21,
+			Subtotal: 21,
+			Items: []*pb.Item{
+				{Description: "Pizza margherita", Amount: 13, Quantity: 1, UnitPrice: 13, ParticipantIds: []string{"Alice", "Bob"}, Origin: origin},
+				{Description: "Wine", Amount: 8, ParticipantIds: []string{"Alice", "Bob"}},
+			},
+			Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+		}))
+		return err
+	}
+	if err := update(items[0].Origin); err != nil {
+		t.Fatalf("UpdateBill failed: %v", err)
+	}
+	items = getItems()
+	if !proto.Equal(items[0].Origin, parsed) || !items[0].Edited || items[0].Amount != 13 {
+		t.Errorf("corrected item = %v, want amount 13, edited, with the parsed origin", items[0])
+	}
+
+	// The parsed values themselves can't be rewritten.
+	forged := &pb.ItemOrigin{Source: "receipt", Description: "Pizza margherita", Amount: 13, Quantity: 1, UnitPrice: 13}
+	if err := update(forged); connect.CodeOf(err) != connect.CodeInvalidArgument {
+		t.Errorf("rewriting an origin: code = %v, want InvalidArgument", connect.CodeOf(err))
+	}
+}
+
+func TestRestoreAndPurgeBill(t *testing.T) {
+	client, cleanup := setupTestServer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	createResp, err := client.CreateBill(ctx, connect.NewRequest(&pb.CreateBillRequest{
+		Title:        "Dinner",
+		Total:        30,
+		Subtotal:     30,
+		Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+	}))
+	if err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+	billID := createResp.Msg.BillId
+
+	// Purging a live bill is refused: it has to go to the trash first.
+	_, err = client.PurgeBill(ctx, connect.NewRequest(&pb.PurgeBillRequest{BillId: billID}))
+	if connect.CodeOf(err) != connect.CodeNotFound {
+		t.Fatalf("PurgeBill on a live bill: code = %v, want NotFound", connect.CodeOf(err))
+	}
+
+	if _, err := client.DeleteBill(ctx, connect.NewRequest(&pb.DeleteBillRequest{BillId: billID})); err != nil {
+		t.Fatalf("DeleteBill failed: %v", err)
+	}
+	myBills, err := client.ListMyBills(ctx, connect.NewRequest(&pb.ListMyBillsRequest{}))
+	if err != nil {
+		t.Fatalf("ListMyBills failed: %v", err)
+	}
+	if len(myBills.Msg.Bills) != 0 {
+		t.Errorf("ListMyBills returned %d bills, want the deleted bill hidden", len(myBills.Msg.Bills))
+	}
+	trash, err := client.ListDeletedBills(ctx, connect.NewRequest(&pb.ListDeletedBillsRequest{}))
+	if err != nil {
+		t.Fatalf("ListDeletedBills failed: %v", err)
+	}
+	if len(trash.Msg.Bills) != 1 || trash.Msg.Bills[0].BillId != billID || trash.Msg.Bills[0].DeletedAt == 0 {
+		t.Fatalf("trash = %v, want the deleted bill with its deletion time", trash.Msg.Bills)
+	}
+
+	if _, err := client.RestoreBill(ctx, connect.NewRequest(&pb.RestoreBillRequest{BillId: billID})); err != nil {
+		t.Fatalf("RestoreBill failed: %v", err)
+	}
+	getResp, err := client.GetBill(ctx, connect.NewRequest(&pb.GetBillRequest{BillId: billID}))
+	if err != nil {
+		t.Fatalf("GetBill after restore failed: %v", err)
+	}
+	if getResp.Msg.Title != "Dinner" || len(getResp.Msg.Participants) != 2 {
+		t.Errorf("restored bill = %q with %d participants, want Dinner with 2", getResp.Msg.Title, len(getResp.Msg.Participants))
+	}
+
+	if _, err := client.DeleteBill(ctx, connect.NewRequest(&pb.DeleteBillRequest{BillId: billID})); err != nil {
+		t.Fatalf("DeleteBill failed: %v", err)
+	}
+	if _, err := client.PurgeBill(ctx, connect.NewRequest(&pb.PurgeBillRequest{BillId: billID})); err != nil {
+		t.Fatalf("PurgeBill failed: %v", err)
+	}
+	_, err = client.RestoreBill(ctx, connect.NewRequest(&pb.RestoreBillRequest{BillId: billID}))
+	if connect.CodeOf(err) != connect.CodeNotFound {
+		t.Errorf("RestoreBill after purge: code = %v, want NotFound", connect.CodeOf(err))
+	}
+}
+
 func TestCreateBill_NonParticipantCreator(t *testing.T) {
 	client, cleanup := setupTestServer(t)
 	defer cleanup()
@@ -1006,6 +1147,99 @@ func TestListMyBills(t *testing.T) {
 	}
 }
 
+func TestListBills(t *testing.T) {
+	splitClient, groupClient, cleanup := setupTestServerWithGroupService(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	groupResp, err := groupClient.CreateGroup(ctx, connect.NewRequest(&pb.CreateGroupRequest{
+		Name:    "Flat",
+		Members: []*pb.GroupMember{{DisplayName: "Bob"}},
+	}))
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	groupID := groupResp.Msg.Group.Id
+
+	for i := range 4 {
+		req := &pb.CreateBillRequest{
+			Title:        fmt.Sprintf("Bill %d", i),
+			Total:        10,
+			Subtotal:     10,
+			Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+		}
+		if i%2 == 0 {
+			req.GroupId = &groupID
+		}
+		if _, err := splitClient.CreateBill(ctx, connect.NewRequest(req)); err != nil {
+			t.Fatalf("CreateBill %d failed: %v", i, err)
+		}
+	}
+	if _, err := splitClient.CreateBill(ctx, connect.NewRequest(&pb.CreateBillRequest{
+		Title:           "Parked",
+		Total:           10,
+		Subtotal:        10,
+		NeedsAssignment: true,
+	})); err != nil {
+		t.Fatalf("CreateBill parked failed: %v", err)
+	}
+
+	list := func(req *pb.ListBillsRequest) *pb.ListBillsResponse {
+		t.Helper()
+		resp, err := splitClient.ListBills(ctx, connect.NewRequest(req))
+		if err != nil {
+			t.Fatalf("ListBills failed: %v", err)
+		}
+		return resp.Msg
+	}
+
+	// Paging covers every bill once, newest first.
+	var all []*pb.BillSummary
+	req := &pb.ListBillsRequest{PageSize: 2}
+	for {
+		resp := list(req)
+		all = append(all, resp.Bills...)
+		if resp.NextPageToken == "" {
+			break
+		}
+		req.PageToken = resp.NextPageToken
+	}
+	if len(all) != 5 {
+		t.Fatalf("paged through %d bills, want 5", len(all))
+	}
+	var parked int
+	for i, b := range all {
+		if i > 0 && b.CreatedAt > all[i-1].CreatedAt {
+			t.Errorf("bills not newest first: %d after %d", b.CreatedAt, all[i-1].CreatedAt)
+		}
+		if b.NeedsAssignment {
+			parked++
+		}
+	}
+	if parked != 1 {
+		t.Errorf("%d bills reported as awaiting assignment, want 1", parked)
+	}
+
+	if got := list(&pb.ListBillsRequest{GroupId: &groupID}).Bills; len(got) != 2 || got[0].GetGroupName() != "Flat" {
+		t.Errorf("group filter returned %v, want the 2 Flat bills", got)
+	}
+	if got := list(&pb.ListBillsRequest{Ungrouped: true}).Bills; len(got) != 3 {
+		t.Errorf("ungrouped filter returned %d bills, want 3", len(got))
+	}
+	future := time.Now().Add(time.Hour).Unix()
+	if got := list(&pb.ListBillsRequest{Since: future}).Bills; len(got) != 0 {
+		t.Errorf("since filter returned %d bills, want none", len(got))
+	}
+	if got := list(&pb.ListBillsRequest{Until: future}).Bills; len(got) != 5 {
+		t.Errorf("until filter returned %d bills, want 5", len(got))
+	}
+
+	_, err = splitClient.ListBills(ctx, connect.NewRequest(&pb.ListBillsRequest{GroupId: &groupID, Ungrouped: true}))
+	if connect.CodeOf(err) != connect.CodeInvalidArgument {
+		t.Errorf("group_id with ungrouped: got %v, want InvalidArgument", err)
+	}
+}
+
 func TestSearchUsers(t *testing.T) {
 	// setupTestServerWithFriendService already creates Alice (auth user) and Bob
 	client, _, _, _, cleanup := setupTestServerWithFriendService(t)
@@ -1143,3 +1377,990 @@ func TestCreateBill_GuestParticipant(t *testing.T) {
 		t.Error("guest participant not found in response")
 	}
 }
+
+func TestCreateBill_TipSplitEqual(t *testing.T) {
+	client, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	// Alice 20, Bob 10; tax 3 proportional, tip 6 split evenly.
+	createResp, err := client.CreateBill(context.Background(), connect.NewRequest(&pb.CreateBillRequest{
+		Title: "Tipped Dinner",
+		Items: []*pb.Item{
+			{Description: "Steak", Amount: 20, ParticipantIds: []string{"Alice"}},
+			{Description: "Salad", Amount: 10, ParticipantIds: []string{"Bob"}},
+		},
+		Total:        39,
+		Subtotal:     30,
+		Tip:          6,
+		TipSplitMode: pb.TipSplitMode_TIP_SPLIT_MODE_EQUAL,
+		Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+	}))
+	if err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+
+	split := createResp.Msg.Split
+	if split.TaxAmount != 3 || split.TipAmount != 6 {
+		t.Errorf("expected tax 3 and tip 6, got %f and %f", split.TaxAmount, split.TipAmount)
+	}
+	if got := split.Splits["Alice"].Total; got != 25 {
+		t.Errorf("Alice total: expected 25, got %f", got)
+	}
+	if got := split.Splits["Bob"].Total; got != 14 {
+		t.Errorf("Bob total: expected 14, got %f", got)
+	}
+
+	getResp, err := client.GetBill(context.Background(), connect.NewRequest(&pb.GetBillRequest{
+		BillId: createResp.Msg.BillId,
+	}))
+	if err != nil {
+		t.Fatalf("GetBill failed: %v", err)
+	}
+	if getResp.Msg.Tip != 6 {
+		t.Errorf("tip: expected 6, got %f", getResp.Msg.Tip)
+	}
+	if getResp.Msg.TipSplitMode != pb.TipSplitMode_TIP_SPLIT_MODE_EQUAL {
+		t.Errorf("tip_split_mode: expected EQUAL, got %v", getResp.Msg.TipSplitMode)
+	}
+	if got := getResp.Msg.Split.Splits["Bob"].Tip; got != 3 {
+		t.Errorf("Bob tip: expected 3, got %f", got)
+	}
+}
+
+func TestCreateBill_SharesSplit(t *testing.T) {
+	client, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	// Alice counts as 2 shares on the bill; Bob has 3 shares of the wine.
+	alice := aliceBP()
+	alice.Shares = 2
+	createResp, err := client.CreateBill(context.Background(), connect.NewRequest(&pb.CreateBillRequest{
+		Title: "Weighted Dinner",
+		Items: []*pb.Item{
+			{Description: "Pizza", Amount: 30, ParticipantIds: []string{"Alice", "Bob"}},
+			{Description: "Wine", Amount: 40, ParticipantIds: []string{"Alice", "Bob"}, Shares: map[string]float64{"Bob": 3}},
+		},
+		Total:        70,
+		Subtotal:     70,
+		SplitType:    pb.SplitType_SPLIT_TYPE_SHARES,
+		Participants: []*pb.BillParticipant{alice, guestBP("Bob")},
+	}))
+	if err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+
+	// Pizza 20/10, wine 16/24.
+	split := createResp.Msg.Split
+	if got := split.Splits["Alice"].Total; got != 36 {
+		t.Errorf("Alice total: expected 36, got %f", got)
+	}
+	if got := split.Splits["Bob"].Total; got != 34 {
+		t.Errorf("Bob total: expected 34, got %f", got)
+	}
+
+	getResp, err := client.GetBill(context.Background(), connect.NewRequest(&pb.GetBillRequest{
+		BillId: createResp.Msg.BillId,
+	}))
+	if err != nil {
+		t.Fatalf("GetBill failed: %v", err)
+	}
+	if getResp.Msg.SplitType != pb.SplitType_SPLIT_TYPE_SHARES {
+		t.Errorf("split_type: expected SHARES, got %v", getResp.Msg.SplitType)
+	}
+	for _, p := range getResp.Msg.Participants {
+		if p.DisplayName == "Alice" && p.Shares != 2 {
+			t.Errorf("Alice shares: expected 2, got %f", p.Shares)
+		}
+	}
+	if got := getResp.Msg.Split.Splits["Alice"].Total; got != 36 {
+		t.Errorf("Alice total after reload: expected 36, got %f", got)
+	}
+}
+
+func TestCreateBill_ExactSplit(t *testing.T) {
+	client, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	alice := aliceBP()
+	alice.Amount = 35
+	bob := guestBP("Bob")
+	bob.Amount = 15
+	createResp, err := client.CreateBill(context.Background(), connect.NewRequest(&pb.CreateBillRequest{
+		Title:        "Exact Dinner",
+		Total:        50,
+		Subtotal:     50,
+		SplitType:    pb.SplitType_SPLIT_TYPE_EXACT,
+		Participants: []*pb.BillParticipant{alice, bob},
+	}))
+	if err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+	if got := createResp.Msg.Split.Splits["Alice"].Total; got != 35 {
+		t.Errorf("Alice total: expected 35, got %f", got)
+	}
+
+	getResp, err := client.GetBill(context.Background(), connect.NewRequest(&pb.GetBillRequest{
+		BillId: createResp.Msg.BillId,
+	}))
+	if err != nil {
+		t.Fatalf("GetBill failed: %v", err)
+	}
+	if getResp.Msg.SplitType != pb.SplitType_SPLIT_TYPE_EXACT {
+		t.Errorf("split_type: expected EXACT, got %v", getResp.Msg.SplitType)
+	}
+	if got := getResp.Msg.Split.Splits["Bob"].Total; got != 15 {
+		t.Errorf("Bob total after reload: expected 15, got %f", got)
+	}
+}
+
+func TestCreateBill_ExactSplitMismatch_Rejected(t *testing.T) {
+	client, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	alice := aliceBP()
+	alice.Amount = 30
+	bob := guestBP("Bob")
+	bob.Amount = 15
+	_, err := client.CreateBill(context.Background(), connect.NewRequest(&pb.CreateBillRequest{
+		Title:        "Bad Exact",
+		Total:        50,
+		Subtotal:     50,
+		SplitType:    pb.SplitType_SPLIT_TYPE_EXACT,
+		Participants: []*pb.BillParticipant{alice, bob},
+	}))
+	if connect.CodeOf(err) != connect.CodeInvalidArgument {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+}
+
+func TestCreateBill_NegativeTip_Rejected(t *testing.T) {
+	client, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	_, err := client.CreateBill(context.Background(), connect.NewRequest(&pb.CreateBillRequest{
+		Title:        "Bad Tip",
+		Total:        10,
+		Subtotal:     10,
+		Tip:          -2,
+		Participants: []*pb.BillParticipant{aliceBP()},
+	}))
+	if connect.CodeOf(err) != connect.CodeInvalidArgument {
+		t.Fatalf("expected CodeInvalidArgument, got %v", err)
+	}
+
+	bills, err := client.ListMyBills(context.Background(), connect.NewRequest(&pb.ListMyBillsRequest{}))
+	if err != nil {
+		t.Fatalf("ListMyBills failed: %v", err)
+	}
+	if len(bills.Msg.Bills) != 0 {
+		t.Errorf("expected rejected bill not to be stored, got %d bills", len(bills.Msg.Bills))
+	}
+}
+
+func TestUnassignedBill_ParkAndFinalize(t *testing.T) {
+	splitClient, groupClient, cleanup := setupTestServerWithGroupService(t)
+	defer cleanup()
+
+	groupResp, err := groupClient.CreateGroup(context.Background(), connect.NewRequest(&pb.CreateGroupRequest{
+		Name:    "Trip",
+		Members: []*pb.GroupMember{{DisplayName: "Bob"}},
+	}))
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	groupID := groupResp.Msg.Group.Id
+
+	createResp, err := splitClient.CreateBill(context.Background(), connect.NewRequest(&pb.CreateBillRequest{
+		Title:           "Groceries",
+		Items:           []*pb.Item{{Description: "Produce", Amount: 40}},
+		Total:           40,
+		Subtotal:        40,
+		PayerId:         strPtr("Alice"),
+		GroupId:         &groupID,
+		NeedsAssignment: true,
+	}))
+	if err != nil {
+		t.Fatalf("CreateBill (parked) failed: %v", err)
+	}
+	if createResp.Msg.Split != nil {
+		t.Errorf("expected no split for parked bill, got %v", createResp.Msg.Split)
+	}
+	billID := createResp.Msg.BillId
+
+	unassigned, err := splitClient.ListUnassignedBills(context.Background(), connect.NewRequest(&pb.ListUnassignedBillsRequest{}))
+	if err != nil {
+		t.Fatalf("ListUnassignedBills failed: %v", err)
+	}
+	if len(unassigned.Msg.Bills) != 1 || unassigned.Msg.Bills[0].BillId != billID {
+		t.Fatalf("expected parked bill in unassigned list, got %v", unassigned.Msg.Bills)
+	}
+
+	balResp, err := groupClient.GetGroupBalances(context.Background(), connect.NewRequest(&pb.GetGroupBalancesRequest{
+		GroupId: groupID,
+	}))
+	if err != nil {
+		t.Fatalf("GetGroupBalances failed: %v", err)
+	}
+	if len(balResp.Msg.DebtMatrix) != 0 {
+		t.Errorf("expected parked bill to be excluded from balances, got %v", balResp.Msg.DebtMatrix)
+	}
+
+	// Finalize by assigning participants.
+	updateResp, err := splitClient.UpdateBill(context.Background(), connect.NewRequest(&pb.UpdateBillRequest{
+		BillId:       billID,
+		Title:        "Groceries",
+		Items:        []*pb.Item{{Description: "Produce", Amount: 40}},
+		Total:        40,
+		Subtotal:     40,
+		Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+		PayerId:      strPtr("Alice"),
+		GroupId:      &groupID,
+	}))
+	if err != nil {
+		t.Fatalf("UpdateBill (finalize) failed: %v", err)
+	}
+	if got := updateResp.Msg.Split.Splits["Bob"].Total; got != 20 {
+		t.Errorf("Bob total: expected 20, got %f", got)
+	}
+
+	unassigned, err = splitClient.ListUnassignedBills(context.Background(), connect.NewRequest(&pb.ListUnassignedBillsRequest{}))
+	if err != nil {
+		t.Fatalf("ListUnassignedBills failed: %v", err)
+	}
+	if len(unassigned.Msg.Bills) != 0 {
+		t.Errorf("expected no unassigned bills after finalizing, got %d", len(unassigned.Msg.Bills))
+	}
+
+	balResp, err = groupClient.GetGroupBalances(context.Background(), connect.NewRequest(&pb.GetGroupBalancesRequest{
+		GroupId: groupID,
+	}))
+	if err != nil {
+		t.Fatalf("GetGroupBalances failed: %v", err)
+	}
+	if len(balResp.Msg.DebtMatrix) != 1 || balResp.Msg.DebtMatrix[0].Amount != 20 {
+		t.Errorf("expected Bob to owe Alice 20 after finalizing, got %v", balResp.Msg.DebtMatrix)
+	}
+}
+
+func TestUpdateBill_FinalizeWithoutParticipants_Rejected(t *testing.T) {
+	client, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	createResp, err := client.CreateBill(context.Background(), connect.NewRequest(&pb.CreateBillRequest{
+		Title:           "Mystery receipt",
+		Total:           12,
+		Subtotal:        12,
+		NeedsAssignment: true,
+	}))
+	if err != nil {
+		t.Fatalf("CreateBill (parked) failed: %v", err)
+	}
+
+	_, err = client.UpdateBill(context.Background(), connect.NewRequest(&pb.UpdateBillRequest{
+		BillId:   createResp.Msg.BillId,
+		Title:    "Mystery receipt",
+		Total:    12,
+		Subtotal: 12,
+	}))
+	if connect.CodeOf(err) != connect.CodeInvalidArgument {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+}
+
+func TestCreateBill_Discount(t *testing.T) {
+	client, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	createResp, err := client.CreateBill(context.Background(), connect.NewRequest(&pb.CreateBillRequest{
+		Title: "Promo Dinner",
+		Items: []*pb.Item{
+			{Description: "Steak", Amount: 60, ParticipantIds: []string{"Alice"}},
+			{Description: "Salad", Amount: 40, ParticipantIds: []string{"Bob"}},
+		},
+		Total:        88,
+		Subtotal:     100,
+		Discount:     20,
+		DiscountType: pb.DiscountType_DISCOUNT_TYPE_PERCENT,
+		Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+	}))
+	if err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+	if got := createResp.Msg.Split.DiscountAmount; got != 20 {
+		t.Errorf("discount_amount: expected 20, got %f", got)
+	}
+	if got := createResp.Msg.Split.TaxAmount; got != 8 {
+		t.Errorf("tax_amount: expected 8, got %f", got)
+	}
+
+	getResp, err := client.GetBill(context.Background(), connect.NewRequest(&pb.GetBillRequest{
+		BillId: createResp.Msg.BillId,
+	}))
+	if err != nil {
+		t.Fatalf("GetBill failed: %v", err)
+	}
+	if getResp.Msg.Discount != 20 || getResp.Msg.DiscountType != pb.DiscountType_DISCOUNT_TYPE_PERCENT {
+		t.Errorf("expected 20%% discount after reload, got %f %v", getResp.Msg.Discount, getResp.Msg.DiscountType)
+	}
+	alice := getResp.Msg.Split.Splits["Alice"]
+	if alice.Discount != 12 || alice.Total < 52.79 || alice.Total > 52.81 {
+		t.Errorf("Alice: expected discount 12 and total 52.8, got %f and %f", alice.Discount, alice.Total)
+	}
+}
+
+func TestCreateBill_Fees(t *testing.T) {
+	client, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	createResp, err := client.CreateBill(context.Background(), connect.NewRequest(&pb.CreateBillRequest{
+		Title: "Takeout",
+		Items: []*pb.Item{
+			{Description: "Curry", Amount: 30, ParticipantIds: []string{"Alice"}},
+			{Description: "Naan", Amount: 10, ParticipantIds: []string{"Bob"}},
+		},
+		Total:    54,
+		Subtotal: 40,
+		Fees: []*pb.Fee{
+			{Description: "Delivery", Amount: 6, SplitMode: pb.FeeSplitMode_FEE_SPLIT_MODE_EQUAL},
+			{Description: "Service", Amount: 4},
+		},
+		Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+	}))
+	if err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+	if got := createResp.Msg.Split.FeeAmount; got != 10 {
+		t.Errorf("fee_amount: expected 10, got %f", got)
+	}
+	if got := createResp.Msg.Split.TaxAmount; got != 4 {
+		t.Errorf("tax_amount: expected 4, got %f", got)
+	}
+
+	getResp, err := client.GetBill(context.Background(), connect.NewRequest(&pb.GetBillRequest{
+		BillId: createResp.Msg.BillId,
+	}))
+	if err != nil {
+		t.Fatalf("GetBill failed: %v", err)
+	}
+	if len(getResp.Msg.Fees) != 2 || getResp.Msg.Fees[0].SplitMode != pb.FeeSplitMode_FEE_SPLIT_MODE_EQUAL {
+		t.Fatalf("expected 2 fees with an equal delivery fee after reload, got %v", getResp.Msg.Fees)
+	}
+	alice := getResp.Msg.Split.Splits["Alice"]
+	if alice.Fees < 5.99 || alice.Fees > 6.01 || alice.Total < 38.99 || alice.Total > 39.01 {
+		t.Errorf("Alice: expected fees 6 and total 39, got %f and %f", alice.Fees, alice.Total)
+	}
+}
+
+func TestCreateBill_Currency(t *testing.T) {
+	client, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	createResp, err := client.CreateBill(context.Background(), connect.NewRequest(&pb.CreateBillRequest{
+		Title:        "Ramen",
+		Total:        3300,
+		Subtotal:     3000,
+		Currency:     "jpy",
+		Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob"), guestBP("Carol")},
+	}))
+	if err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+	if got := createResp.Msg.Split.Currency; got != "JPY" {
+		t.Errorf("split currency: expected JPY, got %q", got)
+	}
+
+	getResp, err := client.GetBill(context.Background(), connect.NewRequest(&pb.GetBillRequest{
+		BillId: createResp.Msg.BillId,
+	}))
+	if err != nil {
+		t.Fatalf("GetBill failed: %v", err)
+	}
+	if getResp.Msg.Currency != "JPY" {
+		t.Errorf("currency: expected JPY after reload, got %q", getResp.Msg.Currency)
+	}
+	if got := getResp.Msg.Split.Splits["Alice"].Total; got != 1100 {
+		t.Errorf("Alice total: expected whole yen 1100, got %f", got)
+	}
+
+	listResp, err := client.ListMyBills(context.Background(), connect.NewRequest(&pb.ListMyBillsRequest{}))
+	if err != nil {
+		t.Fatalf("ListMyBills failed: %v", err)
+	}
+	if len(listResp.Msg.Bills) != 1 || listResp.Msg.Bills[0].Currency != "JPY" {
+		t.Errorf("expected bill summary with currency JPY, got %v", listResp.Msg.Bills)
+	}
+
+	_, err = client.CreateBill(context.Background(), connect.NewRequest(&pb.CreateBillRequest{
+		Title:        "Bad",
+		Total:        10,
+		Subtotal:     10,
+		Currency:     "dollars",
+		Participants: []*pb.BillParticipant{aliceBP()},
+	}))
+	if connect.CodeOf(err) != connect.CodeInvalidArgument {
+		t.Errorf("expected InvalidArgument for malformed currency, got %v", err)
+	}
+}
+
+func TestCreateBill_ItemUnits(t *testing.T) {
+	client, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	createResp, err := client.CreateBill(context.Background(), connect.NewRequest(&pb.CreateBillRequest{
+		Title: "Pub",
+		Items: []*pb.Item{{
+			Description:    "Beer",
+			Quantity:       3,
+			UnitPrice:      6,
+			ParticipantIds: []string{"Alice", "Bob"},
+			Units:          map[string]float64{"Alice": 2, "Bob": 1},
+		}},
+		Total:        18,
+		Subtotal:     18,
+		Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+	}))
+	if err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+
+	getResp, err := client.GetBill(context.Background(), connect.NewRequest(&pb.GetBillRequest{
+		BillId: createResp.Msg.BillId,
+	}))
+	if err != nil {
+		t.Fatalf("GetBill failed: %v", err)
+	}
+	item := getResp.Msg.Items[0]
+	if item.Amount != 18 || item.Quantity != 3 || item.UnitPrice != 6 {
+		t.Errorf("expected 3 × 6 = 18 after reload, got %f × %f = %f", item.Quantity, item.UnitPrice, item.Amount)
+	}
+	if item.Units["Alice"] != 2 || item.Units["Bob"] != 1 {
+		t.Errorf("expected units Alice 2 / Bob 1 after reload, got %v", item.Units)
+	}
+	if got := getResp.Msg.Split.Splits["Alice"].Total; got != 12 {
+		t.Errorf("Alice: expected 12, got %f", got)
+	}
+}
+
+func TestCreateBill_CoveredParticipant(t *testing.T) {
+	client, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	carol := guestBP("Carol")
+	carol.Covered = pb.CoverMode_COVER_MODE_ALL
+	createResp, err := client.CreateBill(context.Background(), connect.NewRequest(&pb.CreateBillRequest{
+		Title:        "Birthday Dinner",
+		Total:        90,
+		Subtotal:     90,
+		Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob"), carol},
+	}))
+	if err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+
+	getResp, err := client.GetBill(context.Background(), connect.NewRequest(&pb.GetBillRequest{
+		BillId: createResp.Msg.BillId,
+	}))
+	if err != nil {
+		t.Fatalf("GetBill failed: %v", err)
+	}
+	for _, p := range getResp.Msg.Participants {
+		if p.DisplayName == "Carol" && p.Covered != pb.CoverMode_COVER_MODE_ALL {
+			t.Errorf("expected Carol to stay covered after reload, got %v", p.Covered)
+		}
+	}
+	splits := getResp.Msg.Split.Splits
+	if splits["Carol"].Total != 0 || splits["Alice"].Total != 45 || splits["Bob"].Total != 45 {
+		t.Errorf("expected 45/45/0, got %f/%f/%f", splits["Alice"].Total, splits["Bob"].Total, splits["Carol"].Total)
+	}
+}
+
+func TestCreateBill_Adjustment(t *testing.T) {
+	client, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	alice := aliceBP()
+	alice.Adjustment = 6
+	createResp, err := client.CreateBill(context.Background(), connect.NewRequest(&pb.CreateBillRequest{
+		Title:        "Drinks",
+		Total:        66,
+		Subtotal:     66,
+		Participants: []*pb.BillParticipant{alice, guestBP("Bob")},
+	}))
+	if err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+
+	getResp, err := client.GetBill(context.Background(), connect.NewRequest(&pb.GetBillRequest{
+		BillId: createResp.Msg.BillId,
+	}))
+	if err != nil {
+		t.Fatalf("GetBill failed: %v", err)
+	}
+	for _, p := range getResp.Msg.Participants {
+		if p.DisplayName == "Alice" && p.Adjustment != 6 {
+			t.Errorf("expected Alice's adjustment to survive reload, got %v", p.Adjustment)
+		}
+	}
+	splits := getResp.Msg.Split.Splits
+	if splits["Alice"].Total != 36 || splits["Bob"].Total != 30 {
+		t.Errorf("expected 36/30, got %f/%f", splits["Alice"].Total, splits["Bob"].Total)
+	}
+}
+
+func TestCreateBill_RemainderToPayer(t *testing.T) {
+	client, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	payer := "Alice"
+	createResp, err := client.CreateBill(context.Background(), connect.NewRequest(&pb.CreateBillRequest{
+		Title:         "Takeout",
+		Items:         []*pb.Item{{Description: "Noodles", Amount: 20, ParticipantIds: []string{"Bob"}}},
+		Total:         30,
+		Subtotal:      30,
+		Participants:  []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+		PayerId:       &payer,
+		RemainderMode: pb.RemainderMode_REMAINDER_MODE_PAYER,
+	}))
+	if err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+
+	getResp, err := client.GetBill(context.Background(), connect.NewRequest(&pb.GetBillRequest{
+		BillId: createResp.Msg.BillId,
+	}))
+	if err != nil {
+		t.Fatalf("GetBill failed: %v", err)
+	}
+	if getResp.Msg.RemainderMode != pb.RemainderMode_REMAINDER_MODE_PAYER {
+		t.Errorf("remainder mode = %v, want payer", getResp.Msg.RemainderMode)
+	}
+	splits := getResp.Msg.Split.Splits
+	if splits["Alice"].Total != 10 || splits["Bob"].Total != 20 {
+		t.Errorf("expected 10/20, got %f/%f", splits["Alice"].Total, splits["Bob"].Total)
+	}
+}
+
+func TestCreateBill_TaxInclusive(t *testing.T) {
+	client, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	createResp, err := client.CreateBill(context.Background(), connect.NewRequest(&pb.CreateBillRequest{
+		Title: "Trattoria",
+		Items: []*pb.Item{
+			{Description: "Wine", Amount: 72, ParticipantIds: []string{"Alice"}},
+			{Description: "Pasta", Amount: 48, ParticipantIds: []string{"Bob"}},
+		},
+		Total:        120,
+		Subtotal:     120,
+		Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+		TaxInclusive: true,
+		TaxRate:      20,
+	}))
+	if err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+
+	getResp, err := client.GetBill(context.Background(), connect.NewRequest(&pb.GetBillRequest{
+		BillId: createResp.Msg.BillId,
+	}))
+	if err != nil {
+		t.Fatalf("GetBill failed: %v", err)
+	}
+	if !getResp.Msg.TaxInclusive || getResp.Msg.TaxRate != 20 {
+		t.Errorf("tax inclusive/rate = %v/%v, want true/20", getResp.Msg.TaxInclusive, getResp.Msg.TaxRate)
+	}
+	split := getResp.Msg.Split
+	if math.Abs(split.TaxAmount-20) > 0.01 || math.Abs(split.Subtotal-100) > 0.01 {
+		t.Errorf("tax/subtotal = %v/%v, want 20/100", split.TaxAmount, split.Subtotal)
+	}
+	if alice := split.Splits["Alice"]; math.Abs(alice.Tax-12) > 0.01 || math.Abs(alice.Total-72) > 0.01 {
+		t.Errorf("Alice tax/total = %v/%v, want 12/72", alice.Tax, alice.Total)
+	}
+}
+
+func TestCreateBill_ItemCategories(t *testing.T) {
+	client, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	createResp, err := client.CreateBill(context.Background(), connect.NewRequest(&pb.CreateBillRequest{
+		Title: "Groceries",
+		Items: []*pb.Item{
+			{Description: "Dish soap", Amount: 6, Category: pb.ItemCategory_ITEM_CATEGORY_HOUSEHOLD},
+			{Description: "Chocolate", Amount: 4, Category: pb.ItemCategory_ITEM_CATEGORY_PERSONAL, Owner: "Bob"},
+		},
+		Total:        10,
+		Subtotal:     10,
+		Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+	}))
+	if err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+
+	getResp, err := client.GetBill(context.Background(), connect.NewRequest(&pb.GetBillRequest{
+		BillId: createResp.Msg.BillId,
+	}))
+	if err != nil {
+		t.Fatalf("GetBill failed: %v", err)
+	}
+	chocolate := getResp.Msg.Items[1]
+	if getResp.Msg.Items[0].Category != pb.ItemCategory_ITEM_CATEGORY_HOUSEHOLD ||
+		chocolate.Category != pb.ItemCategory_ITEM_CATEGORY_PERSONAL || chocolate.Owner != "Bob" {
+		t.Errorf("items = %v, want household dish soap and Bob's chocolate", getResp.Msg.Items)
+	}
+	splits := getResp.Msg.Split.Splits
+	if math.Abs(splits["Alice"].Total-3) > 0.01 || math.Abs(splits["Bob"].Total-7) > 0.01 {
+		t.Errorf("totals = %v/%v, want 3/7", splits["Alice"].Total, splits["Bob"].Total)
+	}
+
+	_, err = client.CreateBill(context.Background(), connect.NewRequest(&pb.CreateBillRequest{
+		Title:        "Groceries",
+		Items:        []*pb.Item{{Description: "Razor", Amount: 10, Category: pb.ItemCategory_ITEM_CATEGORY_PERSONAL}},
+		Total:        10,
+		Subtotal:     10,
+		Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+	}))
+	if connect.CodeOf(err) != connect.CodeInvalidArgument {
+		t.Errorf("personal item without owner: got %v, want InvalidArgument", err)
+	}
+}
+
+func TestCreateBill_RoundingMode(t *testing.T) {
+	groupClient, splitClient, cleanup := setupGroupTestServer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	groupResp, err := groupClient.CreateGroup(ctx, connect.NewRequest(&pb.CreateGroupRequest{
+		Name:     "Market",
+		Members:  gm("Alice", "Bob", "Carol"),
+		Settings: &pb.GroupSettings{RoundingMode: pb.RoundingMode_ROUNDING_MODE_CASH},
+	}))
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	groupID := groupResp.Msg.Group.Id
+
+	tests := []struct {
+		name string
+		mode pb.RoundingMode
+		want pb.RoundingMode
+		// Alice's total; Bob and Carol pay the rest evenly.
+		alice float64
+	}{
+		{"group default", pb.RoundingMode_ROUNDING_MODE_UNSPECIFIED, pb.RoundingMode_ROUNDING_MODE_CASH, 3.30},
+		{"bill override", pb.RoundingMode_ROUNDING_MODE_HALF_UP, pb.RoundingMode_ROUNDING_MODE_HALF_UP, 3.34},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			createResp, err := splitClient.CreateBill(ctx, connect.NewRequest(&pb.CreateBillRequest{
+				Title:        "Vegetables",
+				Total:        10,
+				Subtotal:     10,
+				Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob"), guestBP("Carol")},
+				GroupId:      &groupID,
+				RoundingMode: tt.mode,
+			}))
+			if err != nil {
+				t.Fatalf("CreateBill failed: %v", err)
+			}
+
+			getResp, err := splitClient.GetBill(ctx, connect.NewRequest(&pb.GetBillRequest{BillId: createResp.Msg.BillId}))
+			if err != nil {
+				t.Fatalf("GetBill failed: %v", err)
+			}
+			if getResp.Msg.RoundingMode != tt.want {
+				t.Errorf("rounding mode = %v, want %v", getResp.Msg.RoundingMode, tt.want)
+			}
+			sum := 0.0
+			for _, split := range getResp.Msg.Split.Splits {
+				sum += split.Total
+			}
+			if math.Abs(sum-10) > 1e-9 {
+				t.Errorf("totals add up to %v, want 10", sum)
+			}
+			if got := getResp.Msg.Split.Splits["Alice"].Total; math.Abs(got-tt.alice) > 1e-9 {
+				t.Errorf("Alice total = %v, want %v", got, tt.alice)
+			}
+		})
+	}
+}
+
+func TestCreateBill_ItemValidation(t *testing.T) {
+	client, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	create := func(subtotal float64, mode pb.ItemValidation, tolerance float64) error {
+		_, err := client.CreateBill(context.Background(), connect.NewRequest(&pb.CreateBillRequest{
+			Title: "Groceries",
+			Items: []*pb.Item{
+				{Description: "Bread", Amount: 20, ParticipantIds: []string{"Alice"}},
+				{Description: "Milk", Amount: 30, ParticipantIds: []string{"Bob"}},
+			},
+			Total:          subtotal,
+			Subtotal:       subtotal,
+			Participants:   []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+			ItemValidation: mode,
+			ItemTolerance:  tolerance,
+		}))
+		return err
+	}
+
+	tests := []struct {
+		name      string
+		subtotal  float64
+		mode      pb.ItemValidation
+		tolerance float64
+		wantErr   string
+	}{
+		{"lenient accepts excess", 45, pb.ItemValidation_ITEM_VALIDATION_LENIENT, 0, ""},
+		{"no excess rejects excess", 45, pb.ItemValidation_ITEM_VALIDATION_NO_EXCESS, 0, "items total 50.00 exceeds subtotal 45.00 by 5.00"},
+		{"no excess accepts shortfall", 60, pb.ItemValidation_ITEM_VALIDATION_NO_EXCESS, 0, ""},
+		{"exact rejects shortfall", 60, pb.ItemValidation_ITEM_VALIDATION_EXACT, 0, "items total 50.00 is 10.00 short of subtotal 60.00"},
+		{"exact within tolerance", 50.5, pb.ItemValidation_ITEM_VALIDATION_EXACT, 1, ""},
+		{"exact match", 50, pb.ItemValidation_ITEM_VALIDATION_EXACT, 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := create(tt.subtotal, tt.mode, tt.tolerance)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("CreateBill failed: %v", err)
+				}
+				return
+			}
+			if connect.CodeOf(err) != connect.CodeInvalidArgument {
+				t.Fatalf("code = %v, want InvalidArgument", connect.CodeOf(err))
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %q, want it to contain %q", err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetBill_DebugTrace(t *testing.T) {
+	client, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	createResp, err := client.CreateBill(context.Background(), connect.NewRequest(&pb.CreateBillRequest{
+		Title:        "Lunch",
+		Items:        []*pb.Item{{Description: "Soup", Amount: 10, ParticipantIds: []string{"Alice"}}},
+		Total:        22,
+		Subtotal:     20,
+		Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+	}))
+	if err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+	if len(createResp.Msg.Split.Trace) != 0 {
+		t.Errorf("expected no trace without debug, got %d steps", len(createResp.Msg.Split.Trace))
+	}
+
+	getResp, err := client.GetBill(context.Background(), connect.NewRequest(&pb.GetBillRequest{
+		BillId: createResp.Msg.BillId,
+		Debug:  true,
+	}))
+	if err != nil {
+		t.Fatalf("GetBill failed: %v", err)
+	}
+	var aliceSteps float64
+	for _, step := range getResp.Msg.Split.Trace {
+		if step.Participant == "Alice" && (step.Step == "item" || step.Step == "remainder" || step.Step == "tax") {
+			aliceSteps += step.Amount
+		}
+	}
+	// Soup 10 + half the 10 remainder + 10% tax on 15.
+	if aliceSteps < 16.49 || aliceSteps > 16.51 {
+		t.Errorf("expected Alice's traced item, remainder and tax to add up to 16.5, got %f", aliceSteps)
+	}
+}
+
+func TestGetBill_ItemMatrix(t *testing.T) {
+	client, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	createResp, err := client.CreateBill(context.Background(), connect.NewRequest(&pb.CreateBillRequest{
+		Title: "Groceries",
+		Items: []*pb.Item{
+			{Description: "Cheese", Amount: 9, ParticipantIds: []string{"Alice", "Bob", "Carol"}},
+			{Description: "Dish soap", Amount: 6, Category: pb.ItemCategory_ITEM_CATEGORY_HOUSEHOLD},
+			{Description: "Bread", Amount: 3},
+		},
+		Total:        18,
+		Subtotal:     18,
+		Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob"), guestBP("Carol")},
+	}))
+	if err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+
+	getResp, err := client.GetBill(context.Background(), connect.NewRequest(&pb.GetBillRequest{
+		BillId:    createResp.Msg.BillId,
+		FieldMask: &fieldmaskpb.FieldMask{Paths: []string{"item_matrix"}},
+	}))
+	if err != nil {
+		t.Fatalf("GetBill failed: %v", err)
+	}
+	if getResp.Msg.Split != nil {
+		t.Errorf("expected the split to be masked out")
+	}
+	matrix := getResp.Msg.ItemMatrix
+	if len(matrix) != 3 {
+		t.Fatalf("expected a row per item, got %d", len(matrix))
+	}
+	descriptions := []string{"Cheese", "Dish soap", "Bread"}
+	for i, want := range []map[string]float64{
+		{"Alice": 3, "Bob": 3, "Carol": 3},
+		{"Alice": 2, "Bob": 2, "Carol": 2},
+		nil,
+	} {
+		row := matrix[i]
+		if row.Index != int32(i) || row.Description != descriptions[i] {
+			t.Errorf("row %d is %d %q, want %d %q", i, row.Index, row.Description, i, descriptions[i])
+		}
+		if len(row.Shares) != len(want) {
+			t.Errorf("%s shares = %v, want %v", row.Description, row.Shares, want)
+			continue
+		}
+		for name, share := range want {
+			if math.Abs(row.Shares[name]-share) > 0.001 {
+				t.Errorf("%s: %s's share = %v, want %v", row.Description, name, row.Shares[name], share)
+			}
+		}
+	}
+}
+
+func TestPreviewBillUpdate(t *testing.T) {
+	groupClient, splitClient, cleanup := setupGroupTestServer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	groupResp, err := groupClient.CreateGroup(ctx, connect.NewRequest(&pb.CreateGroupRequest{
+		Name:    "Trip",
+		Members: gm("Alice", "Bob", "Carol"),
+	}))
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	groupID := groupResp.Msg.Group.Id
+
+	alicePayer := "Alice"
+	createResp, err := splitClient.CreateBill(ctx, connect.NewRequest(&pb.CreateBillRequest{
+		Title:        "Taxi",
+		Total:        60,
+		Subtotal:     60,
+		Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob")},
+		GroupId:      &groupID,
+		PayerId:      &alicePayer,
+	}))
+	if err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+	billID := createResp.Msg.BillId
+
+	// Adding Carol takes 10 off Alice's and Bob's shares.
+	previewResp, err := splitClient.PreviewBillUpdate(ctx, connect.NewRequest(&pb.PreviewBillUpdateRequest{
+		Update: &pb.UpdateBillRequest{
+			BillId:       billID,
+			Title:        "Taxi",
+			Total:        60,
+			Subtotal:     60,
+			Participants: []*pb.BillParticipant{aliceBP(), guestBP("Bob"), guestBP("Carol")},
+			GroupId:      &groupID,
+			PayerId:      &alicePayer,
+		},
+	}))
+	if err != nil {
+		t.Fatalf("PreviewBillUpdate failed: %v", err)
+	}
+
+	wantDeltas := map[string]float64{"Alice": -10, "Bob": -10, "Carol": 20}
+	if len(previewResp.Msg.ShareDeltas) != len(wantDeltas) {
+		t.Fatalf("expected %d share deltas, got %d", len(wantDeltas), len(previewResp.Msg.ShareDeltas))
+	}
+	for _, d := range previewResp.Msg.ShareDeltas {
+		if d.Delta != wantDeltas[d.Participant] {
+			t.Errorf("%s delta: expected %v, got %v (before %v, after %v)", d.Participant, wantDeltas[d.Participant], d.Delta, d.Before, d.After)
+		}
+	}
+	if previewResp.Msg.ShareDeltas[0].Participant != "Alice" || previewResp.Msg.ShareDeltas[2].Participant != "Carol" {
+		t.Errorf("share deltas not sorted by participant: %v", previewResp.Msg.ShareDeltas)
+	}
+
+	if previewResp.Msg.GetGroupId() != groupID {
+		t.Errorf("group_id: expected %s, got %s", groupID, previewResp.Msg.GetGroupId())
+	}
+	wantNet := map[string]float64{"Alice": 40, "Bob": -20, "Carol": -20}
+	if len(previewResp.Msg.MemberBalances) != len(wantNet) {
+		t.Fatalf("expected %d member balances, got %d", len(wantNet), len(previewResp.Msg.MemberBalances))
+	}
+	for _, bal := range previewResp.Msg.MemberBalances {
+		if bal.NetBalance != wantNet[bal.DisplayName] {
+			t.Errorf("%s net_balance: expected %v, got %v", bal.DisplayName, wantNet[bal.DisplayName], bal.NetBalance)
+		}
+	}
+
+	// Nothing was saved.
+	getResp, err := splitClient.GetBill(ctx, connect.NewRequest(&pb.GetBillRequest{BillId: billID}))
+	if err != nil {
+		t.Fatalf("GetBill failed: %v", err)
+	}
+	if len(getResp.Msg.Participants) != 2 {
+		t.Errorf("expected the bill to keep 2 participants, got %d", len(getResp.Msg.Participants))
+	}
+}
+
+func TestCalculateSplit_EvenSplitHint(t *testing.T) {
+	client, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	calculate := func(t *testing.T, wine float64, tolerance float64) *pb.CalculateSplitResponse {
+		t.Helper()
+		resp, err := client.CalculateSplit(context.Background(), connect.NewRequest(&pb.CalculateSplitRequest{
+			Items: []*pb.Item{
+				{Description: "Pizza", Amount: 40, ParticipantIds: []string{"Alice", "Bob"}},
+				{Description: "Wine", Amount: wine, ParticipantIds: []string{"Alice"}},
+			},
+			Total:              40 + wine,
+			Subtotal:           40 + wine,
+			ParticipantIds:     []string{"Alice", "Bob"},
+			Currency:           "USD",
+			EvenSplitTolerance: tolerance,
+		}))
+		if err != nil {
+			t.Fatalf("CalculateSplit failed: %v", err)
+		}
+		return resp.Msg
+	}
+
+	t.Run("close to even", func(t *testing.T) {
+		// Alice 22, Bob 20: an equal 21 each is within 5%.
+		hint := calculate(t, 2, 0).EvenSplitHint
+		if hint == nil {
+			t.Fatal("expected an even split hint")
+		}
+		if hint.Share != 21 || hint.Difference["Alice"] != -1 || hint.Difference["Bob"] != 1 {
+			t.Errorf("expected share 21, Alice -1, Bob +1; got %v", hint)
+		}
+	})
+	t.Run("too far from even", func(t *testing.T) {
+		if hint := calculate(t, 10, 0).EvenSplitHint; hint != nil {
+			t.Errorf("expected no hint, got %v", hint)
+		}
+	})
+	t.Run("configured tolerance", func(t *testing.T) {
+		if hint := calculate(t, 10, 0.25).EvenSplitHint; hint == nil {
+			t.Error("expected a hint within 25%")
+		}
+	})
+	t.Run("invalid tolerance", func(t *testing.T) {
+		_, err := client.CalculateSplit(context.Background(), connect.NewRequest(&pb.CalculateSplitRequest{
+			Total:              10,
+			Subtotal:           10,
+			ParticipantIds:     []string{"Alice", "Bob"},
+			EvenSplitTolerance: -0.1,
+		}))
+		if connect.CodeOf(err) != connect.CodeInvalidArgument {
+			t.Errorf("expected InvalidArgument, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/mmynk/splitwiser/internal/authctx"
+	"github.com/mmynk/splitwiser/internal/models"
+	"github.com/mmynk/splitwiser/internal/storage"
+	"github.com/mmynk/splitwiser/internal/taxreport"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+)
+
+// ExportTaxReport totals the caller's shares of the bills dated in a year by
+// tax line, for freelancers deducting shared costs. A bill is deductible when
+// its category maps to a tax line (see WithTaxLines), and is listed with its
+// attachments as receipts. Bills the caller isn't on, and bills awaiting
+// assignment, have no share of theirs and are left out.
+func (s *SplitService) ExportTaxReport(ctx context.Context, req *connect.Request[pb.ExportTaxReportRequest]) (*connect.Response[pb.ExportTaxReportResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+	}
+	if len(s.taxLines) == 0 {
+		return nil, connect.NewError(connect.CodeFailedPrecondition, fmt.Errorf("tax reports are not configured on this server"))
+	}
+	year := int(req.Msg.Year)
+	if year < 1970 || year > 9999 {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("year must be between 1970 and 9999"))
+	}
+
+	// A bill can be dated any time before it was created but at most a day
+	// after, so bills created over a day before the year can't be in it.
+	since := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC).Add(-24 * time.Hour)
+	summaries, err := s.store.ListBillsByUser(ctx, userID, storage.BillFilter{Since: since.Unix()})
+	if err != nil {
+		logger.Error("ExportTaxReport failed", "user_id", userID, "error", err)
+		return nil, storeError(err)
+	}
+	categories, err := s.store.ListCategories(ctx)
+	if err != nil {
+		logger.Error("ExportTaxReport failed to list categories", "error", err)
+		return nil, storeError(err)
+	}
+	categoryNames := make(map[string]string, len(categories))
+	for _, c := range categories {
+		categoryNames[c.ID] = c.Name
+	}
+
+	var bills []taxreport.Bill
+	receipts := make(map[string][]*pb.Attachment)
+	for _, summary := range summaries {
+		if _, ok := s.taxLines[summary.CategoryID]; !ok || expenseDate(summary).Year() != year {
+			continue
+		}
+		// The user's listing leaves out items, so the bill is read in full.
+		bill, err := s.store.GetBill(ctx, summary.ID)
+		if err != nil {
+			logger.Error("ExportTaxReport failed to read bill", "bill_id", summary.ID, "error", err)
+			return nil, storeError(err)
+		}
+		idx := slices.IndexFunc(bill.Participants, func(p models.BillParticipant) bool { return p.UserID == userID })
+		if idx < 0 || bill.NeedsAssignment {
+			continue
+		}
+		split, err := billSplit(bill, false)
+		if err != nil {
+			logger.Error("CalculateSplit failed during ExportTaxReport", "bill_id", bill.ID, "error", err)
+			continue
+		}
+		share := split.GetSplits()[bill.Participants[idx].DisplayName]
+		if share == nil {
+			continue
+		}
+		attachments, err := s.store.ListAttachmentsByBill(ctx, bill.ID)
+		if err != nil {
+			logger.Error("ExportTaxReport failed to list attachments", "bill_id", bill.ID, "error", err)
+			return nil, storeError(err)
+		}
+
+		entry := taxreport.Bill{
+			ID:         bill.ID,
+			Title:      bill.Title,
+			CategoryID: bill.CategoryID,
+			Date:       expenseDate(bill),
+			Currency:   bill.Currency,
+			Amount:     share.Total,
+		}
+		for _, a := range attachments {
+			entry.Receipts = append(entry.Receipts, a.Name)
+			receipts[bill.ID] = append(receipts[bill.ID], attachmentToProto(a))
+		}
+		bills = append(bills, entry)
+	}
+
+	report := taxreport.Build(s.taxLines, year, bills)
+	var buf bytes.Buffer
+	if err := taxreport.WriteCSV(&buf, report, categoryNames); err != nil {
+		logger.Error("ExportTaxReport failed to write CSV", "error", err)
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	resp := &pb.ExportTaxReportResponse{Csv: buf.Bytes(), Filename: fmt.Sprintf("tax-report-%d.csv", year)}
+	for _, line := range report {
+		pbLine := &pb.TaxLine{Name: line.Name, Totals: line.Totals}
+		for _, bill := range line.Bills {
+			pbLine.Bills = append(pbLine.Bills, &pb.TaxReportBill{
+				BillId:     bill.ID,
+				Title:      bill.Title,
+				CategoryId: bill.CategoryID,
+				Date:       bill.Date.Unix(),
+				Currency:   bill.Currency,
+				Amount:     bill.Amount,
+				Receipts:   receipts[bill.ID],
+			})
+		}
+		resp.Lines = append(resp.Lines, pbLine)
+	}
+	return connect.NewResponse(resp), nil
+}
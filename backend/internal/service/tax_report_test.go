@@ -0,0 +1,118 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"slices"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/mmynk/splitwiser/internal/blob"
+	"github.com/mmynk/splitwiser/internal/taxreport"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+)
+
+func TestExportTaxReport(t *testing.T) {
+	blobs, err := blob.NewDisk(t.TempDir(), "/blobs/", []byte("url-key"))
+	if err != nil {
+		t.Fatalf("NewDisk failed: %v", err)
+	}
+	_, splitClient, cleanup := setupGroupTestServer(t, WithAttachments(blobs, 64), WithTaxLines(taxreport.Lines{
+		"rent":      "Home office",
+		"utilities": "Home office",
+		"travel":    "Travel",
+	}))
+	defer cleanup()
+	ctx := context.Background()
+
+	lastYear := time.Now().UTC().AddDate(-1, 0, 0)
+	create := func(title string, total float64, category string, date time.Time, people ...*pb.BillParticipant) string {
+		t.Helper()
+		resp, err := splitClient.CreateBill(ctx, connect.NewRequest(&pb.CreateBillRequest{
+			Title:        title,
+			Total:        total,
+			Subtotal:     total,
+			Currency:     "EUR",
+			CategoryId:   category,
+			BillDate:     date.Unix(),
+			Participants: people,
+			PayerId:      strPtr("Alice"),
+		}))
+		if err != nil {
+			t.Fatalf("CreateBill %s failed: %v", title, err)
+		}
+		return resp.Msg.BillId
+	}
+	rent := create("Studio rent", 1200, "rent", lastYear, aliceBP(), guestBP("Bob"))
+	create("Power", 90, "utilities", lastYear, aliceBP(), guestBP("Bob"), guestBP("Carol"))
+	create("Train to client", 40, "travel", lastYear, aliceBP())
+	create("Team lunch", 60, "food", lastYear, aliceBP(), guestBP("Bob"))
+	create("This year's rent", 1200, "rent", time.Now(), aliceBP(), guestBP("Bob"))
+
+	if _, err := splitClient.UploadAttachment(ctx, connect.NewRequest(&pb.UploadAttachmentRequest{
+		BillId: rent,
+		Name:   "lease.png",
+		Data:   testPNG,
+	})); err != nil {
+		t.Fatalf("UploadAttachment failed: %v", err)
+	}
+
+	// Bills count toward the year they're dated in, not the one they were entered in.
+	year := int32(lastYear.Year())
+	report, err := splitClient.ExportTaxReport(ctx, connect.NewRequest(&pb.ExportTaxReportRequest{Year: year}))
+	if err != nil {
+		t.Fatalf("ExportTaxReport failed: %v", err)
+	}
+	lines := report.Msg.Lines
+	if len(lines) != 2 || lines[0].Name != "Home office" || lines[1].Name != "Travel" {
+		t.Fatalf("lines = %v, want Home office and Travel", lines)
+	}
+	if got := lines[0].Totals["EUR"]; got != 630 {
+		t.Errorf("Home office total = %v, want Alice's 600 of the rent and 30 of the power", got)
+	}
+	var titles []string
+	for _, bill := range lines[0].Bills {
+		titles = append(titles, bill.Title)
+		if bill.BillId == rent && (len(bill.Receipts) != 1 || bill.Receipts[0].Name != "lease.png") {
+			t.Errorf("rent receipts = %v, want lease.png", bill.Receipts)
+		}
+	}
+	slices.Sort(titles)
+	if !slices.Equal(titles, []string{"Power", "Studio rent"}) {
+		t.Errorf("Home office bills = %v, want Power and Studio rent", titles)
+	}
+	if got := lines[1].Totals["EUR"]; got != 40 {
+		t.Errorf("Travel total = %v, want 40", got)
+	}
+	rows, err := csv.NewReader(bytes.NewReader(report.Msg.Csv)).ReadAll()
+	if err != nil {
+		t.Fatalf("report isn't valid CSV: %v", err)
+	}
+	if len(rows) != 4 || !slices.Equal(rows[0], taxreport.CSVHeader) {
+		t.Errorf("rows = %v, want a header and a row per deductible bill", rows)
+	}
+
+	earlier, err := splitClient.ExportTaxReport(ctx, connect.NewRequest(&pb.ExportTaxReportRequest{Year: year - 1}))
+	if err != nil {
+		t.Fatalf("ExportTaxReport failed: %v", err)
+	}
+	if len(earlier.Msg.Lines) != 0 {
+		t.Errorf("lines of the year before = %v, want none", earlier.Msg.Lines)
+	}
+
+	if _, err := splitClient.ExportTaxReport(ctx, connect.NewRequest(&pb.ExportTaxReportRequest{})); connect.CodeOf(err) != connect.CodeInvalidArgument {
+		t.Errorf("ExportTaxReport without a year: expected InvalidArgument, got %v", err)
+	}
+}
+
+func TestExportTaxReport_NotConfigured(t *testing.T) {
+	splitClient, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	_, err := splitClient.ExportTaxReport(context.Background(), connect.NewRequest(&pb.ExportTaxReportRequest{Year: 2025}))
+	if connect.CodeOf(err) != connect.CodeFailedPrecondition {
+		t.Errorf("expected FailedPrecondition, got %v", err)
+	}
+}
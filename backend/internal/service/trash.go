@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"connectrpc.com/connect"
+	"github.com/mmynk/splitwiser/internal/authctx"
+	"github.com/mmynk/splitwiser/internal/models"
+	"github.com/mmynk/splitwiser/internal/webhook"
+	pb "github.com/mmynk/splitwiser/pkg/proto"
+)
+
+// deletedBill loads a trashed bill the caller may restore or purge: anyone
+// who could have deleted it.
+func (s *SplitService) deletedBill(ctx context.Context, userID, billID string) (*models.Bill, error) {
+	if billID == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("bill_id required"))
+	}
+	bill, err := s.store.GetDeletedBill(ctx, billID)
+	if err != nil {
+		return nil, storeError(err)
+	}
+	if !hasAccess(userID, bill) {
+		return nil, connect.NewError(connect.CodePermissionDenied, fmt.Errorf("you must be a participant to manage this bill"))
+	}
+	return bill, nil
+}
+
+// ListDeletedBills lists the trashed bills the authenticated user created or
+// participates in, most recently deleted first.
+func (s *SplitService) ListDeletedBills(ctx context.Context, req *connect.Request[pb.ListDeletedBillsRequest]) (*connect.Response[pb.ListDeletedBillsResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+	}
+
+	mask, err := parseFieldMask(req.Msg.FieldMask, &pb.ListDeletedBillsResponse{})
+	if err != nil {
+		return nil, err
+	}
+
+	bills, err := s.store.ListDeletedBillsByUser(ctx, userID)
+	if err != nil {
+		logger.Error("ListDeletedBills failed", "error", err)
+		return nil, storeError(err)
+	}
+
+	resp := &pb.ListDeletedBillsResponse{Bills: s.userBillSummaries(ctx, bills, mask)}
+	mask.apply(resp)
+	return connect.NewResponse(resp), nil
+}
+
+// RestoreBill takes a bill out of the trash, putting it back into balances
+// and listings.
+func (s *SplitService) RestoreBill(ctx context.Context, req *connect.Request[pb.RestoreBillRequest]) (*connect.Response[pb.RestoreBillResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+	}
+
+	bill, err := s.deletedBill(ctx, userID, req.Msg.BillId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.store.RestoreBill(ctx, bill.ID); err != nil {
+		logger.Error("RestoreBill failed", "bill_id", bill.ID, "error", err)
+		return nil, storeError(err)
+	}
+	checkBalanceThresholds(ctx, s.store, s.webhooks, bill.GroupID)
+	notifyBillChange(ctx, s.store, s.webhooks, webhook.EventBillRestored, bill)
+
+	return connect.NewResponse(&pb.RestoreBillResponse{}), nil
+}
+
+// PurgeBill permanently removes a bill from the trash. Live bills must be
+// deleted first.
+func (s *SplitService) PurgeBill(ctx context.Context, req *connect.Request[pb.PurgeBillRequest]) (*connect.Response[pb.PurgeBillResponse], error) {
+	userID := authctx.UserID(ctx)
+	if userID == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("authentication required"))
+	}
+
+	bill, err := s.deletedBill(ctx, userID, req.Msg.BillId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.store.PurgeBill(ctx, bill.ID); err != nil {
+		logger.Error("PurgeBill failed", "bill_id", bill.ID, "error", err)
+		return nil, storeError(err)
+	}
+
+	return connect.NewResponse(&pb.PurgeBillResponse{}), nil
+}
@@ -0,0 +1,104 @@
+// Package audit wraps a storage.Store so that every create, update and
+// delete made through it is recorded in the audit log, with the signed-in
+// caller as the actor and the entity as JSON before and after the change.
+//
+//	store := audit.New(sqliteStore)
+//
+// Entries are written after the change succeeds. A failure to write one is
+// logged rather than returned, since the change itself has been made.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/mmynk/splitwiser/internal/authctx"
+	"github.com/mmynk/splitwiser/internal/models"
+	"github.com/mmynk/splitwiser/internal/storage"
+	"github.com/mmynk/splitwiser/pkg/logging"
+)
+
+// logger logs as the storage component; see pkg/logging.
+var logger = logging.Component("storage")
+
+// Entity types recorded in the audit log.
+const (
+	EntityBill            = "bill"
+	EntityGroup           = "group"
+	EntitySettlement      = "settlement"
+	EntitySettlementPlan  = "settlement_plan"
+	EntityGroupWebhook    = "group_webhook"
+	EntityAccountWebhook  = "account_webhook"
+	EntityAttachment      = "attachment"
+	EntityComment         = "comment"
+	EntityBillShare       = "bill_share"
+	EntityGroupStatsToken = "group_stats_token"
+	EntityBillShareToken  = "bill_share_token"
+	EntityFriendship      = "friendship"
+	EntityBillView        = "bill_view"
+)
+
+// Store is a storage.Store that records its mutations in the audit log.
+// Reads go straight to the wrapped store. Bookkeeping writes the services
+// make on their own behalf, SetGroupWebhookOverThreshold and
+// CreateSpendingCapAlerts, are passed through unrecorded.
+type Store struct {
+	storage.Store
+}
+
+var _ storage.Store = (*Store)(nil)
+
+// New wraps next so its mutations are audited.
+func New(next storage.Store) *Store {
+	return &Store{Store: next}
+}
+
+// record writes an audit entry for a change to an entity. before and after
+// are marshaled to JSON; pass nil for a side that doesn't exist.
+func (s *Store) record(ctx context.Context, action models.AuditAction, entityType, entityID, groupID string, before, after any) {
+	entry := &models.AuditEntry{
+		ActorID:    authctx.UserID(ctx),
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		GroupID:    groupID,
+		Before:     marshal(before),
+		After:      marshal(after),
+	}
+	// The change is made even if the caller has gone away; so is its record.
+	if err := s.Store.CreateAuditEntry(context.WithoutCancel(ctx), entry); err != nil {
+		logger.Error("Failed to record audit entry", "action", action, "entity_type", entityType, "entity_id", entityID, "error", err)
+	}
+}
+
+// marshal returns v as JSON, or "" for nil.
+func marshal(v any) string {
+	if v == nil {
+		return ""
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		logger.Error("Failed to marshal audit snapshot", "error", err)
+		return ""
+	}
+	return string(b)
+}
+
+// snapshot returns v as a before or after state, or nil if it couldn't be
+// read, so a failed lookup is recorded as a missing side rather than null.
+func snapshot[T any](v *T, err error) any {
+	if err != nil || v == nil {
+		return nil
+	}
+	return v
+}
+
+// redactedWebhook returns hook without its signing secret.
+func redactedWebhook(hook *models.AccountWebhook, err error) any {
+	if err != nil || hook == nil {
+		return nil
+	}
+	redacted := *hook
+	redacted.Secret = ""
+	return &redacted
+}
@@ -0,0 +1,422 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/mmynk/splitwiser/internal/models"
+)
+
+// Each mutating storage.Store method reads the entity's prior state where
+// there is one, delegates, and records the change if it succeeded.
+
+func (s *Store) CreateBill(ctx context.Context, bill *models.Bill) error {
+	if err := s.Store.CreateBill(ctx, bill); err != nil {
+		return err
+	}
+	s.record(ctx, models.AuditCreate, EntityBill, bill.ID, bill.GroupID, nil, bill)
+	return nil
+}
+
+func (s *Store) CreateBillInGroup(ctx context.Context, bill *models.Bill, members []models.GroupMember) (int, error) {
+	var before any
+	if bill.GroupID != "" && len(members) > 0 {
+		before = snapshot(s.Store.GetGroup(ctx, bill.GroupID))
+	}
+	added, err := s.Store.CreateBillInGroup(ctx, bill, members)
+	if err != nil {
+		return 0, err
+	}
+	s.record(ctx, models.AuditCreate, EntityBill, bill.ID, bill.GroupID, nil, bill)
+	if added > 0 {
+		s.record(ctx, models.AuditUpdate, EntityGroup, bill.GroupID, bill.GroupID, before, snapshot(s.Store.GetGroup(ctx, bill.GroupID)))
+	}
+	return added, nil
+}
+
+func (s *Store) CreateBillsInGroup(ctx context.Context, groupID string, bills []*models.Bill, members []models.GroupMember) (int, error) {
+	var before any
+	if len(members) > 0 {
+		before = snapshot(s.Store.GetGroup(ctx, groupID))
+	}
+	added, err := s.Store.CreateBillsInGroup(ctx, groupID, bills, members)
+	if err != nil {
+		return 0, err
+	}
+	for _, bill := range bills {
+		s.record(ctx, models.AuditCreate, EntityBill, bill.ID, groupID, nil, bill)
+	}
+	if added > 0 {
+		s.record(ctx, models.AuditUpdate, EntityGroup, groupID, groupID, before, snapshot(s.Store.GetGroup(ctx, groupID)))
+	}
+	return added, nil
+}
+
+func (s *Store) UpdateBill(ctx context.Context, bill *models.Bill) error {
+	before := snapshot(s.Store.GetBill(ctx, bill.ID))
+	if err := s.Store.UpdateBill(ctx, bill); err != nil {
+		return err
+	}
+	s.record(ctx, models.AuditUpdate, EntityBill, bill.ID, bill.GroupID, before, bill)
+	return nil
+}
+
+func (s *Store) UpdateBillInGroup(ctx context.Context, bill *models.Bill, members []models.GroupMember) (int, error) {
+	before := snapshot(s.Store.GetBill(ctx, bill.ID))
+	var groupBefore any
+	if bill.GroupID != "" && len(members) > 0 {
+		groupBefore = snapshot(s.Store.GetGroup(ctx, bill.GroupID))
+	}
+	added, err := s.Store.UpdateBillInGroup(ctx, bill, members)
+	if err != nil {
+		return 0, err
+	}
+	s.record(ctx, models.AuditUpdate, EntityBill, bill.ID, bill.GroupID, before, bill)
+	if added > 0 {
+		s.record(ctx, models.AuditUpdate, EntityGroup, bill.GroupID, bill.GroupID, groupBefore, snapshot(s.Store.GetGroup(ctx, bill.GroupID)))
+	}
+	return added, nil
+}
+
+func (s *Store) DeleteBill(ctx context.Context, billID string) error {
+	before, err := s.Store.GetBill(ctx, billID)
+	if err := s.Store.DeleteBill(ctx, billID); err != nil {
+		return err
+	}
+	s.record(ctx, models.AuditDelete, EntityBill, billID, groupOf(before), snapshot(before, err), nil)
+	return nil
+}
+
+func (s *Store) RestoreBill(ctx context.Context, billID string) error {
+	before, err := s.Store.GetDeletedBill(ctx, billID)
+	if err := s.Store.RestoreBill(ctx, billID); err != nil {
+		return err
+	}
+	s.record(ctx, models.AuditUpdate, EntityBill, billID, groupOf(before), snapshot(before, err), snapshot(s.Store.GetBill(ctx, billID)))
+	return nil
+}
+
+func (s *Store) PurgeBill(ctx context.Context, billID string) error {
+	before, err := s.Store.GetDeletedBill(ctx, billID)
+	if err := s.Store.PurgeBill(ctx, billID); err != nil {
+		return err
+	}
+	s.record(ctx, models.AuditDelete, EntityBill, billID, groupOf(before), snapshot(before, err), nil)
+	return nil
+}
+
+func (s *Store) CreateTransactionBill(ctx context.Context, bill *models.Bill, transactionID string) (bool, error) {
+	created, err := s.Store.CreateTransactionBill(ctx, bill, transactionID)
+	if err == nil && created {
+		s.record(ctx, models.AuditCreate, EntityBill, bill.ID, bill.GroupID, nil, bill)
+	}
+	return created, err
+}
+
+// groupOf returns the bill's group, or "" if the bill couldn't be read.
+func groupOf(bill *models.Bill) string {
+	if bill == nil {
+		return ""
+	}
+	return bill.GroupID
+}
+
+// billGroup returns the group of the bill with the given ID, or "" if it has
+// none or can't be read.
+func (s *Store) billGroup(ctx context.Context, billID string) string {
+	bill, err := s.Store.GetBill(ctx, billID)
+	if err != nil {
+		return ""
+	}
+	return groupOf(bill)
+}
+
+func (s *Store) CreateGroup(ctx context.Context, group *models.Group) error {
+	if err := s.Store.CreateGroup(ctx, group); err != nil {
+		return err
+	}
+	s.record(ctx, models.AuditCreate, EntityGroup, group.ID, group.ID, nil, group)
+	return nil
+}
+
+func (s *Store) UpdateGroup(ctx context.Context, group *models.Group) error {
+	before := snapshot(s.Store.GetGroup(ctx, group.ID))
+	if err := s.Store.UpdateGroup(ctx, group); err != nil {
+		return err
+	}
+	s.record(ctx, models.AuditUpdate, EntityGroup, group.ID, group.ID, before, group)
+	return nil
+}
+
+func (s *Store) AddGroupMembers(ctx context.Context, groupID string, memberIDs []string) error {
+	before := snapshot(s.Store.GetGroup(ctx, groupID))
+	if err := s.Store.AddGroupMembers(ctx, groupID, memberIDs); err != nil {
+		return err
+	}
+	s.record(ctx, models.AuditUpdate, EntityGroup, groupID, groupID, before, snapshot(s.Store.GetGroup(ctx, groupID)))
+	return nil
+}
+
+func (s *Store) AddGroupMembersWithIDs(ctx context.Context, groupID string, members []models.GroupMember) error {
+	before := snapshot(s.Store.GetGroup(ctx, groupID))
+	if err := s.Store.AddGroupMembersWithIDs(ctx, groupID, members); err != nil {
+		return err
+	}
+	s.record(ctx, models.AuditUpdate, EntityGroup, groupID, groupID, before, snapshot(s.Store.GetGroup(ctx, groupID)))
+	return nil
+}
+
+func (s *Store) DeleteGroup(ctx context.Context, groupID string) error {
+	before := snapshot(s.Store.GetGroup(ctx, groupID))
+	if err := s.Store.DeleteGroup(ctx, groupID); err != nil {
+		return err
+	}
+	s.record(ctx, models.AuditDelete, EntityGroup, groupID, groupID, before, nil)
+	return nil
+}
+
+func (s *Store) ImportGroupArchive(ctx context.Context, archive *models.GroupArchive) error {
+	if err := s.Store.ImportGroupArchive(ctx, archive); err != nil {
+		return err
+	}
+	groupID := archive.Group.ID
+	s.record(ctx, models.AuditCreate, EntityGroup, groupID, groupID, nil, archive.Group)
+	for _, bill := range archive.Bills {
+		s.record(ctx, models.AuditCreate, EntityBill, bill.ID, groupID, nil, bill)
+	}
+	for _, settlement := range archive.Settlements {
+		s.record(ctx, models.AuditCreate, EntitySettlement, settlement.ID, groupID, nil, settlement)
+	}
+	for _, plan := range archive.SettlementPlans {
+		s.record(ctx, models.AuditCreate, EntitySettlementPlan, plan.ID, groupID, nil, plan)
+	}
+	return nil
+}
+
+// settlementGroup returns the settlement's group, or "" for direct settlements.
+func settlementGroup(settlement *models.Settlement) string {
+	if settlement == nil || settlement.GroupID == nil {
+		return ""
+	}
+	return *settlement.GroupID
+}
+
+func (s *Store) CreateSettlement(ctx context.Context, settlement *models.Settlement) error {
+	if err := s.Store.CreateSettlement(ctx, settlement); err != nil {
+		return err
+	}
+	s.record(ctx, models.AuditCreate, EntitySettlement, settlement.ID, settlementGroup(settlement), nil, settlement)
+	return nil
+}
+
+func (s *Store) DeleteSettlement(ctx context.Context, settlementID string) error {
+	before, err := s.Store.GetSettlement(ctx, settlementID)
+	if err := s.Store.DeleteSettlement(ctx, settlementID); err != nil {
+		return err
+	}
+	s.record(ctx, models.AuditDelete, EntitySettlement, settlementID, settlementGroup(before), snapshot(before, err), nil)
+	return nil
+}
+
+func (s *Store) CreateSettlementPlan(ctx context.Context, plan *models.SettlementPlan) error {
+	if err := s.Store.CreateSettlementPlan(ctx, plan); err != nil {
+		return err
+	}
+	s.record(ctx, models.AuditCreate, EntitySettlementPlan, plan.ID, plan.GroupID, nil, plan)
+	return nil
+}
+
+func (s *Store) DeleteSettlementPlan(ctx context.Context, planID string) error {
+	before, err := s.Store.GetSettlementPlan(ctx, planID)
+	if err := s.Store.DeleteSettlementPlan(ctx, planID); err != nil {
+		return err
+	}
+	var groupID string
+	if before != nil {
+		groupID = before.GroupID
+	}
+	s.record(ctx, models.AuditDelete, EntitySettlementPlan, planID, groupID, snapshot(before, err), nil)
+	return nil
+}
+
+func (s *Store) CreateGroupWebhook(ctx context.Context, webhook *models.GroupWebhook) error {
+	if err := s.Store.CreateGroupWebhook(ctx, webhook); err != nil {
+		return err
+	}
+	s.record(ctx, models.AuditCreate, EntityGroupWebhook, webhook.ID, webhook.GroupID, nil, webhook)
+	return nil
+}
+
+func (s *Store) DeleteGroupWebhook(ctx context.Context, webhookID string) error {
+	before, err := s.Store.GetGroupWebhook(ctx, webhookID)
+	if err := s.Store.DeleteGroupWebhook(ctx, webhookID); err != nil {
+		return err
+	}
+	var groupID string
+	if before != nil {
+		groupID = before.GroupID
+	}
+	s.record(ctx, models.AuditDelete, EntityGroupWebhook, webhookID, groupID, snapshot(before, err), nil)
+	return nil
+}
+
+func (s *Store) CreateAccountWebhook(ctx context.Context, webhook *models.AccountWebhook) error {
+	if err := s.Store.CreateAccountWebhook(ctx, webhook); err != nil {
+		return err
+	}
+	s.record(ctx, models.AuditCreate, EntityAccountWebhook, webhook.ID, "", nil, redactedWebhook(webhook, nil))
+	return nil
+}
+
+func (s *Store) DeleteAccountWebhook(ctx context.Context, webhookID string) error {
+	before := redactedWebhook(s.Store.GetAccountWebhook(ctx, webhookID))
+	if err := s.Store.DeleteAccountWebhook(ctx, webhookID); err != nil {
+		return err
+	}
+	s.record(ctx, models.AuditDelete, EntityAccountWebhook, webhookID, "", before, nil)
+	return nil
+}
+
+func (s *Store) CreateAttachment(ctx context.Context, attachment *models.Attachment) error {
+	if err := s.Store.CreateAttachment(ctx, attachment); err != nil {
+		return err
+	}
+	s.record(ctx, models.AuditCreate, EntityAttachment, attachment.ID, s.billGroup(ctx, attachment.BillID), nil, attachment)
+	return nil
+}
+
+func (s *Store) CreateComment(ctx context.Context, comment *models.Comment) error {
+	if err := s.Store.CreateComment(ctx, comment); err != nil {
+		return err
+	}
+	s.record(ctx, models.AuditCreate, EntityComment, comment.ID, s.billGroup(ctx, comment.BillID), nil, comment)
+	return nil
+}
+
+func (s *Store) DeleteComment(ctx context.Context, commentID string) error {
+	before, err := s.Store.GetComment(ctx, commentID)
+	if err := s.Store.DeleteComment(ctx, commentID); err != nil {
+		return err
+	}
+	var groupID string
+	if before != nil {
+		groupID = s.billGroup(ctx, before.BillID)
+	}
+	s.record(ctx, models.AuditDelete, EntityComment, commentID, groupID, snapshot(before, err), nil)
+	return nil
+}
+
+func (s *Store) MarkShareAsPaid(ctx context.Context, payment *models.SharePayment) error {
+	if err := s.Store.MarkShareAsPaid(ctx, payment); err != nil {
+		return err
+	}
+	s.record(ctx, models.AuditCreate, EntityBillShare, payment.BillID, s.billGroup(ctx, payment.BillID), nil, payment)
+	return nil
+}
+
+func (s *Store) UnmarkShareAsPaid(ctx context.Context, billID, participant string) error {
+	if err := s.Store.UnmarkShareAsPaid(ctx, billID, participant); err != nil {
+		return err
+	}
+	before := &models.SharePayment{BillID: billID, Participant: participant}
+	s.record(ctx, models.AuditDelete, EntityBillShare, billID, s.billGroup(ctx, billID), before, nil)
+	return nil
+}
+
+func (s *Store) SetBillLocked(ctx context.Context, billID string, lockedAt int64) error {
+	before, err := s.Store.GetBill(ctx, billID)
+	if err := s.Store.SetBillLocked(ctx, billID, lockedAt); err != nil {
+		return err
+	}
+	s.record(ctx, models.AuditUpdate, EntityBill, billID, groupOf(before), snapshot(before, err), snapshot(s.Store.GetBill(ctx, billID)))
+	return nil
+}
+
+// The stats token is only ever stored hashed, but even the hash stays out of the log.
+
+func (s *Store) SetGroupStatsToken(ctx context.Context, groupID, tokenHash, createdBy string) error {
+	if err := s.Store.SetGroupStatsToken(ctx, groupID, tokenHash, createdBy); err != nil {
+		return err
+	}
+	s.record(ctx, models.AuditCreate, EntityGroupStatsToken, groupID, groupID, nil, nil)
+	return nil
+}
+
+func (s *Store) DeleteGroupStatsToken(ctx context.Context, groupID string) error {
+	if err := s.Store.DeleteGroupStatsToken(ctx, groupID); err != nil {
+		return err
+	}
+	s.record(ctx, models.AuditDelete, EntityGroupStatsToken, groupID, groupID, nil, nil)
+	return nil
+}
+
+// Likewise for bill share tokens.
+
+func (s *Store) SetBillShareToken(ctx context.Context, billID, tokenHash, createdBy string) error {
+	if err := s.Store.SetBillShareToken(ctx, billID, tokenHash, createdBy); err != nil {
+		return err
+	}
+	s.record(ctx, models.AuditCreate, EntityBillShareToken, billID, s.billGroup(ctx, billID), nil, nil)
+	return nil
+}
+
+func (s *Store) DeleteBillShareToken(ctx context.Context, billID string) error {
+	if err := s.Store.DeleteBillShareToken(ctx, billID); err != nil {
+		return err
+	}
+	s.record(ctx, models.AuditDelete, EntityBillShareToken, billID, s.billGroup(ctx, billID), nil, nil)
+	return nil
+}
+
+func (s *Store) SendFriendRequest(ctx context.Context, friendship *models.Friendship) error {
+	if err := s.Store.SendFriendRequest(ctx, friendship); err != nil {
+		return err
+	}
+	s.record(ctx, models.AuditCreate, EntityFriendship, friendship.ID, "", nil, friendship)
+	return nil
+}
+
+func (s *Store) UpdateFriendshipStatus(ctx context.Context, id string, status models.FriendshipStatus) error {
+	before := snapshot(s.Store.GetFriendship(ctx, id))
+	if err := s.Store.UpdateFriendshipStatus(ctx, id, status); err != nil {
+		return err
+	}
+	s.record(ctx, models.AuditUpdate, EntityFriendship, id, "", before, snapshot(s.Store.GetFriendship(ctx, id)))
+	return nil
+}
+
+func (s *Store) DeleteFriendship(ctx context.Context, id string) error {
+	before := snapshot(s.Store.GetFriendship(ctx, id))
+	if err := s.Store.DeleteFriendship(ctx, id); err != nil {
+		return err
+	}
+	s.record(ctx, models.AuditDelete, EntityFriendship, id, "", before, nil)
+	return nil
+}
+
+// Saved views are private to their owner, so they're logged outside any group.
+
+func (s *Store) CreateBillView(ctx context.Context, view *models.BillView) error {
+	if err := s.Store.CreateBillView(ctx, view); err != nil {
+		return err
+	}
+	s.record(ctx, models.AuditCreate, EntityBillView, view.ID, "", nil, view)
+	return nil
+}
+
+func (s *Store) UpdateBillView(ctx context.Context, view *models.BillView) error {
+	before := snapshot(s.Store.GetBillView(ctx, view.ID))
+	if err := s.Store.UpdateBillView(ctx, view); err != nil {
+		return err
+	}
+	s.record(ctx, models.AuditUpdate, EntityBillView, view.ID, "", before, view)
+	return nil
+}
+
+func (s *Store) DeleteBillView(ctx context.Context, viewID string) error {
+	before := snapshot(s.Store.GetBillView(ctx, viewID))
+	if err := s.Store.DeleteBillView(ctx, viewID); err != nil {
+		return err
+	}
+	s.record(ctx, models.AuditDelete, EntityBillView, viewID, "", before, nil)
+	return nil
+}
@@ -0,0 +1,145 @@
+// Package chaos wraps a storage.Store with injected failures and latency, for
+// testing how the services degrade when storage misbehaves. It is only used
+// by tests; nothing in the server wires it up.
+//
+//	store := chaos.New(sqliteStore, chaos.Config{Seed: 1})
+//	store.SetFault("GetGroup", chaos.Fault{ErrorRate: 1})
+package chaos
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/mmynk/splitwiser/internal/storage"
+)
+
+// ErrInjected is returned by calls failed by a Fault without its own error.
+var ErrInjected = errors.New("chaos: injected storage fault")
+
+// Fault describes what happens to calls of a Store method.
+type Fault struct {
+	// ErrorRate is the probability, from 0 to 1, that a call fails without
+	// reaching the wrapped store.
+	ErrorRate float64
+	// Err is the error failed calls return; ErrInjected when nil.
+	Err error
+	// Latency is added before every call, cut short if the context is done.
+	Latency time.Duration
+}
+
+// Config sets up a Store's faults.
+type Config struct {
+	// Default applies to methods without a fault of their own.
+	Default Fault
+	// Methods holds faults by Store method name, e.g. "GetBill".
+	Methods map[string]Fault
+	// Seed makes which calls fail reproducible.
+	Seed int64
+}
+
+// Store is a storage.Store that injects faults before delegating to another.
+type Store struct {
+	next storage.Store
+
+	mu      sync.Mutex
+	def     Fault
+	methods map[string]Fault
+	rng     *rand.Rand
+	calls   map[string]int
+	failed  map[string]int
+}
+
+var _ storage.Store = (*Store)(nil)
+
+// New wraps next with the faults in cfg.
+func New(next storage.Store, cfg Config) *Store {
+	methods := make(map[string]Fault, len(cfg.Methods))
+	for name, f := range cfg.Methods {
+		methods[name] = f
+	}
+	return &Store{
+		next:    next,
+		def:     cfg.Default,
+		methods: methods,
+		rng:     rand.New(rand.NewSource(cfg.Seed)),
+		calls:   make(map[string]int),
+		failed:  make(map[string]int),
+	}
+}
+
+// SetFault replaces the fault for one method; a Fault with no error rate or
+// latency removes it.
+func (s *Store) SetFault(method string, f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if f.ErrorRate == 0 && f.Latency == 0 {
+		delete(s.methods, method)
+		return
+	}
+	s.methods[method] = f
+}
+
+// SetDefault replaces the fault for methods without one of their own.
+func (s *Store) SetDefault(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.def = f
+}
+
+// Reset removes every fault and clears the call counts.
+func (s *Store) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.def = Fault{}
+	s.methods = make(map[string]Fault)
+	s.calls = make(map[string]int)
+	s.failed = make(map[string]int)
+}
+
+// Calls returns how many times method was called, and how many of those calls failed.
+func (s *Store) Calls(method string) (calls, failed int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[method], s.failed[method]
+}
+
+// inject applies method's fault to one call, returning the error to fail it
+// with, or nil to let it through.
+func (s *Store) inject(ctx context.Context, method string) error {
+	s.mu.Lock()
+	f, ok := s.methods[method]
+	if !ok {
+		f = s.def
+	}
+	fail := f.ErrorRate > 0 && s.rng.Float64() < f.ErrorRate
+	s.calls[method]++
+	if fail {
+		s.failed[method]++
+	}
+	s.mu.Unlock()
+
+	if f.Latency > 0 {
+		timer := time.NewTimer(f.Latency)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if !fail {
+		return nil
+	}
+	if f.Err != nil {
+		return f.Err
+	}
+	return ErrInjected
+}
+
+// Close closes the wrapped store. It is never failed.
+func (s *Store) Close() error {
+	return s.next.Close()
+}
@@ -0,0 +1,654 @@
+package chaos
+
+import (
+	"context"
+
+	"github.com/mmynk/splitwiser/internal/models"
+	"github.com/mmynk/splitwiser/internal/storage"
+)
+
+// Every storage.Store method applies its fault and then delegates.
+
+func (s *Store) CreateBill(ctx context.Context, bill *models.Bill) error {
+	if err := s.inject(ctx, "CreateBill"); err != nil {
+		return err
+	}
+	return s.next.CreateBill(ctx, bill)
+}
+
+func (s *Store) CreateBillInGroup(ctx context.Context, bill *models.Bill, members []models.GroupMember) (int, error) {
+	if err := s.inject(ctx, "CreateBillInGroup"); err != nil {
+		return 0, err
+	}
+	return s.next.CreateBillInGroup(ctx, bill, members)
+}
+
+func (s *Store) CreateBillsInGroup(ctx context.Context, groupID string, bills []*models.Bill, members []models.GroupMember) (int, error) {
+	if err := s.inject(ctx, "CreateBillsInGroup"); err != nil {
+		return 0, err
+	}
+	return s.next.CreateBillsInGroup(ctx, groupID, bills, members)
+}
+
+func (s *Store) GetBill(ctx context.Context, billID string) (*models.Bill, error) {
+	if err := s.inject(ctx, "GetBill"); err != nil {
+		return nil, err
+	}
+	return s.next.GetBill(ctx, billID)
+}
+
+func (s *Store) GetBillsByIDs(ctx context.Context, billIDs []string) ([]*models.Bill, error) {
+	if err := s.inject(ctx, "GetBillsByIDs"); err != nil {
+		return nil, err
+	}
+	return s.next.GetBillsByIDs(ctx, billIDs)
+}
+
+func (s *Store) UpdateBill(ctx context.Context, bill *models.Bill) error {
+	if err := s.inject(ctx, "UpdateBill"); err != nil {
+		return err
+	}
+	return s.next.UpdateBill(ctx, bill)
+}
+
+func (s *Store) UpdateBillInGroup(ctx context.Context, bill *models.Bill, members []models.GroupMember) (int, error) {
+	if err := s.inject(ctx, "UpdateBillInGroup"); err != nil {
+		return 0, err
+	}
+	return s.next.UpdateBillInGroup(ctx, bill, members)
+}
+
+func (s *Store) ListBillRevisions(ctx context.Context, billID string) ([]*models.BillRevision, error) {
+	if err := s.inject(ctx, "ListBillRevisions"); err != nil {
+		return nil, err
+	}
+	return s.next.ListBillRevisions(ctx, billID)
+}
+
+func (s *Store) GetBillRevision(ctx context.Context, billID string, revision int) (*models.BillRevision, error) {
+	if err := s.inject(ctx, "GetBillRevision"); err != nil {
+		return nil, err
+	}
+	return s.next.GetBillRevision(ctx, billID, revision)
+}
+
+func (s *Store) DeleteBill(ctx context.Context, billID string) error {
+	if err := s.inject(ctx, "DeleteBill"); err != nil {
+		return err
+	}
+	return s.next.DeleteBill(ctx, billID)
+}
+
+func (s *Store) GetDeletedBill(ctx context.Context, billID string) (*models.Bill, error) {
+	if err := s.inject(ctx, "GetDeletedBill"); err != nil {
+		return nil, err
+	}
+	return s.next.GetDeletedBill(ctx, billID)
+}
+
+func (s *Store) ListDeletedBillsByUser(ctx context.Context, userID string) ([]*models.Bill, error) {
+	if err := s.inject(ctx, "ListDeletedBillsByUser"); err != nil {
+		return nil, err
+	}
+	return s.next.ListDeletedBillsByUser(ctx, userID)
+}
+
+func (s *Store) RestoreBill(ctx context.Context, billID string) error {
+	if err := s.inject(ctx, "RestoreBill"); err != nil {
+		return err
+	}
+	return s.next.RestoreBill(ctx, billID)
+}
+
+func (s *Store) PurgeBill(ctx context.Context, billID string) error {
+	if err := s.inject(ctx, "PurgeBill"); err != nil {
+		return err
+	}
+	return s.next.PurgeBill(ctx, billID)
+}
+
+func (s *Store) ListBillsByGroup(ctx context.Context, groupID string, filter storage.BillFilter) ([]*models.Bill, error) {
+	if err := s.inject(ctx, "ListBillsByGroup"); err != nil {
+		return nil, err
+	}
+	return s.next.ListBillsByGroup(ctx, groupID, filter)
+}
+
+func (s *Store) ListCategories(ctx context.Context) ([]models.Category, error) {
+	if err := s.inject(ctx, "ListCategories"); err != nil {
+		return nil, err
+	}
+	return s.next.ListCategories(ctx)
+}
+
+func (s *Store) ListBillsByUser(ctx context.Context, userID string, filter storage.BillFilter) ([]*models.Bill, error) {
+	if err := s.inject(ctx, "ListBillsByUser"); err != nil {
+		return nil, err
+	}
+	return s.next.ListBillsByUser(ctx, userID, filter)
+}
+
+func (s *Store) SearchBills(ctx context.Context, userID, query string, filter storage.BillFilter) ([]*models.Bill, error) {
+	if err := s.inject(ctx, "SearchBills"); err != nil {
+		return nil, err
+	}
+	return s.next.SearchBills(ctx, userID, query, filter)
+}
+
+func (s *Store) ListDirectBillsByUser(ctx context.Context, userID string) ([]*models.Bill, error) {
+	if err := s.inject(ctx, "ListDirectBillsByUser"); err != nil {
+		return nil, err
+	}
+	return s.next.ListDirectBillsByUser(ctx, userID)
+}
+
+func (s *Store) ListUnassignedBillsByUser(ctx context.Context, userID string) ([]*models.Bill, error) {
+	if err := s.inject(ctx, "ListUnassignedBillsByUser"); err != nil {
+		return nil, err
+	}
+	return s.next.ListUnassignedBillsByUser(ctx, userID)
+}
+
+func (s *Store) ListBalanceBillsByUser(ctx context.Context, userID string) ([]*models.Bill, error) {
+	if err := s.inject(ctx, "ListBalanceBillsByUser"); err != nil {
+		return nil, err
+	}
+	return s.next.ListBalanceBillsByUser(ctx, userID)
+}
+
+func (s *Store) CreateGroup(ctx context.Context, group *models.Group) error {
+	if err := s.inject(ctx, "CreateGroup"); err != nil {
+		return err
+	}
+	return s.next.CreateGroup(ctx, group)
+}
+
+func (s *Store) GetGroup(ctx context.Context, groupID string) (*models.Group, error) {
+	if err := s.inject(ctx, "GetGroup"); err != nil {
+		return nil, err
+	}
+	return s.next.GetGroup(ctx, groupID)
+}
+
+func (s *Store) ListGroupsByUser(ctx context.Context, userID string, page storage.Page) ([]*models.Group, error) {
+	if err := s.inject(ctx, "ListGroupsByUser"); err != nil {
+		return nil, err
+	}
+	return s.next.ListGroupsByUser(ctx, userID, page)
+}
+
+func (s *Store) UpdateGroup(ctx context.Context, group *models.Group) error {
+	if err := s.inject(ctx, "UpdateGroup"); err != nil {
+		return err
+	}
+	return s.next.UpdateGroup(ctx, group)
+}
+
+func (s *Store) AddGroupMembers(ctx context.Context, groupID string, memberIDs []string) error {
+	if err := s.inject(ctx, "AddGroupMembers"); err != nil {
+		return err
+	}
+	return s.next.AddGroupMembers(ctx, groupID, memberIDs)
+}
+
+func (s *Store) DeleteGroup(ctx context.Context, groupID string) error {
+	if err := s.inject(ctx, "DeleteGroup"); err != nil {
+		return err
+	}
+	return s.next.DeleteGroup(ctx, groupID)
+}
+
+func (s *Store) CreateSettlement(ctx context.Context, settlement *models.Settlement) error {
+	if err := s.inject(ctx, "CreateSettlement"); err != nil {
+		return err
+	}
+	return s.next.CreateSettlement(ctx, settlement)
+}
+
+func (s *Store) GetSettlement(ctx context.Context, settlementID string) (*models.Settlement, error) {
+	if err := s.inject(ctx, "GetSettlement"); err != nil {
+		return nil, err
+	}
+	return s.next.GetSettlement(ctx, settlementID)
+}
+
+func (s *Store) ListSettlementsByGroup(ctx context.Context, groupID string, filter storage.SettlementFilter) ([]*models.Settlement, error) {
+	if err := s.inject(ctx, "ListSettlementsByGroup"); err != nil {
+		return nil, err
+	}
+	return s.next.ListSettlementsByGroup(ctx, groupID, filter)
+}
+
+func (s *Store) ListDirectSettlementsByUser(ctx context.Context, displayName string) ([]*models.Settlement, error) {
+	if err := s.inject(ctx, "ListDirectSettlementsByUser"); err != nil {
+		return nil, err
+	}
+	return s.next.ListDirectSettlementsByUser(ctx, displayName)
+}
+
+func (s *Store) ListBalanceSettlementsByUser(ctx context.Context, userID, displayName string) ([]*models.Settlement, error) {
+	if err := s.inject(ctx, "ListBalanceSettlementsByUser"); err != nil {
+		return nil, err
+	}
+	return s.next.ListBalanceSettlementsByUser(ctx, userID, displayName)
+}
+
+func (s *Store) DeleteSettlement(ctx context.Context, settlementID string) error {
+	if err := s.inject(ctx, "DeleteSettlement"); err != nil {
+		return err
+	}
+	return s.next.DeleteSettlement(ctx, settlementID)
+}
+
+func (s *Store) CreateSettlementPlan(ctx context.Context, plan *models.SettlementPlan) error {
+	if err := s.inject(ctx, "CreateSettlementPlan"); err != nil {
+		return err
+	}
+	return s.next.CreateSettlementPlan(ctx, plan)
+}
+
+func (s *Store) GetSettlementPlan(ctx context.Context, planID string) (*models.SettlementPlan, error) {
+	if err := s.inject(ctx, "GetSettlementPlan"); err != nil {
+		return nil, err
+	}
+	return s.next.GetSettlementPlan(ctx, planID)
+}
+
+func (s *Store) ListSettlementPlansByGroup(ctx context.Context, groupID string) ([]*models.SettlementPlan, error) {
+	if err := s.inject(ctx, "ListSettlementPlansByGroup"); err != nil {
+		return nil, err
+	}
+	return s.next.ListSettlementPlansByGroup(ctx, groupID)
+}
+
+func (s *Store) ListSettlementPlansByPayer(ctx context.Context, displayName string) ([]*models.SettlementPlan, error) {
+	if err := s.inject(ctx, "ListSettlementPlansByPayer"); err != nil {
+		return nil, err
+	}
+	return s.next.ListSettlementPlansByPayer(ctx, displayName)
+}
+
+func (s *Store) DeleteSettlementPlan(ctx context.Context, planID string) error {
+	if err := s.inject(ctx, "DeleteSettlementPlan"); err != nil {
+		return err
+	}
+	return s.next.DeleteSettlementPlan(ctx, planID)
+}
+
+func (s *Store) CreateGroupWebhook(ctx context.Context, webhook *models.GroupWebhook) error {
+	if err := s.inject(ctx, "CreateGroupWebhook"); err != nil {
+		return err
+	}
+	return s.next.CreateGroupWebhook(ctx, webhook)
+}
+
+func (s *Store) GetGroupWebhook(ctx context.Context, webhookID string) (*models.GroupWebhook, error) {
+	if err := s.inject(ctx, "GetGroupWebhook"); err != nil {
+		return nil, err
+	}
+	return s.next.GetGroupWebhook(ctx, webhookID)
+}
+
+func (s *Store) ListGroupWebhooks(ctx context.Context, groupID string) ([]*models.GroupWebhook, error) {
+	if err := s.inject(ctx, "ListGroupWebhooks"); err != nil {
+		return nil, err
+	}
+	return s.next.ListGroupWebhooks(ctx, groupID)
+}
+
+func (s *Store) SetGroupWebhookOverThreshold(ctx context.Context, webhookID string, members []string) error {
+	if err := s.inject(ctx, "SetGroupWebhookOverThreshold"); err != nil {
+		return err
+	}
+	return s.next.SetGroupWebhookOverThreshold(ctx, webhookID, members)
+}
+
+func (s *Store) DeleteGroupWebhook(ctx context.Context, webhookID string) error {
+	if err := s.inject(ctx, "DeleteGroupWebhook"); err != nil {
+		return err
+	}
+	return s.next.DeleteGroupWebhook(ctx, webhookID)
+}
+
+func (s *Store) CreateAccountWebhook(ctx context.Context, webhook *models.AccountWebhook) error {
+	if err := s.inject(ctx, "CreateAccountWebhook"); err != nil {
+		return err
+	}
+	return s.next.CreateAccountWebhook(ctx, webhook)
+}
+
+func (s *Store) GetAccountWebhook(ctx context.Context, webhookID string) (*models.AccountWebhook, error) {
+	if err := s.inject(ctx, "GetAccountWebhook"); err != nil {
+		return nil, err
+	}
+	return s.next.GetAccountWebhook(ctx, webhookID)
+}
+
+func (s *Store) ListAccountWebhooks(ctx context.Context, userID string) ([]*models.AccountWebhook, error) {
+	if err := s.inject(ctx, "ListAccountWebhooks"); err != nil {
+		return nil, err
+	}
+	return s.next.ListAccountWebhooks(ctx, userID)
+}
+
+func (s *Store) DeleteAccountWebhook(ctx context.Context, webhookID string) error {
+	if err := s.inject(ctx, "DeleteAccountWebhook"); err != nil {
+		return err
+	}
+	return s.next.DeleteAccountWebhook(ctx, webhookID)
+}
+
+func (s *Store) CreateBillView(ctx context.Context, view *models.BillView) error {
+	if err := s.inject(ctx, "CreateBillView"); err != nil {
+		return err
+	}
+	return s.next.CreateBillView(ctx, view)
+}
+
+func (s *Store) GetBillView(ctx context.Context, viewID string) (*models.BillView, error) {
+	if err := s.inject(ctx, "GetBillView"); err != nil {
+		return nil, err
+	}
+	return s.next.GetBillView(ctx, viewID)
+}
+
+func (s *Store) ListBillViews(ctx context.Context, userID string) ([]*models.BillView, error) {
+	if err := s.inject(ctx, "ListBillViews"); err != nil {
+		return nil, err
+	}
+	return s.next.ListBillViews(ctx, userID)
+}
+
+func (s *Store) UpdateBillView(ctx context.Context, view *models.BillView) error {
+	if err := s.inject(ctx, "UpdateBillView"); err != nil {
+		return err
+	}
+	return s.next.UpdateBillView(ctx, view)
+}
+
+func (s *Store) DeleteBillView(ctx context.Context, viewID string) error {
+	if err := s.inject(ctx, "DeleteBillView"); err != nil {
+		return err
+	}
+	return s.next.DeleteBillView(ctx, viewID)
+}
+
+func (s *Store) CreateAttachment(ctx context.Context, attachment *models.Attachment) error {
+	if err := s.inject(ctx, "CreateAttachment"); err != nil {
+		return err
+	}
+	return s.next.CreateAttachment(ctx, attachment)
+}
+
+func (s *Store) GetAttachment(ctx context.Context, attachmentID string) (*models.Attachment, error) {
+	if err := s.inject(ctx, "GetAttachment"); err != nil {
+		return nil, err
+	}
+	return s.next.GetAttachment(ctx, attachmentID)
+}
+
+func (s *Store) ListAttachmentsByBill(ctx context.Context, billID string) ([]*models.Attachment, error) {
+	if err := s.inject(ctx, "ListAttachmentsByBill"); err != nil {
+		return nil, err
+	}
+	return s.next.ListAttachmentsByBill(ctx, billID)
+}
+
+func (s *Store) ListAttachmentBlobKeys(ctx context.Context) ([]string, error) {
+	if err := s.inject(ctx, "ListAttachmentBlobKeys"); err != nil {
+		return nil, err
+	}
+	return s.next.ListAttachmentBlobKeys(ctx)
+}
+
+func (s *Store) CreateComment(ctx context.Context, comment *models.Comment) error {
+	if err := s.inject(ctx, "CreateComment"); err != nil {
+		return err
+	}
+	return s.next.CreateComment(ctx, comment)
+}
+
+func (s *Store) GetComment(ctx context.Context, commentID string) (*models.Comment, error) {
+	if err := s.inject(ctx, "GetComment"); err != nil {
+		return nil, err
+	}
+	return s.next.GetComment(ctx, commentID)
+}
+
+func (s *Store) ListCommentsByBill(ctx context.Context, billID string) ([]*models.Comment, error) {
+	if err := s.inject(ctx, "ListCommentsByBill"); err != nil {
+		return nil, err
+	}
+	return s.next.ListCommentsByBill(ctx, billID)
+}
+
+func (s *Store) DeleteComment(ctx context.Context, commentID string) error {
+	if err := s.inject(ctx, "DeleteComment"); err != nil {
+		return err
+	}
+	return s.next.DeleteComment(ctx, commentID)
+}
+
+func (s *Store) SetGroupStatsToken(ctx context.Context, groupID, tokenHash, createdBy string) error {
+	if err := s.inject(ctx, "SetGroupStatsToken"); err != nil {
+		return err
+	}
+	return s.next.SetGroupStatsToken(ctx, groupID, tokenHash, createdBy)
+}
+
+func (s *Store) DeleteGroupStatsToken(ctx context.Context, groupID string) error {
+	if err := s.inject(ctx, "DeleteGroupStatsToken"); err != nil {
+		return err
+	}
+	return s.next.DeleteGroupStatsToken(ctx, groupID)
+}
+
+func (s *Store) GetGroupIDByStatsToken(ctx context.Context, tokenHash string) (string, error) {
+	if err := s.inject(ctx, "GetGroupIDByStatsToken"); err != nil {
+		return "", err
+	}
+	return s.next.GetGroupIDByStatsToken(ctx, tokenHash)
+}
+
+func (s *Store) MarkShareAsPaid(ctx context.Context, payment *models.SharePayment) error {
+	if err := s.inject(ctx, "MarkShareAsPaid"); err != nil {
+		return err
+	}
+	return s.next.MarkShareAsPaid(ctx, payment)
+}
+
+func (s *Store) UnmarkShareAsPaid(ctx context.Context, billID, participant string) error {
+	if err := s.inject(ctx, "UnmarkShareAsPaid"); err != nil {
+		return err
+	}
+	return s.next.UnmarkShareAsPaid(ctx, billID, participant)
+}
+
+func (s *Store) SetBillLocked(ctx context.Context, billID string, lockedAt int64) error {
+	if err := s.inject(ctx, "SetBillLocked"); err != nil {
+		return err
+	}
+	return s.next.SetBillLocked(ctx, billID, lockedAt)
+}
+
+func (s *Store) ListSharePayments(ctx context.Context, billIDs []string) ([]models.SharePayment, error) {
+	if err := s.inject(ctx, "ListSharePayments"); err != nil {
+		return nil, err
+	}
+	return s.next.ListSharePayments(ctx, billIDs)
+}
+
+func (s *Store) SetBillShareToken(ctx context.Context, billID, tokenHash, createdBy string) error {
+	if err := s.inject(ctx, "SetBillShareToken"); err != nil {
+		return err
+	}
+	return s.next.SetBillShareToken(ctx, billID, tokenHash, createdBy)
+}
+
+func (s *Store) DeleteBillShareToken(ctx context.Context, billID string) error {
+	if err := s.inject(ctx, "DeleteBillShareToken"); err != nil {
+		return err
+	}
+	return s.next.DeleteBillShareToken(ctx, billID)
+}
+
+func (s *Store) GetBillIDByShareToken(ctx context.Context, tokenHash string) (string, error) {
+	if err := s.inject(ctx, "GetBillIDByShareToken"); err != nil {
+		return "", err
+	}
+	return s.next.GetBillIDByShareToken(ctx, tokenHash)
+}
+
+func (s *Store) ListGroupMonthlySpend(ctx context.Context, groupID string) ([]models.MonthlySpend, error) {
+	if err := s.inject(ctx, "ListGroupMonthlySpend"); err != nil {
+		return nil, err
+	}
+	return s.next.ListGroupMonthlySpend(ctx, groupID)
+}
+
+func (s *Store) CreateSpendingCapAlerts(ctx context.Context, alerts []models.SpendingCapAlert) error {
+	if err := s.inject(ctx, "CreateSpendingCapAlerts"); err != nil {
+		return err
+	}
+	return s.next.CreateSpendingCapAlerts(ctx, alerts)
+}
+
+func (s *Store) ListSpendingCapAlerts(ctx context.Context, groupID, month string) ([]models.SpendingCapAlert, error) {
+	if err := s.inject(ctx, "ListSpendingCapAlerts"); err != nil {
+		return nil, err
+	}
+	return s.next.ListSpendingCapAlerts(ctx, groupID, month)
+}
+
+func (s *Store) ListBillSpendingCapAlerts(ctx context.Context, billID string) ([]models.SpendingCapAlert, error) {
+	if err := s.inject(ctx, "ListBillSpendingCapAlerts"); err != nil {
+		return nil, err
+	}
+	return s.next.ListBillSpendingCapAlerts(ctx, billID)
+}
+
+func (s *Store) CreateTransactionBill(ctx context.Context, bill *models.Bill, transactionID string) (bool, error) {
+	if err := s.inject(ctx, "CreateTransactionBill"); err != nil {
+		return false, err
+	}
+	return s.next.CreateTransactionBill(ctx, bill, transactionID)
+}
+
+func (s *Store) ImportGroupArchive(ctx context.Context, archive *models.GroupArchive) error {
+	if err := s.inject(ctx, "ImportGroupArchive"); err != nil {
+		return err
+	}
+	return s.next.ImportGroupArchive(ctx, archive)
+}
+
+func (s *Store) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	if err := s.inject(ctx, "GetUserByEmail"); err != nil {
+		return nil, err
+	}
+	return s.next.GetUserByEmail(ctx, email)
+}
+
+func (s *Store) GetUsersByIDs(ctx context.Context, ids []string) (map[string]*models.User, error) {
+	if err := s.inject(ctx, "GetUsersByIDs"); err != nil {
+		return nil, err
+	}
+	return s.next.GetUsersByIDs(ctx, ids)
+}
+
+func (s *Store) SearchUsers(ctx context.Context, email string, callerID string) (*models.User, error) {
+	if err := s.inject(ctx, "SearchUsers"); err != nil {
+		return nil, err
+	}
+	return s.next.SearchUsers(ctx, email, callerID)
+}
+
+func (s *Store) AddGroupMembersWithIDs(ctx context.Context, groupID string, members []models.GroupMember) error {
+	if err := s.inject(ctx, "AddGroupMembersWithIDs"); err != nil {
+		return err
+	}
+	return s.next.AddGroupMembersWithIDs(ctx, groupID, members)
+}
+
+func (s *Store) SendFriendRequest(ctx context.Context, friendship *models.Friendship) error {
+	if err := s.inject(ctx, "SendFriendRequest"); err != nil {
+		return err
+	}
+	return s.next.SendFriendRequest(ctx, friendship)
+}
+
+func (s *Store) GetFriendship(ctx context.Context, id string) (*models.Friendship, error) {
+	if err := s.inject(ctx, "GetFriendship"); err != nil {
+		return nil, err
+	}
+	return s.next.GetFriendship(ctx, id)
+}
+
+func (s *Store) UpdateFriendshipStatus(ctx context.Context, id string, status models.FriendshipStatus) error {
+	if err := s.inject(ctx, "UpdateFriendshipStatus"); err != nil {
+		return err
+	}
+	return s.next.UpdateFriendshipStatus(ctx, id, status)
+}
+
+func (s *Store) ListFriendships(ctx context.Context, userID string, incoming bool, status models.FriendshipStatus) ([]*models.Friendship, error) {
+	if err := s.inject(ctx, "ListFriendships"); err != nil {
+		return nil, err
+	}
+	return s.next.ListFriendships(ctx, userID, incoming, status)
+}
+
+func (s *Store) DeleteFriendship(ctx context.Context, id string) error {
+	if err := s.inject(ctx, "DeleteFriendship"); err != nil {
+		return err
+	}
+	return s.next.DeleteFriendship(ctx, id)
+}
+
+func (s *Store) AreFriends(ctx context.Context, userIDA, userIDB string) (bool, error) {
+	if err := s.inject(ctx, "AreFriends"); err != nil {
+		return false, err
+	}
+	return s.next.AreFriends(ctx, userIDA, userIDB)
+}
+
+func (s *Store) GetFriends(ctx context.Context, userID string) ([]*models.User, error) {
+	if err := s.inject(ctx, "GetFriends"); err != nil {
+		return nil, err
+	}
+	return s.next.GetFriends(ctx, userID)
+}
+
+func (s *Store) GetFriendshipBetween(ctx context.Context, userIDA, userIDB string) (*models.Friendship, error) {
+	if err := s.inject(ctx, "GetFriendshipBetween"); err != nil {
+		return nil, err
+	}
+	return s.next.GetFriendshipBetween(ctx, userIDA, userIDB)
+}
+
+func (s *Store) SearchFriends(ctx context.Context, callerID string, query string) ([]*models.User, error) {
+	if err := s.inject(ctx, "SearchFriends"); err != nil {
+		return nil, err
+	}
+	return s.next.SearchFriends(ctx, callerID, query)
+}
+
+func (s *Store) CreateAuditEntry(ctx context.Context, entry *models.AuditEntry) error {
+	if err := s.inject(ctx, "CreateAuditEntry"); err != nil {
+		return err
+	}
+	return s.next.CreateAuditEntry(ctx, entry)
+}
+
+func (s *Store) ListAuditEntries(ctx context.Context, filter storage.AuditFilter) ([]*models.AuditEntry, error) {
+	if err := s.inject(ctx, "ListAuditEntries"); err != nil {
+		return nil, err
+	}
+	return s.next.ListAuditEntries(ctx, filter)
+}
+
+func (s *Store) GetUsage(ctx context.Context, userID string, since int64) (*models.Usage, error) {
+	if err := s.inject(ctx, "GetUsage"); err != nil {
+		return nil, err
+	}
+	return s.next.GetUsage(ctx, userID, since)
+}
@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors a Store returns, possibly wrapped, for failures callers handle
+// differently from a broken database. Test for them with errors.Is.
+var (
+	// ErrNotFound means the entity asked for doesn't exist.
+	ErrNotFound = errors.New("not found")
+
+	// ErrConflict means a write would duplicate an entity that already exists.
+	ErrConflict = errors.New("conflict")
+
+	// ErrForeignKey means a write refers to an entity that doesn't exist.
+	ErrForeignKey = errors.New("foreign key violation")
+
+	// ErrReadOnly means a write was refused because the store is read-only,
+	// e.g. while the database is being backed up or migrated.
+	ErrReadOnly = errors.New("read-only")
+)
+
+// kindError is an error of one of the kinds above that keeps its own message.
+type kindError struct {
+	kind error
+	err  error
+}
+
+func (e *kindError) Error() string   { return e.err.Error() }
+func (e *kindError) Unwrap() []error { return []error{e.kind, e.err} }
+
+// Errorf formats an error that matches kind, one of ErrNotFound, ErrConflict,
+// ErrForeignKey or ErrReadOnly, without repeating it in the message:
+//
+//	storage.Errorf(storage.ErrNotFound, "bill not found: %s", billID)
+func Errorf(kind error, format string, args ...any) error {
+	return &kindError{kind: kind, err: fmt.Errorf(format, args...)}
+}
@@ -0,0 +1,35 @@
+package storage
+
+// Page selects a window of a list. The zero Page selects the whole list.
+type Page struct {
+	Limit  int // Rows to return at most; 0 means no limit
+	Offset int // Rows to skip from the start of the list
+}
+
+// BillFilter narrows a bill listing. The zero BillFilter matches every bill.
+type BillFilter struct {
+	GroupID    string // Only bills in this group
+	Ungrouped  bool   // Only bills without a group
+	Since      int64  // Only bills created at or after this Unix time
+	Until      int64  // Only bills created before this Unix time
+	CategoryID string // Only bills filed under this category
+	PaidBy     string // Only bills this user ID paid
+	// ExcludeArchived leaves out bills archived for their age, as listings do
+	// unless asked for them.
+	ExcludeArchived bool
+	Page            Page
+}
+
+// SettlementFilter narrows a settlement listing. The zero SettlementFilter
+// matches every settlement.
+type SettlementFilter struct {
+	Until int64 // Only settlements created before this Unix time
+	Page  Page
+}
+
+// AuditFilter narrows an audit log listing. The zero AuditFilter matches every entry.
+type AuditFilter struct {
+	GroupID  string // Only changes to entities in this group
+	EntityID string // Only changes to this entity
+	Page     Page
+}
@@ -0,0 +1,84 @@
+// Package metrics wraps a storage.Store with Prometheus instrumentation: the
+// latency and errors of every call by Store method, and how many rows list
+// methods return, so slow or fanned-out queries behind an RPC show up.
+//
+//	store := metrics.New(sqliteStore)
+package metrics
+
+import (
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/mmynk/splitwiser/internal/storage"
+)
+
+var (
+	operationDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "splitwiser_store_operation_duration_seconds",
+		Help:    "Duration of storage calls by Store method.",
+		Buckets: prometheus.ExponentialBuckets(0.0005, 4, 8), // 0.5ms to ~8s
+	}, []string{"operation"})
+
+	operationErrorsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "splitwiser_store_errors_total",
+		Help: "Failed storage calls by Store method and kind (not_found, conflict, foreign_key or other).",
+	}, []string{"operation", "kind"})
+
+	operationRows = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "splitwiser_store_rows_returned",
+		Help:    "Rows returned by storage list calls, by Store method.",
+		Buckets: prometheus.ExponentialBuckets(1, 4, 8), // 1 to 16384
+	}, []string{"operation"})
+)
+
+// Store is a storage.Store that records metrics for calls to another.
+type Store struct {
+	next storage.Store
+}
+
+var _ storage.Store = (*Store)(nil)
+
+// New wraps next with metrics.
+func New(next storage.Store) *Store {
+	return &Store{next: next}
+}
+
+// observe records a call of operation that started at start and failed
+// with *err, if not nil.
+func observe(operation string, start time.Time, err *error) {
+	operationDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	if *err != nil {
+		operationErrorsTotal.WithLabelValues(operation, errorKind(*err)).Inc()
+	}
+}
+
+// observeRows is observe for calls returning rows, which rows counts once
+// the call has returned.
+func observeRows(operation string, start time.Time, err *error, rows func() int) {
+	observe(operation, start, err)
+	if *err == nil {
+		operationRows.WithLabelValues(operation).Observe(float64(rows()))
+	}
+}
+
+// errorKind labels err by which of the storage errors it is.
+func errorKind(err error) string {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		return "not_found"
+	case errors.Is(err, storage.ErrConflict):
+		return "conflict"
+	case errors.Is(err, storage.ErrForeignKey):
+		return "foreign_key"
+	default:
+		return "other"
+	}
+}
+
+// Close closes the wrapped store. It isn't recorded.
+func (s *Store) Close() error {
+	return s.next.Close()
+}
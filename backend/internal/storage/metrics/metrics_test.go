@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/mmynk/splitwiser/internal/storage"
+	"github.com/mmynk/splitwiser/internal/storage/sqlite"
+)
+
+// sampleCount is how many observations a histogram has recorded.
+func sampleCount(t *testing.T, h prometheus.Observer) uint64 {
+	t.Helper()
+	var m dto.Metric
+	if err := h.(prometheus.Metric).Write(&m); err != nil {
+		t.Fatal(err)
+	}
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqlite.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	store := New(db)
+	defer store.Close()
+
+	notFound := testutil.ToFloat64(operationErrorsTotal.WithLabelValues("GetBill", "not_found"))
+	calls := sampleCount(t, operationDuration.WithLabelValues("GetBill"))
+	lists := sampleCount(t, operationRows.WithLabelValues("ListGroupsByUser"))
+
+	if _, err := store.GetBill(ctx, "missing"); err == nil {
+		t.Fatal("GetBill of a missing bill succeeded")
+	}
+	if _, err := store.ListGroupsByUser(ctx, "u1", storage.Page{}); err != nil {
+		t.Fatalf("ListGroupsByUser: %v", err)
+	}
+
+	if got := testutil.ToFloat64(operationErrorsTotal.WithLabelValues("GetBill", "not_found")); got != notFound+1 {
+		t.Errorf("not_found errors of GetBill = %v, want %v", got, notFound+1)
+	}
+	if got := sampleCount(t, operationDuration.WithLabelValues("GetBill")); got != calls+1 {
+		t.Errorf("GetBill durations = %d, want %d", got, calls+1)
+	}
+	if got := sampleCount(t, operationRows.WithLabelValues("ListGroupsByUser")); got != lists+1 {
+		t.Errorf("ListGroupsByUser row counts = %d, want %d", got, lists+1)
+	}
+}
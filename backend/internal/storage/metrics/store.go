@@ -0,0 +1,472 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/mmynk/splitwiser/internal/models"
+	"github.com/mmynk/splitwiser/internal/storage"
+)
+
+// Every storage.Store method is timed and counted as it delegates; those
+// returning lists also record how many rows they returned.
+
+func (s *Store) CreateBill(ctx context.Context, bill *models.Bill) (err error) {
+	defer observe("CreateBill", time.Now(), &err)
+	return s.next.CreateBill(ctx, bill)
+}
+
+func (s *Store) CreateBillInGroup(ctx context.Context, bill *models.Bill, members []models.GroupMember) (_ int, err error) {
+	defer observe("CreateBillInGroup", time.Now(), &err)
+	return s.next.CreateBillInGroup(ctx, bill, members)
+}
+
+func (s *Store) CreateBillsInGroup(ctx context.Context, groupID string, bills []*models.Bill, members []models.GroupMember) (_ int, err error) {
+	defer observe("CreateBillsInGroup", time.Now(), &err)
+	return s.next.CreateBillsInGroup(ctx, groupID, bills, members)
+}
+
+func (s *Store) GetBill(ctx context.Context, billID string) (_ *models.Bill, err error) {
+	defer observe("GetBill", time.Now(), &err)
+	return s.next.GetBill(ctx, billID)
+}
+
+func (s *Store) GetBillsByIDs(ctx context.Context, billIDs []string) (rows []*models.Bill, err error) {
+	defer observeRows("GetBillsByIDs", time.Now(), &err, func() int { return len(rows) })
+	return s.next.GetBillsByIDs(ctx, billIDs)
+}
+
+func (s *Store) UpdateBill(ctx context.Context, bill *models.Bill) (err error) {
+	defer observe("UpdateBill", time.Now(), &err)
+	return s.next.UpdateBill(ctx, bill)
+}
+
+func (s *Store) UpdateBillInGroup(ctx context.Context, bill *models.Bill, members []models.GroupMember) (_ int, err error) {
+	defer observe("UpdateBillInGroup", time.Now(), &err)
+	return s.next.UpdateBillInGroup(ctx, bill, members)
+}
+
+func (s *Store) ListBillRevisions(ctx context.Context, billID string) (rows []*models.BillRevision, err error) {
+	defer observeRows("ListBillRevisions", time.Now(), &err, func() int { return len(rows) })
+	return s.next.ListBillRevisions(ctx, billID)
+}
+
+func (s *Store) GetBillRevision(ctx context.Context, billID string, revision int) (_ *models.BillRevision, err error) {
+	defer observe("GetBillRevision", time.Now(), &err)
+	return s.next.GetBillRevision(ctx, billID, revision)
+}
+
+func (s *Store) DeleteBill(ctx context.Context, billID string) (err error) {
+	defer observe("DeleteBill", time.Now(), &err)
+	return s.next.DeleteBill(ctx, billID)
+}
+
+func (s *Store) GetDeletedBill(ctx context.Context, billID string) (_ *models.Bill, err error) {
+	defer observe("GetDeletedBill", time.Now(), &err)
+	return s.next.GetDeletedBill(ctx, billID)
+}
+
+func (s *Store) ListDeletedBillsByUser(ctx context.Context, userID string) (rows []*models.Bill, err error) {
+	defer observeRows("ListDeletedBillsByUser", time.Now(), &err, func() int { return len(rows) })
+	return s.next.ListDeletedBillsByUser(ctx, userID)
+}
+
+func (s *Store) RestoreBill(ctx context.Context, billID string) (err error) {
+	defer observe("RestoreBill", time.Now(), &err)
+	return s.next.RestoreBill(ctx, billID)
+}
+
+func (s *Store) PurgeBill(ctx context.Context, billID string) (err error) {
+	defer observe("PurgeBill", time.Now(), &err)
+	return s.next.PurgeBill(ctx, billID)
+}
+
+func (s *Store) ListBillsByGroup(ctx context.Context, groupID string, filter storage.BillFilter) (rows []*models.Bill, err error) {
+	defer observeRows("ListBillsByGroup", time.Now(), &err, func() int { return len(rows) })
+	return s.next.ListBillsByGroup(ctx, groupID, filter)
+}
+
+func (s *Store) ListCategories(ctx context.Context) (rows []models.Category, err error) {
+	defer observeRows("ListCategories", time.Now(), &err, func() int { return len(rows) })
+	return s.next.ListCategories(ctx)
+}
+
+func (s *Store) ListBillsByUser(ctx context.Context, userID string, filter storage.BillFilter) (rows []*models.Bill, err error) {
+	defer observeRows("ListBillsByUser", time.Now(), &err, func() int { return len(rows) })
+	return s.next.ListBillsByUser(ctx, userID, filter)
+}
+
+func (s *Store) SearchBills(ctx context.Context, userID, query string, filter storage.BillFilter) (rows []*models.Bill, err error) {
+	defer observeRows("SearchBills", time.Now(), &err, func() int { return len(rows) })
+	return s.next.SearchBills(ctx, userID, query, filter)
+}
+
+func (s *Store) ListDirectBillsByUser(ctx context.Context, userID string) (rows []*models.Bill, err error) {
+	defer observeRows("ListDirectBillsByUser", time.Now(), &err, func() int { return len(rows) })
+	return s.next.ListDirectBillsByUser(ctx, userID)
+}
+
+func (s *Store) ListUnassignedBillsByUser(ctx context.Context, userID string) (rows []*models.Bill, err error) {
+	defer observeRows("ListUnassignedBillsByUser", time.Now(), &err, func() int { return len(rows) })
+	return s.next.ListUnassignedBillsByUser(ctx, userID)
+}
+
+func (s *Store) ListBalanceBillsByUser(ctx context.Context, userID string) (rows []*models.Bill, err error) {
+	defer observeRows("ListBalanceBillsByUser", time.Now(), &err, func() int { return len(rows) })
+	return s.next.ListBalanceBillsByUser(ctx, userID)
+}
+
+func (s *Store) CreateGroup(ctx context.Context, group *models.Group) (err error) {
+	defer observe("CreateGroup", time.Now(), &err)
+	return s.next.CreateGroup(ctx, group)
+}
+
+func (s *Store) GetGroup(ctx context.Context, groupID string) (_ *models.Group, err error) {
+	defer observe("GetGroup", time.Now(), &err)
+	return s.next.GetGroup(ctx, groupID)
+}
+
+func (s *Store) ListGroupsByUser(ctx context.Context, userID string, page storage.Page) (rows []*models.Group, err error) {
+	defer observeRows("ListGroupsByUser", time.Now(), &err, func() int { return len(rows) })
+	return s.next.ListGroupsByUser(ctx, userID, page)
+}
+
+func (s *Store) UpdateGroup(ctx context.Context, group *models.Group) (err error) {
+	defer observe("UpdateGroup", time.Now(), &err)
+	return s.next.UpdateGroup(ctx, group)
+}
+
+func (s *Store) AddGroupMembers(ctx context.Context, groupID string, memberIDs []string) (err error) {
+	defer observe("AddGroupMembers", time.Now(), &err)
+	return s.next.AddGroupMembers(ctx, groupID, memberIDs)
+}
+
+func (s *Store) DeleteGroup(ctx context.Context, groupID string) (err error) {
+	defer observe("DeleteGroup", time.Now(), &err)
+	return s.next.DeleteGroup(ctx, groupID)
+}
+
+func (s *Store) CreateSettlement(ctx context.Context, settlement *models.Settlement) (err error) {
+	defer observe("CreateSettlement", time.Now(), &err)
+	return s.next.CreateSettlement(ctx, settlement)
+}
+
+func (s *Store) GetSettlement(ctx context.Context, settlementID string) (_ *models.Settlement, err error) {
+	defer observe("GetSettlement", time.Now(), &err)
+	return s.next.GetSettlement(ctx, settlementID)
+}
+
+func (s *Store) ListSettlementsByGroup(ctx context.Context, groupID string, filter storage.SettlementFilter) (rows []*models.Settlement, err error) {
+	defer observeRows("ListSettlementsByGroup", time.Now(), &err, func() int { return len(rows) })
+	return s.next.ListSettlementsByGroup(ctx, groupID, filter)
+}
+
+func (s *Store) ListDirectSettlementsByUser(ctx context.Context, displayName string) (rows []*models.Settlement, err error) {
+	defer observeRows("ListDirectSettlementsByUser", time.Now(), &err, func() int { return len(rows) })
+	return s.next.ListDirectSettlementsByUser(ctx, displayName)
+}
+
+func (s *Store) ListBalanceSettlementsByUser(ctx context.Context, userID, displayName string) (rows []*models.Settlement, err error) {
+	defer observeRows("ListBalanceSettlementsByUser", time.Now(), &err, func() int { return len(rows) })
+	return s.next.ListBalanceSettlementsByUser(ctx, userID, displayName)
+}
+
+func (s *Store) DeleteSettlement(ctx context.Context, settlementID string) (err error) {
+	defer observe("DeleteSettlement", time.Now(), &err)
+	return s.next.DeleteSettlement(ctx, settlementID)
+}
+
+func (s *Store) CreateSettlementPlan(ctx context.Context, plan *models.SettlementPlan) (err error) {
+	defer observe("CreateSettlementPlan", time.Now(), &err)
+	return s.next.CreateSettlementPlan(ctx, plan)
+}
+
+func (s *Store) GetSettlementPlan(ctx context.Context, planID string) (_ *models.SettlementPlan, err error) {
+	defer observe("GetSettlementPlan", time.Now(), &err)
+	return s.next.GetSettlementPlan(ctx, planID)
+}
+
+func (s *Store) ListSettlementPlansByGroup(ctx context.Context, groupID string) (rows []*models.SettlementPlan, err error) {
+	defer observeRows("ListSettlementPlansByGroup", time.Now(), &err, func() int { return len(rows) })
+	return s.next.ListSettlementPlansByGroup(ctx, groupID)
+}
+
+func (s *Store) ListSettlementPlansByPayer(ctx context.Context, displayName string) (rows []*models.SettlementPlan, err error) {
+	defer observeRows("ListSettlementPlansByPayer", time.Now(), &err, func() int { return len(rows) })
+	return s.next.ListSettlementPlansByPayer(ctx, displayName)
+}
+
+func (s *Store) DeleteSettlementPlan(ctx context.Context, planID string) (err error) {
+	defer observe("DeleteSettlementPlan", time.Now(), &err)
+	return s.next.DeleteSettlementPlan(ctx, planID)
+}
+
+func (s *Store) CreateGroupWebhook(ctx context.Context, webhook *models.GroupWebhook) (err error) {
+	defer observe("CreateGroupWebhook", time.Now(), &err)
+	return s.next.CreateGroupWebhook(ctx, webhook)
+}
+
+func (s *Store) GetGroupWebhook(ctx context.Context, webhookID string) (_ *models.GroupWebhook, err error) {
+	defer observe("GetGroupWebhook", time.Now(), &err)
+	return s.next.GetGroupWebhook(ctx, webhookID)
+}
+
+func (s *Store) ListGroupWebhooks(ctx context.Context, groupID string) (rows []*models.GroupWebhook, err error) {
+	defer observeRows("ListGroupWebhooks", time.Now(), &err, func() int { return len(rows) })
+	return s.next.ListGroupWebhooks(ctx, groupID)
+}
+
+func (s *Store) SetGroupWebhookOverThreshold(ctx context.Context, webhookID string, members []string) (err error) {
+	defer observe("SetGroupWebhookOverThreshold", time.Now(), &err)
+	return s.next.SetGroupWebhookOverThreshold(ctx, webhookID, members)
+}
+
+func (s *Store) DeleteGroupWebhook(ctx context.Context, webhookID string) (err error) {
+	defer observe("DeleteGroupWebhook", time.Now(), &err)
+	return s.next.DeleteGroupWebhook(ctx, webhookID)
+}
+
+func (s *Store) CreateAccountWebhook(ctx context.Context, webhook *models.AccountWebhook) (err error) {
+	defer observe("CreateAccountWebhook", time.Now(), &err)
+	return s.next.CreateAccountWebhook(ctx, webhook)
+}
+
+func (s *Store) GetAccountWebhook(ctx context.Context, webhookID string) (_ *models.AccountWebhook, err error) {
+	defer observe("GetAccountWebhook", time.Now(), &err)
+	return s.next.GetAccountWebhook(ctx, webhookID)
+}
+
+func (s *Store) ListAccountWebhooks(ctx context.Context, userID string) (rows []*models.AccountWebhook, err error) {
+	defer observeRows("ListAccountWebhooks", time.Now(), &err, func() int { return len(rows) })
+	return s.next.ListAccountWebhooks(ctx, userID)
+}
+
+func (s *Store) DeleteAccountWebhook(ctx context.Context, webhookID string) (err error) {
+	defer observe("DeleteAccountWebhook", time.Now(), &err)
+	return s.next.DeleteAccountWebhook(ctx, webhookID)
+}
+
+func (s *Store) CreateBillView(ctx context.Context, view *models.BillView) (err error) {
+	defer observe("CreateBillView", time.Now(), &err)
+	return s.next.CreateBillView(ctx, view)
+}
+
+func (s *Store) GetBillView(ctx context.Context, viewID string) (_ *models.BillView, err error) {
+	defer observe("GetBillView", time.Now(), &err)
+	return s.next.GetBillView(ctx, viewID)
+}
+
+func (s *Store) ListBillViews(ctx context.Context, userID string) (rows []*models.BillView, err error) {
+	defer observeRows("ListBillViews", time.Now(), &err, func() int { return len(rows) })
+	return s.next.ListBillViews(ctx, userID)
+}
+
+func (s *Store) UpdateBillView(ctx context.Context, view *models.BillView) (err error) {
+	defer observe("UpdateBillView", time.Now(), &err)
+	return s.next.UpdateBillView(ctx, view)
+}
+
+func (s *Store) DeleteBillView(ctx context.Context, viewID string) (err error) {
+	defer observe("DeleteBillView", time.Now(), &err)
+	return s.next.DeleteBillView(ctx, viewID)
+}
+
+func (s *Store) CreateAttachment(ctx context.Context, attachment *models.Attachment) (err error) {
+	defer observe("CreateAttachment", time.Now(), &err)
+	return s.next.CreateAttachment(ctx, attachment)
+}
+
+func (s *Store) GetAttachment(ctx context.Context, attachmentID string) (_ *models.Attachment, err error) {
+	defer observe("GetAttachment", time.Now(), &err)
+	return s.next.GetAttachment(ctx, attachmentID)
+}
+
+func (s *Store) ListAttachmentsByBill(ctx context.Context, billID string) (rows []*models.Attachment, err error) {
+	defer observeRows("ListAttachmentsByBill", time.Now(), &err, func() int { return len(rows) })
+	return s.next.ListAttachmentsByBill(ctx, billID)
+}
+
+func (s *Store) ListAttachmentBlobKeys(ctx context.Context) (rows []string, err error) {
+	defer observeRows("ListAttachmentBlobKeys", time.Now(), &err, func() int { return len(rows) })
+	return s.next.ListAttachmentBlobKeys(ctx)
+}
+
+func (s *Store) CreateComment(ctx context.Context, comment *models.Comment) (err error) {
+	defer observe("CreateComment", time.Now(), &err)
+	return s.next.CreateComment(ctx, comment)
+}
+
+func (s *Store) GetComment(ctx context.Context, commentID string) (_ *models.Comment, err error) {
+	defer observe("GetComment", time.Now(), &err)
+	return s.next.GetComment(ctx, commentID)
+}
+
+func (s *Store) ListCommentsByBill(ctx context.Context, billID string) (rows []*models.Comment, err error) {
+	defer observeRows("ListCommentsByBill", time.Now(), &err, func() int { return len(rows) })
+	return s.next.ListCommentsByBill(ctx, billID)
+}
+
+func (s *Store) DeleteComment(ctx context.Context, commentID string) (err error) {
+	defer observe("DeleteComment", time.Now(), &err)
+	return s.next.DeleteComment(ctx, commentID)
+}
+
+func (s *Store) SetGroupStatsToken(ctx context.Context, groupID, tokenHash, createdBy string) (err error) {
+	defer observe("SetGroupStatsToken", time.Now(), &err)
+	return s.next.SetGroupStatsToken(ctx, groupID, tokenHash, createdBy)
+}
+
+func (s *Store) DeleteGroupStatsToken(ctx context.Context, groupID string) (err error) {
+	defer observe("DeleteGroupStatsToken", time.Now(), &err)
+	return s.next.DeleteGroupStatsToken(ctx, groupID)
+}
+
+func (s *Store) GetGroupIDByStatsToken(ctx context.Context, tokenHash string) (_ string, err error) {
+	defer observe("GetGroupIDByStatsToken", time.Now(), &err)
+	return s.next.GetGroupIDByStatsToken(ctx, tokenHash)
+}
+
+func (s *Store) MarkShareAsPaid(ctx context.Context, payment *models.SharePayment) (err error) {
+	defer observe("MarkShareAsPaid", time.Now(), &err)
+	return s.next.MarkShareAsPaid(ctx, payment)
+}
+
+func (s *Store) UnmarkShareAsPaid(ctx context.Context, billID, participant string) (err error) {
+	defer observe("UnmarkShareAsPaid", time.Now(), &err)
+	return s.next.UnmarkShareAsPaid(ctx, billID, participant)
+}
+
+func (s *Store) SetBillLocked(ctx context.Context, billID string, lockedAt int64) (err error) {
+	defer observe("SetBillLocked", time.Now(), &err)
+	return s.next.SetBillLocked(ctx, billID, lockedAt)
+}
+
+func (s *Store) ListSharePayments(ctx context.Context, billIDs []string) (rows []models.SharePayment, err error) {
+	defer observeRows("ListSharePayments", time.Now(), &err, func() int { return len(rows) })
+	return s.next.ListSharePayments(ctx, billIDs)
+}
+
+func (s *Store) SetBillShareToken(ctx context.Context, billID, tokenHash, createdBy string) (err error) {
+	defer observe("SetBillShareToken", time.Now(), &err)
+	return s.next.SetBillShareToken(ctx, billID, tokenHash, createdBy)
+}
+
+func (s *Store) DeleteBillShareToken(ctx context.Context, billID string) (err error) {
+	defer observe("DeleteBillShareToken", time.Now(), &err)
+	return s.next.DeleteBillShareToken(ctx, billID)
+}
+
+func (s *Store) GetBillIDByShareToken(ctx context.Context, tokenHash string) (_ string, err error) {
+	defer observe("GetBillIDByShareToken", time.Now(), &err)
+	return s.next.GetBillIDByShareToken(ctx, tokenHash)
+}
+
+func (s *Store) ListGroupMonthlySpend(ctx context.Context, groupID string) (rows []models.MonthlySpend, err error) {
+	defer observeRows("ListGroupMonthlySpend", time.Now(), &err, func() int { return len(rows) })
+	return s.next.ListGroupMonthlySpend(ctx, groupID)
+}
+
+func (s *Store) CreateSpendingCapAlerts(ctx context.Context, alerts []models.SpendingCapAlert) (err error) {
+	defer observe("CreateSpendingCapAlerts", time.Now(), &err)
+	return s.next.CreateSpendingCapAlerts(ctx, alerts)
+}
+
+func (s *Store) ListSpendingCapAlerts(ctx context.Context, groupID, month string) (rows []models.SpendingCapAlert, err error) {
+	defer observeRows("ListSpendingCapAlerts", time.Now(), &err, func() int { return len(rows) })
+	return s.next.ListSpendingCapAlerts(ctx, groupID, month)
+}
+
+func (s *Store) ListBillSpendingCapAlerts(ctx context.Context, billID string) (rows []models.SpendingCapAlert, err error) {
+	defer observeRows("ListBillSpendingCapAlerts", time.Now(), &err, func() int { return len(rows) })
+	return s.next.ListBillSpendingCapAlerts(ctx, billID)
+}
+
+func (s *Store) CreateTransactionBill(ctx context.Context, bill *models.Bill, transactionID string) (_ bool, err error) {
+	defer observe("CreateTransactionBill", time.Now(), &err)
+	return s.next.CreateTransactionBill(ctx, bill, transactionID)
+}
+
+func (s *Store) ImportGroupArchive(ctx context.Context, archive *models.GroupArchive) (err error) {
+	defer observe("ImportGroupArchive", time.Now(), &err)
+	return s.next.ImportGroupArchive(ctx, archive)
+}
+
+func (s *Store) GetUserByEmail(ctx context.Context, email string) (_ *models.User, err error) {
+	defer observe("GetUserByEmail", time.Now(), &err)
+	return s.next.GetUserByEmail(ctx, email)
+}
+
+func (s *Store) GetUsersByIDs(ctx context.Context, ids []string) (users map[string]*models.User, err error) {
+	defer observeRows("GetUsersByIDs", time.Now(), &err, func() int { return len(users) })
+	return s.next.GetUsersByIDs(ctx, ids)
+}
+
+func (s *Store) SearchUsers(ctx context.Context, email string, callerID string) (_ *models.User, err error) {
+	defer observe("SearchUsers", time.Now(), &err)
+	return s.next.SearchUsers(ctx, email, callerID)
+}
+
+func (s *Store) AddGroupMembersWithIDs(ctx context.Context, groupID string, members []models.GroupMember) (err error) {
+	defer observe("AddGroupMembersWithIDs", time.Now(), &err)
+	return s.next.AddGroupMembersWithIDs(ctx, groupID, members)
+}
+
+func (s *Store) SendFriendRequest(ctx context.Context, friendship *models.Friendship) (err error) {
+	defer observe("SendFriendRequest", time.Now(), &err)
+	return s.next.SendFriendRequest(ctx, friendship)
+}
+
+func (s *Store) GetFriendship(ctx context.Context, id string) (_ *models.Friendship, err error) {
+	defer observe("GetFriendship", time.Now(), &err)
+	return s.next.GetFriendship(ctx, id)
+}
+
+func (s *Store) UpdateFriendshipStatus(ctx context.Context, id string, status models.FriendshipStatus) (err error) {
+	defer observe("UpdateFriendshipStatus", time.Now(), &err)
+	return s.next.UpdateFriendshipStatus(ctx, id, status)
+}
+
+func (s *Store) ListFriendships(ctx context.Context, userID string, incoming bool, status models.FriendshipStatus) (rows []*models.Friendship, err error) {
+	defer observeRows("ListFriendships", time.Now(), &err, func() int { return len(rows) })
+	return s.next.ListFriendships(ctx, userID, incoming, status)
+}
+
+func (s *Store) DeleteFriendship(ctx context.Context, id string) (err error) {
+	defer observe("DeleteFriendship", time.Now(), &err)
+	return s.next.DeleteFriendship(ctx, id)
+}
+
+func (s *Store) AreFriends(ctx context.Context, userIDA, userIDB string) (_ bool, err error) {
+	defer observe("AreFriends", time.Now(), &err)
+	return s.next.AreFriends(ctx, userIDA, userIDB)
+}
+
+func (s *Store) GetFriends(ctx context.Context, userID string) (rows []*models.User, err error) {
+	defer observeRows("GetFriends", time.Now(), &err, func() int { return len(rows) })
+	return s.next.GetFriends(ctx, userID)
+}
+
+func (s *Store) GetFriendshipBetween(ctx context.Context, userIDA, userIDB string) (_ *models.Friendship, err error) {
+	defer observe("GetFriendshipBetween", time.Now(), &err)
+	return s.next.GetFriendshipBetween(ctx, userIDA, userIDB)
+}
+
+func (s *Store) SearchFriends(ctx context.Context, callerID string, query string) (rows []*models.User, err error) {
+	defer observeRows("SearchFriends", time.Now(), &err, func() int { return len(rows) })
+	return s.next.SearchFriends(ctx, callerID, query)
+}
+
+func (s *Store) CreateAuditEntry(ctx context.Context, entry *models.AuditEntry) (err error) {
+	defer observe("CreateAuditEntry", time.Now(), &err)
+	return s.next.CreateAuditEntry(ctx, entry)
+}
+
+func (s *Store) ListAuditEntries(ctx context.Context, filter storage.AuditFilter) (rows []*models.AuditEntry, err error) {
+	defer observeRows("ListAuditEntries", time.Now(), &err, func() int { return len(rows) })
+	return s.next.ListAuditEntries(ctx, filter)
+}
+
+func (s *Store) GetUsage(ctx context.Context, userID string, since int64) (_ *models.Usage, err error) {
+	defer observe("GetUsage", time.Now(), &err)
+	return s.next.GetUsage(ctx, userID, since)
+}
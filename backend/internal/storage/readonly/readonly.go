@@ -0,0 +1,91 @@
+// Package readonly wraps a storage.Store so that every write made through it
+// is refused with storage.ErrReadOnly while reads go through, for taking
+// backups or running migrations against a live instance:
+//
+//	store := readonly.New(sqliteStore)
+//
+// The account storage the auth package writes to directly, for sign-ups,
+// invitations and linked emails, is wrapped the same way by NewAccounts.
+package readonly
+
+import (
+	"context"
+
+	"github.com/mmynk/splitwiser/internal/models"
+	"github.com/mmynk/splitwiser/internal/storage"
+)
+
+// Store is a storage.Store that refuses its mutations. Reads go straight to
+// the wrapped store.
+type Store struct {
+	storage.Store
+}
+
+var _ storage.Store = (*Store)(nil)
+
+// New wraps next so its mutations are refused.
+func New(next storage.Store) *Store {
+	return &Store{Store: next}
+}
+
+// rejected returns the error a refused call of method fails with.
+func rejected(method string) error {
+	return storage.Errorf(storage.ErrReadOnly, "storage is read-only: %s refused", method)
+}
+
+// AccountStore is the account storage behind the auth package's
+// authenticator, email manager and inviter.
+type AccountStore interface {
+	CreateUser(ctx context.Context, user *models.User) error
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	GetUserByID(ctx context.Context, id string) (*models.User, error)
+	SetUserPassword(ctx context.Context, userID, passwordHash string) error
+	AddUserEmail(ctx context.Context, e *models.UserEmail) error
+	GetUserEmail(ctx context.Context, userID, email string) (*models.UserEmail, error)
+	ListUserEmails(ctx context.Context, userID string) ([]*models.UserEmail, error)
+	MarkUserEmailVerified(ctx context.Context, userID, email string, verifiedAt int64) error
+	DeleteUserEmail(ctx context.Context, userID, email string) error
+	CreateInvitation(ctx context.Context, inv *models.Invitation) error
+	GetInvitation(ctx context.Context, tokenHash string) (*models.Invitation, error)
+	DeleteInvitationsByUser(ctx context.Context, userID string) error
+}
+
+// Accounts is an AccountStore that refuses its mutations.
+type Accounts struct {
+	AccountStore
+}
+
+var _ AccountStore = (*Accounts)(nil)
+
+// NewAccounts wraps next so its mutations are refused.
+func NewAccounts(next AccountStore) *Accounts {
+	return &Accounts{AccountStore: next}
+}
+
+func (a *Accounts) CreateUser(ctx context.Context, user *models.User) error {
+	return rejected("CreateUser")
+}
+
+func (a *Accounts) SetUserPassword(ctx context.Context, userID, passwordHash string) error {
+	return rejected("SetUserPassword")
+}
+
+func (a *Accounts) AddUserEmail(ctx context.Context, e *models.UserEmail) error {
+	return rejected("AddUserEmail")
+}
+
+func (a *Accounts) MarkUserEmailVerified(ctx context.Context, userID, email string, verifiedAt int64) error {
+	return rejected("MarkUserEmailVerified")
+}
+
+func (a *Accounts) DeleteUserEmail(ctx context.Context, userID, email string) error {
+	return rejected("DeleteUserEmail")
+}
+
+func (a *Accounts) CreateInvitation(ctx context.Context, inv *models.Invitation) error {
+	return rejected("CreateInvitation")
+}
+
+func (a *Accounts) DeleteInvitationsByUser(ctx context.Context, userID string) error {
+	return rejected("DeleteInvitationsByUser")
+}
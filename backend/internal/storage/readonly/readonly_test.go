@@ -0,0 +1,57 @@
+package readonly
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/mmynk/splitwiser/internal/models"
+	"github.com/mmynk/splitwiser/internal/storage"
+	"github.com/mmynk/splitwiser/internal/storage/sqlite"
+)
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqlite.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	defer db.Close()
+
+	group := &models.Group{Name: "Trip", Members: []models.GroupMember{{DisplayName: "Alice"}}}
+	if err := db.CreateGroup(ctx, group); err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	alice := models.NewUser("alice@example.com", "Alice", "")
+	if err := db.CreateUser(ctx, alice); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+
+	store := New(db)
+	got, err := store.GetGroup(ctx, group.ID)
+	if err != nil || got.Name != "Trip" {
+		t.Fatalf("GetGroup = %v, %v; want the group", got, err)
+	}
+	group.Name = "Holiday"
+	if err := store.UpdateGroup(ctx, group); !errors.Is(err, storage.ErrReadOnly) {
+		t.Errorf("UpdateGroup: expected ErrReadOnly, got %v", err)
+	}
+	if _, err := store.CreateBillInGroup(ctx, &models.Bill{Title: "Dinner", GroupID: group.ID}, nil); !errors.Is(err, storage.ErrReadOnly) {
+		t.Errorf("CreateBillInGroup: expected ErrReadOnly, got %v", err)
+	}
+	if got, _ := db.GetGroup(ctx, group.ID); got.Name != "Trip" {
+		t.Errorf("expected the group to be unchanged, got name %q", got.Name)
+	}
+
+	accounts := NewAccounts(db)
+	if user, err := accounts.GetUserByEmail(ctx, "alice@example.com"); err != nil || user == nil || user.ID != alice.ID {
+		t.Fatalf("GetUserByEmail = %v, %v; want Alice", user, err)
+	}
+	if err := accounts.CreateUser(ctx, models.NewUser("bob@example.com", "Bob", "")); !errors.Is(err, storage.ErrReadOnly) {
+		t.Errorf("CreateUser: expected ErrReadOnly, got %v", err)
+	}
+	if user, _ := db.GetUserByEmail(ctx, "bob@example.com"); user != nil {
+		t.Error("expected Bob not to be created")
+	}
+}
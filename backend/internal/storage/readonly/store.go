@@ -0,0 +1,178 @@
+package readonly
+
+import (
+	"context"
+
+	"github.com/mmynk/splitwiser/internal/models"
+)
+
+// Every mutating storage.Store method fails without reaching the wrapped
+// store.
+
+func (s *Store) CreateBill(ctx context.Context, bill *models.Bill) error {
+	return rejected("CreateBill")
+}
+
+func (s *Store) CreateBillInGroup(ctx context.Context, bill *models.Bill, members []models.GroupMember) (int, error) {
+	return 0, rejected("CreateBillInGroup")
+}
+
+func (s *Store) CreateBillsInGroup(ctx context.Context, groupID string, bills []*models.Bill, members []models.GroupMember) (int, error) {
+	return 0, rejected("CreateBillsInGroup")
+}
+
+func (s *Store) UpdateBill(ctx context.Context, bill *models.Bill) error {
+	return rejected("UpdateBill")
+}
+
+func (s *Store) UpdateBillInGroup(ctx context.Context, bill *models.Bill, members []models.GroupMember) (int, error) {
+	return 0, rejected("UpdateBillInGroup")
+}
+
+func (s *Store) DeleteBill(ctx context.Context, billID string) error {
+	return rejected("DeleteBill")
+}
+
+func (s *Store) RestoreBill(ctx context.Context, billID string) error {
+	return rejected("RestoreBill")
+}
+
+func (s *Store) PurgeBill(ctx context.Context, billID string) error {
+	return rejected("PurgeBill")
+}
+
+func (s *Store) CreateGroup(ctx context.Context, group *models.Group) error {
+	return rejected("CreateGroup")
+}
+
+func (s *Store) UpdateGroup(ctx context.Context, group *models.Group) error {
+	return rejected("UpdateGroup")
+}
+
+func (s *Store) AddGroupMembers(ctx context.Context, groupID string, memberIDs []string) error {
+	return rejected("AddGroupMembers")
+}
+
+func (s *Store) DeleteGroup(ctx context.Context, groupID string) error {
+	return rejected("DeleteGroup")
+}
+
+func (s *Store) CreateSettlement(ctx context.Context, settlement *models.Settlement) error {
+	return rejected("CreateSettlement")
+}
+
+func (s *Store) DeleteSettlement(ctx context.Context, settlementID string) error {
+	return rejected("DeleteSettlement")
+}
+
+func (s *Store) CreateSettlementPlan(ctx context.Context, plan *models.SettlementPlan) error {
+	return rejected("CreateSettlementPlan")
+}
+
+func (s *Store) DeleteSettlementPlan(ctx context.Context, planID string) error {
+	return rejected("DeleteSettlementPlan")
+}
+
+func (s *Store) CreateGroupWebhook(ctx context.Context, webhook *models.GroupWebhook) error {
+	return rejected("CreateGroupWebhook")
+}
+
+func (s *Store) SetGroupWebhookOverThreshold(ctx context.Context, webhookID string, members []string) error {
+	return rejected("SetGroupWebhookOverThreshold")
+}
+
+func (s *Store) DeleteGroupWebhook(ctx context.Context, webhookID string) error {
+	return rejected("DeleteGroupWebhook")
+}
+
+func (s *Store) CreateAccountWebhook(ctx context.Context, webhook *models.AccountWebhook) error {
+	return rejected("CreateAccountWebhook")
+}
+
+func (s *Store) DeleteAccountWebhook(ctx context.Context, webhookID string) error {
+	return rejected("DeleteAccountWebhook")
+}
+
+func (s *Store) CreateBillView(ctx context.Context, view *models.BillView) error {
+	return rejected("CreateBillView")
+}
+
+func (s *Store) UpdateBillView(ctx context.Context, view *models.BillView) error {
+	return rejected("UpdateBillView")
+}
+
+func (s *Store) DeleteBillView(ctx context.Context, viewID string) error {
+	return rejected("DeleteBillView")
+}
+
+func (s *Store) CreateAttachment(ctx context.Context, attachment *models.Attachment) error {
+	return rejected("CreateAttachment")
+}
+
+func (s *Store) CreateComment(ctx context.Context, comment *models.Comment) error {
+	return rejected("CreateComment")
+}
+
+func (s *Store) DeleteComment(ctx context.Context, commentID string) error {
+	return rejected("DeleteComment")
+}
+
+func (s *Store) SetGroupStatsToken(ctx context.Context, groupID, tokenHash, createdBy string) error {
+	return rejected("SetGroupStatsToken")
+}
+
+func (s *Store) DeleteGroupStatsToken(ctx context.Context, groupID string) error {
+	return rejected("DeleteGroupStatsToken")
+}
+
+func (s *Store) MarkShareAsPaid(ctx context.Context, payment *models.SharePayment) error {
+	return rejected("MarkShareAsPaid")
+}
+
+func (s *Store) UnmarkShareAsPaid(ctx context.Context, billID, participant string) error {
+	return rejected("UnmarkShareAsPaid")
+}
+
+func (s *Store) SetBillLocked(ctx context.Context, billID string, lockedAt int64) error {
+	return rejected("SetBillLocked")
+}
+
+func (s *Store) SetBillShareToken(ctx context.Context, billID, tokenHash, createdBy string) error {
+	return rejected("SetBillShareToken")
+}
+
+func (s *Store) DeleteBillShareToken(ctx context.Context, billID string) error {
+	return rejected("DeleteBillShareToken")
+}
+
+func (s *Store) CreateSpendingCapAlerts(ctx context.Context, alerts []models.SpendingCapAlert) error {
+	return rejected("CreateSpendingCapAlerts")
+}
+
+func (s *Store) CreateTransactionBill(ctx context.Context, bill *models.Bill, transactionID string) (bool, error) {
+	return false, rejected("CreateTransactionBill")
+}
+
+func (s *Store) ImportGroupArchive(ctx context.Context, archive *models.GroupArchive) error {
+	return rejected("ImportGroupArchive")
+}
+
+func (s *Store) AddGroupMembersWithIDs(ctx context.Context, groupID string, members []models.GroupMember) error {
+	return rejected("AddGroupMembersWithIDs")
+}
+
+func (s *Store) SendFriendRequest(ctx context.Context, friendship *models.Friendship) error {
+	return rejected("SendFriendRequest")
+}
+
+func (s *Store) UpdateFriendshipStatus(ctx context.Context, id string, status models.FriendshipStatus) error {
+	return rejected("UpdateFriendshipStatus")
+}
+
+func (s *Store) DeleteFriendship(ctx context.Context, id string) error {
+	return rejected("DeleteFriendship")
+}
+
+func (s *Store) CreateAuditEntry(ctx context.Context, entry *models.AuditEntry) error {
+	return rejected("CreateAuditEntry")
+}
@@ -0,0 +1,194 @@
+package sqlite
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Pseudonym kinds. Values of one kind map to the same pseudonym wherever
+// they appear, so a participant still matches their group membership,
+// settlements and item assignments after anonymizing.
+const (
+	pseudoPerson      = "Person"
+	pseudoEmail       = "email"
+	pseudoURL         = "url"
+	pseudoSecret      = "secret"
+	pseudoGroup       = "Group"
+	pseudoBill        = "Bill"
+	pseudoItem        = "Item"
+	pseudoFee         = "Fee"
+	pseudoNote        = "Note"
+	pseudoMerchant    = "Merchant"
+	pseudoPlace       = "Place"
+	pseudoTemplate    = "Template"
+	pseudoFile        = "File"
+	pseudoTransaction = "txn"
+)
+
+// anonymizedColumns lists, per table, the columns holding names, contact
+// details or free text, and the kind of pseudonym that replaces each.
+var anonymizedColumns = []struct {
+	table   string
+	columns map[string]string
+}{
+	{"users", map[string]string{"email": pseudoEmail, "display_name": pseudoPerson}},
+	{"user_emails", map[string]string{"email": pseudoEmail}},
+	{"groups", map[string]string{"name": pseudoGroup, "title_template": pseudoTemplate}},
+	{"group_members", map[string]string{"name": pseudoPerson}},
+	{"bills", map[string]string{"title": pseudoBill, "payer_id": pseudoPerson, "note": pseudoNote, "merchant_name": pseudoMerchant, "location": pseudoPlace}},
+	{"items", map[string]string{"description": pseudoItem, "origin_description": pseudoItem, "owner": pseudoPerson}},
+	{"fees", map[string]string{"description": pseudoFee}},
+	{"item_assignments", map[string]string{"participant": pseudoPerson}},
+	{"participants", map[string]string{"name": pseudoPerson}},
+	{"bill_shares", map[string]string{"participant": pseudoPerson}},
+	{"settlements", map[string]string{"from_user_id": pseudoPerson, "to_user_id": pseudoPerson, "note": pseudoNote}},
+	{"settlement_plans", map[string]string{"from_user_id": pseudoPerson, "to_user_id": pseudoPerson, "note": pseudoNote}},
+	{"group_webhooks", map[string]string{"url": pseudoURL}},
+	{"group_webhook_breaches", map[string]string{"member": pseudoPerson}},
+	{"account_webhooks", map[string]string{"url": pseudoURL, "secret": pseudoSecret}},
+	{"attachments", map[string]string{"name": pseudoFile}},
+	{"comments", map[string]string{"body": pseudoNote}},
+	{"group_spending_caps", map[string]string{"member_name": pseudoPerson}},
+	{"spending_cap_alerts", map[string]string{"member_name": pseudoPerson}},
+	{"imported_transactions", map[string]string{"transaction_id": pseudoTransaction}},
+}
+
+// pseudonyms derives deterministic pseudonyms keyed by a secret, so the same
+// key always gives the same output but the originals can't be guessed
+// without it.
+type pseudonyms struct {
+	key []byte
+}
+
+func (p pseudonyms) of(kind, value string) string {
+	mac := hmac.New(sha256.New, p.key)
+	mac.Write([]byte(kind))
+	mac.Write([]byte{0})
+	mac.Write([]byte(value))
+	h := hex.EncodeToString(mac.Sum(nil))
+	switch kind {
+	case pseudoEmail:
+		return "user-" + h[:12] + "@example.invalid"
+	case pseudoURL:
+		return "https://example.invalid/" + h[:12]
+	case pseudoSecret:
+		return h
+	case pseudoTransaction:
+		return kind + "-" + h[:16]
+	default:
+		return kind + " " + h[:10]
+	}
+}
+
+// Anonymize replaces every name, email address, title, description and note
+// in the database with a pseudonym derived from key, and drops password
+// hashes, pending verification tokens, audit log snapshots and earlier
+// versions of bills. IDs, amounts, shares and timestamps are kept, so
+// balances come out the same. Empty and NULL values stay as they are.
+//
+// It is meant for a copy of a database to be shared in a bug report; the
+// freed pages that held the original text are vacuumed away before it returns.
+func (s *SQLiteStore) Anonymize(ctx context.Context, key []byte) error {
+	p := pseudonyms{key: key}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, t := range anonymizedColumns {
+		if err := anonymizeTable(ctx, tx, p, t.table, t.columns); err != nil {
+			return err
+		}
+	}
+
+	for _, stmt := range []string{
+		"UPDATE users SET password_hash = '', email_lookup = email, email_lookup_version = 0, duplicate_of = ''",
+		"UPDATE user_emails SET token_hash = NULL, token_expires_at = NULL",
+		"DELETE FROM bill_revisions",
+		`UPDATE audit_log SET
+			before_json = CASE WHEN before_json = '' THEN '' ELSE '{}' END,
+			after_json = CASE WHEN after_json = '' THEN '' ELSE '{}' END`,
+		// Rebuild the search index from the pseudonyms, then merge its
+		// segments so no deleted terms linger in them.
+		"DELETE FROM bills_fts",
+		"INSERT INTO bills_fts (bill_id, title, items, participants)" + billSearchDocs,
+		"INSERT INTO bills_fts (bills_fts) VALUES ('optimize')",
+	} {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to anonymize: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit anonymized data: %w", err)
+	}
+	if _, err := s.db.ExecContext(ctx, "VACUUM"); err != nil {
+		return fmt.Errorf("failed to vacuum: %w", err)
+	}
+	return nil
+}
+
+// anonymizeTable rewrites the given columns of every row in table, mapping
+// column name to pseudonym kind.
+func anonymizeTable(ctx context.Context, tx *sql.Tx, p pseudonyms, table string, columns map[string]string) error {
+	names := make([]string, 0, len(columns))
+	for name := range columns {
+		names = append(names, name)
+	}
+	rows, err := tx.QueryContext(ctx, "SELECT rowid, "+strings.Join(names, ", ")+" FROM "+table)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", table, err)
+	}
+	type row struct {
+		rowid  int64
+		values []sql.NullString
+	}
+	var all []row
+	for rows.Next() {
+		r := row{values: make([]sql.NullString, len(names))}
+		dest := []any{&r.rowid}
+		for i := range r.values {
+			dest = append(dest, &r.values[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan %s: %w", table, err)
+		}
+		all = append(all, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read %s: %w", table, err)
+	}
+
+	sets := make([]string, len(names))
+	for i, name := range names {
+		sets[i] = name + " = ?"
+	}
+	stmt, err := tx.PrepareContext(ctx, "UPDATE "+table+" SET "+strings.Join(sets, ", ")+" WHERE rowid = ?")
+	if err != nil {
+		return fmt.Errorf("failed to prepare %s update: %w", table, err)
+	}
+	defer stmt.Close()
+
+	for _, r := range all {
+		args := make([]any, 0, len(names)+1)
+		for i, v := range r.values {
+			if v.Valid && v.String != "" {
+				v.String = p.of(columns[names[i]], v.String)
+			}
+			args = append(args, v)
+		}
+		args = append(args, r.rowid)
+		if _, err := stmt.ExecContext(ctx, args...); err != nil {
+			return fmt.Errorf("failed to anonymize %s: %w", table, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mmynk/splitwiser/internal/models"
+)
+
+// ImportGroupArchive stores an archived group with all of its bills,
+// settlements and settlement plans in one transaction. Everything gets a fresh
+// ID, overwriting the archive-local IDs on the inputs, and settlements' plan
+// IDs are rewritten to match; a plan ID not in the archive is dropped.
+func (s *SQLiteStore) ImportGroupArchive(ctx context.Context, archive *models.GroupArchive) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	group := archive.Group
+	group.ID = uuid.New().String()
+	if group.CreatedAt == 0 {
+		group.CreatedAt = time.Now().Unix()
+	}
+	if err := insertGroup(ctx, tx, group); err != nil {
+		return err
+	}
+
+	planIDs := make(map[string]string, len(archive.SettlementPlans))
+	for _, plan := range archive.SettlementPlans {
+		newID := uuid.New().String()
+		planIDs[plan.ID] = newID
+		plan.ID = newID
+		plan.GroupID = group.ID
+		if err := s.insertSettlementPlan(ctx, tx, plan); err != nil {
+			return err
+		}
+	}
+
+	for _, bill := range archive.Bills {
+		bill.ID = uuid.New().String()
+		bill.GroupID = group.ID
+		for i := range bill.Items {
+			bill.Items[i].ID = ""
+		}
+		for i := range bill.Fees {
+			bill.Fees[i].ID = ""
+		}
+		if err := insertBill(ctx, tx, bill); err != nil {
+			return err
+		}
+	}
+
+	for _, settlement := range archive.Settlements {
+		settlement.ID = uuid.New().String()
+		settlement.GroupID = &group.ID
+		settlement.PlanID = planIDs[settlement.PlanID]
+		if err := s.insertSettlement(ctx, tx, settlement); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
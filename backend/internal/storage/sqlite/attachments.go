@@ -0,0 +1,99 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mmynk/splitwiser/internal/models"
+	"github.com/mmynk/splitwiser/internal/storage"
+)
+
+const attachmentColumns = "id, bill_id, uploader_id, name, content_type, size, sha256, blob_key, created_at"
+
+// scanAttachment scans a row selected with attachmentColumns into an attachment.
+func scanAttachment(row rowScanner) (*models.Attachment, error) {
+	a := &models.Attachment{}
+	if err := row.Scan(&a.ID, &a.BillID, &a.UploaderID, &a.Name, &a.ContentType,
+		&a.Size, &a.SHA256, &a.BlobKey, &a.CreatedAt); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// CreateAttachment persists a new attachment's metadata.
+func (s *SQLiteStore) CreateAttachment(ctx context.Context, attachment *models.Attachment) error {
+	if attachment.ID == "" {
+		attachment.ID = uuid.New().String()
+	}
+	if attachment.CreatedAt == 0 {
+		attachment.CreatedAt = time.Now().Unix()
+	}
+
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO attachments (`+attachmentColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		attachment.ID, attachment.BillID, attachment.UploaderID, attachment.Name, attachment.ContentType,
+		attachment.Size, attachment.SHA256, attachment.BlobKey, attachment.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert attachment: %w", err)
+	}
+	return nil
+}
+
+// GetAttachment retrieves an attachment by ID.
+func (s *SQLiteStore) GetAttachment(ctx context.Context, attachmentID string) (*models.Attachment, error) {
+	attachment, err := scanAttachment(s.db.QueryRowContext(ctx,
+		"SELECT "+attachmentColumns+" FROM attachments WHERE id = ?", attachmentID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, storage.Errorf(storage.ErrNotFound, "attachment not found: %s", attachmentID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attachment: %w", err)
+	}
+	return attachment, nil
+}
+
+// ListAttachmentsByBill retrieves a bill's attachments, oldest first.
+func (s *SQLiteStore) ListAttachmentsByBill(ctx context.Context, billID string) ([]*models.Attachment, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+attachmentColumns+" FROM attachments WHERE bill_id = ? ORDER BY created_at, id", billID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list attachments: %w", err)
+	}
+	defer rows.Close()
+
+	var attachments []*models.Attachment
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan attachment: %w", err)
+		}
+		attachments = append(attachments, attachment)
+	}
+	return attachments, rows.Err()
+}
+
+// ListAttachmentBlobKeys returns the blob key of every attachment, whether
+// or not its bill is in the trash.
+func (s *SQLiteStore) ListAttachmentBlobKeys(ctx context.Context) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT blob_key FROM attachments")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list attachment blob keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, fmt.Errorf("failed to scan attachment blob key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
@@ -0,0 +1,93 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mmynk/splitwiser/internal/models"
+	"github.com/mmynk/splitwiser/internal/storage"
+)
+
+const auditColumns = "id, actor_id, action, entity_type, entity_id, group_id, before_json, after_json, created_at"
+
+// CreateAuditEntry appends an entry to the audit log. Its snapshots are
+// encrypted if the store encrypts, as they may hold settlement notes and
+// comments.
+func (s *SQLiteStore) CreateAuditEntry(ctx context.Context, entry *models.AuditEntry) error {
+	return s.insertAuditEntry(ctx, s.db, entry)
+}
+
+// insertAuditEntry is CreateAuditEntry within a transaction or out of one.
+func (s *SQLiteStore) insertAuditEntry(ctx context.Context, ex execer, entry *models.AuditEntry) error {
+	if entry.ID == "" {
+		entry.ID = uuid.New().String()
+	}
+	if entry.CreatedAt == 0 {
+		entry.CreatedAt = time.Now().Unix()
+	}
+	before, err := s.cipher.encrypt(columnAuditBefore, entry.Before)
+	if err != nil {
+		return err
+	}
+	after, err := s.cipher.encrypt(columnAuditAfter, entry.After)
+	if err != nil {
+		return err
+	}
+
+	_, err = ex.ExecContext(ctx,
+		`INSERT INTO audit_log (`+auditColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		entry.ID, entry.ActorID, string(entry.Action), entry.EntityType, entry.EntityID, entry.GroupID,
+		before, after, entry.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert audit entry: %w", err)
+	}
+	return nil
+}
+
+// ListAuditEntries retrieves the audit entries matching filter, newest first.
+// Entries made in the same second keep the order they were made in.
+func (s *SQLiteStore) ListAuditEntries(ctx context.Context, filter storage.AuditFilter) ([]*models.AuditEntry, error) {
+	query := "SELECT " + auditColumns + " FROM audit_log WHERE 1 = 1"
+	var args []any
+	if filter.GroupID != "" {
+		query += " AND group_id = ?"
+		args = append(args, filter.GroupID)
+	}
+	if filter.EntityID != "" {
+		query += " AND entity_id = ?"
+		args = append(args, filter.EntityID)
+	}
+	query += " ORDER BY created_at DESC, rowid DESC LIMIT ? OFFSET ?"
+	args = append(args, pageLimit(filter.Page), filter.Page.Offset)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*models.AuditEntry
+	for rows.Next() {
+		entry := &models.AuditEntry{}
+		var action string
+		if err := rows.Scan(&entry.ID, &entry.ActorID, &action, &entry.EntityType, &entry.EntityID, &entry.GroupID,
+			&entry.Before, &entry.After, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		entry.Action = models.AuditAction(action)
+		if entry.Before, err = s.cipher.decrypt(columnAuditBefore, entry.Before); err != nil {
+			return nil, err
+		}
+		if entry.After, err = s.cipher.decrypt(columnAuditAfter, entry.After); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate audit entries: %w", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,156 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+
+	sqlitedriver "modernc.org/sqlite"
+)
+
+// backupStepPages is how many pages a backup or restore copies per step.
+// Writers may run between steps; locks are only held during one.
+const backupStepPages = 1024
+
+// backupConn is the part of a modernc SQLite connection that runs the online
+// backup API.
+type backupConn interface {
+	NewBackup(dstURI string) (*sqlitedriver.Backup, error)
+	NewRestore(srcURI string) (*sqlitedriver.Backup, error)
+}
+
+// withBackupConn runs fn on the underlying SQLite connection of one pooled
+// connection.
+func (s *SQLiteStore) withBackupConn(ctx context.Context, fn func(backupConn) error) error {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+	return conn.Raw(func(dc any) error {
+		if hc, ok := dc.(*hookedConn); ok {
+			dc = hc.conn
+		}
+		bc, ok := dc.(backupConn)
+		if !ok {
+			return fmt.Errorf("driver connection %T doesn't support backups", dc)
+		}
+		return fn(bc)
+	})
+}
+
+// runBackup steps b to completion, stopping early if ctx is done.
+func runBackup(ctx context.Context, b *sqlitedriver.Backup) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			b.Finish()
+			return err
+		}
+		more, err := b.Step(backupStepPages)
+		if err != nil {
+			b.Finish()
+			return err
+		}
+		if !more {
+			return b.Finish()
+		}
+	}
+}
+
+// Backup writes a consistent snapshot of the database to w, as an SQLite
+// database file, while the store stays in use. The snapshot is staged in a
+// temporary file so a slow w doesn't hold database locks.
+func (s *SQLiteStore) Backup(ctx context.Context, w io.Writer) error {
+	f, err := os.CreateTemp("", "splitwiser-backup-*.db")
+	if err != nil {
+		return fmt.Errorf("failed to create backup file: %w", err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	err = s.withBackupConn(ctx, func(bc backupConn) error {
+		b, err := bc.NewBackup(f.Name())
+		if err != nil {
+			return err
+		}
+		return runBackup(ctx, b)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to back up database: %w", err)
+	}
+
+	if _, err := io.Copy(w, f); err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+	return nil
+}
+
+// Restore replaces the database's contents with the SQLite database read
+// from r, as written by Backup, then migrates it to the current schema. The
+// backup is checked for corruption and schema drift first; if it fails the
+// checks the database is left untouched. Requests running during a restore
+// may see either version.
+func (s *SQLiteStore) Restore(ctx context.Context, r io.Reader) error {
+	f, err := os.CreateTemp("", "splitwiser-restore-*.db")
+	if err != nil {
+		return fmt.Errorf("failed to create restore file: %w", err)
+	}
+	defer os.Remove(f.Name())
+	_, err = io.Copy(f, r)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read backup: %w", err)
+	}
+
+	if err := checkBackup(f.Name()); err != nil {
+		return err
+	}
+
+	err = s.withBackupConn(ctx, func(bc backupConn) error {
+		b, err := bc.NewRestore(f.Name())
+		if err != nil {
+			return err
+		}
+		return runBackup(ctx, b)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to restore database: %w", err)
+	}
+	if err := runMigrations(s.db); err != nil {
+		return fmt.Errorf("failed to migrate restored database: %w", err)
+	}
+	return nil
+}
+
+// checkBackup verifies that the file at path is an intact SQLite database
+// whose schema the migrations can bring up to date.
+func checkBackup(path string) error {
+	db, err := sql.Open("sqlite", "file:"+path+"?mode=ro")
+	if err != nil {
+		return fmt.Errorf("failed to open backup: %w", err)
+	}
+	defer db.Close()
+
+	var result string
+	if err := db.QueryRow("PRAGMA integrity_check(1)").Scan(&result); err != nil {
+		return fmt.Errorf("backup is not a valid database: %w", err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("backup is corrupt: %s", result)
+	}
+	var tables int
+	if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name IN ('users', 'bills')").Scan(&tables); err != nil {
+		return fmt.Errorf("failed to inspect backup: %w", err)
+	}
+	if tables != 2 {
+		return fmt.Errorf("backup is not a Splitwiser database")
+	}
+	if err := checkSchema(db, false); err != nil {
+		return fmt.Errorf("backup can't be restored: %w", err)
+	}
+	return nil
+}
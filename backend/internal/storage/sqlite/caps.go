@@ -0,0 +1,125 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/mmynk/splitwiser/internal/models"
+)
+
+// insertSpendingCaps stores a group's monthly spending caps. Zero caps are skipped.
+func insertSpendingCaps(ctx context.Context, tx *sql.Tx, groupID string, caps map[string]float64) error {
+	for member, amount := range caps {
+		if amount == 0 {
+			continue
+		}
+		_, err := tx.ExecContext(ctx,
+			"INSERT INTO group_spending_caps (group_id, member_name, monthly_cap) VALUES (?, ?, ?)",
+			groupID, member, amount,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert spending cap: %w", err)
+		}
+	}
+	return nil
+}
+
+// getSpendingCaps retrieves a group's monthly spending caps by member, or nil if it has none.
+func (s *SQLiteStore) getSpendingCaps(ctx context.Context, groupID string) (map[string]float64, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT member_name, monthly_cap FROM group_spending_caps WHERE group_id = ?", groupID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get spending caps: %w", err)
+	}
+	defer rows.Close()
+
+	var caps map[string]float64
+	for rows.Next() {
+		var member string
+		var amount float64
+		if err := rows.Scan(&member, &amount); err != nil {
+			return nil, fmt.Errorf("failed to scan spending cap: %w", err)
+		}
+		if caps == nil {
+			caps = make(map[string]float64)
+		}
+		caps[member] = amount
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate spending caps: %w", err)
+	}
+	return caps, nil
+}
+
+// CreateSpendingCapAlerts records bills that pushed members over their monthly caps.
+func (s *SQLiteStore) CreateSpendingCapAlerts(ctx context.Context, alerts []models.SpendingCapAlert) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().Unix()
+	for i := range alerts {
+		alert := &alerts[i]
+		if alert.CreatedAt == 0 {
+			alert.CreatedAt = now
+		}
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO spending_cap_alerts (bill_id, group_id, member_name, month, spent, cap, created_at)
+			 VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			alert.BillID, alert.GroupID, alert.Member, alert.Month, alert.Spent, alert.Cap, alert.CreatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert spending cap alert: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// spendingCapAlertColumns lists the spending_cap_alerts columns read by scanSpendingCapAlerts.
+const spendingCapAlertColumns = "bill_id, group_id, member_name, month, spent, cap, created_at"
+
+// ListSpendingCapAlerts retrieves a group's cap alerts for a month (YYYY-MM), oldest first.
+func (s *SQLiteStore) ListSpendingCapAlerts(ctx context.Context, groupID, month string) ([]models.SpendingCapAlert, error) {
+	return s.scanSpendingCapAlerts(ctx,
+		"SELECT "+spendingCapAlertColumns+" FROM spending_cap_alerts WHERE group_id = ? AND month = ? ORDER BY created_at, member_name",
+		groupID, month,
+	)
+}
+
+// ListBillSpendingCapAlerts retrieves the cap alerts raised by a bill.
+func (s *SQLiteStore) ListBillSpendingCapAlerts(ctx context.Context, billID string) ([]models.SpendingCapAlert, error) {
+	return s.scanSpendingCapAlerts(ctx,
+		"SELECT "+spendingCapAlertColumns+" FROM spending_cap_alerts WHERE bill_id = ? ORDER BY member_name",
+		billID,
+	)
+}
+
+func (s *SQLiteStore) scanSpendingCapAlerts(ctx context.Context, query string, args ...any) ([]models.SpendingCapAlert, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list spending cap alerts: %w", err)
+	}
+	defer rows.Close()
+
+	var alerts []models.SpendingCapAlert
+	for rows.Next() {
+		var a models.SpendingCapAlert
+		if err := rows.Scan(&a.BillID, &a.GroupID, &a.Member, &a.Month, &a.Spent, &a.Cap, &a.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan spending cap alert: %w", err)
+		}
+		alerts = append(alerts, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate spending cap alerts: %w", err)
+	}
+	return alerts, nil
+}
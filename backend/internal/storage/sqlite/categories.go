@@ -0,0 +1,30 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mmynk/splitwiser/internal/models"
+)
+
+// ListCategories retrieves every expense category, by name.
+func (s *SQLiteStore) ListCategories(ctx context.Context) ([]models.Category, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT id, name FROM categories ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list categories: %w", err)
+	}
+	defer rows.Close()
+
+	var categories []models.Category
+	for rows.Next() {
+		var c models.Category
+		if err := rows.Scan(&c.ID, &c.Name); err != nil {
+			return nil, fmt.Errorf("failed to scan category: %w", err)
+		}
+		categories = append(categories, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate categories: %w", err)
+	}
+	return categories, nil
+}
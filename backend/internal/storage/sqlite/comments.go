@@ -0,0 +1,99 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mmynk/splitwiser/internal/models"
+	"github.com/mmynk/splitwiser/internal/storage"
+)
+
+const commentColumns = "id, bill_id, author_id, body, created_at"
+
+// scanComment scans a row selected with commentColumns into a comment,
+// decrypting its body.
+func (s *SQLiteStore) scanComment(row rowScanner) (*models.Comment, error) {
+	c := &models.Comment{}
+	if err := row.Scan(&c.ID, &c.BillID, &c.AuthorID, &c.Body, &c.CreatedAt); err != nil {
+		return nil, err
+	}
+	body, err := s.cipher.decrypt(columnCommentBody, c.Body)
+	if err != nil {
+		return nil, err
+	}
+	c.Body = body
+	return c, nil
+}
+
+// CreateComment persists a new comment on a bill.
+func (s *SQLiteStore) CreateComment(ctx context.Context, comment *models.Comment) error {
+	if comment.ID == "" {
+		comment.ID = uuid.New().String()
+	}
+	if comment.CreatedAt == 0 {
+		comment.CreatedAt = time.Now().Unix()
+	}
+	body, err := s.cipher.encrypt(columnCommentBody, comment.Body)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx,
+		`INSERT INTO comments (`+commentColumns+`) VALUES (?, ?, ?, ?, ?)`,
+		comment.ID, comment.BillID, comment.AuthorID, body, comment.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert comment: %w", err)
+	}
+	return nil
+}
+
+// GetComment retrieves a comment by ID.
+func (s *SQLiteStore) GetComment(ctx context.Context, commentID string) (*models.Comment, error) {
+	comment, err := s.scanComment(s.db.QueryRowContext(ctx,
+		"SELECT "+commentColumns+" FROM comments WHERE id = ?", commentID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, storage.Errorf(storage.ErrNotFound, "comment not found: %s", commentID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get comment: %w", err)
+	}
+	return comment, nil
+}
+
+// ListCommentsByBill retrieves a bill's comments, oldest first.
+func (s *SQLiteStore) ListCommentsByBill(ctx context.Context, billID string) ([]*models.Comment, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+commentColumns+" FROM comments WHERE bill_id = ? ORDER BY created_at, id", billID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list comments: %w", err)
+	}
+	defer rows.Close()
+
+	var comments []*models.Comment
+	for rows.Next() {
+		comment, err := s.scanComment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan comment: %w", err)
+		}
+		comments = append(comments, comment)
+	}
+	return comments, rows.Err()
+}
+
+// DeleteComment removes a comment by ID.
+func (s *SQLiteStore) DeleteComment(ctx context.Context, commentID string) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM comments WHERE id = ?", commentID)
+	if err != nil {
+		return fmt.Errorf("failed to delete comment: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return storage.Errorf(storage.ErrNotFound, "comment not found: %s", commentID)
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mmynk/splitwiser/internal/storage"
+	"github.com/mmynk/splitwiser/pkg/logging"
+	sqlitedriver "modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// QueryHook is called after every statement the store runs, with the context
+// the statement ran under. For queries, duration covers execution up to the
+// first row, not reading the rest of the result.
+type QueryHook func(ctx context.Context, query string, duration time.Duration, err error)
+
+// logger logs as the storage component; see pkg/logging.
+var logger = logging.Component("storage")
+
+// busyRetryBackoff is the wait before the first retry of a busy statement;
+// it doubles on each further retry.
+const busyRetryBackoff = 10 * time.Millisecond
+
+// buildDSN adds the per-connection pragmas to dbPath. Pragmas in the DSN are
+// applied to every connection the pool opens, unlike a one-off PRAGMA
+// statement, which only reaches whichever connection ran it.
+func buildDSN(dbPath string, o options) string {
+	q := url.Values{}
+	q.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", o.busyTimeout.Milliseconds()))
+	q.Add("_pragma", "foreign_keys(1)")
+	q.Add("_pragma", fmt.Sprintf("journal_mode(%s)", o.journalMode))
+	// Write transactions take the write lock at BEGIN, where busy_timeout
+	// applies, instead of failing when a read upgrades to a write.
+	q.Set("_txlock", "immediate")
+	sep := "?"
+	if strings.Contains(dbPath, "?") {
+		sep = "&"
+	}
+	return dbPath + sep + q.Encode()
+}
+
+// isBusy reports whether err is SQLite failing to get a lock another
+// connection holds, which is worth retrying.
+func isBusy(err error) bool {
+	var sqliteErr *sqlitedriver.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	switch sqliteErr.Code() & 0xff { // primary code, without the extended bits
+	case sqlite3.SQLITE_BUSY, sqlite3.SQLITE_LOCKED:
+		return true
+	}
+	return false
+}
+
+// classifyError marks constraint violations with the storage error they
+// amount to, so callers can tell a duplicate or a dangling reference from a
+// broken database with errors.Is.
+func classifyError(err error) error {
+	var sqliteErr *sqlitedriver.Error
+	if !errors.As(err, &sqliteErr) {
+		return err
+	}
+	switch sqliteErr.Code() {
+	case sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+		return storage.Errorf(storage.ErrConflict, "%w", err)
+	case sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY:
+		return storage.Errorf(storage.ErrForeignKey, "%w", err)
+	}
+	return err
+}
+
+// retryBusy runs fn, retrying up to retries more times with exponential
+// backoff while it fails with a lock error.
+func retryBusy(ctx context.Context, retries int, fn func() error) error {
+	delay := busyRetryBackoff
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= retries || !isBusy(err) {
+			return err
+		}
+		logger.Debug("Database busy, retrying", "attempt", attempt+1, "delay", delay)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// connector opens SQLite connections whose statements are retried on lock
+// errors and, when hook is set, reported to it.
+type connector struct {
+	dsn     string
+	hook    QueryHook
+	retries int
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Driver().Open(c.dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &hookedConn{conn: conn, hook: c.hook, retries: c.retries}, nil
+}
+
+func (c *connector) Driver() driver.Driver {
+	return &sqlitedriver.Driver{}
+}
+
+// hookedConn wraps a SQLite connection, retrying and timing ExecContext,
+// QueryContext and BeginTx, and classifying constraint violations from the
+// first two. The pass-through methods keep the optional
+// interfaces database/sql looks for.
+type hookedConn struct {
+	conn    driver.Conn
+	hook    QueryHook
+	retries int
+}
+
+func (c *hookedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	var res driver.Result
+	err := retryBusy(ctx, c.retries, func() error {
+		start := time.Now()
+		var err error
+		res, err = c.conn.(driver.ExecerContext).ExecContext(ctx, query, args)
+		c.report(ctx, query, time.Since(start), err)
+		return err
+	})
+	return res, classifyError(err)
+}
+
+func (c *hookedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	var rows driver.Rows
+	err := retryBusy(ctx, c.retries, func() error {
+		start := time.Now()
+		var err error
+		rows, err = c.conn.(driver.QueryerContext).QueryContext(ctx, query, args)
+		c.report(ctx, query, time.Since(start), err)
+		return err
+	})
+	return rows, classifyError(err)
+}
+
+// report passes a statement to the hook, if there is one.
+func (c *hookedConn) report(ctx context.Context, query string, duration time.Duration, err error) {
+	logger.DebugContext(ctx, "Query", "query", query, "duration", duration, "error", err)
+	if c.hook != nil {
+		c.hook(ctx, query, duration, err)
+	}
+}
+
+func (c *hookedConn) Prepare(query string) (driver.Stmt, error) {
+	return c.conn.Prepare(query)
+}
+
+func (c *hookedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	return c.conn.(driver.ConnPrepareContext).PrepareContext(ctx, query)
+}
+
+func (c *hookedConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *hookedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	var tx driver.Tx
+	err := retryBusy(ctx, c.retries, func() error {
+		var err error
+		tx, err = c.conn.(driver.ConnBeginTx).BeginTx(ctx, opts)
+		return err
+	})
+	return tx, err
+}
+
+func (c *hookedConn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *hookedConn) Ping(ctx context.Context) error {
+	return c.conn.(driver.Pinger).Ping(ctx)
+}
+
+func (c *hookedConn) ResetSession(ctx context.Context) error {
+	return c.conn.(driver.SessionResetter).ResetSession(ctx)
+}
+
+func (c *hookedConn) IsValid() bool {
+	return c.conn.(driver.Validator).IsValid()
+}
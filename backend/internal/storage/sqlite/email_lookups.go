@@ -0,0 +1,182 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/mmynk/splitwiser/internal/models"
+)
+
+// The ways users.email_lookup_version says a user's email lookup was made:
+// from the address folded to lower case, or also without its plus tag.
+// Lookups made before normalization have version 0.
+const (
+	emailLookupCaseFolded = 1
+	emailLookupPlusFolded = 2
+)
+
+// lookupVersion is the version of the email lookups the store makes.
+func (s *SQLiteStore) lookupVersion() int {
+	if s.foldPlusTags {
+		return emailLookupPlusFolded
+	}
+	return emailLookupCaseFolded
+}
+
+// emailLookup returns what users.email_lookup holds for email: its
+// canonical form, keyed when the store encrypts.
+func (s *SQLiteStore) emailLookup(email string) string {
+	return s.cipher.emailLookup(models.CanonicalEmail(email, s.foldPlusTags))
+}
+
+// emailLookups returns every users.email_lookup value email may be stored
+// under; see fieldCipher.emailLookups.
+func (s *SQLiteStore) emailLookups(email string) []any {
+	return s.cipher.emailLookups(models.CanonicalEmail(email, s.foldPlusTags))
+}
+
+// plusTagFolding reports whether the database's email lookups were made
+// without plus tags, for a store opened without WithPlusTagFolding.
+func plusTagFolding(ctx context.Context, s *SQLiteStore) (bool, error) {
+	var version int
+	if err := s.db.QueryRowContext(ctx, "SELECT COALESCE(MAX(email_lookup_version), 0) FROM users").Scan(&version); err != nil {
+		return false, fmt.Errorf("failed to read email lookup version: %w", err)
+	}
+	return version == emailLookupPlusFolded, nil
+}
+
+// storedEmail is a user's email address as stored, encrypted or not.
+type storedEmail struct{ id, email string }
+
+// normalizeEmailLookups redoes the email lookups not made the way the store
+// makes them, oldest account first, and flags each account whose address
+// now matches an older account's as its duplicate, to be merged into it.
+// Only the oldest account with an address can be found by it, so sign-in
+// and registration treat "User@X.com" and " user@x.com" as one account.
+func (s *SQLiteStore) normalizeEmailLookups(ctx context.Context) error {
+	// Lookups made before normalization were unique as they were. Now only
+	// accounts that aren't duplicates need be.
+	var oldIndex bool
+	if err := s.db.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE type = 'index' AND name = 'idx_users_email_lookup')").Scan(&oldIndex); err != nil {
+		return fmt.Errorf("failed to check email lookup index: %w", err)
+	}
+	if oldIndex {
+		if _, err := s.db.ExecContext(ctx, "DROP INDEX idx_users_email_lookup"); err != nil {
+			return fmt.Errorf("failed to drop email lookup index: %w", err)
+		}
+	}
+
+	version := s.lookupVersion()
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT id, email FROM users WHERE email_lookup_version != ? ORDER BY created_at, id", version)
+	if err != nil {
+		return fmt.Errorf("failed to read email lookups: %w", err)
+	}
+	var users []storedEmail
+	for rows.Next() {
+		var u storedEmail
+		if err := rows.Scan(&u.id, &u.email); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read email lookups: %w", err)
+		}
+		users = append(users, u)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read email lookups: %w", err)
+	}
+
+	if len(users) > 0 {
+		if err := s.relookupUsers(ctx, version, users); err != nil {
+			return err
+		}
+	}
+	if _, err := s.db.ExecContext(ctx,
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_lookup_primary ON users(email_lookup) WHERE duplicate_of = ''"); err != nil {
+		return fmt.Errorf("failed to index email lookups: %w", err)
+	}
+	return nil
+}
+
+// relookupUsers redoes the email lookups of users, in order, in one
+// transaction.
+func (s *SQLiteStore) relookupUsers(ctx context.Context, version int, users []storedEmail) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lookups are redone one by one, and a user's new lookup may still be
+	// another's old one: set them all aside first. IDs hold no "@", so they
+	// match no address.
+	if _, err := tx.ExecContext(ctx, "UPDATE users SET email_lookup = id WHERE email_lookup_version != ?", version); err != nil {
+		return fmt.Errorf("failed to clear email lookups: %w", err)
+	}
+	var duplicates int
+	for _, u := range users {
+		email, err := s.cipher.decrypt(columnUserEmail, u.email)
+		if err != nil {
+			return fmt.Errorf("user %s: %w", u.id, err)
+		}
+		lookup := s.emailLookup(email)
+
+		// Users are redone oldest first, so the oldest with the address is
+		// the one the others duplicate.
+		var original string
+		err = tx.QueryRowContext(ctx, `
+			SELECT id FROM users
+			WHERE email_lookup = ? AND email_lookup_version = ? AND duplicate_of = '' AND id != ?
+			ORDER BY created_at, id LIMIT 1`,
+			lookup, version, u.id,
+		).Scan(&original)
+		if err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("failed to check for duplicate email: %w", err)
+		}
+		if original != "" {
+			duplicates++
+		}
+		if _, err := tx.ExecContext(ctx,
+			"UPDATE users SET email_lookup = ?, email_lookup_version = ?, duplicate_of = ? WHERE id = ?",
+			lookup, version, original, u.id,
+		); err != nil {
+			return fmt.Errorf("failed to update email lookup: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	logger.Info("Normalized email lookups", "users", len(users), "version", version)
+	if duplicates > 0 {
+		logger.Warn("Accounts share an email address and are flagged for merging", "duplicates", duplicates)
+	}
+	return nil
+}
+
+// DuplicateAccount is an account flagged as sharing its email address with
+// an older account, which it should be merged into.
+type DuplicateAccount struct {
+	UserID      string `json:"user_id"`
+	DuplicateOf string `json:"duplicate_of"`
+}
+
+// ListDuplicateAccounts returns the accounts flagged as duplicates, oldest
+// first.
+func (s *SQLiteStore) ListDuplicateAccounts(ctx context.Context) ([]DuplicateAccount, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT id, duplicate_of FROM users WHERE duplicate_of != '' ORDER BY created_at, id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list duplicate accounts: %w", err)
+	}
+	defer rows.Close()
+	var out []DuplicateAccount
+	for rows.Next() {
+		var d DuplicateAccount
+		if err := rows.Scan(&d.UserID, &d.DuplicateOf); err != nil {
+			return nil, fmt.Errorf("failed to scan duplicate account: %w", err)
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
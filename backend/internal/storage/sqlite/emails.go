@@ -0,0 +1,113 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/mmynk/splitwiser/internal/models"
+	"github.com/mmynk/splitwiser/internal/storage"
+)
+
+// AddUserEmail links an unverified email address to a user.
+// Re-adding a pending address replaces its verification token; verified addresses are left untouched.
+func (s *SQLiteStore) AddUserEmail(ctx context.Context, e *models.UserEmail) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO user_emails (user_id, email, verified, token_hash, token_expires_at, created_at)
+		VALUES (?, ?, 0, ?, ?, ?)
+		ON CONFLICT (user_id, email) DO UPDATE SET
+			token_hash = excluded.token_hash,
+			token_expires_at = excluded.token_expires_at
+		WHERE verified = 0`,
+		e.UserID, e.Email, nullString(e.TokenHash), e.TokenExpiresAt, e.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to add user email: %w", err)
+	}
+	return nil
+}
+
+// GetUserEmail retrieves a linked email address for a user.
+func (s *SQLiteStore) GetUserEmail(ctx context.Context, userID, email string) (*models.UserEmail, error) {
+	e := &models.UserEmail{}
+	var tokenHash sql.NullString
+	var tokenExpiresAt, verifiedAt sql.NullInt64
+	err := s.db.QueryRowContext(ctx,
+		`SELECT user_id, email, verified, token_hash, token_expires_at, created_at, verified_at
+		FROM user_emails WHERE user_id = ? AND email = ?`,
+		userID, email,
+	).Scan(&e.UserID, &e.Email, &e.Verified, &tokenHash, &tokenExpiresAt, &e.CreatedAt, &verifiedAt)
+	if err == sql.ErrNoRows {
+		return nil, storage.Errorf(storage.ErrNotFound, "email not found: %s", email)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user email: %w", err)
+	}
+	e.TokenHash = tokenHash.String
+	e.TokenExpiresAt = tokenExpiresAt.Int64
+	e.VerifiedAt = verifiedAt.Int64
+	return e, nil
+}
+
+// ListUserEmails returns the addresses linked to a user, excluding the primary email.
+func (s *SQLiteStore) ListUserEmails(ctx context.Context, userID string) ([]*models.UserEmail, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT user_id, email, verified, created_at, verified_at
+		FROM user_emails WHERE user_id = ?
+		ORDER BY created_at, email`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list user emails: %w", err)
+	}
+	defer rows.Close()
+
+	var emails []*models.UserEmail
+	for rows.Next() {
+		e := &models.UserEmail{}
+		var verifiedAt sql.NullInt64
+		if err := rows.Scan(&e.UserID, &e.Email, &e.Verified, &e.CreatedAt, &verifiedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan user email: %w", err)
+		}
+		e.VerifiedAt = verifiedAt.Int64
+		emails = append(emails, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate user emails: %w", err)
+	}
+	return emails, nil
+}
+
+// MarkUserEmailVerified marks a linked address as verified and clears its token.
+// Fails if another account has already verified the same address.
+func (s *SQLiteStore) MarkUserEmailVerified(ctx context.Context, userID, email string, verifiedAt int64) error {
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE user_emails SET verified = 1, verified_at = ?, token_hash = NULL, token_expires_at = NULL
+		WHERE user_id = ? AND email = ?`,
+		verifiedAt, userID, email,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to verify user email: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return storage.Errorf(storage.ErrNotFound, "email not found: %s", email)
+	}
+	return nil
+}
+
+// DeleteUserEmail unlinks an address from a user.
+func (s *SQLiteStore) DeleteUserEmail(ctx context.Context, userID, email string) error {
+	result, err := s.db.ExecContext(ctx,
+		"DELETE FROM user_emails WHERE user_id = ? AND email = ?",
+		userID, email,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete user email: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return storage.Errorf(storage.ErrNotFound, "email not found: %s", email)
+	}
+	return nil
+}
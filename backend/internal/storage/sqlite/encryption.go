@@ -0,0 +1,282 @@
+package sqlite
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// KeySize is the length of an encryption key: AES-256.
+const KeySize = 32
+
+// encryptedPrefix starts every value the store encrypted. The ID of the key
+// it was encrypted with follows, then the base64 nonce and ciphertext:
+// "enc1:<key id>:<data>".
+const encryptedPrefix = "enc1:"
+
+// The columns encrypted at rest, named as they are bound into their
+// ciphertexts so a value can't be moved to another column. The audit log's
+// snapshots are among them, as they copy settlement notes and comments.
+const (
+	columnUserEmail          = "users.email"
+	columnSettlementNote     = "settlements.note"
+	columnSettlementPlanNote = "settlement_plans.note"
+	columnCommentBody        = "comments.body"
+	columnAuditBefore        = "audit_log.before_json"
+	columnAuditAfter         = "audit_log.after_json"
+)
+
+// emailLookupLabel derives, from each key, the key of the email lookups.
+const emailLookupLabel = "users.email_lookup"
+
+// keyIDLength is how many hex digits of a key's digest name it.
+const keyIDLength = 8
+
+// ParseEncryptionKey decodes a base64 key of KeySize bytes, as generated by
+// `openssl rand -base64 32`.
+func ParseEncryptionKey(s string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("encryption key is not base64: %w", err)
+	}
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("encryption key is %d bytes, want %d", len(key), KeySize)
+	}
+	return key, nil
+}
+
+// ParseEncryptionKeys parses a comma-separated list of keys as
+// ParseEncryptionKey does. An empty list has no keys.
+func ParseEncryptionKeys(s string) ([][]byte, error) {
+	var keys [][]byte
+	for _, field := range strings.Split(s, ",") {
+		if strings.TrimSpace(field) == "" {
+			continue
+		}
+		key, err := ParseEncryptionKey(field)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// fieldCipher encrypts sensitive column values with AES-GCM under the
+// current key and decrypts them under it or any previous key. Values
+// without the encrypted prefix, written before encryption was turned on,
+// read back as they are. A nil fieldCipher leaves everything in plain text.
+type fieldCipher struct {
+	current string                 // ID of the key new values are encrypted with
+	aeads   map[string]cipher.AEAD // by key ID
+	lookups map[string][]byte      // email lookup HMAC key, by key ID
+	order   []string               // key IDs, current first
+}
+
+// newFieldCipher returns a cipher encrypting with key and also decrypting
+// values encrypted with any of previous.
+func newFieldCipher(key []byte, previous ...[]byte) (*fieldCipher, error) {
+	c := &fieldCipher{aeads: make(map[string]cipher.AEAD), lookups: make(map[string][]byte)}
+	for i, k := range append([][]byte{key}, previous...) {
+		if len(k) != KeySize {
+			return nil, fmt.Errorf("encryption key is %d bytes, want %d", len(k), KeySize)
+		}
+		block, err := aes.NewCipher(k)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create cipher: %w", err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create cipher: %w", err)
+		}
+		id := encryptionKeyID(k)
+		if i == 0 {
+			c.current = id
+		}
+		if _, ok := c.aeads[id]; ok {
+			continue
+		}
+		c.aeads[id] = aead
+		c.lookups[id] = hmacSum(k, emailLookupLabel)
+		c.order = append(c.order, id)
+	}
+	return c, nil
+}
+
+// encryptionKeyID names a key in its ciphertexts without revealing it.
+func encryptionKeyID(key []byte) string {
+	return hex.EncodeToString(hmacSum(key, "key id"))[:keyIDLength]
+}
+
+func hmacSum(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// encrypt returns value encrypted for column under the current key. Empty
+// values stay empty, so "no note" needs no key to read.
+func (c *fieldCipher) encrypt(column, value string) (string, error) {
+	if c == nil || value == "" {
+		return value, nil
+	}
+	aead := c.aeads[c.current]
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(value)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(value), []byte(column))
+	return encryptedPrefix + c.current + ":" + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// decrypt returns the plain text of a value read from column.
+func (c *fieldCipher) decrypt(column, value string) (string, error) {
+	rest, ok := strings.CutPrefix(value, encryptedPrefix)
+	if !ok {
+		return value, nil
+	}
+	if c == nil {
+		return "", fmt.Errorf("%s is encrypted but no encryption key is configured", column)
+	}
+	id, data, ok := strings.Cut(rest, ":")
+	aead := c.aeads[id]
+	if !ok || aead == nil {
+		return "", fmt.Errorf("%s is encrypted with unknown key %s", column, id)
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(data)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", fmt.Errorf("%s holds a malformed encrypted value", column)
+	}
+	plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(column))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt %s: %w", column, err)
+	}
+	return string(plain), nil
+}
+
+// decryptNull is decrypt for a nullable column, keeping NULL as NULL.
+func (c *fieldCipher) decryptNull(column string, value sql.NullString) (sql.NullString, error) {
+	if !value.Valid {
+		return value, nil
+	}
+	plain, err := c.decrypt(column, value.String)
+	return sql.NullString{String: plain, Valid: true}, err
+}
+
+// emailLookup returns what users.email_lookup holds for email: a keyed
+// digest, so addresses can be found without storing them in the clear, or
+// the address itself when encryption is off.
+func (c *fieldCipher) emailLookup(email string) string {
+	if c == nil {
+		return email
+	}
+	return hex.EncodeToString(hmacSum(c.lookups[c.current], email))
+}
+
+// emailLookups returns every users.email_lookup value email may be stored
+// under: its digest under each key, for rows not yet rotated to the current
+// one, and the address itself, for rows written before encryption.
+func (c *fieldCipher) emailLookups(email string) []any {
+	lookups := []any{email}
+	if c == nil {
+		return lookups
+	}
+	for _, id := range c.order {
+		lookups = append(lookups, hex.EncodeToString(hmacSum(c.lookups[id], email)))
+	}
+	return lookups
+}
+
+// RotationResult counts the values RotateEncryptionKey re-encrypted, by column.
+type RotationResult struct {
+	Rows map[string]int
+}
+
+// RotateEncryptionKey re-encrypts every encrypted column under the current
+// key, in one transaction: values under a previous key, and values stored in
+// plain text before encryption was turned on. Once it returns, the previous
+// keys are no longer needed.
+func (s *SQLiteStore) RotateEncryptionKey(ctx context.Context) (*RotationResult, error) {
+	if s.cipher == nil {
+		return nil, fmt.Errorf("no encryption key is configured")
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result := &RotationResult{Rows: make(map[string]int)}
+	columns := []struct {
+		table, column, label string
+	}{
+		{"users", "email", columnUserEmail},
+		{"settlements", "note", columnSettlementNote},
+		{"settlement_plans", "note", columnSettlementPlanNote},
+		{"comments", "body", columnCommentBody},
+		{"audit_log", "before_json", columnAuditBefore},
+		{"audit_log", "after_json", columnAuditAfter},
+	}
+	for _, col := range columns {
+		n, err := s.rotateColumn(ctx, tx, col.table, col.column, col.label)
+		if err != nil {
+			return nil, err
+		}
+		result.Rows[col.label] = n
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return result, nil
+}
+
+// rotateColumn re-encrypts the non-empty values of one column under the
+// current key, refreshing the email lookups along with the emails.
+func (s *SQLiteStore) rotateColumn(ctx context.Context, tx *sql.Tx, table, column, label string) (int, error) {
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf("SELECT id, %s FROM %s WHERE %s IS NOT NULL AND %s != ''", column, table, column, column))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", label, err)
+	}
+	values := make(map[string]string)
+	for rows.Next() {
+		var id, value string
+		if err := rows.Scan(&id, &value); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to read %s: %w", label, err)
+		}
+		values[id] = value
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", label, err)
+	}
+
+	for id, value := range values {
+		plain, err := s.cipher.decrypt(label, value)
+		if err != nil {
+			return 0, fmt.Errorf("row %s: %w", id, err)
+		}
+		sealed, err := s.cipher.encrypt(label, plain)
+		if err != nil {
+			return 0, err
+		}
+		query := fmt.Sprintf("UPDATE %s SET %s = ? WHERE id = ?", table, column)
+		args := []any{sealed, id}
+		if label == columnUserEmail {
+			query = "UPDATE users SET email = ?, email_lookup = ? WHERE id = ?"
+			args = []any{sealed, s.emailLookup(plain), id}
+		}
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return 0, fmt.Errorf("failed to re-encrypt %s: %w", label, err)
+		}
+	}
+	return len(values), nil
+}
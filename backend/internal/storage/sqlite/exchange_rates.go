@@ -0,0 +1,25 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mmynk/splitwiser/internal/storage"
+)
+
+// SetBillExchangeRate replaces the exchange rate locked with a bill, for when
+// the rate it was created at was wrong. Balances count the bill at the new
+// rate from then on.
+func (s *SQLiteStore) SetBillExchangeRate(ctx context.Context, billID string, rate float64) error {
+	if rate <= 0 {
+		return fmt.Errorf("exchange rate must be positive, got %v", rate)
+	}
+	result, err := s.db.ExecContext(ctx, "UPDATE bills SET exchange_rate = ? WHERE id = ? AND deleted_at = 0", rate, billID)
+	if err != nil {
+		return fmt.Errorf("failed to set exchange rate: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return storage.Errorf(storage.ErrNotFound, "bill not found: %s", billID)
+	}
+	return nil
+}
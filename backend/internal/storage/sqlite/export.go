@@ -0,0 +1,278 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mmynk/splitwiser/internal/storage"
+)
+
+// exportVersion is the Export format written by ExportAll.
+const exportVersion = 1
+
+// Export is a whole database as a self-contained JSON document: every row of
+// every table, keyed by column name, with the IDs that link them. It carries
+// users, groups, bills and their items, settlements and everything else, so
+// a deployment can move to another by ExportAll on one and ImportAll on the
+// other.
+type Export struct {
+	Version    int                         `json:"version"`
+	ExportedAt int64                       `json:"exported_at"`
+	Tables     map[string][]map[string]any `json:"tables"`
+}
+
+// ImportResult reports what ImportAll imported, or would have on a dry run.
+type ImportResult struct {
+	Rows   map[string]int `json:"rows"` // by table
+	DryRun bool           `json:"dry_run"`
+}
+
+// exportTables lists the tables an Export holds: all but SQLite's own, the
+// search index, which ImportAll rebuilds, and tables named with a leading
+// underscore, which belong to tooling such as replication rather than the
+// app.
+func exportTables(ctx context.Context, q interface {
+	QueryContext(context.Context, string, ...any) (*sql.Rows, error)
+}) ([]string, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT name FROM sqlite_master
+		WHERE type = 'table'
+		  AND name NOT LIKE 'sqlite\_%' ESCAPE '\'
+		  AND name NOT LIKE 'bills\_fts%' ESCAPE '\'
+		  AND name NOT LIKE '\_%' ESCAPE '\'
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	defer rows.Close()
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan table name: %w", err)
+		}
+		tables = append(tables, name)
+	}
+	return tables, rows.Err()
+}
+
+// ExportAll writes every row of the database to w as an Export, read in one
+// transaction so it's consistent while the store stays in use.
+func (s *SQLiteStore) ExportAll(ctx context.Context, w io.Writer) error {
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	tables, err := exportTables(ctx, tx)
+	if err != nil {
+		return err
+	}
+	export := Export{
+		Version:    exportVersion,
+		ExportedAt: time.Now().Unix(),
+		Tables:     make(map[string][]map[string]any, len(tables)),
+	}
+	for _, table := range tables {
+		rows, err := exportRows(ctx, tx, table)
+		if err != nil {
+			return err
+		}
+		export.Tables[table] = rows
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(export); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+	return nil
+}
+
+// exportRows reads every row of table, in insertion order.
+func exportRows(ctx context.Context, tx *sql.Tx, table string) ([]map[string]any, error) {
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf("SELECT * FROM %q ORDER BY rowid", table))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", table, err)
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s columns: %w", table, err)
+	}
+
+	out := []map[string]any{}
+	for rows.Next() {
+		values := make([]any, len(columns))
+		ptrs := make([]any, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, fmt.Errorf("failed to scan %s row: %w", table, err)
+		}
+		row := make(map[string]any, len(columns))
+		for i, column := range columns {
+			if b, ok := values[i].([]byte); ok {
+				values[i] = string(b)
+			}
+			row[column] = values[i]
+		}
+		out = append(out, row)
+	}
+	return out, rows.Err()
+}
+
+// ImportAll loads an Export read from r into the database, which must have
+// no rows in any of the tables it holds, other than seeded ones such as the
+// standard categories, keeping every ID. Columns the export
+// lacks get their defaults, so exports from older versions import; tables or
+// columns this version doesn't know are an error. Everything is checked,
+// foreign keys included, in one transaction, which a dry run rolls back.
+func (s *SQLiteStore) ImportAll(ctx context.Context, r io.Reader, dryRun bool) (*ImportResult, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	var export Export
+	if err := dec.Decode(&export); err != nil {
+		return nil, fmt.Errorf("failed to read export: %w", err)
+	}
+	if export.Version != exportVersion {
+		return nil, fmt.Errorf("unsupported export version %d (want %d)", export.Version, exportVersion)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Rows are inserted table by table, so references are checked at the end.
+	if _, err := tx.ExecContext(ctx, "PRAGMA defer_foreign_keys = ON"); err != nil {
+		return nil, fmt.Errorf("failed to defer foreign keys: %w", err)
+	}
+	known, err := exportTables(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ImportResult{Rows: make(map[string]int, len(export.Tables)), DryRun: dryRun}
+	for _, table := range slices.Sorted(maps.Keys(export.Tables)) {
+		if !slices.Contains(known, table) {
+			return nil, fmt.Errorf("unknown table %q", table)
+		}
+		n, err := importRows(ctx, tx, table, export.Tables[table])
+		if err != nil {
+			return nil, err
+		}
+		result.Rows[table] = n
+	}
+
+	if err := checkForeignKeys(ctx, tx); err != nil {
+		return nil, err
+	}
+	_, err = tx.ExecContext(ctx, "INSERT INTO bills_fts (bill_id, title, items, participants)"+billSearchDocs+
+		" WHERE b.id NOT IN (SELECT bill_id FROM bills_fts)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to index bills: %w", err)
+	}
+	if dryRun {
+		return result, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return result, nil
+}
+
+// seededTables are the tables every database starts with rows in. An export
+// has those rows too, so importRows replaces them.
+var seededTables = map[string]bool{"categories": true}
+
+// importRows inserts rows into table, which must be empty but for seed rows.
+func importRows(ctx context.Context, tx *sql.Tx, table string, rows []map[string]any) (int, error) {
+	if seededTables[table] {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %q", table)); err != nil {
+			return 0, fmt.Errorf("failed to clear %s: %w", table, err)
+		}
+	}
+	var existing bool
+	if err := tx.QueryRowContext(ctx, fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %q)", table)).Scan(&existing); err != nil {
+		return 0, fmt.Errorf("failed to check %s: %w", table, err)
+	}
+	if existing {
+		return 0, storage.Errorf(storage.ErrConflict, "%s already has rows; import into an empty database", table)
+	}
+
+	columns := make(map[string]bool)
+	info, err := tx.QueryContext(ctx, "SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s columns: %w", table, err)
+	}
+	for info.Next() {
+		var name string
+		if err := info.Scan(&name); err != nil {
+			info.Close()
+			return 0, fmt.Errorf("failed to read %s columns: %w", table, err)
+		}
+		columns[name] = true
+	}
+	info.Close()
+
+	for i, row := range rows {
+		names := slices.Sorted(maps.Keys(row))
+		quoted := make([]string, len(names))
+		args := make([]any, len(names))
+		for j, name := range names {
+			if !columns[name] {
+				return 0, fmt.Errorf("unknown column %s.%s", table, name)
+			}
+			quoted[j] = fmt.Sprintf("%q", name)
+			args[j] = importValue(row[name])
+		}
+		query := fmt.Sprintf("INSERT INTO %q (%s) VALUES (%s)", table,
+			strings.Join(quoted, ", "), strings.TrimSuffix(strings.Repeat("?, ", len(names)), ", "))
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return 0, fmt.Errorf("failed to import %s row %d: %w", table, i, err)
+		}
+	}
+	return len(rows), nil
+}
+
+// importValue converts a value decoded from an Export to what SQLite stores:
+// whole numbers as integers, which columns of REAL affinity turn back into
+// reals, and other numbers as reals.
+func importValue(v any) any {
+	n, ok := v.(json.Number)
+	if !ok {
+		return v
+	}
+	if i, err := n.Int64(); err == nil {
+		return i
+	}
+	f, _ := n.Float64()
+	return f
+}
+
+// checkForeignKeys reports the first reference to a row that doesn't exist.
+func checkForeignKeys(ctx context.Context, tx *sql.Tx) error {
+	var table, parent string
+	var rowid sql.NullInt64
+	var fkid int
+	err := tx.QueryRowContext(ctx, "PRAGMA foreign_key_check").Scan(&table, &rowid, &parent, &fkid)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check foreign keys: %w", err)
+	}
+	return storage.Errorf(storage.ErrForeignKey, "a %s row refers to a missing %s row", table, parent)
+}
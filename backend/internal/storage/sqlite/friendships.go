@@ -8,6 +8,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/mmynk/splitwiser/internal/models"
+	"github.com/mmynk/splitwiser/internal/storage"
 )
 
 // SendFriendRequest persists a new friendship request.
@@ -36,7 +37,7 @@ func (s *SQLiteStore) SendFriendRequest(ctx context.Context, friendship *models.
 		return fmt.Errorf("failed to check existing friendship: %w", err)
 	}
 	if err == nil {
-		return fmt.Errorf("friendship request already exists between these users")
+		return storage.Errorf(storage.ErrConflict, "friendship request already exists between these users")
 	}
 
 	_, err = s.db.ExecContext(ctx,
@@ -62,7 +63,7 @@ func (s *SQLiteStore) GetFriendship(ctx context.Context, id string) (*models.Fri
 		id,
 	).Scan(&f.ID, &f.RequesterID, &f.AddresseeID, &status, &f.CreatedAt, &f.UpdatedAt)
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("friendship not found: %s", id)
+		return nil, storage.Errorf(storage.ErrNotFound, "friendship not found: %s", id)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get friendship: %w", err)
@@ -82,7 +83,7 @@ func (s *SQLiteStore) UpdateFriendshipStatus(ctx context.Context, id string, sta
 	}
 	rows, _ := result.RowsAffected()
 	if rows == 0 {
-		return fmt.Errorf("friendship not found: %s", id)
+		return storage.Errorf(storage.ErrNotFound, "friendship not found: %s", id)
 	}
 	return nil
 }
@@ -128,7 +129,7 @@ func (s *SQLiteStore) DeleteFriendship(ctx context.Context, id string) error {
 	}
 	rows, _ := result.RowsAffected()
 	if rows == 0 {
-		return fmt.Errorf("friendship not found: %s", id)
+		return storage.Errorf(storage.ErrNotFound, "friendship not found: %s", id)
 	}
 	return nil
 }
@@ -145,7 +146,7 @@ func (s *SQLiteStore) GetFriendshipBetween(ctx context.Context, userIDA, userIDB
 		userIDA, userIDB, userIDB, userIDA,
 	).Scan(&f.ID, &f.RequesterID, &f.AddresseeID, &status, &f.CreatedAt, &f.UpdatedAt)
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("friendship not found")
+		return nil, storage.Errorf(storage.ErrNotFound, "friendship not found")
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get friendship: %w", err)
@@ -221,6 +222,11 @@ func (s *SQLiteStore) GetFriends(ctx context.Context, userID string) ([]*models.
 		if err := rows.Scan(&u.ID, &u.Email, &u.DisplayName); err != nil {
 			return nil, fmt.Errorf("failed to scan friend: %w", err)
 		}
+		email, err := s.cipher.decrypt(columnUserEmail, u.Email)
+		if err != nil {
+			return nil, err
+		}
+		u.Email = email
 		users = append(users, u)
 	}
 	return users, rows.Err()
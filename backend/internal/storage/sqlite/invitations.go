@@ -0,0 +1,63 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/mmynk/splitwiser/internal/models"
+	"github.com/mmynk/splitwiser/internal/storage"
+)
+
+// CreateInvitation stores a pending account invitation.
+func (s *SQLiteStore) CreateInvitation(ctx context.Context, inv *models.Invitation) error {
+	_, err := s.db.ExecContext(ctx,
+		"INSERT INTO invitations (token_hash, user_id, expires_at, created_at) VALUES (?, ?, ?, ?)",
+		inv.TokenHash, inv.UserID, inv.ExpiresAt, inv.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create invitation: %w", err)
+	}
+	return nil
+}
+
+// GetInvitation retrieves an invitation by its token hash.
+func (s *SQLiteStore) GetInvitation(ctx context.Context, tokenHash string) (*models.Invitation, error) {
+	inv := &models.Invitation{}
+	err := s.db.QueryRowContext(ctx,
+		"SELECT token_hash, user_id, expires_at, created_at FROM invitations WHERE token_hash = ?",
+		tokenHash,
+	).Scan(&inv.TokenHash, &inv.UserID, &inv.ExpiresAt, &inv.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, storage.Errorf(storage.ErrNotFound, "invitation not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invitation: %w", err)
+	}
+	return inv, nil
+}
+
+// DeleteInvitationsByUser removes all invitations for a user.
+func (s *SQLiteStore) DeleteInvitationsByUser(ctx context.Context, userID string) error {
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM invitations WHERE user_id = ?", userID); err != nil {
+		return fmt.Errorf("failed to delete invitations: %w", err)
+	}
+	return nil
+}
+
+// SetUserPassword replaces a user's password hash.
+func (s *SQLiteStore) SetUserPassword(ctx context.Context, userID, passwordHash string) error {
+	result, err := s.db.ExecContext(ctx,
+		"UPDATE users SET password_hash = ?, updated_at = ? WHERE id = ?",
+		passwordHash, time.Now().Unix(), userID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to set user password: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return storage.Errorf(storage.ErrNotFound, "user not found: %s", userID)
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mmynk/splitwiser/internal/storage"
+)
+
+// SetBillLocked sets when a live bill was locked against edits, or unlocks
+// it if lockedAt is zero.
+func (s *SQLiteStore) SetBillLocked(ctx context.Context, billID string, lockedAt int64) error {
+	result, err := s.db.ExecContext(ctx, "UPDATE bills SET locked_at = ? WHERE id = ? AND deleted_at = 0", lockedAt, billID)
+	if err != nil {
+		return fmt.Errorf("failed to set bill lock: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return storage.Errorf(storage.ErrNotFound, "bill not found: %s", billID)
+	}
+	return nil
+}
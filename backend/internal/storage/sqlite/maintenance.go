@@ -0,0 +1,96 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DBSize describes how much space the database file uses.
+type DBSize struct {
+	Bytes     int64 // page_count * page_size
+	FreeBytes int64 // space on the freelist, reclaimable by VACUUM
+}
+
+// FreeRatio is the fraction of the file that VACUUM would reclaim.
+func (s DBSize) FreeRatio() float64 {
+	if s.Bytes == 0 {
+		return 0
+	}
+	return float64(s.FreeBytes) / float64(s.Bytes)
+}
+
+// MaintenanceOptions controls what a maintenance run does beyond the
+// always-on ANALYZE.
+type MaintenanceOptions struct {
+	// SkipCheckpoint leaves the WAL to whatever else checkpoints it, such as
+	// replication, whose open read transaction a TRUNCATE checkpoint would
+	// wait on while holding up writers.
+	SkipCheckpoint bool
+
+	// VacuumFreeRatio triggers a VACUUM once at least this fraction of the file
+	// is free pages. Zero never vacuums unless ForceVacuum is set.
+	VacuumFreeRatio float64
+
+	// ForceVacuum vacuums regardless of free space.
+	ForceVacuum bool
+}
+
+// MaintenanceResult reports what a maintenance run did.
+type MaintenanceResult struct {
+	Before   DBSize
+	After    DBSize
+	Vacuumed bool
+	Duration time.Duration
+}
+
+// Size reports the database's size and reclaimable free space.
+func (s *SQLiteStore) Size(ctx context.Context) (DBSize, error) {
+	var pageSize, pageCount, freePages int64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT
+			(SELECT page_size FROM pragma_page_size),
+			(SELECT page_count FROM pragma_page_count),
+			(SELECT freelist_count FROM pragma_freelist_count)
+	`).Scan(&pageSize, &pageCount, &freePages)
+	if err != nil {
+		return DBSize{}, fmt.Errorf("failed to get database size: %w", err)
+	}
+	return DBSize{Bytes: pageCount * pageSize, FreeBytes: freePages * pageSize}, nil
+}
+
+// Maintain refreshes query planner statistics, checkpoints the write-ahead log
+// (a no-op outside WAL mode) unless opts.SkipCheckpoint is set, and vacuums when free space crosses
+// opts.VacuumFreeRatio. Bills are rewritten on every update, so free pages
+// accumulate with ordinary use. VACUUM holds a write lock for its duration.
+func (s *SQLiteStore) Maintain(ctx context.Context, opts MaintenanceOptions) (*MaintenanceResult, error) {
+	start := time.Now()
+	before, err := s.Size(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := &MaintenanceResult{Before: before}
+
+	if _, err := s.db.ExecContext(ctx, "ANALYZE"); err != nil {
+		return nil, fmt.Errorf("failed to analyze: %w", err)
+	}
+	if !opts.SkipCheckpoint {
+		if _, err := s.db.ExecContext(ctx, "PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
+			return nil, fmt.Errorf("failed to checkpoint: %w", err)
+		}
+	}
+
+	if opts.ForceVacuum || (opts.VacuumFreeRatio > 0 && before.FreeRatio() >= opts.VacuumFreeRatio) {
+		if _, err := s.db.ExecContext(ctx, "VACUUM"); err != nil {
+			return nil, fmt.Errorf("failed to vacuum: %w", err)
+		}
+		result.Vacuumed = true
+	}
+
+	result.After, err = s.Size(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result.Duration = time.Since(start)
+	return result, nil
+}
@@ -0,0 +1,226 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/mmynk/splitwiser/internal/models"
+	"github.com/mmynk/splitwiser/internal/storage"
+)
+
+// auditEntityUser is the audit log's entity type for an account.
+const auditEntityUser = "user"
+
+// userReferences are the columns holding user IDs that MergeUsers moves from
+// the duplicate account to the primary, with the rows of each they apply
+// to. Group settlements and plans name members by display name, so only
+// direct settlements are moved.
+var userReferences = []struct {
+	table, column, where string
+}{
+	{"groups", "creator_id", ""},
+	{"group_members", "user_id", ""},
+	{"bills", "creator_id", ""},
+	{"participants", "user_id", ""},
+	{"settlements", "from_user_id", "group_id IS NULL"},
+	{"settlements", "to_user_id", "group_id IS NULL"},
+	{"group_webhooks", "created_by", ""},
+	{"group_stats_tokens", "created_by", ""},
+	{"bill_share_tokens", "created_by", ""},
+	{"attachments", "uploader_id", ""},
+	{"comments", "author_id", ""},
+	{"bill_revisions", "editor_id", ""},
+	{"bill_shares", "marked_by", ""},
+	{"account_webhooks", "user_id", ""},
+	{"bill_views", "user_id", ""},
+	{"friendships", "requester_id", ""},
+	{"friendships", "addressee_id", ""},
+	{"user_emails", "user_id", ""},
+	{"users", "duplicate_of", ""},
+	{"merged_users", "merged_into", ""},
+}
+
+// MergeResult reports what MergeUsers moved from the duplicate account to
+// the primary, or would have on a dry run.
+type MergeResult struct {
+	PrimaryID   string         `json:"primary_id"`
+	DuplicateID string         `json:"duplicate_id"`
+	Rows        map[string]int `json:"rows"`   // rows moved, by table.column
+	Groups      []string       `json:"groups"` // groups the duplicate was a member of
+	// SharedGroups are the groups both accounts were members of. Each now has
+	// two members for the primary, to be tidied up by hand.
+	SharedGroups []string `json:"shared_groups,omitempty"`
+	// DroppedFriendships counts the duplicate's friendships with the primary
+	// or with someone the primary was already friends with, which are deleted.
+	DroppedFriendships int  `json:"dropped_friendships"`
+	DryRun             bool `json:"dry_run"`
+}
+
+// MergeUsers merges the duplicate account into the primary in one
+// transaction: its groups, bills, direct settlements, comments, friendships,
+// webhooks, saved views and linked emails become the primary's, its address is linked
+// to the primary, verified, and the duplicate account is deleted. Sessions
+// of the duplicate continue as the primary; see MergedInto. The merge is
+// recorded in the audit log of every group the duplicate was in. A dry run
+// reports the same without keeping anything.
+func (s *SQLiteStore) MergeUsers(ctx context.Context, primaryID, duplicateID string, dryRun bool) (*MergeResult, error) {
+	if primaryID == duplicateID {
+		return nil, fmt.Errorf("cannot merge account %s into itself", primaryID)
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM users WHERE id = ?)", primaryID).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if !exists {
+		return nil, storage.Errorf(storage.ErrNotFound, "user not found: %s", primaryID)
+	}
+	var duplicateEmail string
+	var duplicateCreatedAt int64
+	err = tx.QueryRowContext(ctx, "SELECT email, created_at FROM users WHERE id = ?", duplicateID).Scan(&duplicateEmail, &duplicateCreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, storage.Errorf(storage.ErrNotFound, "user not found: %s", duplicateID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if duplicateEmail, err = s.cipher.decrypt(columnUserEmail, duplicateEmail); err != nil {
+		return nil, err
+	}
+
+	result := &MergeResult{PrimaryID: primaryID, DuplicateID: duplicateID, Rows: make(map[string]int), DryRun: dryRun}
+	if result.Groups, err = queryStrings(ctx, tx,
+		"SELECT DISTINCT group_id FROM group_members WHERE user_id = ? ORDER BY group_id", duplicateID); err != nil {
+		return nil, fmt.Errorf("failed to list groups: %w", err)
+	}
+	if result.SharedGroups, err = queryStrings(ctx, tx, `
+		SELECT DISTINCT group_id FROM group_members WHERE user_id = ?
+		INTERSECT SELECT group_id FROM group_members WHERE user_id = ?
+		ORDER BY group_id`, duplicateID, primaryID); err != nil {
+		return nil, fmt.Errorf("failed to list groups: %w", err)
+	}
+
+	// A pair of users has one friendship, so the duplicate's friendships with
+	// the primary and with the primary's friends go, and addresses the primary
+	// already has linked stay the primary's.
+	dropped, err := tx.ExecContext(ctx, `
+		DELETE FROM friendships
+		WHERE (requester_id = ?1 AND (addressee_id = ?2 OR addressee_id IN (
+		          SELECT addressee_id FROM friendships WHERE requester_id = ?2
+		          UNION SELECT requester_id FROM friendships WHERE addressee_id = ?2)))
+		   OR (addressee_id = ?1 AND (requester_id = ?2 OR requester_id IN (
+		          SELECT addressee_id FROM friendships WHERE requester_id = ?2
+		          UNION SELECT requester_id FROM friendships WHERE addressee_id = ?2)))`,
+		duplicateID, primaryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to drop friendships: %w", err)
+	}
+	n, _ := dropped.RowsAffected()
+	result.DroppedFriendships = int(n)
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM user_emails
+		WHERE user_id = ? AND email IN (SELECT email FROM user_emails WHERE user_id = ?)`,
+		duplicateID, primaryID); err != nil {
+		return nil, fmt.Errorf("failed to drop linked emails: %w", err)
+	}
+
+	for _, ref := range userReferences {
+		query := fmt.Sprintf("UPDATE %s SET %s = ? WHERE %s = ?", ref.table, ref.column, ref.column)
+		if ref.where != "" {
+			query += " AND " + ref.where
+		}
+		res, err := tx.ExecContext(ctx, query, primaryID, duplicateID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to move %s.%s: %w", ref.table, ref.column, err)
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			result.Rows[ref.table+"."+ref.column] = int(n)
+		}
+	}
+
+	// The duplicate's address signs in to the primary from now on.
+	now := time.Now().Unix()
+	if _, err := tx.ExecContext(ctx, `
+		INSERT OR IGNORE INTO user_emails (user_id, email, verified, created_at, verified_at)
+		VALUES (?, ?, 1, ?, ?)`,
+		primaryID, models.NormalizeEmail(duplicateEmail), duplicateCreatedAt, now); err != nil {
+		return nil, fmt.Errorf("failed to link email: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM users WHERE id = ?", duplicateID); err != nil {
+		return nil, fmt.Errorf("failed to delete user: %w", err)
+	}
+	// The primary may have been flagged as the duplicate's duplicate.
+	if _, err := tx.ExecContext(ctx, "UPDATE users SET duplicate_of = '' WHERE id = ? AND duplicate_of = id", primaryID); err != nil {
+		return nil, fmt.Errorf("failed to clear duplicate flag: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx,
+		"INSERT INTO merged_users (user_id, merged_into, merged_at) VALUES (?, ?, ?)",
+		duplicateID, primaryID, now); err != nil {
+		return nil, fmt.Errorf("failed to record merge: %w", err)
+	}
+
+	before, _ := json.Marshal(map[string]string{"user_id": duplicateID})
+	after, _ := json.Marshal(map[string]any{"user_id": primaryID, "rows": result.Rows})
+	for _, groupID := range append([]string{""}, result.Groups...) {
+		if err := s.insertAuditEntry(ctx, tx, &models.AuditEntry{
+			Action:     models.AuditUpdate,
+			EntityType: auditEntityUser,
+			EntityID:   duplicateID,
+			GroupID:    groupID,
+			Before:     string(before),
+			After:      string(after),
+			CreatedAt:  now,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	if dryRun {
+		return result, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	logger.Warn("Merged user accounts", "primary_id", primaryID, "duplicate_id", duplicateID, "rows", result.Rows)
+	return result, nil
+}
+
+// MergedInto returns the account userID was merged into, or nil if it
+// wasn't merged.
+func (s *SQLiteStore) MergedInto(ctx context.Context, userID string) (*models.User, error) {
+	var mergedInto string
+	err := s.db.QueryRowContext(ctx, "SELECT merged_into FROM merged_users WHERE user_id = ?", userID).Scan(&mergedInto)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up merged user: %w", err)
+	}
+	return s.GetUserByID(ctx, mergedInto)
+}
+
+// queryStrings returns the single string column of a query's rows.
+func queryStrings(ctx context.Context, tx *sql.Tx, query string, args ...any) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	return out, rows.Err()
+}
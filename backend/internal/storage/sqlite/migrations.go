@@ -1,6 +1,11 @@
 package sqlite
 
-import "database/sql"
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/mmynk/splitwiser/internal/models"
+)
 
 // migrations contains the SQL statements to set up the database schema.
 // These run on startup to ensure tables exist.
@@ -12,13 +17,23 @@ CREATE TABLE IF NOT EXISTS users (
     display_name TEXT NOT NULL,
     password_hash TEXT,
     created_at INTEGER NOT NULL,
-    updated_at INTEGER NOT NULL
+    updated_at INTEGER NOT NULL,
+    email_lookup TEXT NOT NULL DEFAULT '',
+    email_lookup_version INTEGER NOT NULL DEFAULT 0,
+    duplicate_of TEXT NOT NULL DEFAULT ''
 );
 
 CREATE TABLE IF NOT EXISTS groups (
     id TEXT PRIMARY KEY,
     name TEXT NOT NULL,
-    created_at INTEGER NOT NULL
+    created_at INTEGER NOT NULL,
+    creator_id TEXT,
+    disable_auto_title INTEGER NOT NULL DEFAULT 0,
+    title_template TEXT,
+    default_payer_is_creator INTEGER NOT NULL DEFAULT 0,
+    rounding_mode TEXT NOT NULL DEFAULT '',
+    payer_rotation INTEGER NOT NULL DEFAULT 0,
+    currency TEXT NOT NULL DEFAULT ''
 );
 
 CREATE TABLE IF NOT EXISTS group_members (
@@ -29,6 +44,12 @@ CREATE TABLE IF NOT EXISTS group_members (
     FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE
 );
 
+-- Expense categories bills may be filed under, seeded by seedCategories.
+CREATE TABLE IF NOT EXISTS categories (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL
+);
+
 CREATE TABLE IF NOT EXISTS bills (
     id TEXT PRIMARY KEY,
     title TEXT NOT NULL,
@@ -38,6 +59,26 @@ CREATE TABLE IF NOT EXISTS bills (
     group_id TEXT,
     payer_id TEXT,
     creator_id TEXT,
+    tip REAL NOT NULL DEFAULT 0,
+    tip_split_mode TEXT NOT NULL DEFAULT 'proportional',
+    split_type TEXT NOT NULL DEFAULT 'equal',
+    needs_assignment INTEGER NOT NULL DEFAULT 0,
+    discount REAL NOT NULL DEFAULT 0,
+    discount_type TEXT NOT NULL DEFAULT 'amount',
+    currency TEXT NOT NULL DEFAULT '',
+    remainder_mode TEXT NOT NULL DEFAULT 'equal',
+    tax_inclusive INTEGER NOT NULL DEFAULT 0,
+    tax_rate REAL NOT NULL DEFAULT 0,
+    rounding_mode TEXT NOT NULL DEFAULT '',
+    deleted_at INTEGER NOT NULL DEFAULT 0,
+    category_id TEXT REFERENCES categories(id),
+    archived_at INTEGER NOT NULL DEFAULT 0,
+    exchange_rate REAL NOT NULL DEFAULT 0,
+    locked_at INTEGER NOT NULL DEFAULT 0,
+    note TEXT NOT NULL DEFAULT '',
+    merchant_name TEXT NOT NULL DEFAULT '',
+    location TEXT NOT NULL DEFAULT '',
+    bill_date INTEGER NOT NULL DEFAULT 0,
     FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE SET NULL
 );
 
@@ -46,12 +87,33 @@ CREATE TABLE IF NOT EXISTS items (
     bill_id TEXT NOT NULL,
     description TEXT NOT NULL,
     amount REAL NOT NULL,
+    discount REAL NOT NULL DEFAULT 0,
+    quantity REAL NOT NULL DEFAULT 0,
+    unit_price REAL NOT NULL DEFAULT 0,
+    category TEXT NOT NULL DEFAULT '',
+    owner TEXT,
+    origin_source TEXT,
+    origin_description TEXT NOT NULL DEFAULT '',
+    origin_amount REAL NOT NULL DEFAULT 0,
+    origin_quantity REAL NOT NULL DEFAULT 0,
+    origin_unit_price REAL NOT NULL DEFAULT 0,
+    FOREIGN KEY (bill_id) REFERENCES bills(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS fees (
+    id TEXT PRIMARY KEY,
+    bill_id TEXT NOT NULL,
+    description TEXT NOT NULL,
+    amount REAL NOT NULL,
+    split_mode TEXT NOT NULL DEFAULT 'proportional',
     FOREIGN KEY (bill_id) REFERENCES bills(id) ON DELETE CASCADE
 );
 
 CREATE TABLE IF NOT EXISTS item_assignments (
     item_id TEXT NOT NULL,
     participant TEXT NOT NULL,
+    shares REAL NOT NULL DEFAULT 0,
+    units REAL NOT NULL DEFAULT 0,
     PRIMARY KEY (item_id, participant),
     FOREIGN KEY (item_id) REFERENCES items(id) ON DELETE CASCADE
 );
@@ -60,6 +122,10 @@ CREATE TABLE IF NOT EXISTS participants (
     bill_id TEXT NOT NULL,
     name TEXT NOT NULL,
     user_id TEXT,
+    shares REAL NOT NULL DEFAULT 0,
+    amount REAL NOT NULL DEFAULT 0,
+    covered TEXT NOT NULL DEFAULT 'none',
+    adjustment REAL NOT NULL DEFAULT 0,
     PRIMARY KEY (bill_id, name),
     FOREIGN KEY (bill_id) REFERENCES bills(id) ON DELETE CASCADE
 );
@@ -73,17 +139,186 @@ CREATE TABLE IF NOT EXISTS settlements (
     created_at INTEGER NOT NULL,
     created_by TEXT NOT NULL,
     note TEXT,
+    plan_id TEXT,
+    FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS settlement_plans (
+    id TEXT PRIMARY KEY,
+    group_id TEXT NOT NULL,
+    from_user_id TEXT NOT NULL,
+    to_user_id TEXT NOT NULL,
+    total REAL NOT NULL,
+    installment_amount REAL NOT NULL,
+    cadence TEXT NOT NULL,
+    start_at INTEGER NOT NULL,
+    created_at INTEGER NOT NULL,
+    created_by TEXT NOT NULL,
+    note TEXT,
+    FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_settlement_plans_group_id ON settlement_plans(group_id);
+
+CREATE TABLE IF NOT EXISTS group_webhooks (
+    id TEXT PRIMARY KEY,
+    group_id TEXT NOT NULL,
+    url TEXT NOT NULL,
+    threshold REAL NOT NULL,
+    created_at INTEGER NOT NULL,
+    created_by TEXT NOT NULL,
     FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE
 );
+CREATE INDEX IF NOT EXISTS idx_group_webhooks_group_id ON group_webhooks(group_id);
+
+CREATE TABLE IF NOT EXISTS audit_log (
+    id TEXT PRIMARY KEY,
+    actor_id TEXT NOT NULL,
+    action TEXT NOT NULL,
+    entity_type TEXT NOT NULL,
+    entity_id TEXT NOT NULL,
+    group_id TEXT NOT NULL,
+    before_json TEXT NOT NULL,
+    after_json TEXT NOT NULL,
+    created_at INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_audit_log_group_id ON audit_log(group_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_log_entity_id ON audit_log(entity_id);
+
+CREATE TABLE IF NOT EXISTS account_webhooks (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    created_at INTEGER NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_account_webhooks_user_id ON account_webhooks(user_id);
+
+CREATE TABLE IF NOT EXISTS bill_views (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    group_id TEXT NOT NULL DEFAULT '',
+    ungrouped INTEGER NOT NULL DEFAULT 0,
+    last_days INTEGER NOT NULL DEFAULT 0,
+    paid_by_me INTEGER NOT NULL DEFAULT 0,
+    category_id TEXT NOT NULL DEFAULT '',
+    pinned INTEGER NOT NULL DEFAULT 0,
+    created_at INTEGER NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_bill_views_user_id ON bill_views(user_id);
+
+CREATE TABLE IF NOT EXISTS attachments (
+    id TEXT PRIMARY KEY,
+    bill_id TEXT NOT NULL,
+    uploader_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size INTEGER NOT NULL,
+    sha256 TEXT NOT NULL,
+    blob_key TEXT NOT NULL,
+    created_at INTEGER NOT NULL,
+    FOREIGN KEY (bill_id) REFERENCES bills(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_attachments_bill_id ON attachments(bill_id);
+CREATE INDEX IF NOT EXISTS idx_attachments_uploader_id ON attachments(uploader_id);
+
+CREATE TABLE IF NOT EXISTS comments (
+    id TEXT PRIMARY KEY,
+    bill_id TEXT NOT NULL,
+    author_id TEXT NOT NULL,
+    body TEXT NOT NULL,
+    created_at INTEGER NOT NULL,
+    FOREIGN KEY (bill_id) REFERENCES bills(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_comments_bill_id ON comments(bill_id, created_at);
+
+CREATE TABLE IF NOT EXISTS bill_revisions (
+    id TEXT PRIMARY KEY,
+    bill_id TEXT NOT NULL,
+    revision INTEGER NOT NULL,
+    editor_id TEXT NOT NULL DEFAULT '',
+    edited_at INTEGER NOT NULL,
+    bill_json TEXT NOT NULL,
+    UNIQUE (bill_id, revision),
+    FOREIGN KEY (bill_id) REFERENCES bills(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS group_stats_tokens (
+    group_id TEXT PRIMARY KEY,
+    token_hash TEXT NOT NULL UNIQUE,
+    created_at INTEGER NOT NULL,
+    created_by TEXT NOT NULL,
+    FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS bill_shares (
+    bill_id TEXT NOT NULL,
+    participant TEXT NOT NULL,
+    paid_at INTEGER NOT NULL,
+    marked_by TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (bill_id, participant),
+    FOREIGN KEY (bill_id) REFERENCES bills(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS bill_share_tokens (
+    bill_id TEXT PRIMARY KEY,
+    token_hash TEXT NOT NULL UNIQUE,
+    created_at INTEGER NOT NULL,
+    created_by TEXT NOT NULL,
+    FOREIGN KEY (bill_id) REFERENCES bills(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS group_spending_caps (
+    group_id TEXT NOT NULL,
+    member_name TEXT NOT NULL,
+    monthly_cap REAL NOT NULL,
+    PRIMARY KEY (group_id, member_name),
+    FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS spending_cap_alerts (
+    bill_id TEXT NOT NULL,
+    group_id TEXT NOT NULL,
+    member_name TEXT NOT NULL,
+    month TEXT NOT NULL,
+    spent REAL NOT NULL,
+    cap REAL NOT NULL,
+    created_at INTEGER NOT NULL,
+    PRIMARY KEY (bill_id, member_name),
+    FOREIGN KEY (bill_id) REFERENCES bills(id) ON DELETE CASCADE,
+    FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_spending_cap_alerts_group_month ON spending_cap_alerts(group_id, month);
+
+CREATE TABLE IF NOT EXISTS imported_transactions (
+    group_id TEXT NOT NULL,
+    transaction_id TEXT NOT NULL,
+    bill_id TEXT NOT NULL,
+    created_at INTEGER NOT NULL,
+    PRIMARY KEY (group_id, transaction_id),
+    FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS group_webhook_breaches (
+    webhook_id TEXT NOT NULL,
+    member TEXT NOT NULL,
+    PRIMARY KEY (webhook_id, member),
+    FOREIGN KEY (webhook_id) REFERENCES group_webhooks(id) ON DELETE CASCADE
+);
 
 CREATE INDEX IF NOT EXISTS idx_items_bill_id ON items(bill_id);
+CREATE INDEX IF NOT EXISTS idx_fees_bill_id ON fees(bill_id);
 CREATE INDEX IF NOT EXISTS idx_item_assignments_item_id ON item_assignments(item_id);
 CREATE INDEX IF NOT EXISTS idx_participants_bill_id ON participants(bill_id);
 CREATE INDEX IF NOT EXISTS idx_participants_user_id ON participants(user_id);
 CREATE INDEX IF NOT EXISTS idx_group_members_group_id ON group_members(group_id);
 CREATE INDEX IF NOT EXISTS idx_group_members_user_id ON group_members(user_id);
 CREATE INDEX IF NOT EXISTS idx_bills_group_id ON bills(group_id);
+CREATE INDEX IF NOT EXISTS idx_bills_group_created_at ON bills(group_id, created_at);
 CREATE INDEX IF NOT EXISTS idx_settlements_group_id ON settlements(group_id);
+CREATE INDEX IF NOT EXISTS idx_settlements_group_created_at ON settlements(group_id, created_at);
 CREATE INDEX IF NOT EXISTS idx_settlements_user ON settlements(from_user_id, to_user_id) WHERE group_id IS NULL;
 
 CREATE TABLE IF NOT EXISTS friendships (
@@ -99,15 +334,173 @@ CREATE TABLE IF NOT EXISTS friendships (
 );
 CREATE INDEX IF NOT EXISTS idx_friendships_requester ON friendships(requester_id);
 CREATE INDEX IF NOT EXISTS idx_friendships_addressee ON friendships(addressee_id);
+
+CREATE TABLE IF NOT EXISTS user_emails (
+    user_id TEXT NOT NULL,
+    email TEXT NOT NULL,
+    verified INTEGER NOT NULL DEFAULT 0,
+    token_hash TEXT,
+    token_expires_at INTEGER,
+    created_at INTEGER NOT NULL,
+    verified_at INTEGER,
+    PRIMARY KEY (user_id, email),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_emails_verified ON user_emails(email) WHERE verified = 1;
+
+CREATE TABLE IF NOT EXISTS invitations (
+    token_hash TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    expires_at INTEGER NOT NULL,
+    created_at INTEGER NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_invitations_user_id ON invitations(user_id);
+
+-- Accounts merged into another by MergeUsers, so their sessions follow.
+CREATE TABLE IF NOT EXISTS merged_users (
+    user_id TEXT PRIMARY KEY,
+    merged_into TEXT NOT NULL,
+    merged_at INTEGER NOT NULL
+);
+
+-- Full-text index of bills, one row per bill, kept current by indexBill.
+CREATE VIRTUAL TABLE IF NOT EXISTS bills_fts USING fts5(
+    bill_id UNINDEXED,
+    title,
+    items,
+    participants,
+    tokenize = 'unicode61 remove_diacritics 2'
+);
 `
 
+// addedColumns lists columns introduced after a table was first created.
+// CREATE TABLE IF NOT EXISTS leaves existing tables untouched, so these are
+// added with ALTER TABLE on databases that predate them.
+var addedColumns = []struct {
+	table, column, definition string
+}{
+	{"bills", "tip", "REAL NOT NULL DEFAULT 0"},
+	{"bills", "tip_split_mode", "TEXT NOT NULL DEFAULT 'proportional'"},
+	{"groups", "creator_id", "TEXT"},
+	{"bills", "split_type", "TEXT NOT NULL DEFAULT 'equal'"},
+	{"participants", "shares", "REAL NOT NULL DEFAULT 0"},
+	{"item_assignments", "shares", "REAL NOT NULL DEFAULT 0"},
+	{"participants", "amount", "REAL NOT NULL DEFAULT 0"},
+	{"bills", "needs_assignment", "INTEGER NOT NULL DEFAULT 0"},
+	{"groups", "disable_auto_title", "INTEGER NOT NULL DEFAULT 0"},
+	{"groups", "title_template", "TEXT"},
+	{"groups", "default_payer_is_creator", "INTEGER NOT NULL DEFAULT 0"},
+	{"bills", "discount", "REAL NOT NULL DEFAULT 0"},
+	{"bills", "discount_type", "TEXT NOT NULL DEFAULT 'amount'"},
+	{"bills", "currency", "TEXT NOT NULL DEFAULT ''"},
+	{"items", "discount", "REAL NOT NULL DEFAULT 0"},
+	{"items", "quantity", "REAL NOT NULL DEFAULT 0"},
+	{"items", "unit_price", "REAL NOT NULL DEFAULT 0"},
+	{"item_assignments", "units", "REAL NOT NULL DEFAULT 0"},
+	{"participants", "covered", "TEXT NOT NULL DEFAULT 'none'"},
+	{"settlements", "plan_id", "TEXT"},
+	{"participants", "adjustment", "REAL NOT NULL DEFAULT 0"},
+	{"bills", "remainder_mode", "TEXT NOT NULL DEFAULT 'equal'"},
+	{"bills", "tax_inclusive", "INTEGER NOT NULL DEFAULT 0"},
+	{"bills", "tax_rate", "REAL NOT NULL DEFAULT 0"},
+	{"bills", "rounding_mode", "TEXT NOT NULL DEFAULT ''"},
+	{"bills", "deleted_at", "INTEGER NOT NULL DEFAULT 0"},
+	{"groups", "rounding_mode", "TEXT NOT NULL DEFAULT ''"},
+	{"groups", "payer_rotation", "INTEGER NOT NULL DEFAULT 0"},
+	{"items", "category", "TEXT NOT NULL DEFAULT ''"},
+	{"items", "owner", "TEXT"},
+	{"items", "origin_source", "TEXT"},
+	{"items", "origin_description", "TEXT NOT NULL DEFAULT ''"},
+	{"items", "origin_amount", "REAL NOT NULL DEFAULT 0"},
+	{"items", "origin_quantity", "REAL NOT NULL DEFAULT 0"},
+	{"items", "origin_unit_price", "REAL NOT NULL DEFAULT 0"},
+	{"bills", "category_id", "TEXT REFERENCES categories(id)"},
+	{"bills", "archived_at", "INTEGER NOT NULL DEFAULT 0"},
+	{"users", "email_lookup", "TEXT NOT NULL DEFAULT ''"},
+	{"bills", "exchange_rate", "REAL NOT NULL DEFAULT 0"},
+	{"groups", "currency", "TEXT NOT NULL DEFAULT ''"},
+	{"users", "email_lookup_version", "INTEGER NOT NULL DEFAULT 0"},
+	{"users", "duplicate_of", "TEXT NOT NULL DEFAULT ''"},
+	{"bills", "locked_at", "INTEGER NOT NULL DEFAULT 0"},
+	{"bills", "note", "TEXT NOT NULL DEFAULT ''"},
+	{"bills", "merchant_name", "TEXT NOT NULL DEFAULT ''"},
+	{"bills", "location", "TEXT NOT NULL DEFAULT ''"},
+	{"bills", "bill_date", "INTEGER NOT NULL DEFAULT 0"},
+}
+
 // runMigrations executes the schema setup.
 func runMigrations(db *sql.DB) error {
 	if err := migrateSettlementsNullableGroupID(db); err != nil {
 		return err
 	}
-	_, err := db.Exec(schema)
-	return err
+	if err := checkSchema(db, false); err != nil {
+		return err
+	}
+	if _, err := db.Exec(schema); err != nil {
+		return err
+	}
+	if err := addMissingColumns(db); err != nil {
+		return err
+	}
+	if err := checkSchema(db, true); err != nil {
+		return err
+	}
+	if err := seedCategories(db); err != nil {
+		return err
+	}
+	return indexUnindexedBills(db)
+}
+
+// standardCategories are the expense categories every database has. Their
+// IDs are stable, so clients can rely on them.
+var standardCategories = []models.Category{
+	{ID: "food", Name: "Food & drink"},
+	{ID: "groceries", Name: "Groceries"},
+	{ID: "rent", Name: "Rent"},
+	{ID: "utilities", Name: "Utilities"},
+	{ID: "travel", Name: "Travel"},
+	{ID: "transport", Name: "Transport"},
+	{ID: "entertainment", Name: "Entertainment"},
+	{ID: "other", Name: "Other"},
+}
+
+// seedCategories adds the standardCategories to a database that lacks them.
+// It counts first, so opening a seeded database takes no write lock.
+func seedCategories(db *sql.DB) error {
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM categories").Scan(&count); err != nil {
+		return fmt.Errorf("failed to count categories: %w", err)
+	}
+	if count >= len(standardCategories) {
+		return nil
+	}
+	for _, c := range standardCategories {
+		if _, err := db.Exec("INSERT OR IGNORE INTO categories (id, name) VALUES (?, ?)", c.ID, c.Name); err != nil {
+			return fmt.Errorf("failed to seed category %s: %w", c.ID, err)
+		}
+	}
+	return nil
+}
+
+// addMissingColumns applies addedColumns to tables that don't have them yet.
+func addMissingColumns(db *sql.DB) error {
+	for _, c := range addedColumns {
+		var count int
+		err := db.QueryRow(
+			`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, c.table, c.column,
+		).Scan(&count)
+		if err != nil {
+			return fmt.Errorf("failed to inspect %s.%s: %w", c.table, c.column, err)
+		}
+		if count > 0 {
+			continue
+		}
+		if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", c.table, c.column, c.definition)); err != nil {
+			return fmt.Errorf("failed to add column %s.%s: %w", c.table, c.column, err)
+		}
+	}
+	return nil
 }
 
 // migrateSettlementsNullableGroupID makes settlements.group_id nullable on existing databases.
@@ -0,0 +1,98 @@
+package sqlite
+
+import "time"
+
+// Defaults applied by New unless overridden.
+const (
+	DefaultJournalMode = "WAL"
+	DefaultBusyTimeout = 5 * time.Second
+	DefaultBusyRetries = 3
+)
+
+// Option configures a SQLiteStore.
+type Option func(*options)
+
+type options struct {
+	queryHook       QueryHook
+	journalMode     string
+	busyTimeout     time.Duration
+	busyRetries     int
+	maxOpenConns    int
+	maxIdleConns    int
+	connMaxLifetime time.Duration
+	plusTagFolding  *bool
+
+	encryptionKey []byte
+	previousKeys  [][]byte
+}
+
+func defaultOptions() options {
+	return options{
+		journalMode: DefaultJournalMode,
+		busyTimeout: DefaultBusyTimeout,
+		busyRetries: DefaultBusyRetries,
+	}
+}
+
+// WithQueryHook reports every statement the store runs to hook.
+func WithQueryHook(hook QueryHook) Option {
+	return func(o *options) { o.queryHook = hook }
+}
+
+// WithJournalMode sets the journal mode, e.g. "DELETE" for databases on
+// network filesystems where WAL's shared memory doesn't work. Empty keeps WAL.
+func WithJournalMode(mode string) Option {
+	return func(o *options) {
+		if mode != "" {
+			o.journalMode = mode
+		}
+	}
+}
+
+// WithBusyTimeout sets how long a statement waits on another connection's
+// lock before failing with SQLITE_BUSY.
+func WithBusyTimeout(d time.Duration) Option {
+	return func(o *options) { o.busyTimeout = d }
+}
+
+// WithBusyRetries sets how many more times a statement that still failed
+// with SQLITE_BUSY or SQLITE_LOCKED after the busy timeout is retried.
+func WithBusyRetries(n int) Option {
+	return func(o *options) { o.busyRetries = n }
+}
+
+// WithMaxOpenConns limits the connection pool; zero means unlimited.
+func WithMaxOpenConns(n int) Option {
+	return func(o *options) { o.maxOpenConns = n }
+}
+
+// WithMaxIdleConns sets how many idle connections the pool keeps.
+// Zero keeps database/sql's default.
+func WithMaxIdleConns(n int) Option {
+	return func(o *options) { o.maxIdleConns = n }
+}
+
+// WithConnMaxLifetime closes pooled connections after d; zero keeps them.
+func WithConnMaxLifetime(d time.Duration) Option {
+	return func(o *options) { o.connMaxLifetime = d }
+}
+
+// WithEncryption encrypts user emails, settlement notes, bill comments and
+// audit log snapshots with key (KeySize bytes, AES-256-GCM) before storing
+// them, and decrypts values encrypted with key or any of previous on read.
+// Rows stored before encryption was turned on are read as they are until
+// RotateEncryptionKey encrypts them.
+func WithEncryption(key []byte, previous ...[]byte) Option {
+	return func(o *options) {
+		o.encryptionKey = key
+		o.previousKeys = previous
+	}
+}
+
+// WithPlusTagFolding sets whether email lookups ignore a "+tag" after the
+// local part, so "ann+bills@x.com" finds ann@x.com's account. Changing it
+// redoes every user's lookup when the store opens. Without it, the store
+// keeps the setting the database's lookups were made with.
+func WithPlusTagFolding(fold bool) Option {
+	return func(o *options) { o.plusTagFolding = &fold }
+}
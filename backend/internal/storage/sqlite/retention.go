@@ -0,0 +1,27 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ArchiveBills archives the live bills created before the Unix time before,
+// leaving out those still waiting for their items to be assigned, and returns
+// how many it archived. Archived bills stay in place and keep counting toward
+// balances; listings only leave them out when asked to.
+func (s *SQLiteStore) ArchiveBills(ctx context.Context, before int64) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE bills SET archived_at = ?
+		WHERE archived_at = 0 AND deleted_at = 0 AND needs_assignment = 0 AND created_at < ?`,
+		time.Now().Unix(), before,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to archive bills: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count archived bills: %w", err)
+	}
+	return n, nil
+}
@@ -0,0 +1,81 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mmynk/splitwiser/internal/models"
+	"github.com/mmynk/splitwiser/internal/storage"
+)
+
+// insertBillRevision keeps previous, the version of a bill an update is
+// replacing, as the bill's next revision.
+func insertBillRevision(ctx context.Context, tx *sql.Tx, previous *models.Bill, editorID string) error {
+	snapshot, err := json.Marshal(previous)
+	if err != nil {
+		return fmt.Errorf("failed to marshal bill revision: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO bill_revisions (id, bill_id, revision, editor_id, edited_at, bill_json)
+		VALUES (?, ?, (SELECT COALESCE(MAX(revision), 0) + 1 FROM bill_revisions WHERE bill_id = ?), ?, ?, ?)`,
+		uuid.New().String(), previous.ID, previous.ID, editorID, time.Now().Unix(), string(snapshot))
+	if err != nil {
+		return fmt.Errorf("failed to insert bill revision: %w", err)
+	}
+	return nil
+}
+
+const revisionColumns = "id, bill_id, revision, editor_id, edited_at, bill_json"
+
+// scanRevision scans a row selected with revisionColumns into a revision.
+func scanRevision(row rowScanner) (*models.BillRevision, error) {
+	r := &models.BillRevision{}
+	var snapshot string
+	if err := row.Scan(&r.ID, &r.BillID, &r.Revision, &r.EditorID, &r.EditedAt, &snapshot); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(snapshot), &r.Bill); err != nil {
+		return nil, fmt.Errorf("revision %d of bill %s is unreadable: %w", r.Revision, r.BillID, err)
+	}
+	return r, nil
+}
+
+// ListBillRevisions retrieves the versions of a bill its updates replaced,
+// oldest first.
+func (s *SQLiteStore) ListBillRevisions(ctx context.Context, billID string) ([]*models.BillRevision, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+revisionColumns+" FROM bill_revisions WHERE bill_id = ? ORDER BY revision", billID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bill revisions: %w", err)
+	}
+	defer rows.Close()
+
+	var revisions []*models.BillRevision
+	for rows.Next() {
+		revision, err := scanRevision(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan bill revision: %w", err)
+		}
+		revisions = append(revisions, revision)
+	}
+	return revisions, rows.Err()
+}
+
+// GetBillRevision retrieves one revision of a bill by its number.
+func (s *SQLiteStore) GetBillRevision(ctx context.Context, billID string, revision int) (*models.BillRevision, error) {
+	r, err := scanRevision(s.db.QueryRowContext(ctx,
+		"SELECT "+revisionColumns+" FROM bill_revisions WHERE bill_id = ? AND revision = ?", billID, revision,
+	))
+	if err == sql.ErrNoRows {
+		return nil, storage.Errorf(storage.ErrNotFound, "bill %s has no revision %d", billID, revision)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bill revision: %w", err)
+	}
+	return r, nil
+}
@@ -0,0 +1,146 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// SchemaDriftError reports how a database's tables differ from the schema
+// the store expects, one difference per line.
+type SchemaDriftError struct {
+	Diffs []string
+}
+
+func (e *SchemaDriftError) Error() string {
+	return "database schema has drifted from the expected schema:\n  " + strings.Join(e.Diffs, "\n  ")
+}
+
+// columnInfo is a column as described by pragma_table_info.
+type columnInfo struct {
+	name    string
+	typ     string
+	notNull bool
+	hasDflt bool
+	pk      int
+}
+
+func (c columnInfo) String() string {
+	def := c.name + " " + c.typ
+	if c.notNull {
+		def += " NOT NULL"
+	}
+	if c.pk > 0 {
+		def += " PRIMARY KEY"
+	}
+	return def
+}
+
+// checkSchema compares the database against the schema a fresh database
+// gets, so a table that migrations can't repair (say one missing a column
+// created with it) fails with a readable list of differences instead of an
+// opaque error in some later statement. Before migrating (migrated false),
+// missing tables and columns that the migrations add are expected. Extra
+// tables and columns are allowed unless they are NOT NULL without a default,
+// which would make the store's inserts fail.
+func checkSchema(db *sql.DB, migrated bool) error {
+	ref, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		return fmt.Errorf("failed to open reference database: %w", err)
+	}
+	defer ref.Close()
+	ref.SetMaxOpenConns(1) // every :memory: connection is its own database
+	if _, err := ref.Exec(schema); err != nil {
+		return fmt.Errorf("failed to build reference schema: %w", err)
+	}
+
+	want, err := tableColumns(ref)
+	if err != nil {
+		return err
+	}
+	got, err := tableColumns(db)
+	if err != nil {
+		return err
+	}
+
+	var diffs []string
+	for _, table := range slices.Sorted(maps.Keys(want)) {
+		live, ok := got[table]
+		if !ok {
+			if migrated {
+				diffs = append(diffs, fmt.Sprintf("table %s is missing", table))
+			}
+			continue
+		}
+		expected := make(map[string]bool, len(want[table]))
+		for _, w := range want[table] {
+			expected[w.name] = true
+			c, ok := findColumn(live, w.name)
+			switch {
+			case !ok && !migrated && isAddedColumn(table, w.name):
+				// addMissingColumns adds it
+			case !ok:
+				diffs = append(diffs, fmt.Sprintf("%s: missing column %s", table, w))
+			case !strings.EqualFold(c.typ, w.typ) || c.notNull != w.notNull || (c.pk > 0) != (w.pk > 0):
+				diffs = append(diffs, fmt.Sprintf("%s.%s: is %s, want %s", table, w.name, c, w))
+			}
+		}
+		for _, c := range live {
+			if !expected[c.name] && c.notNull && !c.hasDflt {
+				diffs = append(diffs, fmt.Sprintf("%s: unexpected column %s has no default", table, c))
+			}
+		}
+	}
+	if len(diffs) > 0 {
+		return &SchemaDriftError{Diffs: diffs}
+	}
+	return nil
+}
+
+// tableColumns lists the columns of every table in db.
+func tableColumns(db *sql.DB) (map[string][]columnInfo, error) {
+	rows, err := db.Query(`
+		SELECT m.name, p.name, p.type, p."notnull", p.dflt_value IS NOT NULL, p.pk
+		FROM sqlite_master m JOIN pragma_table_info(m.name) p
+		WHERE m.type = 'table' AND m.name NOT LIKE 'sqlite_%'
+		ORDER BY m.name, p.cid`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect schema: %w", err)
+	}
+	defer rows.Close()
+
+	tables := make(map[string][]columnInfo)
+	for rows.Next() {
+		var table string
+		var c columnInfo
+		if err := rows.Scan(&table, &c.name, &c.typ, &c.notNull, &c.hasDflt, &c.pk); err != nil {
+			return nil, fmt.Errorf("failed to scan column: %w", err)
+		}
+		tables[table] = append(tables[table], c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to inspect schema: %w", err)
+	}
+	return tables, nil
+}
+
+func findColumn(columns []columnInfo, name string) (columnInfo, bool) {
+	for _, c := range columns {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return columnInfo{}, false
+}
+
+// isAddedColumn reports whether addMissingColumns adds the column.
+func isAddedColumn(table, column string) bool {
+	for _, c := range addedColumns {
+		if c.table == table && c.column == column {
+			return true
+		}
+	}
+	return false
+}
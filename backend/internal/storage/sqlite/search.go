@@ -0,0 +1,114 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/mmynk/splitwiser/internal/models"
+	"github.com/mmynk/splitwiser/internal/storage"
+)
+
+// billSearchDocs selects the bills_fts row for each bill: its title, its item
+// descriptions and its participants' names.
+const billSearchDocs = `
+	SELECT b.id, b.title,
+	       COALESCE((SELECT group_concat(i.description, ' ') FROM items i WHERE i.bill_id = b.id), ''),
+	       COALESCE((SELECT group_concat(p.name, ' ') FROM participants p WHERE p.bill_id = b.id), '')
+	FROM bills b`
+
+// indexBill rewrites a bill's search index row from its stored title, items
+// and participants. Call it in the transaction that changed them.
+func indexBill(ctx context.Context, tx *sql.Tx, billID string) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM bills_fts WHERE bill_id = ?", billID); err != nil {
+		return fmt.Errorf("failed to unindex bill: %w", err)
+	}
+	_, err := tx.ExecContext(ctx,
+		"INSERT INTO bills_fts (bill_id, title, items, participants)"+billSearchDocs+" WHERE b.id = ?",
+		billID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to index bill: %w", err)
+	}
+	return nil
+}
+
+// indexUnindexedBills adds bills written before the search index existed.
+// It only writes when there are some, so opening an up-to-date database
+// doesn't wait on other writers.
+func indexUnindexedBills(db *sql.DB) error {
+	var missing bool
+	err := db.QueryRow("SELECT EXISTS (SELECT 1 FROM bills WHERE id NOT IN (SELECT bill_id FROM bills_fts))").Scan(&missing)
+	if err != nil {
+		return fmt.Errorf("failed to check the search index: %w", err)
+	}
+	if !missing {
+		return nil
+	}
+	_, err = db.Exec("INSERT INTO bills_fts (bill_id, title, items, participants)" + billSearchDocs +
+		" WHERE b.id NOT IN (SELECT bill_id FROM bills_fts)")
+	if err != nil {
+		return fmt.Errorf("failed to index existing bills: %w", err)
+	}
+	return nil
+}
+
+// ftsQuery turns free text into an FTS5 query matching bills that contain
+// every word, each as a prefix, so "pizz marg" finds "Margherita pizza".
+// Punctuation only separates words; FTS5 operators in the text are not
+// interpreted. Returns "" if the text has no words.
+func ftsQuery(text string) string {
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	terms := make([]string, len(words))
+	for i, w := range words {
+		terms[i] = `"` + w + `"*`
+	}
+	return strings.Join(terms, " ")
+}
+
+// SearchBills finds the live bills matching filter whose title, item
+// descriptions or participant names contain every word of query, among the
+// bills the user created or participates in. Best matches come first.
+func (s *SQLiteStore) SearchBills(ctx context.Context, userID, query string, filter storage.BillFilter) ([]*models.Bill, error) {
+	match := ftsQuery(query)
+	if match == "" {
+		return nil, nil
+	}
+
+	q := "SELECT " + billColumns + ` FROM bills
+		JOIN (SELECT bill_id, rank FROM bills_fts WHERE bills_fts MATCH ?) m ON m.bill_id = bills.id
+		WHERE deleted_at = 0
+		  AND (creator_id = ? OR id IN (SELECT p.bill_id FROM participants p WHERE p.user_id = ?))`
+	args := []any{match, userID, userID}
+	q, args = billFilterSQL(q, args, filter)
+	q += " ORDER BY m.rank, created_at DESC, id LIMIT ? OFFSET ?"
+	args = append(args, pageLimit(filter.Page), filter.Page.Offset)
+
+	rows, err := s.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search bills: %w", err)
+	}
+	defer rows.Close()
+
+	var bills []*models.Bill
+	for rows.Next() {
+		bill, err := scanBill(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan bill: %w", err)
+		}
+		bills = append(bills, bill)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate bills: %w", err)
+	}
+	rows.Close()
+
+	if err := loadParticipants(ctx, s.db, bills); err != nil {
+		return nil, err
+	}
+	return bills, nil
+}
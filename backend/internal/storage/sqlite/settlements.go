@@ -8,6 +8,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/mmynk/splitwiser/internal/models"
+	"github.com/mmynk/splitwiser/internal/storage"
 )
 
 // CreateSettlement persists a new settlement to the database.
@@ -19,6 +20,12 @@ func (s *SQLiteStore) CreateSettlement(ctx context.Context, settlement *models.S
 		settlement.CreatedAt = time.Now().Unix()
 	}
 
+	return s.insertSettlement(ctx, s.db, settlement)
+}
+
+// insertSettlement inserts a settlement row, encrypting the note if the
+// store encrypts.
+func (s *SQLiteStore) insertSettlement(ctx context.Context, ex execer, settlement *models.Settlement) error {
 	var groupID interface{}
 	if settlement.GroupID != nil {
 		groupID = *settlement.GroupID
@@ -26,14 +33,18 @@ func (s *SQLiteStore) CreateSettlement(ctx context.Context, settlement *models.S
 
 	var note interface{}
 	if settlement.Note != "" {
-		note = settlement.Note
+		sealed, err := s.cipher.encrypt(columnSettlementNote, settlement.Note)
+		if err != nil {
+			return err
+		}
+		note = sealed
 	}
 
-	_, err := s.db.ExecContext(ctx,
-		`INSERT INTO settlements (id, group_id, from_user_id, to_user_id, amount, created_at, created_by, note)
-		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+	_, err := ex.ExecContext(ctx,
+		`INSERT INTO settlements (id, group_id, from_user_id, to_user_id, amount, created_at, created_by, note, plan_id)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		settlement.ID, groupID, settlement.FromUserID, settlement.ToUserID,
-		settlement.Amount, settlement.CreatedAt, settlement.CreatedBy, note,
+		settlement.Amount, settlement.CreatedAt, settlement.CreatedBy, note, nullString(settlement.PlanID),
 	)
 	if err != nil {
 		return fmt.Errorf("failed to insert settlement: %w", err)
@@ -45,18 +56,17 @@ func (s *SQLiteStore) CreateSettlement(ctx context.Context, settlement *models.S
 // GetSettlement retrieves a settlement by ID.
 func (s *SQLiteStore) GetSettlement(ctx context.Context, settlementID string) (*models.Settlement, error) {
 	settlement := &models.Settlement{}
-	var groupID sql.NullString
-	var note sql.NullString
+	var groupID, note, planID sql.NullString
 
 	err := s.db.QueryRowContext(ctx,
-		`SELECT id, group_id, from_user_id, to_user_id, amount, created_at, created_by, note
+		`SELECT id, group_id, from_user_id, to_user_id, amount, created_at, created_by, note, plan_id
 		 FROM settlements WHERE id = ?`,
 		settlementID,
 	).Scan(&settlement.ID, &groupID, &settlement.FromUserID, &settlement.ToUserID,
-		&settlement.Amount, &settlement.CreatedAt, &settlement.CreatedBy, &note)
+		&settlement.Amount, &settlement.CreatedAt, &settlement.CreatedBy, &note, &planID)
 
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("settlement not found: %s", settlementID)
+		return nil, storage.Errorf(storage.ErrNotFound, "settlement not found: %s", settlementID)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get settlement: %w", err)
@@ -65,33 +75,42 @@ func (s *SQLiteStore) GetSettlement(ctx context.Context, settlementID string) (*
 	if groupID.Valid {
 		settlement.GroupID = &groupID.String
 	}
+	if note, err = s.cipher.decryptNull(columnSettlementNote, note); err != nil {
+		return nil, err
+	}
 	if note.Valid {
 		settlement.Note = note.String
 	}
+	settlement.PlanID = planID.String
 
 	return settlement, nil
 }
 
-// ListSettlementsByGroup retrieves all settlements for a group.
-func (s *SQLiteStore) ListSettlementsByGroup(ctx context.Context, groupID string) ([]*models.Settlement, error) {
-	rows, err := s.db.QueryContext(ctx,
-		`SELECT id, group_id, from_user_id, to_user_id, amount, created_at, created_by, note
-		 FROM settlements WHERE group_id = ? ORDER BY created_at DESC`,
-		groupID,
-	)
+// ListSettlementsByGroup retrieves the settlements for a group that match
+// filter, newest first.
+func (s *SQLiteStore) ListSettlementsByGroup(ctx context.Context, groupID string, filter storage.SettlementFilter) ([]*models.Settlement, error) {
+	query := `SELECT id, group_id, from_user_id, to_user_id, amount, created_at, created_by, note, plan_id
+		 FROM settlements WHERE group_id = ?`
+	args := []any{groupID}
+	if filter.Until != 0 {
+		query += " AND created_at < ?"
+		args = append(args, filter.Until)
+	}
+	rows, err := s.db.QueryContext(ctx, query+" ORDER BY created_at DESC, id LIMIT ? OFFSET ?",
+		append(args, pageLimit(filter.Page), filter.Page.Offset)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list settlements by group: %w", err)
 	}
 	defer rows.Close()
 
-	return scanSettlements(rows)
+	return s.scanSettlements(rows)
 }
 
 // ListDirectSettlementsByUser retrieves all settlements with no group (cross-group settle ups)
 // involving the given display name as either payer or payee.
 func (s *SQLiteStore) ListDirectSettlementsByUser(ctx context.Context, displayName string) ([]*models.Settlement, error) {
 	rows, err := s.db.QueryContext(ctx,
-		`SELECT id, group_id, from_user_id, to_user_id, amount, created_at, created_by, note
+		`SELECT id, group_id, from_user_id, to_user_id, amount, created_at, created_by, note, plan_id
 		 FROM settlements
 		 WHERE group_id IS NULL AND (from_user_id = ? OR to_user_id = ?)
 		 ORDER BY created_at DESC`,
@@ -102,7 +121,26 @@ func (s *SQLiteStore) ListDirectSettlementsByUser(ctx context.Context, displayNa
 	}
 	defer rows.Close()
 
-	return scanSettlements(rows)
+	return s.scanSettlements(rows)
+}
+
+// ListBalanceSettlementsByUser retrieves settlements in groups the user belongs
+// to, plus direct settlements the user's display name paid or received.
+func (s *SQLiteStore) ListBalanceSettlementsByUser(ctx context.Context, userID, displayName string) ([]*models.Settlement, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, group_id, from_user_id, to_user_id, amount, created_at, created_by, note, plan_id
+		 FROM settlements
+		 WHERE group_id IN (SELECT gm.group_id FROM group_members gm WHERE gm.user_id = ?)
+		    OR (group_id IS NULL AND (from_user_id = ? OR to_user_id = ?))
+		 ORDER BY created_at DESC`,
+		userID, displayName, displayName,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list balance settlements: %w", err)
+	}
+	defer rows.Close()
+
+	return s.scanSettlements(rows)
 }
 
 // DeleteSettlement removes a settlement by ID.
@@ -110,7 +148,7 @@ func (s *SQLiteStore) DeleteSettlement(ctx context.Context, settlementID string)
 	var exists int
 	err := s.db.QueryRowContext(ctx, "SELECT 1 FROM settlements WHERE id = ?", settlementID).Scan(&exists)
 	if err == sql.ErrNoRows {
-		return fmt.Errorf("settlement not found: %s", settlementID)
+		return storage.Errorf(storage.ErrNotFound, "settlement not found: %s", settlementID)
 	}
 	if err != nil {
 		return fmt.Errorf("failed to check settlement existence: %w", err)
@@ -124,24 +162,29 @@ func (s *SQLiteStore) DeleteSettlement(ctx context.Context, settlementID string)
 	return nil
 }
 
-func scanSettlements(rows *sql.Rows) ([]*models.Settlement, error) {
+// scanSettlements scans settlement rows, decrypting their notes.
+func (s *SQLiteStore) scanSettlements(rows *sql.Rows) ([]*models.Settlement, error) {
 	var settlements []*models.Settlement
 	for rows.Next() {
 		settlement := &models.Settlement{}
-		var groupID sql.NullString
-		var note sql.NullString
+		var groupID, note, planID sql.NullString
 
 		if err := rows.Scan(&settlement.ID, &groupID, &settlement.FromUserID, &settlement.ToUserID,
-			&settlement.Amount, &settlement.CreatedAt, &settlement.CreatedBy, &note); err != nil {
+			&settlement.Amount, &settlement.CreatedAt, &settlement.CreatedBy, &note, &planID); err != nil {
 			return nil, fmt.Errorf("failed to scan settlement: %w", err)
 		}
 
 		if groupID.Valid {
 			settlement.GroupID = &groupID.String
 		}
+		note, err := s.cipher.decryptNull(columnSettlementNote, note)
+		if err != nil {
+			return nil, err
+		}
 		if note.Valid {
 			settlement.Note = note.String
 		}
+		settlement.PlanID = planID.String
 
 		settlements = append(settlements, settlement)
 	}
@@ -152,3 +195,139 @@ func scanSettlements(rows *sql.Rows) ([]*models.Settlement, error) {
 
 	return settlements, nil
 }
+
+// settlementPlanColumns lists the settlement_plans columns read by scanSettlementPlan,
+// in scan order, plus the amount paid so far from linked settlements.
+const settlementPlanColumns = `id, group_id, from_user_id, to_user_id, total, installment_amount, cadence,
+	start_at, created_at, created_by, note,
+	COALESCE((SELECT SUM(amount) FROM settlements WHERE plan_id = settlement_plans.id), 0)`
+
+// scanSettlementPlan scans a row selected with settlementPlanColumns into a
+// plan, decrypting its note.
+func (s *SQLiteStore) scanSettlementPlan(row rowScanner) (*models.SettlementPlan, error) {
+	plan := &models.SettlementPlan{}
+	var cadence string
+	var note sql.NullString
+	if err := row.Scan(&plan.ID, &plan.GroupID, &plan.FromUserID, &plan.ToUserID, &plan.Total,
+		&plan.InstallmentAmount, &cadence, &plan.StartAt, &plan.CreatedAt, &plan.CreatedBy, &note, &plan.Paid); err != nil {
+		return nil, err
+	}
+	plan.Cadence = models.PlanCadence(cadence)
+	plain, err := s.cipher.decrypt(columnSettlementPlanNote, note.String)
+	if err != nil {
+		return nil, err
+	}
+	plan.Note = plain
+	return plan, nil
+}
+
+// CreateSettlementPlan persists a new settlement plan.
+func (s *SQLiteStore) CreateSettlementPlan(ctx context.Context, plan *models.SettlementPlan) error {
+	if plan.ID == "" {
+		plan.ID = uuid.New().String()
+	}
+	if plan.CreatedAt == 0 {
+		plan.CreatedAt = time.Now().Unix()
+	}
+
+	return s.insertSettlementPlan(ctx, s.db, plan)
+}
+
+// insertSettlementPlan inserts a settlement plan row, encrypting the note if
+// the store encrypts.
+func (s *SQLiteStore) insertSettlementPlan(ctx context.Context, ex execer, plan *models.SettlementPlan) error {
+	note, err := s.cipher.encrypt(columnSettlementPlanNote, plan.Note)
+	if err != nil {
+		return err
+	}
+	_, err = ex.ExecContext(ctx,
+		`INSERT INTO settlement_plans (id, group_id, from_user_id, to_user_id, total, installment_amount, cadence, start_at, created_at, created_by, note)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		plan.ID, plan.GroupID, plan.FromUserID, plan.ToUserID, plan.Total, plan.InstallmentAmount,
+		string(plan.Cadence), plan.StartAt, plan.CreatedAt, plan.CreatedBy, nullString(note),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert settlement plan: %w", err)
+	}
+	return nil
+}
+
+// GetSettlementPlan retrieves a settlement plan by ID, with the amount paid so far.
+func (s *SQLiteStore) GetSettlementPlan(ctx context.Context, planID string) (*models.SettlementPlan, error) {
+	plan, err := s.scanSettlementPlan(s.db.QueryRowContext(ctx,
+		"SELECT "+settlementPlanColumns+" FROM settlement_plans WHERE id = ?", planID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, storage.Errorf(storage.ErrNotFound, "settlement plan not found: %s", planID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get settlement plan: %w", err)
+	}
+	return plan, nil
+}
+
+// ListSettlementPlansByGroup retrieves all settlement plans for a group, newest first.
+func (s *SQLiteStore) ListSettlementPlansByGroup(ctx context.Context, groupID string) ([]*models.SettlementPlan, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+settlementPlanColumns+" FROM settlement_plans WHERE group_id = ? ORDER BY created_at DESC",
+		groupID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list settlement plans by group: %w", err)
+	}
+	defer rows.Close()
+
+	return s.scanSettlementPlans(rows)
+}
+
+// ListSettlementPlansByPayer retrieves all settlement plans the given display
+// name is repaying, across groups.
+func (s *SQLiteStore) ListSettlementPlansByPayer(ctx context.Context, displayName string) ([]*models.SettlementPlan, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+settlementPlanColumns+" FROM settlement_plans WHERE from_user_id = ? ORDER BY start_at",
+		displayName,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list settlement plans by payer: %w", err)
+	}
+	defer rows.Close()
+
+	return s.scanSettlementPlans(rows)
+}
+
+// DeleteSettlementPlan removes a settlement plan. Settlements recorded against
+// it are kept and unlinked.
+func (s *SQLiteStore) DeleteSettlementPlan(ctx context.Context, planID string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM settlement_plans WHERE id = ?", planID)
+	if err != nil {
+		return fmt.Errorf("failed to delete settlement plan: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return storage.Errorf(storage.ErrNotFound, "settlement plan not found: %s", planID)
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE settlements SET plan_id = NULL WHERE plan_id = ?", planID); err != nil {
+		return fmt.Errorf("failed to unlink settlements: %w", err)
+	}
+	return tx.Commit()
+}
+
+func (s *SQLiteStore) scanSettlementPlans(rows *sql.Rows) ([]*models.SettlementPlan, error) {
+	var plans []*models.SettlementPlan
+	for rows.Next() {
+		plan, err := s.scanSettlementPlan(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan settlement plan: %w", err)
+		}
+		plans = append(plans, plan)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate settlement plans: %w", err)
+	}
+	return plans, nil
+}
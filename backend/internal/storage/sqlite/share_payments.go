@@ -0,0 +1,68 @@
+package sqlite
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/mmynk/splitwiser/internal/models"
+)
+
+// MarkShareAsPaid records that a participant has paid their share of a bill,
+// setting PaidAt if it is zero. Marking a share again updates who marked it
+// and when.
+func (s *SQLiteStore) MarkShareAsPaid(ctx context.Context, payment *models.SharePayment) error {
+	if payment.PaidAt == 0 {
+		payment.PaidAt = time.Now().Unix()
+	}
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO bill_shares (bill_id, participant, paid_at, marked_by) VALUES (?, ?, ?, ?)
+		 ON CONFLICT (bill_id, participant) DO UPDATE SET paid_at = excluded.paid_at, marked_by = excluded.marked_by`,
+		payment.BillID, payment.Participant, payment.PaidAt, payment.MarkedBy,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to mark share as paid: %w", err)
+	}
+	return nil
+}
+
+// UnmarkShareAsPaid records that a participant's share of a bill is
+// outstanding again. It is a no-op if it wasn't marked as paid.
+func (s *SQLiteStore) UnmarkShareAsPaid(ctx context.Context, billID, participant string) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM bill_shares WHERE bill_id = ? AND participant = ?", billID, participant)
+	if err != nil {
+		return fmt.Errorf("failed to unmark share as paid: %w", err)
+	}
+	return nil
+}
+
+// ListSharePayments retrieves the shares marked as paid of the given bills,
+// in one query.
+func (s *SQLiteStore) ListSharePayments(ctx context.Context, billIDs []string) ([]models.SharePayment, error) {
+	if len(billIDs) == 0 {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(billIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode bill IDs: %w", err)
+	}
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT bill_id, participant, paid_at, marked_by FROM bill_shares WHERE bill_id IN (SELECT value FROM json_each(?)) ORDER BY bill_id, participant",
+		string(encoded),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list share payments: %w", err)
+	}
+	defer rows.Close()
+
+	var payments []models.SharePayment
+	for rows.Next() {
+		var p models.SharePayment
+		if err := rows.Scan(&p.BillID, &p.Participant, &p.PaidAt, &p.MarkedBy); err != nil {
+			return nil, fmt.Errorf("failed to scan share payment: %w", err)
+		}
+		payments = append(payments, p)
+	}
+	return payments, rows.Err()
+}
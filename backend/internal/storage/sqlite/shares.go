@@ -0,0 +1,48 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// SetBillShareToken shares a bill read-only under the token with the given
+// hash, replacing any previous token.
+func (s *SQLiteStore) SetBillShareToken(ctx context.Context, billID, tokenHash, createdBy string) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO bill_share_tokens (bill_id, token_hash, created_at, created_by) VALUES (?, ?, ?, ?)
+		 ON CONFLICT (bill_id) DO UPDATE SET token_hash = excluded.token_hash,
+		     created_at = excluded.created_at, created_by = excluded.created_by`,
+		billID, tokenHash, time.Now().Unix(), createdBy,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to set bill share token: %w", err)
+	}
+	return nil
+}
+
+// DeleteBillShareToken stops sharing a bill. It is a no-op if it wasn't
+// shared.
+func (s *SQLiteStore) DeleteBillShareToken(ctx context.Context, billID string) error {
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM bill_share_tokens WHERE bill_id = ?", billID); err != nil {
+		return fmt.Errorf("failed to delete bill share token: %w", err)
+	}
+	return nil
+}
+
+// GetBillIDByShareToken returns the bill shared under the token with the
+// given hash, or "" if there is none.
+func (s *SQLiteStore) GetBillIDByShareToken(ctx context.Context, tokenHash string) (string, error) {
+	var billID string
+	err := s.db.QueryRowContext(ctx,
+		"SELECT bill_id FROM bill_share_tokens WHERE token_hash = ?", tokenHash,
+	).Scan(&billID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to look up bill share token: %w", err)
+	}
+	return billID, nil
+}
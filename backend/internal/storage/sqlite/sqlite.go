@@ -4,9 +4,11 @@ package sqlite
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
@@ -22,28 +24,39 @@ var _ storage.Store = (*SQLiteStore)(nil)
 
 // SQLiteStore implements storage.Store using SQLite.
 type SQLiteStore struct {
-	db *sql.DB
+	db     *sql.DB
+	cipher *fieldCipher // encrypts sensitive columns; nil stores them in plain text
+
+	foldPlusTags bool // email lookups ignore plus tags
 }
 
 // New creates a new SQLiteStore with the given database path.
 // It creates the parent directories and runs migrations automatically.
-func New(dbPath string) (*SQLiteStore, error) {
+func New(dbPath string, opts ...Option) (*SQLiteStore, error) {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	// Create parent directory if it doesn't exist
 	dir := filepath.Dir(dbPath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create database directory: %w", err)
 	}
 
-	// Open database with pure Go driver
-	db, err := sql.Open("sqlite", dbPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open database: %w", err)
+	// Open database with pure Go driver. Every connection gets foreign keys,
+	// the journal mode and the busy timeout from the DSN.
+	db := sql.OpenDB(&connector{dsn: buildDSN(dbPath, o), hook: o.queryHook, retries: o.busyRetries})
+	db.SetMaxOpenConns(o.maxOpenConns)
+	if o.maxIdleConns > 0 {
+		db.SetMaxIdleConns(o.maxIdleConns)
 	}
+	db.SetConnMaxLifetime(o.connMaxLifetime)
 
-	// Enable foreign keys
-	if _, err := db.Exec("PRAGMA foreign_keys = ON"); err != nil {
+	// Opening is lazy; connect now so a bad path or pragma fails here
+	if err := db.Ping(); err != nil {
 		db.Close()
-		return nil, fmt.Errorf("failed to enable foreign keys: %w", err)
+		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
 	// Run migrations
@@ -52,7 +65,34 @@ func New(dbPath string) (*SQLiteStore, error) {
 		return nil, fmt.Errorf("failed to run migrations: %w", err)
 	}
 
-	return &SQLiteStore{db: db}, nil
+	store := &SQLiteStore{db: db}
+	if o.encryptionKey != nil {
+		c, err := newFieldCipher(o.encryptionKey, o.previousKeys...)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+		store.cipher = c
+	}
+
+	// Email lookups depend on the key and plus tag folding, so they're
+	// normalized once both are known.
+	ctx := context.Background()
+	if o.plusTagFolding != nil {
+		store.foldPlusTags = *o.plusTagFolding
+	} else {
+		fold, err := plusTagFolding(ctx, store)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+		store.foldPlusTags = fold
+	}
+	if err := store.normalizeEmailLookups(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to run migrations: %w", err)
+	}
+	return store, nil
 }
 
 // Close closes the database connection.
@@ -60,6 +100,11 @@ func (s *SQLiteStore) Close() error {
 	return s.db.Close()
 }
 
+// Ping checks the database can still be reached.
+func (s *SQLiteStore) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
 // nullString returns a sql.NullString for a string value, treating empty string as NULL.
 func nullString(v string) sql.NullString {
 	if v == "" {
@@ -68,290 +113,424 @@ func nullString(v string) sql.NullString {
 	return sql.NullString{String: v, Valid: true}
 }
 
-// CreateBill persists a new bill to the database.
-func (s *SQLiteStore) CreateBill(ctx context.Context, bill *models.Bill) error {
-	// Generate IDs if not set
-	if bill.ID == "" {
-		bill.ID = uuid.New().String()
+// pageLimit returns the LIMIT for page; SQLite reads a negative limit as none.
+func pageLimit(page storage.Page) int {
+	if page.Limit <= 0 {
+		return -1
 	}
-	if bill.CreatedAt == 0 {
-		bill.CreatedAt = time.Now().Unix()
+	return page.Limit
+}
+
+// tipSplitMode returns the stored value for a bill's tip split mode, defaulting to proportional.
+func tipSplitMode(mode models.TipSplitMode) string {
+	if mode == "" {
+		return string(models.TipSplitProportional)
 	}
-	if bill.Title == "" {
-		bill.Title = generateTitle(bill.Items, bill.Participants)
+	return string(mode)
+}
+
+// billColumns lists the bills columns read by scanBill, in scan order.
+const billColumns = "id, title, total, subtotal, tip, tip_split_mode, split_type, created_at, group_id, payer_id, creator_id, needs_assignment, discount, discount_type, currency, remainder_mode, tax_inclusive, tax_rate, rounding_mode, deleted_at, category_id, archived_at, exchange_rate, locked_at, note, merchant_name, location, bill_date"
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// queryer is satisfied by both *sql.DB and *sql.Tx.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// scanBill scans a row selected with billColumns into a bill (without items or participants).
+func scanBill(row rowScanner) (*models.Bill, error) {
+	bill := &models.Bill{}
+	var groupID, payerID, creatorID, categoryID sql.NullString
+	var tipMode, splitType, discountType, remainderMode, roundingMode string
+	if err := row.Scan(&bill.ID, &bill.Title, &bill.Total, &bill.Subtotal, &bill.Tip, &tipMode, &splitType,
+		&bill.CreatedAt, &groupID, &payerID, &creatorID, &bill.NeedsAssignment, &bill.Discount, &discountType, &bill.Currency,
+		&remainderMode, &bill.TaxInclusive, &bill.TaxRate, &roundingMode, &bill.DeletedAt, &categoryID, &bill.ArchivedAt, &bill.ExchangeRate, &bill.LockedAt,
+		&bill.Note, &bill.MerchantName, &bill.Location, &bill.BillDate); err != nil {
+		return nil, err
+	}
+	bill.RemainderMode = models.RemainderMode(remainderMode)
+	bill.RoundingMode = models.RoundingMode(roundingMode)
+	bill.DiscountType = models.DiscountType(discountType)
+	bill.TipSplitMode = models.TipSplitMode(tipMode)
+	bill.SplitType = models.SplitType(splitType)
+	bill.GroupID = groupID.String
+	bill.PayerID = payerID.String
+	bill.CreatorID = creatorID.String
+	bill.CategoryID = categoryID.String
+	return bill, nil
+}
+
+// coverMode returns the stored value for a participant's cover mode, defaulting to none.
+func coverMode(mode models.CoverMode) string {
+	if mode == "" {
+		return string(models.CoverNone)
 	}
+	return string(mode)
+}
 
-	tx, err := s.db.BeginTx(ctx, nil)
-	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+// feeSplitMode returns the stored value for a fee's split mode, defaulting to proportional.
+func feeSplitMode(mode models.FeeSplitMode) string {
+	if mode == "" {
+		return string(models.FeeSplitProportional)
 	}
-	defer tx.Rollback()
+	return string(mode)
+}
 
-	// Insert bill
-	_, err = tx.ExecContext(ctx,
-		"INSERT INTO bills (id, title, total, subtotal, created_at, group_id, payer_id, creator_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
-		bill.ID, bill.Title, bill.Total, bill.Subtotal, bill.CreatedAt,
-		nullString(bill.GroupID), nullString(bill.PayerID), nullString(bill.CreatorID),
-	)
-	if err != nil {
-		return fmt.Errorf("failed to insert bill: %w", err)
+// discountType returns the stored value for a bill's discount type, defaulting to amount.
+func discountType(t models.DiscountType) string {
+	if t == "" {
+		return string(models.DiscountAmount)
 	}
+	return string(t)
+}
 
-	// Insert participants
-	for _, p := range bill.Participants {
-		_, err = tx.ExecContext(ctx,
-			"INSERT INTO participants (bill_id, name, user_id) VALUES (?, ?, ?)",
-			bill.ID, p.DisplayName, nullString(p.UserID),
-		)
-		if err != nil {
-			return fmt.Errorf("failed to insert participant: %w", err)
-		}
+// remainderMode returns the stored value for a bill's remainder mode, defaulting to equal.
+func remainderMode(mode models.RemainderMode) string {
+	if mode == "" {
+		return string(models.RemainderEqual)
 	}
+	return string(mode)
+}
 
-	// Insert items and their assignments
-	for i := range bill.Items {
-		item := &bill.Items[i]
-		if item.ID == "" {
-			item.ID = uuid.New().String()
-		}
+// splitType returns the stored value for a bill's split type, defaulting to equal.
+func splitType(t models.SplitType) string {
+	if t == "" {
+		return string(models.SplitEqual)
+	}
+	return string(t)
+}
 
-		_, err = tx.ExecContext(ctx,
-			"INSERT INTO items (id, bill_id, description, amount) VALUES (?, ?, ?, ?)",
-			item.ID, bill.ID, item.Description, item.Amount,
-		)
-		if err != nil {
-			return fmt.Errorf("failed to insert item: %w", err)
-		}
+// CreateBill persists a new bill to the database.
+func (s *SQLiteStore) CreateBill(ctx context.Context, bill *models.Bill) error {
+	_, err := s.CreateBillInGroup(ctx, bill, nil)
+	return err
+}
 
-		// Insert item assignments (display names)
-		for _, participant := range item.Participants {
-			_, err = tx.ExecContext(ctx,
-				"INSERT INTO item_assignments (item_id, participant) VALUES (?, ?)",
-				item.ID, participant,
-			)
-			if err != nil {
-				return fmt.Errorf("failed to insert item assignment: %w", err)
-			}
+// CreateBillInGroup persists a new bill and adds members to its group in the
+// same transaction, skipping those already in it. It returns how many
+// members were added.
+func (s *SQLiteStore) CreateBillInGroup(ctx context.Context, bill *models.Bill, members []models.GroupMember) (int, error) {
+	if err := s.fillNewBill(ctx, bill); err != nil {
+		return 0, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := insertBill(ctx, tx, bill); err != nil {
+		return 0, err
+	}
+	added := 0
+	if bill.GroupID != "" {
+		if added, err = addGroupMembers(ctx, tx, bill.GroupID, members); err != nil {
+			return 0, err
 		}
 	}
 
 	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	return nil
+	return added, nil
 }
 
-// GetBill retrieves a bill by ID, including all items and participants.
-func (s *SQLiteStore) GetBill(ctx context.Context, billID string) (*models.Bill, error) {
-	bill := &models.Bill{}
-	var groupID sql.NullString
-	var payerID sql.NullString
-	var creatorID sql.NullString
-	err := s.db.QueryRowContext(ctx,
-		"SELECT id, title, total, subtotal, created_at, group_id, payer_id, creator_id FROM bills WHERE id = ?",
-		billID,
-	).Scan(&bill.ID, &bill.Title, &bill.Total, &bill.Subtotal, &bill.CreatedAt, &groupID, &payerID, &creatorID)
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("bill not found: %s", billID)
+// CreateBillsInGroup persists new bills of a group and adds members to it,
+// all in one transaction, skipping members already in it. It returns how
+// many members were added.
+func (s *SQLiteStore) CreateBillsInGroup(ctx context.Context, groupID string, bills []*models.Bill, members []models.GroupMember) (int, error) {
+	for _, bill := range bills {
+		bill.GroupID = groupID
+		if err := s.fillNewBill(ctx, bill); err != nil {
+			return 0, err
+		}
 	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get bill: %w", err)
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
 	}
-	if groupID.Valid {
-		bill.GroupID = groupID.String
-	}
-	if payerID.Valid {
-		bill.PayerID = payerID.String
+	defer tx.Rollback()
+
+	for _, bill := range bills {
+		if err := insertBill(ctx, tx, bill); err != nil {
+			return 0, err
+		}
 	}
-	if creatorID.Valid {
-		bill.CreatorID = creatorID.String
+	added, err := addGroupMembers(ctx, tx, groupID, members)
+	if err != nil {
+		return 0, err
 	}
 
-	// Get participants
-	rows, err := s.db.QueryContext(ctx,
-		"SELECT name, user_id FROM participants WHERE bill_id = ? ORDER BY name",
-		billID,
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get participants: %w", err)
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
 	}
-	defer rows.Close()
+	return added, nil
+}
 
-	for rows.Next() {
-		var name string
-		var userID sql.NullString
-		if err := rows.Scan(&name, &userID); err != nil {
-			return nil, fmt.Errorf("failed to scan participant: %w", err)
+// fillNewBill fills in the ID, creation time and title of a bill about to
+// be created, where they aren't set.
+func (s *SQLiteStore) fillNewBill(ctx context.Context, bill *models.Bill) error {
+	if bill.ID == "" {
+		bill.ID = uuid.New().String()
+	}
+	if bill.CreatedAt == 0 {
+		bill.CreatedAt = time.Now().Unix()
+	}
+	if bill.Title == "" {
+		template, err := s.groupTitleTemplate(ctx, bill.GroupID)
+		if err != nil {
+			return err
 		}
-		p := models.BillParticipant{DisplayName: name}
-		if userID.Valid {
-			p.UserID = userID.String
+		if template != "" {
+			bill.Title = renderTitleTemplate(template, bill)
+		}
+		if bill.Title == "" {
+			bill.Title = generateTitle(bill.Items, bill.Participants)
 		}
-		bill.Participants = append(bill.Participants, p)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("failed to iterate participants: %w", err)
 	}
+	return nil
+}
 
-	// Get items with their assignments
-	itemRows, err := s.db.QueryContext(ctx,
-		"SELECT id, description, amount FROM items WHERE bill_id = ?",
-		billID,
+// insertBill inserts a bill row and its contents.
+func insertBill(ctx context.Context, tx *sql.Tx, bill *models.Bill) error {
+	_, err := tx.ExecContext(ctx,
+		"INSERT INTO bills (id, title, total, subtotal, tip, tip_split_mode, split_type, created_at, group_id, payer_id, creator_id, needs_assignment, discount, discount_type, currency, remainder_mode, tax_inclusive, tax_rate, rounding_mode, category_id, exchange_rate, note, merchant_name, location, bill_date) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		bill.ID, bill.Title, bill.Total, bill.Subtotal, bill.Tip, tipSplitMode(bill.TipSplitMode), splitType(bill.SplitType), bill.CreatedAt,
+		nullString(bill.GroupID), nullString(bill.PayerID), nullString(bill.CreatorID), bill.NeedsAssignment,
+		bill.Discount, discountType(bill.DiscountType), bill.Currency, remainderMode(bill.RemainderMode), bill.TaxInclusive, bill.TaxRate, bill.RoundingMode,
+		nullString(bill.CategoryID), bill.ExchangeRate, bill.Note, bill.MerchantName, bill.Location, bill.BillDate,
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get items: %w", err)
+		return fmt.Errorf("failed to insert bill: %w", err)
 	}
-	defer itemRows.Close()
 
-	for itemRows.Next() {
-		var item models.Item
-		if err := itemRows.Scan(&item.ID, &item.Description, &item.Amount); err != nil {
-			return nil, fmt.Errorf("failed to scan item: %w", err)
+	return insertBillContents(ctx, tx, bill)
+}
+
+// insertBillContents inserts a bill's participants, items, item assignments and fees.
+// Item and fee IDs are generated for those that don't have one.
+func insertBillContents(ctx context.Context, tx *sql.Tx, bill *models.Bill) error {
+	participants := make([][]any, len(bill.Participants))
+	for i, p := range bill.Participants {
+		participants[i] = []any{bill.ID, p.DisplayName, nullString(p.UserID), p.Shares, p.Amount, coverMode(p.Covered), p.Adjustment}
+	}
+	if err := insertRows(ctx, tx, "participants", "bill_id, name, user_id, shares, amount, covered, adjustment", participants); err != nil {
+		return fmt.Errorf("failed to insert participants: %w", err)
+	}
+
+	items := make([][]any, len(bill.Items))
+	var assignments [][]any
+	for i := range bill.Items {
+		item := &bill.Items[i]
+		if item.ID == "" {
+			item.ID = uuid.New().String()
 		}
 
-		// Get assignments for this item
-		assignRows, err := s.db.QueryContext(ctx,
-			"SELECT participant FROM item_assignments WHERE item_id = ? ORDER BY participant",
-			item.ID,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get item assignments: %w", err)
+		var origin models.ItemOrigin
+		if item.Origin != nil {
+			origin = *item.Origin
+		}
+		items[i] = []any{
+			item.ID, bill.ID, item.Description, item.Amount, item.Discount, item.Quantity, item.UnitPrice, item.Category, nullString(item.Owner),
+			nullString(origin.Source), origin.Description, origin.Amount, origin.Quantity, origin.UnitPrice,
+		}
+		// Item assignments (display names)
+		for _, participant := range item.Participants {
+			assignments = append(assignments, []any{item.ID, participant, item.Shares[participant], item.Units[participant]})
+		}
+	}
+	// Items are read back in rowid order, which a multi-row INSERT keeps.
+	if err := insertRows(ctx, tx, "items", "id, bill_id, description, amount, discount, quantity, unit_price, category, owner, origin_source, origin_description, origin_amount, origin_quantity, origin_unit_price", items); err != nil {
+		return fmt.Errorf("failed to insert items: %w", err)
+	}
+	if err := insertRows(ctx, tx, "item_assignments", "item_id, participant, shares, units", assignments); err != nil {
+		return fmt.Errorf("failed to insert item assignments: %w", err)
+	}
+
+	fees := make([][]any, len(bill.Fees))
+	for i := range bill.Fees {
+		fee := &bill.Fees[i]
+		if fee.ID == "" {
+			fee.ID = uuid.New().String()
 		}
+		fees[i] = []any{fee.ID, bill.ID, fee.Description, fee.Amount, feeSplitMode(fee.SplitMode)}
+	}
+	if err := insertRows(ctx, tx, "fees", "id, bill_id, description, amount, split_mode", fees); err != nil {
+		return fmt.Errorf("failed to insert fees: %w", err)
+	}
 
-		for assignRows.Next() {
-			var participant string
-			if err := assignRows.Scan(&participant); err != nil {
-				assignRows.Close()
-				return nil, fmt.Errorf("failed to scan assignment: %w", err)
-			}
-			item.Participants = append(item.Participants, participant)
+	return indexBill(ctx, tx, bill.ID)
+}
+
+// maxInsertRows caps the rows of one multi-row INSERT, keeping its bound
+// parameters well under SQLite's limit of 32766.
+const maxInsertRows = 500
+
+// insertRows inserts rows, each holding a value for every one of columns, into
+// table, with one multi-row INSERT per maxInsertRows rows.
+func insertRows(ctx context.Context, tx *sql.Tx, table, columns string, rows [][]any) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	row := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(rows[0])), ", ") + ")"
+	for batch := range slices.Chunk(rows, maxInsertRows) {
+		args := make([]any, 0, len(batch)*len(batch[0]))
+		for _, values := range batch {
+			args = append(args, values...)
 		}
-		assignRows.Close()
-		if err := assignRows.Err(); err != nil {
-			return nil, fmt.Errorf("failed to iterate assignments: %w", err)
+		query := "INSERT INTO " + table + " (" + columns + ") VALUES " + strings.TrimSuffix(strings.Repeat(row+", ", len(batch)), ", ")
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return err
 		}
+	}
+	return nil
+}
 
-		bill.Items = append(bill.Items, item)
+// GetBill retrieves a live bill by ID, including all items and participants.
+func (s *SQLiteStore) GetBill(ctx context.Context, billID string) (*models.Bill, error) {
+	return getBill(ctx, s.db, billID, false)
+}
+
+// getBill retrieves a bill by ID from the live bills or, if trashed is set,
+// from the trash, including all items and participants.
+func getBill(ctx context.Context, q queryer, billID string, trashed bool) (*models.Bill, error) {
+	deleted := "deleted_at = 0"
+	if trashed {
+		deleted = "deleted_at != 0"
 	}
-	if err := itemRows.Err(); err != nil {
-		return nil, fmt.Errorf("failed to iterate items: %w", err)
+	bill, err := scanBill(q.QueryRowContext(ctx,
+		"SELECT "+billColumns+" FROM bills WHERE id = ? AND "+deleted,
+		billID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, storage.Errorf(storage.ErrNotFound, "bill not found: %s", billID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bill: %w", err)
+	}
+
+	if err := loadBillContents(ctx, q, []*models.Bill{bill}); err != nil {
+		return nil, err
 	}
 
 	return bill, nil
 }
 
 // UpdateBill updates an existing bill, replacing all items and participants.
+// The version it replaces is kept as the bill's next revision, credited to
+// bill.EditorID.
 func (s *SQLiteStore) UpdateBill(ctx context.Context, bill *models.Bill) error {
+	_, err := s.UpdateBillInGroup(ctx, bill, nil)
+	return err
+}
+
+// UpdateBillInGroup updates an existing bill like UpdateBill and adds members
+// to its group in the same transaction, skipping those already in it. It
+// returns how many members were added.
+func (s *SQLiteStore) UpdateBillInGroup(ctx context.Context, bill *models.Bill, members []models.GroupMember) (int, error) {
 	if bill.ID == "" {
-		return fmt.Errorf("bill ID is required for update")
+		return 0, fmt.Errorf("bill ID is required for update")
 	}
 
-	// Check if bill exists
-	var exists int
-	err := s.db.QueryRowContext(ctx, "SELECT 1 FROM bills WHERE id = ?", bill.ID).Scan(&exists)
-	if err == sql.ErrNoRows {
-		return fmt.Errorf("bill not found: %s", bill.ID)
-	}
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to check bill existence: %w", err)
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	tx, err := s.db.BeginTx(ctx, nil)
+	// The version replaced is read in the transaction, so concurrent updates
+	// each keep the version they replaced.
+	previous, err := getBill(ctx, tx, bill.ID, false)
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return 0, err
+	}
+	if err := insertBillRevision(ctx, tx, previous, bill.EditorID); err != nil {
+		return 0, err
 	}
-	defer tx.Rollback()
 
 	_, err = tx.ExecContext(ctx,
-		"UPDATE bills SET title = ?, total = ?, subtotal = ?, group_id = ?, payer_id = ? WHERE id = ?",
-		bill.Title, bill.Total, bill.Subtotal, nullString(bill.GroupID), nullString(bill.PayerID), bill.ID,
+		"UPDATE bills SET title = ?, total = ?, subtotal = ?, tip = ?, tip_split_mode = ?, split_type = ?, group_id = ?, payer_id = ?, needs_assignment = ?, discount = ?, discount_type = ?, currency = ?, remainder_mode = ?, tax_inclusive = ?, tax_rate = ?, rounding_mode = ?, category_id = ?, exchange_rate = ?, note = ?, merchant_name = ?, location = ?, bill_date = ? WHERE id = ?",
+		bill.Title, bill.Total, bill.Subtotal, bill.Tip, tipSplitMode(bill.TipSplitMode), splitType(bill.SplitType),
+		nullString(bill.GroupID), nullString(bill.PayerID), bill.NeedsAssignment, bill.Discount, discountType(bill.DiscountType), bill.Currency, remainderMode(bill.RemainderMode),
+		bill.TaxInclusive, bill.TaxRate, bill.RoundingMode, nullString(bill.CategoryID), bill.ExchangeRate,
+		bill.Note, bill.MerchantName, bill.Location, bill.BillDate, bill.ID,
 	)
 	if err != nil {
-		return fmt.Errorf("failed to update bill: %w", err)
+		return 0, fmt.Errorf("failed to update bill: %w", err)
 	}
 
 	// Delete existing items (cascades to item_assignments via FK)
 	_, err = tx.ExecContext(ctx, "DELETE FROM items WHERE bill_id = ?", bill.ID)
 	if err != nil {
-		return fmt.Errorf("failed to delete existing items: %w", err)
+		return 0, fmt.Errorf("failed to delete existing items: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM fees WHERE bill_id = ?", bill.ID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete existing fees: %w", err)
 	}
 
 	// Delete existing participants
 	_, err = tx.ExecContext(ctx, "DELETE FROM participants WHERE bill_id = ?", bill.ID)
 	if err != nil {
-		return fmt.Errorf("failed to delete existing participants: %w", err)
+		return 0, fmt.Errorf("failed to delete existing participants: %w", err)
 	}
 
-	// Insert new participants
-	for _, p := range bill.Participants {
-		_, err = tx.ExecContext(ctx,
-			"INSERT INTO participants (bill_id, name, user_id) VALUES (?, ?, ?)",
-			bill.ID, p.DisplayName, nullString(p.UserID),
-		)
-		if err != nil {
-			return fmt.Errorf("failed to insert participant: %w", err)
-		}
+	if err := insertBillContents(ctx, tx, bill); err != nil {
+		return 0, err
 	}
-
-	// Insert new items and their assignments
-	for i := range bill.Items {
-		item := &bill.Items[i]
-		if item.ID == "" {
-			item.ID = uuid.New().String()
-		}
-
-		_, err = tx.ExecContext(ctx,
-			"INSERT INTO items (id, bill_id, description, amount) VALUES (?, ?, ?, ?)",
-			item.ID, bill.ID, item.Description, item.Amount,
-		)
-		if err != nil {
-			return fmt.Errorf("failed to insert item: %w", err)
-		}
-
-		for _, participant := range item.Participants {
-			_, err = tx.ExecContext(ctx,
-				"INSERT INTO item_assignments (item_id, participant) VALUES (?, ?)",
-				item.ID, participant,
-			)
-			if err != nil {
-				return fmt.Errorf("failed to insert item assignment: %w", err)
-			}
+	added := 0
+	if bill.GroupID != "" {
+		if added, err = addGroupMembers(ctx, tx, bill.GroupID, members); err != nil {
+			return 0, err
 		}
 	}
 
 	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	return nil
+	return added, nil
 }
 
-// DeleteBill removes a bill and its associated data (items, participants, assignments).
+// DeleteBill moves a bill to the trash, keeping its items and participants
+// until it is purged.
 func (s *SQLiteStore) DeleteBill(ctx context.Context, billID string) error {
-	var exists int
-	err := s.db.QueryRowContext(ctx, "SELECT 1 FROM bills WHERE id = ?", billID).Scan(&exists)
-	if err == sql.ErrNoRows {
-		return fmt.Errorf("bill not found: %s", billID)
-	}
-	if err != nil {
-		return fmt.Errorf("failed to check bill existence: %w", err)
-	}
-
-	_, err = s.db.ExecContext(ctx, "DELETE FROM bills WHERE id = ?", billID)
+	result, err := s.db.ExecContext(ctx,
+		"UPDATE bills SET deleted_at = ? WHERE id = ? AND deleted_at = 0",
+		time.Now().Unix(), billID,
+	)
 	if err != nil {
 		return fmt.Errorf("failed to delete bill: %w", err)
 	}
-
+	if n, _ := result.RowsAffected(); n == 0 {
+		return storage.Errorf(storage.ErrNotFound, "bill not found: %s", billID)
+	}
 	return nil
 }
 
 // ListBillsByGroup retrieves all bills associated with a group.
-func (s *SQLiteStore) ListBillsByGroup(ctx context.Context, groupID string) ([]*models.Bill, error) {
-	rows, err := s.db.QueryContext(ctx,
-		"SELECT id, title, total, subtotal, payer_id, created_at, group_id FROM bills WHERE group_id = ? ORDER BY created_at DESC",
-		groupID,
-	)
+func (s *SQLiteStore) ListBillsByGroup(ctx context.Context, groupID string, filter storage.BillFilter) ([]*models.Bill, error) {
+	filter.GroupID, filter.Ungrouped = groupID, false
+	query, args := billFilterSQL("SELECT "+billColumns+" FROM bills WHERE deleted_at = 0", nil, filter)
+	query += " ORDER BY created_at DESC, id LIMIT ? OFFSET ?"
+	args = append(args, pageLimit(filter.Page), filter.Page.Offset)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list bills by group: %w", err)
 	}
@@ -359,88 +538,194 @@ func (s *SQLiteStore) ListBillsByGroup(ctx context.Context, groupID string) ([]*
 
 	var bills []*models.Bill
 	for rows.Next() {
-		bill := &models.Bill{}
-		var payerIDStr sql.NullString
-		var groupIDStr sql.NullString
-		if err := rows.Scan(&bill.ID, &bill.Title, &bill.Total, &bill.Subtotal, &payerIDStr, &bill.CreatedAt, &groupIDStr); err != nil {
+		bill, err := scanBill(rows)
+		if err != nil {
 			return nil, fmt.Errorf("failed to scan bill: %w", err)
 		}
-		if payerIDStr.Valid {
-			bill.PayerID = payerIDStr.String
-		}
-		if groupIDStr.Valid {
-			bill.GroupID = groupIDStr.String
-		}
+		bills = append(bills, bill)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate bills: %w", err)
+	}
+	rows.Close()
 
-		bill.Participants, err = s.getParticipants(ctx, bill.ID)
-		if err != nil {
-			return nil, err
-		}
+	if err := loadBillContents(ctx, s.db, bills); err != nil {
+		return nil, err
+	}
+	return bills, nil
+}
 
-		bill.Items, err = s.getItemsWithAssignments(ctx, bill.ID)
+// loadBillContents fills in each bill's participants, items and fees, in four
+// queries however many bills there are.
+func loadBillContents(ctx context.Context, q queryer, bills []*models.Bill) error {
+	if err := loadParticipants(ctx, q, bills); err != nil {
+		return err
+	}
+	if err := loadItems(ctx, q, bills); err != nil {
+		return err
+	}
+	return loadFees(ctx, q, bills)
+}
+
+// GetBillsByIDs retrieves the live bills with the given IDs, including all
+// items and participants, in the order of ids: the bills in one query and
+// their contents in four. IDs with no live bill are left out.
+func (s *SQLiteStore) GetBillsByIDs(ctx context.Context, ids []string) ([]*models.Bill, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode bill IDs: %w", err)
+	}
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+billColumns+" FROM bills WHERE id IN (SELECT value FROM json_each(?)) AND deleted_at = 0",
+		string(encoded),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bills: %w", err)
+	}
+	defer rows.Close()
+
+	byID := make(map[string]*models.Bill, len(ids))
+	for rows.Next() {
+		bill, err := scanBill(rows)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("failed to scan bill: %w", err)
 		}
-
-		bills = append(bills, bill)
+		byID[bill.ID] = bill
 	}
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("failed to iterate bills: %w", err)
 	}
+	rows.Close()
 
+	bills := make([]*models.Bill, 0, len(byID))
+	for _, id := range ids {
+		if bill, ok := byID[id]; ok {
+			bills = append(bills, bill)
+			delete(byID, id) // a repeated ID is returned once
+		}
+	}
+	if err := loadBillContents(ctx, s.db, bills); err != nil {
+		return nil, err
+	}
 	return bills, nil
 }
 
-// ListBillsByUser retrieves all bills where the given user is the creator or a participant.
-func (s *SQLiteStore) ListBillsByUser(ctx context.Context, userID string) ([]*models.Bill, error) {
+// ListBalanceBillsByUser retrieves every bill that can affect the user's
+// balances, fully loaded: bills in groups the user belongs to, and bills with
+// no group that the user created or participates in. Bills awaiting
+// assignment are left out.
+func (s *SQLiteStore) ListBalanceBillsByUser(ctx context.Context, userID string) ([]*models.Bill, error) {
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT b.id, b.title, b.total, b.subtotal, b.payer_id, b.group_id, b.created_at
-		FROM bills b
-		WHERE b.creator_id = ?
-		   OR b.id IN (SELECT p.bill_id FROM participants p WHERE p.user_id = ?)
-		ORDER BY b.created_at DESC`,
-		userID, userID,
+		SELECT `+billColumns+`
+		FROM bills
+		WHERE needs_assignment = 0 AND deleted_at = 0
+		  AND (group_id IN (SELECT gm.group_id FROM group_members gm WHERE gm.user_id = ?)
+		       OR (group_id IS NULL
+		           AND (creator_id = ?
+		                OR id IN (SELECT p.bill_id FROM participants p WHERE p.user_id = ?))))
+		ORDER BY created_at DESC`,
+		userID, userID, userID,
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list bills by participant: %w", err)
+		return nil, fmt.Errorf("failed to list balance bills: %w", err)
 	}
 	defer rows.Close()
 
 	var bills []*models.Bill
 	for rows.Next() {
-		bill := &models.Bill{}
-		var payerID sql.NullString
-		var groupID sql.NullString
-		if err := rows.Scan(&bill.ID, &bill.Title, &bill.Total, &bill.Subtotal, &payerID, &groupID, &bill.CreatedAt); err != nil {
+		bill, err := scanBill(rows)
+		if err != nil {
 			return nil, fmt.Errorf("failed to scan bill: %w", err)
 		}
-		if payerID.Valid {
-			bill.PayerID = payerID.String
-		}
-		if groupID.Valid {
-			bill.GroupID = groupID.String
-		}
+		bills = append(bills, bill)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate bills: %w", err)
+	}
+	rows.Close()
+
+	if err := loadBillContents(ctx, s.db, bills); err != nil {
+		return nil, err
+	}
+	return bills, nil
+}
+
+// ListBillsByUser retrieves the bills matching filter where the given user is the creator or a participant.
+func (s *SQLiteStore) ListBillsByUser(ctx context.Context, userID string, filter storage.BillFilter) ([]*models.Bill, error) {
+	query := "SELECT " + billColumns + ` FROM bills
+		WHERE deleted_at = 0
+		  AND (creator_id = ? OR id IN (SELECT p.bill_id FROM participants p WHERE p.user_id = ?))`
+	args := []any{userID, userID}
+	query, args = billFilterSQL(query, args, filter)
+	query += " ORDER BY created_at DESC, id LIMIT ? OFFSET ?"
+	args = append(args, pageLimit(filter.Page), filter.Page.Offset)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bills by participant: %w", err)
+	}
+	defer rows.Close()
 
-		bill.Participants, err = s.getParticipants(ctx, bill.ID)
+	var bills []*models.Bill
+	for rows.Next() {
+		bill, err := scanBill(rows)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("failed to scan bill: %w", err)
 		}
-
 		bills = append(bills, bill)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("failed to iterate bills: %w", err)
 	}
+	rows.Close()
 
+	if err := loadParticipants(ctx, s.db, bills); err != nil {
+		return nil, err
+	}
 	return bills, nil
 }
 
+// billFilterSQL appends the conditions of filter, other than its page, to a
+// query on the bills table.
+func billFilterSQL(query string, args []any, filter storage.BillFilter) (string, []any) {
+	switch {
+	case filter.GroupID != "":
+		query += " AND group_id = ?"
+		args = append(args, filter.GroupID)
+	case filter.Ungrouped:
+		query += " AND group_id IS NULL"
+	}
+	if filter.Since != 0 {
+		query += " AND created_at >= ?"
+		args = append(args, filter.Since)
+	}
+	if filter.Until != 0 {
+		query += " AND created_at < ?"
+		args = append(args, filter.Until)
+	}
+	if filter.CategoryID != "" {
+		query += " AND category_id = ?"
+		args = append(args, filter.CategoryID)
+	}
+	if filter.PaidBy != "" {
+		query += " AND id IN (SELECT p.bill_id FROM participants p WHERE p.user_id = ? AND p.name = bills.payer_id)"
+		args = append(args, filter.PaidBy)
+	}
+	if filter.ExcludeArchived {
+		query += " AND archived_at = 0"
+	}
+	return query, args
+}
+
 // ListDirectBillsByUser retrieves bills with no group where the user is creator or participant.
 func (s *SQLiteStore) ListDirectBillsByUser(ctx context.Context, userID string) ([]*models.Bill, error) {
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT b.id, b.title, b.total, b.subtotal, b.payer_id, b.group_id, b.created_at
+		SELECT b.id, b.title, b.total, b.subtotal, b.payer_id, b.group_id, b.created_at, b.currency
 		FROM bills b
-		WHERE b.group_id IS NULL
+		WHERE b.group_id IS NULL AND b.deleted_at = 0
 		  AND (b.creator_id = ?
 		       OR b.id IN (SELECT p.bill_id FROM participants p WHERE p.user_id = ?))
 		ORDER BY b.created_at DESC`,
@@ -456,7 +741,7 @@ func (s *SQLiteStore) ListDirectBillsByUser(ctx context.Context, userID string)
 		bill := &models.Bill{}
 		var payerID sql.NullString
 		var groupID sql.NullString
-		if err := rows.Scan(&bill.ID, &bill.Title, &bill.Total, &bill.Subtotal, &payerID, &groupID, &bill.CreatedAt); err != nil {
+		if err := rows.Scan(&bill.ID, &bill.Title, &bill.Total, &bill.Subtotal, &payerID, &groupID, &bill.CreatedAt, &bill.Currency); err != nil {
 			return nil, fmt.Errorf("failed to scan bill: %w", err)
 		}
 		if payerID.Valid {
@@ -471,75 +756,211 @@ func (s *SQLiteStore) ListDirectBillsByUser(ctx context.Context, userID string)
 	return bills, nil
 }
 
-// getParticipants is a helper that fetches participants for a bill.
-func (s *SQLiteStore) getParticipants(ctx context.Context, billID string) ([]models.BillParticipant, error) {
-	rows, err := s.db.QueryContext(ctx,
-		"SELECT name, user_id FROM participants WHERE bill_id = ? ORDER BY name",
-		billID,
+// ListUnassignedBillsByUser retrieves bills awaiting participant assignment that the
+// user created or that belong to one of the user's groups.
+func (s *SQLiteStore) ListUnassignedBillsByUser(ctx context.Context, userID string) ([]*models.Bill, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+billColumns+`
+		FROM bills
+		WHERE needs_assignment = 1 AND deleted_at = 0
+		  AND (creator_id = ?
+		       OR group_id IN (SELECT gm.group_id FROM group_members gm WHERE gm.user_id = ?))
+		ORDER BY created_at DESC`,
+		userID, userID,
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get participants: %w", err)
+		return nil, fmt.Errorf("failed to list unassigned bills: %w", err)
 	}
 	defer rows.Close()
 
-	var participants []models.BillParticipant
+	var bills []*models.Bill
 	for rows.Next() {
-		var name string
+		bill, err := scanBill(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan bill: %w", err)
+		}
+		bills = append(bills, bill)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate bills: %w", err)
+	}
+	rows.Close()
+
+	if err := loadItems(ctx, s.db, bills); err != nil {
+		return nil, err
+	}
+	return bills, nil
+}
+
+// billIDs encodes the IDs of bills as a JSON array, for queries to match
+// with json_each: one parameter however many bills there are.
+func billIDs(bills []*models.Bill) string {
+	ids := make([]string, len(bills))
+	for i, bill := range bills {
+		ids[i] = bill.ID
+	}
+	data, _ := json.Marshal(ids)
+	return string(data)
+}
+
+// loadParticipants fills in the participants of bills in one query.
+func loadParticipants(ctx context.Context, q queryer, bills []*models.Bill) error {
+	if len(bills) == 0 {
+		return nil
+	}
+	rows, err := q.QueryContext(ctx,
+		"SELECT bill_id, name, user_id, shares, amount, covered, adjustment FROM participants WHERE bill_id IN (SELECT value FROM json_each(?)) ORDER BY name",
+		billIDs(bills),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get participants: %w", err)
+	}
+	defer rows.Close()
+
+	byID := billsByID(bills)
+	for rows.Next() {
+		var billID, name, covered string
 		var userID sql.NullString
-		if err := rows.Scan(&name, &userID); err != nil {
-			return nil, fmt.Errorf("failed to scan participant: %w", err)
+		var shares, amount, adjustment float64
+		if err := rows.Scan(&billID, &name, &userID, &shares, &amount, &covered, &adjustment); err != nil {
+			return fmt.Errorf("failed to scan participant: %w", err)
 		}
-		p := models.BillParticipant{DisplayName: name}
+		p := models.BillParticipant{DisplayName: name, Shares: shares, Amount: amount, Covered: models.CoverMode(covered), Adjustment: adjustment}
 		if userID.Valid {
 			p.UserID = userID.String
 		}
-		participants = append(participants, p)
+		bill := byID[billID]
+		bill.Participants = append(bill.Participants, p)
 	}
-	return participants, rows.Err()
+	return rows.Err()
 }
 
-// getItemsWithAssignments is a helper that fetches items and their participant assignments.
-func (s *SQLiteStore) getItemsWithAssignments(ctx context.Context, billID string) ([]models.Item, error) {
-	itemRows, err := s.db.QueryContext(ctx,
-		"SELECT id, description, amount FROM items WHERE bill_id = ?",
-		billID,
+// loadItems fills in the items of bills, with their participant assignments,
+// in two queries.
+func loadItems(ctx context.Context, q queryer, bills []*models.Bill) error {
+	if len(bills) == 0 {
+		return nil
+	}
+	ids := billIDs(bills)
+	itemRows, err := q.QueryContext(ctx,
+		"SELECT bill_id, id, description, amount, discount, quantity, unit_price, category, owner, origin_source, origin_description, origin_amount, origin_quantity, origin_unit_price FROM items WHERE bill_id IN (SELECT value FROM json_each(?)) ORDER BY rowid",
+		ids,
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get items: %w", err)
+		return fmt.Errorf("failed to get items: %w", err)
 	}
 	defer itemRows.Close()
 
-	var items []models.Item
+	// Items are appended to their bills once assignments are filled in, so
+	// they're collected by pointer until then.
+	type billItem struct {
+		billID string
+		item   *models.Item
+	}
+	var items []billItem
+	itemsByID := make(map[string]*models.Item)
 	for itemRows.Next() {
-		var item models.Item
-		if err := itemRows.Scan(&item.ID, &item.Description, &item.Amount); err != nil {
-			return nil, fmt.Errorf("failed to scan item: %w", err)
+		var billID, category string
+		item := &models.Item{}
+		var owner, originSource sql.NullString
+		var origin models.ItemOrigin
+		if err := itemRows.Scan(&billID, &item.ID, &item.Description, &item.Amount, &item.Discount, &item.Quantity, &item.UnitPrice, &category, &owner,
+			&originSource, &origin.Description, &origin.Amount, &origin.Quantity, &origin.UnitPrice); err != nil {
+			return fmt.Errorf("failed to scan item: %w", err)
 		}
-
-		assignRows, err := s.db.QueryContext(ctx,
-			"SELECT participant FROM item_assignments WHERE item_id = ? ORDER BY participant",
-			item.ID,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get item assignments: %w", err)
+		item.Category = models.ItemCategory(category)
+		item.Owner = owner.String
+		if originSource.Valid {
+			origin.Source = originSource.String
+			item.Origin = &origin
 		}
+		items = append(items, billItem{billID, item})
+		itemsByID[item.ID] = item
+	}
+	if err := itemRows.Err(); err != nil {
+		return fmt.Errorf("failed to iterate items: %w", err)
+	}
+	itemRows.Close()
 
-		for assignRows.Next() {
-			var participant string
-			if err := assignRows.Scan(&participant); err != nil {
-				assignRows.Close()
-				return nil, fmt.Errorf("failed to scan assignment: %w", err)
+	assignRows, err := q.QueryContext(ctx, `
+		SELECT a.item_id, a.participant, a.shares, a.units
+		FROM item_assignments a JOIN items i ON i.id = a.item_id
+		WHERE i.bill_id IN (SELECT value FROM json_each(?))
+		ORDER BY a.participant`,
+		ids,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get item assignments: %w", err)
+	}
+	defer assignRows.Close()
+	for assignRows.Next() {
+		var itemID, participant string
+		var shares, units float64
+		if err := assignRows.Scan(&itemID, &participant, &shares, &units); err != nil {
+			return fmt.Errorf("failed to scan assignment: %w", err)
+		}
+		item := itemsByID[itemID]
+		item.Participants = append(item.Participants, participant)
+		if shares > 0 {
+			if item.Shares == nil {
+				item.Shares = make(map[string]float64)
 			}
-			item.Participants = append(item.Participants, participant)
+			item.Shares[participant] = shares
 		}
-		assignRows.Close()
-		if err := assignRows.Err(); err != nil {
-			return nil, fmt.Errorf("failed to iterate assignments: %w", err)
+		if units > 0 {
+			if item.Units == nil {
+				item.Units = make(map[string]float64)
+			}
+			item.Units[participant] = units
 		}
+	}
+	if err := assignRows.Err(); err != nil {
+		return fmt.Errorf("failed to iterate assignments: %w", err)
+	}
 
-		items = append(items, item)
+	byID := billsByID(bills)
+	for _, bi := range items {
+		bill := byID[bi.billID]
+		bill.Items = append(bill.Items, *bi.item)
 	}
-	return items, itemRows.Err()
+	return nil
+}
+
+// loadFees fills in the fees of bills in one query.
+func loadFees(ctx context.Context, q queryer, bills []*models.Bill) error {
+	if len(bills) == 0 {
+		return nil
+	}
+	rows, err := q.QueryContext(ctx,
+		"SELECT bill_id, id, description, amount, split_mode FROM fees WHERE bill_id IN (SELECT value FROM json_each(?)) ORDER BY rowid",
+		billIDs(bills),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get fees: %w", err)
+	}
+	defer rows.Close()
+
+	byID := billsByID(bills)
+	for rows.Next() {
+		var billID, mode string
+		var fee models.Fee
+		if err := rows.Scan(&billID, &fee.ID, &fee.Description, &fee.Amount, &mode); err != nil {
+			return fmt.Errorf("failed to scan fee: %w", err)
+		}
+		fee.SplitMode = models.FeeSplitMode(mode)
+		bill := byID[billID]
+		bill.Fees = append(bill.Fees, fee)
+	}
+	return rows.Err()
+}
+
+// billsByID indexes bills by ID.
+func billsByID(bills []*models.Bill) map[string]*models.Bill {
+	byID := make(map[string]*models.Bill, len(bills))
+	for _, bill := range bills {
+		byID[bill.ID] = bill
+	}
+	return byID
 }
 
 // Stats holds aggregate counts for observability metrics.
@@ -556,7 +977,7 @@ func (s *SQLiteStore) GetStats(ctx context.Context) (Stats, error) {
 	row := s.db.QueryRowContext(ctx, `
 		SELECT
 			(SELECT COUNT(*) FROM users),
-			(SELECT COUNT(*) FROM bills),
+			(SELECT COUNT(*) FROM bills WHERE deleted_at = 0),
 			(SELECT COUNT(*) FROM groups)
 	`)
 	if err := row.Scan(&stats.Users, &stats.Bills, &stats.Groups); err != nil {
@@ -565,6 +986,22 @@ func (s *SQLiteStore) GetStats(ctx context.Context) (Stats, error) {
 	return stats, nil
 }
 
+// GetUsage counts the groups a user created and the bills they created at or
+// after since, and totals the size of every attachment they uploaded.
+func (s *SQLiteStore) GetUsage(ctx context.Context, userID string, since int64) (*models.Usage, error) {
+	usage := &models.Usage{}
+	row := s.db.QueryRowContext(ctx, `
+		SELECT
+			(SELECT COUNT(*) FROM groups WHERE creator_id = ?),
+			(SELECT COUNT(*) FROM bills WHERE creator_id = ? AND created_at >= ?),
+			(SELECT COALESCE(SUM(size), 0) FROM attachments WHERE uploader_id = ?)
+	`, userID, userID, since, userID)
+	if err := row.Scan(&usage.GroupsCreated, &usage.BillsCreated, &usage.AttachmentBytes); err != nil {
+		return nil, fmt.Errorf("failed to get usage: %w", err)
+	}
+	return usage, nil
+}
+
 // generateTitle creates an auto-generated title using hybrid "Items - Participants" format.
 func generateTitle(items []models.Item, participants []models.BillParticipant) string {
 	itemsStr := ""
@@ -603,6 +1040,59 @@ func generateTitle(items []models.Item, participants []models.BillParticipant) s
 	return fmt.Sprintf("Bill - %s", time.Now().Format("Jan 2, 2006"))
 }
 
+// groupTitleTemplate returns the title template configured for a group, if any.
+func (s *SQLiteStore) groupTitleTemplate(ctx context.Context, groupID string) (string, error) {
+	if groupID == "" {
+		return "", nil
+	}
+	var template sql.NullString
+	err := s.db.QueryRowContext(ctx, "SELECT title_template FROM groups WHERE id = ?", groupID).Scan(&template)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get group title template: %w", err)
+	}
+	return template.String, nil
+}
+
+// renderTitleTemplate fills a group's title template from the bill.
+// {date} is the bill's creation date, {payer} its payer and {top_item} the
+// description of its most expensive item. Unknown placeholders are left as is.
+func renderTitleTemplate(template string, bill *models.Bill) string {
+	topItem := ""
+	topAmount := 0.0
+	for _, item := range bill.Items {
+		if topItem == "" || item.Amount > topAmount {
+			topItem, topAmount = item.Description, item.Amount
+		}
+	}
+	r := strings.NewReplacer(
+		"{date}", time.Unix(bill.CreatedAt, 0).Format("Jan 2, 2006"),
+		"{payer}", bill.PayerID,
+		"{top_item}", topItem,
+	)
+	return strings.TrimSpace(r.Replace(template))
+}
+
+// groupColumns lists the groups columns read by scanGroup, in scan order.
+const groupColumns = "id, name, created_at, creator_id, disable_auto_title, title_template, default_payer_is_creator, rounding_mode, payer_rotation, currency"
+
+// scanGroup scans a row selected with groupColumns into a group (without members).
+func scanGroup(row rowScanner) (*models.Group, error) {
+	group := &models.Group{}
+	var creatorID, titleTemplate sql.NullString
+	var roundingMode string
+	if err := row.Scan(&group.ID, &group.Name, &group.CreatedAt, &creatorID,
+		&group.Settings.DisableAutoTitle, &titleTemplate, &group.Settings.DefaultPayerIsCreator, &roundingMode, &group.Settings.PayerRotation, &group.Settings.Currency); err != nil {
+		return nil, err
+	}
+	group.Settings.RoundingMode = models.RoundingMode(roundingMode)
+	group.CreatorID = creatorID.String
+	group.Settings.TitleTemplate = titleTemplate.String
+	return group, nil
+}
+
 // CreateGroup persists a new group to the database.
 func (s *SQLiteStore) CreateGroup(ctx context.Context, group *models.Group) error {
 	if group.ID == "" {
@@ -618,9 +1108,24 @@ func (s *SQLiteStore) CreateGroup(ctx context.Context, group *models.Group) erro
 	}
 	defer tx.Rollback()
 
-	_, err = tx.ExecContext(ctx,
-		"INSERT INTO groups (id, name, created_at) VALUES (?, ?, ?)",
-		group.ID, group.Name, group.CreatedAt,
+	if err := insertGroup(ctx, tx, group); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// insertGroup inserts a group row and its members.
+func insertGroup(ctx context.Context, tx *sql.Tx, group *models.Group) error {
+	_, err := tx.ExecContext(ctx,
+		"INSERT INTO groups (id, name, created_at, creator_id, disable_auto_title, title_template, default_payer_is_creator, rounding_mode, payer_rotation, currency) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		group.ID, group.Name, group.CreatedAt, nullString(group.CreatorID),
+		group.Settings.DisableAutoTitle, nullString(group.Settings.TitleTemplate), group.Settings.DefaultPayerIsCreator,
+		group.Settings.RoundingMode, group.Settings.PayerRotation, group.Settings.Currency,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to insert group: %w", err)
@@ -635,41 +1140,40 @@ func (s *SQLiteStore) CreateGroup(ctx context.Context, group *models.Group) erro
 			return fmt.Errorf("failed to insert group member: %w", err)
 		}
 	}
-
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
-	}
-
-	return nil
+	return insertSpendingCaps(ctx, tx, group.ID, group.Settings.SpendingCaps)
 }
 
 // GetGroup retrieves a group by ID, including all members.
 func (s *SQLiteStore) GetGroup(ctx context.Context, groupID string) (*models.Group, error) {
-	group := &models.Group{}
-	err := s.db.QueryRowContext(ctx,
-		"SELECT id, name, created_at FROM groups WHERE id = ?",
+	group, err := scanGroup(s.db.QueryRowContext(ctx,
+		"SELECT "+groupColumns+" FROM groups WHERE id = ?",
 		groupID,
-	).Scan(&group.ID, &group.Name, &group.CreatedAt)
+	))
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("group not found: %s", groupID)
+		return nil, storage.Errorf(storage.ErrNotFound, "group not found: %s", groupID)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get group: %w", err)
 	}
 
 	group.Members, err = s.getGroupMembers(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	group.Settings.SpendingCaps, err = s.getSpendingCaps(ctx, groupID)
 	return group, err
 }
 
 // ListGroupsByUser retrieves all groups where the given user_id is a member.
-func (s *SQLiteStore) ListGroupsByUser(ctx context.Context, userID string) ([]*models.Group, error) {
+func (s *SQLiteStore) ListGroupsByUser(ctx context.Context, userID string, page storage.Page) ([]*models.Group, error) {
 	rows, err := s.db.QueryContext(ctx,
-		`SELECT g.id, g.name, g.created_at
+		`SELECT g.id, g.name, g.created_at, g.creator_id, g.disable_auto_title, g.title_template, g.default_payer_is_creator, g.rounding_mode, g.payer_rotation, g.currency
 		FROM groups g
 		JOIN group_members gm ON g.id = gm.group_id
 		WHERE gm.user_id = ?
-		ORDER BY g.created_at DESC`,
-		userID,
+		ORDER BY g.created_at DESC, g.id
+		LIMIT ? OFFSET ?`,
+		userID, pageLimit(page), page.Offset,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list groups: %w", err)
@@ -678,8 +1182,8 @@ func (s *SQLiteStore) ListGroupsByUser(ctx context.Context, userID string) ([]*m
 
 	var groups []*models.Group
 	for rows.Next() {
-		group := &models.Group{}
-		if err := rows.Scan(&group.ID, &group.Name, &group.CreatedAt); err != nil {
+		group, err := scanGroup(rows)
+		if err != nil {
 			return nil, fmt.Errorf("failed to scan group: %w", err)
 		}
 		groups = append(groups, group)
@@ -693,6 +1197,10 @@ func (s *SQLiteStore) ListGroupsByUser(ctx context.Context, userID string) ([]*m
 		if err != nil {
 			return nil, err
 		}
+		group.Settings.SpendingCaps, err = s.getSpendingCaps(ctx, group.ID)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	return groups, nil
@@ -707,7 +1215,7 @@ func (s *SQLiteStore) UpdateGroup(ctx context.Context, group *models.Group) erro
 	var exists int
 	err := s.db.QueryRowContext(ctx, "SELECT 1 FROM groups WHERE id = ?", group.ID).Scan(&exists)
 	if err == sql.ErrNoRows {
-		return fmt.Errorf("group not found: %s", group.ID)
+		return storage.Errorf(storage.ErrNotFound, "group not found: %s", group.ID)
 	}
 	if err != nil {
 		return fmt.Errorf("failed to check group existence: %w", err)
@@ -720,8 +1228,9 @@ func (s *SQLiteStore) UpdateGroup(ctx context.Context, group *models.Group) erro
 	defer tx.Rollback()
 
 	_, err = tx.ExecContext(ctx,
-		"UPDATE groups SET name = ? WHERE id = ?",
-		group.Name, group.ID,
+		"UPDATE groups SET name = ?, disable_auto_title = ?, title_template = ?, default_payer_is_creator = ?, rounding_mode = ?, payer_rotation = ?, currency = ? WHERE id = ?",
+		group.Name, group.Settings.DisableAutoTitle, nullString(group.Settings.TitleTemplate),
+		group.Settings.DefaultPayerIsCreator, group.Settings.RoundingMode, group.Settings.PayerRotation, group.Settings.Currency, group.ID,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to update group: %w", err)
@@ -742,6 +1251,13 @@ func (s *SQLiteStore) UpdateGroup(ctx context.Context, group *models.Group) erro
 		}
 	}
 
+	if _, err := tx.ExecContext(ctx, "DELETE FROM group_spending_caps WHERE group_id = ?", group.ID); err != nil {
+		return fmt.Errorf("failed to delete existing spending caps: %w", err)
+	}
+	if err := insertSpendingCaps(ctx, tx, group.ID, group.Settings.SpendingCaps); err != nil {
+		return err
+	}
+
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
@@ -771,14 +1287,8 @@ func (s *SQLiteStore) AddGroupMembersWithIDs(ctx context.Context, groupID string
 	}
 	defer tx.Rollback()
 
-	for _, m := range members {
-		_, err = tx.ExecContext(ctx,
-			"INSERT OR IGNORE INTO group_members (group_id, name, user_id) VALUES (?, ?, ?)",
-			groupID, m.DisplayName, nullString(m.UserID),
-		)
-		if err != nil {
-			return fmt.Errorf("failed to add group member: %w", err)
-		}
+	if _, err := addGroupMembers(ctx, tx, groupID, members); err != nil {
+		return err
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -788,12 +1298,33 @@ func (s *SQLiteStore) AddGroupMembersWithIDs(ctx context.Context, groupID string
 	return nil
 }
 
+// addGroupMembers adds members to a group, skipping those whose name is
+// already taken, and returns how many were added.
+func addGroupMembers(ctx context.Context, tx *sql.Tx, groupID string, members []models.GroupMember) (int, error) {
+	added := 0
+	for _, m := range members {
+		res, err := tx.ExecContext(ctx,
+			"INSERT OR IGNORE INTO group_members (group_id, name, user_id) VALUES (?, ?, ?)",
+			groupID, m.DisplayName, nullString(m.UserID),
+		)
+		if err != nil {
+			return 0, fmt.Errorf("failed to add group member: %w", err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("failed to add group member: %w", err)
+		}
+		added += int(n)
+	}
+	return added, nil
+}
+
 // DeleteGroup removes a group by ID.
 func (s *SQLiteStore) DeleteGroup(ctx context.Context, groupID string) error {
 	var exists int
 	err := s.db.QueryRowContext(ctx, "SELECT 1 FROM groups WHERE id = ?", groupID).Scan(&exists)
 	if err == sql.ErrNoRows {
-		return fmt.Errorf("group not found: %s", groupID)
+		return storage.Errorf(storage.ErrNotFound, "group not found: %s", groupID)
 	}
 	if err != nil {
 		return fmt.Errorf("failed to check group existence: %w", err)
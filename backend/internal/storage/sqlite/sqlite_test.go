@@ -1,12 +1,22 @@
 package sqlite
 
 import (
+	"bytes"
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/mmynk/splitwiser/internal/models"
+	"github.com/mmynk/splitwiser/internal/storage"
 )
 
 // strPtr returns a pointer to s.
@@ -134,6 +144,161 @@ func TestSQLiteStore(t *testing.T) {
 		}
 	})
 
+	t.Run("CreateBill persists tip and tip split mode", func(t *testing.T) {
+		bill := &models.Bill{
+			Title:        "Tipped",
+			Total:        39.0,
+			Subtotal:     30.0,
+			Tip:          6.0,
+			TipSplitMode: models.TipSplitEqual,
+			Participants: bp("Alice", "Bob"),
+		}
+		if err := store.CreateBill(ctx, bill); err != nil {
+			t.Fatalf("CreateBill failed: %v", err)
+		}
+
+		retrieved, err := store.GetBill(ctx, bill.ID)
+		if err != nil {
+			t.Fatalf("GetBill failed: %v", err)
+		}
+		if retrieved.Tip != 6.0 {
+			t.Errorf("Tip mismatch: got %f, want 6.0", retrieved.Tip)
+		}
+		if retrieved.TipSplitMode != models.TipSplitEqual {
+			t.Errorf("TipSplitMode mismatch: got %q, want %q", retrieved.TipSplitMode, models.TipSplitEqual)
+		}
+
+		bill.Tip = 0
+		bill.TipSplitMode = ""
+		if err := store.UpdateBill(ctx, bill); err != nil {
+			t.Fatalf("UpdateBill failed: %v", err)
+		}
+		retrieved, err = store.GetBill(ctx, bill.ID)
+		if err != nil {
+			t.Fatalf("GetBill failed: %v", err)
+		}
+		if retrieved.Tip != 0 || retrieved.TipSplitMode != models.TipSplitProportional {
+			t.Errorf("expected tip reset to 0/proportional, got %f/%q", retrieved.Tip, retrieved.TipSplitMode)
+		}
+	})
+
+	t.Run("CreateBill persists split type, shares and amounts", func(t *testing.T) {
+		participants := bp("Alice", "Bob")
+		participants[0].Shares = 2
+		participants[1].Amount = 12.5
+		bill := &models.Bill{
+			Title:        "Weighted",
+			Total:        30.0,
+			Subtotal:     30.0,
+			SplitType:    models.SplitShares,
+			Participants: participants,
+			Items: []models.Item{
+				{Description: "Wine", Amount: 30.0, Participants: []string{"Alice", "Bob"}, Shares: map[string]float64{"Bob": 3}},
+			},
+		}
+		if err := store.CreateBill(ctx, bill); err != nil {
+			t.Fatalf("CreateBill failed: %v", err)
+		}
+
+		retrieved, err := store.GetBill(ctx, bill.ID)
+		if err != nil {
+			t.Fatalf("GetBill failed: %v", err)
+		}
+		if retrieved.SplitType != models.SplitShares {
+			t.Errorf("SplitType mismatch: got %q, want %q", retrieved.SplitType, models.SplitShares)
+		}
+		if retrieved.Participants[0].Shares != 2 || retrieved.Participants[1].Shares != 0 {
+			t.Errorf("participant shares mismatch: got %+v", retrieved.Participants)
+		}
+		if retrieved.Participants[1].Amount != 12.5 {
+			t.Errorf("participant amount mismatch: got %f, want 12.5", retrieved.Participants[1].Amount)
+		}
+		item := retrieved.Items[0]
+		if item.Shares["Bob"] != 3 {
+			t.Errorf("item shares for Bob: got %f, want 3", item.Shares["Bob"])
+		}
+		if _, ok := item.Shares["Alice"]; ok {
+			t.Errorf("expected no item share override for Alice, got %f", item.Shares["Alice"])
+		}
+	})
+
+	t.Run("CreateBill persists discount", func(t *testing.T) {
+		bill := &models.Bill{
+			Title:        "Promo",
+			Total:        27.0,
+			Subtotal:     30.0,
+			Discount:     10,
+			DiscountType: models.DiscountPercent,
+			Participants: bp("Alice"),
+			Items:        []models.Item{{Description: "Happy hour beer", Amount: 8.0, Discount: 3.0, Participants: []string{"Alice"}}},
+		}
+		if err := store.CreateBill(ctx, bill); err != nil {
+			t.Fatalf("CreateBill failed: %v", err)
+		}
+
+		retrieved, err := store.GetBill(ctx, bill.ID)
+		if err != nil {
+			t.Fatalf("GetBill failed: %v", err)
+		}
+		if retrieved.Discount != 10 || retrieved.DiscountType != models.DiscountPercent {
+			t.Errorf("discount mismatch: got %f/%q, want 10/%q", retrieved.Discount, retrieved.DiscountType, models.DiscountPercent)
+		}
+		if retrieved.Items[0].Discount != 3.0 {
+			t.Errorf("item discount mismatch: got %f, want 3", retrieved.Items[0].Discount)
+		}
+
+		bill.Discount = 0
+		bill.DiscountType = ""
+		if err := store.UpdateBill(ctx, bill); err != nil {
+			t.Fatalf("UpdateBill failed: %v", err)
+		}
+		retrieved, err = store.GetBill(ctx, bill.ID)
+		if err != nil {
+			t.Fatalf("GetBill failed: %v", err)
+		}
+		if retrieved.Discount != 0 || retrieved.DiscountType != models.DiscountAmount {
+			t.Errorf("expected discount reset to 0/amount, got %f/%q", retrieved.Discount, retrieved.DiscountType)
+		}
+	})
+
+	t.Run("Unassigned bills are listed until finalized", func(t *testing.T) {
+		bill := &models.Bill{
+			Title:           "Parked",
+			Total:           20.0,
+			Subtotal:        20.0,
+			CreatorID:       "parker",
+			NeedsAssignment: true,
+			Items:           []models.Item{{Description: "Snacks", Amount: 20.0}},
+		}
+		if err := store.CreateBill(ctx, bill); err != nil {
+			t.Fatalf("CreateBill failed: %v", err)
+		}
+
+		unassigned, err := store.ListUnassignedBillsByUser(ctx, "parker")
+		if err != nil {
+			t.Fatalf("ListUnassignedBillsByUser failed: %v", err)
+		}
+		if len(unassigned) != 1 || unassigned[0].ID != bill.ID || !unassigned[0].NeedsAssignment {
+			t.Fatalf("expected parked bill to be listed, got %+v", unassigned)
+		}
+		if len(unassigned[0].Items) != 1 {
+			t.Errorf("expected items on unassigned bill, got %d", len(unassigned[0].Items))
+		}
+
+		bill.NeedsAssignment = false
+		bill.Participants = bp("Alice")
+		if err := store.UpdateBill(ctx, bill); err != nil {
+			t.Fatalf("UpdateBill failed: %v", err)
+		}
+		unassigned, err = store.ListUnassignedBillsByUser(ctx, "parker")
+		if err != nil {
+			t.Fatalf("ListUnassignedBillsByUser failed: %v", err)
+		}
+		if len(unassigned) != 0 {
+			t.Errorf("expected no unassigned bills after finalizing, got %d", len(unassigned))
+		}
+	})
+
 	t.Run("GetBill returns error for nonexistent bill", func(t *testing.T) {
 		_, err := store.GetBill(ctx, "nonexistent-id")
 		if err == nil {
@@ -299,6 +464,34 @@ func TestGenerateTitle(t *testing.T) {
 	}
 }
 
+func TestRenderTitleTemplate(t *testing.T) {
+	bill := &models.Bill{
+		PayerID:   "Alice",
+		CreatedAt: time.Date(2024, 3, 9, 12, 0, 0, 0, time.Local).Unix(),
+		Items: []models.Item{
+			{Description: "Wine", Amount: 20},
+			{Description: "Lobster", Amount: 45},
+			{Description: "Bread", Amount: 4},
+		},
+	}
+
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{"{top_item} on {date}", "Lobster on Mar 9, 2024"},
+		{"Paid by {payer}", "Paid by Alice"},
+		{"{unknown} stays", "{unknown} stays"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			if got := renderTitleTemplate(tt.template, bill); got != tt.want {
+				t.Errorf("renderTitleTemplate(%q) = %q, want %q", tt.template, got, tt.want)
+			}
+		})
+	}
+}
+
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(substr) == 0 ||
 		(len(s) > 0 && len(substr) > 0 && findSubstring(s, substr)))
@@ -377,6 +570,37 @@ func TestGroupStorage(t *testing.T) {
 		}
 	})
 
+	t.Run("Group settings persist through create and update", func(t *testing.T) {
+		group := &models.Group{
+			Name:     "Settings",
+			Members:  gm("Alice"),
+			Settings: models.GroupSettings{DisableAutoTitle: true},
+		}
+		if err := store.CreateGroup(ctx, group); err != nil {
+			t.Fatalf("CreateGroup failed: %v", err)
+		}
+
+		retrieved, err := store.GetGroup(ctx, group.ID)
+		if err != nil {
+			t.Fatalf("GetGroup failed: %v", err)
+		}
+		if !retrieved.Settings.DisableAutoTitle || retrieved.Settings.TitleTemplate != "" {
+			t.Errorf("settings mismatch after create: got %+v", retrieved.Settings)
+		}
+
+		group.Settings = models.GroupSettings{TitleTemplate: "{payer} - {date}", SpendingCaps: map[string]float64{"Alice": 200}, RoundingMode: models.RoundCash, PayerRotation: true}
+		if err := store.UpdateGroup(ctx, group); err != nil {
+			t.Fatalf("UpdateGroup failed: %v", err)
+		}
+		retrieved, err = store.GetGroup(ctx, group.ID)
+		if err != nil {
+			t.Fatalf("GetGroup failed: %v", err)
+		}
+		if !reflect.DeepEqual(retrieved.Settings, group.Settings) {
+			t.Errorf("settings mismatch after update: got %+v, want %+v", retrieved.Settings, group.Settings)
+		}
+	})
+
 	t.Run("GetGroup returns error for nonexistent group", func(t *testing.T) {
 		_, err := store.GetGroup(ctx, "nonexistent-id")
 		if err == nil {
@@ -395,7 +619,7 @@ func TestGroupStorage(t *testing.T) {
 			gmWithID("B2", "uuid-b2"),
 		}})
 
-		groupsA, err := store.ListGroupsByUser(ctx, "uuid-a1")
+		groupsA, err := store.ListGroupsByUser(ctx, "uuid-a1", storage.Page{})
 		if err != nil {
 			t.Fatalf("ListGroupsByUser failed: %v", err)
 		}
@@ -408,7 +632,7 @@ func TestGroupStorage(t *testing.T) {
 			}
 		}
 
-		groupsB, err := store.ListGroupsByUser(ctx, "uuid-b1")
+		groupsB, err := store.ListGroupsByUser(ctx, "uuid-b1", storage.Page{})
 		if err != nil {
 			t.Fatalf("ListGroupsByUser failed: %v", err)
 		}
@@ -502,6 +726,236 @@ func TestGroupStorage(t *testing.T) {
 	})
 }
 
+func TestGetUsage(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "splitwiser-usage-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	dbPath := filepath.Join(tempDir, "test.db")
+	store, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+
+	mine := &models.Group{Name: "Mine", Members: gm("Alice"), CreatorID: "user-alice"}
+	for _, g := range []*models.Group{
+		mine,
+		{Name: "Theirs", Members: gm("Bob"), CreatorID: "user-bob"},
+	} {
+		if err := store.CreateGroup(ctx, g); err != nil {
+			t.Fatalf("CreateGroup failed: %v", err)
+		}
+	}
+	bills := []*models.Bill{
+		{Title: "Old", Total: 10, Subtotal: 10, Participants: bp("Alice"), CreatorID: "user-alice", CreatedAt: 100},
+		{Title: "New", Total: 10, Subtotal: 10, Participants: bp("Alice"), CreatorID: "user-alice", CreatedAt: 200},
+		{Title: "Other", Total: 10, Subtotal: 10, Participants: bp("Bob"), CreatorID: "user-bob", CreatedAt: 200},
+	}
+	for _, b := range bills {
+		if err := store.CreateBill(ctx, b); err != nil {
+			t.Fatalf("CreateBill failed: %v", err)
+		}
+	}
+	for _, a := range []*models.Attachment{
+		{BillID: bills[0].ID, UploaderID: "user-alice", Name: "a.jpg", ContentType: "image/jpeg", Size: 100, BlobKey: "a"},
+		{BillID: bills[0].ID, UploaderID: "user-alice", Name: "b.pdf", ContentType: "application/pdf", Size: 250, BlobKey: "b"},
+		{BillID: bills[0].ID, UploaderID: "user-bob", Name: "c.png", ContentType: "image/png", Size: 1000, BlobKey: "c"},
+	} {
+		if err := store.CreateAttachment(ctx, a); err != nil {
+			t.Fatalf("CreateAttachment failed: %v", err)
+		}
+	}
+
+	usage, err := store.GetUsage(ctx, "user-alice", 150)
+	if err != nil {
+		t.Fatalf("GetUsage failed: %v", err)
+	}
+	if usage.GroupsCreated != 1 {
+		t.Errorf("GroupsCreated: got %d, want 1", usage.GroupsCreated)
+	}
+	if usage.BillsCreated != 1 {
+		t.Errorf("BillsCreated: got %d, want 1", usage.BillsCreated)
+	}
+	if usage.AttachmentBytes != 350 {
+		t.Errorf("AttachmentBytes: got %d, want 350", usage.AttachmentBytes)
+	}
+	keys, err := store.ListAttachmentBlobKeys(ctx)
+	if err != nil {
+		t.Fatalf("ListAttachmentBlobKeys failed: %v", err)
+	}
+	slices.Sort(keys)
+	if want := []string{"a", "b", "c"}; !slices.Equal(keys, want) {
+		t.Errorf("ListAttachmentBlobKeys: got %v, want %v", keys, want)
+	}
+
+	group, err := store.GetGroup(ctx, mine.ID)
+	if err != nil {
+		t.Fatalf("GetGroup failed: %v", err)
+	}
+	if group.CreatorID != "user-alice" {
+		t.Errorf("CreatorID: got %q, want %q", group.CreatorID, "user-alice")
+	}
+}
+
+func TestMaintain(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "splitwiser-maintain-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	dbPath := filepath.Join(tempDir, "test.db")
+	store, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+
+	// Churn: create bills with many items, then purge them, leaving free pages.
+	var items []models.Item
+	for i := range 200 {
+		items = append(items, models.Item{Description: fmt.Sprintf("Item %d with a long description", i), Amount: 1})
+	}
+	var ids []string
+	for range 20 {
+		bill := &models.Bill{Title: "Churn", Total: 200, Subtotal: 200, Items: slices.Clone(items), Participants: bp("Alice")}
+		if err := store.CreateBill(ctx, bill); err != nil {
+			t.Fatalf("CreateBill failed: %v", err)
+		}
+		ids = append(ids, bill.ID)
+	}
+	for _, id := range ids {
+		if err := store.DeleteBill(ctx, id); err != nil {
+			t.Fatalf("DeleteBill failed: %v", err)
+		}
+		if err := store.PurgeBill(ctx, id); err != nil {
+			t.Fatalf("PurgeBill failed: %v", err)
+		}
+	}
+
+	size, err := store.Size(ctx)
+	if err != nil {
+		t.Fatalf("Size failed: %v", err)
+	}
+	if size.FreeBytes == 0 {
+		t.Fatalf("expected free pages after churn, got %+v", size)
+	}
+
+	// Below the ratio: analyze and checkpoint only.
+	result, err := store.Maintain(ctx, MaintenanceOptions{VacuumFreeRatio: 1})
+	if err != nil {
+		t.Fatalf("Maintain failed: %v", err)
+	}
+	if result.Vacuumed {
+		t.Error("expected no vacuum below the free ratio")
+	}
+
+	result, err = store.Maintain(ctx, MaintenanceOptions{VacuumFreeRatio: size.FreeRatio() / 2})
+	if err != nil {
+		t.Fatalf("Maintain failed: %v", err)
+	}
+	if !result.Vacuumed {
+		t.Fatal("expected a vacuum above the free ratio")
+	}
+	if result.After.FreeBytes != 0 || result.After.Bytes >= result.Before.Bytes {
+		t.Errorf("expected vacuum to reclaim free pages: before %+v, after %+v", result.Before, result.After)
+	}
+}
+
+func TestBillTrash(t *testing.T) {
+	store, err := New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+
+	group := &models.Group{Name: "Trip", Members: gm("Alice", "Bob")}
+	if err := store.CreateGroup(ctx, group); err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	bill := &models.Bill{
+		Title:        "Dinner",
+		Total:        30,
+		Subtotal:     30,
+		GroupID:      group.ID,
+		CreatorID:    "user-1",
+		Participants: bp("Alice", "Bob"),
+		Items:        []models.Item{{Description: "Pizza", Amount: 30, Participants: []string{"Alice", "Bob"}}},
+	}
+	if err := store.CreateBill(ctx, bill); err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+
+	if err := store.DeleteBill(ctx, bill.ID); err != nil {
+		t.Fatalf("DeleteBill failed: %v", err)
+	}
+	if err := store.DeleteBill(ctx, bill.ID); err == nil {
+		t.Error("expected deleting a trashed bill to fail")
+	}
+	if _, err := store.GetBill(ctx, bill.ID); err == nil {
+		t.Error("expected GetBill to hide a trashed bill")
+	}
+	if err := store.UpdateBill(ctx, bill); err == nil {
+		t.Error("expected UpdateBill to refuse a trashed bill")
+	}
+	if bills, _ := store.ListBillsByGroup(ctx, group.ID, storage.BillFilter{}); len(bills) != 0 {
+		t.Errorf("ListBillsByGroup returned %d bills, want 0", len(bills))
+	}
+	if bills, _ := store.ListBillsByUser(ctx, "user-1", storage.BillFilter{}); len(bills) != 0 {
+		t.Errorf("ListBillsByUser returned %d bills, want 0", len(bills))
+	}
+
+	trashed, err := store.GetDeletedBill(ctx, bill.ID)
+	if err != nil {
+		t.Fatalf("GetDeletedBill failed: %v", err)
+	}
+	if trashed.DeletedAt == 0 || len(trashed.Items) != 1 {
+		t.Errorf("trashed bill = %+v, want a deletion time and its item", trashed)
+	}
+	deleted, err := store.ListDeletedBillsByUser(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("ListDeletedBillsByUser failed: %v", err)
+	}
+	if len(deleted) != 1 || len(deleted[0].Participants) != 2 {
+		t.Errorf("ListDeletedBillsByUser = %v, want the bill with its participants", deleted)
+	}
+
+	if err := store.RestoreBill(ctx, bill.ID); err != nil {
+		t.Fatalf("RestoreBill failed: %v", err)
+	}
+	restored, err := store.GetBill(ctx, bill.ID)
+	if err != nil {
+		t.Fatalf("GetBill after restore failed: %v", err)
+	}
+	if restored.DeletedAt != 0 || len(restored.Items) != 1 {
+		t.Errorf("restored bill = %+v, want live with its item", restored)
+	}
+	if err := store.RestoreBill(ctx, bill.ID); err == nil {
+		t.Error("expected restoring a live bill to fail")
+	}
+	if err := store.PurgeBill(ctx, bill.ID); err == nil {
+		t.Error("expected purging a live bill to fail")
+	}
+
+	if err := store.DeleteBill(ctx, bill.ID); err != nil {
+		t.Fatalf("DeleteBill failed: %v", err)
+	}
+	if err := store.PurgeBill(ctx, bill.ID); err != nil {
+		t.Fatalf("PurgeBill failed: %v", err)
+	}
+	if _, err := store.GetDeletedBill(ctx, bill.ID); err == nil {
+		t.Error("expected a purged bill to be gone from the trash")
+	}
+}
+
 func TestAddGroupMembers(t *testing.T) {
 	tempDir, err := os.MkdirTemp("", "splitwiser-addmembers-test-*")
 	if err != nil {
@@ -565,40 +1019,132 @@ func TestAddGroupMembers(t *testing.T) {
 			t.Fatalf("AddGroupMembers with empty list failed: %v", err)
 		}
 	})
-}
-
-func TestBillWithGroup(t *testing.T) {
-	tempDir, err := os.MkdirTemp("", "splitwiser-bill-group-test-*")
-	if err != nil {
-		t.Fatalf("Failed to create temp dir: %v", err)
-	}
-	defer os.RemoveAll(tempDir)
-
-	dbPath := filepath.Join(tempDir, "test.db")
-	store, err := New(dbPath)
-	if err != nil {
-		t.Fatalf("Failed to create store: %v", err)
-	}
-	defer store.Close()
-
-	ctx := context.Background()
 
-	t.Run("Bill with group_id", func(t *testing.T) {
-		group := &models.Group{
-			Name:    "Test Group",
-			Members: gm("Alice", "Bob"),
+	t.Run("CreateBillInGroup adds members with the bill", func(t *testing.T) {
+		bill := &models.Bill{Title: "Dinner", Total: 30, Subtotal: 30, GroupID: group.ID, Participants: []models.BillParticipant{{DisplayName: "Alice"}, bpWithID("Frank", "frank-id")}}
+		added, err := store.CreateBillInGroup(ctx, bill, []models.GroupMember{gmWithID("Alice", ""), gmWithID("Frank", "frank-id")})
+		if err != nil {
+			t.Fatalf("CreateBillInGroup failed: %v", err)
 		}
-		err := store.CreateGroup(ctx, group)
+		if added != 1 {
+			t.Errorf("added = %d, want 1", added)
+		}
+		retrieved, err := store.GetGroup(ctx, group.ID)
 		if err != nil {
-			t.Fatalf("CreateGroup failed: %v", err)
+			t.Fatalf("GetGroup failed: %v", err)
 		}
-
-		bill := &models.Bill{
-			Title:        "Group Dinner",
-			Total:        50.0,
-			Subtotal:     45.0,
-			Participants: bp("Alice", "Bob"),
-			GroupID:      group.ID,
+		if !slices.Contains(retrieved.Members, gmWithID("Frank", "frank-id")) {
+			t.Errorf("Frank not added: %v", retrieved.Members)
+		}
+
+		// A bill that can't be saved adds nobody.
+		dup := &models.Bill{ID: bill.ID, Title: "Again", Total: 10, Subtotal: 10, GroupID: group.ID, Participants: bp("Grace")}
+		if _, err := store.CreateBillInGroup(ctx, dup, gm("Grace")); !errors.Is(err, storage.ErrConflict) {
+			t.Fatalf("CreateBillInGroup with a taken ID = %v, want ErrConflict", err)
+		}
+		retrieved, err = store.GetGroup(ctx, group.ID)
+		if err != nil {
+			t.Fatalf("GetGroup failed: %v", err)
+		}
+		if slices.Contains(retrieved.Members, gmWithID("Grace", "")) {
+			t.Errorf("Grace added without her bill: %v", retrieved.Members)
+		}
+	})
+
+	t.Run("UpdateBillInGroup adds members with the update", func(t *testing.T) {
+		bill := &models.Bill{Title: "Lunch", Total: 20, Subtotal: 20, Participants: bp("Alice", "Heidi")}
+		if err := store.CreateBill(ctx, bill); err != nil {
+			t.Fatalf("CreateBill failed: %v", err)
+		}
+		bill.GroupID = group.ID
+		added, err := store.UpdateBillInGroup(ctx, bill, gm("Heidi"))
+		if err != nil {
+			t.Fatalf("UpdateBillInGroup failed: %v", err)
+		}
+		if added != 1 {
+			t.Errorf("added = %d, want 1", added)
+		}
+		moved, err := store.GetBill(ctx, bill.ID)
+		if err != nil {
+			t.Fatalf("GetBill failed: %v", err)
+		}
+		if moved.GroupID != group.ID {
+			t.Errorf("bill group = %q, want %q", moved.GroupID, group.ID)
+		}
+		retrieved, err := store.GetGroup(ctx, group.ID)
+		if err != nil {
+			t.Fatalf("GetGroup failed: %v", err)
+		}
+		if !slices.Contains(retrieved.Members, gmWithID("Heidi", "")) {
+			t.Errorf("Heidi not added: %v", retrieved.Members)
+		}
+	})
+
+	t.Run("CreateBillsInGroup saves all bills or none", func(t *testing.T) {
+		first := &models.Bill{Title: "Rent", Total: 900, Subtotal: 900, Participants: bp("Alice", "Ivan")}
+		second := &models.Bill{Title: "Power", Total: 60, Subtotal: 60, Participants: bp("Alice")}
+		added, err := store.CreateBillsInGroup(ctx, group.ID, []*models.Bill{first, second}, gm("Ivan"))
+		if err != nil {
+			t.Fatalf("CreateBillsInGroup failed: %v", err)
+		}
+		if added != 1 {
+			t.Errorf("added = %d, want 1", added)
+		}
+		if got, err := store.GetBill(ctx, second.ID); err != nil || got.GroupID != group.ID {
+			t.Errorf("GetBill = %+v, %v; want the bill in the group", got, err)
+		}
+
+		// The second bill reuses an ID, so the first isn't saved either.
+		fresh := &models.Bill{Title: "Water", Total: 20, Subtotal: 20, Participants: bp("Judy")}
+		dup := &models.Bill{ID: first.ID, Title: "Again", Total: 10, Subtotal: 10, Participants: bp("Alice")}
+		if _, err := store.CreateBillsInGroup(ctx, group.ID, []*models.Bill{fresh, dup}, gm("Judy")); !errors.Is(err, storage.ErrConflict) {
+			t.Fatalf("CreateBillsInGroup with a taken ID = %v, want ErrConflict", err)
+		}
+		if _, err := store.GetBill(ctx, fresh.ID); !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("GetBill of the rolled back bill = %v, want ErrNotFound", err)
+		}
+		retrieved, err := store.GetGroup(ctx, group.ID)
+		if err != nil {
+			t.Fatalf("GetGroup failed: %v", err)
+		}
+		if slices.Contains(retrieved.Members, gmWithID("Judy", "")) {
+			t.Errorf("Judy added without her bill: %v", retrieved.Members)
+		}
+	})
+}
+
+func TestBillWithGroup(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "splitwiser-bill-group-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	dbPath := filepath.Join(tempDir, "test.db")
+	store, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+
+	t.Run("Bill with group_id", func(t *testing.T) {
+		group := &models.Group{
+			Name:    "Test Group",
+			Members: gm("Alice", "Bob"),
+		}
+		err := store.CreateGroup(ctx, group)
+		if err != nil {
+			t.Fatalf("CreateGroup failed: %v", err)
+		}
+
+		bill := &models.Bill{
+			Title:        "Group Dinner",
+			Total:        50.0,
+			Subtotal:     45.0,
+			Participants: bp("Alice", "Bob"),
+			GroupID:      group.ID,
 		}
 
 		err = store.CreateBill(ctx, bill)
@@ -786,7 +1332,7 @@ func TestListBillsByUser(t *testing.T) {
 	}
 
 	t.Run("returns bills where user is participant", func(t *testing.T) {
-		bills, err := store.ListBillsByUser(ctx, aliceID)
+		bills, err := store.ListBillsByUser(ctx, aliceID, storage.BillFilter{})
 		if err != nil {
 			t.Fatalf("ListBillsByUser failed: %v", err)
 		}
@@ -816,7 +1362,7 @@ func TestListBillsByUser(t *testing.T) {
 	})
 
 	t.Run("creator-only bill appears without participant entry", func(t *testing.T) {
-		bills, err := store.ListBillsByUser(ctx, aliceID)
+		bills, err := store.ListBillsByUser(ctx, aliceID, storage.BillFilter{})
 		if err != nil {
 			t.Fatalf("ListBillsByUser failed: %v", err)
 		}
@@ -838,7 +1384,7 @@ func TestListBillsByUser(t *testing.T) {
 	})
 
 	t.Run("returns empty slice for nonexistent user_id", func(t *testing.T) {
-		bills, err := store.ListBillsByUser(ctx, "nonexistent-uuid")
+		bills, err := store.ListBillsByUser(ctx, "nonexistent-uuid", storage.BillFilter{})
 		if err != nil {
 			t.Fatalf("ListBillsByUser failed: %v", err)
 		}
@@ -848,7 +1394,7 @@ func TestListBillsByUser(t *testing.T) {
 	})
 
 	t.Run("participants are populated on returned bills", func(t *testing.T) {
-		bills, err := store.ListBillsByUser(ctx, aliceID)
+		bills, err := store.ListBillsByUser(ctx, aliceID, storage.BillFilter{})
 		if err != nil {
 			t.Fatalf("ListBillsByUser failed: %v", err)
 		}
@@ -1007,7 +1553,7 @@ func TestSettlementStorage(t *testing.T) {
 			CreatedBy:  charlieUser.ID,
 		})
 
-		settlements, err := store.ListSettlementsByGroup(ctx, group2.ID)
+		settlements, err := store.ListSettlementsByGroup(ctx, group2.ID, storage.SettlementFilter{})
 		if err != nil {
 			t.Fatalf("ListSettlementsByGroup failed: %v", err)
 		}
@@ -1203,3 +1749,1421 @@ func TestFriendshipStorage(t *testing.T) {
 		}
 	})
 }
+
+func TestUserEmailStorage(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "splitwiser-emails-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	store, err := New(filepath.Join(tempDir, "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+
+	for _, u := range []*models.User{
+		{ID: "user-alice", Email: "alice@example.com", DisplayName: "Alice", CreatedAt: 1000, UpdatedAt: 1000},
+		{ID: "user-bob", Email: "bob@example.com", DisplayName: "Bob", CreatedAt: 1000, UpdatedAt: 1000},
+	} {
+		if err := store.CreateUser(ctx, u); err != nil {
+			t.Fatalf("CreateUser failed: %v", err)
+		}
+	}
+
+	link := &models.UserEmail{UserID: "user-alice", Email: "alice@work.com", TokenHash: "hash", TokenExpiresAt: 5000, CreatedAt: 2000}
+	if err := store.AddUserEmail(ctx, link); err != nil {
+		t.Fatalf("AddUserEmail failed: %v", err)
+	}
+
+	t.Run("GetUserByEmail ignores unverified addresses", func(t *testing.T) {
+		user, err := store.GetUserByEmail(ctx, "alice@work.com")
+		if err != nil {
+			t.Fatalf("GetUserByEmail failed: %v", err)
+		}
+		if user != nil {
+			t.Errorf("expected no user for unverified email, got %s", user.ID)
+		}
+	})
+
+	t.Run("GetUserByEmail resolves verified addresses", func(t *testing.T) {
+		if err := store.MarkUserEmailVerified(ctx, "user-alice", "alice@work.com", 3000); err != nil {
+			t.Fatalf("MarkUserEmailVerified failed: %v", err)
+		}
+		user, err := store.GetUserByEmail(ctx, "alice@work.com")
+		if err != nil {
+			t.Fatalf("GetUserByEmail failed: %v", err)
+		}
+		if user == nil || user.ID != "user-alice" {
+			t.Fatalf("expected user-alice, got %+v", user)
+		}
+
+		got, err := store.GetUserEmail(ctx, "user-alice", "alice@work.com")
+		if err != nil {
+			t.Fatalf("GetUserEmail failed: %v", err)
+		}
+		if !got.Verified || got.TokenHash != "" || got.VerifiedAt != 3000 {
+			t.Errorf("unexpected verified email state: %+v", got)
+		}
+	})
+
+	t.Run("Only one account can verify an address", func(t *testing.T) {
+		if err := store.AddUserEmail(ctx, &models.UserEmail{UserID: "user-bob", Email: "alice@work.com", CreatedAt: 4000}); err != nil {
+			t.Fatalf("AddUserEmail failed: %v", err)
+		}
+		if err := store.MarkUserEmailVerified(ctx, "user-bob", "alice@work.com", 4000); err == nil {
+			t.Error("expected error verifying an address another account already verified")
+		}
+	})
+
+	t.Run("ListUserEmails and DeleteUserEmail", func(t *testing.T) {
+		emails, err := store.ListUserEmails(ctx, "user-alice")
+		if err != nil {
+			t.Fatalf("ListUserEmails failed: %v", err)
+		}
+		if len(emails) != 1 || emails[0].Email != "alice@work.com" {
+			t.Fatalf("expected one linked email, got %+v", emails)
+		}
+
+		if err := store.DeleteUserEmail(ctx, "user-alice", "alice@work.com"); err != nil {
+			t.Fatalf("DeleteUserEmail failed: %v", err)
+		}
+		if err := store.DeleteUserEmail(ctx, "user-alice", "alice@work.com"); err == nil {
+			t.Error("expected error deleting already-removed email")
+		}
+	})
+}
+
+func TestQueryHook(t *testing.T) {
+	type ctxKey struct{}
+	var (
+		mu      sync.Mutex
+		queries []string
+	)
+	hook := func(ctx context.Context, query string, duration time.Duration, err error) {
+		if ctx.Value(ctxKey{}) == nil {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		queries = append(queries, query)
+	}
+
+	store, err := New(filepath.Join(t.TempDir(), "test.db"), WithQueryHook(hook))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, true)
+	bill := &models.Bill{Title: "Lunch", Total: 10, Subtotal: 10, Participants: bp("Alice")}
+	if err := store.CreateBill(ctx, bill); err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+	if _, err := store.GetBill(ctx, bill.ID); err != nil {
+		t.Fatalf("GetBill failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	var sawInsert, sawSelect bool
+	for _, q := range queries {
+		sawInsert = sawInsert || strings.Contains(q, "INSERT INTO bills")
+		sawSelect = sawSelect || strings.Contains(q, "FROM bills")
+	}
+	if !sawInsert || !sawSelect {
+		t.Errorf("hook saw %d queries without the bill insert and select: %v", len(queries), queries)
+	}
+}
+
+func TestConnectionSettings(t *testing.T) {
+	ctx := context.Background()
+	store, err := New(filepath.Join(t.TempDir(), "test.db"), WithMaxOpenConns(4))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	var mode string
+	if err := store.db.QueryRowContext(ctx, "PRAGMA journal_mode").Scan(&mode); err != nil {
+		t.Fatalf("journal_mode: %v", err)
+	}
+	if mode != "wal" {
+		t.Errorf("journal_mode = %q, want wal", mode)
+	}
+
+	// Pragmas from the DSN reach every pooled connection, not just the first.
+	var conns []*sql.Conn
+	for range 3 {
+		conn, err := store.db.Conn(ctx)
+		if err != nil {
+			t.Fatalf("Conn: %v", err)
+		}
+		defer conn.Close()
+		conns = append(conns, conn)
+	}
+	for i, conn := range conns {
+		var fk, timeout int
+		if err := conn.QueryRowContext(ctx, "SELECT (SELECT foreign_keys FROM pragma_foreign_keys), (SELECT timeout FROM pragma_busy_timeout)").Scan(&fk, &timeout); err != nil {
+			t.Fatalf("conn %d pragmas: %v", i, err)
+		}
+		if fk != 1 || timeout != int(DefaultBusyTimeout.Milliseconds()) {
+			t.Errorf("conn %d: foreign_keys = %d, busy_timeout = %d", i, fk, timeout)
+		}
+	}
+}
+
+func TestConcurrentWrites(t *testing.T) {
+	store, err := New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 40)
+	for i := range 40 {
+		wg.Go(func() {
+			bill := &models.Bill{Title: fmt.Sprintf("Bill %d", i), Total: 10, Subtotal: 10, Participants: bp("Alice", "Bob")}
+			errs <- store.CreateBill(context.Background(), bill)
+		})
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("concurrent CreateBill failed: %v", err)
+		}
+	}
+}
+
+func TestBusyRetry(t *testing.T) {
+	ctx := context.Background()
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	holder, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer holder.Close()
+
+	// Without waiting or retrying, a write behind another writer fails.
+	noRetry, err := New(dbPath, WithBusyTimeout(0), WithBusyRetries(0))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer noRetry.Close()
+	tx, err := holder.db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("BeginTx: %v", err)
+	}
+	err = noRetry.CreateBill(ctx, &models.Bill{Title: "Lunch", Total: 10, Subtotal: 10, Participants: bp("Alice")})
+	if !isBusy(err) {
+		t.Errorf("CreateBill under a held lock = %v, want SQLITE_BUSY", err)
+	}
+
+	// Retries outlast a lock that is released shortly.
+	retrying, err := New(dbPath, WithBusyTimeout(0), WithBusyRetries(6))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer retrying.Close()
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		tx.Rollback()
+	}()
+	if err := retrying.CreateBill(ctx, &models.Bill{Title: "Lunch", Total: 10, Subtotal: 10, Participants: bp("Alice")}); err != nil {
+		t.Errorf("CreateBill with retries failed: %v", err)
+	}
+}
+
+func TestTypedErrors(t *testing.T) {
+	ctx := context.Background()
+	store, err := New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	if _, err := store.GetBill(ctx, "missing"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("GetBill of a missing bill = %v, want ErrNotFound", err)
+	}
+	if err := store.DeleteGroup(ctx, "missing"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("DeleteGroup of a missing group = %v, want ErrNotFound", err)
+	}
+
+	alice := &models.User{ID: "alice-id", Email: "alice@test.com", DisplayName: "Alice", PasswordHash: "h", CreatedAt: 1, UpdatedAt: 1}
+	if err := store.CreateUser(ctx, alice); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	again := &models.User{ID: "alice-2", Email: "alice@test.com", DisplayName: "Alice", PasswordHash: "h", CreatedAt: 1, UpdatedAt: 1}
+	if err := store.CreateUser(ctx, again); !errors.Is(err, storage.ErrConflict) {
+		t.Errorf("CreateUser with a taken email = %v, want ErrConflict", err)
+	}
+
+	attachment := &models.Attachment{BillID: "missing", UploaderID: alice.ID, Name: "r.png", ContentType: "image/png", BlobKey: "k"}
+	if err := store.CreateAttachment(ctx, attachment); !errors.Is(err, storage.ErrForeignKey) {
+		t.Errorf("CreateAttachment for a missing bill = %v, want ErrForeignKey", err)
+	}
+}
+
+func TestComments(t *testing.T) {
+	ctx := context.Background()
+	store, err := New(filepath.Join(t.TempDir(), "test.db"), WithEncryption(bytes.Repeat([]byte{1}, KeySize)))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	bill := &models.Bill{Title: "Pub", Total: 30, Subtotal: 30, Participants: bp("Alice", "Bob")}
+	if err := store.CreateBill(ctx, bill); err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+	first := &models.Comment{BillID: bill.ID, AuthorID: "bob-id", Body: "I didn't have the beer", CreatedAt: 2000}
+	second := &models.Comment{BillID: bill.ID, AuthorID: "alice-id", Body: "You had two", CreatedAt: 1000}
+	for _, c := range []*models.Comment{first, second} {
+		if err := store.CreateComment(ctx, c); err != nil {
+			t.Fatalf("CreateComment failed: %v", err)
+		}
+	}
+	if first.ID == "" {
+		t.Error("expected CreateComment to set the ID")
+	}
+
+	comments, err := store.ListCommentsByBill(ctx, bill.ID)
+	if err != nil {
+		t.Fatalf("ListCommentsByBill failed: %v", err)
+	}
+	if len(comments) != 2 || comments[0].ID != second.ID || comments[1].Body != "I didn't have the beer" {
+		t.Errorf("ListCommentsByBill = %v, want the comments oldest first", comments)
+	}
+	var stored string
+	if err := store.db.QueryRowContext(ctx, "SELECT body FROM comments WHERE id = ?", first.ID).Scan(&stored); err != nil {
+		t.Fatalf("failed to read comment: %v", err)
+	}
+	if !strings.HasPrefix(stored, encryptedPrefix) {
+		t.Errorf("comment stored in plain text: %q", stored)
+	}
+
+	if err := store.DeleteComment(ctx, first.ID); err != nil {
+		t.Fatalf("DeleteComment failed: %v", err)
+	}
+	if _, err := store.GetComment(ctx, first.ID); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("GetComment of a deleted comment = %v, want ErrNotFound", err)
+	}
+	if err := store.DeleteComment(ctx, first.ID); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("DeleteComment of a deleted comment = %v, want ErrNotFound", err)
+	}
+	if err := store.CreateComment(ctx, &models.Comment{BillID: "missing", AuthorID: "alice-id", Body: "Hi"}); !errors.Is(err, storage.ErrForeignKey) {
+		t.Errorf("CreateComment for a missing bill = %v, want ErrForeignKey", err)
+	}
+}
+
+func TestSearchBills(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	store, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+
+	group := &models.Group{Name: "Trip", Members: gm("Alice", "Bob")}
+	if err := store.CreateGroup(ctx, group); err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	dinner := &models.Bill{
+		Title:        "Dinner",
+		Total:        30,
+		Subtotal:     30,
+		CreatedAt:    100,
+		GroupID:      group.ID,
+		CreatorID:    "user-1",
+		Participants: bp("Alice", "Bob"),
+		Items:        []models.Item{{Description: "Margherita pizza", Amount: 30, Participants: []string{"Alice", "Bob"}}},
+	}
+	cafe := &models.Bill{
+		Title:        "Café",
+		Total:        8,
+		Subtotal:     8,
+		CreatedAt:    200,
+		CreatorID:    "user-1",
+		Participants: bp("Alice", "Zoë"),
+	}
+	other := &models.Bill{
+		Title:        "Pizza night",
+		Total:        20,
+		Subtotal:     20,
+		CreatorID:    "user-2",
+		Participants: bp("Carol"),
+	}
+	for _, b := range []*models.Bill{dinner, cafe, other} {
+		if err := store.CreateBill(ctx, b); err != nil {
+			t.Fatalf("CreateBill failed: %v", err)
+		}
+	}
+
+	search := func(query string, filter storage.BillFilter) []string {
+		t.Helper()
+		bills, err := store.SearchBills(ctx, "user-1", query, filter)
+		if err != nil {
+			t.Fatalf("SearchBills(%q) failed: %v", query, err)
+		}
+		var titles []string
+		for _, b := range bills {
+			titles = append(titles, b.Title)
+		}
+		return titles
+	}
+	tests := []struct {
+		query  string
+		filter storage.BillFilter
+		want   []string
+	}{
+		{"pizz", storage.BillFilter{}, []string{"Dinner"}},       // item prefix; user-2's bill is excluded
+		{"marg pizza", storage.BillFilter{}, []string{"Dinner"}}, // every word, any order
+		{"pizza zoe", storage.BillFilter{}, nil},                 // words must share a bill
+		{"zoe", storage.BillFilter{}, []string{"Café"}},          // participant name, diacritics folded
+		{"cafe", storage.BillFilter{}, []string{"Café"}},         // title
+		{`"zoë*(`, storage.BillFilter{}, []string{"Café"}},       // FTS5 syntax is ignored
+		{"alice", storage.BillFilter{GroupID: group.ID}, []string{"Dinner"}},
+		{"alice", storage.BillFilter{Ungrouped: true}, []string{"Café"}},
+		{"alice", storage.BillFilter{Since: 150}, []string{"Café"}},
+		{"alice", storage.BillFilter{Page: storage.Page{Limit: 1}}, []string{"Café"}},
+		{"!!", storage.BillFilter{}, nil},
+	}
+	for _, tt := range tests {
+		if got := search(tt.query, tt.filter); !slices.Equal(got, tt.want) {
+			t.Errorf("SearchBills(%q, %+v) = %v, want %v", tt.query, tt.filter, got, tt.want)
+		}
+	}
+
+	dinner.Items = []models.Item{{Description: "Sushi", Amount: 30, Participants: []string{"Alice", "Bob"}}}
+	if err := store.UpdateBill(ctx, dinner); err != nil {
+		t.Fatalf("UpdateBill failed: %v", err)
+	}
+	if got := search("pizza", storage.BillFilter{}); got != nil {
+		t.Errorf("after replacing the item, pizza found %v", got)
+	}
+	if got := search("sushi", storage.BillFilter{}); !slices.Equal(got, []string{"Dinner"}) {
+		t.Errorf("after replacing the item, sushi found %v, want Dinner", got)
+	}
+
+	if err := store.DeleteBill(ctx, dinner.ID); err != nil {
+		t.Fatalf("DeleteBill failed: %v", err)
+	}
+	if got := search("sushi", storage.BillFilter{}); got != nil {
+		t.Errorf("trashed bill found: %v", got)
+	}
+	if err := store.PurgeBill(ctx, dinner.ID); err != nil {
+		t.Fatalf("PurgeBill failed: %v", err)
+	}
+	var indexed int
+	if err := store.db.QueryRow("SELECT COUNT(*) FROM bills_fts WHERE bill_id = ?", dinner.ID).Scan(&indexed); err != nil || indexed != 0 {
+		t.Errorf("purged bill still indexed: %d rows, err %v", indexed, err)
+	}
+
+	// Bills from before the index existed are indexed on open.
+	if _, err := store.db.Exec("DELETE FROM bills_fts"); err != nil {
+		t.Fatalf("clearing the index: %v", err)
+	}
+	reopened, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	defer reopened.Close()
+	if bills, err := reopened.SearchBills(ctx, "user-1", "zoe", storage.BillFilter{}); err != nil || len(bills) != 1 {
+		t.Errorf("after reopening, zoe found %v (err %v), want Café", bills, err)
+	}
+}
+
+func TestSchemaDrift(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	store, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	store.Close()
+
+	// Hand-edit a table the way an old or foreign tool might have left it:
+	// user_id gone, a required column nothing fills, and amount as text.
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`
+		DROP TABLE participants;
+		CREATE TABLE participants (
+			bill_id TEXT NOT NULL,
+			name TEXT NOT NULL,
+			amount TEXT,
+			legacy_id TEXT NOT NULL,
+			PRIMARY KEY (bill_id, name)
+		);
+		DROP TABLE invitations;`)
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = New(dbPath)
+	var drift *SchemaDriftError
+	if !errors.As(err, &drift) {
+		t.Fatalf("New on a drifted database = %v, want a SchemaDriftError", err)
+	}
+	want := []string{
+		"participants: missing column user_id TEXT",
+		"participants.amount: is amount TEXT, want amount REAL NOT NULL",
+		"participants: unexpected column legacy_id TEXT NOT NULL has no default",
+	}
+	for _, w := range want {
+		if !slices.Contains(drift.Diffs, w) {
+			t.Errorf("diffs %q missing %q", drift.Diffs, w)
+		}
+	}
+	if len(drift.Diffs) != len(want) {
+		t.Errorf("got %d diffs, want %d: %q", len(drift.Diffs), len(want), drift.Diffs)
+	}
+}
+
+func TestBackupRestore(t *testing.T) {
+	store, err := New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+
+	lunch := &models.Bill{Title: "Lunch", Total: 10, Subtotal: 10, CreatorID: "user-1", Participants: bp("Alice")}
+	if err := store.CreateBill(ctx, lunch); err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+	var backup bytes.Buffer
+	if err := store.Backup(ctx, &backup); err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+
+	dinner := &models.Bill{Title: "Dinner", Total: 30, Subtotal: 30, CreatorID: "user-1", Participants: bp("Alice")}
+	if err := store.CreateBill(ctx, dinner); err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+
+	// Bad backups are refused and leave the database alone.
+	for name, data := range map[string][]byte{
+		"garbage":        []byte("not a database at all, just some bytes that go on for a while"),
+		"empty database": nil,
+	} {
+		if err := store.Restore(ctx, bytes.NewReader(data)); err == nil {
+			t.Errorf("Restore(%s) succeeded, want an error", name)
+		}
+	}
+	if _, err := store.GetBill(ctx, dinner.ID); err != nil {
+		t.Fatalf("a refused restore changed the database: %v", err)
+	}
+
+	if err := store.Restore(ctx, bytes.NewReader(backup.Bytes())); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if _, err := store.GetBill(ctx, lunch.ID); err != nil {
+		t.Errorf("backed-up bill missing after restore: %v", err)
+	}
+	if _, err := store.GetBill(ctx, dinner.ID); err == nil {
+		t.Error("bill created after the backup survived the restore")
+	}
+	if bills, err := store.SearchBills(ctx, "user-1", "lunch", storage.BillFilter{}); err != nil || len(bills) != 1 {
+		t.Errorf("search after restore = %v (err %v), want Lunch", bills, err)
+	}
+}
+
+func TestAnonymize(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	store, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	ctx := context.Background()
+
+	secrets := []string{"Zelda Quixote", "Yorick Bramble", "zelda@secret.test", "Smuggler Cove", "Zanzibar Dinner", "Wagyu Tartare", "for the wagyu"}
+	if err := store.CreateUser(ctx, &models.User{ID: "user-zelda", Email: "zelda@secret.test", DisplayName: "Zelda Quixote", PasswordHash: "hash", CreatedAt: 1, UpdatedAt: 1}); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	group := &models.Group{Name: "Smuggler Cove", Members: []models.GroupMember{gmWithID("Zelda Quixote", "user-zelda"), {DisplayName: "Yorick Bramble"}}, CreatorID: "user-zelda"}
+	if err := store.CreateGroup(ctx, group); err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	bill := &models.Bill{
+		Title:        "Zanzibar Dinner",
+		Total:        90,
+		Subtotal:     90,
+		Items:        []models.Item{{Description: "Wagyu Tartare", Amount: 90, Participants: []string{"Yorick Bramble"}}},
+		Participants: []models.BillParticipant{bpWithID("Zelda Quixote", "user-zelda"), {DisplayName: "Yorick Bramble"}},
+		PayerID:      "Zelda Quixote",
+		GroupID:      group.ID,
+		CreatorID:    "user-zelda",
+	}
+	if err := store.CreateBill(ctx, bill); err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+	if err := store.CreateSettlement(ctx, &models.Settlement{GroupID: &group.ID, FromUserID: "Yorick Bramble", ToUserID: "Zelda Quixote", Amount: 40, CreatedBy: "user-zelda", Note: "for the wagyu"}); err != nil {
+		t.Fatalf("CreateSettlement failed: %v", err)
+	}
+
+	if err := store.Anonymize(ctx, []byte("key")); err != nil {
+		t.Fatalf("Anonymize failed: %v", err)
+	}
+
+	got, err := store.GetBill(ctx, bill.ID)
+	if err != nil {
+		t.Fatalf("GetBill failed: %v", err)
+	}
+	zelda, yorick := got.Participants[0].DisplayName, got.Participants[1].DisplayName
+	if zelda == "Zelda Quixote" || !strings.HasPrefix(zelda, "Person ") || zelda == yorick {
+		t.Errorf("participants = %q, %q; want distinct pseudonyms", zelda, yorick)
+	}
+	if got.PayerID != zelda || got.Items[0].Participants[0] != yorick {
+		t.Errorf("payer %q and item participant %q don't match participants %q, %q", got.PayerID, got.Items[0].Participants[0], zelda, yorick)
+	}
+	if got.Total != 90 || got.Items[0].Amount != 90 || got.Participants[0].UserID != "user-zelda" {
+		t.Errorf("amounts or links changed: %+v", got)
+	}
+
+	g, err := store.GetGroup(ctx, group.ID)
+	if err != nil {
+		t.Fatalf("GetGroup failed: %v", err)
+	}
+	members := []string{g.Members[0].DisplayName, g.Members[1].DisplayName}
+	if !slices.Contains(members, zelda) || !slices.Contains(members, yorick) {
+		t.Errorf("group members %v don't match bill participants %q, %q", members, zelda, yorick)
+	}
+	settlements, err := store.ListSettlementsByGroup(ctx, group.ID, storage.SettlementFilter{})
+	if err != nil || len(settlements) != 1 {
+		t.Fatalf("ListSettlementsByGroup = %v, %v", settlements, err)
+	}
+	if s := settlements[0]; s.FromUserID != yorick || s.ToUserID != zelda || s.Amount != 40 {
+		t.Errorf("settlement = %+v, want %s paying %s 40", s, yorick, zelda)
+	}
+
+	user, err := store.GetUserByID(ctx, "user-zelda")
+	if err != nil {
+		t.Fatalf("GetUserByID failed: %v", err)
+	}
+	if user.DisplayName != zelda || !strings.HasSuffix(user.Email, "@example.invalid") || user.PasswordHash != "" {
+		t.Errorf("user = %+v, want pseudonymous name and email and no password", user)
+	}
+
+	// The same key gives the same pseudonyms; another key doesn't.
+	p := pseudonyms{key: []byte("key")}
+	if p.of(pseudoPerson, "Zelda Quixote") != zelda {
+		t.Error("pseudonyms are not deterministic")
+	}
+	if (pseudonyms{key: []byte("other")}).of(pseudoPerson, "Zelda Quixote") == zelda {
+		t.Error("pseudonyms don't depend on the key")
+	}
+
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	for _, path := range []string{dbPath, dbPath + "-wal"} {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			t.Fatalf("ReadFile failed: %v", err)
+		}
+		for _, secret := range secrets {
+			if bytes.Contains(bytes.ToLower(data), bytes.ToLower([]byte(secret))) {
+				t.Errorf("%s still contains %q", filepath.Base(path), secret)
+			}
+		}
+	}
+}
+
+func TestExportImportAll(t *testing.T) {
+	ctx := context.Background()
+	newStore := func(t *testing.T) *SQLiteStore {
+		t.Helper()
+		store, err := New(filepath.Join(t.TempDir(), "test.db"))
+		if err != nil {
+			t.Fatalf("Failed to create store: %v", err)
+		}
+		t.Cleanup(func() { store.Close() })
+		return store
+	}
+
+	src := newStore(t)
+	if err := src.CreateUser(ctx, &models.User{ID: "user-alice", Email: "alice@example.com", DisplayName: "Alice", PasswordHash: "hash", CreatedAt: 1, UpdatedAt: 1}); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	group := &models.Group{Name: "Flat", Members: []models.GroupMember{gmWithID("Alice", "user-alice"), {DisplayName: "Bob"}}, CreatorID: "user-alice"}
+	if err := src.CreateGroup(ctx, group); err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	bill := &models.Bill{
+		Title:        "Groceries",
+		Total:        12.5,
+		Subtotal:     12.5,
+		Items:        []models.Item{{Description: "Cheese", Amount: 12.5, Participants: []string{"Alice", "Bob"}}},
+		Participants: []models.BillParticipant{bpWithID("Alice", "user-alice"), {DisplayName: "Bob"}},
+		PayerID:      "Alice",
+		GroupID:      group.ID,
+		CreatorID:    "user-alice",
+	}
+	if err := src.CreateBill(ctx, bill); err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+	if err := src.CreateSettlement(ctx, &models.Settlement{GroupID: &group.ID, FromUserID: "Bob", ToUserID: "Alice", Amount: 6.25, CreatedBy: "user-alice"}); err != nil {
+		t.Fatalf("CreateSettlement failed: %v", err)
+	}
+
+	var export bytes.Buffer
+	if err := src.ExportAll(ctx, &export); err != nil {
+		t.Fatalf("ExportAll failed: %v", err)
+	}
+
+	dst := newStore(t)
+	result, err := dst.ImportAll(ctx, bytes.NewReader(export.Bytes()), true)
+	if err != nil {
+		t.Fatalf("ImportAll (dry run) failed: %v", err)
+	}
+	if !result.DryRun || result.Rows["bills"] != 1 || result.Rows["users"] != 1 {
+		t.Errorf("dry run result = %+v, want one bill and one user", result)
+	}
+	if _, err := dst.GetBill(ctx, bill.ID); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("a dry run kept the bill: %v", err)
+	}
+
+	if _, err := dst.ImportAll(ctx, bytes.NewReader(export.Bytes()), false); err != nil {
+		t.Fatalf("ImportAll failed: %v", err)
+	}
+	want, err := src.GetBill(ctx, bill.ID)
+	if err != nil {
+		t.Fatalf("GetBill failed: %v", err)
+	}
+	got, err := dst.GetBill(ctx, bill.ID)
+	if err != nil {
+		t.Fatalf("imported bill missing: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("imported bill = %+v, want %+v", got, want)
+	}
+	if user, err := dst.GetUserByEmail(ctx, "alice@example.com"); err != nil || user.ID != "user-alice" {
+		t.Errorf("imported user = %+v (err %v), want user-alice", user, err)
+	}
+	settlements, err := dst.ListSettlementsByGroup(ctx, group.ID, storage.SettlementFilter{})
+	if err != nil || len(settlements) != 1 || settlements[0].Amount != 6.25 {
+		t.Errorf("imported settlements = %v (err %v), want Bob paying 6.25", settlements, err)
+	}
+	if bills, err := dst.SearchBills(ctx, "user-alice", "cheese", storage.BillFilter{}); err != nil || len(bills) != 1 {
+		t.Errorf("search after import = %v (err %v), want Groceries", bills, err)
+	}
+
+	// A database with rows already is refused.
+	if _, err := dst.ImportAll(ctx, bytes.NewReader(export.Bytes()), true); !errors.Is(err, storage.ErrConflict) {
+		t.Errorf("ImportAll into a non-empty database = %v, want ErrConflict", err)
+	}
+
+	// So is an export whose rows refer to rows it doesn't have.
+	dangling := strings.Replace(export.String(), `"group_id": "`+group.ID+`"`, `"group_id": "no-such-group"`, 1)
+	if _, err := newStore(t).ImportAll(ctx, strings.NewReader(dangling), true); !errors.Is(err, storage.ErrForeignKey) {
+		t.Errorf("ImportAll with a dangling reference = %v, want ErrForeignKey", err)
+	}
+}
+
+func TestCategories(t *testing.T) {
+	store, err := New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+
+	categories, err := store.ListCategories(ctx)
+	if err != nil {
+		t.Fatalf("ListCategories failed: %v", err)
+	}
+	if len(categories) != len(standardCategories) {
+		t.Errorf("got %d categories, want the %d standard ones", len(categories), len(standardCategories))
+	}
+
+	for _, category := range []string{"food", "rent", ""} {
+		bill := &models.Bill{Title: "Bill " + category, Total: 10, Subtotal: 10, CreatorID: "user-1", Participants: bp("Alice"), CategoryID: category}
+		if err := store.CreateBill(ctx, bill); err != nil {
+			t.Fatalf("CreateBill failed: %v", err)
+		}
+	}
+	bills, err := store.ListBillsByUser(ctx, "user-1", storage.BillFilter{CategoryID: "rent"})
+	if err != nil || len(bills) != 1 || bills[0].CategoryID != "rent" {
+		t.Errorf("rent bills = %v (err %v), want one", bills, err)
+	}
+
+	bogus := &models.Bill{Title: "Bogus", Total: 10, Subtotal: 10, Participants: bp("Alice"), CategoryID: "no-such-category"}
+	if err := store.CreateBill(ctx, bogus); !errors.Is(err, storage.ErrForeignKey) {
+		t.Errorf("CreateBill with an unknown category = %v, want ErrForeignKey", err)
+	}
+}
+
+func TestGetBillsByIDs(t *testing.T) {
+	type ctxKey struct{}
+	var (
+		mu      sync.Mutex
+		queries int
+	)
+	hook := func(ctx context.Context, query string, duration time.Duration, err error) {
+		if ctx.Value(ctxKey{}) != nil {
+			mu.Lock()
+			queries++
+			mu.Unlock()
+		}
+	}
+	store, err := New(filepath.Join(t.TempDir(), "test.db"), WithQueryHook(hook))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+
+	group := &models.Group{Name: "Trip", Members: gm("Alice", "Bob"), CreatorID: "user-1"}
+	if err := store.CreateGroup(ctx, group); err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	var ids []string
+	for i := range 5 {
+		bill := &models.Bill{
+			Title:    fmt.Sprintf("Bill %d", i),
+			Total:    30,
+			Subtotal: 30,
+			Items: []models.Item{
+				{Description: "Beer", Amount: 18, Quantity: 3, UnitPrice: 6, Participants: []string{"Alice", "Bob"}, Units: map[string]float64{"Alice": 2, "Bob": 1}},
+				{Description: "Chips", Amount: 12, Participants: []string{"Alice", "Bob"}},
+			},
+			Fees:         []models.Fee{{Description: "Delivery", Amount: 2}},
+			Participants: bp("Alice", "Bob"),
+			PayerID:      "Alice",
+			GroupID:      group.ID,
+		}
+		if err := store.CreateBill(ctx, bill); err != nil {
+			t.Fatalf("CreateBill failed: %v", err)
+		}
+		ids = append(ids, bill.ID)
+	}
+	if err := store.DeleteBill(ctx, ids[4]); err != nil {
+		t.Fatalf("DeleteBill failed: %v", err)
+	}
+
+	// The order of ids is kept; unknown, repeated and trashed IDs are left out.
+	counted := context.WithValue(ctx, ctxKey{}, true)
+	bills, err := store.GetBillsByIDs(counted, []string{ids[2], "no-such-bill", ids[0], ids[3], ids[1], ids[0], ids[4]})
+	if err != nil {
+		t.Fatalf("GetBillsByIDs failed: %v", err)
+	}
+	var got []string
+	for _, bill := range bills {
+		got = append(got, bill.ID)
+	}
+	if want := []string{ids[2], ids[0], ids[3], ids[1]}; !slices.Equal(got, want) {
+		t.Fatalf("GetBillsByIDs returned %v, want %v", got, want)
+	}
+	if queries != 5 {
+		t.Errorf("GetBillsByIDs ran %d queries for %d bills, want 5", queries, len(bills))
+	}
+
+	for _, bill := range bills {
+		want, err := store.GetBill(ctx, bill.ID)
+		if err != nil {
+			t.Fatalf("GetBill failed: %v", err)
+		}
+		if !reflect.DeepEqual(bill, want) {
+			t.Errorf("GetBillsByIDs bill = %+v, GetBill = %+v", bill, want)
+		}
+	}
+
+	if bills, err := store.GetBillsByIDs(ctx, nil); err != nil || len(bills) != 0 {
+		t.Errorf("GetBillsByIDs(nil) = %v, %v; want nothing", bills, err)
+	}
+}
+
+func TestCreateBillBatchesItems(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		inserts = make(map[string]int)
+	)
+	hook := func(ctx context.Context, query string, duration time.Duration, err error) {
+		if table, ok := strings.CutPrefix(query, "INSERT INTO "); ok {
+			table, _, _ = strings.Cut(table, " ")
+			mu.Lock()
+			inserts[table]++
+			mu.Unlock()
+		}
+	}
+	store, err := New(filepath.Join(t.TempDir(), "test.db"), WithQueryHook(hook))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+
+	// More items, and assignments, than fit in one statement.
+	bill := &models.Bill{Title: "Warehouse run", Participants: bp("Alice", "Bob"), PayerID: "Alice"}
+	for i := range maxInsertRows + 1 {
+		bill.Items = append(bill.Items, models.Item{
+			Description:  fmt.Sprintf("Item %d", i),
+			Amount:       1,
+			Participants: []string{"Alice", "Bob"},
+			Units:        map[string]float64{"Alice": 1, "Bob": 2},
+		})
+		bill.Total++
+	}
+	bill.Subtotal = bill.Total
+	if err := store.CreateBill(ctx, bill); err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+	if inserts["items"] != 2 || inserts["item_assignments"] != 3 || inserts["participants"] != 1 {
+		t.Errorf("INSERT statements by table = %v, want 2 for items, 3 for item_assignments and 1 for participants", inserts)
+	}
+
+	got, err := store.GetBill(ctx, bill.ID)
+	if err != nil {
+		t.Fatalf("GetBill failed: %v", err)
+	}
+	if len(got.Items) != len(bill.Items) {
+		t.Fatalf("got %d items, want %d", len(got.Items), len(bill.Items))
+	}
+	for i, item := range got.Items {
+		if item.Description != bill.Items[i].Description || item.Units["Bob"] != 2 || len(item.Participants) != 2 {
+			t.Fatalf("item %d = %+v, want %q shared by Alice and Bob", i, item, bill.Items[i].Description)
+		}
+	}
+}
+
+func TestArchiveBills(t *testing.T) {
+	store, err := New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+
+	group := &models.Group{Name: "Trip", Members: gm("Alice", "Bob")}
+	if err := store.CreateGroup(ctx, group); err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	newBill := func(title string, createdAt int64, needsAssignment bool) *models.Bill {
+		t.Helper()
+		bill := &models.Bill{
+			Title:           title,
+			Total:           30,
+			Subtotal:        30,
+			GroupID:         group.ID,
+			CreatorID:       "user-1",
+			CreatedAt:       createdAt,
+			NeedsAssignment: needsAssignment,
+			Participants:    bp("Alice", "Bob"),
+			Items:           []models.Item{{Description: title, Amount: 30, Participants: []string{"Alice", "Bob"}}},
+		}
+		if err := store.CreateBill(ctx, bill); err != nil {
+			t.Fatalf("CreateBill failed: %v", err)
+		}
+		return bill
+	}
+	old := newBill("Old", 1000, false)
+	unassigned := newBill("Unassigned", 1000, true)
+	recent := newBill("Recent", 5000, false)
+	trashed := newBill("Trashed", 1000, false)
+	if err := store.DeleteBill(ctx, trashed.ID); err != nil {
+		t.Fatalf("DeleteBill failed: %v", err)
+	}
+
+	n, err := store.ArchiveBills(ctx, 2000)
+	if err != nil {
+		t.Fatalf("ArchiveBills failed: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("expected 1 bill archived, got %d", n)
+	}
+	if n, _ := store.ArchiveBills(ctx, 2000); n != 0 {
+		t.Errorf("expected archiving again to archive nothing, got %d", n)
+	}
+
+	got, err := store.GetBill(ctx, old.ID)
+	if err != nil {
+		t.Fatalf("GetBill failed: %v", err)
+	}
+	if got.ArchivedAt == 0 {
+		t.Error("expected the old bill to be archived")
+	}
+
+	titles := func(filter storage.BillFilter) []string {
+		t.Helper()
+		bills, err := store.ListBillsByGroup(ctx, group.ID, filter)
+		if err != nil {
+			t.Fatalf("ListBillsByGroup failed: %v", err)
+		}
+		var titles []string
+		for _, b := range bills {
+			titles = append(titles, b.Title)
+		}
+		slices.Sort(titles)
+		return titles
+	}
+	if got, want := titles(storage.BillFilter{ExcludeArchived: true}), []string{recent.Title, unassigned.Title}; !slices.Equal(got, want) {
+		t.Errorf("excluding archived: expected %v, got %v", want, got)
+	}
+	if got, want := titles(storage.BillFilter{}), []string{old.Title, recent.Title, unassigned.Title}; !slices.Equal(got, want) {
+		t.Errorf("including archived: expected %v, got %v", want, got)
+	}
+}
+
+func TestEncryption(t *testing.T) {
+	ctx := context.Background()
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	oldKey := bytes.Repeat([]byte{1}, KeySize)
+	newKey := bytes.Repeat([]byte{2}, KeySize)
+	open := func(t *testing.T, opts ...Option) *SQLiteStore {
+		t.Helper()
+		store, err := New(dbPath, opts...)
+		if err != nil {
+			t.Fatalf("Failed to create store: %v", err)
+		}
+		return store
+	}
+	raw := func(t *testing.T, store *SQLiteStore, query string) string {
+		t.Helper()
+		var value string
+		if err := store.db.QueryRowContext(ctx, query).Scan(&value); err != nil {
+			t.Fatalf("%s: %v", query, err)
+		}
+		return value
+	}
+	// check reads everything back through the store in plain text.
+	check := func(t *testing.T, store *SQLiteStore, email string) {
+		t.Helper()
+		for _, addr := range []string{"alice@example.com", "bob@example.com"} {
+			user, err := store.GetUserByEmail(ctx, addr)
+			if err != nil || user == nil {
+				t.Fatalf("GetUserByEmail(%s) = %v, %v", addr, user, err)
+			}
+			if user.Email != addr {
+				t.Errorf("expected email %s, got %s", addr, user.Email)
+			}
+		}
+		if found, err := store.SearchUsers(ctx, "bob@example.com", "alice"); err != nil || found == nil || found.ID != "bob" {
+			t.Errorf("SearchUsers = %v, %v; want bob", found, err)
+		}
+		users, err := store.GetUsersByIDs(ctx, []string{"alice"})
+		if err != nil || users["alice"].Email != "alice@example.com" {
+			t.Errorf("GetUsersByIDs = %v, %v", users, err)
+		}
+		settlement, err := store.GetSettlement(ctx, "s1")
+		if err != nil {
+			t.Fatalf("GetSettlement failed: %v", err)
+		}
+		if settlement.Note != "Taxi, cash" {
+			t.Errorf("expected note %q, got %q", "Taxi, cash", settlement.Note)
+		}
+		entries, err := store.ListAuditEntries(ctx, storage.AuditFilter{})
+		if err != nil || len(entries) != 1 || entries[0].After != `{"note":"Taxi, cash"}` {
+			t.Errorf("ListAuditEntries = %v, %v", entries, err)
+		}
+		if got := raw(t, store, "SELECT email FROM users WHERE id = 'alice'"); !strings.HasPrefix(got, email) {
+			t.Errorf("stored email = %q, want prefix %q", got, email)
+		}
+	}
+
+	// Rows written before encryption was turned on.
+	plain := open(t)
+	for _, u := range []*models.User{
+		{ID: "alice", Email: "alice@example.com", DisplayName: "Alice"},
+		{ID: "bob", Email: "bob@example.com", DisplayName: "Bob"},
+	} {
+		if err := plain.CreateUser(ctx, u); err != nil {
+			t.Fatalf("CreateUser failed: %v", err)
+		}
+	}
+	if err := plain.CreateSettlement(ctx, &models.Settlement{ID: "s1", FromUserID: "Bob", ToUserID: "Alice", Amount: 5, Note: "Taxi, cash"}); err != nil {
+		t.Fatalf("CreateSettlement failed: %v", err)
+	}
+	if err := plain.CreateAuditEntry(ctx, &models.AuditEntry{Action: models.AuditCreate, EntityType: "settlement", EntityID: "s1", After: `{"note":"Taxi, cash"}`}); err != nil {
+		t.Fatalf("CreateAuditEntry failed: %v", err)
+	}
+	check(t, plain, "alice@")
+	plain.Close()
+
+	// A key reads them as they are, and RotateEncryptionKey encrypts them.
+	encrypted := open(t, WithEncryption(oldKey))
+	check(t, encrypted, "alice@")
+	if err := encrypted.CreateUser(ctx, &models.User{ID: "carol", Email: "carol@example.com", DisplayName: "Carol"}); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	if err := encrypted.CreateUser(ctx, &models.User{ID: "carol2", Email: "carol@example.com", DisplayName: "Carol"}); err == nil {
+		t.Error("expected a second user with the same email to fail")
+	}
+	result, err := encrypted.RotateEncryptionKey(ctx)
+	if err != nil {
+		t.Fatalf("RotateEncryptionKey failed: %v", err)
+	}
+	if result.Rows[columnUserEmail] != 3 || result.Rows[columnSettlementNote] != 1 {
+		t.Errorf("unexpected rotation counts %v", result.Rows)
+	}
+	check(t, encrypted, encryptedPrefix)
+	if got := raw(t, encrypted, "SELECT note FROM settlements"); strings.Contains(got, "Taxi") {
+		t.Errorf("note stored in plain text: %q", got)
+	}
+	encrypted.Close()
+
+	// Without the key, encrypted values can't be read.
+	noKey := open(t)
+	if _, err := noKey.GetSettlement(ctx, "s1"); err == nil {
+		t.Error("expected reading an encrypted note without a key to fail")
+	}
+	noKey.Close()
+
+	// A new key reads values under the previous one, then takes them over.
+	rotated := open(t, WithEncryption(newKey, oldKey))
+	check(t, rotated, encryptedPrefix)
+	if _, err := rotated.RotateEncryptionKey(ctx); err != nil {
+		t.Fatalf("RotateEncryptionKey failed: %v", err)
+	}
+	rotated.Close()
+
+	newOnly := open(t, WithEncryption(newKey))
+	defer newOnly.Close()
+	check(t, newOnly, encryptedPrefix)
+	if user, _ := newOnly.GetUserByEmail(ctx, "carol@example.com"); user == nil || user.ID != "carol" {
+		t.Errorf("expected to find carol by email, got %v", user)
+	}
+}
+
+func TestSetBillExchangeRate(t *testing.T) {
+	store, err := New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+
+	group := &models.Group{Name: "Trip", Members: gm("Alice", "Bob"), Settings: models.GroupSettings{Currency: "EUR"}}
+	if err := store.CreateGroup(ctx, group); err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	bill := &models.Bill{
+		Title:        "Dinner",
+		Total:        40,
+		Subtotal:     40,
+		Currency:     "USD",
+		ExchangeRate: 0.9,
+		GroupID:      group.ID,
+		Participants: bp("Alice", "Bob"),
+	}
+	if err := store.CreateBill(ctx, bill); err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+	got, err := store.GetGroup(ctx, group.ID)
+	if err != nil || got.Settings.Currency != "EUR" {
+		t.Fatalf("GetGroup = %v, %v; want currency EUR", got, err)
+	}
+
+	if err := store.SetBillExchangeRate(ctx, bill.ID, 0.92); err != nil {
+		t.Fatalf("SetBillExchangeRate failed: %v", err)
+	}
+	stored, err := store.GetBill(ctx, bill.ID)
+	if err != nil {
+		t.Fatalf("GetBill failed: %v", err)
+	}
+	if stored.ExchangeRate != 0.92 {
+		t.Errorf("expected rate 0.92, got %v", stored.ExchangeRate)
+	}
+
+	if err := store.SetBillExchangeRate(ctx, "missing", 1); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if err := store.SetBillExchangeRate(ctx, bill.ID, 0); err == nil {
+		t.Error("expected a zero rate to be rejected")
+	}
+}
+
+func TestNormalizeEmailLookups(t *testing.T) {
+	ctx := context.Background()
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	open := func(opts ...Option) *SQLiteStore {
+		t.Helper()
+		store, err := New(dbPath, opts...)
+		if err != nil {
+			t.Fatalf("Failed to create store: %v", err)
+		}
+		return store
+	}
+
+	// Accounts registered before addresses were normalized.
+	store := open()
+	for i, email := range []string{"User@X.com", "ann+bills@x.com", " user@x.com", "ann@x.com"} {
+		_, err := store.db.ExecContext(ctx,
+			"INSERT INTO users (id, email, email_lookup, display_name, password_hash, created_at, updated_at) VALUES (?, ?, ?, ?, '', ?, ?)",
+			fmt.Sprintf("u%d", i), email, email, "User", i, i)
+		if err != nil {
+			t.Fatalf("insert user: %v", err)
+		}
+	}
+	store.Close()
+
+	duplicates := func(store *SQLiteStore) []DuplicateAccount {
+		t.Helper()
+		dups, err := store.ListDuplicateAccounts(ctx)
+		if err != nil {
+			t.Fatalf("ListDuplicateAccounts failed: %v", err)
+		}
+		return dups
+	}
+	store = open()
+	if got, want := duplicates(store), []DuplicateAccount{{UserID: "u2", DuplicateOf: "u0"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("duplicates = %v, want %v", got, want)
+	}
+	if user, err := store.GetUserByEmail(ctx, "USER@x.COM"); err != nil || user == nil || user.ID != "u0" {
+		t.Errorf("GetUserByEmail = %v, %v; want u0", user, err)
+	}
+	if err := store.CreateUser(ctx, &models.User{ID: "u4", Email: "user@x.com ", DisplayName: "User"}); err == nil {
+		t.Error("expected creating a user with a duplicate address to fail")
+	}
+	store.Close()
+
+	// Folding plus tags redoes the lookups, and is kept by stores opened
+	// without saying.
+	store = open(WithPlusTagFolding(true))
+	store.Close()
+	store = open()
+	defer store.Close()
+	want := []DuplicateAccount{{UserID: "u2", DuplicateOf: "u0"}, {UserID: "u3", DuplicateOf: "u1"}}
+	if got := duplicates(store); !reflect.DeepEqual(got, want) {
+		t.Errorf("duplicates = %v, want %v", got, want)
+	}
+	if user, err := store.GetUserByEmail(ctx, "Ann+Trips@x.com"); err != nil || user == nil || user.ID != "u1" {
+		t.Errorf("GetUserByEmail = %v, %v; want u1", user, err)
+	}
+}
+
+func TestMergeUsers(t *testing.T) {
+	ctx := context.Background()
+	store, err := New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	for _, u := range []*models.User{
+		{ID: "ann", Email: "ann@x.com", DisplayName: "Ann"},
+		{ID: "ann2", Email: "ann@work.com", DisplayName: "Ann W"},
+		{ID: "bob", Email: "bob@x.com", DisplayName: "Bob"},
+		{ID: "cat", Email: "cat@x.com", DisplayName: "Cat"},
+	} {
+		if err := store.CreateUser(ctx, u); err != nil {
+			t.Fatalf("CreateUser failed: %v", err)
+		}
+	}
+	trip := &models.Group{Name: "Trip", Members: []models.GroupMember{gmWithID("Ann W", "ann2"), gmWithID("Bob", "bob")}}
+	flat := &models.Group{Name: "Flat", Members: []models.GroupMember{gmWithID("Ann", "ann"), gmWithID("Ann W", "ann2")}}
+	for _, g := range []*models.Group{trip, flat} {
+		if err := store.CreateGroup(ctx, g); err != nil {
+			t.Fatalf("CreateGroup failed: %v", err)
+		}
+	}
+	bill := &models.Bill{Title: "Dinner", Total: 20, Subtotal: 20, GroupID: trip.ID, CreatorID: "ann2",
+		Participants: []models.BillParticipant{{DisplayName: "Ann W", UserID: "ann2"}, {DisplayName: "Bob", UserID: "bob"}}}
+	if err := store.CreateBill(ctx, bill); err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+	for _, f := range []*models.Friendship{
+		{RequesterID: "ann", AddresseeID: "bob", Status: models.FriendshipAccepted},
+		{RequesterID: "bob", AddresseeID: "ann2", Status: models.FriendshipAccepted},
+		{RequesterID: "ann2", AddresseeID: "cat", Status: models.FriendshipPending},
+	} {
+		if err := store.SendFriendRequest(ctx, f); err != nil {
+			t.Fatalf("SendFriendRequest failed: %v", err)
+		}
+	}
+
+	want := &MergeResult{
+		PrimaryID:   "ann",
+		DuplicateID: "ann2",
+		Rows: map[string]int{
+			"group_members.user_id":    2,
+			"bills.creator_id":         1,
+			"participants.user_id":     1,
+			"friendships.requester_id": 1,
+		},
+		Groups:             []string{flat.ID, trip.ID},
+		SharedGroups:       []string{flat.ID},
+		DroppedFriendships: 1,
+		DryRun:             true,
+	}
+	slices.Sort(want.Groups)
+
+	result, err := store.MergeUsers(ctx, "ann", "ann2", true)
+	if err != nil {
+		t.Fatalf("MergeUsers dry run failed: %v", err)
+	}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("dry run = %+v, want %+v", result, want)
+	}
+	if user, _ := store.GetUserByID(ctx, "ann2"); user == nil {
+		t.Fatal("expected the dry run to keep the duplicate account")
+	}
+
+	result, err = store.MergeUsers(ctx, "ann", "ann2", false)
+	if err != nil {
+		t.Fatalf("MergeUsers failed: %v", err)
+	}
+	want.DryRun = false
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("merge = %+v, want %+v", result, want)
+	}
+
+	if user, _ := store.GetUserByID(ctx, "ann2"); user != nil {
+		t.Error("expected the duplicate account to be deleted")
+	}
+	if user, err := store.GetUserByEmail(ctx, "Ann@Work.com"); err != nil || user == nil || user.ID != "ann" {
+		t.Errorf("GetUserByEmail of the duplicate's address = %v, %v; want ann", user, err)
+	}
+	if user, err := store.MergedInto(ctx, "ann2"); err != nil || user == nil || user.ID != "ann" {
+		t.Errorf("MergedInto = %v, %v; want ann", user, err)
+	}
+	if user, err := store.MergedInto(ctx, "bob"); err != nil || user != nil {
+		t.Errorf("MergedInto of an unmerged account = %v, %v; want nil", user, err)
+	}
+	got, err := store.GetBill(ctx, bill.ID)
+	if err != nil {
+		t.Fatalf("GetBill failed: %v", err)
+	}
+	if got.CreatorID != "ann" || got.Participants[0].UserID != "ann" {
+		t.Errorf("expected the bill to be ann's, got creator %q and participant %q", got.CreatorID, got.Participants[0].UserID)
+	}
+	for _, other := range []string{"bob", "cat"} {
+		if f, err := store.GetFriendshipBetween(ctx, "ann", other); err != nil || f == nil {
+			t.Errorf("expected ann and %s to have a friendship, got %v, %v", other, f, err)
+		}
+	}
+	entries, err := store.ListAuditEntries(ctx, storage.AuditFilter{GroupID: trip.ID})
+	if err != nil {
+		t.Fatalf("ListAuditEntries failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].EntityType != "user" || entries[0].EntityID != "ann2" {
+		t.Errorf("expected the merge in the group's audit log, got %+v", entries)
+	}
+
+	if _, err := store.MergeUsers(ctx, "ann", "ann2", false); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected ErrNotFound merging a deleted account, got %v", err)
+	}
+}
+
+func TestBillRevisions(t *testing.T) {
+	ctx := context.Background()
+	store, err := New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	bill := &models.Bill{
+		Title:        "Pub",
+		Total:        30,
+		Subtotal:     30,
+		Items:        []models.Item{{Description: "Beer", Amount: 30, Participants: []string{"Alice", "Bob"}}},
+		Participants: bp("Alice", "Bob"),
+	}
+	if err := store.CreateBill(ctx, bill); err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+	bill.EditorID = "bob-id"
+	for _, title := range []string{"Pub night", "Pub quiz"} {
+		bill.Title = title
+		if err := store.UpdateBill(ctx, bill); err != nil {
+			t.Fatalf("UpdateBill failed: %v", err)
+		}
+	}
+
+	revisions, err := store.ListBillRevisions(ctx, bill.ID)
+	if err != nil {
+		t.Fatalf("ListBillRevisions failed: %v", err)
+	}
+	if len(revisions) != 2 {
+		t.Fatalf("got %d revisions, want 2", len(revisions))
+	}
+	for i, want := range []string{"Pub", "Pub night"} {
+		r := revisions[i]
+		if r.Revision != i+1 || r.EditorID != "bob-id" || r.Bill.Title != want {
+			t.Errorf("revision %d = %d by %q titled %q, want %d by bob-id titled %q", i, r.Revision, r.EditorID, r.Bill.Title, i+1, want)
+		}
+	}
+	if items := revisions[0].Bill.Items; len(items) != 1 || items[0].Description != "Beer" {
+		t.Errorf("revision 1 items = %v, want the beer", items)
+	}
+
+	got, err := store.GetBillRevision(ctx, bill.ID, 2)
+	if err != nil {
+		t.Fatalf("GetBillRevision failed: %v", err)
+	}
+	if got.ID != revisions[1].ID {
+		t.Errorf("GetBillRevision(2) = %s, want %s", got.ID, revisions[1].ID)
+	}
+	if _, err := store.GetBillRevision(ctx, bill.ID, 3); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("GetBillRevision(3): expected ErrNotFound, got %v", err)
+	}
+
+	// Purging the bill drops its history.
+	if err := store.DeleteBill(ctx, bill.ID); err != nil {
+		t.Fatalf("DeleteBill failed: %v", err)
+	}
+	if err := store.PurgeBill(ctx, bill.ID); err != nil {
+		t.Fatalf("PurgeBill failed: %v", err)
+	}
+	if revisions, err := store.ListBillRevisions(ctx, bill.ID); err != nil || len(revisions) != 0 {
+		t.Errorf("ListBillRevisions after purge = %v, %v; want none", revisions, err)
+	}
+}
+
+func TestSharePayments(t *testing.T) {
+	ctx := context.Background()
+	store, err := New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	bill := &models.Bill{Title: "Cabin", Total: 90, Subtotal: 90, PayerID: "Alice", Participants: bp("Alice", "Bob", "Carol")}
+	if err := store.CreateBill(ctx, bill); err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+	for _, name := range []string{"Carol", "Bob"} {
+		if err := store.MarkShareAsPaid(ctx, &models.SharePayment{BillID: bill.ID, Participant: name, MarkedBy: "alice-id"}); err != nil {
+			t.Fatalf("MarkShareAsPaid(%s) failed: %v", name, err)
+		}
+	}
+	// Marking again is not an error.
+	if err := store.MarkShareAsPaid(ctx, &models.SharePayment{BillID: bill.ID, Participant: "Bob", PaidAt: 5, MarkedBy: "bob-id"}); err != nil {
+		t.Fatalf("MarkShareAsPaid failed: %v", err)
+	}
+
+	payments, err := store.ListSharePayments(ctx, []string{bill.ID, "other"})
+	if err != nil {
+		t.Fatalf("ListSharePayments failed: %v", err)
+	}
+	if len(payments) != 2 || payments[0].Participant != "Bob" || payments[0].PaidAt != 5 || payments[0].MarkedBy != "bob-id" || payments[1].PaidAt == 0 {
+		t.Errorf("ListSharePayments = %v, want Bob as marked the second time, then Carol", payments)
+	}
+
+	if err := store.UnmarkShareAsPaid(ctx, bill.ID, "Bob"); err != nil {
+		t.Fatalf("UnmarkShareAsPaid failed: %v", err)
+	}
+	payments, err = store.ListSharePayments(ctx, []string{bill.ID})
+	if err != nil {
+		t.Fatalf("ListSharePayments failed: %v", err)
+	}
+	if len(payments) != 1 || payments[0].Participant != "Carol" {
+		t.Errorf("ListSharePayments after unmarking Bob = %v, want only Carol", payments)
+	}
+
+	if err := store.MarkShareAsPaid(ctx, &models.SharePayment{BillID: "missing", Participant: "Bob"}); !errors.Is(err, storage.ErrForeignKey) {
+		t.Errorf("MarkShareAsPaid on a missing bill: expected ErrForeignKey, got %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/mmynk/splitwiser/internal/models"
+)
+
+// SetGroupStatsToken publishes a group's aggregate stats under the token with
+// the given hash, replacing any previous token.
+func (s *SQLiteStore) SetGroupStatsToken(ctx context.Context, groupID, tokenHash, createdBy string) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO group_stats_tokens (group_id, token_hash, created_at, created_by) VALUES (?, ?, ?, ?)
+		 ON CONFLICT (group_id) DO UPDATE SET token_hash = excluded.token_hash,
+		     created_at = excluded.created_at, created_by = excluded.created_by`,
+		groupID, tokenHash, time.Now().Unix(), createdBy,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to set group stats token: %w", err)
+	}
+	return nil
+}
+
+// DeleteGroupStatsToken stops publishing a group's stats. It is a no-op if
+// they weren't published.
+func (s *SQLiteStore) DeleteGroupStatsToken(ctx context.Context, groupID string) error {
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM group_stats_tokens WHERE group_id = ?", groupID); err != nil {
+		return fmt.Errorf("failed to delete group stats token: %w", err)
+	}
+	return nil
+}
+
+// GetGroupIDByStatsToken returns the group whose stats are published under
+// the token with the given hash, or "" if there is none.
+func (s *SQLiteStore) GetGroupIDByStatsToken(ctx context.Context, tokenHash string) (string, error) {
+	var groupID string
+	err := s.db.QueryRowContext(ctx,
+		"SELECT group_id FROM group_stats_tokens WHERE token_hash = ?", tokenHash,
+	).Scan(&groupID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to look up group stats token: %w", err)
+	}
+	return groupID, nil
+}
+
+// ListGroupMonthlySpend totals a group's bills by calendar month (UTC) and
+// currency, oldest first. Bills awaiting assignment are left out.
+func (s *SQLiteStore) ListGroupMonthlySpend(ctx context.Context, groupID string) ([]models.MonthlySpend, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT strftime('%Y-%m', created_at, 'unixepoch') AS month, currency, COUNT(*), SUM(total)
+		 FROM bills
+		 WHERE group_id = ? AND needs_assignment = 0 AND deleted_at = 0
+		 GROUP BY month, currency
+		 ORDER BY month, currency`,
+		groupID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list monthly spend: %w", err)
+	}
+	defer rows.Close()
+
+	var months []models.MonthlySpend
+	for rows.Next() {
+		var m models.MonthlySpend
+		if err := rows.Scan(&m.Month, &m.Currency, &m.BillCount, &m.Total); err != nil {
+			return nil, fmt.Errorf("failed to scan monthly spend: %w", err)
+		}
+		months = append(months, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate monthly spend: %w", err)
+	}
+	return months, nil
+}
@@ -0,0 +1,61 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/mmynk/splitwiser/internal/models"
+)
+
+// CreateTransactionBill creates bill for a bank or card transaction unless the
+// bill's group already has one for transactionID. It reports whether the bill
+// was created; if not, bill.ID is set to the earlier bill's ID. The record of
+// the transaction outlives the bill, so a deleted draft isn't imported again.
+func (s *SQLiteStore) CreateTransactionBill(ctx context.Context, bill *models.Bill, transactionID string) (bool, error) {
+	if bill.ID == "" {
+		bill.ID = uuid.New().String()
+	}
+	if bill.CreatedAt == 0 {
+		bill.CreatedAt = time.Now().Unix()
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var existingID string
+	err = tx.QueryRowContext(ctx,
+		"SELECT bill_id FROM imported_transactions WHERE group_id = ? AND transaction_id = ?",
+		bill.GroupID, transactionID,
+	).Scan(&existingID)
+	if err == nil {
+		bill.ID = existingID
+		return false, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return false, fmt.Errorf("failed to look up imported transaction: %w", err)
+	}
+
+	if err := insertBill(ctx, tx, bill); err != nil {
+		return false, err
+	}
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO imported_transactions (group_id, transaction_id, bill_id, created_at) VALUES (?, ?, ?, ?)",
+		bill.GroupID, transactionID, bill.ID, time.Now().Unix(),
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to record imported transaction: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,77 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mmynk/splitwiser/internal/models"
+	"github.com/mmynk/splitwiser/internal/storage"
+)
+
+// GetDeletedBill retrieves a trashed bill by ID, including all items and participants.
+func (s *SQLiteStore) GetDeletedBill(ctx context.Context, billID string) (*models.Bill, error) {
+	return getBill(ctx, s.db, billID, true)
+}
+
+// ListDeletedBillsByUser retrieves the trashed bills the user created or
+// participates in, most recently deleted first.
+func (s *SQLiteStore) ListDeletedBillsByUser(ctx context.Context, userID string) ([]*models.Bill, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+billColumns+`
+		FROM bills
+		WHERE deleted_at != 0
+		  AND (creator_id = ? OR id IN (SELECT p.bill_id FROM participants p WHERE p.user_id = ?))
+		ORDER BY deleted_at DESC, id`,
+		userID, userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deleted bills: %w", err)
+	}
+	defer rows.Close()
+
+	var bills []*models.Bill
+	for rows.Next() {
+		bill, err := scanBill(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan bill: %w", err)
+		}
+		bills = append(bills, bill)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate bills: %w", err)
+	}
+	rows.Close()
+
+	if err := loadParticipants(ctx, s.db, bills); err != nil {
+		return nil, err
+	}
+	return bills, nil
+}
+
+// RestoreBill takes a bill out of the trash.
+func (s *SQLiteStore) RestoreBill(ctx context.Context, billID string) error {
+	result, err := s.db.ExecContext(ctx, "UPDATE bills SET deleted_at = 0 WHERE id = ? AND deleted_at != 0", billID)
+	if err != nil {
+		return fmt.Errorf("failed to restore bill: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return storage.Errorf(storage.ErrNotFound, "deleted bill not found: %s", billID)
+	}
+	return nil
+}
+
+// PurgeBill permanently removes a trashed bill; its items, participants and
+// assignments go with it via ON DELETE CASCADE, and it leaves the search index.
+func (s *SQLiteStore) PurgeBill(ctx context.Context, billID string) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM bills WHERE id = ? AND deleted_at != 0", billID)
+	if err != nil {
+		return fmt.Errorf("failed to purge bill: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return storage.Errorf(storage.ErrNotFound, "deleted bill not found: %s", billID)
+	}
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM bills_fts WHERE bill_id = ?", billID); err != nil {
+		return fmt.Errorf("failed to unindex bill: %w", err)
+	}
+	return nil
+}
@@ -4,20 +4,28 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"slices"
 
 	"github.com/mmynk/splitwiser/internal/models"
 )
 
-// CreateUser inserts a new user into the database.
+// CreateUser inserts a new user into the database, encrypting the email
+// address if the store encrypts.
 func (s *SQLiteStore) CreateUser(ctx context.Context, user *models.User) error {
 	query := `
-		INSERT INTO users (id, email, display_name, password_hash, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?)
+		INSERT INTO users (id, email, email_lookup, email_lookup_version, display_name, password_hash, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	_, err := s.db.ExecContext(ctx, query,
+	email, err := s.cipher.encrypt(columnUserEmail, user.Email)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, query,
 		user.ID,
-		user.Email,
+		email,
+		s.emailLookup(user.Email),
+		s.lookupVersion(),
 		user.DisplayName,
 		user.PasswordHash,
 		user.CreatedAt,
@@ -31,16 +39,24 @@ func (s *SQLiteStore) CreateUser(ctx context.Context, user *models.User) error {
 	return nil
 }
 
-// GetUserByEmail retrieves a user by their email address.
+// GetUserByEmail retrieves a user by their primary email address or any
+// verified linked address, ignoring case and surrounding space. Of accounts
+// flagged as duplicates, it finds the one they duplicate.
 func (s *SQLiteStore) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	lookups := s.emailLookups(email)
+	in := "(?" + repeatPlaceholder(len(lookups)-1) + ")"
 	query := `
 		SELECT id, email, display_name, password_hash, created_at, updated_at
 		FROM users
-		WHERE email = ?
+		WHERE email_lookup IN ` + in + `
+		   OR id = (SELECT user_id FROM user_emails WHERE lower(email) = ? AND verified = 1)
+		ORDER BY email_lookup IN ` + in + ` DESC, duplicate_of = '' DESC, created_at, id
+		LIMIT 1
 	`
+	args := append(append(slices.Clone(lookups), models.NormalizeEmail(email)), lookups...)
 
 	user := &models.User{}
-	err := s.db.QueryRowContext(ctx, query, email).Scan(
+	err := s.db.QueryRowContext(ctx, query, args...).Scan(
 		&user.ID,
 		&user.Email,
 		&user.DisplayName,
@@ -55,6 +71,9 @@ func (s *SQLiteStore) GetUserByEmail(ctx context.Context, email string) (*models
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user by email: %w", err)
 	}
+	if user.Email, err = s.cipher.decrypt(columnUserEmail, user.Email); err != nil {
+		return nil, err
+	}
 
 	return user, nil
 }
@@ -83,6 +102,9 @@ func (s *SQLiteStore) GetUserByID(ctx context.Context, id string) (*models.User,
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user by ID: %w", err)
 	}
+	if user.Email, err = s.cipher.decrypt(columnUserEmail, user.Email); err != nil {
+		return nil, err
+	}
 
 	return user, nil
 }
@@ -126,6 +148,11 @@ func (s *SQLiteStore) GetUsersByIDs(ctx context.Context, ids []string) (map[stri
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan user: %w", err)
 		}
+		email, err := s.cipher.decrypt(columnUserEmail, user.Email)
+		if err != nil {
+			return nil, err
+		}
+		user.Email = email
 		users[user.ID] = user
 	}
 
@@ -139,10 +166,12 @@ func (s *SQLiteStore) GetUsersByIDs(ctx context.Context, ids []string) (map[stri
 // SearchUsers finds a user by exact email address, excluding the caller.
 // Returns nil, nil when no matching user is found.
 func (s *SQLiteStore) SearchUsers(ctx context.Context, email string, callerID string) (*models.User, error) {
+	lookups := s.emailLookups(email)
 	u := &models.User{}
 	err := s.db.QueryRowContext(ctx,
-		`SELECT id, display_name FROM users WHERE email = ? AND id != ?`,
-		email, callerID,
+		`SELECT id, display_name FROM users WHERE email_lookup IN (?`+repeatPlaceholder(len(lookups)-1)+`) AND id != ?
+		ORDER BY duplicate_of = '' DESC, created_at, id LIMIT 1`,
+		append(lookups, callerID)...,
 	).Scan(&u.ID, &u.DisplayName)
 	if err == sql.ErrNoRows {
 		return nil, nil
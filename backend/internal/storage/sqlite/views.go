@@ -0,0 +1,114 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mmynk/splitwiser/internal/models"
+	"github.com/mmynk/splitwiser/internal/storage"
+)
+
+const billViewColumns = "id, user_id, name, group_id, ungrouped, last_days, paid_by_me, category_id, pinned, created_at"
+
+// scanBillView scans a row selected with billViewColumns into a view.
+func scanBillView(row rowScanner) (*models.BillView, error) {
+	view := &models.BillView{}
+	if err := row.Scan(&view.ID, &view.UserID, &view.Name, &view.GroupID, &view.Ungrouped,
+		&view.LastDays, &view.PaidByMe, &view.CategoryID, &view.Pinned, &view.CreatedAt); err != nil {
+		return nil, err
+	}
+	return view, nil
+}
+
+// CreateBillView persists a new saved bill view.
+func (s *SQLiteStore) CreateBillView(ctx context.Context, view *models.BillView) error {
+	if view.ID == "" {
+		view.ID = uuid.New().String()
+	}
+	if view.CreatedAt == 0 {
+		view.CreatedAt = time.Now().Unix()
+	}
+
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO bill_views (`+billViewColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		view.ID, view.UserID, view.Name, view.GroupID, view.Ungrouped,
+		view.LastDays, view.PaidByMe, view.CategoryID, view.Pinned, view.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert bill view: %w", err)
+	}
+	return nil
+}
+
+// GetBillView retrieves a saved bill view by ID.
+func (s *SQLiteStore) GetBillView(ctx context.Context, viewID string) (*models.BillView, error) {
+	view, err := scanBillView(s.db.QueryRowContext(ctx,
+		"SELECT "+billViewColumns+" FROM bill_views WHERE id = ?", viewID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, storage.Errorf(storage.ErrNotFound, "bill view not found: %s", viewID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bill view: %w", err)
+	}
+	return view, nil
+}
+
+// ListBillViews retrieves all views a user saved, oldest first.
+func (s *SQLiteStore) ListBillViews(ctx context.Context, userID string) ([]*models.BillView, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+billViewColumns+" FROM bill_views WHERE user_id = ? ORDER BY created_at, rowid",
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bill views: %w", err)
+	}
+	defer rows.Close()
+
+	var views []*models.BillView
+	for rows.Next() {
+		view, err := scanBillView(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan bill view: %w", err)
+		}
+		views = append(views, view)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate bill views: %w", err)
+	}
+	return views, nil
+}
+
+// UpdateBillView replaces a saved view's name, filter and pinning. Its owner
+// and creation time stay.
+func (s *SQLiteStore) UpdateBillView(ctx context.Context, view *models.BillView) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE bill_views
+		SET name = ?, group_id = ?, ungrouped = ?, last_days = ?, paid_by_me = ?, category_id = ?, pinned = ?
+		WHERE id = ?`,
+		view.Name, view.GroupID, view.Ungrouped, view.LastDays, view.PaidByMe, view.CategoryID, view.Pinned,
+		view.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update bill view: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return storage.Errorf(storage.ErrNotFound, "bill view not found: %s", view.ID)
+	}
+	return nil
+}
+
+// DeleteBillView removes a saved bill view.
+func (s *SQLiteStore) DeleteBillView(ctx context.Context, viewID string) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM bill_views WHERE id = ?", viewID)
+	if err != nil {
+		return fmt.Errorf("failed to delete bill view: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return storage.Errorf(storage.ErrNotFound, "bill view not found: %s", viewID)
+	}
+	return nil
+}
@@ -0,0 +1,237 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mmynk/splitwiser/internal/models"
+	"github.com/mmynk/splitwiser/internal/storage"
+)
+
+const groupWebhookColumns = "id, group_id, url, threshold, created_at, created_by"
+
+// scanGroupWebhook scans a row selected with groupWebhookColumns into a webhook.
+func scanGroupWebhook(row rowScanner) (*models.GroupWebhook, error) {
+	webhook := &models.GroupWebhook{}
+	if err := row.Scan(&webhook.ID, &webhook.GroupID, &webhook.URL, &webhook.Threshold,
+		&webhook.CreatedAt, &webhook.CreatedBy); err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+// CreateGroupWebhook persists a new group webhook.
+func (s *SQLiteStore) CreateGroupWebhook(ctx context.Context, webhook *models.GroupWebhook) error {
+	if webhook.ID == "" {
+		webhook.ID = uuid.New().String()
+	}
+	if webhook.CreatedAt == 0 {
+		webhook.CreatedAt = time.Now().Unix()
+	}
+
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO group_webhooks (`+groupWebhookColumns+`) VALUES (?, ?, ?, ?, ?, ?)`,
+		webhook.ID, webhook.GroupID, webhook.URL, webhook.Threshold, webhook.CreatedAt, webhook.CreatedBy,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert group webhook: %w", err)
+	}
+	return nil
+}
+
+// GetGroupWebhook retrieves a group webhook by ID.
+func (s *SQLiteStore) GetGroupWebhook(ctx context.Context, webhookID string) (*models.GroupWebhook, error) {
+	webhook, err := scanGroupWebhook(s.db.QueryRowContext(ctx,
+		"SELECT "+groupWebhookColumns+" FROM group_webhooks WHERE id = ?", webhookID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, storage.Errorf(storage.ErrNotFound, "group webhook not found: %s", webhookID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group webhook: %w", err)
+	}
+	return webhook, nil
+}
+
+// ListGroupWebhooks retrieves all webhooks on a group, oldest first, with the
+// members currently over each one's threshold.
+func (s *SQLiteStore) ListGroupWebhooks(ctx context.Context, groupID string) ([]*models.GroupWebhook, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+groupWebhookColumns+" FROM group_webhooks WHERE group_id = ? ORDER BY created_at, id",
+		groupID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list group webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	var webhooks []*models.GroupWebhook
+	for rows.Next() {
+		webhook, err := scanGroupWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan group webhook: %w", err)
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate group webhooks: %w", err)
+	}
+	rows.Close()
+
+	for _, webhook := range webhooks {
+		webhook.OverThreshold, err = s.getWebhookBreaches(ctx, webhook.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return webhooks, nil
+}
+
+// getWebhookBreaches returns the members recorded as over a webhook's threshold.
+func (s *SQLiteStore) getWebhookBreaches(ctx context.Context, webhookID string) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT member FROM group_webhook_breaches WHERE webhook_id = ? ORDER BY member",
+		webhookID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook breaches: %w", err)
+	}
+	defer rows.Close()
+
+	var members []string
+	for rows.Next() {
+		var member string
+		if err := rows.Scan(&member); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook breach: %w", err)
+		}
+		members = append(members, member)
+	}
+	return members, rows.Err()
+}
+
+// SetGroupWebhookOverThreshold replaces the members recorded as over the
+// webhook's threshold.
+func (s *SQLiteStore) SetGroupWebhookOverThreshold(ctx context.Context, webhookID string, members []string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM group_webhook_breaches WHERE webhook_id = ?", webhookID); err != nil {
+		return fmt.Errorf("failed to clear webhook breaches: %w", err)
+	}
+	for _, member := range members {
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO group_webhook_breaches (webhook_id, member) VALUES (?, ?)", webhookID, member,
+		); err != nil {
+			return fmt.Errorf("failed to insert webhook breach: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// DeleteGroupWebhook removes a group webhook and its recorded breaches.
+func (s *SQLiteStore) DeleteGroupWebhook(ctx context.Context, webhookID string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM group_webhooks WHERE id = ?", webhookID)
+	if err != nil {
+		return fmt.Errorf("failed to delete group webhook: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return storage.Errorf(storage.ErrNotFound, "group webhook not found: %s", webhookID)
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM group_webhook_breaches WHERE webhook_id = ?", webhookID); err != nil {
+		return fmt.Errorf("failed to delete webhook breaches: %w", err)
+	}
+	return tx.Commit()
+}
+
+const accountWebhookColumns = "id, user_id, url, secret, created_at"
+
+// scanAccountWebhook scans a row selected with accountWebhookColumns into a webhook.
+func scanAccountWebhook(row rowScanner) (*models.AccountWebhook, error) {
+	webhook := &models.AccountWebhook{}
+	if err := row.Scan(&webhook.ID, &webhook.UserID, &webhook.URL, &webhook.Secret, &webhook.CreatedAt); err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+// CreateAccountWebhook persists a new account webhook.
+func (s *SQLiteStore) CreateAccountWebhook(ctx context.Context, webhook *models.AccountWebhook) error {
+	if webhook.ID == "" {
+		webhook.ID = uuid.New().String()
+	}
+	if webhook.CreatedAt == 0 {
+		webhook.CreatedAt = time.Now().Unix()
+	}
+
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO account_webhooks (`+accountWebhookColumns+`) VALUES (?, ?, ?, ?, ?)`,
+		webhook.ID, webhook.UserID, webhook.URL, webhook.Secret, webhook.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert account webhook: %w", err)
+	}
+	return nil
+}
+
+// GetAccountWebhook retrieves an account webhook by ID.
+func (s *SQLiteStore) GetAccountWebhook(ctx context.Context, webhookID string) (*models.AccountWebhook, error) {
+	webhook, err := scanAccountWebhook(s.db.QueryRowContext(ctx,
+		"SELECT "+accountWebhookColumns+" FROM account_webhooks WHERE id = ?", webhookID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, storage.Errorf(storage.ErrNotFound, "account webhook not found: %s", webhookID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account webhook: %w", err)
+	}
+	return webhook, nil
+}
+
+// ListAccountWebhooks retrieves all webhooks a user registered, oldest first.
+func (s *SQLiteStore) ListAccountWebhooks(ctx context.Context, userID string) ([]*models.AccountWebhook, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+accountWebhookColumns+" FROM account_webhooks WHERE user_id = ? ORDER BY created_at, id",
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list account webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	var webhooks []*models.AccountWebhook
+	for rows.Next() {
+		webhook, err := scanAccountWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan account webhook: %w", err)
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate account webhooks: %w", err)
+	}
+	return webhooks, nil
+}
+
+// DeleteAccountWebhook removes an account webhook.
+func (s *SQLiteStore) DeleteAccountWebhook(ctx context.Context, webhookID string) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM account_webhooks WHERE id = ?", webhookID)
+	if err != nil {
+		return fmt.Errorf("failed to delete account webhook: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return storage.Errorf(storage.ErrNotFound, "account webhook not found: %s", webhookID)
+	}
+	return nil
+}
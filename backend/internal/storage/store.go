@@ -15,30 +15,97 @@ type Store interface {
 	// The bill.ID field will be populated by the store.
 	CreateBill(ctx context.Context, bill *models.Bill) error
 
+	// CreateBillInGroup persists a new bill like CreateBill and, in the same
+	// transaction, adds members to the bill's group unless a member of that
+	// name already exists. It returns how many members were added. members
+	// are ignored for a bill without a group.
+	CreateBillInGroup(ctx context.Context, bill *models.Bill, members []models.GroupMember) (int, error)
+
+	// CreateBillsInGroup persists new bills in the group and adds members to
+	// it, all in one transaction, so either every bill is saved or none is.
+	// It returns how many members were added.
+	CreateBillsInGroup(ctx context.Context, groupID string, bills []*models.Bill, members []models.GroupMember) (int, error)
+
 	// GetBill retrieves a bill by its ID.
 	// Returns nil and an error if the bill is not found.
 	GetBill(ctx context.Context, billID string) (*models.Bill, error)
 
-	// UpdateBill updates an existing bill.
+	// GetBillsByIDs retrieves the bills with the given IDs, as GetBill would,
+	// in a fixed number of queries, in the order of billIDs. IDs with no bill
+	// are left out.
+	GetBillsByIDs(ctx context.Context, billIDs []string) ([]*models.Bill, error)
+
+	// UpdateBill updates an existing bill, keeping the version it replaces as
+	// a revision credited to bill.EditorID.
 	// Returns an error if the bill is not found.
 	UpdateBill(ctx context.Context, bill *models.Bill) error
 
-	// DeleteBill removes a bill by its ID.
-	// Returns an error if the bill is not found.
+	// UpdateBillInGroup updates a bill like UpdateBill and, in the same
+	// transaction, adds members to the bill's group unless a member of that
+	// name already exists. It returns how many members were added.
+	UpdateBillInGroup(ctx context.Context, bill *models.Bill, members []models.GroupMember) (int, error)
+
+	// ListBillRevisions retrieves the versions of a bill its updates replaced,
+	// oldest first.
+	ListBillRevisions(ctx context.Context, billID string) ([]*models.BillRevision, error)
+
+	// GetBillRevision retrieves one revision of a bill by its number.
+	GetBillRevision(ctx context.Context, billID string, revision int) (*models.BillRevision, error)
+
+	// DeleteBill moves a bill to the trash, hiding it from every other read.
+	// Returns an error if the bill is not found or already trashed.
 	DeleteBill(ctx context.Context, billID string) error
 
-	// ListBillsByGroup retrieves all bills associated with a group.
-	// Returns an empty slice if the group has no bills.
-	ListBillsByGroup(ctx context.Context, groupID string) ([]*models.Bill, error)
+	// GetDeletedBill retrieves a trashed bill by its ID.
+	// Returns nil and an error if no trashed bill has the ID.
+	GetDeletedBill(ctx context.Context, billID string) (*models.Bill, error)
+
+	// ListDeletedBillsByUser retrieves the trashed bills the user created or
+	// participates in, most recently deleted first, with participants but no items.
+	ListDeletedBillsByUser(ctx context.Context, userID string) ([]*models.Bill, error)
 
-	// ListBillsByUser retrieves all bills where the given user is the creator or a participant.
-	// Returns an empty slice if the user has no bills.
-	ListBillsByUser(ctx context.Context, userID string) ([]*models.Bill, error)
+	// RestoreBill takes a bill out of the trash.
+	// Returns an error if no trashed bill has the ID.
+	RestoreBill(ctx context.Context, billID string) error
+
+	// PurgeBill permanently removes a trashed bill and everything attached to it.
+	// Returns an error if no trashed bill has the ID.
+	PurgeBill(ctx context.Context, billID string) error
+
+	// ListBillsByGroup retrieves the page of bills associated with a group
+	// that match filter, newest first, with their items and participants;
+	// filter's GroupID and Ungrouped are ignored. Returns an empty slice if
+	// the group has no such bills.
+	ListBillsByGroup(ctx context.Context, groupID string, filter BillFilter) ([]*models.Bill, error)
+
+	// ListCategories retrieves every expense category bills can be filed
+	// under, by name.
+	ListCategories(ctx context.Context) ([]models.Category, error)
+
+	// ListBillsByUser retrieves the bills matching filter where the given user
+	// is the creator or a participant, newest first. Returns summaries with
+	// participants but no items. Returns an empty slice if the user has no bills.
+	ListBillsByUser(ctx context.Context, userID string, filter BillFilter) ([]*models.Bill, error)
+
+	// SearchBills finds the bills matching filter where the given user is the
+	// creator or a participant and whose title, item descriptions or
+	// participant names contain every word of query, best matches first.
+	// Returns summaries with participants but no items.
+	SearchBills(ctx context.Context, userID, query string, filter BillFilter) ([]*models.Bill, error)
 
 	// ListDirectBillsByUser retrieves bills with no group where the user is creator or participant.
 	// Returns lightweight summaries (no items/participants); callers use GetBill for full details.
 	ListDirectBillsByUser(ctx context.Context, userID string) ([]*models.Bill, error)
 
+	// ListUnassignedBillsByUser retrieves bills still awaiting participant assignment
+	// that the user created or that belong to one of the user's groups.
+	ListUnassignedBillsByUser(ctx context.Context, userID string) ([]*models.Bill, error)
+
+	// ListBalanceBillsByUser retrieves, with items and participants, every bill
+	// that can affect the user's balances: bills in the user's groups and
+	// no-group bills the user created or participates in. Parked bills are excluded.
+	ListBalanceBillsByUser(ctx context.Context, userID string) ([]*models.Bill, error)
+
 	// CreateGroup persists a new group.
 	// The group.ID field will be populated by the store.
 	CreateGroup(ctx context.Context, group *models.Group) error
@@ -47,8 +114,8 @@ type Store interface {
 	// Returns nil and an error if the group is not found.
 	GetGroup(ctx context.Context, groupID string) (*models.Group, error)
 
-	// ListGroupsByUser retrieves all groups the given user belongs to.
-	ListGroupsByUser(ctx context.Context, userID string) ([]*models.Group, error)
+	// ListGroupsByUser retrieves the page of groups the given user belongs to, newest first.
+	ListGroupsByUser(ctx context.Context, userID string, page Page) ([]*models.Group, error)
 
 	// UpdateGroup updates an existing group.
 	// Returns an error if the group is not found.
@@ -70,18 +137,176 @@ type Store interface {
 	// Returns nil and an error if the settlement is not found.
 	GetSettlement(ctx context.Context, settlementID string) (*models.Settlement, error)
 
-	// ListSettlementsByGroup retrieves all settlements for a group.
+	// ListSettlementsByGroup retrieves the page of settlements for a group, newest first.
 	// Returns an empty slice if the group has no settlements.
-	ListSettlementsByGroup(ctx context.Context, groupID string) ([]*models.Settlement, error)
+	ListSettlementsByGroup(ctx context.Context, groupID string, filter SettlementFilter) ([]*models.Settlement, error)
 
 	// ListDirectSettlementsByUser retrieves settlements with no group (cross-group settle ups)
 	// where the given display name is the payer or payee.
 	ListDirectSettlementsByUser(ctx context.Context, displayName string) ([]*models.Settlement, error)
 
+	// ListBalanceSettlementsByUser retrieves settlements in the user's groups and
+	// direct settlements where the given display name is the payer or payee.
+	ListBalanceSettlementsByUser(ctx context.Context, userID, displayName string) ([]*models.Settlement, error)
+
 	// DeleteSettlement removes a settlement by its ID.
 	// Returns an error if the settlement is not found.
 	DeleteSettlement(ctx context.Context, settlementID string) error
 
+	// CreateSettlementPlan persists a new settlement plan.
+	// The plan.ID field will be populated by the store.
+	CreateSettlementPlan(ctx context.Context, plan *models.SettlementPlan) error
+
+	// GetSettlementPlan retrieves a settlement plan by its ID, with Paid filled
+	// in from the settlements recorded against it.
+	GetSettlementPlan(ctx context.Context, planID string) (*models.SettlementPlan, error)
+
+	// ListSettlementPlansByGroup retrieves all settlement plans for a group.
+	ListSettlementPlansByGroup(ctx context.Context, groupID string) ([]*models.SettlementPlan, error)
+
+	// ListSettlementPlansByPayer retrieves all settlement plans where the given
+	// display name is the one repaying.
+	ListSettlementPlansByPayer(ctx context.Context, displayName string) ([]*models.SettlementPlan, error)
+
+	// DeleteSettlementPlan removes a settlement plan by its ID.
+	// Settlements recorded against it are kept but no longer linked.
+	DeleteSettlementPlan(ctx context.Context, planID string) error
+
+	// CreateGroupWebhook persists a new group webhook.
+	CreateGroupWebhook(ctx context.Context, webhook *models.GroupWebhook) error
+
+	// GetGroupWebhook retrieves a group webhook by its ID.
+	GetGroupWebhook(ctx context.Context, webhookID string) (*models.GroupWebhook, error)
+
+	// ListGroupWebhooks retrieves all webhooks registered on a group, with
+	// OverThreshold filled in.
+	ListGroupWebhooks(ctx context.Context, groupID string) ([]*models.GroupWebhook, error)
+
+	// SetGroupWebhookOverThreshold replaces the members recorded as over the
+	// webhook's threshold.
+	SetGroupWebhookOverThreshold(ctx context.Context, webhookID string, members []string) error
+
+	// DeleteGroupWebhook removes a group webhook by its ID.
+	DeleteGroupWebhook(ctx context.Context, webhookID string) error
+
+	// CreateAccountWebhook persists a new account webhook.
+	// The webhook.ID field will be populated by the store.
+	CreateAccountWebhook(ctx context.Context, webhook *models.AccountWebhook) error
+
+	// GetAccountWebhook retrieves an account webhook by its ID.
+	GetAccountWebhook(ctx context.Context, webhookID string) (*models.AccountWebhook, error)
+
+	// ListAccountWebhooks retrieves all webhooks a user registered, oldest first.
+	ListAccountWebhooks(ctx context.Context, userID string) ([]*models.AccountWebhook, error)
+
+	// DeleteAccountWebhook removes an account webhook by its ID.
+	DeleteAccountWebhook(ctx context.Context, webhookID string) error
+
+	// CreateBillView persists a new saved bill view.
+	// The view.ID field will be populated by the store.
+	CreateBillView(ctx context.Context, view *models.BillView) error
+
+	// GetBillView retrieves a saved bill view by its ID.
+	GetBillView(ctx context.Context, viewID string) (*models.BillView, error)
+
+	// ListBillViews retrieves all views a user saved, oldest first.
+	ListBillViews(ctx context.Context, userID string) ([]*models.BillView, error)
+
+	// UpdateBillView replaces a saved view's name, filter and pinning.
+	UpdateBillView(ctx context.Context, view *models.BillView) error
+
+	// DeleteBillView removes a saved bill view by its ID.
+	DeleteBillView(ctx context.Context, viewID string) error
+
+	// CreateAttachment persists a new attachment's metadata.
+	// The attachment.ID field will be populated by the store.
+	CreateAttachment(ctx context.Context, attachment *models.Attachment) error
+
+	// GetAttachment retrieves an attachment by its ID.
+	GetAttachment(ctx context.Context, attachmentID string) (*models.Attachment, error)
+
+	// ListAttachmentsByBill retrieves a bill's attachments, oldest first.
+	ListAttachmentsByBill(ctx context.Context, billID string) ([]*models.Attachment, error)
+
+	// ListAttachmentBlobKeys returns the blob key of every stored attachment,
+	// including those of trashed bills.
+	ListAttachmentBlobKeys(ctx context.Context) ([]string, error)
+
+	// CreateComment persists a new comment on a bill.
+	// The comment.ID field will be populated by the store.
+	CreateComment(ctx context.Context, comment *models.Comment) error
+
+	// GetComment retrieves a comment by its ID.
+	GetComment(ctx context.Context, commentID string) (*models.Comment, error)
+
+	// ListCommentsByBill retrieves a bill's comments, oldest first.
+	ListCommentsByBill(ctx context.Context, billID string) ([]*models.Comment, error)
+
+	// DeleteComment removes a comment by its ID.
+	DeleteComment(ctx context.Context, commentID string) error
+
+	// SetGroupStatsToken publishes a group's aggregate stats under the token
+	// with the given hash, replacing any previous token.
+	SetGroupStatsToken(ctx context.Context, groupID, tokenHash, createdBy string) error
+
+	// DeleteGroupStatsToken stops publishing a group's stats.
+	DeleteGroupStatsToken(ctx context.Context, groupID string) error
+
+	// GetGroupIDByStatsToken returns the group published under the token with
+	// the given hash, or "" if there is none.
+	GetGroupIDByStatsToken(ctx context.Context, tokenHash string) (string, error)
+
+	// MarkShareAsPaid records that a participant has paid their share of a
+	// bill, setting PaidAt if it is zero.
+	MarkShareAsPaid(ctx context.Context, payment *models.SharePayment) error
+
+	// UnmarkShareAsPaid records that a participant's share of a bill is
+	// outstanding again.
+	UnmarkShareAsPaid(ctx context.Context, billID, participant string) error
+
+	// SetBillLocked sets when a bill was locked against edits, or unlocks it
+	// if lockedAt is zero.
+	SetBillLocked(ctx context.Context, billID string, lockedAt int64) error
+
+	// ListSharePayments retrieves the shares marked as paid of the given bills.
+	ListSharePayments(ctx context.Context, billIDs []string) ([]models.SharePayment, error)
+
+	// SetBillShareToken shares a bill read-only under the token with the
+	// given hash, replacing any previous token.
+	SetBillShareToken(ctx context.Context, billID, tokenHash, createdBy string) error
+
+	// DeleteBillShareToken stops sharing a bill.
+	DeleteBillShareToken(ctx context.Context, billID string) error
+
+	// GetBillIDByShareToken returns the bill shared under the token with the
+	// given hash, or "" if there is none.
+	GetBillIDByShareToken(ctx context.Context, tokenHash string) (string, error)
+
+	// ListGroupMonthlySpend totals a group's bills by month and currency, oldest first.
+	ListGroupMonthlySpend(ctx context.Context, groupID string) ([]models.MonthlySpend, error)
+
+	// CreateSpendingCapAlerts records bills that pushed members over their monthly caps.
+	CreateSpendingCapAlerts(ctx context.Context, alerts []models.SpendingCapAlert) error
+
+	// ListSpendingCapAlerts retrieves a group's cap alerts for a month (YYYY-MM), oldest first.
+	ListSpendingCapAlerts(ctx context.Context, groupID, month string) ([]models.SpendingCapAlert, error)
+
+	// ListBillSpendingCapAlerts retrieves the cap alerts raised by a bill.
+	ListBillSpendingCapAlerts(ctx context.Context, billID string) ([]models.SpendingCapAlert, error)
+
+	// CreateTransactionBill creates bill for a bank or card transaction unless
+	// the bill's group already has one for transactionID. It reports whether
+	// the bill was created; if not, bill.ID is set to the earlier bill's ID.
+	CreateTransactionBill(ctx context.Context, bill *models.Bill, transactionID string) (bool, error)
+
+	// ImportGroupArchive stores a group with its bills, settlements and
+	// settlement plans atomically, assigning fresh IDs to all of them.
+	ImportGroupArchive(ctx context.Context, archive *models.GroupArchive) error
+
+	// GetUserByEmail retrieves a user by primary or verified linked email.
+	// Returns nil, nil when no user has the address.
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+
 	// GetUsersByIDs retrieves multiple users by their IDs. Missing IDs are omitted.
 	GetUsersByIDs(ctx context.Context, ids []string) (map[string]*models.User, error)
 
@@ -123,6 +348,17 @@ type Store interface {
 	// SearchFriends finds accepted friends matching a partial display name query.
 	SearchFriends(ctx context.Context, callerID string, query string) ([]*models.User, error)
 
+	// CreateAuditEntry appends an entry to the audit log.
+	// The entry.ID field will be populated by the store.
+	CreateAuditEntry(ctx context.Context, entry *models.AuditEntry) error
+
+	// ListAuditEntries retrieves the audit entries matching filter, newest first.
+	ListAuditEntries(ctx context.Context, filter AuditFilter) ([]*models.AuditEntry, error)
+
+	// GetUsage returns what the user has created, for quota enforcement.
+	// Bills are counted only if created at or after since (Unix seconds).
+	GetUsage(ctx context.Context, userID string, since int64) (*models.Usage, error)
+
 	// Close releases any resources held by the store.
 	Close() error
 }
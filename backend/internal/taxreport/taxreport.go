@@ -0,0 +1,126 @@
+// Package taxreport builds yearly tax reports: a user's shares of their
+// deductible bills, totaled by the tax line each bill's category maps to.
+package taxreport
+
+import (
+	"cmp"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mmynk/splitwiser/pkg/splitmath"
+)
+
+// Lines maps category IDs to the tax line bills in them are deducted under.
+// Bills in categories it leaves out aren't deductible.
+type Lines map[string]string
+
+// ParseLines parses a comma-separated list of category=line pairs, such as
+// "rent=Home office,utilities=Home office,travel=Travel", into Lines.
+func ParseLines(s string) (Lines, error) {
+	lines := make(Lines)
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		category, line, ok := strings.Cut(field, "=")
+		category, line = strings.TrimSpace(category), strings.TrimSpace(line)
+		if !ok || category == "" || line == "" {
+			return nil, fmt.Errorf("tax line %q is not category=line", field)
+		}
+		lines[category] = line
+	}
+	return lines, nil
+}
+
+// Bill is a user's share of one bill, as a report lists it.
+type Bill struct {
+	ID         string
+	Title      string
+	CategoryID string
+	Date       time.Time // When the expense happened
+	Currency   string
+	Amount     float64  // The user's share, including tax, tip and fees
+	Receipts   []string // Names of the receipts attached to the bill
+}
+
+// Line is one line of a report: the deductible bills filed under it, oldest
+// first, and the user's total by currency.
+type Line struct {
+	Name   string
+	Totals map[string]float64
+	Bills  []Bill
+}
+
+// Build files the bills dated in year (UTC) under the tax lines their
+// categories map to, leaving out the rest. Lines are in name order.
+func Build(lines Lines, year int, bills []Bill) []Line {
+	byName := make(map[string]*Line)
+	for _, bill := range bills {
+		name, ok := lines[bill.CategoryID]
+		if !ok || bill.Date.UTC().Year() != year {
+			continue
+		}
+		line := byName[name]
+		if line == nil {
+			line = &Line{Name: name, Totals: make(map[string]float64)}
+			byName[name] = line
+		}
+		line.Bills = append(line.Bills, bill)
+		line.Totals[bill.Currency] = splitmath.RoundAmount(line.Totals[bill.Currency]+bill.Amount, bill.Currency)
+	}
+
+	report := make([]Line, 0, len(byName))
+	for _, line := range byName {
+		slices.SortFunc(line.Bills, func(a, b Bill) int {
+			return cmp.Or(a.Date.Compare(b.Date), strings.Compare(a.ID, b.ID))
+		})
+		report = append(report, *line)
+	}
+	slices.SortFunc(report, func(a, b Line) int { return strings.Compare(a.Name, b.Name) })
+	return report
+}
+
+// CSVHeader names the columns WriteCSV writes.
+var CSVHeader = []string{
+	"tax_line", "bill_id", "bill", "date", "category", "currency", "amount", "receipts",
+}
+
+// WriteCSV writes a header row and then one row per bill of report, naming
+// its receipts. categoryNames maps category IDs to names; a category without
+// one is written as its ID. Amounts are plain decimals in the bill's
+// currency, for spreadsheets to read.
+func WriteCSV(w io.Writer, report []Line, categoryNames map[string]string) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(CSVHeader); err != nil {
+		return err
+	}
+	for _, line := range report {
+		for _, bill := range line.Bills {
+			if err := cw.Write([]string{
+				text(line.Name), bill.ID, text(bill.Title), bill.Date.UTC().Format(time.DateOnly),
+				text(cmp.Or(categoryNames[bill.CategoryID], bill.CategoryID)), bill.Currency,
+				strconv.FormatFloat(bill.Amount, 'f', splitmath.MinorUnits(bill.Currency), 64),
+				text(strings.Join(bill.Receipts, "; ")),
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// text keeps text a spreadsheet would read as a formula, such as
+// "=SUM(A1)", as text by quoting it with a leading apostrophe.
+func text(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
@@ -0,0 +1,82 @@
+package taxreport
+
+import (
+	"bytes"
+	"encoding/csv"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseLines(t *testing.T) {
+	lines, err := ParseLines(" rent=Home office, utilities = Home office,,travel=Travel")
+	if err != nil {
+		t.Fatalf("ParseLines failed: %v", err)
+	}
+	want := Lines{"rent": "Home office", "utilities": "Home office", "travel": "Travel"}
+	if len(lines) != len(want) {
+		t.Fatalf("lines = %v, want %v", lines, want)
+	}
+	for category, line := range want {
+		if lines[category] != line {
+			t.Errorf("lines[%q] = %q, want %q", category, lines[category], line)
+		}
+	}
+
+	for _, spec := range []string{"rent", "rent=", "=Home office"} {
+		if _, err := ParseLines(spec); err == nil {
+			t.Errorf("ParseLines(%q) succeeded, want an error", spec)
+		}
+	}
+}
+
+func TestBuild(t *testing.T) {
+	day := func(month time.Month, d int) time.Time { return time.Date(2025, month, d, 12, 0, 0, 0, time.UTC) }
+	lines := Lines{"rent": "Home office", "utilities": "Home office", "travel": "Travel"}
+	report := Build(lines, 2025, []Bill{
+		{ID: "b1", Title: "Studio rent", CategoryID: "rent", Date: day(3, 1), Currency: "EUR", Amount: 600, Receipts: []string{"lease.png"}},
+		{ID: "b2", Title: "Power", CategoryID: "utilities", Date: day(2, 10), Currency: "EUR", Amount: 30},
+		{ID: "b3", Title: "=Train", CategoryID: "travel", Date: day(6, 5), Currency: "EUR", Amount: 40},
+		{ID: "b4", Title: "Team lunch", CategoryID: "food", Date: day(6, 5), Currency: "EUR", Amount: 25},
+		{ID: "b5", Title: "Last year's rent", CategoryID: "rent", Date: day(1, 1).AddDate(0, 0, -1), Currency: "EUR", Amount: 600},
+		{ID: "b6", Title: "Tokyo rent", CategoryID: "rent", Date: day(4, 1), Currency: "JPY", Amount: 80000},
+	})
+
+	if len(report) != 2 || report[0].Name != "Home office" || report[1].Name != "Travel" {
+		t.Fatalf("report = %v, want Home office and Travel", report)
+	}
+	if got := report[0].Totals; len(got) != 2 || got["EUR"] != 630 || got["JPY"] != 80000 {
+		t.Errorf("Home office totals = %v, want EUR 630 and JPY 80000", got)
+	}
+	var ids []string
+	for _, bill := range report[0].Bills {
+		ids = append(ids, bill.ID)
+	}
+	if !slices.Equal(ids, []string{"b2", "b1", "b6"}) {
+		t.Errorf("Home office bills = %v, want oldest first: b2, b1, b6", ids)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, report, map[string]string{"rent": "Rent", "utilities": "Utilities"}); err != nil {
+		t.Fatalf("WriteCSV failed: %v", err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("report isn't valid CSV: %v", err)
+	}
+	want := [][]string{
+		CSVHeader,
+		{"Home office", "b2", "Power", "2025-02-10", "Utilities", "EUR", "30.00", ""},
+		{"Home office", "b1", "Studio rent", "2025-03-01", "Rent", "EUR", "600.00", "lease.png"},
+		{"Home office", "b6", "Tokyo rent", "2025-04-01", "Rent", "JPY", "80000", ""},
+		{"Travel", "b3", "'=Train", "2025-06-05", "travel", "EUR", "40.00", ""},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("got %d rows, want %d: %v", len(rows), len(want), rows)
+	}
+	for i := range want {
+		if !slices.Equal(rows[i], want[i]) {
+			t.Errorf("row %d = %v, want %v", i, rows[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,143 @@
+// Package webhook delivers group and account events to subscriber URLs.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/mmynk/splitwiser/pkg/logging"
+)
+
+// logger logs as the webhook component; see pkg/logging.
+var logger = logging.Component("webhook")
+
+// Event types posted to group webhooks.
+const (
+	// EventThresholdExceeded fires when a member's debt rises above the threshold.
+	EventThresholdExceeded = "balance.threshold_exceeded"
+	// EventThresholdCleared fires when a member's debt falls back to the threshold or below.
+	EventThresholdCleared = "balance.threshold_cleared"
+)
+
+// Event types posted to account webhooks, for changes involving the account's user.
+const (
+	EventBillCreated        = "bill.created"
+	EventBillUpdated        = "bill.updated"
+	EventBillDeleted        = "bill.deleted"
+	EventBillRestored       = "bill.restored"
+	EventSettlementRecorded = "settlement.recorded"
+	EventSettlementDeleted  = "settlement.deleted"
+)
+
+// SignatureHeader carries "sha256=" followed by the hex HMAC-SHA256 of the
+// request body, keyed with the webhook's secret, on signed deliveries.
+const SignatureHeader = "X-Splitwiser-Signature"
+
+// Event is the JSON body posted to a group webhook URL.
+type Event struct {
+	Type       string  `json:"type"`
+	WebhookID  string  `json:"webhook_id"`
+	GroupID    string  `json:"group_id"`
+	GroupName  string  `json:"group_name"`
+	Member     string  `json:"member"` // display name
+	Owes       float64 `json:"owes"`   // member's debt in the group after the change
+	Threshold  float64 `json:"threshold"`
+	OccurredAt int64   `json:"occurred_at"`
+}
+
+// AccountEvent is the JSON body posted to an account webhook URL. It names
+// the bill or settlement that changed but, unlike a group's view, leaves out
+// other people's shares.
+type AccountEvent struct {
+	Type         string  `json:"type"`
+	WebhookID    string  `json:"webhook_id"`
+	BillID       string  `json:"bill_id,omitempty"`
+	SettlementID string  `json:"settlement_id,omitempty"`
+	GroupID      string  `json:"group_id,omitempty"`
+	Title        string  `json:"title,omitempty"` // bill title or settlement note
+	Amount       float64 `json:"amount"`          // bill total or settlement amount
+	Currency     string  `json:"currency,omitempty"`
+	OccurredAt   int64   `json:"occurred_at"`
+}
+
+// Sign returns the SignatureHeader value for body under secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Dispatcher posts events to webhook URLs in the background so a slow or
+// unreachable subscriber never delays the write that triggered it.
+// A nil *Dispatcher drops every event, so callers don't need to guard it.
+type Dispatcher struct {
+	client  *http.Client
+	timeout time.Duration
+}
+
+// NewDispatcher creates a Dispatcher that gives each delivery up to timeout.
+func NewDispatcher(timeout time.Duration) *Dispatcher {
+	return &Dispatcher{client: &http.Client{}, timeout: timeout}
+}
+
+// Send delivers event to url asynchronously. Failures are logged, not retried.
+func (d *Dispatcher) Send(url string, event Event) {
+	if d == nil {
+		return
+	}
+	go func() {
+		if err := d.post(url, "", event); err != nil {
+			logger.Warn("Webhook delivery failed", "url", url, "type", event.Type, "group_id", event.GroupID, "error", err)
+		}
+	}()
+}
+
+// SendSigned delivers an account event to url asynchronously, signed with
+// secret. Failures are logged, not retried.
+func (d *Dispatcher) SendSigned(url, secret string, event AccountEvent) {
+	if d == nil {
+		return
+	}
+	go func() {
+		if err := d.post(url, secret, event); err != nil {
+			logger.Warn("Webhook delivery failed", "url", url, "type", event.Type, "webhook_id", event.WebhookID, "error", err)
+		}
+	}()
+}
+
+// post sends event as JSON, signing it when secret is set.
+func (d *Dispatcher) post(url, secret string, event any) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Splitwiser-Webhook")
+	if secret != "" {
+		req.Header.Set(SignatureHeader, Sign(secret, body))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
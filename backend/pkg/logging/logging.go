@@ -6,46 +6,211 @@
 //	logging.Setup()                          // INFO level, from LOG_LEVEL env
 //	logging.SetupWithLevel(slog.LevelDebug)  // explicit level override
 //
+//	var logger = logging.Component("storage") // per-package logger
+//
 // Environment variables:
 //
-//	LOG_LEVEL: debug, info, warn, error (default: info)
+//	LOG_LEVEL: debug, info, warn, error (default: info), optionally followed
+//	           by per-component overrides, e.g. info,calculator=debug,storage=warn
+//
+// Components log under a slog group of their name, so their attributes read
+// storage.error=... and their level can be set apart from everything else.
+// The components in this repo are auth, bankfeed, calculator, http, notify,
+// provision, service, storage and webhook.
 package logging
 
 import (
+	"context"
+	"fmt"
 	"log/slog"
 	"os"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/lmittmann/tint"
 )
 
-// Setup configures colored logging at the level specified by LOG_LEVEL env var
-// (default: INFO).
-func Setup() {
-	SetupWithLevel(levelFromEnv())
+// Levels is the minimum level for each component, and for everything else.
+type Levels struct {
+	Default    slog.Level
+	Components map[string]slog.Level
 }
 
-// SetupWithLevel configures colored logging at the given level.
-func SetupWithLevel(level slog.Level) {
-	slog.SetDefault(slog.New(
-		tint.NewHandler(os.Stderr, &tint.Options{
-			Level:      level,
-			TimeFormat: time.Kitchen,
-			AddSource:  true,
-		}),
-	))
+// Of returns the minimum level for component; "" is logging outside any
+// component.
+func (l Levels) Of(component string) slog.Level {
+	if level, ok := l.Components[component]; ok {
+		return level
+	}
+	return l.Default
+}
+
+// min returns the lowest level any component logs at.
+func (l Levels) min() slog.Level {
+	lowest := l.Default
+	for _, level := range l.Components {
+		lowest = min(lowest, level)
+	}
+	return lowest
 }
 
-func levelFromEnv() slog.Level {
-	switch strings.ToLower(os.Getenv("LOG_LEVEL")) {
+// ParseLevels parses a LOG_LEVEL value: a comma-separated list of a default
+// level and component=level overrides, in any order. An empty value is INFO.
+func ParseLevels(s string) (Levels, error) {
+	levels := Levels{Default: slog.LevelInfo}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		component, name, ok := strings.Cut(part, "=")
+		if !ok {
+			component, name = "", part
+		}
+		level, err := parseLevel(name)
+		if err != nil {
+			return Levels{Default: slog.LevelInfo}, err
+		}
+		if component == "" {
+			levels.Default = level
+			continue
+		}
+		if levels.Components == nil {
+			levels.Components = make(map[string]slog.Level)
+		}
+		levels.Components[strings.TrimSpace(component)] = level
+	}
+	return levels, nil
+}
+
+func parseLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
 	case "debug":
-		return slog.LevelDebug
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
 	case "warn":
-		return slog.LevelWarn
+		return slog.LevelWarn, nil
 	case "error":
-		return slog.LevelError
+		return slog.LevelError, nil
 	default:
-		return slog.LevelInfo
+		return 0, fmt.Errorf("unknown log level %q", name)
+	}
+}
+
+// Setup configures colored logging at the levels specified by the LOG_LEVEL
+// env var (default: INFO). An invalid value falls back to INFO with a warning.
+func Setup() {
+	levels, err := ParseLevels(os.Getenv("LOG_LEVEL"))
+	SetupWithLevels(levels)
+	if err != nil {
+		slog.Warn("Invalid LOG_LEVEL, using info", "error", err)
+	}
+}
+
+// SetupWithLevel configures colored logging at the given level.
+func SetupWithLevel(level slog.Level) {
+	SetupWithLevels(Levels{Default: level})
+}
+
+// SetupWithLevels configures colored logging with per-component levels.
+func SetupWithLevels(levels Levels) {
+	SetupHandler(tint.NewHandler(os.Stderr, &tint.Options{
+		Level:      levels.min(),
+		TimeFormat: time.Kitchen,
+		AddSource:  true,
+	}), levels)
+}
+
+// SetupHandler sends all logging to handler, filtered by levels. handler
+// must let through records at levels' lowest level.
+func SetupHandler(handler slog.Handler, levels Levels) {
+	root.Store(&output{handler: handler, levels: levels})
+	slog.SetDefault(slog.New(&componentHandler{}))
+}
+
+// Component returns the logger for one component of the program. It may be
+// called before Setup, typically in a package-level var, and follows
+// whatever Setup installs later.
+func Component(name string) *slog.Logger {
+	return slog.New(&componentHandler{component: name})
+}
+
+// output is what Setup installed.
+type output struct {
+	handler slog.Handler
+	levels  Levels
+}
+
+var root atomic.Pointer[output]
+
+// componentHandler filters records by its component's level and passes
+// them to the installed handler, scoped to a group named after the
+// component. Before Setup it passes them to slog's default handler.
+type componentHandler struct {
+	component string
+	// scope replays the WithAttrs and WithGroup calls made on this handler.
+	scope func(slog.Handler) slog.Handler
+	// cached is the installed handler with scope applied, rebuilt when Setup
+	// runs again. Nothing is cached before Setup.
+	cached atomic.Pointer[scopedHandler]
+}
+
+type scopedHandler struct {
+	from    *output
+	handler slog.Handler
+}
+
+func (h *componentHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	out := root.Load()
+	if out == nil {
+		return slog.Default().Handler().Enabled(ctx, level)
+	}
+	return level >= out.levels.Of(h.component) && out.handler.Enabled(ctx, level)
+}
+
+func (h *componentHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.handler().Handle(ctx, r)
+}
+
+func (h *componentHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(func(inner slog.Handler) slog.Handler { return inner.WithAttrs(attrs) })
+}
+
+func (h *componentHandler) WithGroup(name string) slog.Handler {
+	return h.with(func(inner slog.Handler) slog.Handler { return inner.WithGroup(name) })
+}
+
+func (h *componentHandler) with(step func(slog.Handler) slog.Handler) slog.Handler {
+	scope := step
+	if prev := h.scope; prev != nil {
+		scope = func(inner slog.Handler) slog.Handler { return step(prev(inner)) }
+	}
+	return &componentHandler{component: h.component, scope: scope}
+}
+
+// handler returns the handler records currently go to.
+func (h *componentHandler) handler() slog.Handler {
+	out := root.Load()
+	if cached := h.cached.Load(); cached != nil && cached.from == out {
+		return cached.handler
+	}
+	if out == nil {
+		return h.scoped(slog.Default().Handler())
+	}
+	inner := h.scoped(out.handler)
+	h.cached.Store(&scopedHandler{from: out, handler: inner})
+	return inner
+}
+
+// scoped applies the component group and scope to inner.
+func (h *componentHandler) scoped(inner slog.Handler) slog.Handler {
+	if h.component != "" {
+		inner = inner.WithGroup(h.component)
+	}
+	if h.scope != nil {
+		inner = h.scope(inner)
 	}
+	return inner
 }
@@ -0,0 +1,67 @@
+package logging
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestParseLevels(t *testing.T) {
+	levels, err := ParseLevels("warn, calculator=debug,storage=ERROR")
+	if err != nil {
+		t.Fatalf("ParseLevels: %v", err)
+	}
+	for component, want := range map[string]slog.Level{
+		"":           slog.LevelWarn,
+		"http":       slog.LevelWarn,
+		"calculator": slog.LevelDebug,
+		"storage":    slog.LevelError,
+	} {
+		if got := levels.Of(component); got != want {
+			t.Errorf("Of(%q) = %v, want %v", component, got, want)
+		}
+	}
+
+	if levels, err := ParseLevels(""); err != nil || levels.Default != slog.LevelInfo {
+		t.Errorf("ParseLevels(\"\") = %v, %v; want info", levels, err)
+	}
+	if _, err := ParseLevels("info,calculator=loud"); err == nil {
+		t.Error("ParseLevels accepted an unknown level")
+	}
+}
+
+func TestComponent(t *testing.T) {
+	// Loggers made before setup follow it.
+	calculator := Component("calculator").With("bill", "b1")
+	storage := Component("storage")
+
+	prev := slog.Default()
+	defer func() {
+		root.Store(nil)
+		slog.SetDefault(prev)
+	}()
+	var buf bytes.Buffer
+	SetupHandler(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}), Levels{
+		Default:    slog.LevelInfo,
+		Components: map[string]slog.Level{"calculator": slog.LevelDebug, "storage": slog.LevelWarn},
+	})
+
+	calculator.Debug("share", "cents", 250)
+	storage.Info("query")
+	storage.Warn("busy")
+	slog.Debug("request")
+	slog.Info("started")
+
+	out := buf.String()
+	for _, want := range []string{"msg=share calculator.bill=b1 calculator.cents=250", "msg=busy", "msg=started"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	for _, unwanted := range []string{"msg=query", "msg=request"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("output has %q below its component's level:\n%s", unwanted, out)
+		}
+	}
+}
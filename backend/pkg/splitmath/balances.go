@@ -0,0 +1,292 @@
+package splitmath
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+
+	"github.com/mmynk/splitwiser/pkg/logging"
+)
+
+// logger logs as the calculator component; balance math is traced at debug
+// level. See pkg/logging.
+var logger = logging.Component("calculator")
+
+// BillForBalance represents a bill with the minimal information needed for balance calculations.
+type BillForBalance struct {
+	Total        float64
+	Subtotal     float64
+	PayerID      string
+	Items        []Item
+	Participants []string
+	Options      Options
+
+	// Rate converts the bill's amounts into the currency balances are kept
+	// in, per unit of the bill's currency. Zero leaves them as they are.
+	Rate float64
+}
+
+// converted returns amount of bill in the currency balances are kept in.
+func (bill BillForBalance) converted(amount float64) float64 {
+	if bill.Rate > 0 {
+		return amount * bill.Rate
+	}
+	return amount
+}
+
+// MemberBalance represents the balance information for one group member.
+type MemberBalance struct {
+	MemberName string
+	NetBalance float64 // Positive = owed money, Negative = owes money
+	TotalPaid  float64 // Total amount paid across all bills
+	TotalOwed  float64 // Total amount this person owes
+}
+
+// DebtEdge represents a debt from one person to another.
+type DebtEdge struct {
+	From   string  // Person who owes
+	To     string  // Person who is owed
+	Amount float64
+}
+
+// SettlementForBalance represents a settlement with the minimal information needed for balance calculations.
+type SettlementForBalance struct {
+	FromUserID string  // Who paid (debtor settling up)
+	ToUserID   string  // Who received (creditor being paid)
+	Amount     float64
+}
+
+// CalculateGroupBalances computes balances across multiple bills and settlements.
+// It aggregates who paid what and who owes what, returning both individual
+// member balances and a detailed debt matrix.
+//
+// Algorithm:
+// - For each bill: payer contributed +total, each participant owes their split
+// - For each settlement: payer's balance improves, receiver's balance decreases
+// - Aggregate: net_balance = total_paid - total_owed
+// - Debt matrix: simplified using greedy matching
+//
+// Member balances are sorted by name and debt edges by debtor then creditor,
+// so the same input always produces the same output.
+func CalculateGroupBalances(bills []BillForBalance, settlements []SettlementForBalance) ([]MemberBalance, []DebtEdge, error) {
+	return CalculateGroupBalancesWithMode(bills, settlements, SimplifyGreedy)
+}
+
+// CalculateGroupBalancesWithMode is CalculateGroupBalances with the debt matrix
+// simplified by the given mode.
+//
+// Every amount is accumulated in whole cents, so balances always sum to zero
+// and debt matching needs no tolerance; floats only appear in the output.
+func CalculateGroupBalancesWithMode(bills []BillForBalance, settlements []SettlementForBalance, mode SimplifyMode) ([]MemberBalance, []DebtEdge, error) {
+	// Track paid and owed cents per member
+	type ledger struct{ paid, owed int64 }
+	ledgers := make(map[string]*ledger)
+	member := func(name string) *ledger {
+		if _, exists := ledgers[name]; !exists {
+			ledgers[name] = &ledger{}
+		}
+		return ledgers[name]
+	}
+
+	for _, bill := range bills {
+		// Skip bills without payer (can't calculate balances)
+		if bill.PayerID == "" {
+			logger.Debug("Skipping bill without payer", "total", bill.Total)
+			continue
+		}
+
+		shares, err := billShares(bill)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// Payer paid the full amount
+		member(bill.PayerID).paid += toCents(bill.converted(bill.Total))
+
+		// Each participant owes their share
+		for participant, share := range shares {
+			member(participant).owed += share
+		}
+	}
+
+	// Apply settlements to balances
+	for _, s := range settlements {
+		amount := toCents(s.Amount)
+		// Payer's balance improves (they effectively "paid" to settle debt)
+		member(s.FromUserID).paid += amount
+		// Receiver's balance decreases (they received payment)
+		member(s.ToUserID).owed += amount
+	}
+
+	// Convert to slices, sorted so map iteration order never leaks into the output
+	var memberBalances []MemberBalance
+	for name, l := range ledgers {
+		memberBalances = append(memberBalances, MemberBalance{
+			MemberName: name,
+			NetBalance: fromCents(l.paid - l.owed),
+			TotalPaid:  fromCents(l.paid),
+			TotalOwed:  fromCents(l.owed),
+		})
+	}
+	slices.SortFunc(memberBalances, func(a, b MemberBalance) int {
+		return cmp.Compare(a.MemberName, b.MemberName)
+	})
+
+	var debtEdges []DebtEdge
+	if mode == SimplifyMinTransfers {
+		debtEdges = simplifyMinTransfers(memberBalances)
+	} else {
+		debtEdges = simplifyGreedy(memberBalances)
+	}
+	slices.SortFunc(debtEdges, func(a, b DebtEdge) int {
+		return cmp.Or(cmp.Compare(a.From, b.From), cmp.Compare(a.To, b.To))
+	})
+	if logger.Enabled(context.Background(), slog.LevelDebug) {
+		for _, b := range memberBalances {
+			logger.Debug("Member balance", "member", b.MemberName, "paid", b.TotalPaid, "owed", b.TotalOwed, "net", b.NetBalance)
+		}
+		logger.Debug("Group balances computed", "bills", len(bills), "settlements", len(settlements), "mode", mode, "edges", len(debtEdges))
+	}
+	return memberBalances, debtEdges, nil
+}
+
+// PairwiseBalances computes what each counterparty owes person directly, without
+// simplifying debts through third parties. Positive amounts are owed to person;
+// negative amounts are owed by person. Bills without a payer are skipped, and
+// counterparties who come out even are left out.
+func PairwiseBalances(person string, bills []BillForBalance, settlements []SettlementForBalance) (map[string]float64, error) {
+	net := make(map[string]int64)
+	for _, bill := range bills {
+		if bill.PayerID == "" {
+			continue
+		}
+		shares, err := billShares(bill)
+		if err != nil {
+			return nil, err
+		}
+		for participant, share := range shares {
+			switch {
+			case participant == bill.PayerID:
+				continue
+			case bill.PayerID == person:
+				net[participant] += share
+			case participant == person:
+				net[bill.PayerID] -= share
+			}
+		}
+	}
+
+	for _, s := range settlements {
+		switch person {
+		case s.FromUserID:
+			net[s.ToUserID] += toCents(s.Amount)
+		case s.ToUserID:
+			net[s.FromUserID] -= toCents(s.Amount)
+		}
+	}
+
+	out := make(map[string]float64, len(net))
+	for name, amount := range net {
+		if amount != 0 {
+			out[name] = fromCents(amount)
+		}
+	}
+	return out, nil
+}
+
+// toCents converts an amount to whole cents, rounding half away from zero.
+func toCents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+// fromCents converts whole cents back to an amount.
+func fromCents(cents int64) float64 {
+	return float64(cents) / 100
+}
+
+// billShares splits bill and returns each participant's share in cents,
+// converted at the bill's rate. When rounding each share leaves the shares a
+// few cents off the bill's converted total, the difference goes one cent at a
+// time to whoever rounding moved furthest, ties broken by name, so the payer
+// is owed exactly what they paid.
+func billShares(bill BillForBalance) (map[string]int64, error) {
+	splitResult, err := CalculateSplitWithOptions(bill.Items, bill.Total, bill.Subtotal, bill.Participants, bill.Options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate split: %w", err)
+	}
+
+	shares := make(map[string]int64, len(splitResult))
+	lost := make(map[string]float64, len(splitResult))
+	names := make([]string, 0, len(splitResult))
+	var sum int64
+	for name, split := range splitResult {
+		total := bill.converted(split.Total)
+		shares[name] = toCents(total)
+		lost[name] = total*100 - float64(shares[name])
+		names = append(names, name)
+		sum += shares[name]
+	}
+
+	// Only rounding residue is reconciled; a larger gap means the split
+	// doesn't cover the total, and is left as the calculator reported it.
+	residue := toCents(bill.converted(bill.Total)) - sum
+	if residue == 0 || residue > int64(len(names)) || -residue > int64(len(names)) {
+		return shares, nil
+	}
+	unit := int64(1)
+	if residue < 0 {
+		unit, residue = -1, -residue
+	}
+	slices.SortFunc(names, func(a, b string) int {
+		return cmp.Or(cmp.Compare(lost[b]*float64(unit), lost[a]*float64(unit)), cmp.Compare(a, b))
+	})
+	for i := range residue {
+		shares[names[i]] += unit
+	}
+	return shares, nil
+}
+
+// simplifyGreedy matches debtors with creditors in order, settling at least one
+// of them per transfer. It's fast but doesn't always find the fewest transfers.
+func simplifyGreedy(balances []MemberBalance) []DebtEdge {
+	// Create lists of creditors (owed money) and debtors (owe money), in cents
+	type party struct {
+		name  string
+		cents int64
+	}
+	var creditors, debtors []party
+	for _, bal := range balances {
+		switch c := toCents(bal.NetBalance); {
+		case c > 0:
+			creditors = append(creditors, party{bal.MemberName, c})
+		case c < 0:
+			debtors = append(debtors, party{bal.MemberName, -c}) // Make positive
+		}
+	}
+
+	// Match debtors with creditors; every transfer settles at least one side
+	var debtEdges []DebtEdge
+	for i, j := 0, 0; i < len(debtors) && j < len(creditors); {
+		// Amount to settle is minimum of what debtor owes and creditor is owed
+		amount := min(debtors[i].cents, creditors[j].cents)
+		debtEdges = append(debtEdges, DebtEdge{
+			From:   debtors[i].name,
+			To:     creditors[j].name,
+			Amount: fromCents(amount),
+		})
+
+		debtors[i].cents -= amount
+		creditors[j].cents -= amount
+		if debtors[i].cents == 0 {
+			i++
+		}
+		if creditors[j].cents == 0 {
+			j++
+		}
+	}
+
+	return debtEdges
+}
@@ -0,0 +1,200 @@
+package splitmath
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCalculateGroupBalances_Deterministic(t *testing.T) {
+	names := []string{"Zoe", "Yan", "Xia", "Wes", "Val", "Uma", "Ted", "Sam"}
+	var bills []BillForBalance
+	for i, payer := range names {
+		bills = append(bills, BillForBalance{
+			Total:        float64(10 * (i + 1)),
+			Subtotal:     float64(10 * (i + 1)),
+			PayerID:      payer,
+			Participants: names[:len(names)-i],
+		})
+	}
+	settlements := []SettlementForBalance{{FromUserID: "Sam", ToUserID: "Zoe", Amount: 3}}
+
+	for _, mode := range []SimplifyMode{SimplifyGreedy, SimplifyMinTransfers} {
+		t.Run(string(mode), func(t *testing.T) {
+			wantBalances, wantEdges, err := CalculateGroupBalancesWithMode(bills, settlements, mode)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for i := 1; i < len(wantBalances); i++ {
+				if wantBalances[i-1].MemberName >= wantBalances[i].MemberName {
+					t.Fatalf("balances not sorted by name: %q before %q", wantBalances[i-1].MemberName, wantBalances[i].MemberName)
+				}
+			}
+			for i := 1; i < len(wantEdges); i++ {
+				prev, cur := wantEdges[i-1], wantEdges[i]
+				if prev.From > cur.From || (prev.From == cur.From && prev.To >= cur.To) {
+					t.Fatalf("debt edges not sorted: %+v before %+v", prev, cur)
+				}
+			}
+
+			// Map iteration order differs between runs; the output must not.
+			for range 50 {
+				balances, edges, err := CalculateGroupBalancesWithMode(bills, settlements, mode)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if !reflect.DeepEqual(balances, wantBalances) {
+					t.Fatalf("balances changed between calls:\n%+v\n%+v", balances, wantBalances)
+				}
+				if !reflect.DeepEqual(edges, wantEdges) {
+					t.Fatalf("debt edges changed between calls:\n%+v\n%+v", edges, wantEdges)
+				}
+			}
+		})
+	}
+}
+
+func TestPairwiseBalances(t *testing.T) {
+	bills := []BillForBalance{
+		// Alice paid 30 for Alice, Bob and Carol: Bob and Carol owe her 10 each.
+		{Total: 30, Subtotal: 30, PayerID: "Alice", Participants: []string{"Alice", "Bob", "Carol"}},
+		// Carol paid 20 for Alice and Carol: Alice owes her 10.
+		{Total: 20, Subtotal: 20, PayerID: "Carol", Participants: []string{"Alice", "Carol"}},
+		// Bob paid for Carol alone; Alice isn't involved.
+		{Total: 50, Subtotal: 50, PayerID: "Bob", Participants: []string{"Carol"}},
+		// No payer: skipped.
+		{Total: 40, Subtotal: 40, Participants: []string{"Alice", "Dave"}},
+	}
+	settlements := []SettlementForBalance{
+		{FromUserID: "Bob", ToUserID: "Alice", Amount: 4},
+		{FromUserID: "Alice", ToUserID: "Dave", Amount: 5},
+	}
+
+	got, err := PairwiseBalances("Alice", bills, settlements)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Carol's 10 and Alice's 10 cancel out, so Carol is left out.
+	want := map[string]float64{"Bob": 6, "Dave": 5}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("PairwiseBalances = %v, want %v", got, want)
+	}
+}
+
+func TestCalculateGroupBalances_CentBoundaries(t *testing.T) {
+	t.Run("one-cent debts are kept", func(t *testing.T) {
+		bills := []BillForBalance{{Total: 0.02, Subtotal: 0.02, PayerID: "Bob", Participants: []string{"Bob", "Dan"}}}
+		_, edges, err := CalculateGroupBalances(bills, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := []DebtEdge{{From: "Dan", To: "Bob", Amount: 0.01}}
+		if !reflect.DeepEqual(edges, want) {
+			t.Errorf("edges = %+v, want %+v", edges, want)
+		}
+	})
+
+	t.Run("repeated dimes add up exactly", func(t *testing.T) {
+		// 0.1 summed ten times is 0.9999999999999999 in floats.
+		var bills []BillForBalance
+		for range 10 {
+			bills = append(bills, BillForBalance{Total: 0.1, Subtotal: 0.1, PayerID: "Bob", Participants: []string{"Dan"}})
+		}
+		balances, edges, err := CalculateGroupBalances(bills, []SettlementForBalance{{FromUserID: "Dan", ToUserID: "Bob", Amount: 0.99}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if balances[0].TotalPaid != 1 || balances[0].NetBalance != 0.01 {
+			t.Errorf("Bob = %+v, want paid 1 and owed 0.01", balances[0])
+		}
+		want := []DebtEdge{{From: "Dan", To: "Bob", Amount: 0.01}}
+		if !reflect.DeepEqual(edges, want) {
+			t.Errorf("edges = %+v, want %+v", edges, want)
+		}
+	})
+
+	t.Run("settled to the cent leaves no edges", func(t *testing.T) {
+		bills := []BillForBalance{{Total: 0.3, Subtotal: 0.3, PayerID: "Bob", Participants: []string{"Dan"}}}
+		settlements := []SettlementForBalance{
+			{FromUserID: "Dan", ToUserID: "Bob", Amount: 0.1},
+			{FromUserID: "Dan", ToUserID: "Bob", Amount: 0.2},
+		}
+		for _, mode := range []SimplifyMode{SimplifyGreedy, SimplifyMinTransfers} {
+			_, edges, err := CalculateGroupBalancesWithMode(bills, settlements, mode)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(edges) != 0 {
+				t.Errorf("%s: edges = %+v, want none", mode, edges)
+			}
+		}
+	})
+
+	t.Run("rounded shares add up to the total", func(t *testing.T) {
+		// 100 split three ways is 33.33 each, a cent short, and 0.99 four
+		// ways is 0.25 each, a cent over. Ties go by name, so Amy takes the
+		// first cent and gives back the second.
+		bills := []BillForBalance{
+			{Total: 100, Subtotal: 100, PayerID: "Amy", Participants: []string{"Amy", "Bob", "Cat"}},
+			{Total: 0.99, Subtotal: 0.99, PayerID: "Bob", Participants: []string{"Amy", "Bob", "Cat", "Dan"}},
+		}
+		for _, mode := range []SimplifyMode{SimplifyGreedy, SimplifyMinTransfers} {
+			balances, edges, err := CalculateGroupBalancesWithMode(bills, nil, mode)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var net int64
+			for _, b := range balances {
+				net += toCents(b.NetBalance)
+			}
+			if net != 0 {
+				t.Errorf("%s: balances sum to %d cents, want 0: %+v", mode, net, balances)
+			}
+			if !settles(balances, edges) {
+				t.Errorf("%s: edges %+v don't settle %+v", mode, edges, balances)
+			}
+			if balances[0].TotalOwed != 33.58 {
+				t.Errorf("%s: Amy owes %v, want 33.34 + 0.24", mode, balances[0].TotalOwed)
+			}
+		}
+	})
+}
+
+func TestPairwiseBalances_CentBoundaries(t *testing.T) {
+	bills := []BillForBalance{
+		{Total: 0.03, Subtotal: 0.03, PayerID: "Alice", Participants: []string{"Alice", "Bob", "Carol"}},
+		{Total: 0.7, Subtotal: 0.7, PayerID: "Carol", Participants: []string{"Alice"}},
+	}
+	settlements := []SettlementForBalance{{FromUserID: "Alice", ToUserID: "Carol", Amount: 0.69}}
+
+	got, err := PairwiseBalances("Alice", bills, settlements)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Bob owes a cent; Carol's cent cancels Alice's remaining cent to her.
+	want := map[string]float64{"Bob": 0.01}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("PairwiseBalances = %v, want %v", got, want)
+	}
+}
+
+func TestCalculateGroupBalances_Rate(t *testing.T) {
+	bills := []BillForBalance{
+		// 100 JPY at 0.0067 per yen: 0.67, of which Alice owes 0.33 and Bob 0.34.
+		{Total: 100, Subtotal: 100, PayerID: "Alice", Participants: []string{"Alice", "Bob"}, Rate: 0.0067},
+		// 10 EUR at 1.1: Alice owes Bob 5.50.
+		{Total: 10, Subtotal: 10, PayerID: "Bob", Participants: []string{"Alice", "Bob"}, Rate: 1.1},
+		// No rate counts the bill as it is.
+		{Total: 4, Subtotal: 4, PayerID: "Alice", Participants: []string{"Alice", "Bob"}},
+	}
+	balances, _, err := CalculateGroupBalances(bills, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []MemberBalance{
+		{MemberName: "Alice", NetBalance: -3.16, TotalPaid: 4.67, TotalOwed: 7.83},
+		{MemberName: "Bob", NetBalance: 3.16, TotalPaid: 11, TotalOwed: 7.84},
+	}
+	if !reflect.DeepEqual(balances, want) {
+		t.Errorf("got %+v, want %+v", balances, want)
+	}
+}
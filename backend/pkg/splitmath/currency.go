@@ -0,0 +1,80 @@
+package splitmath
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// minorUnits lists ISO 4217 currencies whose minor unit isn't cents.
+// Anything not listed has two decimal places.
+var minorUnits = map[string]int{
+	// No minor unit
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0,
+	"KRW": 0, "PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0,
+	"XAF": 0, "XOF": 0, "XPF": 0,
+	// Thousandths
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	// Ten-thousandths
+	"CLF": 4, "UYW": 4,
+}
+
+// MinorUnits returns the number of decimal places used by an ISO 4217
+// currency code. An empty or unlisted code has two.
+func MinorUnits(currency string) int {
+	if digits, ok := minorUnits[currency]; ok {
+		return digits
+	}
+	return 2
+}
+
+// RoundAmount rounds amount to the currency's minor unit, half away from zero.
+func RoundAmount(amount float64, currency string) float64 {
+	scale := math.Pow10(MinorUnits(currency))
+	return math.Round(amount*scale) / scale
+}
+
+// FormatAmount renders amount with the currency's number of decimal places,
+// prefixed by the currency code when there is one (e.g. "JPY 1235", "BHD 1.235").
+func FormatAmount(amount float64, currency string) string {
+	s := strconv.FormatFloat(amount, 'f', MinorUnits(currency), 64)
+	if currency == "" {
+		return s
+	}
+	return currency + " " + s
+}
+
+// validateCurrency accepts an empty code or three upper-case letters.
+func validateCurrency(currency string) error {
+	if currency == "" {
+		return nil
+	}
+	if len(currency) != 3 {
+		return fmt.Errorf("currency %q must be a three-letter ISO 4217 code", currency)
+	}
+	for _, r := range currency {
+		if r < 'A' || r > 'Z' {
+			return fmt.Errorf("currency %q must be a three-letter ISO 4217 code", currency)
+		}
+	}
+	return nil
+}
+
+// roundSplits rounds every amount in splits to the currency's minor unit.
+// Each total is rounded from its unrounded value, so it can differ from the
+// sum of the rounded parts by a minor unit.
+func roundSplits(splits map[string]*PersonSplit, currency string) {
+	round := func(v float64) float64 { return RoundAmount(v, currency) }
+	for _, split := range splits {
+		split.Subtotal = round(split.Subtotal)
+		split.Discount = round(split.Discount)
+		split.Tax = round(split.Tax)
+		split.Tip = round(split.Tip)
+		split.Fees = round(split.Fees)
+		split.Covered = round(split.Covered)
+		split.Total = round(split.Total)
+		for i := range split.Items {
+			split.Items[i].Amount = round(split.Items[i].Amount)
+		}
+	}
+}
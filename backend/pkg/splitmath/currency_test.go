@@ -0,0 +1,42 @@
+package splitmath
+
+import "testing"
+
+func TestRoundAmount(t *testing.T) {
+	tests := []struct {
+		currency string
+		amount   float64
+		want     float64
+	}{
+		{"USD", 12.345, 12.35},
+		{"", 12.344, 12.34},
+		{"JPY", 1234.5, 1235},
+		{"KRW", 999.4, 999},
+		{"BHD", 1.2345, 1.235},
+		{"KWD", -0.0004, 0},
+		{"EUR", -3.335, -3.34},
+	}
+	for _, tt := range tests {
+		if got := RoundAmount(tt.amount, tt.currency); got != tt.want {
+			t.Errorf("RoundAmount(%v, %q) = %v, want %v", tt.amount, tt.currency, got, tt.want)
+		}
+	}
+}
+
+func TestFormatAmount(t *testing.T) {
+	tests := []struct {
+		currency string
+		amount   float64
+		want     string
+	}{
+		{"USD", 12.5, "USD 12.50"},
+		{"JPY", 1234.6, "JPY 1235"},
+		{"BHD", 1.2, "BHD 1.200"},
+		{"", 7, "7.00"},
+	}
+	for _, tt := range tests {
+		if got := FormatAmount(tt.amount, tt.currency); got != tt.want {
+			t.Errorf("FormatAmount(%v, %q) = %q, want %q", tt.amount, tt.currency, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+// Package splitmath computes how a bill splits among the people who shared
+// it, and who owes whom across many bills. It is pure computation with no
+// storage or network access, so CLI tools and bots can split bills offline
+// with the same results as the server, which uses this package itself.
+//
+// # Splitting a bill
+//
+// A bill is a list of Items, each shared by some participants, and the bill's
+// subtotal and total. CalculateSplit divides each item among its
+// participants and spreads the difference between total and subtotal, the
+// tax, in proportion to what each person had:
+//
+//	splits, err := splitmath.CalculateSplit(items, 33, 30, []string{"Alice", "Bob"})
+//	// splits["Alice"].Total, splits["Bob"].Total
+//
+// CalculateSplitWithOptions takes the rest of a bill through Options:
+//
+//   - Weights: SplitShares weighs people by Options.Shares or Item.Shares,
+//     Item.Units assigns units of an item (3 beers: Alice 2, Bob 1), and
+//     SplitExact takes each person's total as given.
+//   - Surcharges: Options.Tip, split per TipSplitMode, and Options.Fees,
+//     flat charges such as delivery that are part of the total but not taxed.
+//     Whatever else the total holds beyond the subtotal is tax.
+//   - Discounts, tax-inclusive prices, covered participants and per-person
+//     adjustments.
+//   - Money: Options.Currency rounds every amount to the currency's minor
+//     unit, and Options.Rounding rounds so shares add up to the total.
+//
+// Options.Trace records every step of the computation for explaining a split.
+//
+// # Balances
+//
+// CalculateGroupBalances nets many bills and settlements into what each
+// person paid and owes, and the transfers that settle everyone, simplified
+// per SimplifyMode. PairwiseBalances gives one person's balance with each
+// other person. Installments schedules paying a debt off over time.
+//
+// # Money
+//
+// Amounts are float64 in major units (dollars, not cents) throughout. Money
+// pairs one with its currency for rounding, formatting and dividing it into
+// parts that add up exactly; RoundAmount and FormatAmount do the same for
+// bare amounts.
+//
+// # Stability
+//
+// Exported identifiers keep their meaning and signatures; new options are
+// added as Options fields whose zero value keeps the old behavior.
+package splitmath
@@ -0,0 +1,52 @@
+package splitmath
+
+import "math"
+
+// DefaultEvenTolerance is the tolerance NearEven is usually given: totals
+// within 5% of an equal share count as close to even.
+const DefaultEvenTolerance = 0.05
+
+// EvenSplit describes the equal split an itemized one is close to.
+type EvenSplit struct {
+	Share float64 // Each person's total when the bill is split equally
+	// Difference is what each person would pay more (negative: less) if the
+	// bill were split equally instead.
+	Difference map[string]float64
+}
+
+// NearEven reports whether splitting the bill equally would change no one's
+// total by more than tolerance, a fraction of the equal share (0.05 for 5%),
+// and if so, by how much it would change each. It returns nil when some
+// total is further off, or when the split is already even to within the
+// currency's minor unit, so there is nothing to simplify.
+func NearEven(splits map[string]*PersonSplit, tolerance float64, currency string) *EvenSplit {
+	if len(splits) < 2 || tolerance <= 0 {
+		return nil
+	}
+	sum := 0.0
+	for _, split := range splits {
+		sum += split.Total
+	}
+	share := RoundAmount(sum/float64(len(splits)), currency)
+	if share <= 0 {
+		return nil
+	}
+
+	unit := math.Pow10(-MinorUnits(currency))
+	even := &EvenSplit{Share: share, Difference: make(map[string]float64, len(splits))}
+	uneven := false
+	for person, split := range splits {
+		diff := RoundAmount(share-split.Total, currency)
+		if math.Abs(diff) > share*tolerance {
+			return nil
+		}
+		if math.Abs(diff) > unit {
+			uneven = true
+		}
+		even.Difference[person] = diff
+	}
+	if !uneven {
+		return nil
+	}
+	return even
+}
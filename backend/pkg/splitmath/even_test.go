@@ -0,0 +1,86 @@
+package splitmath
+
+import "testing"
+
+func TestNearEven(t *testing.T) {
+	totals := func(amounts map[string]float64) map[string]*PersonSplit {
+		splits := make(map[string]*PersonSplit, len(amounts))
+		for person, total := range amounts {
+			splits[person] = &PersonSplit{Total: total}
+		}
+		return splits
+	}
+
+	tests := []struct {
+		name      string
+		totals    map[string]float64
+		tolerance float64
+		currency  string
+		want      *EvenSplit
+	}{
+		{
+			name:      "close to even",
+			totals:    map[string]float64{"Alice": 31, "Bob": 29},
+			tolerance: DefaultEvenTolerance,
+			currency:  "USD",
+			want:      &EvenSplit{Share: 30, Difference: map[string]float64{"Alice": -1, "Bob": 1}},
+		},
+		{
+			name:      "too far from even",
+			totals:    map[string]float64{"Alice": 40, "Bob": 20},
+			tolerance: DefaultEvenTolerance,
+			currency:  "USD",
+		},
+		{
+			name:      "wider tolerance",
+			totals:    map[string]float64{"Alice": 40, "Bob": 20},
+			tolerance: 0.5,
+			currency:  "USD",
+			want:      &EvenSplit{Share: 30, Difference: map[string]float64{"Alice": -10, "Bob": 10}},
+		},
+		{
+			name:      "already even but for the odd cent",
+			totals:    map[string]float64{"Alice": 3.34, "Bob": 3.33, "Carol": 3.33},
+			tolerance: DefaultEvenTolerance,
+			currency:  "USD",
+		},
+		{
+			name:      "minor unit of the currency",
+			totals:    map[string]float64{"Alice": 1010, "Bob": 990},
+			tolerance: DefaultEvenTolerance,
+			currency:  "JPY",
+			want:      &EvenSplit{Share: 1000, Difference: map[string]float64{"Alice": -10, "Bob": 10}},
+		},
+		{
+			name:      "one person",
+			totals:    map[string]float64{"Alice": 30},
+			tolerance: DefaultEvenTolerance,
+		},
+		{
+			name:   "no tolerance",
+			totals: map[string]float64{"Alice": 31, "Bob": 29},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NearEven(totals(tt.totals), tt.tolerance, tt.currency)
+			if tt.want == nil {
+				if got != nil {
+					t.Fatalf("NearEven() = %+v, want nil", got)
+				}
+				return
+			}
+			if got == nil {
+				t.Fatalf("NearEven() = nil, want %+v", tt.want)
+			}
+			if got.Share != tt.want.Share {
+				t.Errorf("Share = %v, want %v", got.Share, tt.want.Share)
+			}
+			for person, want := range tt.want.Difference {
+				if got.Difference[person] != want {
+					t.Errorf("Difference[%s] = %v, want %v", person, got.Difference[person], want)
+				}
+			}
+		})
+	}
+}
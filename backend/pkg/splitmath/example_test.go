@@ -0,0 +1,59 @@
+package splitmath_test
+
+import (
+	"fmt"
+
+	"github.com/mmynk/splitwiser/pkg/splitmath"
+)
+
+func ExampleCalculateSplitWithOptions() {
+	items := []splitmath.Item{
+		{Description: "Pizza", Amount: 20, Participants: []string{"Alice", "Bob"}},
+		{Description: "Salad", Amount: 10, Participants: []string{"Alice"}},
+	}
+	// 30 of food, 3 of tax, 6 of tip and a 2 delivery fee split evenly.
+	splits, err := splitmath.CalculateSplitWithOptions(items, 41, 30, []string{"Alice", "Bob"}, splitmath.Options{
+		Tip:      6,
+		Fees:     []splitmath.Fee{{Description: "Delivery", Amount: 2, SplitMode: splitmath.FeeEqual}},
+		Currency: "USD",
+	})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, person := range []string{"Alice", "Bob"} {
+		s := splits[person]
+		fmt.Printf("%s: %v food, %v tax, %v tip, %v fees, %v total\n", person, s.Subtotal, s.Tax, s.Tip, s.Fees, s.Total)
+	}
+	// Output:
+	// Alice: 20 food, 2 tax, 4 tip, 1 fees, 27 total
+	// Bob: 10 food, 1 tax, 2 tip, 1 fees, 14 total
+}
+
+func ExampleCalculateGroupBalances() {
+	bills := []splitmath.BillForBalance{
+		{Total: 30, Subtotal: 30, PayerID: "Alice", Participants: []string{"Alice", "Bob", "Carol"}},
+		{Total: 12, Subtotal: 12, PayerID: "Bob", Participants: []string{"Bob", "Carol"}},
+	}
+	_, debts, err := splitmath.CalculateGroupBalances(bills, nil)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, d := range debts {
+		fmt.Printf("%s pays %s %v\n", d.From, d.To, d.Amount)
+	}
+	// Output:
+	// Bob pays Alice 4
+	// Carol pays Alice 16
+}
+
+func ExampleMoney_Allocate() {
+	parts, err := splitmath.Money{Amount: 100, Currency: "USD"}.Allocate(1, 1, 1)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(parts)
+	// Output: [USD 33.34 USD 33.33 USD 33.33]
+}
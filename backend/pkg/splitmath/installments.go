@@ -0,0 +1,87 @@
+package splitmath
+
+import (
+	"math"
+	"time"
+)
+
+// Cadence is how often a settlement plan's installments fall due.
+type Cadence string
+
+const (
+	// CadenceWeekly schedules an installment every 7 days.
+	CadenceWeekly Cadence = "weekly"
+	// CadenceBiweekly schedules an installment every 14 days.
+	CadenceBiweekly Cadence = "biweekly"
+	// CadenceMonthly schedules an installment on the same day each month.
+	CadenceMonthly Cadence = "monthly"
+)
+
+// InstallmentStatus describes where an installment stands relative to now.
+type InstallmentStatus string
+
+const (
+	InstallmentUpcoming InstallmentStatus = "upcoming" // not due within ReminderWindow
+	InstallmentDue      InstallmentStatus = "due"      // due within ReminderWindow
+	InstallmentOverdue  InstallmentStatus = "overdue"  // past its due date and not fully paid
+	InstallmentPaid     InstallmentStatus = "paid"
+)
+
+// ReminderWindow is how far ahead of its due date an installment counts as due.
+const ReminderWindow = 3 * 24 * time.Hour
+
+// Installment is one scheduled repayment of a settlement plan.
+type Installment struct {
+	Number int // 1-based
+	DueAt  time.Time
+	Amount float64
+	Paid   float64 // portion covered by payments made so far
+	Status InstallmentStatus
+}
+
+// next returns the due date one cadence after t.
+func (c Cadence) next(t time.Time) time.Time {
+	switch c {
+	case CadenceWeekly:
+		return t.AddDate(0, 0, 7)
+	case CadenceBiweekly:
+		return t.AddDate(0, 0, 14)
+	default:
+		return t.AddDate(0, 1, 0)
+	}
+}
+
+// Installments schedules total in installments of installmentAmount, the first
+// due at start and the rest one cadence apart; the last one takes whatever is
+// left. Payments so far (paid) are applied to installments in order, and each
+// installment's status is judged against now.
+func Installments(total, installmentAmount float64, cadence Cadence, start time.Time, paid float64, now time.Time) []Installment {
+	if total <= 0 || installmentAmount <= 0 {
+		return nil
+	}
+	count := int(math.Ceil(total/installmentAmount - 1e-9))
+	installments := make([]Installment, count)
+	due := start
+	remaining := total
+	for i := range installments {
+		amount := math.Min(installmentAmount, remaining)
+		remaining -= amount
+		covered := math.Min(amount, paid)
+		paid -= covered
+
+		inst := Installment{Number: i + 1, DueAt: due, Amount: amount, Paid: covered}
+		switch {
+		case amount-covered < 0.005:
+			inst.Status = InstallmentPaid
+		case !due.After(now):
+			inst.Status = InstallmentOverdue
+		case due.Sub(now) <= ReminderWindow:
+			inst.Status = InstallmentDue
+		default:
+			inst.Status = InstallmentUpcoming
+		}
+		installments[i] = inst
+		due = cadence.next(due)
+	}
+	return installments
+}
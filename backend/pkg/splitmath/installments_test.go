@@ -0,0 +1,67 @@
+package splitmath
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInstallments(t *testing.T) {
+	start := time.Date(2026, 1, 31, 12, 0, 0, 0, time.UTC)
+
+	t.Run("last installment takes the remainder", func(t *testing.T) {
+		got := Installments(100, 30, CadenceWeekly, start, 0, start.AddDate(0, 0, -30))
+		if len(got) != 4 {
+			t.Fatalf("got %d installments, want 4", len(got))
+		}
+		if got[3].Amount != 10 {
+			t.Errorf("last amount = %v, want 10", got[3].Amount)
+		}
+		if want := start.AddDate(0, 0, 21); !got[3].DueAt.Equal(want) {
+			t.Errorf("last due = %v, want %v", got[3].DueAt, want)
+		}
+		for _, inst := range got {
+			if inst.Status != InstallmentUpcoming {
+				t.Errorf("installment %d status = %s, want upcoming", inst.Number, inst.Status)
+			}
+		}
+	})
+
+	t.Run("monthly cadence follows the calendar", func(t *testing.T) {
+		got := Installments(20, 10, CadenceMonthly, start, 0, start)
+		if want := start.AddDate(0, 1, 0); !got[1].DueAt.Equal(want) {
+			t.Errorf("second due = %v, want %v", got[1].DueAt, want)
+		}
+	})
+
+	t.Run("payments apply in order and set statuses", func(t *testing.T) {
+		now := start.AddDate(0, 0, 15)
+		got := Installments(100, 30, CadenceWeekly, start, 45, now)
+		want := []struct {
+			paid   float64
+			status InstallmentStatus
+		}{
+			{30, InstallmentPaid},
+			{15, InstallmentOverdue},
+			{0, InstallmentOverdue},
+			{0, InstallmentUpcoming}, // due in 6 days, outside the reminder window
+		}
+		for i, w := range want {
+			if got[i].Paid != w.paid || got[i].Status != w.status {
+				t.Errorf("installment %d = paid %v %s, want paid %v %s", i+1, got[i].Paid, got[i].Status, w.paid, w.status)
+			}
+		}
+	})
+
+	t.Run("due within the reminder window", func(t *testing.T) {
+		got := Installments(10, 10, CadenceWeekly, start, 0, start.Add(-ReminderWindow/2))
+		if got[0].Status != InstallmentDue {
+			t.Errorf("status = %s, want due", got[0].Status)
+		}
+	})
+
+	t.Run("non-positive amounts schedule nothing", func(t *testing.T) {
+		if got := Installments(100, 0, CadenceWeekly, start, 0, start); got != nil {
+			t.Errorf("got %v, want nil", got)
+		}
+	})
+}
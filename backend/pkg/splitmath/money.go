@@ -0,0 +1,77 @@
+package splitmath
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+)
+
+// Money is an amount in a currency.
+type Money struct {
+	Amount   float64 // In major units, e.g. dollars
+	Currency string  // ISO 4217 code; empty for an unknown currency with cents
+}
+
+// Round returns m rounded to its currency's minor unit, halves away from zero.
+func (m Money) Round() Money {
+	return Money{Amount: RoundAmount(m.Amount, m.Currency), Currency: m.Currency}
+}
+
+// MinorUnits returns m in its currency's minor unit, e.g. cents, rounded.
+func (m Money) MinorUnits() int64 {
+	return int64(math.Round(m.Amount * math.Pow10(MinorUnits(m.Currency))))
+}
+
+// String formats m as FormatAmount does, e.g. "JPY 1235" or "12.50".
+func (m Money) String() string {
+	return FormatAmount(m.Amount, m.Currency)
+}
+
+// Allocate divides m, rounded to its minor unit, into one part per weight in
+// proportion to the weights. The parts are whole minor units and add up to
+// exactly the rounded amount: the units left over from rounding down go to
+// the parts that lost most, earlier parts first on ties. Weights must not be
+// negative, and at least one must be positive.
+func (m Money) Allocate(weights ...float64) ([]Money, error) {
+	totalWeight := 0.0
+	for _, w := range weights {
+		if w < 0 || math.IsNaN(w) || math.IsInf(w, 0) {
+			return nil, fmt.Errorf("weight %v must be a non-negative number", w)
+		}
+		totalWeight += w
+	}
+	if totalWeight == 0 {
+		return nil, fmt.Errorf("at least one weight must be positive")
+	}
+
+	units := m.MinorUnits()
+	sign := int64(1)
+	if units < 0 {
+		sign, units = -1, -units
+	}
+	parts := make([]int64, len(weights))
+	lost := make([]float64, len(weights))
+	left := units
+	for i, w := range weights {
+		exact := float64(units) * w / totalWeight
+		parts[i] = int64(math.Floor(exact))
+		lost[i] = exact - float64(parts[i])
+		left -= parts[i]
+	}
+	order := make([]int, len(weights))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int { return cmp.Compare(lost[b], lost[a]) })
+	for i := int64(0); i < left; i++ {
+		parts[order[int(i)%len(order)]]++
+	}
+
+	scale := math.Pow10(MinorUnits(m.Currency))
+	out := make([]Money, len(parts))
+	for i, p := range parts {
+		out[i] = Money{Amount: float64(sign*p) / scale, Currency: m.Currency}
+	}
+	return out, nil
+}
@@ -0,0 +1,53 @@
+package splitmath
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMoneyAllocate(t *testing.T) {
+	tests := []struct {
+		name    string
+		money   Money
+		weights []float64
+		want    []float64
+		wantErr bool
+	}{
+		{name: "even", money: Money{Amount: 30}, weights: []float64{1, 1, 1}, want: []float64{10, 10, 10}},
+		{name: "leftover cent to the first on a tie", money: Money{Amount: 10}, weights: []float64{1, 1, 1}, want: []float64{3.34, 3.33, 3.33}},
+		{name: "leftover to the largest remainder", money: Money{Amount: 1}, weights: []float64{1, 2}, want: []float64{0.33, 0.67}},
+		{name: "yen has no cents", money: Money{Amount: 1000, Currency: "JPY"}, weights: []float64{1, 1, 1}, want: []float64{334, 333, 333}},
+		{name: "rounds the amount first", money: Money{Amount: 0.019}, weights: []float64{1, 1}, want: []float64{0.01, 0.01}},
+		{name: "negative", money: Money{Amount: -10}, weights: []float64{1, 1, 1}, want: []float64{-3.34, -3.33, -3.33}},
+		{name: "zero weight gets nothing", money: Money{Amount: 5}, weights: []float64{0, 1}, want: []float64{0, 5}},
+		{name: "no weights", money: Money{Amount: 5}, wantErr: true},
+		{name: "all zero", money: Money{Amount: 5}, weights: []float64{0, 0}, wantErr: true},
+		{name: "negative weight", money: Money{Amount: 5}, weights: []float64{2, -1}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts, err := tt.money.Allocate(tt.weights...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Allocate error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			var got []float64
+			var sum int64
+			for _, p := range parts {
+				if p.Currency != tt.money.Currency {
+					t.Errorf("part currency = %q, want %q", p.Currency, tt.money.Currency)
+				}
+				got = append(got, p.Amount)
+				sum += p.MinorUnits()
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Allocate = %v, want %v", got, tt.want)
+			}
+			if want := tt.money.MinorUnits(); sum != want {
+				t.Errorf("parts add up to %d minor units, want %d", sum, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package splitmath
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+// RoundingMode controls how a split's amounts are rounded and how the cents
+// lost to rounding are handed back so the shares still add up.
+type RoundingMode string
+
+const (
+	// RoundHalfUp rounds to the currency's minor unit, halves away from zero.
+	RoundHalfUp RoundingMode = "half_up"
+	// RoundCash rounds to the nearest 0.05 (or whole unit for currencies
+	// without cents), for bills settled in coins, halves away from zero.
+	RoundCash RoundingMode = "cash"
+	// RoundBankers rounds to the currency's minor unit, halves to even.
+	RoundBankers RoundingMode = "bankers"
+)
+
+// roundingEpsilon absorbs float error before rounding, so 1.005 counts as a
+// half rather than 1.00499999.
+const roundingEpsilon = 1e-6
+
+// step returns the rounding increment for currency in minor units, and the
+// number of minor units per major unit.
+func (mode RoundingMode) step(currency string) (step, scale float64) {
+	digits := MinorUnits(currency)
+	scale = math.Pow10(digits)
+	if mode == RoundCash && digits >= 2 {
+		return 5 * math.Pow10(digits-2), scale
+	}
+	return 1, scale
+}
+
+// Round rounds amount to the mode's increment in currency. An empty mode
+// falls back to RoundAmount.
+func (mode RoundingMode) Round(amount float64, currency string) float64 {
+	if mode == "" {
+		return RoundAmount(amount, currency)
+	}
+	step, scale := mode.step(currency)
+	return mode.roundSteps(amount*scale/step) * step / scale
+}
+
+// roundSteps rounds a count of increments to a whole number under mode.
+func (mode RoundingMode) roundSteps(steps float64) float64 {
+	steps = math.Round(steps/roundingEpsilon) * roundingEpsilon
+	if mode == RoundBankers {
+		return math.RoundToEven(steps)
+	}
+	return math.Round(steps)
+}
+
+// validateRounding accepts an empty mode or one of the known rounding modes.
+func validateRounding(mode RoundingMode) error {
+	switch mode {
+	case "", RoundHalfUp, RoundCash, RoundBankers:
+		return nil
+	}
+	return fmt.Errorf("unknown rounding mode %q", mode)
+}
+
+// roundSplitsWith rounds every amount in splits under opts.Rounding, then
+// nudges totals by one increment each until they add up to the rounded sum
+// of the unrounded totals. The increments go to whoever lost the most to
+// rounding (or, when taking back, gained the most), ties broken by name, so
+// the same bill always rounds the same way.
+func roundSplitsWith(splits map[string]*PersonSplit, participants []string, opts Options) {
+	mode, currency := opts.Rounding, opts.Currency
+	round := func(v float64) float64 { return mode.Round(v, currency) }
+
+	exact := make(map[string]float64, len(participants))
+	sum, roundedSum := 0.0, 0.0
+	for _, p := range participants {
+		split := splits[p]
+		exact[p] = split.Total
+		sum += split.Total
+		split.Subtotal = round(split.Subtotal)
+		split.Discount = round(split.Discount)
+		split.Tax = round(split.Tax)
+		split.Tip = round(split.Tip)
+		split.Fees = round(split.Fees)
+		split.Covered = round(split.Covered)
+		split.Total = round(split.Total)
+		for i := range split.Items {
+			split.Items[i].Amount = round(split.Items[i].Amount)
+		}
+		roundedSum += split.Total
+	}
+
+	step, scale := mode.step(currency)
+	residue := int(math.Round((round(sum) - roundedSum) * scale / step))
+	if residue == 0 {
+		return
+	}
+	order := slices.Clone(participants)
+	slices.SortFunc(order, func(a, b string) int {
+		lostA, lostB := exact[a]-splits[a].Total, exact[b]-splits[b].Total
+		if residue < 0 {
+			lostA, lostB = -lostA, -lostB
+		}
+		if lostA != lostB {
+			if lostA > lostB {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a, b)
+	})
+
+	unit := step / scale
+	if residue < 0 {
+		unit, residue = -unit, -residue
+	}
+	for i := range residue {
+		p := order[i%len(order)]
+		splits[p].Total = round(splits[p].Total + unit)
+		opts.Trace.add(TraceStep{Step: StepRounding, Participant: p, Detail: "rounding residue so shares add up to the total", Amount: unit})
+	}
+}
@@ -0,0 +1,136 @@
+package splitmath
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRoundingModeRound(t *testing.T) {
+	tests := []struct {
+		mode     RoundingMode
+		currency string
+		amount   float64
+		want     float64
+	}{
+		{RoundHalfUp, "USD", 2.675, 2.68},
+		{RoundHalfUp, "", 1.005, 1.01},
+		{RoundHalfUp, "JPY", 1234.5, 1235},
+		{RoundBankers, "USD", 0.125, 0.12},
+		{RoundBankers, "USD", 0.135, 0.14},
+		{RoundBankers, "JPY", 2.5, 2},
+		{RoundCash, "", 1.02, 1.00},
+		{RoundCash, "", 1.025, 1.05},
+		{RoundCash, "CHF", 3.333, 3.35},
+		{RoundCash, "JPY", 1234.5, 1235},
+		{RoundCash, "BHD", 1.2345, 1.25},
+		{"", "USD", 12.345, 12.35},
+	}
+	for _, tt := range tests {
+		if got := tt.mode.Round(tt.amount, tt.currency); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%q.Round(%v, %q) = %v, want %v", tt.mode, tt.amount, tt.currency, got, tt.want)
+		}
+	}
+}
+
+func TestCalculateSplitWithOptions_Rounding(t *testing.T) {
+	participants := []string{"Carol", "Alice", "Bob"}
+
+	tests := []struct {
+		name     string
+		total    float64
+		subtotal float64
+		people   []string
+		mode     RoundingMode
+		want     map[string]float64
+	}{
+		{
+			name:     "half up hands the lost cent to the first name",
+			total:    100,
+			subtotal: 100,
+			people:   participants,
+			mode:     RoundHalfUp,
+			want:     map[string]float64{"Alice": 33.34, "Bob": 33.33, "Carol": 33.33},
+		},
+		{
+			name:     "cash takes back the extra nickel",
+			total:    10,
+			subtotal: 10,
+			people:   participants,
+			mode:     RoundCash,
+			want:     map[string]float64{"Alice": 3.30, "Bob": 3.35, "Carol": 3.35},
+		},
+		{
+			name:     "cash rounds the total too",
+			total:    10.02,
+			subtotal: 10.02,
+			people:   []string{"Alice", "Bob"},
+			mode:     RoundCash,
+			want:     map[string]float64{"Alice": 5.00, "Bob": 5.00},
+		},
+		{
+			name:     "bankers rounds halves to even",
+			total:    0.25,
+			subtotal: 0.25,
+			people:   []string{"Bob", "Alice"},
+			mode:     RoundBankers,
+			want:     map[string]float64{"Alice": 0.13, "Bob": 0.12},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trace := &Trace{}
+			splits, err := CalculateSplitWithOptions(nil, tt.total, tt.subtotal, tt.people, Options{Rounding: tt.mode, Trace: trace})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			sum := 0.0
+			for person, want := range tt.want {
+				if got := splits[person].Total; math.Abs(got-want) > 1e-9 {
+					t.Errorf("%s total = %v, want %v", person, got, want)
+				}
+				sum += splits[person].Total
+			}
+			if want := tt.mode.Round(tt.total, ""); math.Abs(sum-want) > 1e-9 {
+				t.Errorf("totals add up to %v, want %v", sum, want)
+			}
+		})
+	}
+}
+
+func TestCalculateSplitWithOptions_RoundingDeterministic(t *testing.T) {
+	// The person who lost the most to rounding gets the residue, whatever the input order.
+	items := []Item{
+		{Description: "A", Amount: 10.004, Participants: []string{"Alice"}},
+		{Description: "B", Amount: 10.001, Participants: []string{"Bob"}},
+		{Description: "C", Amount: 10.004, Participants: []string{"Carol"}},
+	}
+	for _, order := range [][]string{{"Alice", "Bob", "Carol"}, {"Carol", "Bob", "Alice"}} {
+		trace := &Trace{}
+		splits, err := CalculateSplitWithOptions(items, 30.009, 30.009, order, Options{Rounding: RoundHalfUp, Trace: trace})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := map[string]float64{"Alice": 10.01, "Bob": 10.00, "Carol": 10.00}
+		for person, total := range want {
+			if got := splits[person].Total; math.Abs(got-total) > 1e-9 {
+				t.Errorf("order %v: %s total = %v, want %v", order, person, got, total)
+			}
+		}
+		var rounding []TraceStep
+		for _, step := range trace.Steps {
+			if step.Step == StepRounding {
+				rounding = append(rounding, step)
+			}
+		}
+		if len(rounding) != 1 || rounding[0].Participant != "Alice" {
+			t.Errorf("order %v: rounding steps = %+v, want one for Alice", order, rounding)
+		}
+	}
+}
+
+func TestCalculateSplitWithOptions_UnknownRounding(t *testing.T) {
+	_, err := CalculateSplitWithOptions(nil, 10, 10, []string{"Alice"}, Options{Rounding: "ceil"})
+	if err == nil {
+		t.Fatal("expected error for unknown rounding mode")
+	}
+}
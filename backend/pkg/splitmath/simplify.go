@@ -0,0 +1,91 @@
+package splitmath
+
+import (
+	"math/bits"
+	"slices"
+	"strings"
+)
+
+// SimplifyMode selects how net balances are turned into transfers.
+type SimplifyMode string
+
+const (
+	// SimplifyGreedy matches debtors with creditors in order.
+	SimplifyGreedy SimplifyMode = "greedy"
+	// SimplifyMinTransfers finds the fewest transfers that settle everyone,
+	// falling back to greedy above MaxExactSimplifyMembers.
+	SimplifyMinTransfers SimplifyMode = "min_transfers"
+)
+
+// MaxExactSimplifyMembers caps how many members with a nonzero balance
+// SimplifyMinTransfers solves exactly; the search is exponential in this.
+const MaxExactSimplifyMembers = 16
+
+// simplifyMinTransfers returns the fewest transfers that settle balances.
+//
+// A group of k people whose balances sum to zero can always be settled with
+// k-1 transfers, so the minimum is n minus the largest number of disjoint
+// zero-sum groups the n nonzero balances can be partitioned into. That
+// partition is found with a DP over subsets, then each group is settled greedily.
+func simplifyMinTransfers(balances []MemberBalance) []DebtEdge {
+	// Work in cents so zero-sum groups are detected exactly.
+	var members []MemberBalance
+	var cents []int64
+	total := int64(0)
+	for _, bal := range balances {
+		c := toCents(bal.NetBalance)
+		if c != 0 {
+			members = append(members, bal)
+			cents = append(cents, c)
+			total += c
+		}
+	}
+	if len(members) > MaxExactSimplifyMembers || total != 0 {
+		return simplifyGreedy(balances)
+	}
+
+	// Sort by name so equally minimal answers come out the same every time.
+	order := make([]int, len(members))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortFunc(order, func(a, b int) int { return strings.Compare(members[a].MemberName, members[b].MemberName) })
+
+	n := len(members)
+	full := 1<<n - 1
+	sums := make([]int64, full+1)
+	groups := make([]int, full+1) // most zero-sum groups mask can be split into
+	last := make([]int, full+1)   // member removed from mask on the way to that split
+	for mask := 1; mask <= full; mask++ {
+		low := bits.TrailingZeros(uint(mask))
+		sums[mask] = sums[mask&(mask-1)] + cents[order[low]]
+		best := -1
+		for rest := mask; rest != 0; rest &= rest - 1 {
+			i := bits.TrailingZeros(uint(rest))
+			if g := groups[mask&^(1<<i)]; g > best {
+				best, last[mask] = g, i
+			}
+		}
+		groups[mask] = best
+		if sums[mask] == 0 {
+			groups[mask]++
+		}
+	}
+
+	// Walk back from the full set; each stretch between zero-sum masks is a group.
+	var edges []DebtEdge
+	var group []MemberBalance
+	for mask := full; mask != 0; {
+		i := last[mask]
+		group = append(group, MemberBalance{
+			MemberName: members[order[i]].MemberName,
+			NetBalance: fromCents(cents[order[i]]),
+		})
+		mask &^= 1 << i
+		if sums[mask] == 0 {
+			edges = append(edges, simplifyGreedy(group)...)
+			group = nil
+		}
+	}
+	return edges
+}
@@ -0,0 +1,95 @@
+package splitmath
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// settles reports whether edges bring every balance to zero.
+func settles(balances []MemberBalance, edges []DebtEdge) bool {
+	net := make(map[string]float64)
+	for _, b := range balances {
+		net[b.MemberName] = b.NetBalance
+	}
+	for _, e := range edges {
+		net[e.From] += e.Amount
+		net[e.To] -= e.Amount
+	}
+	for _, v := range net {
+		if math.Abs(v) > 0.01 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSimplifyMinTransfers(t *testing.T) {
+	t.Run("beats greedy when debts pair up exactly", func(t *testing.T) {
+		balances := []MemberBalance{
+			{MemberName: "Dan", NetBalance: -4},
+			{MemberName: "Eve", NetBalance: -6},
+			{MemberName: "Cat", NetBalance: 6},
+			{MemberName: "Bob", NetBalance: 4},
+		}
+		greedy := simplifyGreedy(balances)
+		exact := simplifyMinTransfers(balances)
+		if len(greedy) != 3 {
+			t.Fatalf("greedy transfers = %d, want 3 for this order", len(greedy))
+		}
+		if len(exact) != 2 {
+			t.Errorf("min transfers = %d (%v), want 2", len(exact), exact)
+		}
+		if !settles(balances, exact) {
+			t.Errorf("min transfers %v don't settle %v", exact, balances)
+		}
+	})
+
+	t.Run("uneven amounts still settle", func(t *testing.T) {
+		balances := []MemberBalance{
+			{MemberName: "A", NetBalance: 10.33},
+			{MemberName: "B", NetBalance: -3.33},
+			{MemberName: "C", NetBalance: -7},
+			{MemberName: "D", NetBalance: 2.5},
+			{MemberName: "E", NetBalance: -2.5},
+		}
+		edges := simplifyMinTransfers(balances)
+		if len(edges) != 3 || !settles(balances, edges) {
+			t.Errorf("got %v, want 3 transfers that settle everyone", edges)
+		}
+	})
+
+	t.Run("falls back to greedy above the size cutoff", func(t *testing.T) {
+		var balances []MemberBalance
+		for i := range MaxExactSimplifyMembers + 2 {
+			amount := float64(i + 1)
+			if i%2 == 1 {
+				amount = -float64(i)
+			}
+			balances = append(balances, MemberBalance{MemberName: fmt.Sprintf("M%02d", i), NetBalance: amount})
+		}
+		edges := simplifyMinTransfers(balances)
+		if !settles(balances, edges) {
+			t.Errorf("fallback transfers %v don't settle everyone", edges)
+		}
+	})
+}
+
+func TestCalculateGroupBalancesWithMode(t *testing.T) {
+	bills := []BillForBalance{
+		{Total: 4, Subtotal: 4, PayerID: "Bob", Participants: []string{"Dan"}},
+		{Total: 6, Subtotal: 6, PayerID: "Cat", Participants: []string{"Eve"}},
+	}
+	_, edges, err := CalculateGroupBalancesWithMode(bills, nil, SimplifyMinTransfers)
+	if err != nil {
+		t.Fatalf("CalculateGroupBalancesWithMode() error = %v", err)
+	}
+	if len(edges) != 2 {
+		t.Errorf("got %v, want Dan→Bob and Eve→Cat", edges)
+	}
+	for _, e := range edges {
+		if (e.From == "Dan") != (e.To == "Bob") {
+			t.Errorf("unexpected transfer %+v", e)
+		}
+	}
+}
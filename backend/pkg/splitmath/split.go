@@ -0,0 +1,855 @@
+package splitmath
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+// PersonItem represents an item's share for one person
+type PersonItem struct {
+	Description string
+	Amount      float64 // This person's share of the item
+	Item        int     // Index of the item in the bill's items; -1 for the shared remainder and adjustments
+}
+
+// PersonSplit represents the calculated split for one person
+type PersonSplit struct {
+	Subtotal float64 // Before discount
+	Discount float64 // This person's share of the bill discount
+	Tax      float64
+	Tip      float64
+	Fees     float64 // This person's share of the bill's fees
+	Covered  float64 // Negative for a covered person; the extra others pay for them
+	Total    float64
+	Items    []PersonItem // Items assigned to this person with their share
+}
+
+// Item represents a single item on the bill
+type Item struct {
+	Description  string
+	Amount       float64
+	Participants []string           // was: AssignedTo
+	Shares       map[string]float64 // Per-person weight overrides for SplitShares
+
+	// Discount is taken off Amount and only benefits the item's participants
+	// (e.g. happy hour, BOGO). The bill subtotal is after item discounts.
+	Discount float64
+
+	// Quantity and UnitPrice describe items like "3x Beer $6"; Amount defaults
+	// to Quantity × UnitPrice when zero. Units assigns each participant a number
+	// of units (Alice 2, Bob 1) and overrides any other weighting for the item.
+	Quantity  float64
+	UnitPrice float64
+	Units     map[string]float64
+
+	// Category sorts items on a mixed receipt. Household items are shared by
+	// every participant at their bill-level weight, and personal items are
+	// charged in full to Owner; either way Participants, Shares and Units are
+	// ignored. Uncategorized items are split among Participants as usual.
+	Category ItemCategory
+	Owner    string
+}
+
+// ItemCategory marks an item as shared by the household or bought for one person.
+type ItemCategory string
+
+const (
+	// ItemHousehold items are shared by everyone on the bill.
+	ItemHousehold ItemCategory = "household"
+	// ItemPersonal items are charged in full to the item's owner.
+	ItemPersonal ItemCategory = "personal"
+)
+
+// amount returns the item's amount, derived from quantity and unit price if unset.
+func (item Item) amount() float64 {
+	if item.Amount == 0 && item.Quantity > 0 {
+		return item.Quantity * item.UnitPrice
+	}
+	return item.Amount
+}
+
+// net returns the item's amount after its discount.
+func (item Item) net() float64 {
+	return item.amount() - item.Discount
+}
+
+// ItemsTotal returns the sum of the items' amounts after item discounts.
+func ItemsTotal(items []Item) float64 {
+	total := 0.0
+	for _, item := range items {
+		total += item.net()
+	}
+	return total
+}
+
+// SplitType controls how an amount is divided among the people sharing it.
+type SplitType string
+
+const (
+	// SplitEqual divides each amount evenly among the people sharing it.
+	SplitEqual SplitType = "equal"
+	// SplitShares divides each amount in proportion to each person's shares.
+	SplitShares SplitType = "shares"
+	// SplitExact assigns each person an exact amount owed; items are not used.
+	SplitExact SplitType = "exact"
+)
+
+// TipSplitMode controls how a bill's tip is distributed among participants.
+type TipSplitMode string
+
+const (
+	// TipProportional distributes tip by subtotal share, the same way as tax.
+	TipProportional TipSplitMode = "proportional"
+	// TipEqual divides tip evenly among all participants.
+	TipEqual TipSplitMode = "equal"
+)
+
+// DiscountType controls how Options.Discount is interpreted.
+type DiscountType string
+
+const (
+	// DiscountAmount takes a fixed amount off the subtotal.
+	DiscountAmount DiscountType = "amount"
+	// DiscountPercent takes a percentage off the subtotal.
+	DiscountPercent DiscountType = "percent"
+)
+
+// FeeSplitMode controls how a fee is distributed among participants.
+type FeeSplitMode string
+
+const (
+	// FeeProportional distributes a fee by discounted subtotal share.
+	FeeProportional FeeSplitMode = "proportional"
+	// FeeEqual divides a fee evenly among all participants.
+	FeeEqual FeeSplitMode = "equal"
+)
+
+// RemainderMode controls how the part of the subtotal not covered by items is divided.
+type RemainderMode string
+
+const (
+	// RemainderEqual divides the remainder evenly, or by bill-level shares for SplitShares.
+	RemainderEqual RemainderMode = "equal"
+	// RemainderProportional divides the remainder by each person's itemized subtotal.
+	RemainderProportional RemainderMode = "proportional"
+	// RemainderPayer assigns the whole remainder to the payer.
+	RemainderPayer RemainderMode = "payer"
+)
+
+// Fee is a flat charge on the bill, such as a delivery or service fee.
+// Fees are part of the total but are not taxed.
+type Fee struct {
+	Description string
+	Amount      float64
+	SplitMode   FeeSplitMode // Defaults to FeeProportional when empty
+}
+
+// CoverMode controls how much of a participant's share the others pay for.
+type CoverMode string
+
+const (
+	// CoverNone leaves the participant's share as calculated.
+	CoverNone CoverMode = "none"
+	// CoverTaxAndTip moves the participant's tax and tip to the others.
+	CoverTaxAndTip CoverMode = "tax_tip"
+	// CoverAll moves the participant's whole share to the others.
+	CoverAll CoverMode = "all"
+)
+
+// Options holds optional bill-level settings that refine how a split is computed.
+// The zero value reproduces the plain CalculateSplit behavior.
+type Options struct {
+	Tip          float64      // Portion of the total that is tip; the rest of total - subtotal is tax
+	TipSplitMode TipSplitMode // Defaults to TipProportional when empty
+
+	// SplitType defaults to SplitEqual when empty. With SplitShares, each person
+	// weighs Item.Shares[person], else Shares[person], else 1.
+	SplitType SplitType
+	Shares    map[string]float64
+
+	// Amounts holds each person's exact total (tax and tip included) for SplitExact.
+	Amounts map[string]float64
+
+	// Discount is taken off the subtotal before tax and allocated in proportion
+	// to each person's subtotal. DiscountType defaults to DiscountAmount when empty.
+	Discount     float64
+	DiscountType DiscountType
+
+	// Fees are flat charges included in the total and kept out of tax.
+	Fees []Fee
+
+	// RemainderMode controls how the part of the subtotal not covered by
+	// items is divided; it defaults to RemainderEqual when empty. Bills
+	// without items are always divided by weight. Payer is the display name
+	// of who paid, required for RemainderPayer.
+	RemainderMode RemainderMode
+	Payer         string
+
+	// Adjustments moves a fixed amount of the evenly split subtotal onto
+	// (positive) or off (negative) a person, e.g. +5 for whoever had a
+	// cocktail. They apply to the part of the subtotal not covered by items:
+	// the whole subtotal when there are none. Not valid with SplitExact.
+	Adjustments map[string]float64
+
+	// TaxInclusive means item prices, and so the subtotal and discount,
+	// already include tax at TaxRate percent (e.g. VAT). The included tax is
+	// backed out of each person's subtotal and reported as tax; any total
+	// beyond the subtotal, tip and fees is still added on top as usual.
+	TaxInclusive bool
+	TaxRate      float64
+
+	// Covered lists participants whose share is paid by the others (e.g. a
+	// birthday). The covered amount is divided evenly among everyone not covered.
+	Covered map[string]CoverMode
+
+	// Currency is the bill's ISO 4217 code. When set, every amount in the
+	// result is rounded to the currency's minor unit (none for JPY, three
+	// for BHD); when empty, amounts are left unrounded.
+	Currency string
+
+	// Rounding, when set, rounds every amount in the result to the mode's
+	// increment in Currency (cents when empty) and then moves whole
+	// increments between totals so they add up to the rounded bill total.
+	Rounding RoundingMode
+
+	// Trace, when non-nil, receives a step-by-step explanation of the split.
+	Trace *Trace
+}
+
+// FeesTotal returns the sum of all fees in opts.
+func (opts Options) FeesTotal() float64 {
+	total := 0.0
+	for _, fee := range opts.Fees {
+		total += fee.Amount
+	}
+	return total
+}
+
+// TaxAmount returns the tax implied by a bill's total and subtotal under opts:
+// whatever is left after the discounted subtotal, tip and fees, plus the tax
+// included in the discounted subtotal for tax-inclusive prices.
+func (opts Options) TaxAmount(total, subtotal float64) float64 {
+	net := subtotal - opts.DiscountAmount(subtotal)
+	return total - opts.ExcludingTax(net) - opts.Tip - opts.FeesTotal()
+}
+
+// ExcludingTax returns amount without the tax included in it under opts.
+// It is amount itself unless prices are tax-inclusive.
+func (opts Options) ExcludingTax(amount float64) float64 {
+	if !opts.TaxInclusive {
+		return amount
+	}
+	return amount / (1 + opts.TaxRate/100)
+}
+
+// format renders amount for trace details in the bill's currency.
+func (opts Options) format(amount float64) string {
+	return FormatAmount(amount, opts.Currency)
+}
+
+// DiscountAmount returns the amount opts.Discount takes off subtotal.
+func (opts Options) DiscountAmount(subtotal float64) float64 {
+	if opts.DiscountType == DiscountPercent {
+		return subtotal * opts.Discount / 100
+	}
+	return opts.Discount
+}
+
+// CalculateSplit computes how much each person owes including proportional tax
+// Based on the algorithm: person_total = person_subtotal × (1 + (total_tax / bill_subtotal))
+func CalculateSplit(items []Item, billTotal float64, billSubtotal float64, participants []string) (map[string]*PersonSplit, error) {
+	return CalculateSplitWithOptions(items, billTotal, billSubtotal, participants, Options{})
+}
+
+// CalculateSplitWithOptions is CalculateSplit with bill-level options applied.
+// Tax is total - (subtotal - discount) - tip - fees and is always distributed
+// proportionally; tip and fees are distributed according to their split modes.
+func CalculateSplitWithOptions(items []Item, billTotal float64, billSubtotal float64, participants []string, opts Options) (map[string]*PersonSplit, error) {
+	if billSubtotal == 0 {
+		return nil, fmt.Errorf("subtotal cannot be zero")
+	}
+	if len(participants) == 0 {
+		return nil, fmt.Errorf("must have at least one participant")
+	}
+	items, err := categorizeItems(items, participants)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Tip < 0 {
+		return nil, fmt.Errorf("tip cannot be negative")
+	}
+	switch opts.TipSplitMode {
+	case "", TipProportional, TipEqual:
+	default:
+		return nil, fmt.Errorf("unknown tip split mode %q", opts.TipSplitMode)
+	}
+	switch opts.RemainderMode {
+	case "", RemainderEqual, RemainderProportional, RemainderPayer:
+	default:
+		return nil, fmt.Errorf("unknown remainder mode %q", opts.RemainderMode)
+	}
+	if err := validateFees(opts.Fees); err != nil {
+		return nil, err
+	}
+	if err := validateShares(items, opts); err != nil {
+		return nil, err
+	}
+	if err := validateUnits(items); err != nil {
+		return nil, err
+	}
+	if err := validateDiscount(items, billSubtotal, opts); err != nil {
+		return nil, err
+	}
+	if err := validateCovered(participants, opts.Covered); err != nil {
+		return nil, err
+	}
+	if err := validateAdjustments(participants, opts); err != nil {
+		return nil, err
+	}
+	if err := validateCurrency(opts.Currency); err != nil {
+		return nil, err
+	}
+	if err := validateTaxRate(opts); err != nil {
+		return nil, err
+	}
+	if err := validateRounding(opts.Rounding); err != nil {
+		return nil, err
+	}
+	if opts.SplitType == SplitExact {
+		splits, err := calculateExactSplit(items, billTotal, billSubtotal, participants, opts)
+		if err != nil {
+			return nil, err
+		}
+		applyCoverage(splits, participants, opts)
+		finish(splits, participants, opts)
+		return splits, nil
+	}
+
+	tax := opts.TaxAmount(billTotal, billSubtotal)
+	splits := make(map[string]*PersonSplit)
+
+	// Initialize splits for all participants
+	for _, p := range participants {
+		splits[p] = &PersonSplit{
+			Subtotal: 0,
+			Tax:      0,
+			Total:    0,
+			Items:    []PersonItem{},
+		}
+	}
+
+	// If no items, split total among all participants (equally unless weighted)
+	if len(items) == 0 {
+		pool, err := opts.adjustedPool(billSubtotal)
+		if err != nil {
+			return nil, err
+		}
+		totalWeight := opts.totalWeight(Item{}, participants)
+		for _, p := range participants {
+			ratio := opts.weight(Item{}, p) / totalWeight
+			splits[p].Subtotal = pool * ratio
+			opts.Trace.add(TraceStep{Step: StepEven, Participant: p, Detail: "no items; share of the subtotal", Amount: splits[p].Subtotal, Ratio: ratio})
+			if err := opts.applyAdjustment(splits[p], p); err != nil {
+				return nil, err
+			}
+		}
+		applyTaxAndTip(splits, participants, billSubtotal, tax, opts)
+		applyCoverage(splits, participants, opts)
+		finish(splits, participants, opts)
+		return splits, nil
+	}
+
+	// Calculate each person's subtotal based on assigned items
+	itemsTotal := 0.0
+	for i, item := range items {
+		if len(item.Participants) == 0 {
+			continue
+		}
+
+		itemsTotal += item.net()
+
+		// Split item among assigned people
+		totalWeight := opts.totalWeight(item, item.Participants)
+		for _, person := range item.Participants {
+			ratio := opts.weight(item, person) / totalWeight
+			perPersonAmount := item.net() * ratio
+			if split, exists := splits[person]; exists {
+				opts.Trace.add(TraceStep{
+					Step:        StepItem,
+					Participant: person,
+					Detail:      item.Description,
+					Amount:      perPersonAmount,
+					Ratio:       ratio,
+				})
+				split.Subtotal += perPersonAmount
+				split.Items = append(split.Items, PersonItem{
+					Description: item.Description,
+					Amount:      perPersonAmount,
+					Item:        i,
+				})
+			}
+		}
+	}
+
+	// If items don't account for full subtotal, split the remainder per opts.RemainderMode
+	if itemsTotal < billSubtotal {
+		remainder := billSubtotal - itemsTotal
+		opts.Trace.add(TraceStep{Step: StepRemainder, Detail: fmt.Sprintf("items total %s of subtotal %s", opts.format(itemsTotal), opts.format(billSubtotal)), Amount: remainder})
+		pool, err := opts.adjustedPool(remainder)
+		if err != nil {
+			return nil, err
+		}
+		ratios, detail, err := opts.remainderRatios(splits, participants)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range participants {
+			split := splits[p]
+			ratio := ratios[p]
+			if ratio > 0 {
+				perPersonShare := pool * ratio
+				opts.Trace.add(TraceStep{Step: StepRemainder, Participant: p, Detail: detail, Amount: perPersonShare, Ratio: ratio})
+				split.Subtotal += perPersonShare
+				split.Items = append(split.Items, PersonItem{
+					Description: "Shared",
+					Amount:      perPersonShare,
+					Item:        -1,
+				})
+			}
+			if err := opts.applyAdjustment(split, p); err != nil {
+				return nil, err
+			}
+		}
+	} else if len(opts.Adjustments) > 0 {
+		return nil, fmt.Errorf("adjustments need part of the subtotal left unitemized")
+	}
+
+	applyTaxAndTip(splits, participants, billSubtotal, tax, opts)
+	applyCoverage(splits, participants, opts)
+	finish(splits, participants, opts)
+
+	return splits, nil
+}
+
+// finish rounds splits under the bill's rounding mode or to its currency, if
+// it has either, and records each person's final total.
+func finish(splits map[string]*PersonSplit, participants []string, opts Options) {
+	switch {
+	case opts.Rounding != "":
+		roundSplitsWith(splits, participants, opts)
+	case opts.Currency != "":
+		roundSplits(splits, opts.Currency)
+	}
+	for _, p := range participants {
+		opts.Trace.add(TraceStep{Step: StepTotal, Participant: p, Amount: splits[p].Total})
+	}
+}
+
+// applyCoverage moves each covered participant's tax and tip, or whole total,
+// onto the participants who aren't covered, divided evenly.
+func applyCoverage(splits map[string]*PersonSplit, participants []string, opts Options) {
+	moved := 0.0
+	var payers []string
+	for _, p := range participants {
+		split := splits[p]
+		var amount float64
+		switch opts.Covered[p] {
+		case CoverTaxAndTip:
+			amount = split.Tax + split.Tip
+		case CoverAll:
+			amount = split.Total
+		default:
+			payers = append(payers, p)
+			continue
+		}
+		split.Covered = -amount
+		split.Total -= amount
+		moved += amount
+		opts.Trace.add(TraceStep{Step: StepCover, Participant: p, Detail: fmt.Sprintf("covered (%s) by the others", opts.Covered[p]), Amount: -amount})
+	}
+	if moved == 0 {
+		return
+	}
+	perPayer := moved / float64(len(payers))
+	for _, p := range payers {
+		splits[p].Covered = perPayer
+		splits[p].Total += perPayer
+		opts.Trace.add(TraceStep{Step: StepCover, Participant: p, Detail: "share of covered participants", Amount: perPayer, Ratio: 1 / float64(len(payers))})
+	}
+}
+
+// validateCovered rejects unknown cover modes, covering someone who isn't a
+// participant, and covering everyone.
+func validateCovered(participants []string, covered map[string]CoverMode) error {
+	uncovered := len(participants)
+	for person, mode := range covered {
+		switch mode {
+		case "", CoverNone:
+			continue
+		case CoverTaxAndTip, CoverAll:
+		default:
+			return fmt.Errorf("unknown cover mode %q for %s", mode, person)
+		}
+		if !slices.Contains(participants, person) {
+			return fmt.Errorf("covered person %s is not a participant", person)
+		}
+		uncovered--
+	}
+	if uncovered == 0 {
+		return fmt.Errorf("at least one participant must not be covered")
+	}
+	return nil
+}
+
+// remainderRatios returns each participant's share of the unitemized
+// remainder under opts.RemainderMode, given splits holding their itemized
+// subtotals, and a trace detail describing it.
+func (opts Options) remainderRatios(splits map[string]*PersonSplit, participants []string) (map[string]float64, string, error) {
+	ratios := make(map[string]float64, len(participants))
+	switch opts.RemainderMode {
+	case RemainderPayer:
+		if !slices.Contains(participants, opts.Payer) {
+			return nil, "", fmt.Errorf("assigning the remainder to the payer requires a payer among the participants")
+		}
+		ratios[opts.Payer] = 1
+		return ratios, "unassigned remainder goes to the payer", nil
+	case RemainderProportional:
+		itemized := 0.0
+		for _, p := range participants {
+			itemized += splits[p].Subtotal
+		}
+		// With nothing itemized to anyone there's nothing to be proportional to.
+		if itemized > 0 {
+			for _, p := range participants {
+				ratios[p] = splits[p].Subtotal / itemized
+			}
+			return ratios, "share of the unassigned remainder by itemized subtotal", nil
+		}
+	}
+	totalWeight := opts.totalWeight(Item{}, participants)
+	for _, p := range participants {
+		ratios[p] = opts.weight(Item{}, p) / totalWeight
+	}
+	return ratios, "share of the unassigned remainder", nil
+}
+
+// validateAdjustments rejects adjustments for non-participants and on exact splits.
+func validateAdjustments(participants []string, opts Options) error {
+	for person, amount := range opts.Adjustments {
+		if amount == 0 {
+			continue
+		}
+		if opts.SplitType == SplitExact {
+			return fmt.Errorf("adjustments cannot be used with exact splits")
+		}
+		if !slices.Contains(participants, person) {
+			return fmt.Errorf("adjusted person %s is not a participant", person)
+		}
+	}
+	return nil
+}
+
+// adjustedPool returns what is left of amount to split by weight once the
+// adjustments are set aside.
+func (opts Options) adjustedPool(amount float64) (float64, error) {
+	adjusted := 0.0
+	for _, adjustment := range opts.Adjustments {
+		adjusted += adjustment
+	}
+	if adjusted > amount {
+		return 0, fmt.Errorf("adjustments of %s exceed the %s split evenly", opts.format(adjusted), opts.format(amount))
+	}
+	return amount - adjusted, nil
+}
+
+// applyAdjustment adds person's adjustment, if any, to their subtotal.
+func (opts Options) applyAdjustment(split *PersonSplit, person string) error {
+	adjustment := opts.Adjustments[person]
+	if adjustment == 0 {
+		return nil
+	}
+	split.Subtotal += adjustment
+	if split.Subtotal < 0 {
+		return fmt.Errorf("adjustment for %s is more than their share", person)
+	}
+	split.Items = append(split.Items, PersonItem{Description: "Adjustment", Amount: adjustment, Item: -1})
+	opts.Trace.add(TraceStep{Step: StepAdjust, Participant: person, Detail: "adjustment", Amount: adjustment})
+	return nil
+}
+
+// applyTaxAndTip allocates the discount by subtotal, distributes tax
+// proportionally over the discounted subtotals and tip and fees per their
+// split modes, then fills in each person's total. Tax-inclusive subtotals
+// are first reduced to their pre-tax amounts.
+func applyTaxAndTip(splits map[string]*PersonSplit, participants []string, billSubtotal, tax float64, opts Options) {
+	discount := opts.DiscountAmount(billSubtotal)
+	if opts.TaxInclusive {
+		excludeTax(splits, participants, opts)
+		billSubtotal = opts.ExcludingTax(billSubtotal)
+		discount = opts.ExcludingTax(discount)
+	}
+	netSubtotal := billSubtotal - discount
+	perPersonTip := opts.Tip / float64(len(splits))
+	detail := fmt.Sprintf("tax = total - (subtotal %s - discount %s) - tip %s - fees %s",
+		opts.format(billSubtotal), opts.format(discount), opts.format(opts.Tip), opts.format(opts.FeesTotal()))
+	if opts.TaxInclusive {
+		detail += fmt.Sprintf(", with prices including %g%% tax", opts.TaxRate)
+	}
+	opts.Trace.add(TraceStep{
+		Step:   StepTaxRatio,
+		Detail: detail,
+		Amount: tax,
+		Ratio:  tax / netSubtotal,
+	})
+	for _, p := range participants {
+		split := splits[p]
+		split.Discount = split.Subtotal * (discount / billSubtotal)
+		net := split.Subtotal - split.Discount
+		if discount != 0 {
+			opts.Trace.add(TraceStep{Step: StepDiscount, Participant: p, Detail: "share of the bill discount by subtotal", Amount: split.Discount, Ratio: split.Subtotal / billSubtotal})
+		}
+		split.Tax = net * (tax / netSubtotal)
+		opts.Trace.add(TraceStep{Step: StepTax, Participant: p, Detail: "discounted subtotal × tax ratio", Amount: split.Tax, Ratio: tax / netSubtotal})
+		if opts.TipSplitMode == TipEqual {
+			split.Tip = perPersonTip
+			opts.Trace.add(TraceStep{Step: StepTip, Participant: p, Detail: "tip split equally", Amount: split.Tip, Ratio: 1 / float64(len(splits))})
+		} else {
+			split.Tip = net * (opts.Tip / netSubtotal)
+			opts.Trace.add(TraceStep{Step: StepTip, Participant: p, Detail: "tip by discounted subtotal", Amount: split.Tip, Ratio: net / netSubtotal})
+		}
+		split.Fees = 0
+		for _, fee := range opts.Fees {
+			ratio := net / netSubtotal
+			if fee.SplitMode == FeeEqual {
+				ratio = 1 / float64(len(splits))
+			}
+			share := fee.Amount * ratio
+			split.Fees += share
+			opts.Trace.add(TraceStep{Step: StepFee, Participant: p, Detail: fee.Description, Amount: share, Ratio: ratio})
+		}
+		split.Total = net + split.Tax + split.Tip + split.Fees
+	}
+}
+
+// excludeTax reduces each person's tax-inclusive subtotal and item shares to
+// their pre-tax amounts.
+func excludeTax(splits map[string]*PersonSplit, participants []string, opts Options) {
+	for _, p := range participants {
+		split := splits[p]
+		gross := split.Subtotal
+		split.Subtotal = opts.ExcludingTax(gross)
+		for i := range split.Items {
+			split.Items[i].Amount = opts.ExcludingTax(split.Items[i].Amount)
+		}
+		opts.Trace.add(TraceStep{Step: StepIncludedTax, Participant: p, Detail: fmt.Sprintf("%g%% tax included in the subtotal", opts.TaxRate), Amount: gross - split.Subtotal})
+	}
+}
+
+// validateTaxRate rejects negative tax rates and rates on tax-exclusive bills,
+// and requires one for tax-inclusive bills.
+func validateTaxRate(opts Options) error {
+	if opts.TaxRate < 0 {
+		return fmt.Errorf("tax rate cannot be negative")
+	}
+	if opts.TaxInclusive && opts.TaxRate == 0 {
+		return fmt.Errorf("tax-inclusive prices require a tax rate")
+	}
+	if !opts.TaxInclusive && opts.TaxRate != 0 {
+		return fmt.Errorf("a tax rate only applies to tax-inclusive prices")
+	}
+	return nil
+}
+
+// validateFees rejects negative fees and unknown fee split modes.
+func validateFees(fees []Fee) error {
+	for _, fee := range fees {
+		if fee.Amount < 0 {
+			return fmt.Errorf("fee %q cannot be negative", fee.Description)
+		}
+		switch fee.SplitMode {
+		case "", FeeProportional, FeeEqual:
+		default:
+			return fmt.Errorf("unknown split mode %q for fee %q", fee.SplitMode, fee.Description)
+		}
+	}
+	return nil
+}
+
+// validateDiscount rejects unknown discount types and bill or item discounts
+// that are negative or exceed what they discount.
+func validateDiscount(items []Item, billSubtotal float64, opts Options) error {
+	for _, item := range items {
+		if item.Discount < 0 {
+			return fmt.Errorf("discount on %q cannot be negative", item.Description)
+		}
+		if item.Discount > item.amount() {
+			return fmt.Errorf("discount on %q cannot exceed its amount", item.Description)
+		}
+	}
+	switch opts.DiscountType {
+	case "", DiscountAmount, DiscountPercent:
+	default:
+		return fmt.Errorf("unknown discount type %q", opts.DiscountType)
+	}
+	if opts.Discount < 0 {
+		return fmt.Errorf("discount cannot be negative")
+	}
+	if opts.Discount > 0 && opts.DiscountAmount(billSubtotal) >= billSubtotal {
+		return fmt.Errorf("discount must be less than the subtotal")
+	}
+	return nil
+}
+
+// weight returns how many shares person holds in item under opts.
+// Per-unit assignments take precedence over the split type.
+func (opts Options) weight(item Item, person string) float64 {
+	if len(item.Units) > 0 {
+		return item.Units[person]
+	}
+	if opts.SplitType != SplitShares {
+		return 1
+	}
+	if w := item.Shares[person]; w > 0 {
+		return w
+	}
+	if w := opts.Shares[person]; w > 0 {
+		return w
+	}
+	return 1
+}
+
+// totalWeight sums the shares of everyone splitting item.
+func (opts Options) totalWeight(item Item, people []string) float64 {
+	total := 0.0
+	for _, p := range people {
+		total += opts.weight(item, p)
+	}
+	return total
+}
+
+// validateShares checks the split type and rejects negative share weights.
+func validateShares(items []Item, opts Options) error {
+	switch opts.SplitType {
+	case "", SplitEqual, SplitShares, SplitExact:
+	default:
+		return fmt.Errorf("unknown split type %q", opts.SplitType)
+	}
+	for person, w := range opts.Shares {
+		if w < 0 {
+			return fmt.Errorf("shares for %s cannot be negative", person)
+		}
+	}
+	for _, item := range items {
+		for person, w := range item.Shares {
+			if w < 0 {
+				return fmt.Errorf("shares for %s on %q cannot be negative", person, item.Description)
+			}
+		}
+	}
+	return nil
+}
+
+// categorizeItems returns items with household items assigned to every
+// participant and personal items to their owner. Items without a category
+// are returned as they are.
+func categorizeItems(items []Item, participants []string) ([]Item, error) {
+	out := make([]Item, len(items))
+	for i, item := range items {
+		switch item.Category {
+		case "":
+		case ItemHousehold:
+			item.Participants = participants
+			item.Shares, item.Units = nil, nil
+		case ItemPersonal:
+			if !slices.Contains(participants, item.Owner) {
+				return nil, fmt.Errorf("personal item %q needs an owner who is a participant", item.Description)
+			}
+			item.Participants = []string{item.Owner}
+			item.Shares, item.Units = nil, nil
+		default:
+			return nil, fmt.Errorf("unknown category %q on %q", item.Category, item.Description)
+		}
+		out[i] = item
+	}
+	return out, nil
+}
+
+// validateUnits checks quantities and unit prices, and that per-unit
+// assignments go to the item's participants and add up to its quantity.
+func validateUnits(items []Item) error {
+	for _, item := range items {
+		if item.Quantity < 0 || item.UnitPrice < 0 {
+			return fmt.Errorf("quantity and unit price on %q cannot be negative", item.Description)
+		}
+		if len(item.Units) == 0 {
+			continue
+		}
+		if item.Quantity == 0 {
+			return fmt.Errorf("units on %q require a quantity", item.Description)
+		}
+		assigned := 0.0
+		for person, units := range item.Units {
+			if units < 0 {
+				return fmt.Errorf("units for %s on %q cannot be negative", person, item.Description)
+			}
+			if !slices.Contains(item.Participants, person) {
+				return fmt.Errorf("units on %q assigned to %s, who isn't sharing it", item.Description, person)
+			}
+			assigned += units
+		}
+		if math.Abs(assigned-item.Quantity) > 1e-9 {
+			return fmt.Errorf("units on %q add up to %g, expected quantity %g", item.Description, assigned, item.Quantity)
+		}
+	}
+	return nil
+}
+
+// calculateExactSplit assigns each participant their exact amount and breaks it
+// into subtotal, tax and tip using the bill's overall ratios.
+func calculateExactSplit(items []Item, billTotal, billSubtotal float64, participants []string, opts Options) (map[string]*PersonSplit, error) {
+	if len(items) > 0 {
+		return nil, fmt.Errorf("exact splits cannot have items")
+	}
+	if billTotal <= 0 {
+		return nil, fmt.Errorf("total must be positive for exact splits")
+	}
+
+	known := make(map[string]bool, len(participants))
+	for _, p := range participants {
+		known[p] = true
+	}
+	sum := 0.0
+	for person, amount := range opts.Amounts {
+		if !known[person] {
+			return nil, fmt.Errorf("exact amount given for unknown participant %s", person)
+		}
+		if amount < 0 {
+			return nil, fmt.Errorf("exact amount for %s cannot be negative", person)
+		}
+		sum += amount
+	}
+	if math.Abs(sum-billTotal) > 0.01 {
+		return nil, fmt.Errorf("exact amounts sum to %.2f, expected total %.2f", sum, billTotal)
+	}
+
+	discount := opts.ExcludingTax(opts.DiscountAmount(billSubtotal))
+	tax := opts.TaxAmount(billTotal, billSubtotal)
+	fees := opts.FeesTotal()
+	billSubtotal = opts.ExcludingTax(billSubtotal)
+	splits := make(map[string]*PersonSplit, len(participants))
+	for _, p := range participants {
+		amount := opts.Amounts[p]
+		opts.Trace.add(TraceStep{Step: StepExact, Participant: p, Detail: "exact amount split by the bill's subtotal/tax/tip ratios", Amount: amount, Ratio: amount / billTotal})
+		splits[p] = &PersonSplit{
+			Subtotal: amount * billSubtotal / billTotal,
+			Discount: amount * discount / billTotal,
+			Tax:      amount * tax / billTotal,
+			Tip:      amount * opts.Tip / billTotal,
+			Fees:     amount * fees / billTotal,
+			Total:    amount,
+			Items:    []PersonItem{},
+		}
+	}
+	return splits, nil
+}
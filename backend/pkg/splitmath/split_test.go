@@ -0,0 +1,809 @@
+package splitmath
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestCalculateSplit(t *testing.T) {
+	tests := []struct {
+		name          string
+		items         []Item
+		billTotal     float64
+		billSubtotal  float64
+		participants  []string
+		wantErr       bool
+		validateFunc  func(t *testing.T, splits map[string]*PersonSplit)
+	}{
+		{
+			name: "simple two-person split with tax",
+			items: []Item{
+				{Description: "Pizza", Amount: 20.0, Participants: []string{"Alice", "Bob"}},
+				{Description: "Salad", Amount: 10.0, Participants: []string{"Alice"}},
+			},
+			billTotal:    33.0,
+			billSubtotal: 30.0,
+			participants: []string{"Alice", "Bob"},
+			wantErr:      false,
+			validateFunc: func(t *testing.T, splits map[string]*PersonSplit) {
+				// Alice: subtotal = 10 + 10 = 20, tax = 20 * (3/30) = 2, total = 22
+				// Bob: subtotal = 10, tax = 10 * (3/30) = 1, total = 11
+				alice := splits["Alice"]
+				if math.Abs(alice.Subtotal-20.0) > 0.01 {
+					t.Errorf("Alice subtotal = %v, want 20.0", alice.Subtotal)
+				}
+				if math.Abs(alice.Tax-2.0) > 0.01 {
+					t.Errorf("Alice tax = %v, want 2.0", alice.Tax)
+				}
+				if math.Abs(alice.Total-22.0) > 0.01 {
+					t.Errorf("Alice total = %v, want 22.0", alice.Total)
+				}
+
+				bob := splits["Bob"]
+				if math.Abs(bob.Subtotal-10.0) > 0.01 {
+					t.Errorf("Bob subtotal = %v, want 10.0", bob.Subtotal)
+				}
+				if math.Abs(bob.Total-11.0) > 0.01 {
+					t.Errorf("Bob total = %v, want 11.0", bob.Total)
+				}
+			},
+		},
+		{
+			name:         "zero subtotal should error",
+			items:        []Item{{Description: "Item", Amount: 10.0, Participants: []string{"Alice"}}},
+			billTotal:    10.0,
+			billSubtotal: 0.0,
+			participants: []string{"Alice"},
+			wantErr:      true,
+		},
+		{
+			name:         "no participants should error",
+			items:        []Item{{Description: "Item", Amount: 10.0, Participants: []string{"Alice"}}},
+			billTotal:    10.0,
+			billSubtotal: 10.0,
+			participants: []string{},
+			wantErr:      true,
+		},
+		{
+			name:         "no items - split equally among participants",
+			items:        []Item{},
+			billTotal:    33.0,
+			billSubtotal: 30.0,
+			participants: []string{"Alice", "Bob"},
+			wantErr:      false,
+			validateFunc: func(t *testing.T, splits map[string]*PersonSplit) {
+				// Total bill = 33, split between 2 people = 16.50 each
+				// Subtotal = 30, split between 2 = 15 each
+				// Tax = 3, split between 2 = 1.50 each
+				for _, person := range []string{"Alice", "Bob"} {
+					split := splits[person]
+					if math.Abs(split.Subtotal-15.0) > 0.01 {
+						t.Errorf("%s subtotal = %v, want 15.0", person, split.Subtotal)
+					}
+					if math.Abs(split.Tax-1.5) > 0.01 {
+						t.Errorf("%s tax = %v, want 1.5", person, split.Tax)
+					}
+					if math.Abs(split.Total-16.5) > 0.01 {
+						t.Errorf("%s total = %v, want 16.5", person, split.Total)
+					}
+				}
+			},
+		},
+		{
+			name:         "no items - three people split",
+			items:        []Item{},
+			billTotal:    90.0,
+			billSubtotal: 75.0,
+			participants: []string{"Alice", "Bob", "Charlie"},
+			wantErr:      false,
+			validateFunc: func(t *testing.T, splits map[string]*PersonSplit) {
+				// Total = 90 / 3 = 30 each
+				// Subtotal = 75 / 3 = 25 each
+				// Tax = 15 / 3 = 5 each
+				for _, person := range []string{"Alice", "Bob", "Charlie"} {
+					split := splits[person]
+					if math.Abs(split.Subtotal-25.0) > 0.01 {
+						t.Errorf("%s subtotal = %v, want 25.0", person, split.Subtotal)
+					}
+					if math.Abs(split.Tax-5.0) > 0.01 {
+						t.Errorf("%s tax = %v, want 5.0", person, split.Tax)
+					}
+					if math.Abs(split.Total-30.0) > 0.01 {
+						t.Errorf("%s total = %v, want 30.0", person, split.Total)
+					}
+				}
+			},
+		},
+		{
+			name: "items don't cover full subtotal - remainder split equally",
+			items: []Item{
+				{Description: "Banana", Amount: 10.0, Participants: []string{"Ree"}},
+			},
+			billTotal:    100.0,
+			billSubtotal: 90.0,
+			participants: []string{"Mo", "Ree"},
+			wantErr:      false,
+			validateFunc: func(t *testing.T, splits map[string]*PersonSplit) {
+				// Banana ($10) -> Ree only
+				// Remainder ($80) split equally -> $40 each
+				// Mo: subtotal = 40, Ree: subtotal = 10 + 40 = 50
+				// Tax = 10, distributed proportionally
+				// Mo: tax = 40 * (10/90) = 4.44, total = 44.44
+				// Ree: tax = 50 * (10/90) = 5.56, total = 55.56
+				mo := splits["Mo"]
+				if math.Abs(mo.Subtotal-40.0) > 0.01 {
+					t.Errorf("Mo subtotal = %v, want 40.0", mo.Subtotal)
+				}
+				if len(mo.Items) != 1 || mo.Items[0].Description != "Shared" {
+					t.Errorf("Mo should have 1 'Shared' item, got %v", mo.Items)
+				}
+
+				ree := splits["Ree"]
+				if math.Abs(ree.Subtotal-50.0) > 0.01 {
+					t.Errorf("Ree subtotal = %v, want 50.0", ree.Subtotal)
+				}
+				if len(ree.Items) != 2 {
+					t.Errorf("Ree should have 2 items (Banana + Shared), got %d", len(ree.Items))
+				}
+
+				// Verify totals add up to bill total
+				totalOwed := mo.Total + ree.Total
+				if math.Abs(totalOwed-100.0) > 0.01 {
+					t.Errorf("Total owed = %v, want 100.0", totalOwed)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			splits, err := CalculateSplit(tt.items, tt.billTotal, tt.billSubtotal, tt.participants)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CalculateSplit() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && tt.validateFunc != nil {
+				tt.validateFunc(t, splits)
+			}
+		})
+	}
+}
+
+func TestCalculateSplitWithOptions_Tip(t *testing.T) {
+	// Alice: 20, Bob: 10 (subtotal 30). Tax 3, tip 6 → total 39.
+	items := []Item{
+		{Description: "Steak", Amount: 20.0, Participants: []string{"Alice"}},
+		{Description: "Salad", Amount: 10.0, Participants: []string{"Bob"}},
+	}
+	participants := []string{"Alice", "Bob"}
+
+	t.Run("proportional tip follows subtotal share", func(t *testing.T) {
+		splits, err := CalculateSplitWithOptions(items, 39.0, 30.0, participants, Options{Tip: 6.0})
+		if err != nil {
+			t.Fatalf("CalculateSplitWithOptions() error = %v", err)
+		}
+		alice, bob := splits["Alice"], splits["Bob"]
+		if math.Abs(alice.Tax-2.0) > 0.01 || math.Abs(bob.Tax-1.0) > 0.01 {
+			t.Errorf("tax = %v/%v, want 2/1", alice.Tax, bob.Tax)
+		}
+		if math.Abs(alice.Tip-4.0) > 0.01 || math.Abs(bob.Tip-2.0) > 0.01 {
+			t.Errorf("tip = %v/%v, want 4/2", alice.Tip, bob.Tip)
+		}
+		if math.Abs(alice.Total-26.0) > 0.01 || math.Abs(bob.Total-13.0) > 0.01 {
+			t.Errorf("total = %v/%v, want 26/13", alice.Total, bob.Total)
+		}
+	})
+
+	t.Run("equal tip split evenly, tax stays proportional", func(t *testing.T) {
+		splits, err := CalculateSplitWithOptions(items, 39.0, 30.0, participants, Options{Tip: 6.0, TipSplitMode: TipEqual})
+		if err != nil {
+			t.Fatalf("CalculateSplitWithOptions() error = %v", err)
+		}
+		alice, bob := splits["Alice"], splits["Bob"]
+		if math.Abs(alice.Tax-2.0) > 0.01 || math.Abs(bob.Tax-1.0) > 0.01 {
+			t.Errorf("tax = %v/%v, want 2/1", alice.Tax, bob.Tax)
+		}
+		if math.Abs(alice.Tip-3.0) > 0.01 || math.Abs(bob.Tip-3.0) > 0.01 {
+			t.Errorf("tip = %v/%v, want 3/3", alice.Tip, bob.Tip)
+		}
+		if math.Abs(alice.Total+bob.Total-39.0) > 0.01 {
+			t.Errorf("totals sum to %v, want 39", alice.Total+bob.Total)
+		}
+	})
+
+	t.Run("negative tip should error", func(t *testing.T) {
+		if _, err := CalculateSplitWithOptions(items, 30.0, 30.0, participants, Options{Tip: -1}); err == nil {
+			t.Error("expected error for negative tip")
+		}
+	})
+
+	t.Run("unknown tip mode should error", func(t *testing.T) {
+		if _, err := CalculateSplitWithOptions(items, 30.0, 30.0, participants, Options{TipSplitMode: "random"}); err == nil {
+			t.Error("expected error for unknown tip split mode")
+		}
+	})
+}
+
+func TestCalculateSplitWithOptions_Shares(t *testing.T) {
+	participants := []string{"Alice", "Bob"}
+
+	t.Run("bill-level shares weight the no-items split", func(t *testing.T) {
+		opts := Options{SplitType: SplitShares, Shares: map[string]float64{"Alice": 2}}
+		splits, err := CalculateSplitWithOptions(nil, 33.0, 30.0, participants, opts)
+		if err != nil {
+			t.Fatalf("CalculateSplitWithOptions() error = %v", err)
+		}
+		if math.Abs(splits["Alice"].Subtotal-20.0) > 0.01 || math.Abs(splits["Bob"].Subtotal-10.0) > 0.01 {
+			t.Errorf("subtotal = %v/%v, want 20/10", splits["Alice"].Subtotal, splits["Bob"].Subtotal)
+		}
+		if math.Abs(splits["Alice"].Total-22.0) > 0.01 || math.Abs(splits["Bob"].Total-11.0) > 0.01 {
+			t.Errorf("total = %v/%v, want 22/11", splits["Alice"].Total, splits["Bob"].Total)
+		}
+	})
+
+	t.Run("item shares override bill-level shares", func(t *testing.T) {
+		items := []Item{
+			{Description: "Pizza", Amount: 30.0, Participants: participants, Shares: map[string]float64{"Bob": 2}},
+			{Description: "Wine", Amount: 30.0, Participants: participants},
+		}
+		opts := Options{SplitType: SplitShares, Shares: map[string]float64{"Alice": 2}}
+		splits, err := CalculateSplitWithOptions(items, 60.0, 60.0, participants, opts)
+		if err != nil {
+			t.Fatalf("CalculateSplitWithOptions() error = %v", err)
+		}
+		// Pizza: Alice 2 (bill-level) vs Bob 2 (item) → 15/15. Wine: Alice 2 vs Bob 1 → 20/10.
+		if math.Abs(splits["Alice"].Subtotal-35.0) > 0.01 || math.Abs(splits["Bob"].Subtotal-25.0) > 0.01 {
+			t.Errorf("subtotal = %v/%v, want 35/25", splits["Alice"].Subtotal, splits["Bob"].Subtotal)
+		}
+	})
+
+	t.Run("shares are ignored for equal splits", func(t *testing.T) {
+		splits, err := CalculateSplitWithOptions(nil, 30.0, 30.0, participants, Options{Shares: map[string]float64{"Alice": 2}})
+		if err != nil {
+			t.Fatalf("CalculateSplitWithOptions() error = %v", err)
+		}
+		if math.Abs(splits["Alice"].Total-15.0) > 0.01 {
+			t.Errorf("Alice total = %v, want 15", splits["Alice"].Total)
+		}
+	})
+
+	t.Run("negative shares should error", func(t *testing.T) {
+		opts := Options{SplitType: SplitShares, Shares: map[string]float64{"Alice": -1}}
+		if _, err := CalculateSplitWithOptions(nil, 30.0, 30.0, participants, opts); err == nil {
+			t.Error("expected error for negative shares")
+		}
+	})
+}
+
+func TestCalculateSplitWithOptions_Exact(t *testing.T) {
+	participants := []string{"Alice", "Bob"}
+
+	t.Run("exact amounts become totals with proportional breakdown", func(t *testing.T) {
+		opts := Options{Tip: 4.0, SplitType: SplitExact, Amounts: map[string]float64{"Alice": 30, "Bob": 10}}
+		splits, err := CalculateSplitWithOptions(nil, 40.0, 32.0, participants, opts)
+		if err != nil {
+			t.Fatalf("CalculateSplitWithOptions() error = %v", err)
+		}
+		alice := splits["Alice"]
+		if alice.Total != 30 {
+			t.Errorf("Alice total = %v, want 30", alice.Total)
+		}
+		if math.Abs(alice.Subtotal-24.0) > 0.01 || math.Abs(alice.Tax-3.0) > 0.01 || math.Abs(alice.Tip-3.0) > 0.01 {
+			t.Errorf("Alice breakdown = %v/%v/%v, want 24/3/3", alice.Subtotal, alice.Tax, alice.Tip)
+		}
+		if splits["Bob"].Total != 10 {
+			t.Errorf("Bob total = %v, want 10", splits["Bob"].Total)
+		}
+	})
+
+	tests := []struct {
+		name    string
+		items   []Item
+		amounts map[string]float64
+	}{
+		{"amounts must sum to total", nil, map[string]float64{"Alice": 30, "Bob": 5}},
+		{"negative amount", nil, map[string]float64{"Alice": 50, "Bob": -10}},
+		{"unknown participant", nil, map[string]float64{"Alice": 20, "Carol": 20}},
+		{"items not allowed", []Item{{Description: "Food", Amount: 40, Participants: participants}}, map[string]float64{"Alice": 20, "Bob": 20}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := Options{SplitType: SplitExact, Amounts: tt.amounts}
+			if _, err := CalculateSplitWithOptions(tt.items, 40.0, 40.0, participants, opts); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestCalculateSplitWithOptions_Units(t *testing.T) {
+	participants := []string{"Alice", "Bob"}
+	beer := func(units map[string]float64) Item {
+		return Item{Description: "Beer", Quantity: 3, UnitPrice: 6.0, Participants: participants, Units: units}
+	}
+
+	t.Run("units split the item and amount comes from unit price", func(t *testing.T) {
+		items := []Item{beer(map[string]float64{"Alice": 2, "Bob": 1})}
+		// Shares split type is overridden by the per-unit assignment.
+		splits, err := CalculateSplitWithOptions(items, 19.8, 18.0, participants, Options{SplitType: SplitShares, Shares: map[string]float64{"Bob": 5}})
+		if err != nil {
+			t.Fatalf("CalculateSplitWithOptions() error = %v", err)
+		}
+		if math.Abs(splits["Alice"].Subtotal-12.0) > 0.01 || math.Abs(splits["Bob"].Subtotal-6.0) > 0.01 {
+			t.Errorf("subtotal = %v/%v, want 12/6", splits["Alice"].Subtotal, splits["Bob"].Subtotal)
+		}
+		if math.Abs(splits["Alice"].Total-13.2) > 0.01 {
+			t.Errorf("Alice total = %v, want 13.2", splits["Alice"].Total)
+		}
+	})
+
+	t.Run("units not adding up to quantity should error", func(t *testing.T) {
+		items := []Item{beer(map[string]float64{"Alice": 2})}
+		if _, err := CalculateSplitWithOptions(items, 18.0, 18.0, participants, Options{}); err == nil {
+			t.Error("expected error for unassigned units")
+		}
+	})
+
+	t.Run("units for a non-participant should error", func(t *testing.T) {
+		items := []Item{beer(map[string]float64{"Alice": 2, "Carol": 1})}
+		if _, err := CalculateSplitWithOptions(items, 18.0, 18.0, participants, Options{}); err == nil {
+			t.Error("expected error for units assigned to someone not sharing the item")
+		}
+	})
+}
+
+func TestCalculateSplitWithOptions_Covered(t *testing.T) {
+	participants := []string{"Alice", "Bob", "Carol"}
+	// Each person: subtotal 30, tax 3, tip 3, total 36.
+	calc := func(covered map[string]CoverMode) (map[string]*PersonSplit, error) {
+		return CalculateSplitWithOptions(nil, 108.0, 90.0, participants, Options{Tip: 9.0, Covered: covered})
+	}
+
+	t.Run("tax and tip covered", func(t *testing.T) {
+		splits, err := calc(map[string]CoverMode{"Carol": CoverTaxAndTip})
+		if err != nil {
+			t.Fatalf("CalculateSplitWithOptions() error = %v", err)
+		}
+		if math.Abs(splits["Carol"].Total-30.0) > 0.01 || math.Abs(splits["Carol"].Covered+6.0) > 0.01 {
+			t.Errorf("Carol total/covered = %v/%v, want 30/-6", splits["Carol"].Total, splits["Carol"].Covered)
+		}
+		if math.Abs(splits["Alice"].Total-39.0) > 0.01 || math.Abs(splits["Bob"].Total-39.0) > 0.01 {
+			t.Errorf("Alice/Bob total = %v/%v, want 39/39", splits["Alice"].Total, splits["Bob"].Total)
+		}
+	})
+
+	t.Run("whole share covered", func(t *testing.T) {
+		splits, err := calc(map[string]CoverMode{"Carol": CoverAll})
+		if err != nil {
+			t.Fatalf("CalculateSplitWithOptions() error = %v", err)
+		}
+		if math.Abs(splits["Carol"].Total) > 0.01 {
+			t.Errorf("Carol total = %v, want 0", splits["Carol"].Total)
+		}
+		if math.Abs(splits["Alice"].Total-54.0) > 0.01 || math.Abs(splits["Bob"].Covered-18.0) > 0.01 {
+			t.Errorf("Alice total = %v, Bob covered = %v, want 54 and 18", splits["Alice"].Total, splits["Bob"].Covered)
+		}
+	})
+
+	t.Run("covering everyone should error", func(t *testing.T) {
+		if _, err := calc(map[string]CoverMode{"Alice": CoverAll, "Bob": CoverAll, "Carol": CoverTaxAndTip}); err == nil {
+			t.Error("expected error when every participant is covered")
+		}
+	})
+
+	t.Run("covering a non-participant should error", func(t *testing.T) {
+		if _, err := calc(map[string]CoverMode{"Dave": CoverAll}); err == nil {
+			t.Error("expected error for covered non-participant")
+		}
+	})
+}
+
+func TestCalculateSplitWithOptions_Adjustments(t *testing.T) {
+	participants := []string{"Alice", "Bob", "Carol"}
+
+	t.Run("adjusted equal split", func(t *testing.T) {
+		// 90 subtotal, 9 tax. Alice's +6 cocktail leaves 84 to split: 28 each.
+		splits, err := CalculateSplitWithOptions(nil, 99.0, 90.0, participants, Options{Adjustments: map[string]float64{"Alice": 6}})
+		if err != nil {
+			t.Fatalf("CalculateSplitWithOptions() error = %v", err)
+		}
+		if math.Abs(splits["Alice"].Subtotal-34.0) > 0.01 || math.Abs(splits["Bob"].Subtotal-28.0) > 0.01 {
+			t.Errorf("Alice/Bob subtotal = %v/%v, want 34/28", splits["Alice"].Subtotal, splits["Bob"].Subtotal)
+		}
+		if math.Abs(splits["Alice"].Tax-3.4) > 0.01 {
+			t.Errorf("Alice tax = %v, want 3.4 (tax follows the adjusted subtotal)", splits["Alice"].Tax)
+		}
+	})
+
+	t.Run("negative adjustment on the remainder", func(t *testing.T) {
+		items := []Item{{Description: "Steak", Amount: 30, Participants: []string{"Bob"}}}
+		splits, err := CalculateSplitWithOptions(items, 90.0, 90.0, participants, Options{Adjustments: map[string]float64{"Carol": -3}})
+		if err != nil {
+			t.Fatalf("CalculateSplitWithOptions() error = %v", err)
+		}
+		// Remainder 60 + 3 = 63 split evenly: 21 each, Carol pays 18.
+		if math.Abs(splits["Carol"].Total-18.0) > 0.01 || math.Abs(splits["Bob"].Total-51.0) > 0.01 {
+			t.Errorf("Carol/Bob total = %v/%v, want 18/51", splits["Carol"].Total, splits["Bob"].Total)
+		}
+	})
+
+	t.Run("invalid adjustments should error", func(t *testing.T) {
+		fullyItemized := []Item{{Description: "Pizza", Amount: 90, Participants: participants}}
+		cases := map[string]func() error{
+			"non-participant": func() error {
+				_, err := CalculateSplitWithOptions(nil, 90.0, 90.0, participants, Options{Adjustments: map[string]float64{"Dave": 5}})
+				return err
+			},
+			"exceeds share": func() error {
+				_, err := CalculateSplitWithOptions(nil, 90.0, 90.0, participants, Options{Adjustments: map[string]float64{"Alice": -50}})
+				return err
+			},
+			"no remainder": func() error {
+				_, err := CalculateSplitWithOptions(fullyItemized, 90.0, 90.0, participants, Options{Adjustments: map[string]float64{"Alice": 5}})
+				return err
+			},
+			"exact split": func() error {
+				_, err := CalculateSplitWithOptions(nil, 90.0, 90.0, participants, Options{
+					SplitType:   SplitExact,
+					Amounts:     map[string]float64{"Alice": 30, "Bob": 30, "Carol": 30},
+					Adjustments: map[string]float64{"Alice": 5},
+				})
+				return err
+			},
+		}
+		for name, calc := range cases {
+			if calc() == nil {
+				t.Errorf("%s: expected error", name)
+			}
+		}
+	})
+}
+
+func TestCalculateSplitWithOptions_RemainderMode(t *testing.T) {
+	participants := []string{"Alice", "Bob"}
+	// Items cover 40 of the 60 subtotal: Alice 30, Bob 10. No tax.
+	items := []Item{
+		{Description: "Steak", Amount: 30, Participants: []string{"Alice"}},
+		{Description: "Salad", Amount: 10, Participants: []string{"Bob"}},
+	}
+	calc := func(mode RemainderMode, payer string) (map[string]*PersonSplit, error) {
+		return CalculateSplitWithOptions(items, 60.0, 60.0, participants, Options{RemainderMode: mode, Payer: payer})
+	}
+
+	tests := []struct {
+		name       string
+		mode       RemainderMode
+		payer      string
+		alice, bob float64
+	}{
+		{"equal", RemainderEqual, "", 40, 20},
+		{"default is equal", "", "", 40, 20},
+		{"proportional", RemainderProportional, "", 45, 15},
+		{"payer", RemainderPayer, "Bob", 30, 30},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			splits, err := calc(tt.mode, tt.payer)
+			if err != nil {
+				t.Fatalf("CalculateSplitWithOptions() error = %v", err)
+			}
+			if math.Abs(splits["Alice"].Total-tt.alice) > 0.01 || math.Abs(splits["Bob"].Total-tt.bob) > 0.01 {
+				t.Errorf("Alice/Bob = %v/%v, want %v/%v", splits["Alice"].Total, splits["Bob"].Total, tt.alice, tt.bob)
+			}
+		})
+	}
+
+	t.Run("payer mode without a payer should error", func(t *testing.T) {
+		if _, err := calc(RemainderPayer, ""); err == nil {
+			t.Error("expected error when no payer is given")
+		}
+	})
+
+	t.Run("unknown mode should error", func(t *testing.T) {
+		if _, err := calc("random", ""); err == nil {
+			t.Error("expected error for unknown remainder mode")
+		}
+	})
+}
+
+func TestCalculateSplitWithOptions_TaxInclusive(t *testing.T) {
+	participants := []string{"Alice", "Bob"}
+	// Shelf prices include 20% VAT: Alice's 72 holds 12 tax, Bob's 48 holds 8.
+	items := []Item{
+		{Description: "Wine", Amount: 72, Participants: []string{"Alice"}},
+		{Description: "Pasta", Amount: 48, Participants: []string{"Bob"}},
+	}
+
+	t.Run("tax backed out per person", func(t *testing.T) {
+		splits, err := CalculateSplitWithOptions(items, 130.0, 120.0, participants, Options{TaxInclusive: true, TaxRate: 20, Tip: 10, TipSplitMode: TipEqual})
+		if err != nil {
+			t.Fatalf("CalculateSplitWithOptions() error = %v", err)
+		}
+		alice, bob := splits["Alice"], splits["Bob"]
+		if math.Abs(alice.Subtotal-60) > 0.01 || math.Abs(alice.Tax-12) > 0.01 || math.Abs(alice.Total-77) > 0.01 {
+			t.Errorf("Alice subtotal/tax/total = %v/%v/%v, want 60/12/77", alice.Subtotal, alice.Tax, alice.Total)
+		}
+		if math.Abs(bob.Subtotal-40) > 0.01 || math.Abs(bob.Tax-8) > 0.01 || math.Abs(bob.Total-53) > 0.01 {
+			t.Errorf("Bob subtotal/tax/total = %v/%v/%v, want 40/8/53", bob.Subtotal, bob.Tax, bob.Total)
+		}
+		if math.Abs(alice.Items[0].Amount-60) > 0.01 {
+			t.Errorf("Alice's item share = %v, want pre-tax 60", alice.Items[0].Amount)
+		}
+		if tax := (Options{TaxInclusive: true, TaxRate: 20, Tip: 10}).TaxAmount(130, 120); math.Abs(tax-20) > 0.01 {
+			t.Errorf("TaxAmount() = %v, want 20", tax)
+		}
+	})
+
+	t.Run("discount on inclusive prices", func(t *testing.T) {
+		splits, err := CalculateSplitWithOptions(items, 108.0, 120.0, participants, Options{TaxInclusive: true, TaxRate: 20, Discount: 10, DiscountType: DiscountPercent})
+		if err != nil {
+			t.Fatalf("CalculateSplitWithOptions() error = %v", err)
+		}
+		alice := splits["Alice"]
+		if math.Abs(alice.Discount-6) > 0.01 || math.Abs(alice.Tax-10.8) > 0.01 || math.Abs(alice.Total-64.8) > 0.01 {
+			t.Errorf("Alice discount/tax/total = %v/%v/%v, want 6/10.8/64.8", alice.Discount, alice.Tax, alice.Total)
+		}
+	})
+
+	t.Run("exact split", func(t *testing.T) {
+		splits, err := CalculateSplitWithOptions(nil, 120.0, 120.0, participants, Options{
+			TaxInclusive: true, TaxRate: 20, SplitType: SplitExact,
+			Amounts: map[string]float64{"Alice": 90, "Bob": 30},
+		})
+		if err != nil {
+			t.Fatalf("CalculateSplitWithOptions() error = %v", err)
+		}
+		if alice := splits["Alice"]; math.Abs(alice.Subtotal-75) > 0.01 || math.Abs(alice.Tax-15) > 0.01 {
+			t.Errorf("Alice subtotal/tax = %v/%v, want 75/15", alice.Subtotal, alice.Tax)
+		}
+	})
+
+	t.Run("inclusive without a rate should error", func(t *testing.T) {
+		if _, err := CalculateSplitWithOptions(items, 120.0, 120.0, participants, Options{TaxInclusive: true}); err == nil {
+			t.Error("expected error for tax-inclusive prices without a rate")
+		}
+	})
+
+	t.Run("rate without inclusive should error", func(t *testing.T) {
+		if _, err := CalculateSplitWithOptions(items, 120.0, 120.0, participants, Options{TaxRate: 20}); err == nil {
+			t.Error("expected error for a tax rate on tax-exclusive prices")
+		}
+	})
+}
+
+func TestCalculateSplitWithOptions_Trace(t *testing.T) {
+	items := []Item{{Description: "Pizza", Amount: 20.0, Participants: []string{"Alice", "Bob"}}}
+	trace := &Trace{}
+	// 30 subtotal: 20 in items, 10 unassigned; 3 tax.
+	splits, err := CalculateSplitWithOptions(items, 33.0, 30.0, []string{"Alice", "Bob"}, Options{Trace: trace})
+	if err != nil {
+		t.Fatalf("CalculateSplitWithOptions() error = %v", err)
+	}
+
+	var kinds []string
+	for _, step := range trace.Steps {
+		if step.Participant == "Alice" || step.Participant == "" {
+			kinds = append(kinds, step.Step)
+		}
+	}
+	want := []string{StepItem, StepRemainder, StepRemainder, StepTaxRatio, StepTax, StepTip, StepTotal}
+	if !slices.Equal(kinds, want) {
+		t.Errorf("steps for Alice = %v, want %v", kinds, want)
+	}
+
+	last := trace.Steps[len(trace.Steps)-1]
+	if last.Step != StepTotal || last.Participant != "Bob" || math.Abs(last.Amount-splits["Bob"].Total) > 0.01 {
+		t.Errorf("last step = %+v, want Bob's total %v", last, splits["Bob"].Total)
+	}
+}
+
+func TestCalculateSplitWithOptions_Fees(t *testing.T) {
+	participants := []string{"Alice", "Bob"}
+	items := []Item{
+		{Description: "Curry", Amount: 30.0, Participants: []string{"Alice"}},
+		{Description: "Naan", Amount: 10.0, Participants: []string{"Bob"}},
+	}
+	opts := Options{Fees: []Fee{
+		{Description: "Delivery", Amount: 6.0, SplitMode: FeeEqual},
+		{Description: "Service", Amount: 4.0},
+	}}
+
+	t.Run("fees are split by mode and not taxed", func(t *testing.T) {
+		// Subtotal 40, tax 4, fees 10.
+		splits, err := CalculateSplitWithOptions(items, 54.0, 40.0, participants, opts)
+		if err != nil {
+			t.Fatalf("CalculateSplitWithOptions() error = %v", err)
+		}
+		alice, bob := splits["Alice"], splits["Bob"]
+		if math.Abs(alice.Tax-3.0) > 0.01 || math.Abs(bob.Tax-1.0) > 0.01 {
+			t.Errorf("tax = %v/%v, want 3/1", alice.Tax, bob.Tax)
+		}
+		if math.Abs(alice.Fees-6.0) > 0.01 || math.Abs(bob.Fees-4.0) > 0.01 {
+			t.Errorf("fees = %v/%v, want 6/4", alice.Fees, bob.Fees)
+		}
+		if math.Abs(alice.Total+bob.Total-54.0) > 0.01 {
+			t.Errorf("totals sum to %v, want 54", alice.Total+bob.Total)
+		}
+	})
+
+	t.Run("negative fee should error", func(t *testing.T) {
+		bad := Options{Fees: []Fee{{Description: "Delivery", Amount: -1}}}
+		if _, err := CalculateSplitWithOptions(items, 44.0, 40.0, participants, bad); err == nil {
+			t.Error("expected error for negative fee")
+		}
+	})
+
+	t.Run("unknown fee split mode should error", func(t *testing.T) {
+		bad := Options{Fees: []Fee{{Description: "Delivery", Amount: 1, SplitMode: "random"}}}
+		if _, err := CalculateSplitWithOptions(items, 45.0, 40.0, participants, bad); err == nil {
+			t.Error("expected error for unknown fee split mode")
+		}
+	})
+}
+
+func TestCalculateSplitWithOptions_Currency(t *testing.T) {
+	participants := []string{"Alice", "Bob", "Carol"}
+
+	t.Run("JPY rounds to whole yen", func(t *testing.T) {
+		splits, err := CalculateSplitWithOptions(nil, 1100.0, 1000.0, participants, Options{Currency: "JPY"})
+		if err != nil {
+			t.Fatalf("CalculateSplitWithOptions() error = %v", err)
+		}
+		for _, p := range participants {
+			if got := splits[p].Total; got != 367 {
+				t.Errorf("%s total = %v, want 367", p, got)
+			}
+			if got := splits[p].Subtotal; got != 333 {
+				t.Errorf("%s subtotal = %v, want 333", p, got)
+			}
+		}
+	})
+
+	t.Run("BHD keeps three decimals", func(t *testing.T) {
+		splits, err := CalculateSplitWithOptions(nil, 10.0, 10.0, participants, Options{Currency: "BHD"})
+		if err != nil {
+			t.Fatalf("CalculateSplitWithOptions() error = %v", err)
+		}
+		if got := splits["Alice"].Total; got != 3.333 {
+			t.Errorf("total = %v, want 3.333", got)
+		}
+	})
+
+	t.Run("no currency leaves amounts unrounded", func(t *testing.T) {
+		splits, err := CalculateSplitWithOptions(nil, 10.0, 10.0, participants, Options{})
+		if err != nil {
+			t.Fatalf("CalculateSplitWithOptions() error = %v", err)
+		}
+		if got := splits["Alice"].Total; got == 3.33 {
+			t.Errorf("total = %v, want unrounded 10/3", got)
+		}
+	})
+
+	t.Run("malformed currency should error", func(t *testing.T) {
+		if _, err := CalculateSplitWithOptions(nil, 10.0, 10.0, participants, Options{Currency: "usd"}); err == nil {
+			t.Error("expected error for lower-case currency code")
+		}
+	})
+}
+
+func TestCalculateSplitWithOptions_Discount(t *testing.T) {
+	participants := []string{"Alice", "Bob"}
+	items := []Item{
+		{Description: "Steak", Amount: 60.0, Participants: []string{"Alice"}},
+		{Description: "Salad", Amount: 40.0, Participants: []string{"Bob"}},
+	}
+
+	t.Run("fixed amount is allocated by subtotal before tax", func(t *testing.T) {
+		// $10 off a $100 subtotal; 10% tax on the discounted $90 → total $99.
+		splits, err := CalculateSplitWithOptions(items, 99.0, 100.0, participants, Options{Discount: 10})
+		if err != nil {
+			t.Fatalf("CalculateSplitWithOptions() error = %v", err)
+		}
+		if math.Abs(splits["Alice"].Discount-6.0) > 0.01 || math.Abs(splits["Bob"].Discount-4.0) > 0.01 {
+			t.Errorf("discount = %v/%v, want 6/4", splits["Alice"].Discount, splits["Bob"].Discount)
+		}
+		if math.Abs(splits["Alice"].Tax-5.4) > 0.01 || math.Abs(splits["Bob"].Tax-3.6) > 0.01 {
+			t.Errorf("tax = %v/%v, want 5.4/3.6", splits["Alice"].Tax, splits["Bob"].Tax)
+		}
+		if math.Abs(splits["Alice"].Total-59.4) > 0.01 || math.Abs(splits["Bob"].Total-39.6) > 0.01 {
+			t.Errorf("total = %v/%v, want 59.4/39.6", splits["Alice"].Total, splits["Bob"].Total)
+		}
+	})
+
+	t.Run("percent off", func(t *testing.T) {
+		splits, err := CalculateSplitWithOptions(items, 88.0, 100.0, participants, Options{Discount: 20, DiscountType: DiscountPercent})
+		if err != nil {
+			t.Fatalf("CalculateSplitWithOptions() error = %v", err)
+		}
+		if math.Abs(splits["Alice"].Total-52.8) > 0.01 || math.Abs(splits["Bob"].Total-35.2) > 0.01 {
+			t.Errorf("total = %v/%v, want 52.8/35.2", splits["Alice"].Total, splits["Bob"].Total)
+		}
+	})
+
+	t.Run("discount covering the subtotal should error", func(t *testing.T) {
+		if _, err := CalculateSplitWithOptions(items, 0, 100.0, participants, Options{Discount: 100, DiscountType: DiscountPercent}); err == nil {
+			t.Error("expected error for 100% discount")
+		}
+	})
+
+	t.Run("item discount only reduces that item's participants", func(t *testing.T) {
+		items := []Item{
+			{Description: "Beer", Amount: 20.0, Discount: 10.0, Participants: []string{"Alice", "Bob"}},
+			{Description: "Burger", Amount: 30.0, Participants: []string{"Bob"}},
+		}
+		// Subtotal is after item discounts: 10 + 30 = 40; 10% tax.
+		splits, err := CalculateSplitWithOptions(items, 44.0, 40.0, participants, Options{})
+		if err != nil {
+			t.Fatalf("CalculateSplitWithOptions() error = %v", err)
+		}
+		if math.Abs(splits["Alice"].Total-5.5) > 0.01 || math.Abs(splits["Bob"].Total-38.5) > 0.01 {
+			t.Errorf("total = %v/%v, want 5.5/38.5", splits["Alice"].Total, splits["Bob"].Total)
+		}
+	})
+
+	t.Run("item discount above its amount should error", func(t *testing.T) {
+		items := []Item{{Description: "Beer", Amount: 5.0, Discount: 6.0, Participants: participants}}
+		if _, err := CalculateSplitWithOptions(items, 5.0, 5.0, participants, Options{}); err == nil {
+			t.Error("expected error for item discount above amount")
+		}
+	})
+
+	t.Run("negative discount should error", func(t *testing.T) {
+		if _, err := CalculateSplitWithOptions(items, 100.0, 100.0, participants, Options{Discount: -5}); err == nil {
+			t.Error("expected error for negative discount")
+		}
+	})
+}
+
+func TestCalculateSplitWithOptions_ItemCategories(t *testing.T) {
+	participants := []string{"Alice", "Bob", "Carol"}
+	items := []Item{
+		{Description: "Paper towels", Amount: 12.0, Category: ItemHousehold},
+		{Description: "Shampoo", Amount: 8.0, Category: ItemPersonal, Owner: "Alice"},
+		// Participants are ignored once an item is categorized.
+		{Description: "Protein bars", Amount: 10.0, Category: ItemPersonal, Owner: "Bob", Participants: []string{"Carol"}},
+	}
+
+	t.Run("household shared, personal charged to the owner", func(t *testing.T) {
+		// 10% tax: subtotals 12/14/4.
+		splits, err := CalculateSplitWithOptions(items, 33.0, 30.0, participants, Options{})
+		if err != nil {
+			t.Fatalf("CalculateSplitWithOptions() error = %v", err)
+		}
+		want := map[string]float64{"Alice": 13.2, "Bob": 15.4, "Carol": 4.4}
+		for person, total := range want {
+			if math.Abs(splits[person].Total-total) > 0.01 {
+				t.Errorf("%s total = %v, want %v", person, splits[person].Total, total)
+			}
+		}
+	})
+
+	t.Run("household follows bill shares", func(t *testing.T) {
+		opts := Options{SplitType: SplitShares, Shares: map[string]float64{"Alice": 2, "Bob": 1, "Carol": 1}}
+		splits, err := CalculateSplitWithOptions(items, 30.0, 30.0, participants, opts)
+		if err != nil {
+			t.Fatalf("CalculateSplitWithOptions() error = %v", err)
+		}
+		want := map[string]float64{"Alice": 14, "Bob": 13, "Carol": 3}
+		for person, total := range want {
+			if math.Abs(splits[person].Total-total) > 0.01 {
+				t.Errorf("%s total = %v, want %v", person, splits[person].Total, total)
+			}
+		}
+	})
+
+	t.Run("personal item without a participant owner should error", func(t *testing.T) {
+		bad := []Item{{Description: "Razor", Amount: 5.0, Category: ItemPersonal, Owner: "Dave"}}
+		if _, err := CalculateSplitWithOptions(bad, 5.0, 5.0, participants, Options{}); err == nil {
+			t.Error("expected error for personal item owned by a non-participant")
+		}
+	})
+
+	t.Run("unknown category should error", func(t *testing.T) {
+		bad := []Item{{Description: "Razor", Amount: 5.0, Category: "gift"}}
+		if _, err := CalculateSplitWithOptions(bad, 5.0, 5.0, participants, Options{}); err == nil {
+			t.Error("expected error for unknown category")
+		}
+	})
+}
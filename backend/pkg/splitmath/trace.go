@@ -0,0 +1,40 @@
+package splitmath
+
+// Trace step kinds, in the order they're applied.
+const (
+	StepItem        = "item"         // a person's share of one item
+	StepEven        = "even"         // a person's share of a bill without items
+	StepRemainder   = "remainder"    // subtotal not covered by items, and each person's share of it
+	StepAdjust      = "adjust"       // a fixed amount added to or taken off a person's even share
+	StepIncludedTax = "included_tax" // tax backed out of a person's tax-inclusive subtotal
+	StepTaxRatio    = "tax_ratio"    // bill-level tax and its rate over the discounted subtotal
+	StepDiscount    = "discount"     // a person's share of the bill discount
+	StepTax         = "tax"          // a person's share of tax
+	StepTip         = "tip"          // a person's share of tip
+	StepFee         = "fee"          // a person's share of one fee
+	StepCover       = "cover"        // amount moved to or from a person for covered participants
+	StepExact       = "exact"        // a person's exact amount broken down by the bill's ratios
+	StepRounding    = "rounding"     // an increment moved to a person so rounded totals add up
+	StepTotal       = "total"        // a person's final total
+)
+
+// TraceStep records one step of a split computation.
+type TraceStep struct {
+	Step        string
+	Participant string // empty for bill-level steps
+	Detail      string
+	Amount      float64
+	Ratio       float64 // share or rate applied to get Amount, when there is one
+}
+
+// Trace collects a step-by-step explanation of a split when set on Options.Trace.
+// A nil *Trace records nothing.
+type Trace struct {
+	Steps []TraceStep
+}
+
+func (t *Trace) add(step TraceStep) {
+	if t != nil {
+		t.Steps = append(t.Steps, step)
+	}
+}
@@ -1,5 +1,5 @@
 import { get } from 'svelte/store';
-import { token, logout } from '$lib/stores/auth';
+import { token, logout, renewToken } from '$lib/stores/auth';
 
 const BASE_PATH = '/splitwiser.v1.';
 
@@ -49,6 +49,10 @@ export async function apiPost<TReq = unknown, TRes = unknown>(
     throw new ApiError(message, response.status);
   }
 
+  // Active sessions get a fresh token before the current one expires.
+  const renewed = response.headers.get('X-Session-Token');
+  if (renewed && useAuth && get(token)) renewToken(renewed);
+
   return (await response.json()) as TRes;
 }
 
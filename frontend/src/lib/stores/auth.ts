@@ -34,6 +34,12 @@ export function login(newToken: string, user: AuthUser): void {
   currentUser.set(user);
 }
 
+// Replaces the token with one the server renewed, keeping the session alive.
+export function renewToken(newToken: string): void {
+  localStorage.setItem(TOKEN_KEY, newToken);
+  token.set(newToken);
+}
+
 export function logout(): void {
   localStorage.removeItem(TOKEN_KEY);
   localStorage.removeItem(USER_KEY);